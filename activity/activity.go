@@ -0,0 +1,158 @@
+package activity
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/namnguyen191/goravel/cache"
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+)
+
+// Activity is a single entry of a feed: an actor did something (verb) to an object
+type Activity struct {
+	ID         int
+	ActorType  string
+	ActorID    int
+	Verb       string
+	ObjectType string
+	ObjectID   int
+	Data       map[string]interface{}
+	CreatedAt  time.Time
+}
+
+// Feed records activities and reads them back per actor or per object
+type Feed struct {
+	DB           *sql.DB
+	DatabaseType string
+	Cache        cache.Cache
+	// TTL in seconds of cached feed pages
+	TTL int
+	// PerPage is the default page size
+	PerPage int
+	// Clock tells when activities without a time happened; the time of the machine when nil
+	Clock clock.Clock
+}
+
+// New returns a feed backed by the database and, when c is not nil, cached
+func New(db *sql.DB, dbType string, c cache.Cache) *Feed {
+	return &Feed{
+		DB:           db,
+		DatabaseType: dbType,
+		Cache:        c,
+		TTL:          300,
+		PerPage:      20,
+	}
+}
+
+// Record stores an activity and drops the cached pages of its actor and object feeds
+func (f *Feed) Record(ctx context.Context, a Activity) error {
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = clock.Now(f.Clock)
+	}
+
+	data, err := json.Marshal(a.Data)
+	if err != nil {
+		return err
+	}
+
+	query := "insert into activities (actor_type, actor_id, verb, object_type, object_id, data, created_at) values (?, ?, ?, ?, ?, ?, ?)"
+	_, err = f.DB.ExecContext(ctx, database.Rebind(f.DatabaseType, query),
+		a.ActorType, a.ActorID, a.Verb, a.ObjectType, a.ObjectID, string(data), a.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if f.Cache != nil {
+		_ = f.Cache.EmptyByMatch(feedKey("actor", a.ActorType, a.ActorID))
+		_ = f.Cache.EmptyByMatch(feedKey("object", a.ObjectType, a.ObjectID))
+	}
+
+	return nil
+}
+
+// ForActor returns a page (starting at 1) of what the actor did, newest first
+func (f *Feed) ForActor(ctx context.Context, actorType string, actorID, page int) ([]Activity, error) {
+	return f.feed(ctx, "actor", actorType, actorID, page)
+}
+
+// ForObject returns a page (starting at 1) of what happened to the object, newest first
+func (f *Feed) ForObject(ctx context.Context, objectType string, objectID, page int) ([]Activity, error) {
+	return f.feed(ctx, "object", objectType, objectID, page)
+}
+
+func (f *Feed) feed(ctx context.Context, kind, typ string, id, page int) ([]Activity, error) {
+	if page < 1 {
+		page = 1
+	}
+
+	key := pageKey(kind, typ, id, page)
+	if f.Cache != nil {
+		if cached, err := f.Cache.Get(key); err == nil {
+			var list []Activity
+			if b, ok := cached.([]byte); ok && json.Unmarshal(b, &list) == nil {
+				return list, nil
+			}
+		}
+	}
+
+	query := fmt.Sprintf(`select id, actor_type, actor_id, verb, object_type, object_id, data, created_at
+		from activities where %s_type = ? and %s_id = ? order by created_at desc, id desc limit %d offset %d`,
+		kind, kind, f.PerPage, (page-1)*f.PerPage)
+
+	rows, err := f.DB.QueryContext(ctx, database.Rebind(f.DatabaseType, query), typ, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Activity
+	for rows.Next() {
+		var a Activity
+		var data string
+
+		err := rows.Scan(&a.ID, &a.ActorType, &a.ActorID, &a.Verb, &a.ObjectType, &a.ObjectID, &data, &a.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(data), &a.Data); err != nil {
+			return nil, err
+		}
+
+		list = append(list, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// pages are cached as json, which any cache can encode whatever Data holds, and which
+	// decodes Data the way it is read from the database; a page which could not be cached
+	// is read again next time
+	if f.Cache != nil {
+		if b, err := json.Marshal(list); err == nil {
+			_ = f.Cache.Set(key, b, f.TTL)
+		}
+	}
+
+	return list, nil
+}
+
+// Describe returns a short human readable sentence for the activity, e.g. "user 1 commented post 3"
+func (a Activity) Describe() string {
+	return fmt.Sprintf("%s %d %s %s %d", a.ActorType, a.ActorID, a.Verb, a.ObjectType, a.ObjectID)
+}
+
+// feedKey is the prefix of the cached pages of a feed, ending with a colon so the pages of
+// user 1 are forgotten without those of user 10
+func feedKey(kind, typ string, id int) string {
+	return fmt.Sprintf("activity:%s:%s:%d:", kind, typ, id)
+}
+
+func pageKey(kind, typ string, id, page int) string {
+	return fmt.Sprintf("%spage:%d", feedKey(kind, typ, id), page)
+}
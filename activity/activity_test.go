@@ -0,0 +1,76 @@
+package activity
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+	"github.com/namnguyen191/goravel/cache"
+)
+
+func testCache(t *testing.T) *cache.RedisCache {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+
+	return &cache.RedisCache{
+		Conn:   &redis.Pool{Dial: func() (redis.Conn, error) { return redis.Dial("tcp", s.Addr()) }},
+		Prefix: "test",
+	}
+}
+
+func TestFeed_cachedPage(t *testing.T) {
+	c := testCache(t)
+
+	f := New(nil, "postgres", c)
+	want := []Activity{{
+		ID: 1, ActorType: "user", ActorID: 7, Verb: "liked", ObjectType: "post", ObjectID: 3,
+		Data: map[string]interface{}{"tags": []interface{}{"go"}, "stars": 5},
+	}}
+	b, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(pageKey("actor", "user", 7, 1), b); err != nil {
+		t.Fatal(err)
+	}
+
+	// a cached page is served without touching the database
+	got, err := f.ForActor(context.Background(), "user", 7, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 1 || got[0].Describe() != "user 7 liked post 3" {
+		t.Errorf("unexpected feed %+v", got)
+	}
+	if tags, ok := got[0].Data["tags"].([]interface{}); !ok || len(tags) != 1 || got[0].Data["stars"] != float64(5) {
+		t.Errorf("expected Data decoded as from the database, got %#v", got[0].Data)
+	}
+}
+
+func TestFeedKey(t *testing.T) {
+	c := testCache(t)
+
+	for _, id := range []int{1, 10, 11} {
+		if err := c.Set(pageKey("actor", "user", id, 1), []byte("[]")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := c.EmptyByMatch(feedKey("actor", "user", 1)); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := c.Has(pageKey("actor", "user", 1, 1)); ok {
+		t.Error("expected the pages of user 1 forgotten")
+	}
+	for _, id := range []int{10, 11} {
+		if ok, _ := c.Has(pageKey("actor", "user", id, 1)); !ok {
+			t.Errorf("expected the pages of user %d kept", id)
+		}
+	}
+}
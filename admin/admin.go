@@ -0,0 +1,488 @@
+package admin
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/justinas/nosurf"
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+	"github.com/namnguyen191/goravel/render"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// Admin serves CRUD pages for the registered models
+type Admin struct {
+	DB           *sql.DB
+	DatabaseType string
+	Session      *scs.SessionManager
+	// Render, when set, is used for pages the app provides under views/admin
+	Render *render.Render
+	// Authorize decides who can use the panel; without it nobody can
+	Authorize func(r *http.Request) bool
+	// LoginURL is where unauthorized visitors are redirected
+	LoginURL string
+	// Prefix is the path the routes are mounted on
+	Prefix   string
+	ErrorLog func(v ...interface{})
+	// Clock tells the time of created and updated rows; the time of the machine when nil
+	Clock clock.Clock
+
+	mu        sync.RWMutex
+	resources map[string]*Resource
+}
+
+type pageData struct {
+	Prefix    string
+	Resources []*Resource
+	Resource  *Resource
+	Columns   []string
+	Rows      []map[string]string
+	Record    map[string]string
+	Query     string
+	Sort      string
+	Dir       string
+	Page      int
+	Pages     int
+	Total     int
+	CSRFToken string
+	Error     string
+}
+
+// New returns an admin panel using the given database pool
+func New(db *sql.DB, dbType string, session *scs.SessionManager) *Admin {
+	return &Admin{
+		DB:           db,
+		DatabaseType: dbType,
+		Session:      session,
+		LoginURL:     "/users/login",
+		Prefix:       "/admin",
+		resources:    make(map[string]*Resource),
+	}
+}
+
+// Register adds a model to the panel; the model's db tags describe its columns
+func (a *Admin) Register(model interface{}) (*Resource, error) {
+	res, err := newResource(model)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.resources[res.Name] = res
+
+	return res, nil
+}
+
+// Routes returns the admin handlers, meant to be mounted with Routes.Mount("/admin", ...)
+func (a *Admin) Routes() http.Handler {
+	mux := chi.NewRouter()
+	mux.Use(a.auth)
+
+	mux.Get("/", a.index)
+	mux.Get("/{resource}", a.list)
+	mux.Get("/{resource}/new", a.create)
+	mux.Post("/{resource}", a.store)
+	mux.Get("/{resource}/{id}/edit", a.edit)
+	mux.Post("/{resource}/{id}", a.update)
+	mux.Get("/{resource}/{id}/delete", a.confirmDelete)
+	mux.Post("/{resource}/{id}/delete", a.destroy)
+
+	return mux
+}
+
+// auth lets through the users Authorize allows, turning away the other logged in users and
+// sending visitors to LoginURL
+func (a *Admin) auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if a.Authorize != nil && a.Authorize(r) {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		if a.Session != nil && a.Session.Exists(r.Context(), "userID") {
+			http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		http.Redirect(rw, r, a.LoginURL, http.StatusSeeOther)
+	})
+}
+
+func (a *Admin) resource(rw http.ResponseWriter, r *http.Request) (*Resource, bool) {
+	a.mu.RLock()
+	res, ok := a.resources[chi.URLParam(r, "resource")]
+	a.mu.RUnlock()
+
+	if !ok {
+		http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+	}
+
+	return res, ok
+}
+
+func (a *Admin) sortedResources() []*Resource {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	list := make([]*Resource, 0, len(a.resources))
+	for _, res := range a.resources {
+		list = append(list, res)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Label < list[j].Label })
+
+	return list
+}
+
+func (a *Admin) index(rw http.ResponseWriter, r *http.Request) {
+	a.page(rw, r, "index", &pageData{})
+}
+
+func (a *Admin) list(rw http.ResponseWriter, r *http.Request) {
+	res, ok := a.resource(rw, r)
+	if !ok {
+		return
+	}
+
+	data := &pageData{
+		Resource: res,
+		Columns:  res.columns(),
+		Query:    r.URL.Query().Get("q"),
+		Sort:     "id",
+		Dir:      "asc",
+		Page:     1,
+	}
+
+	if s := r.URL.Query().Get("sort"); res.field(s) != nil {
+		data.Sort = s
+	}
+
+	if strings.ToLower(r.URL.Query().Get("dir")) == "desc" {
+		data.Dir = "desc"
+	}
+
+	if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && p > 0 {
+		data.Page = p
+	}
+
+	where, args := a.searchClause(res, data.Query)
+
+	err := a.DB.QueryRowContext(r.Context(), a.rebind(fmt.Sprintf("select count(*) from %s%s", res.Table, where)), args...).Scan(&data.Total)
+	if err != nil {
+		a.serverError(rw, err)
+		return
+	}
+
+	data.Pages = (data.Total + res.PerPage - 1) / res.PerPage
+
+	query := fmt.Sprintf("select %s from %s%s order by %s %s limit %d offset %d",
+		strings.Join(data.Columns, ", "), res.Table, where, data.Sort, data.Dir, res.PerPage, (data.Page-1)*res.PerPage)
+
+	data.Rows, err = a.query(r, res, query, args...)
+	if err != nil {
+		a.serverError(rw, err)
+		return
+	}
+
+	a.page(rw, r, "list", data)
+}
+
+func (a *Admin) create(rw http.ResponseWriter, r *http.Request) {
+	res, ok := a.resource(rw, r)
+	if !ok {
+		return
+	}
+
+	a.page(rw, r, "form", &pageData{Resource: res, Record: map[string]string{}})
+}
+
+func (a *Admin) store(rw http.ResponseWriter, r *http.Request) {
+	res, ok := a.resource(rw, r)
+	if !ok {
+		return
+	}
+
+	cols, args, err := a.formValues(r, res)
+	if err != nil {
+		a.page(rw, r, "form", &pageData{Resource: res, Record: formRecord(r), Error: err.Error()})
+		return
+	}
+
+	now := clock.Now(a.Clock)
+	for _, c := range []string{"created_at", "updated_at"} {
+		if res.field(c) != nil {
+			cols = append(cols, c)
+			args = append(args, now)
+		}
+	}
+
+	query := fmt.Sprintf("insert into %s (%s) values (%s)", res.Table, strings.Join(cols, ", "), placeholders(len(cols)))
+	if _, err := a.DB.ExecContext(r.Context(), a.rebind(query), args...); err != nil {
+		a.serverError(rw, err)
+		return
+	}
+
+	res.saved()
+	http.Redirect(rw, r, a.Prefix+"/"+res.Name, http.StatusSeeOther)
+}
+
+func (a *Admin) edit(rw http.ResponseWriter, r *http.Request) {
+	res, ok := a.resource(rw, r)
+	if !ok {
+		return
+	}
+
+	record, ok := a.find(rw, r, res)
+	if !ok {
+		return
+	}
+
+	a.page(rw, r, "form", &pageData{Resource: res, Record: record})
+}
+
+func (a *Admin) update(rw http.ResponseWriter, r *http.Request) {
+	res, ok := a.resource(rw, r)
+	if !ok {
+		return
+	}
+
+	cols, args, err := a.formValues(r, res)
+	if err != nil {
+		record := formRecord(r)
+		record["id"] = chi.URLParam(r, "id")
+		a.page(rw, r, "form", &pageData{Resource: res, Record: record, Error: err.Error()})
+		return
+	}
+
+	if res.field("updated_at") != nil {
+		cols = append(cols, "updated_at")
+		args = append(args, clock.Now(a.Clock))
+	}
+
+	sets := make([]string, len(cols))
+	for i, c := range cols {
+		sets[i] = c + " = ?"
+	}
+	args = append(args, chi.URLParam(r, "id"))
+
+	query := fmt.Sprintf("update %s set %s where id = ?", res.Table, strings.Join(sets, ", "))
+	if _, err := a.DB.ExecContext(r.Context(), a.rebind(query), args...); err != nil {
+		a.serverError(rw, err)
+		return
+	}
+
+	res.saved()
+	http.Redirect(rw, r, a.Prefix+"/"+res.Name, http.StatusSeeOther)
+}
+
+func (a *Admin) confirmDelete(rw http.ResponseWriter, r *http.Request) {
+	res, ok := a.resource(rw, r)
+	if !ok {
+		return
+	}
+
+	record, ok := a.find(rw, r, res)
+	if !ok {
+		return
+	}
+
+	a.page(rw, r, "delete", &pageData{Resource: res, Record: record})
+}
+
+func (a *Admin) destroy(rw http.ResponseWriter, r *http.Request) {
+	res, ok := a.resource(rw, r)
+	if !ok {
+		return
+	}
+
+	query := fmt.Sprintf("delete from %s where id = ?", res.Table)
+	if _, err := a.DB.ExecContext(r.Context(), a.rebind(query), chi.URLParam(r, "id")); err != nil {
+		a.serverError(rw, err)
+		return
+	}
+
+	res.saved()
+	http.Redirect(rw, r, a.Prefix+"/"+res.Name, http.StatusSeeOther)
+}
+
+func (a *Admin) find(rw http.ResponseWriter, r *http.Request, res *Resource) (map[string]string, bool) {
+	query := fmt.Sprintf("select %s from %s where id = ?", strings.Join(res.columns(), ", "), res.Table)
+
+	rows, err := a.query(r, res, query, chi.URLParam(r, "id"))
+	if err != nil {
+		a.serverError(rw, err)
+		return nil, false
+	}
+
+	if len(rows) == 0 {
+		http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return nil, false
+	}
+
+	return rows[0], true
+}
+
+func (a *Admin) query(r *http.Request, res *Resource, query string, args ...interface{}) ([]map[string]string, error) {
+	rows, err := a.DB.QueryContext(r.Context(), a.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols := res.columns()
+	var result []map[string]string
+
+	for rows.Next() {
+		values := make([]interface{}, len(cols))
+		dest := make([]interface{}, len(cols))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]string, len(cols))
+		for i, c := range cols {
+			row[c] = formatValue(values[i])
+		}
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
+
+func (a *Admin) searchClause(res *Resource, q string) (string, []interface{}) {
+	cols := res.searchable()
+	if q == "" || len(cols) == 0 {
+		return "", nil
+	}
+
+	conds := make([]string, len(cols))
+	args := make([]interface{}, len(cols))
+	for i, c := range cols {
+		conds[i] = fmt.Sprintf("%s %s ?", c, database.Like(a.DatabaseType))
+		args[i] = "%" + q + "%"
+	}
+
+	return " where " + strings.Join(conds, " or "), args
+}
+
+// formValues converts the submitted form into column values using the Go type of each field
+func (a *Admin) formValues(r *http.Request, res *Resource) ([]string, []interface{}, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, nil, err
+	}
+
+	var cols []string
+	var args []interface{}
+
+	for _, f := range res.editable() {
+		raw := r.Form.Get(f.Column)
+
+		var value interface{}
+		var err error
+
+		switch {
+		case f.Type == timeType:
+			value, err = time.Parse("2006-01-02T15:04", raw)
+		case f.Type.Kind() == reflect.Bool:
+			value = raw != ""
+		case f.InputType() == "number" && strings.HasPrefix(f.Type.Kind().String(), "float"):
+			value, err = strconv.ParseFloat(raw, 64)
+		case f.InputType() == "number":
+			value, err = strconv.ParseInt(raw, 10, 64)
+		default:
+			value = raw
+		}
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: invalid value %q", f.Label, raw)
+		}
+
+		cols = append(cols, f.Column)
+		args = append(args, value)
+	}
+
+	return cols, args, nil
+}
+
+func (a *Admin) rebind(query string) string {
+	return database.Rebind(a.DatabaseType, query)
+}
+
+// page renders views/admin/<name> through the app renderer when it exists, and the built-in
+// template otherwise
+func (a *Admin) page(rw http.ResponseWriter, r *http.Request, name string, data *pageData) {
+	data.Prefix = a.Prefix
+	data.Resources = a.sortedResources()
+	data.CSRFToken = nosurf.Token(r)
+
+	if a.Render != nil && a.Render.Exists("admin/"+name) {
+		td := &render.TemplateData{Data: map[string]interface{}{"admin": data}}
+		if err := a.Render.Page(rw, r, "admin/"+name, nil, td); err != nil {
+			a.serverError(rw, err)
+		}
+		return
+	}
+
+	tmpl, err := template.New("layout.page.tmpl").Funcs(template.FuncMap{
+		"add": func(a, b int) int { return a + b },
+	}).ParseFS(templateFS, "templates/layout.page.tmpl", "templates/"+name+".page.tmpl")
+	if err != nil {
+		a.serverError(rw, err)
+		return
+	}
+
+	if err := tmpl.Execute(rw, data); err != nil {
+		a.serverError(rw, err)
+	}
+}
+
+// serverError logs err, which may hold the SQL of a failed query, and answers with a plain 500
+func (a *Admin) serverError(rw http.ResponseWriter, err error) {
+	if a.ErrorLog != nil {
+		a.ErrorLog("admin:", err)
+	}
+	http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+func formRecord(r *http.Request) map[string]string {
+	record := make(map[string]string)
+	for k := range r.Form {
+		record[k] = r.Form.Get(k)
+	}
+
+	return record
+}
+
+func formatValue(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(x)
+	case time.Time:
+		return x.Format("2006-01-02T15:04")
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
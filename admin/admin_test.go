@@ -0,0 +1,135 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+)
+
+type testUser struct {
+	ID        int       `db:"id,omitempty"`
+	FirstName string    `db:"first_name"`
+	Active    int       `db:"user_active"`
+	Password  string    `db:"password" admin:"-"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (u *testUser) Table() string {
+	return "users"
+}
+
+func TestNewResource(t *testing.T) {
+	res, err := newResource(&testUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Name != "users" {
+		t.Errorf("expected resource name users, got %s", res.Name)
+	}
+
+	if res.field("password") != nil {
+		t.Error("fields tagged admin:\"-\" must be hidden")
+	}
+
+	editable := res.editable()
+	if len(editable) != 2 || editable[0].Column != "first_name" || editable[1].Column != "user_active" {
+		t.Errorf("unexpected editable fields %v", editable)
+	}
+
+	if _, err := newResource(struct{ Name string }{}); err == nil {
+		t.Error("expected an error for a model without an id column")
+	}
+}
+
+func TestAdmin_formValues(t *testing.T) {
+	a := New(nil, "postgres", nil)
+	res, _ := newResource(&testUser{})
+
+	r := httptest.NewRequest("POST", "/admin/users", strings.NewReader(url.Values{"first_name": {"Jane"}, "user_active": {"1"}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	cols, args, err := a.formValues(r, res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cols) != 2 || args[0] != "Jane" || args[1] != int64(1) {
+		t.Errorf("unexpected values %v %v", cols, args)
+	}
+
+	r = httptest.NewRequest("POST", "/admin/users", strings.NewReader("user_active=abc"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if _, _, err := a.formValues(r, res); err == nil {
+		t.Error("expected an error for a non numeric value")
+	}
+}
+
+func TestAdmin_Routes(t *testing.T) {
+	a := New(nil, "postgres", nil)
+	_, _ = a.Register(&testUser{})
+
+	rw := httptest.NewRecorder()
+	a.Routes().ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	if rw.Code != http.StatusSeeOther {
+		t.Errorf("expected redirect to login, got %d", rw.Code)
+	}
+
+	a.Authorize = func(r *http.Request) bool { return true }
+	rw = httptest.NewRecorder()
+	a.Routes().ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	if rw.Code != http.StatusOK || !strings.Contains(rw.Body.String(), `href="/admin/users"`) {
+		t.Errorf("expected dashboard listing users, got %d %s", rw.Code, rw.Body.String())
+	}
+
+	rw = httptest.NewRecorder()
+	a.Routes().ServeHTTP(rw, httptest.NewRequest("GET", "/users/new", nil))
+	if !strings.Contains(rw.Body.String(), `name="first_name"`) {
+		t.Errorf("expected create form, got %s", rw.Body.String())
+	}
+}
+
+func TestAdmin_Authorize(t *testing.T) {
+	sess := scs.New()
+	a := New(nil, "postgres", sess)
+	h := sess.LoadAndSave(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		sess.Put(r.Context(), "userID", 1)
+		a.Routes().ServeHTTP(rw, r)
+	}))
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	if rw.Code != http.StatusForbidden {
+		t.Errorf("expected a logged in user to be turned away without Authorize, got %d", rw.Code)
+	}
+
+	a.Authorize = func(r *http.Request) bool { return sess.GetInt(r.Context(), "userID") == 2 }
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	if rw.Code != http.StatusForbidden {
+		t.Errorf("expected a user Authorize does not allow to be turned away, got %d", rw.Code)
+	}
+}
+
+func TestAdmin_serverError(t *testing.T) {
+	a := New(nil, "postgres", nil)
+	var logged string
+	a.ErrorLog = func(v ...interface{}) { logged = fmt.Sprint(v...) }
+
+	rw := httptest.NewRecorder()
+	a.serverError(rw, errors.New(`pq: column "secret" does not exist`))
+	if rw.Code != http.StatusInternalServerError || strings.Contains(rw.Body.String(), "secret") {
+		t.Errorf("expected a plain 500, got %d %s", rw.Code, rw.Body.String())
+	}
+	if !strings.Contains(logged, "secret") {
+		t.Errorf("expected the error logged, got %q", logged)
+	}
+}
@@ -0,0 +1,149 @@
+package admin
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/iancoleman/strcase"
+)
+
+// Field describes a column of a registered model
+type Field struct {
+	Name     string
+	Column   string
+	Label    string
+	Type     reflect.Type
+	Editable bool
+}
+
+// Resource is a model registered with the admin panel
+type Resource struct {
+	Name    string
+	Label   string
+	Table   string
+	Fields  []Field
+	PerPage int
+	// AfterSave is called once a record has been created, updated or deleted
+	AfterSave func()
+}
+
+type tabler interface {
+	Table() string
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// newResource builds the resource metadata from the db struct tags of a model. Fields tagged
+// `admin:"-"` are hidden and fields tagged `admin:"readonly"` are shown but never edited.
+func newResource(model interface{}) (*Resource, error) {
+	rt := reflect.TypeOf(model)
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+
+	if rt.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("admin model must be a struct, got %s", rt.Kind())
+	}
+
+	table := strings.ToLower(strcase.ToSnake(rt.Name())) + "s"
+	if t, ok := model.(tabler); ok {
+		table = t.Table()
+	}
+
+	res := &Resource{
+		Name:    table,
+		Label:   strcase.ToCamel(table),
+		Table:   table,
+		PerPage: 20,
+	}
+
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		tag := f.Tag.Get("db")
+		if tag == "" || tag == "-" || f.Tag.Get("admin") == "-" {
+			continue
+		}
+
+		column := strings.Split(tag, ",")[0]
+		res.Fields = append(res.Fields, Field{
+			Name:     f.Name,
+			Column:   column,
+			Label:    strings.ReplaceAll(strcase.ToSnake(f.Name), "_", " "),
+			Type:     f.Type,
+			Editable: column != "id" && column != "created_at" && column != "updated_at" && f.Tag.Get("admin") != "readonly",
+		})
+	}
+
+	if res.field("id") == nil {
+		return nil, fmt.Errorf("admin model %s must have an id column", rt.Name())
+	}
+
+	return res, nil
+}
+
+func (res *Resource) field(column string) *Field {
+	for i := range res.Fields {
+		if res.Fields[i].Column == column {
+			return &res.Fields[i]
+		}
+	}
+
+	return nil
+}
+
+func (res *Resource) columns() []string {
+	cols := make([]string, 0, len(res.Fields))
+	for _, f := range res.Fields {
+		cols = append(cols, f.Column)
+	}
+
+	return cols
+}
+
+func (res *Resource) editable() []Field {
+	var fields []Field
+	for _, f := range res.Fields {
+		if f.Editable {
+			fields = append(fields, f)
+		}
+	}
+
+	return fields
+}
+
+func (res *Resource) searchable() []string {
+	var cols []string
+	for _, f := range res.Fields {
+		if f.Type.Kind() == reflect.String {
+			cols = append(cols, f.Column)
+		}
+	}
+
+	return cols
+}
+
+func (res *Resource) saved() {
+	if res.AfterSave != nil {
+		res.AfterSave()
+	}
+}
+
+// InputType returns the HTML input type used for the field in forms
+func (f Field) InputType() string {
+	switch f.Type.Kind() {
+	case reflect.Bool:
+		return "checkbox"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	}
+
+	if f.Type == timeType {
+		return "datetime-local"
+	}
+
+	return "text"
+}
@@ -0,0 +1,48 @@
+package goravel
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/namnguyen191/goravel/analytics"
+)
+
+// createAnalytics starts page view tracking when ANALYTICS is true and a database is configured
+func (grv *Goravel) createAnalytics() error {
+	if strings.ToLower(os.Getenv("ANALYTICS")) != "true" || grv.DB.Pool == nil {
+		return nil
+	}
+
+	grv.Analytics = analytics.New(grv.DB.Pool, grv.DB.DataBaseType, os.Getenv("KEY"))
+	grv.Analytics.ErrorLog = grv.ErrorLog.Println
+	grv.Analytics.Clock = grv.Clock
+	grv.Analytics.Visitors = grv.NewCounter(48 * time.Hour)
+	grv.Analytics.Start()
+
+	// today is rolled up every hour, and yesterday once more to catch its last hour
+	_, err := grv.Scheduler.AddFunc("@hourly", func() {
+		now := grv.Now()
+		for _, day := range []time.Time{now.AddDate(0, 0, -1), now} {
+			if err := grv.Analytics.Aggregate(context.Background(), day); err != nil {
+				grv.ErrorLog.Println("analytics:", err)
+			}
+		}
+	})
+
+	return err
+}
+
+// AnalyticsStats serves the built-in stats page to logged in users, meant to be mounted with
+// app.Routes.Handle("/stats", app.AnalyticsStats())
+func (grv *Goravel) AnalyticsStats() http.Handler {
+	if grv.Analytics == nil {
+		return http.HandlerFunc(grv.Error404)
+	}
+
+	return grv.Analytics.StatsPage(func(r *http.Request) bool {
+		return grv.Session.Exists(r.Context(), "userID")
+	})
+}
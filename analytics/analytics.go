@@ -0,0 +1,296 @@
+package analytics
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/namnguyen191/goravel/bots"
+	"github.com/namnguyen191/goravel/clientinfo"
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+	"github.com/namnguyen191/goravel/sketch"
+)
+
+// PageView is the name of the event recorded by the middleware
+const PageView = "pageview"
+
+// Event is a single recorded page view or custom event
+type Event struct {
+	Name     string
+	Path     string
+	Referrer string
+	// Visitor is a daily rotating hash of the client, so no personal data is stored
+	Visitor   string
+	Country   string
+	Device    string
+	Data      map[string]interface{}
+	CreatedAt time.Time
+}
+
+// Analytics buffers events and writes them to the database in batches, off the request path
+type Analytics struct {
+	DB           *sql.DB
+	DatabaseType string
+	// Secret salts the visitor hash
+	Secret string
+	// BatchSize events are written in one statement; FlushEvery bounds how long they wait
+	BatchSize  int
+	FlushEvery time.Duration
+	// Skip excludes paths, by prefix, from page view tracking
+	Skip []string
+	// Visitors counts the visitors of the day as events are written, for LiveVisitors
+	Visitors sketch.Counter
+	ErrorLog func(v ...interface{})
+	// Clock tells when the events happen; the time of the machine when nil
+	Clock clock.Clock
+
+	events chan Event
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// New returns an analytics recorder; call Start to begin writing events
+func New(db *sql.DB, dbType, secret string) *Analytics {
+	return &Analytics{
+		DB:           db,
+		DatabaseType: dbType,
+		Secret:       secret,
+		BatchSize:    100,
+		FlushEvery:   5 * time.Second,
+		Skip:         []string{"/public/", "/favicon.ico", "/robots.txt"},
+		events:       make(chan Event, 1000),
+		done:         make(chan struct{}),
+	}
+}
+
+// Start runs the background writer
+func (a *Analytics) Start() {
+	a.wg.Add(1)
+	go a.writer()
+}
+
+// Stop flushes buffered events and stops the writer
+func (a *Analytics) Stop() {
+	close(a.done)
+	a.wg.Wait()
+}
+
+// Record queues an event; when the buffer is full the event is dropped rather than slowing the request
+func (a *Analytics) Record(e Event) {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = clock.Now(a.Clock)
+	}
+
+	select {
+	case a.events <- e:
+	default:
+		a.logError("analytics: buffer full, dropping event", e.Name)
+	}
+}
+
+// Track records a custom event for the client behind r
+func (a *Analytics) Track(r *http.Request, name string, data map[string]interface{}) {
+	e := a.event(r, name)
+	e.Data = data
+	a.Record(e)
+}
+
+// Middleware records a page view for every successful GET request from a human visitor
+func (a *Analytics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		sw := &statusWriter{ResponseWriter: rw, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+
+		if r.Method != http.MethodGet || sw.status != http.StatusOK || a.skipped(r.URL.Path) || bots.IsBot(r) {
+			return
+		}
+
+		a.Record(a.event(r, PageView))
+	})
+}
+
+func (a *Analytics) skipped(path string) bool {
+	if strings.HasPrefix(path, "/api/") {
+		return true
+	}
+
+	for _, prefix := range a.Skip {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a *Analytics) event(r *http.Request, name string) Event {
+	info := clientinfo.FromRequest(r)
+
+	e := Event{
+		Name:      name,
+		Path:      r.URL.Path,
+		Referrer:  referrer(r),
+		Visitor:   a.visitor(r, info),
+		Country:   info.Country(),
+		Device:    info.Agent.Device,
+		CreatedAt: clock.Now(a.Clock),
+	}
+
+	return e
+}
+
+// visitor hashes the client's address and user agent with a salt that changes every day
+func (a *Analytics) visitor(r *http.Request, info *clientinfo.Info) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		a.Secret,
+		clock.Now(a.Clock).UTC().Format("2006-01-02"),
+		info.IP.String(),
+		r.UserAgent(),
+	}, "|")))
+
+	return hex.EncodeToString(sum[:16])
+}
+
+// referrer keeps only the host of external referrers
+func referrer(r *http.Request) string {
+	ref := r.Referer()
+	if ref == "" {
+		return ""
+	}
+
+	ref = strings.TrimPrefix(strings.TrimPrefix(ref, "https://"), "http://")
+	if i := strings.IndexAny(ref, "/?#"); i >= 0 {
+		ref = ref[:i]
+	}
+
+	if ref == r.Host {
+		return ""
+	}
+
+	return ref
+}
+
+func (a *Analytics) writer() {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(a.FlushEvery)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, a.BatchSize)
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := a.insert(context.Background(), batch); err != nil {
+			a.logError("analytics:", err)
+		}
+		if err := a.countVisitors(context.Background(), batch); err != nil {
+			a.logError("analytics: visitors:", err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case e := <-a.events:
+			batch = append(batch, e)
+			if len(batch) >= a.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-a.done:
+			for {
+				select {
+				case e := <-a.events:
+					batch = append(batch, e)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (a *Analytics) insert(ctx context.Context, events []Event) error {
+	query := "insert into analytics_events (name, path, referrer, visitor, country, device, data, created_at) values "
+	args := make([]interface{}, 0, len(events)*8)
+
+	for i, e := range events {
+		if i > 0 {
+			query += ", "
+		}
+		query += "(?, ?, ?, ?, ?, ?, ?, ?)"
+
+		data := "{}"
+		if len(e.Data) > 0 {
+			b, err := json.Marshal(e.Data)
+			if err != nil {
+				return err
+			}
+			data = string(b)
+		}
+
+		args = append(args, e.Name, e.Path, e.Referrer, e.Visitor, e.Country, e.Device, data, e.CreatedAt)
+	}
+
+	_, err := a.DB.ExecContext(ctx, database.Rebind(a.DatabaseType, query), args...)
+
+	return err
+}
+
+func visitorsKey(day time.Time) string {
+	return "analytics:visitors:" + day.UTC().Format("2006-01-02")
+}
+
+func (a *Analytics) countVisitors(ctx context.Context, events []Event) error {
+	if a.Visitors == nil {
+		return nil
+	}
+
+	byDay := map[string][]string{}
+	for _, e := range events {
+		key := visitorsKey(e.CreatedAt)
+		byDay[key] = append(byDay[key], e.Visitor)
+	}
+
+	for key, visitors := range byDay {
+		if err := a.Visitors.Add(ctx, key, visitors...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LiveVisitors estimates the visitors of day so far, without waiting for Aggregate
+func (a *Analytics) LiveVisitors(ctx context.Context, day time.Time) (int64, error) {
+	if a.Visitors == nil {
+		return 0, nil
+	}
+	return a.Visitors.Count(ctx, visitorsKey(day))
+}
+
+func (a *Analytics) logError(v ...interface{}) {
+	if a.ErrorLog != nil {
+		a.ErrorLog(v...)
+	}
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
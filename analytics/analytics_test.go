@@ -0,0 +1,77 @@
+package analytics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/namnguyen191/goravel/sketch"
+)
+
+func TestMiddleware(t *testing.T) {
+	a := New(nil, "postgres", "secret")
+
+	h := a.Middleware(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	request := func(method, path, ua string) {
+		r := httptest.NewRequest(method, path, nil)
+		r.Header.Set("User-Agent", ua)
+		r.Header.Set("Referer", "https://news.ycombinator.com/item?id=1")
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	browser := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/118.0 Safari/537.36"
+	request("GET", "/blog/hello", browser)
+	request("GET", "/missing", browser)
+	request("POST", "/blog/hello", browser)
+	request("GET", "/public/app.css", browser)
+	request("GET", "/blog/hello", "Googlebot/2.1")
+
+	if len(a.events) != 1 {
+		t.Fatalf("expected a single page view, got %d", len(a.events))
+	}
+
+	e := <-a.events
+	if e.Name != PageView || e.Path != "/blog/hello" || e.Referrer != "news.ycombinator.com" || e.Device != "desktop" {
+		t.Errorf("unexpected event %+v", e)
+	}
+	if len(e.Visitor) != 32 {
+		t.Error("expected a hashed visitor id, got", e.Visitor)
+	}
+}
+
+func TestStatsPageRequiresAuthorization(t *testing.T) {
+	a := New(nil, "postgres", "secret")
+
+	rw := httptest.NewRecorder()
+	a.StatsPage(nil).ServeHTTP(rw, httptest.NewRequest("GET", "/stats", nil))
+	if rw.Code != http.StatusForbidden {
+		t.Error("expected 403, got", rw.Code)
+	}
+}
+
+func TestLiveVisitors(t *testing.T) {
+	a := New(nil, "postgres", "secret")
+	a.Visitors = sketch.NewMemoryCounter()
+
+	now := time.Now()
+	events := []Event{
+		{Visitor: "a", CreatedAt: now},
+		{Visitor: "b", CreatedAt: now},
+		{Visitor: "a", CreatedAt: now},
+		{Visitor: "c", CreatedAt: now.AddDate(0, 0, -1)},
+	}
+	if err := a.countVisitors(context.Background(), events); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, _ := a.LiveVisitors(context.Background(), now); n != 2 {
+		t.Errorf("expected 2 visitors today, got %d", n)
+	}
+}
@@ -0,0 +1,93 @@
+package analytics
+
+import (
+	"context"
+	"embed"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+type statsPage struct {
+	Days      int
+	Totals    Totals
+	Daily     []Point
+	Max       int
+	Pages     []Row
+	Referrers []Row
+	Countries []Row
+	Events    []Row
+}
+
+// StatsPage serves a simple traffic report; ?days= picks the period (30 by default).
+// Requests are refused unless Authorize allows them.
+func (a *Analytics) StatsPage(authorize func(r *http.Request) bool) http.Handler {
+	tmpl := template.Must(template.New("stats.page.tmpl").Funcs(template.FuncMap{
+		"percent": func(n, max int) int { return n * 100 / max },
+		"table": func(title string, rows []Row) map[string]interface{} {
+			return map[string]interface{}{"Title": title, "Rows": rows}
+		},
+	}).ParseFS(templateFS, "templates/stats.page.tmpl"))
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if authorize == nil || !authorize(r) {
+			http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		days, err := strconv.Atoi(r.URL.Query().Get("days"))
+		if err != nil || days < 1 || days > 365 {
+			days = 30
+		}
+
+		to := clock.Now(a.Clock)
+		from := to.AddDate(0, 0, -days+1)
+		ctx := r.Context()
+
+		page := statsPage{Days: days}
+		if err = a.load(ctx, &page, from, to); err != nil {
+			a.logError("analytics:", err)
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		for _, p := range page.Daily {
+			if p.Views > page.Max {
+				page.Max = p.Views
+			}
+		}
+
+		if err := tmpl.Execute(rw, page); err != nil {
+			a.logError("analytics:", err)
+		}
+	})
+}
+
+func (a *Analytics) load(ctx context.Context, page *statsPage, from, to time.Time) error {
+	var err error
+
+	if page.Totals, err = a.Summary(ctx, from, to); err != nil {
+		return err
+	}
+	if page.Daily, err = a.Daily(ctx, from, to); err != nil {
+		return err
+	}
+	if page.Pages, err = a.TopPages(ctx, from, to, 20); err != nil {
+		return err
+	}
+	if page.Referrers, err = a.TopReferrers(ctx, from, to, 10); err != nil {
+		return err
+	}
+	if page.Countries, err = a.TopCountries(ctx, from, to, 10); err != nil {
+		return err
+	}
+	page.Events, err = a.Events(ctx, from, to, 10)
+
+	return err
+}
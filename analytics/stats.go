@@ -0,0 +1,160 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+)
+
+// Row is one line of a top-N report
+type Row struct {
+	Label    string
+	Views    int
+	Visitors int
+}
+
+// Point is one day of the traffic series
+type Point struct {
+	Day      time.Time
+	Views    int
+	Visitors int
+}
+
+// Totals sums page views and visitors over a period; visitors are counted per day
+type Totals struct {
+	Views    int
+	Visitors int
+}
+
+// Aggregate rolls the page views of day up into analytics_daily; running it twice for the same day is safe
+func (a *Analytics) Aggregate(ctx context.Context, day time.Time) error {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+
+	tx, err := a.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, database.Rebind(a.DatabaseType, "delete from analytics_daily where day = ?"), start)
+	if err != nil {
+		return err
+	}
+
+	query := `insert into analytics_daily (day, path, views, visitors)
+		select ?, path, count(*), count(distinct visitor) from analytics_events
+		where name = ? and created_at >= ? and created_at < ? group by path`
+	_, err = tx.ExecContext(ctx, database.Rebind(a.DatabaseType, query), start, PageView, start, end)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Prune deletes raw events older than the given age; aggregated rows are kept
+func (a *Analytics) Prune(ctx context.Context, olderThan time.Duration) (int64, error) {
+	res, err := a.DB.ExecContext(ctx, database.Rebind(a.DatabaseType, "delete from analytics_events where created_at < ?"), clock.Now(a.Clock).Add(-olderThan))
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// Summary returns the totals between from and to (inclusive days)
+func (a *Analytics) Summary(ctx context.Context, from, to time.Time) (Totals, error) {
+	var t Totals
+
+	query := "select coalesce(sum(views), 0), coalesce(sum(visitors), 0) from analytics_daily where day >= ? and day <= ?"
+	err := a.DB.QueryRowContext(ctx, database.Rebind(a.DatabaseType, query), from, to).Scan(&t.Views, &t.Visitors)
+
+	return t, err
+}
+
+// Daily returns one point per aggregated day between from and to
+func (a *Analytics) Daily(ctx context.Context, from, to time.Time) ([]Point, error) {
+	query := "select day, sum(views), sum(visitors) from analytics_daily where day >= ? and day <= ? group by day order by day"
+	rows, err := a.DB.QueryContext(ctx, database.Rebind(a.DatabaseType, query), from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []Point
+	for rows.Next() {
+		var p Point
+		if err := rows.Scan(&p.Day, &p.Views, &p.Visitors); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+
+	return points, rows.Err()
+}
+
+// TopPages returns the most viewed paths between from and to
+func (a *Analytics) TopPages(ctx context.Context, from, to time.Time, limit int) ([]Row, error) {
+	query := fmt.Sprintf(`select path, sum(views), sum(visitors) from analytics_daily
+		where day >= ? and day <= ? group by path order by 2 desc limit %d`, limit)
+
+	return a.rows(ctx, query, from, to)
+}
+
+// TopReferrers returns the external sites sending the most visitors; it reads raw events, so only
+// periods that haven't been pruned are covered
+func (a *Analytics) TopReferrers(ctx context.Context, from, to time.Time, limit int) ([]Row, error) {
+	return a.topBy(ctx, "referrer", from, to, limit)
+}
+
+// TopCountries returns the countries sending the most visitors
+func (a *Analytics) TopCountries(ctx context.Context, from, to time.Time, limit int) ([]Row, error) {
+	return a.topBy(ctx, "country", from, to, limit)
+}
+
+// Events counts custom events by name
+func (a *Analytics) Events(ctx context.Context, from, to time.Time, limit int) ([]Row, error) {
+	query := fmt.Sprintf(`select name, count(*), count(distinct visitor) from analytics_events
+		where name <> ? and created_at >= ? and created_at < ? group by name order by 2 desc limit %d`, limit)
+
+	return a.rows(ctx, query, PageView, from, to.AddDate(0, 0, 1))
+}
+
+// EventsLike counts events whose name matches a LIKE pattern since the given time
+func (a *Analytics) EventsLike(ctx context.Context, pattern string, since time.Time) ([]Row, error) {
+	query := `select name, count(*), count(distinct visitor) from analytics_events
+		where name like ? and created_at >= ? group by name`
+
+	return a.rows(ctx, query, pattern, since)
+}
+
+// topBy groups page views by a column of analytics_events; column is never user input
+func (a *Analytics) topBy(ctx context.Context, column string, from, to time.Time, limit int) ([]Row, error) {
+	query := fmt.Sprintf(`select %[1]s, count(*), count(distinct visitor) from analytics_events
+		where name = ? and %[1]s <> '' and created_at >= ? and created_at < ? group by %[1]s order by 2 desc limit %[2]d`, column, limit)
+
+	return a.rows(ctx, query, PageView, from, to.AddDate(0, 0, 1))
+}
+
+func (a *Analytics) rows(ctx context.Context, query string, args ...interface{}) ([]Row, error) {
+	rows, err := a.DB.QueryContext(ctx, database.Rebind(a.DatabaseType, query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []Row
+	for rows.Next() {
+		var r Row
+		if err := rows.Scan(&r.Label, &r.Views, &r.Visitors); err != nil {
+			return nil, err
+		}
+		result = append(result, r)
+	}
+
+	return result, rows.Err()
+}
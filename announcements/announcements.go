@@ -0,0 +1,266 @@
+package announcements
+
+import (
+	"context"
+	"database/sql"
+	"encoding/gob"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+	"github.com/namnguyen191/goravel/render"
+)
+
+const dismissedKey = "_dismissed_announcements"
+
+// Audiences shipped with the board; more can be added with Board.Audience
+const (
+	Everyone = "all"
+	Guests   = "guests"
+	Users    = "users"
+)
+
+// noEnd is stored for announcements without an end, since MySQL rejects zero dates
+var noEnd = time.Date(1000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func init() {
+	gob.Register([]int{})
+}
+
+// Announcement is a banner shown between StartsAt and EndsAt; a zero (or year 1000) EndsAt never expires
+type Announcement struct {
+	ID        int       `db:"id"`
+	Message   string    `db:"message"`
+	Level     string    `db:"level"`
+	Audience  string    `db:"audience"`
+	StartsAt  time.Time `db:"starts_at"`
+	EndsAt    time.Time `db:"ends_at"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Table is the table announcements are stored in
+func (a *Announcement) Table() string {
+	return "announcements"
+}
+
+// Live reports whether the announcement is scheduled to show at t
+func (a Announcement) Live(t time.Time) bool {
+	return !t.Before(a.StartsAt) && (!a.EndsAt.After(noEnd) || t.Before(a.EndsAt))
+}
+
+// Board loads scheduled announcements and picks the ones to show on each request
+type Board struct {
+	DB           *sql.DB
+	DatabaseType string
+	Session      *scs.SessionManager
+	// Refresh is how long announcements are kept in memory before reloading them
+	Refresh time.Duration
+	// Clock tells which announcements are showing; the time of the machine when nil
+	Clock clock.Clock
+
+	mu        sync.RWMutex
+	all       []Announcement
+	loadedAt  time.Time
+	audiences map[string]func(r *http.Request) bool
+}
+
+// New returns a board reading the announcements table
+func New(db *sql.DB, dbType string, session *scs.SessionManager) *Board {
+	b := &Board{
+		DB:           db,
+		DatabaseType: dbType,
+		Session:      session,
+		Refresh:      time.Minute,
+	}
+
+	b.audiences = map[string]func(r *http.Request) bool{
+		Everyone: func(r *http.Request) bool { return true },
+		Guests:   func(r *http.Request) bool { return !b.loggedIn(r) },
+		Users:    b.loggedIn,
+	}
+
+	return b
+}
+
+// Audience registers a named audience, e.g. "admins", matched by fn
+func (b *Board) Audience(name string, fn func(r *http.Request) bool) {
+	b.mu.Lock()
+	b.audiences[name] = fn
+	b.mu.Unlock()
+}
+
+// Active returns the live announcements for the visitor that they haven't dismissed
+func (b *Board) Active(r *http.Request) []Announcement {
+	all, err := b.load(r.Context())
+	if err != nil {
+		return nil
+	}
+
+	dismissed := map[int]bool{}
+	for _, id := range b.dismissed(r.Context()) {
+		dismissed[id] = true
+	}
+
+	now := clock.Now(b.Clock)
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var active []Announcement
+	for _, a := range all {
+		match, ok := b.audiences[a.Audience]
+		if !ok || dismissed[a.ID] || !a.Live(now) || !match(r) {
+			continue
+		}
+		active = append(active, a)
+	}
+
+	return active
+}
+
+// Dismiss hides an announcement for the rest of the visitor's session
+func (b *Board) Dismiss(ctx context.Context, id int) {
+	if b.Session == nil {
+		return
+	}
+
+	ids := b.dismissed(ctx)
+	for _, existing := range ids {
+		if existing == id {
+			return
+		}
+	}
+
+	b.Session.Put(ctx, dismissedKey, append(ids, id))
+}
+
+// Create schedules a new announcement
+func (b *Board) Create(ctx context.Context, a Announcement) error {
+	if a.Level == "" {
+		a.Level = "info"
+	}
+	if a.Audience == "" {
+		a.Audience = Everyone
+	}
+	if a.StartsAt.IsZero() {
+		a.StartsAt = clock.Now(b.Clock)
+	}
+	if a.EndsAt.IsZero() {
+		a.EndsAt = noEnd
+	}
+
+	query := "insert into announcements (message, level, audience, starts_at, ends_at, created_at) values (?, ?, ?, ?, ?, ?)"
+	_, err := b.DB.ExecContext(ctx, database.Rebind(b.DatabaseType, query), a.Message, a.Level, a.Audience, a.StartsAt, a.EndsAt, clock.Now(b.Clock))
+	if err != nil {
+		return err
+	}
+
+	b.Flush()
+
+	return nil
+}
+
+// Delete removes an announcement
+func (b *Board) Delete(ctx context.Context, id int) error {
+	_, err := b.DB.ExecContext(ctx, database.Rebind(b.DatabaseType, "delete from announcements where id = ?"), id)
+	if err != nil {
+		return err
+	}
+
+	b.Flush()
+
+	return nil
+}
+
+// Flush drops the in-memory copy so the next request reloads from the database
+func (b *Board) Flush() {
+	b.mu.Lock()
+	b.loadedAt = time.Time{}
+	b.mu.Unlock()
+}
+
+// TemplateData adds the visitor's announcements to td.Data["announcements"]
+func (b *Board) TemplateData(r *http.Request, td *render.TemplateData) {
+	td.Data["announcements"] = b.Active(r)
+}
+
+// DismissHandler handles POST /announcements/{id}/dismiss and sends the visitor back where they came from
+func (b *Board) DismissHandler(rw http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	b.Dismiss(r.Context(), id)
+
+	back := r.Referer()
+	if back == "" {
+		back = "/"
+	}
+
+	http.Redirect(rw, r, back, http.StatusSeeOther)
+}
+
+func (b *Board) load(ctx context.Context) ([]Announcement, error) {
+	b.mu.RLock()
+	if clock.Since(b.Clock, b.loadedAt) < b.Refresh {
+		defer b.mu.RUnlock()
+		return b.all, nil
+	}
+	b.mu.RUnlock()
+
+	// only announcements that haven't ended are worth keeping around
+	query := "select id, message, level, audience, starts_at, ends_at, created_at from announcements where ends_at > ? or ends_at <= ? order by starts_at desc"
+	rows, err := b.DB.QueryContext(ctx, database.Rebind(b.DatabaseType, query), clock.Now(b.Clock), noEnd)
+	if err != nil {
+		// keep serving what we had instead of querying again on every request
+		b.set(b.all)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var all []Announcement
+	for rows.Next() {
+		var a Announcement
+		if err := rows.Scan(&a.ID, &a.Message, &a.Level, &a.Audience, &a.StartsAt, &a.EndsAt, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		all = append(all, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	b.set(all)
+
+	return all, nil
+}
+
+// set replaces the in-memory announcements
+func (b *Board) set(all []Announcement) {
+	b.mu.Lock()
+	b.all = all
+	b.loadedAt = clock.Now(b.Clock)
+	b.mu.Unlock()
+}
+
+func (b *Board) dismissed(ctx context.Context) []int {
+	if b.Session == nil {
+		return nil
+	}
+
+	ids, _ := b.Session.Get(ctx, dismissedKey).([]int)
+
+	return ids
+}
+
+func (b *Board) loggedIn(r *http.Request) bool {
+	return b.Session != nil && b.Session.Exists(r.Context(), "userID")
+}
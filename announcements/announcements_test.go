@@ -0,0 +1,48 @@
+package announcements
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+)
+
+func TestActive(t *testing.T) {
+	session := scs.New()
+	b := New(nil, "postgres", session)
+	b.Refresh = time.Hour
+
+	now := time.Now()
+	b.set([]Announcement{
+		{ID: 1, Message: "maintenance tonight", Audience: Everyone, StartsAt: now.Add(-time.Hour)},
+		{ID: 2, Message: "sign up!", Audience: Guests, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)},
+		{ID: 3, Message: "welcome back", Audience: Users, StartsAt: now.Add(-time.Hour)},
+		{ID: 4, Message: "next week", Audience: Everyone, StartsAt: now.Add(24 * time.Hour)},
+		{ID: 5, Message: "over", Audience: Everyone, StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-time.Hour)},
+	})
+
+	ids := func(list []Announcement) []int {
+		var out []int
+		for _, a := range list {
+			out = append(out, a.ID)
+		}
+		return out
+	}
+
+	h := session.LoadAndSave(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if got := ids(b.Active(r)); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+			t.Errorf("expected live guest announcements [1 2], got %v", got)
+		}
+
+		b.Dismiss(r.Context(), 1)
+		session.Put(r.Context(), "userID", 7)
+
+		if got := ids(b.Active(r)); len(got) != 1 || got[0] != 3 {
+			t.Errorf("expected only the users announcement after dismissal and login, got %v", got)
+		}
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
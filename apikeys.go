@@ -0,0 +1,41 @@
+package goravel
+
+import (
+	"context"
+	"time"
+
+	"github.com/namnguyen191/goravel/apikeys"
+	"github.com/namnguyen191/goravel/leader"
+)
+
+// createAPIKeys sets up the api keys when APIKEYS is true, sealing their secrets with KEY and
+// counting their requests in redis when it is configured, so the rate limits hold across
+// instances. The requests are counted in every instance, which writes them to the database
+// every minute and when it shuts down.
+func (grv *Goravel) createAPIKeys() (*apikeys.Keys, error) {
+	if !grv.Env.Bool("APIKEYS", false) {
+		return nil, nil
+	}
+
+	ks := apikeys.New(grv.DB.Pool, grv.DB.DataBaseType, []byte(grv.EncryptionKey))
+	ks.RateLimit = grv.Env.Int("APIKEYS_RATE_LIMIT", 60)
+	ks.Window = grv.Env.Duration("APIKEYS_WINDOW", 5*time.Minute)
+	ks.Clock = grv.Clock
+	if redisPool != nil {
+		ks.Limiter = &apikeys.RedisLimiter{Pool: redisPool, Prefix: grv.Namespace}
+	} else {
+		ks.Limiter = &apikeys.MemoryLimiter{Clock: grv.Clock}
+	}
+
+	_, err := grv.Scheduler.AddJob("@every 1m", leader.Everywhere(func() {
+		if err := ks.Flush(context.Background()); err != nil {
+			grv.ErrorLog.Println("apikeys: writing usage:", err)
+		}
+	}))
+	if err != nil {
+		return nil, err
+	}
+	grv.OnShutdown(ks.Flush)
+
+	return ks, nil
+}
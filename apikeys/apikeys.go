@@ -0,0 +1,367 @@
+// Package apikeys issues API keys to external integrations, apart from the personal tokens of
+// auth: a key is a public id with a secret signing the requests with HMAC-SHA256, has scopes
+// and a rate limit, counts its use by day, and is rotated with a grace period during which
+// the previous secret still works.
+package apikeys
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+)
+
+var (
+	// ErrInvalid is returned for an unknown key id or a signature made with another secret
+	ErrInvalid = errors.New("apikeys: invalid api key or signature")
+	// ErrRevoked is returned for a key which was revoked
+	ErrRevoked = errors.New("apikeys: api key revoked")
+	// ErrExpired is returned for a request signed outside of the Window around its timestamp
+	ErrExpired = errors.New("apikeys: request timestamp out of window")
+	// ErrName is returned by Issue for a key without a name
+	ErrName = errors.New("apikeys: a key needs a name")
+)
+
+// Key is a row of the api_keys table
+type Key struct {
+	ID   int
+	Name string
+	// KeyID is the public id of the key, sent by the integration with every request
+	KeyID string
+	// Secret signs the requests; it is only known when the key is issued or rotated
+	Secret string `json:"-"`
+	Scopes []string
+	// OwnerID is the user the key was issued to, 0 for the app itself
+	OwnerID int
+	// RateLimit is the requests allowed a minute, the RateLimit of Keys when 0
+	RateLimit int
+	// Requests counts the requests made with the key, as of the last Flush
+	Requests   int64
+	LastUsedAt time.Time
+	// RotatedAt is when the secret was last replaced, the previous one working until PreviousUntil
+	RotatedAt     time.Time
+	PreviousUntil time.Time
+	RevokedAt     time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	// secret and previous are the sealed secrets, opened when a request is verified
+	secret   string
+	previous string
+}
+
+// Can reports whether the key was issued for scope, or for every scope with "*"
+func (k *Key) Can(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Revoked reports whether the key can no longer be used
+func (k *Key) Revoked() bool {
+	return !k.RevokedAt.IsZero()
+}
+
+// NewKey is what Issue needs to create a key
+type NewKey struct {
+	Name      string
+	OwnerID   int
+	Scopes    []string
+	RateLimit int
+}
+
+// Keys keeps the api keys in the api_keys table and their use by day in api_key_usage
+type Keys struct {
+	DB           *sql.DB
+	DatabaseType string
+	// Secret seals the secrets of the keys in the table, which the signatures are checked
+	// with; it is the KEY of the app, and changing it makes every key worthless
+	Secret []byte
+	// Limiter counts the requests of the keys within a minute
+	Limiter Limiter
+	// RateLimit is the requests a minute of the keys without their own, unlimited when 0
+	RateLimit int
+	// Window is how far the timestamp of a request may be from now, 5 minutes by default
+	Window time.Duration
+	// MaxBody is the largest body a signed request may have, 10MB by default
+	MaxBody int64
+	// Clock tells the time of the requests and of their use; the time of the machine when nil
+	Clock clock.Clock
+
+	mu    sync.Mutex
+	usage map[usageKey]int64
+	last  map[int]time.Time
+}
+
+type usageKey struct {
+	id  int
+	day string
+}
+
+// New returns the keys of db, whose secrets are sealed with secret
+func New(db *sql.DB, dbType string, secret []byte) *Keys {
+	return &Keys{
+		DB:           db,
+		DatabaseType: dbType,
+		Secret:       secret,
+		Limiter:      &MemoryLimiter{},
+		RateLimit:    60,
+		Window:       5 * time.Minute,
+		MaxBody:      10 << 20,
+	}
+}
+
+func (ks *Keys) rebind(query string) string {
+	return database.Rebind(ks.DatabaseType, query)
+}
+
+// random returns n random bytes as url safe text
+func random(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// seal encrypts a secret for the table with AES-GCM, keyed by a hash of Secret so a KEY of
+// any length will do
+func (ks *Keys) seal(plain string) (string, error) {
+	gcm, err := ks.cipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plain), nil)), nil
+}
+
+func (ks *Keys) open(sealed string) (string, error) {
+	gcm, err := ks.cipher()
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil || len(data) < gcm.NonceSize() {
+		return "", ErrInvalid
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", ErrInvalid
+	}
+	return string(plain), nil
+}
+
+func (ks *Keys) cipher() (cipher.AEAD, error) {
+	if len(ks.Secret) == 0 {
+		return nil, errors.New("apikeys: sealing secrets needs KEY")
+	}
+	sum := sha256.Sum256(ks.Secret)
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+const columns = `id, name, key_id, secret, previous_secret, previous_until, scopes, owner_id, rate_limit, requests,
+	last_used_at, rotated_at, revoked_at, created_at, updated_at`
+
+func scan(row interface{ Scan(...interface{}) error }) (*Key, error) {
+	var k Key
+	var scopes string
+	var previousUntil, lastUsed, rotated, revoked sql.NullTime
+	err := row.Scan(&k.ID, &k.Name, &k.KeyID, &k.secret, &k.previous, &previousUntil, &scopes, &k.OwnerID,
+		&k.RateLimit, &k.Requests, &lastUsed, &rotated, &revoked, &k.CreatedAt, &k.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	k.Scopes = strings.Fields(scopes)
+	k.PreviousUntil = previousUntil.Time
+	k.LastUsedAt = lastUsed.Time
+	k.RotatedAt = rotated.Time
+	k.RevokedAt = revoked.Time
+	return &k, nil
+}
+
+// Find returns the key with id, or sql.ErrNoRows
+func (ks *Keys) Find(ctx context.Context, id int) (*Key, error) {
+	return scan(ks.DB.QueryRowContext(ctx, ks.rebind("select "+columns+" from api_keys where id = ?"), id))
+}
+
+// FindByKeyID returns the key with the public id keyID, or sql.ErrNoRows
+func (ks *Keys) FindByKeyID(ctx context.Context, keyID string) (*Key, error) {
+	return scan(ks.DB.QueryRowContext(ctx, ks.rebind("select "+columns+" from api_keys where key_id = ?"), keyID))
+}
+
+// ForOwner returns the keys issued to the user with ownerID, revoked ones included, the
+// latest first
+func (ks *Keys) ForOwner(ctx context.Context, ownerID int) ([]*Key, error) {
+	query := ks.rebind("select " + columns + " from api_keys where owner_id = ? order by created_at desc, id desc")
+	rows, err := ks.DB.QueryContext(ctx, query, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*Key
+	for rows.Next() {
+		k, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, k)
+	}
+	return list, rows.Err()
+}
+
+// Issue creates a key for in, whose Secret is given to the integration once and never shown again
+func (ks *Keys) Issue(ctx context.Context, in NewKey) (*Key, error) {
+	if strings.TrimSpace(in.Name) == "" {
+		return nil, ErrName
+	}
+
+	id, err := random(12)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := random(32)
+	if err != nil {
+		return nil, err
+	}
+	secret = "sk_" + secret
+	sealed, err := ks.seal(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	now := clock.Now(ks.Clock)
+	k := &Key{
+		Name:      strings.TrimSpace(in.Name),
+		KeyID:     "ak_" + id,
+		Secret:    secret,
+		Scopes:    in.Scopes,
+		OwnerID:   in.OwnerID,
+		RateLimit: in.RateLimit,
+		CreatedAt: now,
+		UpdatedAt: now,
+		secret:    sealed,
+	}
+
+	query := `insert into api_keys (name, key_id, secret, previous_secret, scopes, owner_id, rate_limit, requests, created_at, updated_at)
+		values (?, ?, ?, '', ?, ?, ?, 0, ?, ?)`
+	args := []interface{}{k.Name, k.KeyID, k.secret, strings.Join(k.Scopes, " "), k.OwnerID, k.RateLimit, k.CreatedAt, k.UpdatedAt}
+
+	if database.IsPostgres(ks.DatabaseType) {
+		err = ks.DB.QueryRowContext(ctx, ks.rebind(query+" returning id"), args...).Scan(&k.ID)
+	} else {
+		var res sql.Result
+		if res, err = ks.DB.ExecContext(ctx, query, args...); err == nil {
+			var id int64
+			id, err = res.LastInsertId()
+			k.ID = int(id)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Rotate gives the key with id a new Secret, returned once like the one of Issue. The previous
+// secret keeps working for grace, so the integration can switch without failing requests; a
+// secret rotated before that is dropped.
+func (ks *Keys) Rotate(ctx context.Context, id int, grace time.Duration) (*Key, error) {
+	k, err := ks.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if k.Revoked() {
+		return nil, ErrRevoked
+	}
+
+	secret, err := random(32)
+	if err != nil {
+		return nil, err
+	}
+	secret = "sk_" + secret
+	sealed, err := ks.seal(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	now := clock.Now(ks.Clock)
+	k.Secret = secret
+	k.previous, k.secret = k.secret, sealed
+	k.PreviousUntil, k.RotatedAt, k.UpdatedAt = now.Add(grace), now, now
+
+	query := ks.rebind("update api_keys set secret = ?, previous_secret = ?, previous_until = ?, rotated_at = ?, updated_at = ? where id = ?")
+	if _, err := ks.DB.ExecContext(ctx, query, k.secret, k.previous, k.PreviousUntil, k.RotatedAt, k.UpdatedAt, k.ID); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Revoke stops the key with id from working, at once and for good
+func (ks *Keys) Revoke(ctx context.Context, id int) error {
+	now := clock.Now(ks.Clock)
+	query := ks.rebind("update api_keys set revoked_at = ?, updated_at = ? where id = ? and revoked_at is null")
+	_, err := ks.DB.ExecContext(ctx, query, now, now, id)
+	return err
+}
+
+// SetScopes replaces the scopes of the key with id, taking effect on its next request
+func (ks *Keys) SetScopes(ctx context.Context, id int, scopes ...string) error {
+	query := ks.rebind("update api_keys set scopes = ?, updated_at = ? where id = ?")
+	_, err := ks.DB.ExecContext(ctx, query, strings.Join(scopes, " "), clock.Now(ks.Clock), id)
+	return err
+}
+
+// SetRateLimit replaces the requests a minute of the key with id, the RateLimit of Keys when 0
+func (ks *Keys) SetRateLimit(ctx context.Context, id, limit int) error {
+	query := ks.rebind("update api_keys set rate_limit = ?, updated_at = ? where id = ?")
+	_, err := ks.DB.ExecContext(ctx, query, limit, clock.Now(ks.Clock), id)
+	return err
+}
+
+// secrets returns the secrets a request signed at now may be made with: the current one, and
+// the previous one during the grace period of a rotation
+func (ks *Keys) secrets(k *Key, now time.Time) ([]string, error) {
+	current, err := ks.open(k.secret)
+	if err != nil {
+		return nil, err
+	}
+	list := []string{current}
+	if k.previous != "" && now.Before(k.PreviousUntil) {
+		previous, err := ks.open(k.previous)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, previous)
+	}
+	return list, nil
+}
+
+// limit is the requests a minute allowed to k
+func (ks *Keys) limit(k *Key) int {
+	if k.RateLimit > 0 {
+		return k.RateLimit
+	}
+	return ks.RateLimit
+}
@@ -0,0 +1,249 @@
+package apikeys
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+	"github.com/namnguyen191/goravel/clock"
+)
+
+func newKeys() *Keys {
+	return New(nil, "postgres", []byte("a secret of the app of 32 bytes!"))
+}
+
+func TestSealOpen(t *testing.T) {
+	ks := newKeys()
+
+	sealed, err := ks.seal("sk_plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sealed, "plain") {
+		t.Error("expected the secret to be encrypted")
+	}
+	if plain, err := ks.open(sealed); err != nil || plain != "sk_plain" {
+		t.Errorf("expected the secret back, got %q %v", plain, err)
+	}
+
+	other := New(nil, "postgres", []byte("another key"))
+	if _, err := other.open(sealed); err != ErrInvalid {
+		t.Errorf("expected another KEY to fail, got %v", err)
+	}
+	if _, err := New(nil, "postgres", nil).seal("sk_plain"); err == nil {
+		t.Error("expected sealing without KEY to fail")
+	}
+}
+
+func TestSignature(t *testing.T) {
+	a := Signature("sk_secret", "POST", "/api/orders?page=2", 1700000000, []byte(`{"id":1}`))
+	if len(a) != 64 {
+		t.Errorf("expected a hex SHA-256 HMAC, got %q", a)
+	}
+	if a != Signature("sk_secret", "POST", "/api/orders?page=2", 1700000000, []byte(`{"id":1}`)) {
+		t.Error("expected the signature to be stable")
+	}
+	for _, b := range []string{
+		Signature("sk_other", "POST", "/api/orders?page=2", 1700000000, []byte(`{"id":1}`)),
+		Signature("sk_secret", "PUT", "/api/orders?page=2", 1700000000, []byte(`{"id":1}`)),
+		Signature("sk_secret", "POST", "/api/orders?page=3", 1700000000, []byte(`{"id":1}`)),
+		Signature("sk_secret", "POST", "/api/orders?page=2", 1700000001, []byte(`{"id":1}`)),
+		Signature("sk_secret", "POST", "/api/orders?page=2", 1700000000, []byte(`{"id":2}`)),
+	} {
+		if b == a {
+			t.Error("expected every part of the request to be signed")
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	r := httptest.NewRequest("POST", "/api/orders", strings.NewReader(`{"id":1}`))
+	if err := Sign(r, "ak_id", "sk_secret", now); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Header.Get(KeyHeader) != "ak_id" || r.Header.Get(TimestampHeader) != "1700000000" {
+		t.Errorf("expected the key and timestamp headers, got %v", r.Header)
+	}
+	if r.Header.Get(SignatureHeader) != Signature("sk_secret", "POST", "/api/orders", now.Unix(), []byte(`{"id":1}`)) {
+		t.Error("expected the signature of the request")
+	}
+	if body, _ := io.ReadAll(r.Body); string(body) != `{"id":1}` {
+		t.Errorf("expected the body to be put back, got %q", body)
+	}
+}
+
+func TestVerifyWindow(t *testing.T) {
+	c := clock.NewTest(time.Unix(1700000000, 0))
+	ks := newKeys()
+	ks.Clock = c
+
+	r := httptest.NewRequest("GET", "/api/orders", nil)
+	if _, err := ks.Verify(r); err != ErrInvalid {
+		t.Errorf("expected an unsigned request to be invalid, got %v", err)
+	}
+
+	// the timestamp is checked before the key is looked up
+	_ = Sign(r, "ak_id", "sk_secret", c.Now().Add(-6*time.Minute))
+	if _, err := ks.Verify(r); err != ErrExpired {
+		t.Errorf("expected an old request to be turned down, got %v", err)
+	}
+	_ = Sign(r, "ak_id", "sk_secret", c.Now().Add(6*time.Minute))
+	if _, err := ks.Verify(r); err != ErrExpired {
+		t.Errorf("expected a request from the future to be turned down, got %v", err)
+	}
+
+	ks.MaxBody = 4
+	r = httptest.NewRequest("POST", "/api/orders", strings.NewReader(`{"id":1}`))
+	_ = Sign(r, "ak_id", "sk_secret", c.Now())
+	if _, err := ks.Verify(r); err != ErrBodyTooLarge {
+		t.Errorf("expected a large body to be turned down, got %v", err)
+	}
+}
+
+func TestSecretsDuringRotation(t *testing.T) {
+	c := clock.NewTest(time.Unix(1700000000, 0))
+	ks := newKeys()
+	ks.Clock = c
+
+	current, _ := ks.seal("sk_new")
+	previous, _ := ks.seal("sk_old")
+	k := &Key{secret: current, previous: previous, PreviousUntil: c.Now().Add(time.Hour)}
+
+	list, err := ks.secrets(k, c.Now())
+	if err != nil || len(list) != 2 || list[0] != "sk_new" || list[1] != "sk_old" {
+		t.Errorf("expected both secrets during the grace period, got %v %v", list, err)
+	}
+
+	list, err = ks.secrets(k, c.Advance(time.Hour))
+	if err != nil || len(list) != 1 || list[0] != "sk_new" {
+		t.Errorf("expected only the new secret after the grace period, got %v %v", list, err)
+	}
+}
+
+func TestCan(t *testing.T) {
+	k := &Key{Scopes: []string{"orders:read"}}
+	if !k.Can("orders:read") || k.Can("orders:write") {
+		t.Error("expected only the scopes of the key")
+	}
+	if !(&Key{Scopes: []string{"*"}}).Can("orders:write") {
+		t.Error("expected * to allow every scope")
+	}
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	c := clock.NewTest(time.Unix(1700000000, 0))
+	l := &MemoryLimiter{Clock: c}
+	ctx := context.Background()
+
+	for i := 1; i <= 3; i++ {
+		n, reset, _ := l.Hit(ctx, "ak_a", time.Minute)
+		if n != i || reset != time.Minute {
+			t.Errorf("expected hit %d with a minute left, got %d %s", i, n, reset)
+		}
+	}
+	if n, _, _ := l.Hit(ctx, "ak_b", time.Minute); n != 1 {
+		t.Errorf("expected keys to be counted apart, got %d", n)
+	}
+
+	c.Advance(40 * time.Second)
+	if n, reset, _ := l.Hit(ctx, "ak_a", time.Minute); n != 4 || reset != 20*time.Second {
+		t.Errorf("expected the same window, got %d %s", n, reset)
+	}
+	c.Advance(20 * time.Second)
+	if n, _, _ := l.Hit(ctx, "ak_a", time.Minute); n != 1 {
+		t.Errorf("expected a new window, got %d", n)
+	}
+}
+
+func TestRedisLimiter(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	pool := &redis.Pool{Dial: func() (redis.Conn, error) { return redis.Dial("tcp", s.Addr()) }}
+	l := &RedisLimiter{Pool: pool, Prefix: "myapp"}
+	ctx := context.Background()
+
+	for i := 1; i <= 2; i++ {
+		n, reset, err := l.Hit(ctx, "ak_a", time.Minute)
+		if err != nil || n != i || reset <= 0 || reset > time.Minute {
+			t.Errorf("expected hit %d within a minute, got %d %s %v", i, n, reset, err)
+		}
+	}
+	if !s.Exists("myapp:apikeys:ak_a") {
+		t.Error("expected the counter under the prefix")
+	}
+
+	s.FastForward(time.Minute)
+	if n, _, _ := l.Hit(ctx, "ak_a", time.Minute); n != 1 {
+		t.Errorf("expected a new window, got %d", n)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	ks := newKeys()
+	h := ks.Authenticate(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		t.Error("expected an unsigned request to be turned down")
+	}))
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "/api/orders", nil))
+	if rw.Code != http.StatusUnauthorized || !strings.Contains(rw.Body.String(), `"error":true`) {
+		t.Errorf("expected a 401 json error, got %d %s", rw.Code, rw.Body)
+	}
+
+	scoped := RequireScope("orders:write")(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	r := httptest.NewRequest("POST", "/api/orders", nil)
+
+	rw = httptest.NewRecorder()
+	scoped.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), keyContext{}, &Key{Scopes: []string{"orders:read"}})))
+	if rw.Code != http.StatusForbidden {
+		t.Errorf("expected a key without the scope to get a 403, got %d", rw.Code)
+	}
+
+	rw = httptest.NewRecorder()
+	scoped.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), keyContext{}, &Key{Scopes: []string{"orders:write"}})))
+	if rw.Code != http.StatusNoContent {
+		t.Errorf("expected a key with the scope to be let through, got %d", rw.Code)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	c := clock.NewTest(time.Date(2026, 3, 14, 23, 59, 0, 0, time.UTC))
+	ks := newKeys()
+	ks.Clock = c
+
+	k := &Key{ID: 7}
+	ks.record(k)
+	ks.record(k)
+	c.Advance(2 * time.Minute)
+	ks.record(k)
+
+	if ks.usage[usageKey{7, "2026-03-14"}] != 2 || ks.usage[usageKey{7, "2026-03-15"}] != 1 {
+		t.Errorf("expected the requests counted by day, got %v", ks.usage)
+	}
+	if !ks.last[7].Equal(c.Now()) {
+		t.Errorf("expected the time of the last request, got %s", ks.last[7])
+	}
+
+	// counts a failed Flush could not write are added to the ones since
+	usage, last := ks.usage, ks.last
+	ks.usage, ks.last = nil, nil
+	ks.record(k)
+	ks.restore(usage, last)
+	if ks.usage[usageKey{7, "2026-03-15"}] != 2 || ks.usage[usageKey{7, "2026-03-14"}] != 2 {
+		t.Errorf("expected the counts to be put back, got %v", ks.usage)
+	}
+}
@@ -0,0 +1,87 @@
+package apikeys
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/namnguyen191/goravel/clock"
+)
+
+// Limiter counts the requests of a key in fixed windows
+type Limiter interface {
+	// Hit counts a request of name, returning the requests counted in the current window and
+	// how long until it ends
+	Hit(ctx context.Context, name string, window time.Duration) (int, time.Duration, error)
+}
+
+// hit counts a request and starts the window with the first one, returning the count and the
+// time left in ms
+var hit = redis.NewScript(1, `
+local n = redis.call("INCR", KEYS[1])
+if n == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return {n, redis.call("PTTL", KEYS[1])}`)
+
+// RedisLimiter counts the requests in redis, so the limits hold across instances
+type RedisLimiter struct {
+	Pool   *redis.Pool
+	Prefix string
+}
+
+func (l *RedisLimiter) Hit(ctx context.Context, name string, window time.Duration) (int, time.Duration, error) {
+	conn, err := l.Pool.GetContext(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Close()
+
+	values, err := redis.Int64s(hit.DoContext(ctx, conn, l.Prefix+":apikeys:"+name, window.Milliseconds()))
+	if err != nil {
+		return 0, 0, err
+	}
+	ttl := time.Duration(values[1]) * time.Millisecond
+	if ttl < 0 {
+		ttl = window
+	}
+	return int(values[0]), ttl, nil
+}
+
+// MemoryLimiter counts the requests in the process, for tests and single instance apps
+type MemoryLimiter struct {
+	// Clock starts and ends the windows; the time of the machine when nil
+	Clock clock.Clock
+
+	mu      sync.Mutex
+	windows map[string]*window
+}
+
+type window struct {
+	count int
+	ends  time.Time
+}
+
+func (l *MemoryLimiter) Hit(ctx context.Context, name string, d time.Duration) (int, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := clock.Now(l.Clock)
+	if l.windows == nil {
+		l.windows = map[string]*window{}
+	}
+	for n, w := range l.windows {
+		if !now.Before(w.ends) {
+			delete(l.windows, n)
+		}
+	}
+
+	w := l.windows[name]
+	if w == nil {
+		w = &window{ends: now.Add(d)}
+		l.windows[name] = w
+	}
+	w.count++
+	return w.count, w.ends.Sub(now), nil
+}
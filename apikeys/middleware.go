@@ -0,0 +1,85 @@
+package apikeys
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// failed answers the json error of the middleware, like the one of the token middleware of auth
+func failed(rw http.ResponseWriter, status int, message string) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	_ = json.NewEncoder(rw).Encode(map[string]interface{}{"error": true, "message": message})
+}
+
+// Authenticate lets through the requests signed with a valid key within its rate limit,
+// putting the key in the request context for From; it counts the request for Usage. Routes of
+// integrations go under the CSRF-exempt /api prefix, e.g.
+// r.With(app.APIKeys.Authenticate, apikeys.RequireScope("orders:read")).
+func (ks *Keys) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		k, err := ks.Verify(r)
+		switch {
+		case errors.Is(err, ErrInvalid), errors.Is(err, ErrRevoked), errors.Is(err, ErrExpired):
+			failed(rw, http.StatusUnauthorized, "invalid api key or signature")
+			return
+		case errors.Is(err, ErrBodyTooLarge):
+			failed(rw, http.StatusRequestEntityTooLarge, http.StatusText(http.StatusRequestEntityTooLarge))
+			return
+		case err != nil:
+			failed(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+
+		if limit := ks.limit(k); limit > 0 && ks.Limiter != nil {
+			n, reset, err := ks.Limiter.Hit(r.Context(), k.KeyID, time.Minute)
+			if err != nil {
+				failed(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+				return
+			}
+
+			seconds := strconv.Itoa(int(math.Ceil(reset.Seconds())))
+			rw.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit))
+			rw.Header().Set("X-RateLimit-Remaining", strconv.Itoa(max(limit-n, 0)))
+			rw.Header().Set("X-RateLimit-Reset", seconds)
+			if n > limit {
+				rw.Header().Set("Retry-After", seconds)
+				failed(rw, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+		}
+
+		ks.record(k)
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), keyContext{}, k)))
+	})
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// RequireScope lets through the requests whose key has scope; it goes after Authenticate
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			k := From(r.Context())
+			if k == nil {
+				failed(rw, http.StatusUnauthorized, "invalid api key or signature")
+				return
+			}
+			if !k.Can(scope) {
+				failed(rw, http.StatusForbidden, "the api key is missing the scope "+scope)
+				return
+			}
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
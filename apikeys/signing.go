@@ -0,0 +1,138 @@
+package apikeys
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+)
+
+// The headers of a signed request
+const (
+	KeyHeader       = "X-Api-Key"
+	TimestampHeader = "X-Api-Timestamp"
+	SignatureHeader = "X-Api-Signature"
+)
+
+// ErrBodyTooLarge is returned for a signed request with a body past MaxBody
+var ErrBodyTooLarge = errors.New("apikeys: request body too large")
+
+// Signature is the hex HMAC-SHA256 with secret of the method, the path with its query, the
+// unix timestamp and the hex SHA-256 of the body, each on their own line. Integrations in
+// other languages compute the same to sign their requests.
+func Signature(secret, method, uri string, timestamp int64, body []byte) string {
+	sum := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(method + "\n" + uri + "\n" + strconv.FormatInt(timestamp, 10) + "\n" + hex.EncodeToString(sum[:])))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sign adds the headers of a request signed at t with the key keyID and its secret, e.g. in
+// the Go client of an integration or in tests; the body is read and put back
+func Sign(r *http.Request, keyID, secret string, t time.Time) error {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return err
+		}
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	r.Header.Set(KeyHeader, keyID)
+	r.Header.Set(TimestampHeader, strconv.FormatInt(t.Unix(), 10))
+	r.Header.Set(SignatureHeader, Signature(secret, r.Method, r.URL.RequestURI(), t.Unix(), body))
+	return nil
+}
+
+// Verify returns the key a request was signed with, checking its timestamp is within Window
+// of now and its signature is made with the secret of the key, or the previous one during the
+// grace period of a rotation. The body is read and put back for the handlers.
+func (ks *Keys) Verify(r *http.Request) (*Key, error) {
+	keyID := r.Header.Get(KeyHeader)
+	signature, err := hex.DecodeString(r.Header.Get(SignatureHeader))
+	if keyID == "" || err != nil || len(signature) == 0 {
+		return nil, ErrInvalid
+	}
+
+	timestamp, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	now := clock.Now(ks.Clock)
+	window := ks.Window
+	if window <= 0 {
+		window = 5 * time.Minute
+	}
+	if d := now.Sub(time.Unix(timestamp, 0)); d > window || d < -window {
+		return nil, ErrExpired
+	}
+
+	body, err := ks.body(r)
+	if err != nil {
+		return nil, err
+	}
+
+	k, err := ks.FindByKeyID(r.Context(), keyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if k.Revoked() {
+		return nil, ErrRevoked
+	}
+
+	secrets, err := ks.secrets(k, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, secret := range secrets {
+		want, _ := hex.DecodeString(Signature(secret, r.Method, r.URL.RequestURI(), timestamp, body))
+		if hmac.Equal(signature, want) {
+			return k, nil
+		}
+	}
+	return nil, ErrInvalid
+}
+
+// body reads the body of r up to MaxBody, putting it back for the handlers
+func (ks *Keys) body(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	max := ks.MaxBody
+	if max <= 0 {
+		max = 10 << 20
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, max+1))
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > max {
+		return nil, ErrBodyTooLarge
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+type keyContext struct{}
+
+// From returns the key of the request context set by Authenticate, or nil
+func From(ctx context.Context) *Key {
+	k, _ := ctx.Value(keyContext{}).(*Key)
+	return k
+}
@@ -0,0 +1,112 @@
+package apikeys
+
+import (
+	"context"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+)
+
+// Day is the use of a key on a day
+type Day struct {
+	Day      time.Time
+	Requests int64
+}
+
+// record counts a request of k in the process, written with the next Flush so a request does
+// not wait on the database
+func (ks *Keys) record(k *Key) {
+	now := clock.Now(ks.Clock)
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if ks.usage == nil {
+		ks.usage = map[usageKey]int64{}
+		ks.last = map[int]time.Time{}
+	}
+	ks.usage[usageKey{id: k.ID, day: now.UTC().Format("2006-01-02")}]++
+	ks.last[k.ID] = now
+}
+
+// Flush writes the requests counted since the last one to api_key_usage, and adds them to the
+// requests of the keys with the time they were last used. The app runs it every minute on
+// every instance and when it shuts down; counts which could not be written are kept for the
+// next one.
+func (ks *Keys) Flush(ctx context.Context) error {
+	ks.mu.Lock()
+	usage, last := ks.usage, ks.last
+	ks.usage, ks.last = nil, nil
+	ks.mu.Unlock()
+
+	upsert := "insert into api_key_usage (api_key_id, day, requests) values (?, ?, ?) " +
+		"on duplicate key update requests = requests + values(requests)"
+	if database.IsPostgres(ks.DatabaseType) {
+		upsert = "insert into api_key_usage (api_key_id, day, requests) values (?, ?, ?) " +
+			"on conflict (api_key_id, day) do update set requests = api_key_usage.requests + excluded.requests"
+	}
+
+	var failed error
+	totals := map[int]int64{}
+	for key, n := range usage {
+		if _, err := ks.DB.ExecContext(ctx, ks.rebind(upsert), key.id, key.day, n); err != nil {
+			failed = err
+			break
+		}
+		delete(usage, key)
+		totals[key.id] += n
+	}
+	if failed != nil {
+		ks.restore(usage, last)
+	}
+
+	// the counts by day are written, so a failure here only loses the totals of the keys
+	query := ks.rebind("update api_keys set requests = requests + ?, last_used_at = ? where id = ?")
+	for id, n := range totals {
+		if _, err := ks.DB.ExecContext(ctx, query, n, last[id], id); err != nil && failed == nil {
+			failed = err
+		}
+	}
+	return failed
+}
+
+// restore puts back the counts a Flush did not write, adding them to the ones since
+func (ks *Keys) restore(usage map[usageKey]int64, last map[int]time.Time) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if ks.usage == nil {
+		ks.usage = map[usageKey]int64{}
+		ks.last = map[int]time.Time{}
+	}
+	for key, n := range usage {
+		ks.usage[key] += n
+	}
+	for id, t := range last {
+		if t.After(ks.last[id]) {
+			ks.last[id] = t
+		}
+	}
+}
+
+// Usage returns the requests made with the key with id each day from since, as written by
+// Flush, the oldest first; days without requests are left out
+func (ks *Keys) Usage(ctx context.Context, id int, since time.Time) ([]Day, error) {
+	query := ks.rebind("select day, requests from api_key_usage where api_key_id = ? and day >= ? order by day")
+	rows, err := ks.DB.QueryContext(ctx, query, id, since.UTC().Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []Day
+	for rows.Next() {
+		var d Day
+		if err := rows.Scan(&d.Day, &d.Requests); err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
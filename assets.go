@@ -0,0 +1,48 @@
+package goravel
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/namnguyen191/goravel/assets"
+)
+
+// createAssets builds the bundles of the manifest at ASSETS_MANIFEST, assets.json by default,
+// into public/bundles; views reference them with asset, stylesheet and script. Apps without a
+// manifest get no pipeline. When the bundles cannot be written, e.g. on a read-only file system,
+// the ones built ahead with "goravel assets:build" are used.
+func (grv *Goravel) createAssets() *assets.Assets {
+	if _, err := os.Stat(grv.assetsManifest()); err != nil {
+		return nil
+	}
+
+	a := assets.New(grv.RootPath)
+	a.Minify = grv.Env.Bool("ASSETS_MINIFY", !grv.Debug)
+	a.URLFunc = grv.CDN.URL
+
+	if err := grv.buildAssets(a); err != nil {
+		grv.ErrorLog.Println("assets:", err)
+		if err := a.Load(); err != nil {
+			grv.ErrorLog.Println("assets:", err)
+		}
+	}
+
+	return a
+}
+
+func (grv *Goravel) assetsManifest() string {
+	manifest := grv.Env.String("ASSETS_MANIFEST", "assets.json")
+	if !filepath.IsAbs(manifest) {
+		manifest = filepath.Join(grv.RootPath, manifest)
+	}
+	return manifest
+}
+
+// buildAssets reads the manifest again, so bundles added while the app runs are built too
+func (grv *Goravel) buildAssets(a *assets.Assets) error {
+	bundles, err := assets.ReadManifest(grv.assetsManifest())
+	if err != nil {
+		return err
+	}
+	return a.Build(bundles)
+}
@@ -0,0 +1,200 @@
+package assets
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// manifestFile records the built bundles in the bundles directory, so an app deployed with
+// prebuilt bundles can find them without building
+const manifestFile = "manifest.json"
+
+// Assets concatenates the CSS and JS files of bundles into one file each, minified and named
+// after a hash of their content so they can be cached forever. Bundles are listed in a
+// manifest keyed by bundle name, with files relative to the app root:
+//
+//	{
+//		"app.css": ["assets/css/reset.css", "assets/css/app.css"],
+//		"app.js": ["assets/js/htmx.js", "assets/js/app.js"]
+//	}
+type Assets struct {
+	// Root is the app root, which the files of the manifest are relative to
+	Root string
+	// Dir is where bundles are written, by default public/bundles of Root
+	Dir string
+	// BaseURL is the url Dir is served at, by default /public/bundles
+	BaseURL string
+	// Minify is on by default; without it files are only concatenated, e.g. while debugging
+	Minify bool
+	// URLFunc rewrites the urls of bundles, e.g. to serve them from a CDN
+	URLFunc func(path string) string
+
+	mu    sync.RWMutex
+	built map[string]string
+}
+
+// New returns a pipeline writing minified bundles to root/public/bundles
+func New(root string) *Assets {
+	return &Assets{
+		Root:    root,
+		Dir:     filepath.Join(root, "public", "bundles"),
+		BaseURL: "/public/bundles",
+		Minify:  true,
+		built:   map[string]string{},
+	}
+}
+
+// ReadManifest reads the bundles of a manifest file
+func ReadManifest(file string) (map[string][]string, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest map[string][]string
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("assets: %s: %w", file, err)
+	}
+	return manifest, nil
+}
+
+// Build writes the bundles of manifest and removes older builds of them
+func (a *Assets) Build(manifest map[string][]string) error {
+	if err := os.MkdirAll(a.Dir, 0755); err != nil {
+		return err
+	}
+
+	built := map[string]string{}
+	for name, files := range manifest {
+		file, err := a.bundle(name, files)
+		if err != nil {
+			return err
+		}
+		built[name] = file
+	}
+
+	data, err := json.MarshalIndent(built, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(a.Dir, manifestFile), data, 0644); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.built = built
+	a.mu.Unlock()
+
+	return nil
+}
+
+func (a *Assets) bundle(name string, files []string) (string, error) {
+	ext := path.Ext(name)
+	if ext != ".css" && ext != ".js" {
+		return "", fmt.Errorf("assets: %s: only .css and .js bundles are supported", name)
+	}
+
+	var buf bytes.Buffer
+	for _, f := range files {
+		data, err := ioutil.ReadFile(filepath.Join(a.Root, f))
+		if err != nil {
+			return "", fmt.Errorf("assets: %s: %w", name, err)
+		}
+
+		if a.Minify {
+			if ext == ".css" {
+				data = MinifyCSS(data)
+			} else {
+				data = MinifyJS(data)
+			}
+		}
+
+		buf.Write(data)
+		// a file without a trailing semicolon must not run into the next one
+		if ext == ".js" {
+			buf.WriteString(";\n")
+		} else {
+			buf.WriteByte('\n')
+		}
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	base := strings.TrimSuffix(name, ext)
+	file := base + "." + hex.EncodeToString(sum[:])[:12] + ext
+
+	if err := ioutil.WriteFile(filepath.Join(a.Dir, file), buf.Bytes(), 0644); err != nil {
+		return "", err
+	}
+
+	// earlier builds of the bundle are replaced
+	old, _ := filepath.Glob(filepath.Join(a.Dir, base+".*"+ext))
+	for _, o := range old {
+		hash := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(o), base+"."), ext)
+		if _, err := hex.DecodeString(hash); err == nil && len(hash) == 12 && filepath.Base(o) != file {
+			_ = os.Remove(o)
+		}
+	}
+
+	return file, nil
+}
+
+// Load reads the bundles written by an earlier Build, e.g. with "goravel assets:build"
+func (a *Assets) Load() error {
+	data, err := ioutil.ReadFile(filepath.Join(a.Dir, manifestFile))
+	if err != nil {
+		return err
+	}
+
+	built := map[string]string{}
+	if err := json.Unmarshal(data, &built); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.built = built
+	a.mu.Unlock()
+
+	return nil
+}
+
+// URL returns the url of the current build of the bundle name; a name which is not a bundle
+// is returned under BaseURL unchanged
+func (a *Assets) URL(name string) string {
+	a.mu.RLock()
+	file, ok := a.built[name]
+	a.mu.RUnlock()
+	if !ok {
+		file = name
+	}
+
+	u := strings.TrimSuffix(a.BaseURL, "/") + "/" + file
+	if a.URLFunc != nil {
+		return a.URLFunc(u)
+	}
+	return u
+}
+
+// TemplateFuncs provides the asset helper to views: {{ asset("app.css") }}, and stylesheet
+// and script to write the tags of a bundle
+func (a *Assets) TemplateFuncs(r *http.Request) template.FuncMap {
+	return template.FuncMap{
+		"asset": a.URL,
+		"stylesheet": func(name string) template.HTML {
+			return template.HTML(`<link rel="stylesheet" href="` + template.HTMLEscapeString(a.URL(name)) + `">`)
+		},
+		"script": func(name string) template.HTML {
+			return template.HTML(`<script src="` + template.HTMLEscapeString(a.URL(name)) + `" defer></script>`)
+		},
+	}
+}
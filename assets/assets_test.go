@@ -0,0 +1,155 @@
+package assets
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMinifyCSS(t *testing.T) {
+	src := `/* reset */
+body ,  p {
+	margin : 0;
+	font-family: "Helvetica  Neue", sans-serif;
+}
+
+/*! license */
+a > b:hover { width: calc(1px + 2px); }
+`
+	want := `body,p{margin : 0;font-family: "Helvetica  Neue",sans-serif}/*! license */a>b:hover{width: calc(1px + 2px)}`
+	if got := string(MinifyCSS([]byte(src))); got != want {
+		t.Errorf("unexpected css\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestMinifyJS(t *testing.T) {
+	src := `// greet the user
+function greet(name) {
+    /* keep "quotes" */
+    var re = /\/*not a comment/g;
+    var half = total / 2; // divide
+    return "hello  // " + name + ` + "`  ${name}  `" + `
+}
+`
+	want := "function greet(name) {\nvar re = /\\/*not a comment/g;\nvar half = total / 2;\nreturn \"hello  // \" + name + `  ${name}  `\n}"
+	if got := string(MinifyJS([]byte(src))); got != want {
+		t.Errorf("unexpected js\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(root, name)
+		_ = os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("assets/a.css", "a { color: red; }")
+	write("assets/b.css", "b { color: blue; }")
+	write("assets/app.js", "var x = 1 // one")
+
+	a := New(root)
+	err := a.Build(map[string][]string{
+		"app.css": {"assets/a.css", "assets/b.css"},
+		"app.js":  {"assets/app.js"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := a.URL("app.css")
+	if !strings.HasPrefix(u, "/public/bundles/app.") || !strings.HasSuffix(u, ".css") {
+		t.Fatalf("unexpected url %s", u)
+	}
+	data, _ := ioutil.ReadFile(filepath.Join(a.Dir, strings.TrimPrefix(u, "/public/bundles/")))
+	if string(data) != "a{color: red}\nb{color: blue}\n" {
+		t.Errorf("unexpected bundle %q", data)
+	}
+
+	// a change gives the bundle a new name and removes the old one
+	write("assets/b.css", "b { color: green; }")
+	if err := a.Build(map[string][]string{"app.css": {"assets/a.css", "assets/b.css"}}); err != nil {
+		t.Fatal(err)
+	}
+	if a.URL("app.css") == u {
+		t.Error("expected a new hash")
+	}
+	if _, err := os.Stat(filepath.Join(a.Dir, strings.TrimPrefix(u, "/public/bundles/"))); !os.IsNotExist(err) {
+		t.Error("expected the old bundle to be removed")
+	}
+
+	loaded := New(root)
+	if err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.URL("app.css") != a.URL("app.css") {
+		t.Error("expected Load to find the built bundle")
+	}
+	if got := loaded.URL("logo.png"); got != "/public/bundles/logo.png" {
+		t.Errorf("unexpected url for a file which is not a bundle: %s", got)
+	}
+}
+
+func TestMinifyHTML(t *testing.T) {
+	src := `<!doctype html>
+<html>
+  <head>
+    <!-- the title -->
+    <title>  Home  </title>
+    <style>
+      body { margin: 0; }
+    </style>
+    <!--[if IE]><p>old</p><![endif]-->
+  </head>
+  <body   class="home"  data-x = "a  b" >
+    <b>bold</b>   <i>italic</i>
+    <pre>
+  keep   this
+    </pre>
+    <textarea name="t">  as  typed </textarea>
+    <script>
+      // greet
+      var a = 1;
+    </script>
+    <script type="text/template"><p>  {{ x }}  </p></script>
+  </body>
+</html>
+`
+	want := `<!doctype html> <html> <head> <title> Home </title> <style>body{margin: 0}</style> <!--[if IE]><p>old</p><![endif]--> </head> <body class="home" data-x = "a  b"> <b>bold</b> <i>italic</i> <pre>
+  keep   this
+    </pre> <textarea name="t">  as  typed </textarea> <script>var a = 1;</script> <script type="text/template"><p>  {{ x }}  </p></script> </body> </html>`
+	if got := string(MinifyHTML([]byte(src))); got != want {
+		t.Errorf("unexpected html\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestMinifyResponses(t *testing.T) {
+	handler := MinifyResponses(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api" {
+			rw.Header().Set("Content-Type", "application/json")
+			_, _ = rw.Write([]byte("{\n  \"a\": 1\n}"))
+			return
+		}
+		rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+		rw.WriteHeader(http.StatusAccepted)
+		_, _ = rw.Write([]byte("<p>\n  hello\n</p>\n"))
+	}))
+
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	if rw.Code != http.StatusAccepted || rw.Body.String() != "<p> hello </p>" {
+		t.Errorf("unexpected page %d %q", rw.Code, rw.Body.String())
+	}
+
+	rw = httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest("GET", "/api", nil))
+	if rw.Body.String() != "{\n  \"a\": 1\n}" {
+		t.Errorf("expected json untouched, got %q", rw.Body.String())
+	}
+}
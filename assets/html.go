@@ -0,0 +1,245 @@
+package assets
+
+import (
+	"bytes"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// rawTags keep their content as written, apart from minifying scripts and styles
+var rawTags = map[string]bool{"pre": true, "textarea": true, "script": true, "style": true}
+
+var scriptType = regexp.MustCompile(`(?i)\stype\s*=\s*["']?([^"'\s>]+)`)
+
+// MinifyHTML collapses whitespace, removes comments other than conditional comments and
+// minifies inline styles and scripts. The content of pre and textarea is left alone, and
+// whitespace between inline elements is kept as a single space so the page looks the same.
+func MinifyHTML(src []byte) []byte {
+	var out bytes.Buffer
+	out.Grow(len(src))
+
+	space := false
+	for i := 0; i < len(src); {
+		c := src[i]
+
+		if c != '<' {
+			if isSpace(c) {
+				space = true
+			} else {
+				if space {
+					out.WriteByte(' ')
+					space = false
+				}
+				out.WriteByte(c)
+			}
+			i++
+			continue
+		}
+
+		if bytes.HasPrefix(src[i:], []byte("<!--")) {
+			end := bytes.Index(src[i+4:], []byte("-->"))
+			if end < 0 {
+				end = len(src)
+			} else {
+				end += i + 7
+			}
+			if bytes.HasPrefix(src[i:], []byte("<!--[if")) || bytes.HasPrefix(src[i:], []byte("<!--<![endif")) {
+				flushHTMLSpace(&out, &space)
+				out.Write(src[i:end])
+			}
+			i = end
+			continue
+		}
+
+		name, closing := tagName(src[i+1:])
+		if name == "" {
+			flushHTMLSpace(&out, &space)
+			out.WriteByte(c)
+			i++
+			continue
+		}
+
+		end := tagEnd(src, i)
+		flushHTMLSpace(&out, &space)
+		tag := src[i:end]
+		out.Write(minifyTag(tag))
+		i = end
+
+		if closing || !rawTags[name] {
+			continue
+		}
+
+		closeAt := indexFold(src[i:], "</"+name)
+		if closeAt < 0 {
+			closeAt = len(src) - i
+		}
+		content := src[i : i+closeAt]
+		switch name {
+		case "style":
+			content = MinifyCSS(content)
+		case "script":
+			if isJavaScript(tag) {
+				content = MinifyJS(content)
+			}
+		}
+		out.Write(content)
+		i += closeAt
+	}
+
+	return bytes.TrimSpace(out.Bytes())
+}
+
+func flushHTMLSpace(out *bytes.Buffer, space *bool) {
+	if *space && out.Len() > 0 {
+		out.WriteByte(' ')
+	}
+	*space = false
+}
+
+// tagName returns the lower case name of the tag src starts with, after the <
+func tagName(src []byte) (string, bool) {
+	closing := false
+	if len(src) > 0 && src[0] == '/' {
+		closing = true
+		src = src[1:]
+	}
+
+	n := 0
+	for n < len(src) && (src[n] >= 'a' && src[n] <= 'z' || src[n] >= 'A' && src[n] <= 'Z' || n > 0 && (src[n] == '-' || src[n] >= '0' && src[n] <= '9')) {
+		n++
+	}
+	return strings.ToLower(string(src[:n])), closing
+}
+
+// tagEnd returns the index after the > closing the tag starting at src[start], skipping
+// quoted attribute values
+func tagEnd(src []byte, start int) int {
+	for i := start + 1; i < len(src); i++ {
+		switch src[i] {
+		case '"', '\'':
+			i = skipString(src, i, src[i]) - 1
+		case '>':
+			return i + 1
+		}
+	}
+	return len(src)
+}
+
+// minifyTag collapses the whitespace between attributes
+func minifyTag(tag []byte) []byte {
+	var out bytes.Buffer
+	space := false
+	for i := 0; i < len(tag); i++ {
+		c := tag[i]
+		switch {
+		case c == '"' || c == '\'':
+			if space {
+				out.WriteByte(' ')
+				space = false
+			}
+			end := skipString(tag, i, c)
+			out.Write(tag[i:end])
+			i = end - 1
+		case isSpace(c):
+			space = true
+		case c == '>' || c == '/' && i+1 < len(tag) && tag[i+1] == '>':
+			space = false
+			out.WriteByte(c)
+		default:
+			if space {
+				out.WriteByte(' ')
+				space = false
+			}
+			out.WriteByte(c)
+		}
+	}
+	return out.Bytes()
+}
+
+func isJavaScript(tag []byte) bool {
+	m := scriptType.FindSubmatch(tag)
+	if m == nil {
+		return true
+	}
+	t := strings.ToLower(string(m[1]))
+	return t == "module" || strings.Contains(t, "javascript") || strings.Contains(t, "ecmascript")
+}
+
+func indexFold(s []byte, sub string) int {
+	return bytes.Index(bytes.ToLower(s), []byte(sub))
+}
+
+// MinifyResponses minifies the HTML responses of next; other responses, and responses which
+// are already compressed, are passed through as they are
+func MinifyResponses(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		w := &htmlWriter{ResponseWriter: rw, status: http.StatusOK}
+		next.ServeHTTP(w, r)
+		w.finish()
+	})
+}
+
+type htmlWriter struct {
+	http.ResponseWriter
+	status  int
+	decided bool
+	html    bool
+	buf     bytes.Buffer
+}
+
+func (w *htmlWriter) decide(p []byte) {
+	if w.decided {
+		return
+	}
+	w.decided = true
+
+	h := w.Header()
+	ct := h.Get("Content-Type")
+	if ct == "" && len(p) > 0 {
+		ct = http.DetectContentType(p)
+	}
+	w.html = strings.HasPrefix(ct, "text/html") && h.Get("Content-Encoding") == ""
+	if !w.html {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+func (w *htmlWriter) WriteHeader(status int) {
+	if w.decided {
+		return
+	}
+	w.status = status
+	if w.Header().Get("Content-Type") != "" {
+		w.decide(nil)
+	}
+}
+
+func (w *htmlWriter) Write(p []byte) (int, error) {
+	w.decide(p)
+	if w.html {
+		return w.buf.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush sends what was written so far of responses which are not minified, e.g. event streams
+func (w *htmlWriter) Flush() {
+	if !w.decided || w.html {
+		return
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *htmlWriter) finish() {
+	w.decide(nil)
+	if !w.html {
+		return
+	}
+
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+	_, _ = w.ResponseWriter.Write(MinifyHTML(w.buf.Bytes()))
+}
@@ -0,0 +1,226 @@
+package assets
+
+import (
+	"bytes"
+	"strings"
+)
+
+// MinifyCSS removes comments, except /*! license comments, and the whitespace which does
+// not change the meaning of the stylesheet
+func MinifyCSS(src []byte) []byte {
+	var out bytes.Buffer
+	out.Grow(len(src))
+
+	space := false
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+
+		switch {
+		case c == '"' || c == '\'':
+			if space {
+				writeSpace(&out)
+				space = false
+			}
+			end := skipString(src, i, c)
+			out.Write(src[i:end])
+			i = end - 1
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := bytes.Index(src[i+2:], []byte("*/"))
+			if end < 0 {
+				return out.Bytes()
+			}
+			end += i + 4
+			if i+2 < len(src) && src[i+2] == '!' {
+				out.Write(src[i:end])
+				for end < len(src) && isSpace(src[end]) {
+					end++
+				}
+				space = false
+			}
+			i = end - 1
+		case isSpace(c):
+			space = true
+		case strings.IndexByte("{};,>", c) >= 0:
+			space = false
+			if c == '}' {
+				trimByte(&out, ';')
+			}
+			trimByte(&out, ' ')
+			out.WriteByte(c)
+			// so "a , b" loses the space after the comma too
+			for i+1 < len(src) && isSpace(src[i+1]) {
+				i++
+			}
+		default:
+			if space {
+				writeSpace(&out)
+				space = false
+			}
+			out.WriteByte(c)
+		}
+	}
+
+	return bytes.TrimSpace(out.Bytes())
+}
+
+// MinifyJS removes comments, except /*! license comments, and leading, trailing and repeated
+// whitespace. Line breaks are kept, so code relying on automatic semicolon insertion still works.
+func MinifyJS(src []byte) []byte {
+	var out bytes.Buffer
+	out.Grow(len(src))
+
+	space, newline := false, false
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+
+		if c == '\n' || c == '\r' {
+			newline = true
+			continue
+		}
+		if isSpace(c) {
+			space = true
+			continue
+		}
+
+		if c == '/' && i+1 < len(src) && src[i+1] == '/' {
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			newline = true
+			continue
+		}
+		if c == '/' && i+1 < len(src) && src[i+1] == '*' {
+			end := bytes.Index(src[i+2:], []byte("*/"))
+			if end < 0 {
+				return out.Bytes()
+			}
+			end += i + 4
+			if i+2 < len(src) && src[i+2] == '!' {
+				flushSpace(&out, newline, space)
+				newline, space = false, false
+				out.Write(src[i:end])
+			} else {
+				space = true
+			}
+			i = end - 1
+			continue
+		}
+
+		flushSpace(&out, newline, space)
+		newline, space = false, false
+
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			end := skipString(src, i, c)
+			out.Write(src[i:end])
+			i = end - 1
+		case c == '/' && regexAllowed(out.Bytes()):
+			end := skipRegex(src, i)
+			out.Write(src[i:end])
+			i = end - 1
+		default:
+			out.WriteByte(c)
+		}
+	}
+
+	return out.Bytes()
+}
+
+// flushSpace writes the whitespace skipped before a token, a line break winning over a space
+func flushSpace(out *bytes.Buffer, newline, space bool) {
+	b := out.Bytes()
+	if len(b) == 0 || !newline && !space {
+		return
+	}
+
+	last := b[len(b)-1]
+	switch {
+	case newline && last == ' ':
+		out.Truncate(len(b) - 1)
+		out.WriteByte('\n')
+	case newline && last != '\n':
+		out.WriteByte('\n')
+	case !newline && last != ' ' && last != '\n':
+		out.WriteByte(' ')
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func writeSpace(out *bytes.Buffer) {
+	b := out.Bytes()
+	if len(b) == 0 || strings.IndexByte("{};,>", b[len(b)-1]) >= 0 {
+		return
+	}
+	out.WriteByte(' ')
+}
+
+func trimByte(out *bytes.Buffer, c byte) {
+	if b := out.Bytes(); len(b) > 0 && b[len(b)-1] == c {
+		out.Truncate(len(b) - 1)
+	}
+}
+
+// skipString returns the index after the string starting at src[start]
+func skipString(src []byte, start int, quote byte) int {
+	for i := start + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		}
+	}
+	return len(src)
+}
+
+// skipRegex returns the index after the regular expression literal starting at src[start]
+func skipRegex(src []byte, start int) int {
+	class := false
+	for i := start + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '[':
+			class = true
+		case ']':
+			class = false
+		case '\n':
+			// not a regular expression after all
+			return i
+		case '/':
+			if !class {
+				return i + 1
+			}
+		}
+	}
+	return len(src)
+}
+
+// regexAllowed reports whether a slash after out starts a regular expression rather than
+// dividing, going by the last token written
+func regexAllowed(out []byte) bool {
+	b := bytes.TrimRight(out, " \n")
+	if len(b) == 0 {
+		return true
+	}
+	if strings.IndexByte("(,=:[!&|?{};+-*%<>~^", b[len(b)-1]) >= 0 {
+		return true
+	}
+
+	for _, keyword := range []string{"return", "typeof", "case", "do", "else", "in", "of", "void"} {
+		if bytes.HasSuffix(b, []byte(keyword)) {
+			before := len(b) - len(keyword) - 1
+			if before < 0 || !isIdent(b[before]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isIdent(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,51 @@
+package goravel
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/namnguyen191/goravel/auth"
+)
+
+// createAuth returns the logins of the app. The API tokens are kept by AUTH_TOKENS, cache or
+// database, in the cache by default when there is one; with AUTH_JWT the JWTs signed with the
+// encryption key are accepted too.
+func (grv *Goravel) createAuth() (*auth.Auth, error) {
+	a := auth.New(grv.DB.Pool, grv.DB.DataBaseType, grv.Session, grv.AppName)
+	a.RememberFor = time.Duration(grv.Env.Int("AUTH_REMEMBER_DAYS", 30)) * 24 * time.Hour
+	a.LoginURL = grv.Env.String("AUTH_LOGIN_URL", a.LoginURL)
+	a.Issuer = grv.AppName
+	a.Clock = grv.Clock
+
+	tokens := strings.ToLower(grv.Env.String("AUTH_TOKENS", ""))
+	switch tokens {
+	case "":
+		if grv.Cache != nil {
+			a.Tokens = &auth.CacheTokens{Cache: grv.Cache, Clock: grv.Clock}
+		} else if grv.DB.Pool != nil {
+			a.Tokens = &auth.DBTokens{DB: grv.DB.Pool, DatabaseType: grv.DB.DataBaseType, Clock: grv.Clock}
+		}
+	case "cache":
+		if grv.Cache == nil {
+			return nil, fmt.Errorf("AUTH_TOKENS=cache needs CACHE")
+		}
+		a.Tokens = &auth.CacheTokens{Cache: grv.Cache, Clock: grv.Clock}
+	case "database":
+		if grv.DB.Pool == nil {
+			return nil, fmt.Errorf("AUTH_TOKENS=database needs DATABASE_TYPE")
+		}
+		a.Tokens = &auth.DBTokens{DB: grv.DB.Pool, DatabaseType: grv.DB.DataBaseType, Clock: grv.Clock}
+	default:
+		return nil, fmt.Errorf("unknown AUTH_TOKENS %q", tokens)
+	}
+
+	if grv.Env.Bool("AUTH_JWT", false) {
+		if grv.EncryptionKey == "" {
+			return nil, fmt.Errorf("AUTH_JWT needs KEY")
+		}
+		a.JWTKey = []byte(grv.EncryptionKey)
+	}
+
+	return a, nil
+}
@@ -0,0 +1,426 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// session keys, the ones of the handlers scaffolded by "goravel make auth"
+const (
+	userKey     = "userID"
+	rememberKey = "remember_token"
+	intendedKey = "auth.intended"
+)
+
+var (
+	// ErrInvalidCredentials is returned by Attempt for an unknown email or a wrong password
+	ErrInvalidCredentials = errors.New("auth: invalid credentials")
+	// ErrInactive is returned by Attempt for a user who is not active
+	ErrInactive = errors.New("auth: user is not active")
+)
+
+// User is a row of the users table created by "goravel make auth"
+type User struct {
+	ID        int
+	FirstName string
+	LastName  string
+	Email     string
+	Active    bool
+	// Password is the bcrypt hash of the password
+	Password  string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Auth logs users in and out of the session, keeping them in the users table and their
+// remember-me tokens in the remember_tokens table
+type Auth struct {
+	DB           *sql.DB
+	DatabaseType string
+	Session      *scs.SessionManager
+	// CookieName is the name of the remember-me cookie
+	CookieName string
+	// RememberFor is how long a remember-me cookie keeps a user logged in
+	RememberFor time.Duration
+	// LoginURL is where Require sends visitors who are not logged in, and LogoutHandler sends
+	// the ones logging out
+	LoginURL string
+	// HomeURL is where LoginHandler sends users without a page they were sent away from
+	HomeURL string
+	// Cost is the bcrypt cost of new password hashes
+	Cost int
+	// Tokens keeps the API tokens of IssueToken
+	Tokens TokenStore
+	// JWTKey signs the tokens of IssueJWT; without it no JWT is issued or accepted
+	JWTKey []byte
+	// Issuer is the iss claim of the JWTs, checked when they come back
+	Issuer string
+	// Clock tells when tokens and remember-me cookies expire; the time of the machine when nil
+	Clock clock.Clock
+}
+
+// New returns auth against the users of db, logging them into session
+func New(db *sql.DB, dbType string, session *scs.SessionManager, appName string) *Auth {
+	return &Auth{
+		DB:           db,
+		DatabaseType: dbType,
+		Session:      session,
+		CookieName:   "_" + appName + "_remember",
+		RememberFor:  30 * 24 * time.Hour,
+		LoginURL:     "/users/login",
+		HomeURL:      "/",
+		Cost:         bcrypt.DefaultCost,
+	}
+}
+
+// HashPassword returns the bcrypt hash of password to store in the users table
+func (a *Auth) HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), a.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password is the one of hash
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+const userColumns = "id, first_name, last_name, email, user_active, password, created_at, updated_at"
+
+func (a *Auth) scanUser(row *sql.Row) (*User, error) {
+	var u User
+	var active int
+	err := row.Scan(&u.ID, &u.FirstName, &u.LastName, &u.Email, &active, &u.Password, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	u.Active = active == 1
+	return &u, nil
+}
+
+// Find returns the user with id, or sql.ErrNoRows
+func (a *Auth) Find(ctx context.Context, id int) (*User, error) {
+	query := database.Rebind(a.DatabaseType, "select "+userColumns+" from users where id = ?")
+	return a.scanUser(a.DB.QueryRowContext(ctx, query, id))
+}
+
+// FindByEmail returns the user with the email, in any case, or sql.ErrNoRows
+func (a *Auth) FindByEmail(ctx context.Context, email string) (*User, error) {
+	query := database.Rebind(a.DatabaseType, "select "+userColumns+" from users where lower(email) = lower(?)")
+	return a.scanUser(a.DB.QueryRowContext(ctx, query, strings.TrimSpace(email)))
+}
+
+// Register stores u with the hash of password, setting its id
+func (a *Auth) Register(ctx context.Context, u *User, password string) error {
+	hash, err := a.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	u.Email = strings.TrimSpace(u.Email)
+	u.Password = hash
+	u.CreatedAt = clock.Now(a.Clock)
+	u.UpdatedAt = u.CreatedAt
+	active := 0
+	if u.Active {
+		active = 1
+	}
+
+	query := `insert into users (first_name, last_name, email, user_active, password, created_at, updated_at)
+		values (?, ?, ?, ?, ?, ?, ?)`
+	args := []interface{}{u.FirstName, u.LastName, u.Email, active, u.Password, u.CreatedAt, u.UpdatedAt}
+
+	if database.IsPostgres(a.DatabaseType) {
+		return a.DB.QueryRowContext(ctx, database.Rebind(a.DatabaseType, query+" returning id"), args...).Scan(&u.ID)
+	}
+
+	res, err := a.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	u.ID = int(id)
+	return err
+}
+
+// SetPassword replaces the password of the user with id, and forgets their remember-me
+// tokens so other devices have to log in again
+func (a *Auth) SetPassword(ctx context.Context, id int, password string) error {
+	hash, err := a.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	query := database.Rebind(a.DatabaseType, "update users set password = ?, updated_at = ? where id = ?")
+	if _, err := a.DB.ExecContext(ctx, query, hash, clock.Now(a.Clock), id); err != nil {
+		return err
+	}
+
+	_, err = a.DB.ExecContext(ctx, database.Rebind(a.DatabaseType, "delete from remember_tokens where user_id = ?"), id)
+	return err
+}
+
+// Attempt returns the active user with the email and password
+func (a *Auth) Attempt(ctx context.Context, email, password string) (*User, error) {
+	u, err := a.FindByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		// hash anyway, so unknown addresses take as long as wrong passwords
+		_, _ = a.HashPassword(password)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !CheckPassword(u.Password, password) {
+		return nil, ErrInvalidCredentials
+	}
+	if !u.Active {
+		return nil, ErrInactive
+	}
+	return u, nil
+}
+
+// Login puts u in the session under a new session token, so a token planted before the
+// login is worthless, and sets a remember-me cookie when remember is true
+func (a *Auth) Login(rw http.ResponseWriter, r *http.Request, u *User, remember bool) error {
+	if err := a.Session.RenewToken(r.Context()); err != nil {
+		return err
+	}
+	a.Session.Put(r.Context(), userKey, u.ID)
+
+	if !remember {
+		return nil
+	}
+	return a.remember(rw, r, u.ID)
+}
+
+// Logout removes the user from the session, forgets their remember-me token and starts a
+// new session
+func (a *Auth) Logout(rw http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	if hash := a.Session.GetString(ctx, rememberKey); hash != "" {
+		query := database.Rebind(a.DatabaseType, "delete from remember_tokens where remember_token = ?")
+		if _, err := a.DB.ExecContext(ctx, query, hash); err != nil {
+			return err
+		}
+	}
+	a.clearCookie(rw)
+
+	if err := a.Session.Destroy(ctx); err != nil {
+		return err
+	}
+	return a.Session.RenewToken(ctx)
+}
+
+// UserID returns the id of the user logged into the session of r
+func (a *Auth) UserID(r *http.Request) (int, bool) {
+	id := a.Session.GetInt(r.Context(), userKey)
+	return id, id != 0
+}
+
+// Check reports whether a user is logged into the session of r
+func (a *Auth) Check(r *http.Request) bool {
+	_, ok := a.UserID(r)
+	return ok
+}
+
+// User returns the user logged into the session of r, or nil
+func (a *Auth) User(r *http.Request) (*User, error) {
+	id, ok := a.UserID(r)
+	if !ok {
+		return nil, nil
+	}
+
+	u, err := a.Find(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return u, err
+}
+
+// remember stores the hash of a new random token for the user, and gives the token itself to
+// the browser: a leaked table does not log anyone in
+func (a *Auth) remember(rw http.ResponseWriter, r *http.Request, id int) error {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	hash := hashToken(token)
+
+	query := database.Rebind(a.DatabaseType, "insert into remember_tokens (user_id, remember_token, created_at, updated_at) values (?, ?, ?, ?)")
+	now := clock.Now(a.Clock)
+	if _, err := a.DB.ExecContext(r.Context(), query, id, hash, now, now); err != nil {
+		return err
+	}
+
+	a.Session.Put(r.Context(), rememberKey, hash)
+	http.SetCookie(rw, a.cookie(strconv.Itoa(id)+"|"+token, int(a.RememberFor.Seconds())))
+	return nil
+}
+
+// recall logs in the user of a valid remember-me cookie. An invalid cookie, e.g. of a token
+// forgotten when the user logged out on another device, is cleared.
+func (a *Auth) recall(rw http.ResponseWriter, r *http.Request) error {
+	c, err := r.Cookie(a.CookieName)
+	if err != nil {
+		return nil
+	}
+
+	id, token, ok := parseCookie(c.Value)
+	if !ok {
+		a.clearCookie(rw)
+		return nil
+	}
+
+	hash := hashToken(token)
+	query := database.Rebind(a.DatabaseType, "select remember_token from remember_tokens where user_id = ? and remember_token = ? and created_at > ?")
+	var stored string
+	err = a.DB.QueryRowContext(r.Context(), query, id, hash, clock.Now(a.Clock).Add(-a.RememberFor)).Scan(&stored)
+	if errors.Is(err, sql.ErrNoRows) {
+		a.clearCookie(rw)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := a.Session.RenewToken(r.Context()); err != nil {
+		return err
+	}
+	a.Session.Put(r.Context(), userKey, id)
+	a.Session.Put(r.Context(), rememberKey, hash)
+	return nil
+}
+
+// parseCookie splits a remember-me cookie into the user id and the token
+func parseCookie(value string) (int, string, bool) {
+	i := strings.Index(value, "|")
+	if i < 0 {
+		return 0, "", false
+	}
+
+	id, err := strconv.Atoi(value[:i])
+	if err != nil || id <= 0 || value[i+1:] == "" {
+		return 0, "", false
+	}
+	return id, value[i+1:], true
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (a *Auth) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     a.CookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Domain:   a.Session.Cookie.Domain,
+		Secure:   a.Session.Cookie.Secure,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func (a *Auth) clearCookie(rw http.ResponseWriter) {
+	http.SetCookie(rw, a.cookie("", -1))
+}
+
+// Remember logs in the visitors with a valid remember-me cookie; it goes after the session
+// middleware and before Require
+func (a *Auth) Remember(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if !a.Check(r) {
+			if err := a.recall(rw, r); err != nil {
+				http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// Require lets only logged in users through. Other visitors are sent to LoginURL, coming
+// back to the page after logging in; requests for JSON get a 401 instead.
+func (a *Auth) Require(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if a.Check(r) {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		if r.Method != http.MethodGet || strings.Contains(r.Header.Get("Accept"), "application/json") {
+			http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		a.Session.Put(r.Context(), intendedKey, r.URL.RequestURI())
+		http.Redirect(rw, r, a.LoginURL, http.StatusSeeOther)
+	})
+}
+
+// LoginHandler logs in the user of the email and password posted by the login form, keeping
+// them logged in when the remember field is set. A failed login goes back to LoginURL with
+// the message in the "error" of the session.
+func (a *Auth) LoginHandler(rw http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	u, err := a.Attempt(r.Context(), r.Form.Get("email"), r.Form.Get("password"))
+	switch {
+	case errors.Is(err, ErrInvalidCredentials):
+		a.Session.Put(r.Context(), "error", "Invalid email or password")
+		http.Redirect(rw, r, a.LoginURL, http.StatusSeeOther)
+		return
+	case errors.Is(err, ErrInactive):
+		a.Session.Put(r.Context(), "error", "This account is not active")
+		http.Redirect(rw, r, a.LoginURL, http.StatusSeeOther)
+		return
+	case err != nil:
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	// read before Login, which keeps the data of the session under its new token
+	to := a.Session.PopString(r.Context(), intendedKey)
+	if !strings.HasPrefix(to, "/") || strings.HasPrefix(to, "//") {
+		to = a.HomeURL
+	}
+
+	remember := r.Form.Get("remember") != ""
+	if err := a.Login(rw, r, u, remember); err != nil {
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(rw, r, to, http.StatusSeeOther)
+}
+
+// LogoutHandler logs the user out and sends them to LoginURL
+func (a *Auth) LogoutHandler(rw http.ResponseWriter, r *http.Request) {
+	if err := a.Logout(rw, r); err != nil {
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(rw, r, a.LoginURL, http.StatusSeeOther)
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// serve runs h within the session of cookie, returning the response
+func serve(a *Auth, h http.HandlerFunc, method, target string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	rw := httptest.NewRecorder()
+	a.Session.LoadAndSave(h).ServeHTTP(rw, r)
+	return rw
+}
+
+func sessionCookie(t *testing.T, rw *httptest.ResponseRecorder) *http.Cookie {
+	t.Helper()
+	for _, c := range rw.Result().Cookies() {
+		if c.Name == "session" {
+			return c
+		}
+	}
+	t.Fatal("no session cookie")
+	return nil
+}
+
+func TestPasswords(t *testing.T) {
+	a := New(nil, "postgres", scs.New(), "myapp")
+	a.Cost = bcrypt.MinCost
+
+	hash, err := a.HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash == "correct horse" || !CheckPassword(hash, "correct horse") {
+		t.Error("expected the hash to match its password")
+	}
+	if CheckPassword(hash, "battery staple") {
+		t.Error("expected another password not to match")
+	}
+}
+
+func TestLoginRenewsSession(t *testing.T) {
+	a := New(nil, "postgres", scs.New(), "myapp")
+
+	protected := a.Require(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte("secret"))
+	}))
+
+	rw := serve(a, protected.ServeHTTP, "GET", "/account?tab=billing", nil)
+	if rw.Code != http.StatusSeeOther || rw.Header().Get("Location") != "/users/login" {
+		t.Fatalf("expected a redirect to the login page, got %d %s", rw.Code, rw.Header().Get("Location"))
+	}
+	before := sessionCookie(t, rw)
+
+	rw = serve(a, func(rw http.ResponseWriter, r *http.Request) {
+		if got := a.Session.GetString(r.Context(), intendedKey); got != "/account?tab=billing" {
+			t.Errorf("expected the page to come back to, got %q", got)
+		}
+		if err := a.Login(rw, r, &User{ID: 7}, false); err != nil {
+			t.Fatal(err)
+		}
+	}, "POST", "/users/login", before)
+	after := sessionCookie(t, rw)
+	if after.Value == before.Value {
+		t.Error("expected logging in to renew the session token")
+	}
+
+	if rw := serve(a, protected.ServeHTTP, "GET", "/account", before); rw.Code != http.StatusSeeOther {
+		t.Error("expected the token from before the login to stay logged out")
+	}
+	if rw := serve(a, protected.ServeHTTP, "GET", "/account", after); rw.Body.String() != "secret" {
+		t.Errorf("expected the logged in user through, got %d", rw.Code)
+	}
+
+	if rw := serve(a, protected.ServeHTTP, "POST", "/account", nil); rw.Code != http.StatusUnauthorized {
+		t.Errorf("expected a 401 for a post without a user, got %d", rw.Code)
+	}
+}
+
+func TestParseCookie(t *testing.T) {
+	if id, token, ok := parseCookie("12|abc"); !ok || id != 12 || token != "abc" {
+		t.Errorf("unexpected %d %q %v", id, token, ok)
+	}
+	for _, bad := range []string{"", "12", "x|abc", "0|abc", "12|"} {
+		if _, _, ok := parseCookie(bad); ok {
+			t.Errorf("expected %q to be refused", bad)
+		}
+	}
+	if hashToken("abc") == "abc" || hashToken("abc") != hashToken("abc") {
+		t.Error("expected a stable hash of the token")
+	}
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+)
+
+// jwtHeader is the only header accepted, so a token cannot pick its own algorithm
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+type claims struct {
+	Subject   string `json:"sub"`
+	Issuer    string `json:"iss,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+	Scope     string `json:"scope,omitempty"`
+}
+
+// IssueJWT returns a stateless token of the user signed with JWTKey, valid for ttl and scopes.
+// It is checked without a store, so it cannot be revoked before it expires; keep ttl short.
+func (a *Auth) IssueJWT(userID int, ttl time.Duration, scopes ...string) (string, error) {
+	if len(a.JWTKey) == 0 {
+		return "", fmt.Errorf("auth: no JWT key")
+	}
+
+	now := clock.Now(a.Clock)
+	body, err := json.Marshal(claims{
+		Subject:   strconv.Itoa(userID),
+		Issuer:    a.Issuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+		Scope:     strings.Join(scopes, " "),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signed := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(body)
+	return signed + "." + a.sign(signed), nil
+}
+
+func (a *Auth) sign(s string) string {
+	mac := hmac.New(sha256.New, a.JWTKey)
+	mac.Write([]byte(s))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// parseJWT checks the signature, issuer and expiry of a token of IssueJWT
+func (a *Auth) parseJWT(token string) (*Token, error) {
+	if len(a.JWTKey) == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return nil, ErrInvalidToken
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(a.sign(parts[0]+"."+parts[1]))) {
+		return nil, ErrInvalidToken
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var c claims
+	if err := json.Unmarshal(body, &c); err != nil {
+		return nil, ErrInvalidToken
+	}
+	id, err := strconv.Atoi(c.Subject)
+	if err != nil || c.Issuer != a.Issuer {
+		return nil, ErrInvalidToken
+	}
+
+	t := &Token{UserID: id, Scopes: strings.Fields(c.Scope), ExpiresAt: time.Unix(c.ExpiresAt, 0)}
+	if clock.Now(a.Clock).After(t.ExpiresAt) {
+		return nil, ErrExpiredToken
+	}
+	return t, nil
+}
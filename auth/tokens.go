@@ -0,0 +1,275 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/namnguyen191/goravel/cache"
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+)
+
+var (
+	// ErrInvalidToken is returned for a token which was never issued, was revoked or is forged
+	ErrInvalidToken = errors.New("auth: invalid token")
+	// ErrExpiredToken is returned for a token past its expiry
+	ErrExpiredToken = errors.New("auth: token expired")
+)
+
+// Token is a bearer token of the API, sent as "Authorization: Bearer <token>"
+type Token struct {
+	// Plain is the token given to the client, only known when it is issued
+	Plain     string    `json:"-"`
+	UserID    int       `json:"user_id"`
+	Scopes    []string  `json:"scopes,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Can reports whether the token was issued for scope, or for every scope with "*"
+func (t *Token) Can(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope || s == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenStore keeps the issued tokens under the hash of their plain text, so a leaked store
+// does not let anyone in
+type TokenStore interface {
+	Save(ctx context.Context, hash string, t *Token) error
+	// Find returns the token with hash, or nil
+	Find(ctx context.Context, hash string) (*Token, error)
+	Delete(ctx context.Context, hash string) error
+}
+
+// IssueToken returns a new random token of the user, valid for ttl and scopes
+func (a *Auth) IssueToken(ctx context.Context, userID int, ttl time.Duration, scopes ...string) (*Token, error) {
+	if a.Tokens == nil {
+		return nil, fmt.Errorf("auth: no token store")
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	t := &Token{
+		Plain:     base64.RawURLEncoding.EncodeToString(b),
+		UserID:    userID,
+		Scopes:    scopes,
+		ExpiresAt: clock.Now(a.Clock).Add(ttl).Truncate(time.Second),
+	}
+	if err := a.Tokens.Save(ctx, hashToken(t.Plain), t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// RevokeToken forgets a token issued by IssueToken; a JWT stays valid until it expires
+func (a *Auth) RevokeToken(ctx context.Context, plain string) error {
+	if a.Tokens == nil {
+		return nil
+	}
+	return a.Tokens.Delete(ctx, hashToken(plain))
+}
+
+// ValidateToken returns the token of plain, a token of IssueToken or a JWT of IssueJWT
+func (a *Auth) ValidateToken(ctx context.Context, plain string) (*Token, error) {
+	if strings.Count(plain, ".") == 2 {
+		return a.parseJWT(plain)
+	}
+	if a.Tokens == nil || plain == "" {
+		return nil, ErrInvalidToken
+	}
+
+	t, err := a.Tokens.Find(ctx, hashToken(plain))
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrInvalidToken
+	}
+	if clock.Now(a.Clock).After(t.ExpiresAt) {
+		return nil, ErrExpiredToken
+	}
+	return t, nil
+}
+
+type tokenKey struct{}
+type userKeyType struct{}
+
+// TokenFrom returns the token of the request context set by AuthenticateToken, or nil
+func TokenFrom(ctx context.Context) *Token {
+	t, _ := ctx.Value(tokenKey{}).(*Token)
+	return t
+}
+
+// UserFrom returns the user of the request context set by AuthenticateToken, or nil
+func UserFrom(ctx context.Context) *User {
+	u, _ := ctx.Value(userKeyType{}).(*User)
+	return u
+}
+
+// unauthorized answers the json error of the API middleware
+func unauthorized(rw http.ResponseWriter, status int, message string) {
+	rw.Header().Set("Content-Type", "application/json")
+	if status == http.StatusUnauthorized {
+		rw.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	}
+	rw.WriteHeader(status)
+	_ = json.NewEncoder(rw).Encode(map[string]interface{}{"error": true, "message": message})
+}
+
+// AuthenticateToken lets through the requests with a valid bearer token, putting the token
+// and, with a database, its user in the request context for TokenFrom and UserFrom
+func (a *Auth) AuthenticateToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+			unauthorized(rw, http.StatusUnauthorized, "invalid authentication credentials")
+			return
+		}
+
+		t, err := a.ValidateToken(r.Context(), strings.TrimSpace(header[7:]))
+		if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrExpiredToken) {
+			unauthorized(rw, http.StatusUnauthorized, "invalid authentication credentials")
+			return
+		}
+		if err != nil {
+			unauthorized(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), tokenKey{}, t)
+		if a.DB != nil {
+			u, err := a.Find(ctx, t.UserID)
+			if errors.Is(err, sql.ErrNoRows) || (err == nil && !u.Active) {
+				unauthorized(rw, http.StatusUnauthorized, "invalid authentication credentials")
+				return
+			}
+			if err != nil {
+				unauthorized(rw, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+				return
+			}
+			ctx = context.WithValue(ctx, userKeyType{}, u)
+		}
+
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// RequireScope lets through the requests whose token has scope; it goes after
+// AuthenticateToken, e.g. r.With(app.Auth.AuthenticateToken, auth.RequireScope("orders:write"))
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			t := TokenFrom(r.Context())
+			if t == nil {
+				unauthorized(rw, http.StatusUnauthorized, "invalid authentication credentials")
+				return
+			}
+			if !t.Can(scope) {
+				unauthorized(rw, http.StatusForbidden, "the token is missing the scope "+scope)
+				return
+			}
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// CacheTokens keeps the tokens in the cache, where they expire on their own
+type CacheTokens struct {
+	Cache cache.Cache
+	Clock clock.Clock
+}
+
+func (s *CacheTokens) Save(ctx context.Context, hash string, t *Token) error {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	ttl := int(t.ExpiresAt.Sub(clock.Now(s.Clock)).Seconds())
+	if ttl < 1 {
+		ttl = 1
+	}
+	return s.Cache.Set("api-token:"+hash, string(b), ttl)
+}
+
+func (s *CacheTokens) Find(ctx context.Context, hash string) (*Token, error) {
+	found, err := s.Cache.Has("api-token:" + hash)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	v, err := s.Cache.Get("api-token:" + hash)
+	if err != nil {
+		return nil, err
+	}
+	b, _ := v.(string)
+
+	var t Token
+	if err := json.Unmarshal([]byte(b), &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *CacheTokens) Delete(ctx context.Context, hash string) error {
+	return s.Cache.Forget("api-token:" + hash)
+}
+
+// DBTokens keeps the tokens in the tokens table created by "goravel make auth", with their
+// scopes in its scopes column
+type DBTokens struct {
+	DB           *sql.DB
+	DatabaseType string
+	Clock        clock.Clock
+}
+
+func (s *DBTokens) Save(ctx context.Context, hash string, t *Token) error {
+	// the table keeps the name and address of the user, and the token only as its hash
+	query := `insert into tokens (user_id, first_name, email, token, token_hash, scopes, created_at, updated_at, expiry)
+		select id, first_name, email, '', ?, ?, ?, ?, ? from users where id = ?`
+	now := clock.Now(s.Clock)
+
+	res, err := s.DB.ExecContext(ctx, database.Rebind(s.DatabaseType, query), []byte(hash), strings.Join(t.Scopes, " "), now, now, t.ExpiresAt, t.UserID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("auth: no user %d", t.UserID)
+	}
+	return nil
+}
+
+func (s *DBTokens) Find(ctx context.Context, hash string) (*Token, error) {
+	query := database.Rebind(s.DatabaseType, "select user_id, scopes, expiry from tokens where token_hash = ?")
+
+	var t Token
+	var scopes string
+	err := s.DB.QueryRowContext(ctx, query, []byte(hash)).Scan(&t.UserID, &scopes, &t.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.Scopes = strings.Fields(scopes)
+	return &t, nil
+}
+
+func (s *DBTokens) Delete(ctx context.Context, hash string) error {
+	_, err := s.DB.ExecContext(ctx, database.Rebind(s.DatabaseType, "delete from tokens where token_hash = ?"), []byte(hash))
+	return err
+}
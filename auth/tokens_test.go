@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+	"github.com/namnguyen191/goravel/cache"
+)
+
+func cacheTokens(t *testing.T) *CacheTokens {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+
+	pool := &redis.Pool{Dial: func() (redis.Conn, error) { return redis.Dial("tcp", s.Addr()) }}
+	return &CacheTokens{Cache: &cache.RedisCache{Conn: pool, Prefix: "test"}}
+}
+
+func TestTokens(t *testing.T) {
+	a := New(nil, "postgres", nil, "myapp")
+	a.Tokens = cacheTokens(t)
+	ctx := context.Background()
+
+	tok, err := a.IssueToken(ctx, 7, time.Hour, "orders:read")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := a.ValidateToken(ctx, tok.Plain)
+	if err != nil || got.UserID != 7 || !got.Can("orders:read") || got.Can("orders:write") {
+		t.Errorf("expected the issued token back, got %+v %v", got, err)
+	}
+
+	if _, err := a.ValidateToken(ctx, "made-up"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected an unknown token to be invalid, got %v", err)
+	}
+
+	if err := a.RevokeToken(ctx, tok.Plain); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.ValidateToken(ctx, tok.Plain); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected a revoked token to be invalid, got %v", err)
+	}
+}
+
+func TestJWT(t *testing.T) {
+	a := New(nil, "postgres", nil, "myapp")
+	a.JWTKey = []byte("0123456789abcdef0123456789abcdef")
+	a.Issuer = "myapp"
+	ctx := context.Background()
+
+	jwt, err := a.IssueJWT(7, time.Minute, "orders:read", "orders:write")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := a.ValidateToken(ctx, jwt)
+	if err != nil || got.UserID != 7 || !got.Can("orders:write") {
+		t.Errorf("expected the claims of the jwt, got %+v %v", got, err)
+	}
+
+	parts := strings.Split(jwt, ".")
+	forged := parts[0] + "." + parts[1] + "x." + parts[2]
+	if _, err := a.ValidateToken(ctx, forged); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected a tampered jwt to be invalid, got %v", err)
+	}
+
+	expired, _ := a.IssueJWT(7, -time.Minute)
+	if _, err := a.ValidateToken(ctx, expired); !errors.Is(err, ErrExpiredToken) {
+		t.Errorf("expected an expired jwt, got %v", err)
+	}
+
+	other := New(nil, "postgres", nil, "otherapp")
+	other.JWTKey = []byte("another key of thirty two bytes!")
+	if _, err := other.ValidateToken(ctx, jwt); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected a jwt of another key to be invalid, got %v", err)
+	}
+}
+
+func TestAuthenticateToken(t *testing.T) {
+	a := New(nil, "postgres", nil, "myapp")
+	a.JWTKey = []byte("0123456789abcdef0123456789abcdef")
+	jwt, _ := a.IssueJWT(7, time.Minute, "orders:read")
+
+	h := a.AuthenticateToken(RequireScope("orders:read")(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if tok := TokenFrom(r.Context()); tok == nil || tok.UserID != 7 {
+			t.Errorf("expected the token in the context, got %+v", tok)
+		}
+	})))
+
+	for header, want := range map[string]int{
+		"":                     http.StatusUnauthorized,
+		"Basic dXNlcjpwYXNz":   http.StatusUnauthorized,
+		"Bearer not-a-token":   http.StatusUnauthorized,
+		"Bearer " + jwt:        http.StatusOK,
+		"bearer " + jwt + "  ": http.StatusOK,
+	} {
+		r := httptest.NewRequest("GET", "/api/orders", nil)
+		if header != "" {
+			r.Header.Set("Authorization", header)
+		}
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, r)
+		if rw.Code != want {
+			t.Errorf("%q: expected %d, got %d", header, want, rw.Code)
+		}
+	}
+
+	writer, _ := a.IssueJWT(7, time.Minute, "orders:write")
+	r := httptest.NewRequest("GET", "/api/orders", nil)
+	r.Header.Set("Authorization", "Bearer "+writer)
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, r)
+	if rw.Code != http.StatusForbidden {
+		t.Errorf("expected a token without the scope to be forbidden, got %d", rw.Code)
+	}
+}
@@ -0,0 +1,341 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/namnguyen191/goravel/filesystems"
+)
+
+// Database holds the connection details passed to the dump tools
+type Database struct {
+	Type     string
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+}
+
+// Store is where backup archives are kept: Local keeps them in a directory, and Disk in a file
+// system of the app such as an S3 bucket.
+type Store interface {
+	Put(name string, data []byte) error
+	List() ([]string, error)
+	Delete(name string) error
+}
+
+// Result reports the outcome of a backup run
+type Result struct {
+	Name     string
+	Size     int
+	Duration time.Duration
+	Error    error
+}
+
+// Backup dumps the database and optional directories into an encrypted tar.gz archive
+type Backup struct {
+	Database Database
+	// Paths are extra directories (e.g. storage disks) added to the archive
+	Paths []string
+	// Key encrypts the archive with AES-GCM; backups are stored unencrypted when empty
+	Key   []byte
+	Store Store
+	// Keep is the number of archives retained; zero keeps everything
+	Keep int
+	// Notify is called after every run
+	Notify func(Result)
+	// Dump produces the database dump; it defaults to running pg_dump, mysqldump or sqlite3
+	Dump func(db Database) ([]byte, error)
+}
+
+// Run creates a backup, stores it, enforces retention and notifies the result
+func (b *Backup) Run() Result {
+	start := time.Now()
+	res := Result{Name: fmt.Sprintf("backup-%s.tar.gz", start.UTC().Format("20060102-150405"))}
+
+	data, err := b.archive()
+	if err == nil && len(b.Key) > 0 {
+		data, err = encrypt(b.Key, data)
+		res.Name += ".enc"
+	}
+
+	if err == nil {
+		err = b.Store.Put(res.Name, data)
+	}
+
+	if err == nil {
+		err = b.prune()
+	}
+
+	res.Size = len(data)
+	res.Duration = time.Since(start)
+	res.Error = err
+
+	if b.Notify != nil {
+		b.Notify(res)
+	}
+
+	return res
+}
+
+func (b *Backup) archive() ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	if b.Database.Type != "" {
+		dump := b.Dump
+		if dump == nil {
+			dump = Dump
+		}
+
+		sql, err := dump(b.Database)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := addFile(tw, "database.sql", sql); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, root := range b.Paths {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			rel, err := filepath.Rel(filepath.Dir(root), path)
+			if err != nil {
+				return err
+			}
+
+			return addFile(tw, filepath.ToSlash(rel), content)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// prune deletes the oldest archives beyond the retention count; names sort chronologically
+func (b *Backup) prune() error {
+	if b.Keep <= 0 {
+		return nil
+	}
+
+	names, err := b.Store.List()
+	if err != nil {
+		return err
+	}
+
+	var backups []string
+	for _, n := range names {
+		if strings.HasPrefix(n, "backup-") {
+			backups = append(backups, n)
+		}
+	}
+	sort.Strings(backups)
+
+	for len(backups) > b.Keep {
+		if err := b.Store.Delete(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+
+	return nil
+}
+
+// Dump runs the dump tool of the database type and returns its output
+func Dump(db Database) ([]byte, error) {
+	var cmd *exec.Cmd
+
+	switch strings.ToLower(db.Type) {
+	case "postgres", "postgresql":
+		cmd = exec.Command("pg_dump", "-h", db.Host, "-p", db.Port, "-U", db.User, "--no-owner", db.Name)
+		cmd.Env = append(os.Environ(), "PGPASSWORD="+db.Password)
+	case "mysql", "mariadb":
+		cmd = exec.Command("mysqldump", "-h", db.Host, "-P", db.Port, "-u", db.User, "--single-transaction", db.Name)
+		cmd.Env = append(os.Environ(), "MYSQL_PWD="+db.Password)
+	case "sqlite", "sqlite3":
+		cmd = exec.Command("sqlite3", db.Name, ".dump")
+	default:
+		return nil, fmt.Errorf("backups are not supported for database type %q", db.Type)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", cmd.Path, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return out, nil
+}
+
+func addFile(tw *tar.Writer, name string, content []byte) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(content)),
+		ModTime: time.Now(),
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	_, err := tw.Write(content)
+
+	return err
+}
+
+// encrypt seals data with AES-GCM, the random nonce coming first
+func encrypt(key, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// Decrypt reverses the encryption of an archive created with a key, failing for an archive
+// which was changed since
+func Decrypt(key, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("encrypted backup is too short")
+	}
+
+	n := gcm.NonceSize()
+	out, err := gcm.Open(nil, data[:n], data[n:], nil)
+	if err != nil {
+		return nil, errors.New("encrypted backup was changed or the key is wrong")
+	}
+
+	return out, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// Local stores archives in a directory on disk
+type Local struct {
+	Dir string
+}
+
+// Put writes an archive
+func (l *Local) Put(name string, data []byte) error {
+	if err := os.MkdirAll(l.Dir, 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(l.Dir, name), data, 0600)
+}
+
+// List returns the archive names
+func (l *Local) List() ([]string, error) {
+	entries, err := os.ReadDir(l.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+
+	return names, nil
+}
+
+// Delete removes an archive
+func (l *Local) Delete(name string) error {
+	return os.Remove(filepath.Join(l.Dir, name))
+}
+
+// Disk stores archives in Dir of a file system of the app
+type Disk struct {
+	FS  filesystems.FileSystem
+	Dir string
+}
+
+// Put writes an archive
+func (d *Disk) Put(name string, data []byte) error {
+	return d.FS.Put(context.Background(), path.Join(d.Dir, name), bytes.NewReader(data))
+}
+
+// List returns the archive names
+func (d *Disk) List() ([]string, error) {
+	entries, err := d.FS.List(context.Background(), d.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir {
+			names = append(names, path.Base(e.Path))
+		}
+	}
+
+	return names, nil
+}
+
+// Delete removes an archive
+func (d *Disk) Delete(name string) error {
+	return d.FS.Delete(context.Background(), path.Join(d.Dir, name))
+}
@@ -0,0 +1,121 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/namnguyen191/goravel/filesystems"
+)
+
+func TestBackup_Run(t *testing.T) {
+	dir := t.TempDir()
+	files := filepath.Join(dir, "storage")
+	_ = os.MkdirAll(files, 0755)
+	_ = os.WriteFile(filepath.Join(files, "avatar.png"), []byte("png"), 0644)
+
+	store := &Local{Dir: filepath.Join(dir, "backups")}
+	for _, old := range []string{"backup-20200101-000000.tar.gz", "backup-20200102-000000.tar.gz"} {
+		_ = store.Put(old, []byte("old"))
+	}
+
+	key := []byte("abcdefghijklmnopqrstuvwxyz123456")
+	var notified Result
+
+	b := &Backup{
+		Database: Database{Type: "postgres"},
+		Paths:    []string{files},
+		Key:      key,
+		Store:    store,
+		Keep:     2,
+		Notify:   func(r Result) { notified = r },
+		Dump:     func(db Database) ([]byte, error) { return []byte("create table users();"), nil },
+	}
+
+	res := b.Run()
+	if res.Error != nil {
+		t.Fatal(res.Error)
+	}
+
+	if notified.Name != res.Name {
+		t.Error("notify was not called with the result")
+	}
+
+	names, _ := store.List()
+	if len(names) != 2 || names[0] != "backup-20200102-000000.tar.gz" {
+		t.Errorf("expected the oldest backup to be pruned, got %v", names)
+	}
+
+	encrypted, _ := os.ReadFile(filepath.Join(store.Dir, res.Name))
+	data, err := Decrypt(key, encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents := map[string]string{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(tr)
+		contents[hdr.Name] = string(b)
+	}
+
+	if contents["database.sql"] != "create table users();" || contents["storage/avatar.png"] != "png" {
+		t.Errorf("unexpected archive contents %v", contents)
+	}
+}
+
+func TestDump_unsupported(t *testing.T) {
+	if _, err := Dump(Database{Type: "oracle"}); err == nil {
+		t.Error("expected an error for an unsupported database")
+	}
+}
+
+func TestDecrypt_tampered(t *testing.T) {
+	key := []byte("abcdefghijklmnopqrstuvwxyz123456")
+	sealed, err := encrypt(key, []byte("create table users();"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := Decrypt(key, sealed); err != nil || string(data) != "create table users();" {
+		t.Errorf("expected the archive back, got %q %v", data, err)
+	}
+
+	sealed[len(sealed)-1] ^= 1
+	if _, err := Decrypt(key, sealed); err == nil {
+		t.Error("expected a changed archive to fail")
+	}
+}
+
+func TestDisk(t *testing.T) {
+	d := &Disk{FS: &filesystems.Local{Root: t.TempDir()}, Dir: "backups"}
+	for _, name := range []string{"backup-20200101-000000.tar.gz", "backup-20200102-000000.tar.gz"} {
+		if err := d.Put(name, []byte("tgz")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.Delete("backup-20200101-000000.tar.gz"); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := d.List()
+	if err != nil || len(names) != 1 || names[0] != "backup-20200102-000000.tar.gz" {
+		t.Errorf("expected the names of the archives in the directory, got %v %v", names, err)
+	}
+}
@@ -0,0 +1,76 @@
+package goravel
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/namnguyen191/goravel/backup"
+	"github.com/namnguyen191/goravel/monitor"
+)
+
+// scheduleBackups registers the database backup with the scheduler when BACKUP_SCHEDULE is set.
+// The archives are uploaded to the file system named by BACKUP_DISK, or written to BACKUP_DIR on
+// the local disk without it, and failed runs are sent to the notifiers of the monitor.
+func (grv *Goravel) scheduleBackups() error {
+	schedule := os.Getenv("BACKUP_SCHEDULE")
+	if schedule == "" {
+		return nil
+	}
+
+	var store backup.Store
+	if disk := os.Getenv("BACKUP_DISK"); disk != "" {
+		fs, ok := grv.FileSystems[disk]
+		if !ok {
+			return fmt.Errorf("BACKUP_DISK: no file system %q", disk)
+		}
+		store = &backup.Disk{FS: fs, Dir: grv.Env.String("BACKUP_DIR", "backups")}
+	} else {
+		dir := os.Getenv("BACKUP_DIR")
+		if dir == "" {
+			dir = grv.RootPath + "/backups"
+		}
+		store = &backup.Local{Dir: dir}
+	}
+
+	keep := grv.Env.Int("BACKUP_KEEP", 0)
+
+	var paths []string
+	for _, p := range strings.Split(os.Getenv("BACKUP_PATHS"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, p)
+		}
+	}
+
+	grv.Backup = &backup.Backup{
+		Database: backup.Database{
+			Type:     os.Getenv("DATABASE_TYPE"),
+			Host:     os.Getenv("DATABASE_HOST"),
+			Port:     os.Getenv("DATABASE_PORT"),
+			User:     os.Getenv("DATABASE_USER"),
+			Password: os.Getenv("DATABASE_PASS"),
+			Name:     os.Getenv("DATABASE_NAME"),
+		},
+		Paths: paths,
+		Key:   []byte(grv.EncryptionKey),
+		Store: store,
+		Keep:  keep,
+		Notify: func(res backup.Result) {
+			if res.Error != nil {
+				msg := fmt.Sprintf("backup %s failed: %v", res.Name, res.Error)
+				grv.ErrorLog.Println(msg)
+				if grv.Monitor != nil {
+					grv.Monitor.Notify(monitor.Alert{Check: "backup", Healthy: false, Message: msg})
+				}
+				return
+			}
+			grv.InfoLog.Printf("backup %s completed (%d bytes in %s)", res.Name, res.Size, res.Duration)
+		},
+	}
+
+	_, err := grv.Scheduler.AddFunc(schedule, func() {
+		grv.Backup.Run()
+	})
+
+	return err
+}
@@ -0,0 +1,30 @@
+package goravel
+
+import (
+	"context"
+
+	"github.com/namnguyen191/goravel/billing"
+)
+
+// createBilling adds subscription billing on top of payments and syncs subscription state
+// every hour; plans are defined by the application with grv.Billing.Define
+func (grv *Goravel) createBilling() error {
+	if grv.Payments == nil {
+		return nil
+	}
+
+	provider, ok := grv.Payments.Provider.(billing.Provider)
+	if !ok {
+		return nil
+	}
+
+	grv.Billing = billing.New(grv.Payments, provider, grv.Session)
+
+	_, err := grv.Scheduler.AddFunc("@hourly", func() {
+		if err := grv.Billing.Sync(context.Background()); err != nil {
+			grv.ErrorLog.Println("billing:", err)
+		}
+	})
+
+	return err
+}
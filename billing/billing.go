@@ -0,0 +1,275 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/namnguyen191/goravel/money"
+	"github.com/namnguyen191/goravel/payments"
+)
+
+// ErrUnknownPlan is returned for plans that were never defined
+var ErrUnknownPlan = errors.New("billing: unknown plan")
+
+// ErrNoSubscription is returned when a user has no subscription to change
+var ErrNoSubscription = errors.New("billing: no subscription")
+
+// Provider is the part of a payments provider that manages subscriptions; payments.Stripe implements it
+type Provider interface {
+	Subscription(ctx context.Context, id string) (*payments.Event, error)
+	UpdateQuantity(ctx context.Context, id string, quantity int) error
+	Cancel(ctx context.Context, id string, atPeriodEnd bool) error
+	Invoices(ctx context.Context, customerID string) ([]payments.Invoice, error)
+}
+
+// Plan is a subscription plan; Price is the provider's price id
+type Plan struct {
+	Name      string
+	Price     string
+	Amount    money.Money
+	Interval  string
+	TrialDays int
+	// PerSeat plans are billed by quantity
+	PerSeat  bool
+	Features []string
+}
+
+// Billing manages subscriptions to plans on top of payments
+type Billing struct {
+	Payments *payments.Payments
+	Provider Provider
+	Session  *scs.SessionManager
+	// Grace keeps past due subscriptions usable while the provider retries the charge
+	Grace time.Duration
+	// BillingURL is where RequireSubscription sends users without a plan
+	BillingURL string
+	plans      map[string]Plan
+	order      []string
+	now        func() time.Time
+}
+
+// New returns billing for p; provider is usually p's own provider
+func New(p *payments.Payments, provider Provider, session *scs.SessionManager) *Billing {
+	return &Billing{
+		Payments:   p,
+		Provider:   provider,
+		Session:    session,
+		Grace:      3 * 24 * time.Hour,
+		BillingURL: "/billing",
+		plans:      map[string]Plan{},
+		now:        time.Now,
+	}
+}
+
+// Define adds a plan
+func (b *Billing) Define(plans ...Plan) {
+	for _, p := range plans {
+		if _, ok := b.plans[p.Name]; !ok {
+			b.order = append(b.order, p.Name)
+		}
+		b.plans[p.Name] = p
+	}
+}
+
+// Plans returns the defined plans in the order they were defined
+func (b *Billing) Plans() []Plan {
+	plans := make([]Plan, 0, len(b.order))
+	for _, name := range b.order {
+		plans = append(plans, b.plans[name])
+	}
+
+	return plans
+}
+
+// Plan returns a plan by name
+func (b *Billing) Plan(name string) (Plan, bool) {
+	p, ok := b.plans[name]
+	return p, ok
+}
+
+// Subscribe creates a checkout for plan; seats is ignored unless the plan is per seat
+func (b *Billing) Subscribe(ctx context.Context, userID int, email, plan string, seats int, successURL, cancelURL string) (*payments.Session, error) {
+	p, ok := b.plans[plan]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPlan, plan)
+	}
+
+	if !p.PerSeat || seats < 1 {
+		seats = 1
+	}
+
+	// a trial is offered once; users who subscribed before pay from the start
+	trial := p.TrialDays
+	if subs, err := b.Payments.Subscriptions(ctx, userID); err != nil {
+		return nil, err
+	} else if len(subs) > 0 {
+		trial = 0
+	}
+
+	return b.Payments.Checkout(ctx, payments.Checkout{
+		Subscription: true,
+		TrialDays:    trial,
+		Items:        []payments.Item{{Price: p.Price, Quantity: seats}},
+		UserID:       userID,
+		Email:        email,
+		SuccessURL:   successURL,
+		CancelURL:    cancelURL,
+		Metadata:     map[string]string{"plan": p.Name},
+	})
+}
+
+// Usable reports whether a subscription still grants access: while active or trialing,
+// past due within the grace period, or canceled before the end of the paid period
+func (b *Billing) Usable(s payments.Subscription) bool {
+	now := b.now()
+	end := s.PeriodEnd.Time
+
+	switch s.Status {
+	case "active", "trialing":
+		return true
+	case "past_due", "unpaid":
+		return s.PeriodEnd.Valid && now.Before(end.Add(b.Grace))
+	case "canceled":
+		return s.PeriodEnd.Valid && now.Before(end)
+	}
+
+	return false
+}
+
+// Subscription returns the usable subscription of a user to one of plans, or to any plan
+func (b *Billing) Subscription(ctx context.Context, userID int, plans ...string) (*payments.Subscription, error) {
+	subs, err := b.Payments.Subscriptions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range subs {
+		if !b.Usable(s) {
+			continue
+		}
+		if len(plans) == 0 {
+			return &s, nil
+		}
+		for _, name := range plans {
+			if p, ok := b.plans[name]; ok && p.Price == s.Price {
+				return &s, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// Subscribed reports whether a user has a usable subscription to one of plans
+func (b *Billing) Subscribed(ctx context.Context, userID int, plans ...string) (bool, error) {
+	s, err := b.Subscription(ctx, userID, plans...)
+	return s != nil, err
+}
+
+// OnTrial reports whether the user's subscription is still in its trial
+func (b *Billing) OnTrial(ctx context.Context, userID int) (bool, error) {
+	s, err := b.Subscription(ctx, userID)
+	return s != nil && s.Status == "trialing", err
+}
+
+// SetSeats changes the quantity of a user's per seat subscription
+func (b *Billing) SetSeats(ctx context.Context, userID, seats int) error {
+	s, err := b.Subscription(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		return ErrNoSubscription
+	}
+
+	if err := b.Provider.UpdateQuantity(ctx, s.ID, seats); err != nil {
+		return err
+	}
+
+	return b.sync(ctx, s.ID)
+}
+
+// Cancel cancels a user's subscription at the end of the paid period, or immediately
+func (b *Billing) Cancel(ctx context.Context, userID int, now bool) error {
+	s, err := b.Subscription(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		return ErrNoSubscription
+	}
+
+	if err := b.Provider.Cancel(ctx, s.ID, !now); err != nil {
+		return err
+	}
+
+	return b.sync(ctx, s.ID)
+}
+
+// Invoices lists a user's invoices from the provider
+func (b *Billing) Invoices(ctx context.Context, userID int) ([]payments.Invoice, error) {
+	customer, err := b.Payments.Customer(ctx, userID)
+	if err != nil || customer == "" {
+		return nil, err
+	}
+
+	return b.Provider.Invoices(ctx, customer)
+}
+
+// Sync refreshes every current subscription from the provider, catching webhooks that
+// never arrived; run it from the scheduler
+func (b *Billing) Sync(ctx context.Context) error {
+	subs, err := b.Payments.Current(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range subs {
+		if err := b.sync(ctx, s.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *Billing) sync(ctx context.Context, id string) error {
+	e, err := b.Provider.Subscription(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return b.Payments.Update(ctx, e)
+}
+
+// RequireSubscription lets through users with a usable subscription to one of plans
+// and redirects everyone else to BillingURL
+func (b *Billing) RequireSubscription(plans ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			userID := b.Session.GetInt(r.Context(), "userID")
+			if userID == 0 {
+				http.Redirect(rw, r, b.BillingURL, http.StatusSeeOther)
+				return
+			}
+
+			ok, err := b.Subscribed(r.Context(), userID, plans...)
+			if err != nil {
+				b.Payments.ErrorLog("billing:", err)
+				http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			if !ok {
+				http.Redirect(rw, r, b.BillingURL, http.StatusSeeOther)
+				return
+			}
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
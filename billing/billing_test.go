@@ -0,0 +1,54 @@
+package billing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/namnguyen191/goravel/payments"
+)
+
+func TestUsable(t *testing.T) {
+	b := New(nil, nil, nil)
+	now := time.Date(2021, 7, 10, 0, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	ended := func(d time.Duration) sql.NullTime { return sql.NullTime{Time: now.Add(d), Valid: true} }
+
+	tests := []struct {
+		name   string
+		sub    payments.Subscription
+		usable bool
+	}{
+		{"active", payments.Subscription{Status: "active"}, true},
+		{"trialing", payments.Subscription{Status: "trialing"}, true},
+		{"past due in grace", payments.Subscription{Status: "past_due", PeriodEnd: ended(-48 * time.Hour)}, true},
+		{"past due after grace", payments.Subscription{Status: "past_due", PeriodEnd: ended(-96 * time.Hour)}, false},
+		{"canceled in period", payments.Subscription{Status: "canceled", PeriodEnd: ended(time.Hour)}, true},
+		{"canceled after period", payments.Subscription{Status: "canceled", PeriodEnd: ended(-time.Hour)}, false},
+		{"incomplete", payments.Subscription{Status: "incomplete"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := b.Usable(tt.sub); got != tt.usable {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.usable, got)
+		}
+	}
+}
+
+func TestPlans(t *testing.T) {
+	b := New(nil, nil, nil)
+	b.Define(Plan{Name: "basic", Price: "price_1"}, Plan{Name: "pro", Price: "price_2", PerSeat: true})
+	b.Define(Plan{Name: "basic", Price: "price_3"})
+
+	plans := b.Plans()
+	if len(plans) != 2 || plans[0].Price != "price_3" || plans[1].Name != "pro" {
+		t.Errorf("unexpected plans %v", plans)
+	}
+
+	if _, err := b.Subscribe(context.Background(), 1, "", "enterprise", 1, "", ""); !errors.Is(err, ErrUnknownPlan) {
+		t.Error("expected unknown plan, got", err)
+	}
+}
@@ -0,0 +1,630 @@
+package goravel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/CloudyKit/jet/v6"
+	"github.com/go-chi/chi/v5"
+	"github.com/gomodule/redigo/redis"
+	"github.com/namnguyen191/goravel/activity"
+	"github.com/namnguyen191/goravel/admin"
+	"github.com/namnguyen191/goravel/announcements"
+	"github.com/namnguyen191/goravel/boot"
+	"github.com/namnguyen191/goravel/cache"
+	"github.com/namnguyen191/goravel/cart"
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/env"
+	"github.com/namnguyen191/goravel/experiments"
+	"github.com/namnguyen191/goravel/exports"
+	"github.com/namnguyen191/goravel/invoices"
+	"github.com/namnguyen191/goravel/leader"
+	"github.com/namnguyen191/goravel/links"
+	"github.com/namnguyen191/goravel/media"
+	"github.com/namnguyen191/goravel/navigation"
+	"github.com/namnguyen191/goravel/qrcode"
+	"github.com/namnguyen191/goravel/session"
+	"github.com/namnguyen191/goravel/settings"
+	"github.com/namnguyen191/goravel/tags"
+	"github.com/namnguyen191/goravel/teams"
+	"github.com/namnguyen191/goravel/tokens"
+	"github.com/namnguyen191/goravel/urlsigner"
+	"github.com/robfig/cron/v3"
+)
+
+// step wraps a function without a context as a boot step
+func step(name string, fn func() error) boot.Step {
+	return boot.Step{Name: name, Run: func(ctx context.Context) error { return fn() }}
+}
+
+// after sets the steps a step runs after
+func after(s boot.Step, names ...string) boot.Step {
+	s.After = append(s.After, names...)
+	return s
+}
+
+// requires sets the steps which must be ready before a step runs
+func requires(s boot.Step, names ...string) boot.Step {
+	s.Requires = append(s.Requires, names...)
+	return s
+}
+
+// when only runs a step while enabled returns true
+func when(s boot.Step, enabled func() bool) boot.Step {
+	s.Enabled = enabled
+	return s
+}
+
+// waiting retries a step connecting to a server for up to BOOT_WAIT, e.g. 30s while the
+// database container starts, trying again every BOOT_RETRY_DELAY
+func (grv *Goravel) waiting(s boot.Step) boot.Step {
+	wait := grv.Env.Duration("BOOT_WAIT", 0)
+	s.RetryDelay = grv.Env.Duration("BOOT_RETRY_DELAY", 2*time.Second)
+	if wait > 0 && s.RetryDelay > 0 {
+		s.Retries = int(wait / s.RetryDelay)
+	}
+	return s
+}
+
+// bootSteps are the subsystems started by New, changed by its options before they run
+func (grv *Goravel) bootSteps() []boot.Step {
+	return []boot.Step{
+		step("config", grv.bootConfig),
+		when(grv.waiting(step("db", grv.bootDB)), func() bool { return os.Getenv("DATABASE_TYPE") != "" }),
+		requires(step("clock", grv.bootClock), "config"),
+		requires(step("scheduler", grv.bootScheduler), "clock"),
+		grv.redisStep(),
+		when(after(requires(step("cache", grv.bootCache), "config", "scheduler", "clock"), "redis"), func() bool {
+			return os.Getenv("CACHE") != "" || os.Getenv("SESSION_TYPE") == "redis"
+		}),
+		requires(step("kv", grv.bootKV), "scheduler"),
+		after(requires(step("jobs", grv.bootJobs), "config", "clock"), "redis", "kv"),
+		requires(step("mail", grv.bootMail), "config"),
+		after(requires(step("capabilities", grv.checkCapabilities), "config"), "db", "redis", "cache"),
+		after(requires(step("leader", grv.bootLeader), "scheduler", "capabilities"), "db", "redis"),
+		after(requires(step("pagecache", grv.bootPageCache), "config", "clock"), "cache"),
+		after(requires(step("analytics", grv.createAnalytics), "scheduler"), "db", "redis"),
+		step("clientinfo", grv.bootClientInfo),
+		after(requires(step("sessions", grv.bootSessions), "config", "capabilities", "clock"), "db", "redis"),
+		// the middleware of the router captures the session manager, so it comes first
+		after(requires(step("routes", grv.bootRoutes), "config", "sessions", "clientinfo"), "analytics", "pagecache"),
+		requires(step("views", grv.bootViews), "sessions"),
+		after(requires(step("templates", grv.bootTemplates), "views", "clientinfo"), "analytics", "routes"),
+		after(requires(step("commerce", grv.bootCommerce), "sessions"), "db"),
+		after(requires(step("auth", grv.bootAuth), "config", "sessions"), "db", "cache"),
+		requires(step("inbound", grv.bootInbound), "config", "clock"),
+		requires(step("sms", grv.bootSMS), "config", "clock"),
+		requires(step("filesystems", grv.bootFileSystems), "config"),
+		after(requires(step("tokens", grv.bootTokens), "config", "clock"), "cache"),
+		when(after(requires(step("models", grv.bootModels), "db", "views", "auth"), "analytics", "cache", "tokens", "redis", "scheduler", "jobs"), func() bool { return grv.DB.Pool != nil }),
+		requires(step("backups", grv.scheduleBackups), "scheduler", "filesystems"),
+		after(requires(step("maintenance", grv.scheduleMaintenance), "scheduler"), "models", "jobs"),
+		after(requires(step("monitor", grv.startMonitor), "scheduler"), "db", "redis"),
+		after(step("container", grv.bootContainer), "models", "cache", "mail", "views", "jobs"),
+		after(step("workers", grv.bootWorkers), "mail", "sms"),
+	}
+}
+
+// logBootReport logs how each subsystem started
+func (grv *Goravel) logBootReport() {
+	var b bytes.Buffer
+	_ = grv.Boot.WriteReport(&b)
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		grv.InfoLog.Println("boot:", line)
+	}
+}
+
+func (grv *Goravel) bootConfig() error {
+	grv.config = config{
+		port:     os.Getenv("PORT"),
+		renderer: os.Getenv("RENDERER"),
+		cookie: cookieConfig{
+			name:     os.Getenv("COOKIE_NAME"),
+			lifetime: os.Getenv("COOKIE_LIFETIME"),
+			persist:  env.Get("COOKIE_PERSIST"),
+			secure:   grv.cookieSecure(),
+			domain:   os.Getenv("COOKIE_DOMAIN"),
+		},
+		sessionType: os.Getenv("SESSION_TYPE"),
+		database: databaseConfig{
+			database: os.Getenv("DATABASE_TYPE"),
+			dsn:      grv.BuildDSN(),
+		},
+		redis: redisConfig{
+			host:      os.Getenv("REDIS_HOST"),
+			password:  os.Getenv("REDIS_PASSWORD"),
+			failover:  strings.ToLower(os.Getenv("REDIS_FAILOVER")) != "false",
+			sentinels: grv.envList("REDIS_SENTINELS"),
+			cluster:   grv.envList("REDIS_CLUSTER_NODES"),
+		},
+		router: routerConfig{
+			trailingSlash: grv.routerTrailingSlash(),
+			autoHead:      grv.routerAutoHead(),
+		},
+	}
+
+	secure := true
+	if strings.ToLower(os.Getenv("SECURE")) == "false" {
+		secure = false
+	}
+
+	grv.Server = Server{
+		ServerName: env.Get("SERVER_NAME"),
+		Port:       os.Getenv("PORT"),
+		Secure:     secure,
+		URL:        os.Getenv("APP_URL"),
+	}
+
+	grv.EncryptionKey = os.Getenv("KEY")
+
+	return nil
+}
+
+func (grv *Goravel) bootDB() error {
+	db, err := grv.OpenDB(os.Getenv("DATABASE_TYPE"), grv.BuildDSN())
+	if err != nil {
+		return fmt.Errorf("database: %w", err)
+	}
+
+	grv.DB = Database{
+		DataBaseType: os.Getenv("DATABASE_TYPE"),
+		Pool:         db,
+	}
+
+	return nil
+}
+
+func (grv *Goravel) bootScheduler() error {
+	// jobs only run on the instance elected by createLeader, unless added with leader.Everywhere
+	grv.Scheduler = cron.New(cron.WithLocation(clock.Location(grv.Clock)), cron.WithChain(leader.Only(grv.leading)))
+	return nil
+}
+
+// redisStep connects to redis for the cache or sessions, waiting for it like the database.
+// With REDIS_FAILOVER the app starts without it, and the cache and sessions use their fallbacks.
+func (grv *Goravel) redisStep() boot.Step {
+	s := grv.waiting(boot.Step{
+		Name: "redis",
+		Run: func(ctx context.Context) error {
+			if myRedisCache == nil {
+				c, err := grv.createClientRedisCache()
+				if err != nil {
+					return err
+				}
+				myRedisCache = c
+				redisPool = myRedisCache.Conn
+			}
+
+			conn, err := redisPool.GetContext(ctx)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			_, err = redis.DoContext(conn, ctx, "PING")
+			return err
+		},
+		Enabled: func() bool {
+			return os.Getenv("CACHE") == "redis" || os.Getenv("SESSION_TYPE") == "redis"
+		},
+		Optional: strings.ToLower(os.Getenv("REDIS_FAILOVER")) != "false",
+	})
+	s.Requires = []string{"config"}
+	return s
+}
+
+// bootCache uses redis with CACHE=redis, and also when only the sessions are kept in redis;
+// other values of CACHE name a driver registered with cache.Register
+func (grv *Goravel) bootCache() error {
+	switch driver := os.Getenv("CACHE"); driver {
+	case "", "redis":
+		if myRedisCache == nil {
+			return nil
+		}
+		grv.Cache = myRedisCache
+		if os.Getenv("CACHE") == "redis" && grv.config.redis.failover {
+			failover := cache.NewFailover(myRedisCache, myRedisCache.Ping)
+			failover.OnChange = grv.logFailover("cache")
+			failover.Clock = grv.Clock
+			grv.Cache = failover
+		}
+	case "badger":
+		var err error
+		myBadgerCache, err = grv.createClientBadgerCache()
+		if err != nil {
+			return fmt.Errorf("cache: badger: %w", err)
+		}
+		grv.Cache = myBadgerCache
+		badgerConn = myBadgerCache.Conn
+
+		_, err = grv.Scheduler.AddJob("@daily", leader.Everywhere(func() {
+			myBadgerCache.Conn.RunValueLogGC(0.7)
+		}))
+		if err != nil {
+			return err
+		}
+	case "memcached":
+		store := cache.NewMemcached(grv.memcachedServers()...)
+		store.Timeout = grv.Env.Duration("MEMCACHED_TIMEOUT", time.Second)
+		store.MaxIdle = grv.Env.Int("MEMCACHED_MAX_IDLE", 2)
+		grv.Cache = cache.NewDriverCache(store, grv.Namespace)
+	case "memory":
+		store := cache.NewLRU(grv.Env.Int("CACHE_MEMORY_ITEMS", 10000))
+		store.Clock = grv.Clock
+		grv.Cache = cache.NewDriverCache(store, grv.Namespace)
+	default:
+		c, err := cache.Open(driver, grv.Namespace, cache.Config{Namespace: grv.Namespace})
+		if err != nil {
+			return err
+		}
+		grv.Cache = c
+	}
+
+	return nil
+}
+
+// memcachedServers splits MEMCACHED_SERVERS, a comma separated list of addresses
+func (grv *Goravel) memcachedServers() []string {
+	return grv.envList("MEMCACHED_SERVERS")
+}
+
+// envList splits the comma separated values of the variable name, leaving out empty ones
+func (grv *Goravel) envList(name string) []string {
+	var values []string
+	for _, v := range strings.Split(grv.Env.String(name, ""), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func (grv *Goravel) bootKV() (err error) {
+	grv.KV, err = grv.createKV()
+	return err
+}
+
+func (grv *Goravel) bootJobs() (err error) {
+	grv.Jobs, err = grv.createJobs()
+	return err
+}
+
+func (grv *Goravel) bootMail() (err error) {
+	if grv.Mail, err = grv.createMailer(); err != nil {
+		return err
+	}
+	if grv.Env.Bool("MAIL_VERIFY", false) {
+		if err := grv.Mail.Verify(); err != nil {
+			grv.ErrorLog.Println("mail:", err)
+		}
+	}
+	return nil
+}
+
+func (grv *Goravel) bootLeader() error {
+	grv.createLeader()
+	return nil
+}
+
+func (grv *Goravel) bootPageCache() error {
+	// public pages are cached by adding grv.PageCache.Middleware to their routes
+	grv.PageCache = grv.createPageCache()
+	return nil
+}
+
+func (grv *Goravel) bootClientInfo() (err error) {
+	grv.ClientInfo, err = grv.createClientInfo()
+	return err
+}
+
+func (grv *Goravel) bootSessions() error {
+	// create a Session
+	sess := session.Session{
+		CookieLifeTime: grv.config.cookie.lifetime,
+		CookiePersist:  grv.config.cookie.persist,
+		CookieName:     grv.config.cookie.name,
+		SessionType:    grv.config.sessionType,
+		CookieDomain:   grv.config.cookie.domain,
+		CookieSecure:   grv.config.cookie.secure,
+	}
+
+	switch grv.config.sessionType {
+	case "redis":
+		{
+			sess.RedisPool = myRedisCache.Conn
+			sess.Prefix = grv.Key("session") + ":"
+			sess.Failover = grv.config.redis.failover
+			sess.OnFailover = grv.logFailover("session")
+			sess.Clock = grv.Clock
+			sess.Secret = grv.EncryptionKey
+		}
+	case "mysql", "postgres", "mariadb", "postgresql":
+		{
+			sess.DBPool = grv.DB.Pool
+		}
+	default:
+		if !session.Builtin(grv.config.sessionType) {
+			store, err := session.Open(grv.config.sessionType, session.Config{
+				DBPool:    grv.DB.Pool,
+				RedisPool: redisPool,
+				Prefix:    grv.Key("session") + ":",
+				Lifetime:  time.Duration(grv.Env.Int("COOKIE_LIFETIME", 60)) * time.Minute,
+			})
+			if err != nil {
+				return err
+			}
+			sess.Store = store
+		}
+	}
+
+	grv.Session = sess.InitSession()
+
+	return nil
+}
+
+func (grv *Goravel) bootRoutes() error {
+	grv.BotGuard = grv.createBotGuard()
+	grv.LiveReload = grv.createLiveReload()
+	grv.meta = grv.createMeta()
+	grv.Canonical = grv.createCanonical()
+	grv.Public = grv.createPublic()
+	grv.Routes = grv.routes().(*chi.Mux)
+	return nil
+}
+
+func (grv *Goravel) bootViews() error {
+	if grv.Debug {
+		var views = jet.NewSet(
+			jet.NewOSFileSystemLoader(fmt.Sprintf("%s/views", grv.RootPath)),
+			jet.InDevelopmentMode(),
+		)
+
+		grv.JetViews = views
+	} else {
+		var views = jet.NewSet(
+			jet.NewOSFileSystemLoader(fmt.Sprintf("%s/views", grv.RootPath)),
+		)
+
+		grv.JetViews = views
+	}
+
+	grv.createRenderer()
+
+	return nil
+}
+
+func (grv *Goravel) bootTemplates() error {
+	grv.Navigation = navigation.New()
+	grv.Render.AddFuncs(grv.Navigation.TemplateFuncs)
+
+	grv.CDN = grv.createCDN()
+	grv.Render.AddFuncs(grv.CDN.TemplateFuncs)
+	if grv.meta != nil {
+		grv.Render.AddFuncs(grv.meta.TemplateFuncs)
+	}
+
+	grv.Assets = grv.createAssets()
+	if grv.Assets != nil {
+		grv.Render.AddFuncs(grv.Assets.TemplateFuncs)
+	}
+	grv.Render.AddFuncs(grv.ClientInfo.TemplateFuncs)
+	grv.Render.AddFuncs(qrcode.TemplateFuncs)
+
+	grv.Experiments = experiments.New(grv.Session, grv.Analytics)
+	grv.Render.AddFuncs(grv.Experiments.TemplateFuncs)
+
+	return nil
+}
+
+func (grv *Goravel) bootCommerce() error {
+	// carts live in the session until login, where the app calls grv.Cart.Merge
+	currency := os.Getenv("CART_CURRENCY")
+	if currency == "" {
+		currency = "USD"
+	}
+	grv.Cart = cart.New(grv.Session, grv.DB.Pool, grv.DB.DataBaseType, strings.ToUpper(currency))
+	grv.Cart.Clock = grv.Clock
+
+	return nil
+}
+
+func (grv *Goravel) bootInbound() error {
+	// inbound mail is handed to grv.Inbound.Handle; the app mounts the webhooks it uses under /api,
+	// e.g. Routes.Post("/api/inbound/mailgun", grv.Inbound.Mailgun)
+	grv.Inbound = grv.createInbound()
+	return nil
+}
+
+func (grv *Goravel) bootAuth() error {
+	// the login form posts to a route the app mounts, e.g. Routes.Post("/users/login", grv.Auth.LoginHandler);
+	// grv.Auth.Remember goes after the session middleware and grv.Auth.Require on the protected routes
+	var err error
+	grv.Auth, err = grv.createAuth()
+	return err
+}
+
+func (grv *Goravel) bootSMS() error {
+	// sms is only set up with SMS_DRIVER; delivery reports are posted to a route the app mounts
+	// under /api, e.g. Routes.Post("/api/sms/status", grv.SMS.StatusHandler)
+	grv.SMS = grv.createSMS()
+	return nil
+}
+
+func (grv *Goravel) bootFileSystems() error {
+	// uploads go through grv.UploadFile(r, "avatar", "public", "avatars"), other files straight to
+	// a file system, e.g. grv.FileSystems["s3"].Put(ctx, "reports/may.pdf", f)
+	var err error
+	grv.FileSystems, err = grv.createFileSystems()
+	return err
+}
+
+func (grv *Goravel) bootTokens() error {
+	// links sent to users carry grv.Tokens.Sign(tokens.Invite, email, 72*time.Hour), checked by the
+	// route they open with grv.Tokens.Consume, which needs the cache to turn down replays; without
+	// KEY anyone could sign them
+	if grv.EncryptionKey == "" {
+		return nil
+	}
+	grv.Tokens = tokens.New([]byte(grv.EncryptionKey), grv.Cache)
+	grv.Tokens.Clock = grv.Clock
+	return nil
+}
+
+// bootModels sets up the features keeping their data in the database
+func (grv *Goravel) bootModels() error {
+	var err error
+
+	// the admin panel is only available with a database; apps mount it with Routes.Mount("/admin", grv.Admin.Routes())
+	// and let their administrators in with grv.Admin.Authorize, nobody being let in without it
+	grv.Admin = admin.New(grv.DB.Pool, grv.DB.DataBaseType, grv.Session)
+	grv.Admin.Render = grv.Render
+	grv.Admin.ErrorLog = grv.ErrorLog.Println
+	grv.Admin.LoginURL = grv.Auth.LoginURL
+	grv.Admin.Clock = grv.Clock
+
+	grv.Settings = settings.New(grv.DB.Pool, grv.DB.DataBaseType, grv.Cache)
+	grv.Settings.Clock = grv.Clock
+	if res, err := grv.Admin.Register(&settings.Setting{}); err == nil {
+		res.AfterSave = grv.Settings.Flush
+	}
+
+	grv.Activity = activity.New(grv.DB.Pool, grv.DB.DataBaseType, grv.Cache)
+	grv.Activity.Clock = grv.Clock
+
+	grv.Workflows = grv.createWorkflows()
+
+	// exports are generated by jobs of the queue and kept in tmp/exports, so maintenance clears
+	// them once their links have expired;
+	// downloads are served by a route the app mounts with Routes.Get("/exports/{id}", grv.Exports.DownloadHandler)
+	grv.Exports = exports.New(grv.DB.Pool, grv.DB.DataBaseType, &exports.Local{Dir: grv.RootPath + "/tmp/exports"},
+		&urlsigner.Signer{Secret: []byte(grv.EncryptionKey), Clock: grv.Clock}, grv.Server.URL)
+	grv.Exports.ErrorLog = grv.ErrorLog.Println
+	grv.Exports.Clock = grv.Clock
+	grv.Exports.Dispatch = grv.pushID(exports.Job)
+	grv.handleID(exports.Job, grv.Exports.Generate)
+
+	// retention policies are enforced with the maintenance tasks; user data is exported with
+	// grv.Privacy.Export and accounts erased with grv.Privacy.Erase
+	grv.Privacy = grv.createPrivacy()
+
+	// apps fan out notifications by setting grv.Comments.Notify
+	grv.Comments = grv.createComments()
+
+	grv.Tags = tags.New(grv.DB.Pool, grv.DB.DataBaseType, tags.Tags)
+	grv.Categories = tags.New(grv.DB.Pool, grv.DB.DataBaseType, tags.Categories)
+	grv.Tags.Clock = grv.Clock
+	grv.Categories.Clock = grv.Clock
+
+	// media files are kept in public/media; collections and conversions are set up with grv.Media.Define
+	grv.Media = media.New(grv.DB.Pool, grv.DB.DataBaseType, &media.Local{Dir: grv.RootPath + "/public/media", BaseURL: "/public/media"})
+	grv.Media.Clock = grv.Clock
+	grv.Render.AddFuncs(grv.Media.TemplateFuncs)
+
+	// short links redirect from a route the app mounts, e.g. Routes.Get("/l/{code}", grv.Links.RedirectHandler);
+	// LINKS_URL is the public base of that route
+	linksURL := os.Getenv("LINKS_URL")
+	if linksURL == "" {
+		linksURL = grv.Server.URL + "/l"
+	}
+	grv.Links = links.New(grv.DB.Pool, grv.DB.DataBaseType, linksURL)
+	grv.Links.Analytics = grv.Analytics
+	grv.Links.ErrorLog = grv.ErrorLog.Println
+	grv.Links.Clock = grv.Clock
+
+	// reports are defined by the app and mailed from the scheduler, e.g.
+	// grv.Schedule().Weekly().At("07:00").Func(grv.Reports.Job("weekly-sales"))
+	grv.Reports = grv.createReports()
+
+	// invitations are sent with grv.Invitations.Create and accepted on routes the app mounts, e.g.
+	// Routes.Get("/invitations/accept", grv.Invitations.AcceptPage) and
+	// Routes.Post("/invitations/accept", grv.Invitations.AcceptHandler)
+	grv.Invitations = grv.createInvitations()
+
+	// invoice and receipt templates can be overridden in views/invoices
+	grv.Invoices = invoices.New(grv.DB.Pool, grv.DB.DataBaseType, grv.RootPath+"/views/invoices")
+	grv.Invoices.Clock = grv.Clock
+
+	// payment webhooks are posted to a route the app mounts under the CSRF-exempt /api prefix,
+	// e.g. Routes.Post("/api/webhooks/payments", grv.Payments.WebhookHandler)
+	grv.Payments = grv.createPayments()
+
+	// browsers subscribe with the vapidPublicKey template func and post the subscription to a route
+	// the app mounts, e.g. Routes.Post("/push/subscribe", grv.Push.SubscribeHandler)
+	grv.Push, err = grv.createPush()
+	if err != nil {
+		return err
+	}
+	if grv.Push != nil {
+		grv.Render.AddFuncs(grv.Push.TemplateFuncs)
+		go grv.Push.ListenForPush()
+	}
+
+	// routes are gated on plans with grv.Billing.RequireSubscription("pro")
+	if err := grv.createBilling(); err != nil {
+		return err
+	}
+
+	// announcements are added to every page as .Data.announcements; dismissals are posted to
+	// a route the app mounts with Routes.Post("/announcements/{id}/dismiss", grv.Announcements.DismissHandler)
+	if strings.ToLower(os.Getenv("ANNOUNCEMENTS")) == "true" {
+		grv.Announcements = announcements.New(grv.DB.Pool, grv.DB.DataBaseType, grv.Session)
+		grv.Announcements.Clock = grv.Clock
+		grv.Render.AddData(grv.Announcements.TemplateData)
+		if res, err := grv.Admin.Register(&announcements.Announcement{}); err == nil {
+			res.AfterSave = grv.Announcements.Flush
+		}
+	}
+
+	// the current team is added to every page as .Data.team; routes of a team are gated with
+	// grv.Teams.Require(teams.Admin), and switching teams is posted to a route the app mounts with
+	// Routes.Post("/teams/{id}/switch", grv.Teams.SwitchHandler)
+	if strings.ToLower(os.Getenv("TEAMS")) == "true" {
+		grv.Teams = teams.New(grv.DB.Pool, grv.DB.DataBaseType, grv.Session, grv.Auth)
+		grv.Teams.Invitations = grv.Invitations
+		grv.Teams.Clock = grv.Clock
+		if grv.Invitations.OnAccept == nil {
+			grv.Invitations.OnAccept = grv.Teams.Accepted
+		}
+		grv.Render.AddData(grv.Teams.TemplateData)
+	}
+
+	// with APIKEYS, api keys are issued to integrations with grv.APIKeys.Issue, whose signed requests are let
+	// through on routes the app mounts under the CSRF-exempt /api prefix, e.g.
+	// Routes.With(grv.APIKeys.Authenticate, apikeys.RequireScope("orders:read")).Get("/api/orders", ...)
+	grv.APIKeys, err = grv.createAPIKeys()
+	if err != nil {
+		return err
+	}
+
+	// as an OpenID Connect provider, the app answers on routes it mounts: the discovery and
+	// keys with Routes.Get("/.well-known/openid-configuration", grv.OIDC.DiscoveryHandler) and
+	// Routes.Get("/.well-known/jwks.json", grv.OIDC.JWKSHandler), the authorization and consent
+	// with Routes.With(grv.Auth.Require).Get("/oauth/authorize", grv.OIDC.AuthorizeHandler) and
+	// .Post("/oauth/authorize", grv.OIDC.ConsentHandler), and the clients call
+	// Routes.Post("/api/oauth/token", grv.OIDC.TokenHandler) and Routes.Get("/api/oauth/userinfo", grv.OIDC.UserInfoHandler)
+	grv.OIDC, err = grv.createOIDC()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (grv *Goravel) bootContainer() error {
+	grv.Container = grv.createContainer()
+	return nil
+}
+
+func (grv *Goravel) bootWorkers() error {
+	if grv.Mail.Jobs != nil {
+		go grv.Mail.ListenForMail()
+	}
+	if grv.SMS != nil {
+		go grv.SMS.ListenForSMS()
+	}
+	return nil
+}
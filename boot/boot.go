@@ -0,0 +1,279 @@
+package boot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// States of a step
+const (
+	Pending = "pending"
+	Ready   = "ready"
+	Skipped = "skipped"
+	Failed  = "failed"
+)
+
+// Step initializes one subsystem
+type Step struct {
+	Name string
+	// After are steps which run first when they exist; their outcome does not matter
+	After []string
+	// Requires are steps which must be ready first; "cache|db" is satisfied by either
+	Requires []string
+	// Enabled decides whether the step runs at all; nil means always
+	Enabled func() bool
+	// Optional steps may fail without stopping the boot, e.g. redis when the cache falls
+	// back to memory; the steps requiring them are skipped
+	Optional bool
+	// Retries is how many more attempts are made when Run fails, RetryDelay apart, for
+	// dependencies such as a database still starting up
+	Retries    int
+	RetryDelay time.Duration
+	Run        func(ctx context.Context) error
+}
+
+// Status is the outcome of a step
+type Status struct {
+	Name     string
+	State    string
+	Attempts int
+	Duration time.Duration
+	Err      error
+}
+
+// Boot runs steps in the order their dependencies need, then reports how each went
+type Boot struct {
+	steps  []*Step
+	status map[string]*Status
+	order  []string
+}
+
+// New returns an empty boot sequence
+func New() *Boot {
+	return &Boot{status: map[string]*Status{}}
+}
+
+// Add appends steps; steps without dependencies between them run in the order they were added
+func (b *Boot) Add(steps ...Step) {
+	for i := range steps {
+		s := steps[i]
+		b.steps = append(b.steps, &s)
+	}
+}
+
+// Replace swaps the step of the same name, e.g. to boot a subsystem differently
+func (b *Boot) Replace(step Step) {
+	for i, s := range b.steps {
+		if s.Name == step.Name {
+			b.steps[i] = &step
+			return
+		}
+	}
+	b.Add(step)
+}
+
+// Remove drops steps, along with the steps requiring them which are left without any of
+// their alternatives
+func (b *Boot) Remove(names ...string) {
+	removed := map[string]bool{}
+	for _, name := range names {
+		removed[name] = true
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, s := range b.steps {
+			if removed[s.Name] {
+				continue
+			}
+			for _, r := range s.Requires {
+				left := false
+				for _, name := range strings.Split(r, "|") {
+					if !removed[name] {
+						left = true
+					}
+				}
+				if !left {
+					removed[s.Name] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	kept := b.steps[:0]
+	for _, s := range b.steps {
+		if !removed[s.Name] {
+			kept = append(kept, s)
+		}
+	}
+	b.steps = kept
+}
+
+// Order returns the steps in the order they run, or an error for a dependency cycle
+func (b *Boot) Order() ([]*Step, error) {
+	byName := map[string]*Step{}
+	for _, s := range b.steps {
+		byName[s.Name] = s
+	}
+
+	var order []*Step
+	state := map[string]int{} // 1 visiting, 2 done
+	var visit func(s *Step, path []string) error
+	visit = func(s *Step, path []string) error {
+		switch state[s.Name] {
+		case 1:
+			return fmt.Errorf("boot: dependency cycle %s -> %s", strings.Join(path, " -> "), s.Name)
+		case 2:
+			return nil
+		}
+		state[s.Name] = 1
+		path = append(path, s.Name)
+
+		// requirements which are not steps are reported by Run
+		deps := append([]string{}, s.After...)
+		for _, r := range s.Requires {
+			deps = append(deps, strings.Split(r, "|")...)
+		}
+
+		for _, name := range deps {
+			if dep := byName[name]; dep != nil {
+				if err := visit(dep, path); err != nil {
+					return err
+				}
+			}
+		}
+
+		state[s.Name] = 2
+		order = append(order, s)
+		return nil
+	}
+
+	for _, s := range b.steps {
+		if err := visit(s, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// Run runs the steps, stopping at the first required step which fails
+func (b *Boot) Run(ctx context.Context) error {
+	steps, err := b.Order()
+	if err != nil {
+		return err
+	}
+
+	b.status = map[string]*Status{}
+	b.order = b.order[:0]
+	for _, s := range steps {
+		b.status[s.Name] = &Status{Name: s.Name, State: Pending}
+		b.order = append(b.order, s.Name)
+	}
+
+	for _, s := range steps {
+		st := b.status[s.Name]
+
+		if s.Enabled != nil && !s.Enabled() {
+			st.State = Skipped
+			continue
+		}
+
+		if missing := b.missing(s); missing != "" {
+			st.State = Skipped
+			st.Err = fmt.Errorf("needs %s", missing)
+			if !s.Optional {
+				st.State = Failed
+				return fmt.Errorf("boot: %s %w", s.Name, st.Err)
+			}
+			continue
+		}
+
+		if err := b.run(ctx, s, st); err != nil {
+			st.State = Failed
+			st.Err = err
+			if !s.Optional {
+				return fmt.Errorf("boot: %s: %w", s.Name, err)
+			}
+			continue
+		}
+		st.State = Ready
+	}
+
+	return nil
+}
+
+// missing names the requirements of s which are not ready
+func (b *Boot) missing(s *Step) string {
+	var missing []string
+	for _, r := range s.Requires {
+		ok := false
+		for _, name := range strings.Split(r, "|") {
+			if st := b.status[name]; st != nil && st.State == Ready {
+				ok = true
+			}
+		}
+		if !ok {
+			missing = append(missing, strings.ReplaceAll(r, "|", " or "))
+		}
+	}
+	return strings.Join(missing, " and ")
+}
+
+func (b *Boot) run(ctx context.Context, s *Step, st *Status) error {
+	start := time.Now()
+	defer func() { st.Duration = time.Since(start) }()
+
+	for {
+		st.Attempts++
+		err := s.Run(ctx)
+		if err == nil || st.Attempts > s.Retries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(s.RetryDelay):
+		}
+	}
+}
+
+// Ready reports whether the step of name ran successfully
+func (b *Boot) Ready(name string) bool {
+	st := b.status[name]
+	return st != nil && st.State == Ready
+}
+
+// Report returns the status of every step in the order they ran
+func (b *Boot) Report() []Status {
+	report := make([]Status, 0, len(b.order))
+	for _, name := range b.order {
+		report = append(report, *b.status[name])
+	}
+	return report
+}
+
+// WriteReport writes the readiness report as a table
+func (b *Boot) WriteReport(w io.Writer) error {
+	for _, st := range b.Report() {
+		line := fmt.Sprintf("%-14s %-8s", st.Name, st.State)
+		if st.State == Ready || st.State == Failed {
+			line += fmt.Sprintf(" %8s", st.Duration.Round(time.Millisecond))
+		}
+		if st.Attempts > 1 {
+			line += fmt.Sprintf(" after %d attempts", st.Attempts)
+		}
+		if st.Err != nil {
+			line += " " + st.Err.Error()
+		}
+		if _, err := fmt.Fprintln(w, strings.TrimRight(line, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
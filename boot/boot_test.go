@@ -0,0 +1,112 @@
+package boot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func run(name string, ran *[]string, err error) Step {
+	return Step{Name: name, Run: func(ctx context.Context) error {
+		*ran = append(*ran, name)
+		return err
+	}}
+}
+
+func TestOrder(t *testing.T) {
+	var ran []string
+
+	sessions := run("sessions", &ran, nil)
+	sessions.Requires = []string{"cache|db"}
+	cache := run("cache", &ran, nil)
+	cache.After = []string{"redis"}
+	db := run("db", &ran, nil)
+	db.Enabled = func() bool { return false }
+
+	b := New()
+	b.Add(sessions, cache, db, run("redis", &ran, nil))
+	if err := b.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(ran, ",") != "redis,cache,sessions" {
+		t.Errorf("unexpected order %v", ran)
+	}
+	if !b.Ready("sessions") || b.Ready("db") {
+		t.Error("expected sessions to be ready and db skipped")
+	}
+}
+
+func TestFailures(t *testing.T) {
+	var ran []string
+	boom := errors.New("connection refused")
+
+	redis := run("redis", &ran, boom)
+	redis.Optional = true
+	cache := run("cache", &ran, nil)
+	cache.Requires = []string{"redis"}
+	cache.Optional = true
+	db := run("db", &ran, boom)
+	db.Retries = 2
+
+	b := New()
+	b.Add(redis, cache, db, run("models", &ran, nil))
+	err := b.Run(context.Background())
+	if !errors.Is(err, boom) || !strings.Contains(err.Error(), "db") {
+		t.Errorf("expected the db error, got %v", err)
+	}
+
+	report := b.Report()
+	if report[0].State != Failed || report[1].State != Skipped || report[2].Attempts != 3 || report[3].State != Pending {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	var out bytes.Buffer
+	_ = b.WriteReport(&out)
+	if !strings.Contains(out.String(), "after 3 attempts connection refused") {
+		t.Errorf("expected the attempts in the report, got\n%s", out.String())
+	}
+
+	// a required step whose requirement failed stops the boot
+	b = New()
+	cache.Optional = false
+	b.Add(redis, cache)
+	if err := b.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "cache needs redis") {
+		t.Errorf("expected cache to fail, got %v", err)
+	}
+}
+
+func TestCycle(t *testing.T) {
+	var ran []string
+	a, c := run("a", &ran, nil), run("c", &ran, nil)
+	a.After = []string{"c"}
+	c.Requires = []string{"a"}
+
+	b := New()
+	b.Add(a, c)
+	if err := b.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected a cycle error, got %v", err)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	var ran []string
+	views := run("views", &ran, nil)
+	templates := run("templates", &ran, nil)
+	templates.Requires = []string{"views"}
+	sessions := run("sessions", &ran, nil)
+	sessions.Requires = []string{"cache|db"}
+
+	b := New()
+	b.Add(views, templates, run("cache", &ran, nil), run("db", &ran, nil), sessions)
+	b.Remove("views", "cache")
+	if err := b.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(ran, ",") != "db,sessions" {
+		t.Errorf("expected the dependants of views to be removed, ran %v", ran)
+	}
+}
@@ -0,0 +1,168 @@
+package bots
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kind is what a client was classified as
+type Kind int
+
+const (
+	// Human is any client without a bot signature
+	Human Kind = iota
+	// Crawler is a well behaved bot such as a search engine
+	Crawler
+	// Bad is a scraper, vulnerability scanner or other unwanted bot
+	Bad
+)
+
+type contextKey struct{}
+
+// Crawlers are user agent fragments of bots that are allowed but don't need sessions
+var Crawlers = []string{
+	"googlebot", "bingbot", "duckduckbot", "yandexbot", "baiduspider", "applebot",
+	"facebookexternalhit", "twitterbot", "linkedinbot", "slackbot", "discordbot",
+	"bot", "crawler", "spider", "preview",
+}
+
+// BadBots are user agent fragments of bots that are usually worth blocking
+var BadBots = []string{
+	"ahrefsbot", "semrushbot", "mj12bot", "dotbot", "petalbot", "bytespider", "blexbot",
+	"masscan", "zgrab", "nikto", "sqlmap", "nmap", "dirbuster", "gobuster", "wpscan",
+	"python-requests", "go-http-client", "curl/", "wget/", "libwww-perl", "scrapy", "httpclient",
+}
+
+// Detector classifies requests by user agent
+type Detector struct {
+	Crawlers []string
+	Bad      []string
+}
+
+// NewDetector returns a detector using the built-in signature lists
+func NewDetector() *Detector {
+	return &Detector{Crawlers: Crawlers, Bad: BadBots}
+}
+
+// Classify returns the kind of client that sent r
+func (d *Detector) Classify(r *http.Request) Kind {
+	ua := strings.ToLower(r.UserAgent())
+	if ua == "" {
+		return Bad
+	}
+
+	for _, s := range d.Bad {
+		if strings.Contains(ua, s) {
+			return Bad
+		}
+	}
+
+	for _, s := range d.Crawlers {
+		if strings.Contains(ua, s) {
+			return Crawler
+		}
+	}
+
+	return Human
+}
+
+// Guard tags bot traffic and optionally throttles or blocks it
+type Guard struct {
+	Detector *Detector
+	// Block rejects bad bots with 403
+	Block bool
+	// Limit is the number of requests a bot may send per Window before getting 429; 0 disables throttling
+	Limit  int
+	Window time.Duration
+
+	mu      sync.Mutex
+	counts  map[string]*window
+	cleaned time.Time
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+// NewGuard returns a guard that tags bots without blocking or throttling them
+func NewGuard() *Guard {
+	return &Guard{
+		Detector: NewDetector(),
+		Window:   time.Minute,
+		counts:   make(map[string]*window),
+	}
+}
+
+// Middleware classifies each request and stores the result in its context
+func (g *Guard) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		kind := g.Detector.Classify(r)
+
+		if kind == Bad && g.Block {
+			http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		if kind != Human && g.Limit > 0 && !g.allow(clientIP(r)) {
+			rw.Header().Set("Retry-After", "60")
+			http.Error(rw, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), contextKey{}, kind)))
+	})
+}
+
+// allow counts a request from ip in a fixed window and reports whether it is under the limit
+func (g *Guard) allow(ip string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(g.cleaned) > g.Window {
+		for k, w := range g.counts {
+			if now.Sub(w.start) > g.Window {
+				delete(g.counts, k)
+			}
+		}
+		g.cleaned = now
+	}
+
+	w, ok := g.counts[ip]
+	if !ok || now.Sub(w.start) > g.Window {
+		w = &window{start: now}
+		g.counts[ip] = w
+	}
+	w.count++
+
+	return w.count <= g.Limit
+}
+
+// KindOf returns the classification stored by the guard, classifying r itself when the guard didn't run
+func KindOf(r *http.Request) Kind {
+	if kind, ok := r.Context().Value(contextKey{}).(Kind); ok {
+		return kind
+	}
+
+	return NewDetector().Classify(r)
+}
+
+// IsBot reports whether r came from a crawler or a bad bot
+func IsBot(r *http.Request) bool {
+	return KindOf(r) != Human
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
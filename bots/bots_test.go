@@ -0,0 +1,66 @@
+package bots
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		ua   string
+		want Kind
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/115.0 Safari/537.36", Human},
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", Crawler},
+		{"Mozilla/5.0 (compatible; AhrefsBot/7.0; +http://ahrefs.com/robot/)", Bad},
+		{"sqlmap/1.5", Bad},
+		{"", Bad},
+	}
+
+	d := NewDetector()
+	for _, e := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("User-Agent", e.ua)
+		if got := d.Classify(r); got != e.want {
+			t.Errorf("%q: expected %d, got %d", e.ua, e.want, got)
+		}
+	}
+}
+
+func TestGuard(t *testing.T) {
+	g := NewGuard()
+	g.Limit = 2
+
+	var seen Kind
+	h := g.Middleware(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		seen = KindOf(r)
+	}))
+
+	request := func(ua string) int {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("User-Agent", ua)
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, r)
+		return rw.Code
+	}
+
+	if request("Googlebot/2.1") != http.StatusOK || seen != Crawler {
+		t.Error("expected crawler to be let through and tagged")
+	}
+	request("Googlebot/2.1")
+	if request("Googlebot/2.1") != http.StatusTooManyRequests {
+		t.Error("expected crawler over the limit to be throttled")
+	}
+
+	for i := 0; i < 5; i++ {
+		if request("Mozilla/5.0 Firefox/118.0") != http.StatusOK {
+			t.Fatal("humans must never be throttled")
+		}
+	}
+
+	g.Block = true
+	if request("sqlmap/1.5") != http.StatusForbidden {
+		t.Error("expected bad bot to be blocked")
+	}
+}
@@ -0,0 +1,226 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned without calling the wrapped function while the breaker is open
+var ErrOpen = errors.New("breaker: circuit open")
+
+// State is the state of a breaker
+type State int
+
+const (
+	// Closed lets every call through
+	Closed State = iota
+	// Open rejects every call until Timeout has passed
+	Open
+	// HalfOpen lets a limited number of probe calls through
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// Counts holds the counters of a breaker, meant to be exported as metrics
+type Counts struct {
+	State               State
+	Requests            uint64
+	Successes           uint64
+	Failures            uint64
+	Rejected            uint64
+	ConsecutiveFailures int
+}
+
+// Breaker stops calling a failing dependency for a while once it has failed Threshold times in a row
+type Breaker struct {
+	Name string
+	// Threshold is the number of consecutive failures that opens the circuit
+	Threshold int
+	// Timeout is how long the circuit stays open before probing again
+	Timeout time.Duration
+	// Probes is the number of successful calls needed in half-open state to close the circuit
+	Probes int
+	// IsFailure decides which errors count against the dependency; by default every error does
+	IsFailure func(err error) bool
+	// OnStateChange is called on every transition
+	OnStateChange func(name string, from, to State)
+
+	mu       sync.Mutex
+	counts   Counts
+	probes   int
+	inFlight int
+	openedAt time.Time
+}
+
+// New returns a breaker that opens after 5 consecutive failures for 30 seconds
+func New(name string) *Breaker {
+	return &Breaker{
+		Name:      name,
+		Threshold: 5,
+		Timeout:   30 * time.Second,
+		Probes:    1,
+	}
+}
+
+// Execute calls fn unless the circuit is open
+func (b *Breaker) Execute(fn func() error) error {
+	if err := b.before(); err != nil {
+		return err
+	}
+
+	err := fn()
+	b.after(err)
+
+	return err
+}
+
+// State returns the current state
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.current()
+}
+
+// Counts returns a snapshot of the counters
+func (b *Breaker) Counts() Counts {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	counts := b.counts
+	counts.State = b.current()
+
+	return counts
+}
+
+// Reset closes the circuit
+func (b *Breaker) Reset() {
+	b.mu.Lock()
+	b.counts.ConsecutiveFailures = 0
+	from := b.setState(Closed)
+	b.mu.Unlock()
+
+	b.notify(from, Closed)
+}
+
+func (b *Breaker) before() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	from := b.counts.State
+	b.expire()
+	if from != b.counts.State {
+		defer b.notify(from, b.counts.State)
+	}
+
+	switch b.counts.State {
+	case Open:
+		b.counts.Rejected++
+		return ErrOpen
+	case HalfOpen:
+		if b.inFlight >= b.probesNeeded() {
+			b.counts.Rejected++
+			return ErrOpen
+		}
+	}
+
+	b.inFlight++
+	b.counts.Requests++
+
+	return nil
+}
+
+func (b *Breaker) after(err error) {
+	b.mu.Lock()
+
+	b.inFlight--
+	from := b.counts.State
+	to := from
+
+	if err != nil && (b.IsFailure == nil || b.IsFailure(err)) {
+		b.counts.Failures++
+		b.counts.ConsecutiveFailures++
+
+		if from == HalfOpen || b.counts.ConsecutiveFailures >= b.threshold() {
+			to = Open
+		}
+	} else {
+		b.counts.Successes++
+		b.counts.ConsecutiveFailures = 0
+
+		if from == HalfOpen {
+			b.probes++
+			if b.probes >= b.probesNeeded() {
+				to = Closed
+			}
+		}
+	}
+
+	if to != from {
+		b.setState(to)
+	}
+	b.mu.Unlock()
+
+	if to != from {
+		b.notify(from, to)
+	}
+}
+
+// current is the state the next call will see; callers hold the lock
+func (b *Breaker) current() State {
+	if b.counts.State == Open && time.Since(b.openedAt) >= b.Timeout {
+		return HalfOpen
+	}
+	return b.counts.State
+}
+
+// expire moves an open breaker to half-open once Timeout has passed; callers hold the lock
+func (b *Breaker) expire() {
+	if b.counts.State == Open && time.Since(b.openedAt) >= b.Timeout {
+		b.setState(HalfOpen)
+	}
+}
+
+// setState changes the state and returns the previous one; callers hold the lock
+func (b *Breaker) setState(to State) State {
+	from := b.counts.State
+	b.counts.State = to
+	b.probes = 0
+
+	if to == Open {
+		b.openedAt = time.Now()
+	}
+
+	return from
+}
+
+func (b *Breaker) notify(from, to State) {
+	if from != to && b.OnStateChange != nil {
+		b.OnStateChange(b.Name, from, to)
+	}
+}
+
+func (b *Breaker) threshold() int {
+	if b.Threshold < 1 {
+		return 1
+	}
+	return b.Threshold
+}
+
+func (b *Breaker) probesNeeded() int {
+	if b.Probes < 1 {
+		return 1
+	}
+	return b.Probes
+}
@@ -0,0 +1,96 @@
+package breaker
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var errDown = errors.New("down")
+
+func TestBreaker(t *testing.T) {
+	b := New("test")
+	b.Threshold = 2
+	b.Timeout = 10 * time.Millisecond
+
+	var transitions []string
+	b.OnStateChange = func(name string, from, to State) {
+		transitions = append(transitions, from.String()+">"+to.String())
+	}
+
+	fail := func() error { return errDown }
+	ok := func() error { return nil }
+
+	_ = b.Execute(fail)
+	if b.State() != Closed {
+		t.Error("expected breaker to stay closed below the threshold")
+	}
+
+	_ = b.Execute(fail)
+	if b.State() != Open {
+		t.Error("expected breaker to open at the threshold")
+	}
+
+	called := false
+	err := b.Execute(func() error { called = true; return nil })
+	if err != ErrOpen || called {
+		t.Error("expected open breaker to reject calls")
+	}
+
+	time.Sleep(15 * time.Millisecond)
+	if b.State() != HalfOpen {
+		t.Error("expected half-open after timeout, got", b.State())
+	}
+
+	// a failing probe opens the circuit again
+	_ = b.Execute(fail)
+	if b.State() != Open {
+		t.Error("expected failed probe to reopen")
+	}
+
+	time.Sleep(15 * time.Millisecond)
+	if err := b.Execute(ok); err != nil {
+		t.Error("expected probe to be let through:", err)
+	}
+	if b.State() != Closed {
+		t.Error("expected successful probe to close")
+	}
+
+	want := []string{"closed>open", "open>half-open", "half-open>open", "open>half-open", "half-open>closed"}
+	if len(transitions) != len(want) {
+		t.Fatalf("expected transitions %v, got %v", want, transitions)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Errorf("transition %d: expected %s, got %s", i, want[i], transitions[i])
+		}
+	}
+
+	c := b.Counts()
+	if c.Failures != 3 || c.Successes != 1 || c.Rejected != 1 {
+		t.Errorf("unexpected counts %+v", c)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	b := New("http")
+	b.Threshold = 1
+	client := Client(b, nil)
+
+	res, err := client.Get(srv.URL)
+	if err != nil || res.StatusCode != http.StatusBadGateway {
+		t.Fatal("expected the 5xx response to be returned", err)
+	}
+	res.Body.Close()
+
+	if _, err := client.Get(srv.URL); !errors.Is(err, ErrOpen) {
+		t.Error("expected open circuit error, got", err)
+	}
+}
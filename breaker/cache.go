@@ -0,0 +1,147 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/namnguyen191/goravel/cache"
+)
+
+// Cache guards a cache.Cache; misses are not counted as failures
+type Cache struct {
+	cache.Cache
+	Breaker *Breaker
+}
+
+// NewCache wraps c with b; unless b says otherwise, misses and requests cancelled by their
+// context do not count against the store
+func NewCache(c cache.Cache, b *Breaker) *Cache {
+	if b.IsFailure == nil {
+		b.IsFailure = func(err error) bool {
+			return err != redis.ErrNil && err != cache.ErrMissing &&
+				!errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+		}
+	}
+
+	return &Cache{Cache: c, Breaker: b}
+}
+
+func (c *Cache) Has(str string) (bool, error) {
+	var ok bool
+	err := c.Breaker.Execute(func() (err error) {
+		ok, err = c.Cache.Has(str)
+		return err
+	})
+
+	return ok, err
+}
+
+func (c *Cache) Get(str string) (interface{}, error) {
+	var value interface{}
+	err := c.Breaker.Execute(func() (err error) {
+		value, err = c.Cache.Get(str)
+		return err
+	})
+
+	return value, err
+}
+
+func (c *Cache) Set(str string, value interface{}, expires ...int) error {
+	return c.Breaker.Execute(func() error {
+		return c.Cache.Set(str, value, expires...)
+	})
+}
+
+func (c *Cache) SetWithTTL(str string, value interface{}, ttl time.Duration) error {
+	return c.Breaker.Execute(func() error {
+		return c.Cache.SetWithTTL(str, value, ttl)
+	})
+}
+
+// Remember goes through Get and SetWithTTL, so fn still runs while the breaker is open
+func (c *Cache) Remember(str string, ttl time.Duration, fn func() (interface{}, error)) (interface{}, error) {
+	if value, err := c.Get(str); err == nil {
+		return value, nil
+	}
+
+	value, err := fn()
+	if err != nil {
+		return nil, err
+	}
+
+	return value, c.SetWithTTL(str, value, ttl)
+}
+
+func (c *Cache) Increment(str string, by int64) (int64, error) {
+	var n int64
+	err := c.Breaker.Execute(func() (err error) {
+		n, err = c.Cache.Increment(str, by)
+		return err
+	})
+
+	return n, err
+}
+
+func (c *Cache) Decrement(str string, by int64) (int64, error) {
+	var n int64
+	err := c.Breaker.Execute(func() (err error) {
+		n, err = c.Cache.Decrement(str, by)
+		return err
+	})
+
+	return n, err
+}
+
+func (c *Cache) Forget(str string) error {
+	return c.Breaker.Execute(func() error {
+		return c.Cache.Forget(str)
+	})
+}
+
+func (c *Cache) EmptyByMatch(str string) error {
+	return c.Breaker.Execute(func() error {
+		return c.Cache.EmptyByMatch(str)
+	})
+}
+
+func (c *Cache) Empty() error {
+	return c.Breaker.Execute(func() error {
+		return c.Cache.Empty()
+	})
+}
+
+// the context variants stop waiting on the store once ctx is done
+
+func (c *Cache) HasContext(ctx context.Context, str string) (bool, error) {
+	var ok bool
+	err := c.Breaker.Execute(func() (err error) {
+		ok, err = cache.Has(ctx, c.Cache, str)
+		return err
+	})
+
+	return ok, err
+}
+
+func (c *Cache) GetContext(ctx context.Context, str string) (interface{}, error) {
+	var value interface{}
+	err := c.Breaker.Execute(func() (err error) {
+		value, err = cache.Get(ctx, c.Cache, str)
+		return err
+	})
+
+	return value, err
+}
+
+func (c *Cache) SetContext(ctx context.Context, str string, value interface{}, expires ...int) error {
+	return c.Breaker.Execute(func() error {
+		return cache.Set(ctx, c.Cache, str, value, expires...)
+	})
+}
+
+func (c *Cache) ForgetContext(ctx context.Context, str string) error {
+	return c.Breaker.Execute(func() error {
+		return cache.Forget(ctx, c.Cache, str)
+	})
+}
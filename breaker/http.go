@@ -0,0 +1,55 @@
+package breaker
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Transport guards an http.RoundTripper; 5xx responses count as failures
+type Transport struct {
+	Breaker *Breaker
+	// Base is the wrapped transport; http.DefaultTransport when nil
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	var res *http.Response
+	err := t.Breaker.Execute(func() error {
+		var err error
+		res, err = base.RoundTrip(r)
+		if err != nil {
+			return err
+		}
+
+		if res.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("%s responded with %s", r.URL.Host, res.Status)
+		}
+
+		return nil
+	})
+
+	// a 5xx is still a valid response for the caller
+	if res != nil && err != ErrOpen {
+		return res, nil
+	}
+
+	return nil, err
+}
+
+// Client returns an http.Client whose requests go through b
+func Client(b *Breaker, base *http.Client) *http.Client {
+	if base == nil {
+		base = &http.Client{}
+	}
+
+	client := *base
+	client.Transport = &Transport{Breaker: b, Base: base.Transport}
+
+	return &client
+}
@@ -0,0 +1,58 @@
+package goravel
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/namnguyen191/goravel/breaker"
+	"github.com/namnguyen191/goravel/trace"
+)
+
+// Breaker returns the circuit breaker registered under name, creating it on first use.
+// Thresholds come from BREAKER_THRESHOLD and BREAKER_TIMEOUT (seconds); state changes are logged.
+func (grv *Goravel) Breaker(name string) *breaker.Breaker {
+	grv.breakersMu.Lock()
+	defer grv.breakersMu.Unlock()
+
+	if grv.breakers == nil {
+		grv.breakers = make(map[string]*breaker.Breaker)
+	}
+
+	if b, ok := grv.breakers[name]; ok {
+		return b
+	}
+
+	b := breaker.New(name)
+	b.Threshold = grv.Env.Int("BREAKER_THRESHOLD", b.Threshold)
+	b.Timeout = grv.Env.Duration("BREAKER_TIMEOUT", b.Timeout)
+	b.OnStateChange = func(name string, from, to breaker.State) {
+		if to == breaker.Open {
+			grv.ErrorLog.Printf("circuit %s is open, calls are suspended for %s", name, b.Timeout)
+			return
+		}
+		grv.InfoLog.Printf("circuit %s: %s -> %s", name, from, to)
+	}
+
+	grv.breakers[name] = b
+
+	return b
+}
+
+// HTTPClient returns a client for calls to the service name: they go through its circuit
+// breaker, time out after 30 seconds and carry the request id of their context
+func (grv *Goravel) HTTPClient(name string) *http.Client {
+	return trace.Client(breaker.Client(grv.Breaker(name), &http.Client{Timeout: 30 * time.Second}))
+}
+
+// BreakerCounts returns the counters of every registered breaker, keyed by name
+func (grv *Goravel) BreakerCounts() map[string]breaker.Counts {
+	grv.breakersMu.Lock()
+	defer grv.breakersMu.Unlock()
+
+	counts := make(map[string]breaker.Counts, len(grv.breakers))
+	for name, b := range grv.breakers {
+		counts[name] = b.Counts()
+	}
+
+	return counts
+}
@@ -0,0 +1,97 @@
+package goravel
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/namnguyen191/goravel/cache"
+	"github.com/namnguyen191/goravel/pagecache"
+)
+
+// CachePublic marks the response as cacheable by browsers and shared caches for maxAge.
+// An optional second duration sets a different lifetime for the CDN via Surrogate-Control.
+func (grv *Goravel) CachePublic(rw http.ResponseWriter, maxAge time.Duration, cdnMaxAge ...time.Duration) {
+	seconds := int(maxAge / time.Second)
+
+	h := rw.Header()
+	h.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", seconds))
+	h.Set("Expires", grv.Now().Add(maxAge).UTC().Format(http.TimeFormat))
+	h.Del("Pragma")
+
+	surrogate := seconds
+	if len(cdnMaxAge) > 0 {
+		surrogate = int(cdnMaxAge[0] / time.Second)
+	}
+	h.Set("Surrogate-Control", fmt.Sprintf("max-age=%d", surrogate))
+}
+
+// CacheStale lets shared caches serve the response for stale after it expires while it
+// is refreshed, and for staleIfError when refreshing fails. Call it after CachePublic.
+func (grv *Goravel) CacheStale(rw http.ResponseWriter, stale, staleIfError time.Duration) {
+	h := rw.Header()
+	h.Set("Cache-Control", fmt.Sprintf("%s, stale-while-revalidate=%d, stale-if-error=%d",
+		h.Get("Cache-Control"), int(stale/time.Second), int(staleIfError/time.Second)))
+}
+
+// createPageCache keeps pages in the configured cache, or in memory without one.
+// PAGE_CACHE_STALE and PAGE_CACHE_STALE_IF_ERROR, in seconds, apply to pages which do not set their own.
+func (grv *Goravel) createPageCache() *pagecache.PageCache {
+	memory := cache.NewMemoryCache("pages")
+	memory.Clock = grv.Clock
+
+	var store cache.Cache = memory
+	if grv.Cache != nil {
+		store = grv.Cache
+	}
+
+	pc := pagecache.New(store)
+	pc.StaleWhileRevalidate = grv.Env.Duration("PAGE_CACHE_STALE", 60*time.Second)
+	pc.StaleIfError = grv.Env.Duration("PAGE_CACHE_STALE_IF_ERROR", time.Hour)
+	pc.BypassCookie = grv.config.cookie.name
+	pc.ErrorLog = grv.ErrorLog.Println
+	pc.Clock = grv.Clock
+
+	return pc
+}
+
+// CachePrivate lets the browser, but not shared caches or the CDN, keep the response for maxAge
+func (grv *Goravel) CachePrivate(rw http.ResponseWriter, maxAge time.Duration) {
+	h := rw.Header()
+	h.Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(maxAge/time.Second)))
+	h.Set("Expires", grv.Now().Add(maxAge).UTC().Format(http.TimeFormat))
+	h.Set("Surrogate-Control", "no-store")
+	h.Del("Pragma")
+}
+
+// NoStore forbids any cache, including the response cache and the CDN, from keeping the response
+func (grv *Goravel) NoStore(rw http.ResponseWriter) {
+	h := rw.Header()
+	h.Set("Cache-Control", "no-store, no-cache, must-revalidate, max-age=0")
+	h.Set("Expires", "0")
+	h.Set("Pragma", "no-cache")
+	h.Set("Surrogate-Control", "no-store")
+}
+
+// CacheTags labels the response with surrogate keys so it can be purged from the CDN by tag
+func (grv *Goravel) CacheTags(rw http.ResponseWriter, tags ...string) {
+	if len(tags) == 0 {
+		return
+	}
+
+	h := rw.Header()
+	if existing := h.Get("Surrogate-Key"); existing != "" {
+		tags = append(strings.Fields(existing), tags...)
+	}
+
+	// Fastly reads Surrogate-Key, Cloudflare reads Cache-Tag
+	h.Set("Surrogate-Key", strings.Join(tags, " "))
+	h.Set("Cache-Tag", strings.Join(tags, ","))
+}
+
+// Cacheable reports whether a handler marked the response as shareable, and for how long
+func Cacheable(h http.Header) (time.Duration, bool) {
+	l, ok := pagecache.Parse(h)
+	return l.MaxAge, ok
+}
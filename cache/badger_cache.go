@@ -1,16 +1,26 @@
 package cache
 
 import (
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/dgraph-io/badger/v3"
 )
 
 type BadgerCache struct {
-	Conn   *badger.DB
+	Conn *badger.DB
+	// Prefix namespaces the keys, so apps can share a badger directory
 	Prefix string
 }
 
+func (c *BadgerCache) key(str string) string {
+	if c.Prefix == "" {
+		return str
+	}
+	return c.Prefix + ":" + str
+}
+
 func (c *BadgerCache) Has(str string) (bool, error) {
 	_, err := c.Get(str)
 
@@ -22,6 +32,7 @@ func (c *BadgerCache) Has(str string) (bool, error) {
 }
 
 func (c *BadgerCache) Get(str string) (interface{}, error) {
+	str = c.key(str)
 	var fromCache []byte
 
 	err := c.Conn.View(func(txn *badger.Txn) error {
@@ -46,17 +57,20 @@ func (c *BadgerCache) Get(str string) (interface{}, error) {
 		return nil, err
 	}
 
-	decoded, err := decode(string(fromCache))
-	if err != nil {
-		return nil, err
-	}
+	return decodeValue(str, string(fromCache))
+}
 
-	item := decoded[str]
+func (c *BadgerCache) Set(str string, value interface{}, expires ...int) error {
+	var ttl time.Duration
+	if len(expires) > 0 {
+		ttl = time.Second * time.Duration(expires[0])
+	}
 
-	return item, nil
+	return c.SetWithTTL(str, value, ttl)
 }
 
-func (c *BadgerCache) Set(str string, value interface{}, expires ...int) error {
+func (c *BadgerCache) SetWithTTL(str string, value interface{}, ttl time.Duration) error {
+	str = c.key(str)
 	entry := Entry{}
 
 	entry[str] = value
@@ -65,26 +79,57 @@ func (c *BadgerCache) Set(str string, value interface{}, expires ...int) error {
 		return err
 	}
 
-	if len(expires) > 0 {
-		err = c.Conn.Update(func(txn *badger.Txn) error {
-			e := badger.NewEntry([]byte(str), encoded).WithTTL(time.Second * time.Duration(expires[0]))
-			err = txn.SetEntry(e)
-			return err
-		})
-	} else {
-		err = c.Conn.Update(func(txn *badger.Txn) error {
-			e := badger.NewEntry([]byte(str), encoded)
-			err = txn.SetEntry(e)
+	return c.Conn.Update(func(txn *badger.Txn) error {
+		e := badger.NewEntry([]byte(str), encoded)
+		if ttl > 0 {
+			e = e.WithTTL(ttl)
+		}
+		return txn.SetEntry(e)
+	})
+}
+
+func (c *BadgerCache) Remember(str string, ttl time.Duration, fn func() (interface{}, error)) (interface{}, error) {
+	return remember(c, str, ttl, fn)
+}
+
+// Increment reads and writes the counter in one transaction, which badger retries on conflict
+func (c *BadgerCache) Increment(str string, by int64) (int64, error) {
+	key := []byte(c.key(str))
+	var n int64
+
+	err := c.Conn.Update(func(txn *badger.Txn) error {
+		n = 0
+		item, err := txn.Get(key)
+		switch {
+		case err == badger.ErrKeyNotFound:
+		case err != nil:
 			return err
-		})
+		default:
+			if err := item.Value(func(val []byte) error {
+				n, err = strconv.ParseInt(string(val), 10, 64)
+				return err
+			}); err != nil {
+				return fmt.Errorf("cache: %s is not a counter", str)
+			}
+		}
+
+		n += by
+		return txn.Set(key, []byte(strconv.FormatInt(n, 10)))
+	})
+	if err == badger.ErrConflict {
+		return c.Increment(str, by)
 	}
 
-	return nil
+	return n, err
+}
+
+func (c *BadgerCache) Decrement(str string, by int64) (int64, error) {
+	return c.Increment(str, -by)
 }
 
 func (c *BadgerCache) Forget(str string) error {
 	err := c.Conn.Update(func(txn *badger.Txn) error {
-		err := txn.Delete([]byte(str))
+		err := txn.Delete([]byte(c.key(str)))
 		return err
 	})
 
@@ -92,11 +137,14 @@ func (c *BadgerCache) Forget(str string) error {
 }
 
 func (c *BadgerCache) EmptyByMatch(str string) error {
-	return c.emptyByMatch(str)
+	return c.emptyByMatch(c.key(str))
 }
 
 func (c *BadgerCache) Empty() error {
-	return c.emptyByMatch("")
+	if c.Prefix == "" {
+		return c.emptyByMatch("")
+	}
+	return c.emptyByMatch(c.Prefix + ":")
 }
 
 func (c *BadgerCache) emptyByMatch(str string) error {
@@ -134,6 +182,8 @@ func (c *BadgerCache) emptyByMatch(str string) error {
 				if err := deleteKeys(keysForDelete); err != nil {
 					return err
 				}
+				keysForDelete = make([][]byte, 0, collectSize)
+				keysCollected = 0
 			}
 
 		}
@@ -1,7 +1,9 @@
 package cache
 
 import (
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestBadgerCache_Has(t *testing.T) {
@@ -184,3 +186,89 @@ func TestBadgerCache_emptyByMatch(t *testing.T) {
 		t.Error("beta not found in cache, and it should be there")
 	}
 }
+
+func TestBadgerCache_Prefix(t *testing.T) {
+	a := BadgerCache{Conn: testBadgerCache.Conn, Prefix: "app-a"}
+	b := BadgerCache{Conn: testBadgerCache.Conn, Prefix: "app-b"}
+
+	if err := a.Set("shared", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Set("shared", "b"); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, _ := a.Get("shared"); v != "a" {
+		t.Errorf("expected a's value, got %v", v)
+	}
+
+	if err := a.Empty(); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := a.Has("shared"); ok {
+		t.Error("expected a's keys to be emptied")
+	}
+	if v, _ := b.Get("shared"); v != "b" {
+		t.Errorf("expected b's keys to be left alone, got %v", v)
+	}
+}
+
+func TestBadgerCache_SetWithTTL(t *testing.T) {
+	if err := testBadgerCache.SetWithTTL("short", "foo", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := testBadgerCache.Get("short"); v != "foo" {
+		t.Errorf("expected foo, got %v", v)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	if ok, _ := testBadgerCache.Has("short"); ok {
+		t.Error("expected short to have expired")
+	}
+}
+
+func TestBadgerCache_Remember(t *testing.T) {
+	_ = testBadgerCache.Forget("remembered")
+
+	calls := 0
+	for i := 0; i < 2; i++ {
+		v, err := testBadgerCache.Remember("remembered", time.Minute, func() (interface{}, error) {
+			calls++
+			return "computed", nil
+		})
+		if err != nil || v != "computed" {
+			t.Fatalf("expected computed, got %v (%v)", v, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected fn to run once, ran %d times", calls)
+	}
+}
+
+func TestBadgerCache_Increment(t *testing.T) {
+	_ = testBadgerCache.Forget("hits")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := testBadgerCache.Increment("hits", 2); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n, err := testBadgerCache.Decrement("hits", 1); err != nil || n != 19 {
+		t.Fatalf("expected 19, got %d (%v)", n, err)
+	}
+	if v, _ := testBadgerCache.Get("hits"); v != int64(19) {
+		t.Errorf("expected Get to return int64 19, got %v", v)
+	}
+
+	_ = testBadgerCache.Set("word", "foo")
+	if _, err := testBadgerCache.Increment("word", 1); err == nil {
+		t.Error("expected an error incrementing a value which is not a counter")
+	}
+}
@@ -2,8 +2,12 @@ package cache
 
 import (
 	"bytes"
+	"context"
 	"encoding/gob"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gomodule/redigo/redis"
 )
@@ -12,11 +16,35 @@ type Cache interface {
 	Has(string) (bool, error)
 	Get(string) (interface{}, error)
 	Set(string, interface{}, ...int) error
+	// SetWithTTL is Set for a duration; a zero ttl keeps the value until it is forgotten
+	SetWithTTL(string, interface{}, time.Duration) error
+	// Remember returns the cached value of key, or caches the value of fn for ttl and returns it
+	Remember(string, time.Duration, func() (interface{}, error)) (interface{}, error)
+	// Increment adds to the counter of key, created at 0, and returns its new value; Get
+	// returns a counter as an int64
+	Increment(string, int64) (int64, error)
+	Decrement(string, int64) (int64, error)
 	Forget(string) error
+	// EmptyByMatch forgets the keys starting with the string, among the keys of the cache only
 	EmptyByMatch(string) error
 	Empty() error
 }
 
+// remember implements Remember over Get and SetWithTTL. The value of fn is returned even when
+// it could not be cached, along with the error.
+func remember(c Cache, key string, ttl time.Duration, fn func() (interface{}, error)) (interface{}, error) {
+	if value, err := c.Get(key); err == nil {
+		return value, nil
+	}
+
+	value, err := fn()
+	if err != nil {
+		return nil, err
+	}
+
+	return value, c.SetWithTTL(key, value, ttl)
+}
+
 type RedisCache struct {
 	Conn   *redis.Pool
 	Prefix string
@@ -25,12 +53,20 @@ type RedisCache struct {
 type Entry map[string]interface{}
 
 func (c *RedisCache) Has(str string) (bool, error) {
+	return c.HasContext(context.Background(), str)
+}
+
+// HasContext is Has, giving up when ctx is done
+func (c *RedisCache) HasContext(ctx context.Context, str string) (bool, error) {
 	key := fmt.Sprintf("%s:%s", c.Prefix, str)
 
-	conn := c.Conn.Get()
+	conn, err := c.Conn.GetContext(ctx)
+	if err != nil {
+		return false, err
+	}
 	defer conn.Close()
 
-	ok, err := redis.Bool(conn.Do("EXISTS", key))
+	ok, err := redis.Bool(redis.DoContext(conn, ctx, "EXISTS", key))
 	if err != nil {
 		return false, err
 	}
@@ -39,28 +75,37 @@ func (c *RedisCache) Has(str string) (bool, error) {
 }
 
 func (c *RedisCache) Get(str string) (interface{}, error) {
-	key := fmt.Sprintf("%s:%s", c.Prefix, str)
-	conn := c.Conn.Get()
-	defer conn.Close()
+	return c.GetContext(context.Background(), str)
+}
 
-	cacheEntry, err := redis.Bytes(conn.Do("GET", key))
+// GetContext is Get, giving up when ctx is done
+func (c *RedisCache) GetContext(ctx context.Context, str string) (interface{}, error) {
+	key := fmt.Sprintf("%s:%s", c.Prefix, str)
+	conn, err := c.Conn.GetContext(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer conn.Close()
 
-	decoded, err := decode(string(cacheEntry))
+	cacheEntry, err := redis.Bytes(redis.DoContext(conn, ctx, "GET", key))
 	if err != nil {
 		return nil, err
 	}
 
-	item := decoded[key]
-
-	return item, nil
+	return decodeValue(key, string(cacheEntry))
 }
 
 func (c *RedisCache) Set(str string, value interface{}, expires ...int) error {
+	return c.SetContext(context.Background(), str, value, expires...)
+}
+
+// SetContext is Set, giving up when ctx is done
+func (c *RedisCache) SetContext(ctx context.Context, str string, value interface{}, expires ...int) error {
 	key := fmt.Sprintf("%s:%s", c.Prefix, str)
-	conn := c.Conn.Get()
+	conn, err := c.Conn.GetContext(ctx)
+	if err != nil {
+		return err
+	}
 	defer conn.Close()
 
 	entry := Entry{}
@@ -72,12 +117,12 @@ func (c *RedisCache) Set(str string, value interface{}, expires ...int) error {
 	}
 
 	if len(expires) > 0 {
-		_, err := conn.Do("SETEX", key, expires[0], string(encoded))
+		_, err := redis.DoContext(conn, ctx, "SETEX", key, expires[0], string(encoded))
 		if err != nil {
 			return err
 		}
 	} else {
-		_, err := conn.Do("SET", key, string(encoded))
+		_, err := redis.DoContext(conn, ctx, "SET", key, string(encoded))
 		if err != nil {
 			return err
 		}
@@ -86,12 +131,56 @@ func (c *RedisCache) Set(str string, value interface{}, expires ...int) error {
 	return nil
 }
 
-func (c *RedisCache) Forget(str string) error {
+func (c *RedisCache) SetWithTTL(str string, value interface{}, ttl time.Duration) error {
 	key := fmt.Sprintf("%s:%s", c.Prefix, str)
 	conn := c.Conn.Get()
 	defer conn.Close()
 
-	_, err := conn.Do("DEL", key)
+	encoded, err := encode(Entry{key: value})
+	if err != nil {
+		return err
+	}
+
+	if ttl > 0 {
+		_, err = conn.Do("SET", key, string(encoded), "PX", ttl.Milliseconds())
+	} else {
+		_, err = conn.Do("SET", key, string(encoded))
+	}
+
+	return err
+}
+
+func (c *RedisCache) Remember(str string, ttl time.Duration, fn func() (interface{}, error)) (interface{}, error) {
+	return remember(c, str, ttl, fn)
+}
+
+// Increment is atomic across every instance of the app, with INCRBY
+func (c *RedisCache) Increment(str string, by int64) (int64, error) {
+	key := fmt.Sprintf("%s:%s", c.Prefix, str)
+	conn := c.Conn.Get()
+	defer conn.Close()
+
+	return redis.Int64(conn.Do("INCRBY", key, by))
+}
+
+func (c *RedisCache) Decrement(str string, by int64) (int64, error) {
+	return c.Increment(str, -by)
+}
+
+func (c *RedisCache) Forget(str string) error {
+	return c.ForgetContext(context.Background(), str)
+}
+
+// ForgetContext is Forget, giving up when ctx is done
+func (c *RedisCache) ForgetContext(ctx context.Context, str string) error {
+	key := fmt.Sprintf("%s:%s", c.Prefix, str)
+	conn, err := c.Conn.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = redis.DoContext(conn, ctx, "DEL", key)
 	if err != nil {
 		return err
 	}
@@ -100,7 +189,7 @@ func (c *RedisCache) Forget(str string) error {
 }
 
 func (c *RedisCache) EmptyByMatch(str string) error {
-	key := fmt.Sprintf("%s:%s", c.Prefix, str)
+	key := escapeGlob(fmt.Sprintf("%s:%s", c.Prefix, str))
 	conn := c.Conn.Get()
 	defer conn.Close()
 
@@ -120,7 +209,8 @@ func (c *RedisCache) EmptyByMatch(str string) error {
 }
 
 func (c *RedisCache) Empty() error {
-	key := c.Prefix
+	// the separator keeps the keys of an app whose prefix starts with this one
+	key := escapeGlob(c.Prefix + ":")
 	conn := c.Conn.Get()
 	defer conn.Close()
 
@@ -163,6 +253,34 @@ func decode(str string) (Entry, error) {
 	return item, nil
 }
 
+// decodeValue returns the value stored under key, which is a plain number for counters
+func decodeValue(key, str string) (interface{}, error) {
+	if n, err := strconv.ParseInt(str, 10, 64); err == nil {
+		return n, nil
+	}
+
+	decoded, err := decode(str)
+	if err != nil {
+		return nil, err
+	}
+
+	return decoded[key], nil
+}
+
+// escapeGlob escapes the characters of a redis MATCH pattern, so the key is matched as it is
+func escapeGlob(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		switch r {
+		case '*', '?', '[', ']', '\\', '^':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
 func (c *RedisCache) getKeys(pattern string) ([]string, error) {
 	conn := c.Conn.Get()
 	defer conn.Close()
@@ -187,3 +305,13 @@ func (c *RedisCache) getKeys(pattern string) ([]string, error) {
 
 	return keys, nil
 }
+
+// Ping checks that redis is reachable
+func (c *RedisCache) Ping() error {
+	conn := c.Conn.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("PING")
+
+	return err
+}
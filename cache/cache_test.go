@@ -1,6 +1,12 @@
 package cache
 
-import "testing"
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
 
 func TestRedisCache_Has(t *testing.T) {
 	err := testRedisCache.Forget("foo")
@@ -152,3 +158,100 @@ func TestEncodeDecode(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestRedisCache_SetWithTTL(t *testing.T) {
+	if err := testRedisCache.SetWithTTL("ttl", "foo", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	conn := testRedisCache.Conn.Get()
+	defer conn.Close()
+	ttl, err := redis.Int64(conn.Do("PTTL", testRedisCache.Prefix+":ttl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl <= 0 || ttl > time.Minute.Milliseconds() {
+		t.Errorf("expected a ttl of up to a minute, got %dms", ttl)
+	}
+
+	if v, _ := testRedisCache.Get("ttl"); v != "foo" {
+		t.Errorf("expected foo, got %v", v)
+	}
+}
+
+func TestRedisCache_Remember(t *testing.T) {
+	_ = testRedisCache.Forget("remembered")
+
+	calls := 0
+	fn := func() (interface{}, error) {
+		calls++
+		return "computed", nil
+	}
+
+	for i := 0; i < 2; i++ {
+		v, err := testRedisCache.Remember("remembered", time.Minute, fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != "computed" {
+			t.Errorf("expected computed, got %v", v)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected fn to run once, ran %d times", calls)
+	}
+
+	_, err := testRedisCache.Remember("failing", time.Minute, func() (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+	if err == nil {
+		t.Error("expected the error of fn")
+	}
+	if ok, _ := testRedisCache.Has("failing"); ok {
+		t.Error("a failed fn should cache nothing")
+	}
+}
+
+func TestRedisCache_Increment(t *testing.T) {
+	_ = testRedisCache.Forget("hits")
+
+	if n, err := testRedisCache.Increment("hits", 5); err != nil || n != 5 {
+		t.Fatalf("expected 5, got %d (%v)", n, err)
+	}
+	if n, err := testRedisCache.Decrement("hits", 2); err != nil || n != 3 {
+		t.Fatalf("expected 3, got %d (%v)", n, err)
+	}
+	if v, err := testRedisCache.Get("hits"); err != nil || v != int64(3) {
+		t.Errorf("expected Get to return int64 3, got %v (%v)", v, err)
+	}
+}
+
+func TestRedisCache_EmptyByMatchScoped(t *testing.T) {
+	other := RedisCache{Conn: testRedisCache.Conn, Prefix: testRedisCache.Prefix + "-other"}
+	if err := other.Set("key", "other"); err != nil {
+		t.Fatal(err)
+	}
+	if err := testRedisCache.Set("a*b", "glob"); err != nil {
+		t.Fatal(err)
+	}
+	if err := testRedisCache.Set("axb", "plain"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := testRedisCache.EmptyByMatch("a*"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := testRedisCache.Has("a*b"); ok {
+		t.Error("expected a*b to be forgotten")
+	}
+	if ok, _ := testRedisCache.Has("axb"); !ok {
+		t.Error("expected * to be matched literally")
+	}
+
+	if err := testRedisCache.Empty(); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := other.Has("key"); !ok {
+		t.Error("expected the keys of another prefix to be left alone")
+	}
+}
@@ -0,0 +1,114 @@
+package cache
+
+import "context"
+
+// ContextCache is implemented by caches whose operations stop when their context is done, so a
+// request that was cancelled or ran out of time does not keep waiting on the store
+type ContextCache interface {
+	HasContext(ctx context.Context, key string) (bool, error)
+	GetContext(ctx context.Context, key string) (interface{}, error)
+	SetContext(ctx context.Context, key string, value interface{}, expires ...int) error
+	ForgetContext(ctx context.Context, key string) error
+}
+
+// Has checks for key with ctx when c supports it; other caches are only skipped once ctx is done
+func Has(ctx context.Context, c Cache, key string) (bool, error) {
+	if cc, ok := c.(ContextCache); ok {
+		return cc.HasContext(ctx, key)
+	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return c.Has(key)
+}
+
+// Get reads key with ctx when c supports it; other caches are only skipped once ctx is done
+func Get(ctx context.Context, c Cache, key string) (interface{}, error) {
+	if cc, ok := c.(ContextCache); ok {
+		return cc.GetContext(ctx, key)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.Get(key)
+}
+
+// Set writes key with ctx when c supports it; other caches are only skipped once ctx is done
+func Set(ctx context.Context, c Cache, key string, value interface{}, expires ...int) error {
+	if cc, ok := c.(ContextCache); ok {
+		return cc.SetContext(ctx, key, value, expires...)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.Set(key, value, expires...)
+}
+
+// Forget removes key with ctx when c supports it; other caches are only skipped once ctx is done
+func Forget(ctx context.Context, c Cache, key string) error {
+	if cc, ok := c.(ContextCache); ok {
+		return cc.ForgetContext(ctx, key)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.Forget(key)
+}
+
+// local stores answer right away, so they only need to check that ctx is still live
+
+func (c *MemoryCache) HasContext(ctx context.Context, str string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return c.Has(str)
+}
+
+func (c *MemoryCache) GetContext(ctx context.Context, str string) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.Get(str)
+}
+
+func (c *MemoryCache) SetContext(ctx context.Context, str string, value interface{}, expires ...int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.Set(str, value, expires...)
+}
+
+func (c *MemoryCache) ForgetContext(ctx context.Context, str string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.Forget(str)
+}
+
+func (c *BadgerCache) HasContext(ctx context.Context, str string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return c.Has(str)
+}
+
+func (c *BadgerCache) GetContext(ctx context.Context, str string) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.Get(str)
+}
+
+func (c *BadgerCache) SetContext(ctx context.Context, str string, value interface{}, expires ...int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.Set(str, value, expires...)
+}
+
+func (c *BadgerCache) ForgetContext(ctx context.Context, str string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.Forget(str)
+}
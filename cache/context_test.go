@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRedisCache_Context(t *testing.T) {
+	ctx := context.Background()
+	if err := testRedisCache.SetContext(ctx, "ctx", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := Get(ctx, &testRedisCache, "ctx")
+	if err != nil || v != "value" {
+		t.Errorf("expected value, got %v %v", v, err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := testRedisCache.GetContext(cancelled, "ctx"); err == nil {
+		t.Error("expected a cancelled context to stop the read")
+	}
+}
+
+type plainCache struct {
+	*MemoryCache
+}
+
+func TestContextFallback(t *testing.T) {
+	c := plainCache{NewMemoryCache("plain")}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if err := Set(ctx, c, "key", 1); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	if _, err := Get(ctx, c, "key"); err != context.Canceled {
+		t.Errorf("expected the cancelled context to skip the cache, got %v", err)
+	}
+	if ok, _ := c.Has("key"); !ok {
+		t.Error("expected the value to be cached")
+	}
+}
+
+func TestFailoverCache_Cancelled(t *testing.T) {
+	pings := 0
+	c := NewFailover(NewMemoryCache("primary"), func() error {
+		pings++
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := c.GetContext(ctx, "key"); err != context.Canceled {
+		t.Errorf("expected cancelled, got %v", err)
+	}
+	if pings != 0 || c.Degraded() {
+		t.Error("expected a cancelled read not to probe or fail over")
+	}
+}
@@ -0,0 +1,304 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Store is the part of a cache a driver implements: raw bytes under full keys. The framework
+// wraps it in a DriverCache, which prefixes the keys, encodes the values and counts hits.
+type Store interface {
+	// Get returns the value of key, with found false when it is missing or expired
+	Get(ctx context.Context, key string) (value []byte, found bool, err error)
+	// Set stores value under key; a zero ttl keeps it until it is deleted
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+	// Keys lists the keys starting with prefix
+	Keys(ctx context.Context, prefix string) ([]string, error)
+}
+
+// Incrementer is implemented by the stores which update a counter atomically. DriverCache
+// reads and writes the counters of the other stores under a lock, which only keeps the
+// counters right within one process.
+type Incrementer interface {
+	// Increment adds by to the decimal number under key, created at 0, and returns the sum
+	Increment(ctx context.Context, key string, by int64) (int64, error)
+}
+
+// Config is what a driver gets to open its store; drivers read their own settings, such as
+// a table name, from the environment
+type Config struct {
+	// Namespace is the app namespace, for drivers which name their table or bucket after it
+	Namespace string
+}
+
+// Factory opens the store of a driver
+type Factory func(cfg Config) (Store, error)
+
+// builtin are the CACHE values the framework opens itself
+var builtin = map[string]bool{"redis": true, "badger": true, "memcached": true, "memory": true}
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]Factory{}
+)
+
+// Register makes a driver available as CACHE=name, usually from the init function of the
+// package implementing it. It panics when name is taken or factory is nil, like sql.Register.
+func Register(name string, factory Factory) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	if factory == nil {
+		panic("cache: Register factory is nil")
+	}
+	if builtin[name] {
+		panic("cache: Register called for the built in driver " + name)
+	}
+	if _, dup := drivers[name]; dup {
+		panic("cache: Register called twice for driver " + name)
+	}
+	drivers[name] = factory
+}
+
+// Drivers returns the names of the registered drivers, sorted
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Registered reports whether a driver was registered as name
+func Registered(name string) bool {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	_, ok := drivers[name]
+	return ok
+}
+
+// Open opens the store of the driver registered as name and wraps it with prefix
+func Open(name, prefix string, cfg Config) (*DriverCache, error) {
+	driversMu.RLock()
+	factory := drivers[name]
+	driversMu.RUnlock()
+
+	if factory == nil {
+		return nil, fmt.Errorf("cache: unknown driver %q (forgotten import?)", name)
+	}
+
+	store, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("cache: %s: %w", name, err)
+	}
+	return NewDriverCache(store, prefix), nil
+}
+
+// DriverCache is a Cache over the Store of a driver, encoding values the same way as
+// RedisCache so any value it can cache works with every driver
+type DriverCache struct {
+	Store  Store
+	Prefix string
+
+	hits, misses, sets, errors uint64
+	// counters serializes the increments of a store which is not an Incrementer
+	counters sync.Mutex
+}
+
+// DriverStats is a snapshot of the counters of a DriverCache
+type DriverStats struct {
+	Hits   uint64
+	Misses uint64
+	Sets   uint64
+	Errors uint64
+}
+
+// NewDriverCache wraps store, prefixing its keys with prefix
+func NewDriverCache(store Store, prefix string) *DriverCache {
+	return &DriverCache{Store: store, Prefix: prefix}
+}
+
+func (c *DriverCache) key(str string) string {
+	return fmt.Sprintf("%s:%s", c.Prefix, str)
+}
+
+// count records the outcome of an operation and passes err through
+func (c *DriverCache) count(err error) error {
+	if err != nil {
+		atomic.AddUint64(&c.errors, 1)
+	}
+	return err
+}
+
+// Stats returns the counters, meant to be exported as metrics
+func (c *DriverCache) Stats() DriverStats {
+	return DriverStats{
+		Hits:   atomic.LoadUint64(&c.hits),
+		Misses: atomic.LoadUint64(&c.misses),
+		Sets:   atomic.LoadUint64(&c.sets),
+		Errors: atomic.LoadUint64(&c.errors),
+	}
+}
+
+func (c *DriverCache) Has(str string) (bool, error) {
+	return c.HasContext(context.Background(), str)
+}
+
+// HasContext is Has, giving up when ctx is done
+func (c *DriverCache) HasContext(ctx context.Context, str string) (bool, error) {
+	_, found, err := c.Store.Get(ctx, c.key(str))
+	return found, c.count(err)
+}
+
+func (c *DriverCache) Get(str string) (interface{}, error) {
+	return c.GetContext(context.Background(), str)
+}
+
+// GetContext is Get, giving up when ctx is done; a missing key is ErrMissing
+func (c *DriverCache) GetContext(ctx context.Context, str string) (interface{}, error) {
+	key := c.key(str)
+	value, found, err := c.Store.Get(ctx, key)
+	if err != nil {
+		return nil, c.count(err)
+	}
+	if !found {
+		atomic.AddUint64(&c.misses, 1)
+		return nil, ErrMissing
+	}
+
+	decoded, err := decodeValue(key, string(value))
+	if err != nil {
+		return nil, c.count(err)
+	}
+	atomic.AddUint64(&c.hits, 1)
+
+	return decoded, nil
+}
+
+func (c *DriverCache) Set(str string, value interface{}, expires ...int) error {
+	return c.SetContext(context.Background(), str, value, expires...)
+}
+
+// SetContext is Set, giving up when ctx is done
+func (c *DriverCache) SetContext(ctx context.Context, str string, value interface{}, expires ...int) error {
+	key := c.key(str)
+	encoded, err := encode(Entry{key: value})
+	if err != nil {
+		return c.count(err)
+	}
+
+	var ttl time.Duration
+	if len(expires) > 0 {
+		ttl = time.Duration(expires[0]) * time.Second
+	}
+
+	if err := c.Store.Set(ctx, key, encoded, ttl); err != nil {
+		return c.count(err)
+	}
+	atomic.AddUint64(&c.sets, 1)
+
+	return nil
+}
+
+func (c *DriverCache) SetWithTTL(str string, value interface{}, ttl time.Duration) error {
+	key := c.key(str)
+	encoded, err := encode(Entry{key: value})
+	if err != nil {
+		return c.count(err)
+	}
+
+	if err := c.Store.Set(context.Background(), key, encoded, ttl); err != nil {
+		return c.count(err)
+	}
+	atomic.AddUint64(&c.sets, 1)
+
+	return nil
+}
+
+func (c *DriverCache) Remember(str string, ttl time.Duration, fn func() (interface{}, error)) (interface{}, error) {
+	return remember(c, str, ttl, fn)
+}
+
+func (c *DriverCache) Increment(str string, by int64) (int64, error) {
+	ctx := context.Background()
+	key := c.key(str)
+
+	if inc, ok := c.Store.(Incrementer); ok {
+		n, err := inc.Increment(ctx, key, by)
+		return n, c.count(err)
+	}
+
+	c.counters.Lock()
+	defer c.counters.Unlock()
+
+	var n int64
+	value, found, err := c.Store.Get(ctx, key)
+	if err != nil {
+		return 0, c.count(err)
+	}
+	if found {
+		if n, err = strconv.ParseInt(string(value), 10, 64); err != nil {
+			return 0, c.count(fmt.Errorf("cache: %s is not a counter", str))
+		}
+	}
+
+	n += by
+	if err := c.Store.Set(ctx, key, []byte(strconv.FormatInt(n, 10)), 0); err != nil {
+		return 0, c.count(err)
+	}
+
+	return n, nil
+}
+
+func (c *DriverCache) Decrement(str string, by int64) (int64, error) {
+	return c.Increment(str, -by)
+}
+
+func (c *DriverCache) Forget(str string) error {
+	return c.ForgetContext(context.Background(), str)
+}
+
+// ForgetContext is Forget, giving up when ctx is done
+func (c *DriverCache) ForgetContext(ctx context.Context, str string) error {
+	return c.count(c.Store.Delete(ctx, c.key(str)))
+}
+
+func (c *DriverCache) EmptyByMatch(str string) error {
+	return c.deletePrefix(c.key(str))
+}
+
+func (c *DriverCache) Empty() error {
+	return c.deletePrefix(c.Prefix + ":")
+}
+
+func (c *DriverCache) deletePrefix(prefix string) error {
+	ctx := context.Background()
+
+	keys, err := c.Store.Keys(ctx, prefix)
+	if err != nil {
+		return c.count(err)
+	}
+
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if err := c.Store.Delete(ctx, key); err != nil {
+			return c.count(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mapStore is a Store over a map, ignoring expiry
+type mapStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func (s *mapStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.data[key]
+	return v, ok, nil
+}
+
+func (s *mapStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = value
+	return nil
+}
+
+func (s *mapStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, key)
+	return nil
+}
+
+func (s *mapStore) Keys(ctx context.Context, prefix string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for k := range s.data {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func TestRegister(t *testing.T) {
+	store := &mapStore{data: map[string][]byte{}}
+	Register("map", func(cfg Config) (Store, error) { return store, nil })
+
+	if !Registered("map") || Drivers()[0] != "map" {
+		t.Fatalf("expected the map driver, got %v", Drivers())
+	}
+	if _, err := Open("dynamodb", "app", Config{}); err == nil {
+		t.Error("expected an error for an unknown driver")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected registering twice to panic")
+		}
+	}()
+	Register("map", func(cfg Config) (Store, error) { return store, nil })
+}
+
+func TestDriverCache(t *testing.T) {
+	store := &mapStore{data: map[string][]byte{}}
+	c := NewDriverCache(store, "app")
+
+	if err := c.Set("user:1", "Ada", 60); err != nil {
+		t.Fatal(err)
+	}
+	_ = c.Set("user:2", "Grace")
+	_ = c.Set("other", 42)
+
+	if _, ok := store.data["app:user:1"]; !ok {
+		t.Fatalf("expected the key to be prefixed, got %v", store.data)
+	}
+
+	v, err := c.Get("user:1")
+	if err != nil || v != "Ada" {
+		t.Errorf("expected the value back, got %v %v", v, err)
+	}
+	if _, err := c.Get("missing"); err != ErrMissing {
+		t.Errorf("expected ErrMissing, got %v", err)
+	}
+
+	if err := c.EmptyByMatch("user:"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := c.Has("user:2"); ok {
+		t.Error("expected user:2 to be emptied")
+	}
+	if ok, _ := c.Has("other"); !ok {
+		t.Error("expected other to be kept")
+	}
+
+	if s := c.Stats(); s.Hits != 1 || s.Misses != 1 || s.Sets != 3 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+}
+
+func TestDriverCache_Increment(t *testing.T) {
+	c := NewDriverCache(&mapStore{data: map[string][]byte{}}, "app")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = c.Increment("hits", 1)
+		}()
+	}
+	wg.Wait()
+
+	if n, err := c.Decrement("hits", 3); err != nil || n != 7 {
+		t.Fatalf("expected 7, got %d (%v)", n, err)
+	}
+	if v, _ := c.Get("hits"); v != int64(7) {
+		t.Errorf("expected Get to return int64 7, got %v", v)
+	}
+
+	v, err := c.Remember("name", time.Minute, func() (interface{}, error) { return "Ada", nil })
+	if err != nil || v != "Ada" {
+		t.Fatalf("expected Ada, got %v (%v)", v, err)
+	}
+	if v, _ := c.Get("name"); v != "Ada" {
+		t.Errorf("expected Remember to cache the value, got %v", v)
+	}
+}
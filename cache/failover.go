@@ -0,0 +1,282 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/namnguyen191/goravel/clock"
+)
+
+// FailoverCache serves from Primary and switches to Fallback while Primary is unreachable.
+// Only connection and I/O errors from Primary are checked with Ping, failing over when it
+// fails too, so cache misses and encoding errors are passed through untouched.
+type FailoverCache struct {
+	Primary  Cache
+	Fallback Cache
+	// Ping reports whether Primary is reachable
+	Ping func() error
+	// RetryEvery is how often Primary is probed while degraded
+	RetryEvery time.Duration
+	// OnChange is called whenever the cache enters or leaves degraded mode
+	OnChange func(degraded bool, err error)
+	// Clock tells when Primary is due to be probed; the time of the machine when nil
+	Clock clock.Clock
+
+	mu        sync.Mutex
+	degraded  bool
+	lastProbe time.Time
+	failovers uint64
+	fallbacks uint64
+}
+
+// FailoverStats is a snapshot of the failover counters
+type FailoverStats struct {
+	Degraded bool
+	// Failovers counts how many times the cache switched to Fallback
+	Failovers uint64
+	// FallbackHits counts operations served by Fallback
+	FallbackHits uint64
+}
+
+// NewFailover wraps primary, falling back to an in-memory cache when ping fails
+func NewFailover(primary Cache, ping func() error) *FailoverCache {
+	return &FailoverCache{
+		Primary:    primary,
+		Fallback:   NewMemoryCache("failover"),
+		Ping:       ping,
+		RetryEvery: 5 * time.Second,
+	}
+}
+
+// Degraded reports whether the fallback is currently in use
+func (c *FailoverCache) Degraded() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.degraded
+}
+
+// Stats returns the failover counters, meant to be exported as metrics
+func (c *FailoverCache) Stats() FailoverStats {
+	return FailoverStats{
+		Degraded:     c.Degraded(),
+		Failovers:    atomic.LoadUint64(&c.failovers),
+		FallbackHits: atomic.LoadUint64(&c.fallbacks),
+	}
+}
+
+// active returns the cache that should serve the next operation, probing Primary when it's due
+func (c *FailoverCache) active() Cache {
+	c.mu.Lock()
+	if !c.degraded {
+		c.mu.Unlock()
+		return c.Primary
+	}
+
+	if clock.Since(c.Clock, c.lastProbe) < c.RetryEvery {
+		c.mu.Unlock()
+		atomic.AddUint64(&c.fallbacks, 1)
+		return c.Fallback
+	}
+	c.lastProbe = clock.Now(c.Clock)
+	c.mu.Unlock()
+
+	if c.Ping() != nil {
+		atomic.AddUint64(&c.fallbacks, 1)
+		return c.Fallback
+	}
+
+	c.setDegraded(false, nil)
+
+	// whatever was written while degraded is not in Primary, so drop it
+	_ = c.Fallback.Empty()
+
+	return c.Primary
+}
+
+// unreachable reports whether err comes from the connection to Primary rather than from the
+// operation, such as a miss or a value which does not decode
+func unreachable(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, redis.ErrPoolExhausted)
+}
+
+// failed checks whether err means Primary is down, and if so switches to Fallback
+func (c *FailoverCache) failed(err error) bool {
+	if err == nil || !unreachable(err) {
+		return false
+	}
+
+	pingErr := c.Ping()
+	if pingErr == nil {
+		return false
+	}
+
+	c.setDegraded(true, pingErr)
+	atomic.AddUint64(&c.fallbacks, 1)
+
+	return true
+}
+
+func (c *FailoverCache) setDegraded(degraded bool, err error) {
+	c.mu.Lock()
+	if c.degraded == degraded {
+		c.mu.Unlock()
+		return
+	}
+	c.degraded = degraded
+	c.lastProbe = clock.Now(c.Clock)
+	c.mu.Unlock()
+
+	if degraded {
+		atomic.AddUint64(&c.failovers, 1)
+	}
+
+	if c.OnChange != nil {
+		c.OnChange(degraded, err)
+	}
+}
+
+func (c *FailoverCache) Has(str string) (bool, error) {
+	cache := c.active()
+	ok, err := cache.Has(str)
+	if cache == c.Primary && c.failed(err) {
+		return c.Fallback.Has(str)
+	}
+
+	return ok, err
+}
+
+func (c *FailoverCache) Get(str string) (interface{}, error) {
+	cache := c.active()
+	value, err := cache.Get(str)
+	if cache == c.Primary && c.failed(err) {
+		return c.Fallback.Get(str)
+	}
+
+	return value, err
+}
+
+func (c *FailoverCache) Set(str string, value interface{}, expires ...int) error {
+	cache := c.active()
+	err := cache.Set(str, value, expires...)
+	if cache == c.Primary && c.failed(err) {
+		return c.Fallback.Set(str, value, expires...)
+	}
+
+	return err
+}
+
+func (c *FailoverCache) SetWithTTL(str string, value interface{}, ttl time.Duration) error {
+	cache := c.active()
+	err := cache.SetWithTTL(str, value, ttl)
+	if cache == c.Primary && c.failed(err) {
+		return c.Fallback.SetWithTTL(str, value, ttl)
+	}
+
+	return err
+}
+
+func (c *FailoverCache) Remember(str string, ttl time.Duration, fn func() (interface{}, error)) (interface{}, error) {
+	return remember(c, str, ttl, fn)
+}
+
+// Increment counts in Fallback while degraded, so the counters restart when Primary is back
+func (c *FailoverCache) Increment(str string, by int64) (int64, error) {
+	cache := c.active()
+	n, err := cache.Increment(str, by)
+	if cache == c.Primary && c.failed(err) {
+		return c.Fallback.Increment(str, by)
+	}
+
+	return n, err
+}
+
+func (c *FailoverCache) Decrement(str string, by int64) (int64, error) {
+	return c.Increment(str, -by)
+}
+
+func (c *FailoverCache) Forget(str string) error {
+	cache := c.active()
+	err := cache.Forget(str)
+	if cache == c.Primary && c.failed(err) {
+		return c.Fallback.Forget(str)
+	}
+
+	return err
+}
+
+func (c *FailoverCache) EmptyByMatch(str string) error {
+	cache := c.active()
+	err := cache.EmptyByMatch(str)
+	if cache == c.Primary && c.failed(err) {
+		return c.Fallback.EmptyByMatch(str)
+	}
+
+	return err
+}
+
+func (c *FailoverCache) Empty() error {
+	cache := c.active()
+	err := cache.Empty()
+	if cache == c.Primary && c.failed(err) {
+		return c.Fallback.Empty()
+	}
+
+	return err
+}
+
+// the context variants pass ctx through; an operation cut short by ctx never causes a failover
+
+func (c *FailoverCache) HasContext(ctx context.Context, str string) (bool, error) {
+	cache := c.active()
+	ok, err := Has(ctx, cache, str)
+	if cache == c.Primary && ctx.Err() == nil && c.failed(err) {
+		return Has(ctx, c.Fallback, str)
+	}
+
+	return ok, err
+}
+
+func (c *FailoverCache) GetContext(ctx context.Context, str string) (interface{}, error) {
+	cache := c.active()
+	value, err := Get(ctx, cache, str)
+	if cache == c.Primary && ctx.Err() == nil && c.failed(err) {
+		return Get(ctx, c.Fallback, str)
+	}
+
+	return value, err
+}
+
+func (c *FailoverCache) SetContext(ctx context.Context, str string, value interface{}, expires ...int) error {
+	cache := c.active()
+	err := Set(ctx, cache, str, value, expires...)
+	if cache == c.Primary && ctx.Err() == nil && c.failed(err) {
+		return Set(ctx, c.Fallback, str, value, expires...)
+	}
+
+	return err
+}
+
+func (c *FailoverCache) ForgetContext(ctx context.Context, str string) error {
+	cache := c.active()
+	err := Forget(ctx, cache, str)
+	if cache == c.Primary && ctx.Err() == nil && c.failed(err) {
+		return Forget(ctx, c.Fallback, str)
+	}
+
+	return err
+}
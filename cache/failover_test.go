@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+func TestFailoverCache(t *testing.T) {
+	down := false
+	ping := func() error {
+		if down {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+
+	primary := NewMemoryCache("primary")
+	failing := &brokenCache{Cache: primary, down: &down}
+
+	var changes []bool
+	c := NewFailover(failing, ping)
+	c.RetryEvery = 0
+	c.OnChange = func(degraded bool, err error) { changes = append(changes, degraded) }
+
+	_ = c.Set("foo", "bar")
+	if v, _ := primary.Get("foo"); v != "bar" {
+		t.Error("expected value in primary while healthy")
+	}
+
+	down = true
+	if err := c.Set("alpha", "beta"); err != nil {
+		t.Error("expected set to succeed on the fallback:", err)
+	}
+	if v, err := c.Get("alpha"); err != nil || v != "beta" {
+		t.Error("expected value from the fallback, got", v, err)
+	}
+	if !c.Degraded() {
+		t.Error("expected degraded mode")
+	}
+
+	down = false
+	time.Sleep(time.Millisecond)
+	if v, _ := c.Get("foo"); v != "bar" {
+		t.Error("expected primary to serve again after recovery")
+	}
+	if c.Degraded() {
+		t.Error("expected recovery")
+	}
+
+	if len(changes) != 2 || !changes[0] || changes[1] {
+		t.Error("unexpected change notifications:", changes)
+	}
+	if c.Stats().Failovers != 1 {
+		t.Error("expected one failover, got", c.Stats().Failovers)
+	}
+}
+
+// errRefused is the error of a connection to a server which is down
+var errRefused = &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+
+func TestFailoverCache_misses(t *testing.T) {
+	pings := 0
+	c := NewFailover(NewMemoryCache("primary"), func() error {
+		pings++
+		return nil
+	})
+
+	if _, err := c.Get("missing"); err != ErrMissing {
+		t.Errorf("expected the miss passed through, got %v", err)
+	}
+	if pings != 0 || c.Degraded() {
+		t.Errorf("expected errors of the operations not to probe Primary, got %d pings", pings)
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	for _, err := range []error{errRefused, io.EOF, fmt.Errorf("get: %w", io.ErrUnexpectedEOF), redis.ErrPoolExhausted} {
+		if !unreachable(err) {
+			t.Errorf("expected %v to be taken for a connection error", err)
+		}
+	}
+	for _, err := range []error{ErrMissing, redis.ErrNil, errors.New("cache: value does not decode")} {
+		if unreachable(err) {
+			t.Errorf("expected %v not to be taken for a connection error", err)
+		}
+	}
+}
+
+// brokenCache errors on every call while down is true
+type brokenCache struct {
+	Cache
+	down *bool
+}
+
+func (b *brokenCache) Get(str string) (interface{}, error) {
+	if *b.down {
+		return nil, errRefused
+	}
+	return b.Cache.Get(str)
+}
+
+func (b *brokenCache) Set(str string, value interface{}, expires ...int) error {
+	if *b.down {
+		return errRefused
+	}
+	return b.Cache.Set(str, value, expires...)
+}
@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+)
+
+// LRU is a Store in the memory of the process, which forgets the least recently used entries
+// past MaxItems. It suits tests and apps running on a single instance.
+type LRU struct {
+	// MaxItems is how many entries are kept; 10000 by default
+	MaxItems int
+	// Clock tells when the entries expire; the time of the machine when nil
+	Clock clock.Clock
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+	evicted uint64
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewLRU returns an empty store keeping up to maxItems entries
+func NewLRU(maxItems int) *LRU {
+	return &LRU{MaxItems: maxItems}
+}
+
+// element returns the live entry of key, dropping it when it has expired. The caller holds mu.
+func (s *LRU) element(key string) *list.Element {
+	if s.entries == nil {
+		s.entries = map[string]*list.Element{}
+		s.order = list.New()
+	}
+
+	el, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	if e := el.Value.(*lruEntry); !e.expires.IsZero() && !clock.Now(s.Clock).Before(e.expires) {
+		s.order.Remove(el)
+		delete(s.entries, key)
+		return nil
+	}
+	return el
+}
+
+func (s *LRU) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el := s.element(key)
+	if el == nil {
+		return nil, false, nil
+	}
+	s.order.MoveToFront(el)
+
+	return el.Value.(*lruEntry).value, true, nil
+}
+
+func (s *LRU) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	e := &lruEntry{key: key, value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		e.expires = clock.Now(s.Clock).Add(ttl)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.put(e)
+	return nil
+}
+
+// put stores e in front, evicting from the back past MaxItems. The caller holds mu.
+func (s *LRU) put(e *lruEntry) {
+	if el := s.element(e.key); el != nil {
+		el.Value = e
+		s.order.MoveToFront(el)
+		return
+	}
+	s.entries[e.key] = s.order.PushFront(e)
+
+	max := s.MaxItems
+	if max <= 0 {
+		max = 10000
+	}
+	for s.order.Len() > max {
+		last := s.order.Back()
+		s.order.Remove(last)
+		delete(s.entries, last.Value.(*lruEntry).key)
+		s.evicted++
+	}
+}
+
+func (s *LRU) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el := s.element(key); el != nil {
+		s.order.Remove(el)
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+func (s *LRU) Keys(ctx context.Context, prefix string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []string
+	for key := range s.entries {
+		if strings.HasPrefix(key, prefix) && s.element(key) != nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// Increment keeps the expiry of an existing counter and counts as a use of it
+func (s *LRU) Increment(ctx context.Context, key string, by int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := &lruEntry{key: key}
+	var n int64
+	if el := s.element(key); el != nil {
+		current := el.Value.(*lruEntry)
+		var err error
+		if n, err = strconv.ParseInt(string(current.value), 10, 64); err != nil {
+			return 0, errors.New("cache: " + key + " is not a counter")
+		}
+		e.expires = current.expires
+	}
+
+	n += by
+	e.value = []byte(strconv.FormatInt(n, 10))
+	s.put(e)
+
+	return n, nil
+}
+
+// Len returns how many entries are kept, expired ones included until they are next read
+func (s *LRU) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.entries)
+}
+
+// Evicted returns how many entries were forgotten to make room for others
+func (s *LRU) Evicted() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.evicted
+}
@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+)
+
+func TestLRU(t *testing.T) {
+	store := NewLRU(2)
+	c := NewDriverCache(store, "app")
+
+	_ = c.Set("a", "1")
+	_ = c.Set("b", "2")
+	if _, err := c.Get("a"); err != nil {
+		t.Fatal(err)
+	}
+	_ = c.Set("c", "3")
+
+	if ok, _ := c.Has("b"); ok {
+		t.Error("expected b, the least recently used, to be evicted")
+	}
+	if ok, _ := c.Has("a"); !ok {
+		t.Error("expected a to be kept after being read")
+	}
+	if store.Len() != 2 || store.Evicted() != 1 {
+		t.Errorf("expected 2 entries and 1 eviction, got %d and %d", store.Len(), store.Evicted())
+	}
+}
+
+func TestLRU_TTL(t *testing.T) {
+	now := clock.NewTest(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := NewLRU(0)
+	store.Clock = now
+	ctx := context.Background()
+
+	_ = store.Set(ctx, "short", []byte("x"), time.Minute)
+	if _, err := store.Increment(ctx, "hits", 3); err != nil {
+		t.Fatal(err)
+	}
+
+	now.Advance(time.Minute)
+	if _, found, _ := store.Get(ctx, "short"); found {
+		t.Error("expected short to have expired")
+	}
+	if keys, _ := store.Keys(ctx, ""); len(keys) != 1 || keys[0] != "hits" {
+		t.Errorf("expected only hits to be listed, got %v", keys)
+	}
+	if n, _ := store.Increment(ctx, "hits", -1); n != 2 {
+		t.Errorf("expected 2, got %d", n)
+	}
+
+	_ = store.Set(ctx, "word", []byte("foo"), 0)
+	if _, err := store.Increment(ctx, "word", 1); err == nil {
+		t.Error("expected an error incrementing a value which is not a counter")
+	}
+}
@@ -0,0 +1,361 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrKey is returned for a key memcached cannot store: longer than 250 bytes, or with spaces
+// or control characters
+var ErrKey = errors.New("cache: memcached: invalid key")
+
+// maxRelative is the longest ttl memcached takes as a number of seconds; longer ones are sent
+// as the unix time the value expires at
+const maxRelative = 30 * 24 * time.Hour
+
+// Memcached is a Store on memcached servers, which speaks the text protocol itself. Keys are
+// spread over Servers by hash, so every instance of the app must list them in the same order.
+// Keys lists the keys with "lru_crawler metadump", which needs memcached 1.4.31 or later.
+type Memcached struct {
+	// Servers are the addresses of the servers, e.g. "localhost:11211"
+	Servers []string
+	// Timeout bounds every operation without a deadline of its own; 1 second by default
+	Timeout time.Duration
+	// MaxIdle is how many connections are kept open per server; 2 by default
+	MaxIdle int
+
+	mu   sync.Mutex
+	idle map[string][]*mcConn
+}
+
+// NewMemcached returns a store on servers
+func NewMemcached(servers ...string) *Memcached {
+	return &Memcached{Servers: servers, Timeout: time.Second, MaxIdle: 2}
+}
+
+type mcConn struct {
+	net.Conn
+	rw *bufio.ReadWriter
+}
+
+// mcError is an error answered by the server, after which the connection is still usable
+type mcError struct {
+	msg string
+}
+
+func (e *mcError) Error() string {
+	return "cache: memcached: " + e.msg
+}
+
+// server picks the server of key
+func (m *Memcached) server(key string) (string, error) {
+	switch len(m.Servers) {
+	case 0:
+		return "", errors.New("cache: memcached: no servers")
+	case 1:
+		return m.Servers[0], nil
+	}
+	return m.Servers[crc32.ChecksumIEEE([]byte(key))%uint32(len(m.Servers))], nil
+}
+
+func (m *Memcached) conn(ctx context.Context, server string) (*mcConn, error) {
+	m.mu.Lock()
+	if n := len(m.idle[server]); n > 0 {
+		c := m.idle[server][n-1]
+		m.idle[server] = m.idle[server][:n-1]
+		m.mu.Unlock()
+		return c, nil
+	}
+	m.mu.Unlock()
+
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", server)
+	if err != nil {
+		return nil, err
+	}
+	return &mcConn{Conn: nc, rw: bufio.NewReadWriter(bufio.NewReader(nc), bufio.NewWriter(nc))}, nil
+}
+
+func (m *Memcached) release(server string, c *mcConn) {
+	max := m.MaxIdle
+	if max <= 0 {
+		max = 2
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.idle == nil {
+		m.idle = map[string][]*mcConn{}
+	}
+	if len(m.idle[server]) >= max {
+		c.Close()
+		return
+	}
+	m.idle[server] = append(m.idle[server], c)
+}
+
+// with runs fn on a connection to server, which is closed afterwards unless fn succeeded or
+// failed with an answer of the server
+func (m *Memcached) with(ctx context.Context, server string, fn func(c *mcConn) error) error {
+	c, err := m.conn(ctx, server)
+	if err != nil {
+		return err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		timeout := m.Timeout
+		if timeout <= 0 {
+			timeout = time.Second
+		}
+		deadline = time.Now().Add(timeout)
+	}
+	c.SetDeadline(deadline)
+
+	err = fn(c)
+	var answered *mcError
+	if err == nil || errors.As(err, &answered) {
+		m.release(server, c)
+	} else {
+		c.Close()
+	}
+	return err
+}
+
+// withKey runs fn on a connection to the server of key
+func (m *Memcached) withKey(ctx context.Context, key string, fn func(c *mcConn) error) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	server, err := m.server(key)
+	if err != nil {
+		return err
+	}
+	return m.with(ctx, server, fn)
+}
+
+func checkKey(key string) error {
+	if key == "" || len(key) > 250 {
+		return ErrKey
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return ErrKey
+		}
+	}
+	return nil
+}
+
+// line reads a line of the answer, turning the error lines into errors
+func (c *mcConn) line() (string, error) {
+	l, err := c.rw.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	l = strings.TrimSuffix(l, "\r\n")
+
+	switch {
+	case l == "ERROR":
+		return "", &mcError{msg: "unknown command"}
+	case strings.HasPrefix(l, "CLIENT_ERROR "), strings.HasPrefix(l, "SERVER_ERROR "):
+		return "", &mcError{msg: strings.ToLower(l)}
+	}
+	return l, nil
+}
+
+// command sends a command, followed by its data unless data is nil, and returns the answer
+func (c *mcConn) command(data []byte, format string, args ...interface{}) (string, error) {
+	fmt.Fprintf(c.rw, format+"\r\n", args...)
+	if data != nil {
+		c.rw.Write(data)
+		c.rw.WriteString("\r\n")
+	}
+	if err := c.rw.Flush(); err != nil {
+		return "", err
+	}
+	return c.line()
+}
+
+// get reads the value of key with its cas, with found false when it is missing
+func (c *mcConn) get(key string) (value []byte, casID uint64, found bool, err error) {
+	l, err := c.command(nil, "gets %s", key)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if l == "END" {
+		return nil, 0, false, nil
+	}
+
+	// VALUE <key> <flags> <bytes> <cas>
+	f := strings.Fields(l)
+	if len(f) != 5 || f[0] != "VALUE" {
+		return nil, 0, false, fmt.Errorf("cache: memcached: unexpected answer %q", l)
+	}
+	size, err := strconv.Atoi(f[3])
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if casID, err = strconv.ParseUint(f[4], 10, 64); err != nil {
+		return nil, 0, false, err
+	}
+
+	value = make([]byte, size+2)
+	if _, err := io.ReadFull(c.rw, value); err != nil {
+		return nil, 0, false, err
+	}
+	if l, err := c.line(); err != nil || l != "END" {
+		return nil, 0, false, fmt.Errorf("cache: memcached: unexpected answer %q", l)
+	}
+	return value[:size], casID, true, nil
+}
+
+func (m *Memcached) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	var value []byte
+	var found bool
+	err := m.withKey(ctx, key, func(c *mcConn) (err error) {
+		value, _, found, err = c.get(key)
+		return err
+	})
+	return value, found, err
+}
+
+// exptime is ttl as memcached takes it
+func exptime(ttl time.Duration) int64 {
+	switch {
+	case ttl <= 0:
+		return 0
+	case ttl > maxRelative:
+		return time.Now().Add(ttl).Unix()
+	case ttl < time.Second:
+		return 1
+	}
+	return int64(ttl / time.Second)
+}
+
+func (m *Memcached) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return m.withKey(ctx, key, func(c *mcConn) error {
+		l, err := c.command(value, "set %s 0 %d %d", key, exptime(ttl), len(value))
+		if err == nil && l != "STORED" {
+			err = &mcError{msg: strings.ToLower(l)}
+		}
+		return err
+	})
+}
+
+func (m *Memcached) Delete(ctx context.Context, key string) error {
+	return m.withKey(ctx, key, func(c *mcConn) error {
+		l, err := c.command(nil, "delete %s", key)
+		if err == nil && l != "DELETED" && l != "NOT_FOUND" {
+			err = &mcError{msg: strings.ToLower(l)}
+		}
+		return err
+	})
+}
+
+// Increment changes the counter with gets and cas, retrying when another client changed it
+// in between, so counters can go below zero unlike with incr and decr
+func (m *Memcached) Increment(ctx context.Context, key string, by int64) (int64, error) {
+	var n int64
+	err := m.withKey(ctx, key, func(c *mcConn) error {
+		for {
+			value, casID, found, err := c.get(key)
+			if err != nil {
+				return err
+			}
+
+			current := int64(0)
+			if found {
+				if current, err = strconv.ParseInt(string(value), 10, 64); err != nil {
+					return &mcError{msg: key + " is not a counter"}
+				}
+			}
+			n = current + by
+			b := []byte(strconv.FormatInt(n, 10))
+
+			var l string
+			if found {
+				l, err = c.command(b, "cas %s 0 0 %d %d", key, len(b), casID)
+			} else {
+				l, err = c.command(b, "add %s 0 0 %d", key, len(b))
+			}
+			if err != nil {
+				return err
+			}
+			switch l {
+			case "STORED":
+				return nil
+			case "EXISTS", "NOT_FOUND", "NOT_STORED":
+				continue
+			}
+			return &mcError{msg: strings.ToLower(l)}
+		}
+	})
+	return n, err
+}
+
+// Keys lists the keys of every server starting with prefix
+func (m *Memcached) Keys(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	for _, server := range m.Servers {
+		err := m.with(ctx, server, func(c *mcConn) error {
+			l, err := c.command(nil, "lru_crawler metadump all")
+			for ; err == nil && l != "END"; l, err = c.line() {
+				if strings.HasPrefix(l, "BUSY") {
+					return &mcError{msg: strings.ToLower(l)}
+				}
+				// key=<uri encoded key> exp=... la=... cas=... fetch=... cls=... size=...
+				f := strings.Fields(l)
+				if len(f) == 0 || !strings.HasPrefix(f[0], "key=") {
+					continue
+				}
+				key, uerr := url.PathUnescape(strings.TrimPrefix(f[0], "key="))
+				if uerr == nil && strings.HasPrefix(key, prefix) {
+					keys = append(keys, key)
+				}
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// Ping checks that every server answers
+func (m *Memcached) Ping() error {
+	for _, server := range m.Servers {
+		err := m.with(context.Background(), server, func(c *mcConn) error {
+			_, err := c.command(nil, "version")
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the idle connections
+func (m *Memcached) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for server, conns := range m.idle {
+		for _, c := range conns {
+			c.Close()
+		}
+		delete(m.idle, server)
+	}
+	return nil
+}
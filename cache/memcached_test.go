@@ -0,0 +1,185 @@
+package cache
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMemcached answers the commands Memcached sends, ignoring expiry
+type fakeMemcached struct {
+	mu    sync.Mutex
+	items map[string][]byte
+	cas   map[string]uint64
+	next  uint64
+}
+
+func startMemcached(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	f := &fakeMemcached{items: map[string][]byte{}, cas: map[string]uint64{}}
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(c)
+		}
+	}()
+	return l.Addr().String()
+}
+
+func (f *fakeMemcached) serve(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		args := strings.Fields(line)
+		if len(args) == 0 {
+			continue
+		}
+
+		var data []byte
+		switch args[0] {
+		case "set", "add", "cas":
+			size, _ := strconv.Atoi(args[4])
+			data = make([]byte, size+2)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return
+			}
+			data = data[:size]
+		}
+
+		f.mu.Lock()
+		switch args[0] {
+		case "gets":
+			if v, ok := f.items[args[1]]; ok {
+				fmt.Fprintf(c, "VALUE %s 0 %d %d\r\n%s\r\n", args[1], len(v), f.cas[args[1]], v)
+			}
+			fmt.Fprint(c, "END\r\n")
+		case "set", "add", "cas":
+			_, exists := f.items[args[1]]
+			switch {
+			case args[0] == "add" && exists:
+				fmt.Fprint(c, "NOT_STORED\r\n")
+			case args[0] == "cas" && !exists:
+				fmt.Fprint(c, "NOT_FOUND\r\n")
+			case args[0] == "cas" && args[5] != strconv.FormatUint(f.cas[args[1]], 10):
+				fmt.Fprint(c, "EXISTS\r\n")
+			default:
+				f.next++
+				f.items[args[1]], f.cas[args[1]] = data, f.next
+				fmt.Fprint(c, "STORED\r\n")
+			}
+		case "delete":
+			if _, ok := f.items[args[1]]; !ok {
+				fmt.Fprint(c, "NOT_FOUND\r\n")
+			} else {
+				delete(f.items, args[1])
+				fmt.Fprint(c, "DELETED\r\n")
+			}
+		case "lru_crawler":
+			for k := range f.items {
+				fmt.Fprintf(c, "key=%s exp=-1 la=0 cas=1 fetch=no cls=1 size=1\r\n", url.PathEscape(k))
+			}
+			fmt.Fprint(c, "END\r\n")
+		case "version":
+			fmt.Fprint(c, "VERSION 1.6.21\r\n")
+		default:
+			fmt.Fprint(c, "ERROR\r\n")
+		}
+		f.mu.Unlock()
+	}
+}
+
+func TestMemcached(t *testing.T) {
+	store := NewMemcached(startMemcached(t), startMemcached(t))
+	defer store.Close()
+	c := NewDriverCache(store, "app")
+
+	if err := store.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.SetWithTTL("user:1", "Ada", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	_ = c.Set("user:2", "Grace")
+	_ = c.Set("other", "x")
+
+	if v, err := c.Get("user:1"); err != nil || v != "Ada" {
+		t.Errorf("expected Ada, got %v (%v)", v, err)
+	}
+	if _, err := c.Get("missing"); err != ErrMissing {
+		t.Errorf("expected ErrMissing, got %v", err)
+	}
+
+	if err := c.EmptyByMatch("user:"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := c.Has("user:2"); ok {
+		t.Error("expected user:2 to be emptied from its server")
+	}
+	if ok, _ := c.Has("other"); !ok {
+		t.Error("expected other to be kept")
+	}
+
+	if err := c.Set("with space", "x"); err != ErrKey {
+		t.Errorf("expected ErrKey, got %v", err)
+	}
+}
+
+func TestMemcached_Increment(t *testing.T) {
+	store := NewMemcached(startMemcached(t))
+	c := NewDriverCache(store, "app")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Increment("hits", 1); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n, err := c.Decrement("hits", 15); err != nil || n != -5 {
+		t.Fatalf("expected -5, got %d (%v)", n, err)
+	}
+	if v, _ := c.Get("hits"); v != int64(-5) {
+		t.Errorf("expected Get to return int64 -5, got %v", v)
+	}
+}
+
+func TestExptime(t *testing.T) {
+	if got := exptime(0); got != 0 {
+		t.Errorf("expected no expiry, got %d", got)
+	}
+	if got := exptime(time.Millisecond); got != 1 {
+		t.Errorf("expected a second at least, got %d", got)
+	}
+	if got := exptime(time.Hour); got != 3600 {
+		t.Errorf("expected 3600, got %d", got)
+	}
+	if got := exptime(60 * 24 * time.Hour); got < time.Now().Unix() {
+		t.Errorf("expected a unix time past 30 days, got %d", got)
+	}
+}
@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+)
+
+// ErrMissing is returned by MemoryCache.Get when a key is not cached
+var ErrMissing = errors.New("cache: key not found")
+
+// MemoryCache is a process-local cache, used as a fallback when the real store is unavailable
+type MemoryCache struct {
+	Prefix string
+	// Clock tells when the entries expire; the time of the machine when nil
+	Clock   clock.Clock
+	mu      sync.RWMutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// NewMemoryCache returns an empty in-memory cache
+func NewMemoryCache(prefix string) *MemoryCache {
+	return &MemoryCache{
+		Prefix:  prefix,
+		entries: make(map[string]memoryEntry),
+	}
+}
+
+func (c *MemoryCache) key(str string) string {
+	return fmt.Sprintf("%s:%s", c.Prefix, str)
+}
+
+func (c *MemoryCache) Has(str string) (bool, error) {
+	_, err := c.Get(str)
+	if err != nil {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func (c *MemoryCache) Get(str string) (interface{}, error) {
+	c.mu.RLock()
+	entry, ok := c.entries[c.key(str)]
+	c.mu.RUnlock()
+
+	if !ok || (!entry.expires.IsZero() && clock.Now(c.Clock).After(entry.expires)) {
+		return nil, ErrMissing
+	}
+
+	return entry.value, nil
+}
+
+func (c *MemoryCache) Set(str string, value interface{}, expires ...int) error {
+	var ttl time.Duration
+	if len(expires) > 0 {
+		ttl = time.Duration(expires[0]) * time.Second
+	}
+
+	return c.SetWithTTL(str, value, ttl)
+}
+
+func (c *MemoryCache) SetWithTTL(str string, value interface{}, ttl time.Duration) error {
+	entry := memoryEntry{value: value}
+	if ttl > 0 {
+		entry.expires = clock.Now(c.Clock).Add(ttl)
+	}
+
+	c.mu.Lock()
+	c.entries[c.key(str)] = entry
+	c.mu.Unlock()
+
+	return nil
+}
+
+func (c *MemoryCache) Remember(str string, ttl time.Duration, fn func() (interface{}, error)) (interface{}, error) {
+	return remember(c, str, ttl, fn)
+}
+
+// Increment keeps the expiry of an existing counter, as redis does
+func (c *MemoryCache) Increment(str string, by int64) (int64, error) {
+	key := c.key(str)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || (!entry.expires.IsZero() && clock.Now(c.Clock).After(entry.expires)) {
+		entry = memoryEntry{value: int64(0)}
+	}
+	n, ok := entry.value.(int64)
+	if !ok {
+		return 0, fmt.Errorf("cache: %s is not a counter", str)
+	}
+
+	entry.value = n + by
+	c.entries[key] = entry
+
+	return n + by, nil
+}
+
+func (c *MemoryCache) Decrement(str string, by int64) (int64, error) {
+	return c.Increment(str, -by)
+}
+
+func (c *MemoryCache) Forget(str string) error {
+	c.mu.Lock()
+	delete(c.entries, c.key(str))
+	c.mu.Unlock()
+
+	return nil
+}
+
+func (c *MemoryCache) EmptyByMatch(str string) error {
+	prefix := c.key(str)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k := range c.entries {
+		if strings.HasPrefix(k, prefix) {
+			delete(c.entries, k)
+		}
+	}
+
+	return nil
+}
+
+func (c *MemoryCache) Empty() error {
+	c.mu.Lock()
+	c.entries = make(map[string]memoryEntry)
+	c.mu.Unlock()
+
+	return nil
+}
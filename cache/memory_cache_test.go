@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+)
+
+func TestMemoryCache_Clock(t *testing.T) {
+	c := NewMemoryCache("test")
+	now := clock.NewTest(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	c.Clock = now
+
+	if err := c.SetWithTTL("short", "foo", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Increment("hits", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	now.Advance(59 * time.Second)
+	if v, _ := c.Get("short"); v != "foo" {
+		t.Errorf("expected foo before the ttl, got %v", v)
+	}
+
+	now.Advance(2 * time.Second)
+	if _, err := c.Get("short"); err != ErrMissing {
+		t.Errorf("expected short to have expired, got %v", err)
+	}
+	if v, _ := c.Get("hits"); v != int64(1) {
+		t.Errorf("expected a counter without ttl to be kept, got %v", v)
+	}
+}
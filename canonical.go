@@ -0,0 +1,22 @@
+package goravel
+
+import (
+	"strings"
+
+	"github.com/namnguyen191/goravel/canonical"
+)
+
+// createCanonical redirects to CANONICAL_HOST, to https with FORCE_HTTPS, and to clean lower
+// case paths with CANONICAL_CLEAN and CANONICAL_LOWERCASE; proxies in TRUSTED_PROXIES may say
+// the client used https. Without any of these nothing is redirected.
+func (grv *Goravel) createCanonical() *canonical.Canonical {
+	c := canonical.New(grv.Env.String("CANONICAL_HOST", ""), strings.Split(grv.Env.String("TRUSTED_PROXIES", ""), ","))
+	c.HTTPS = grv.Env.Bool("FORCE_HTTPS", false)
+	c.Lowercase = grv.Env.Bool("CANONICAL_LOWERCASE", false)
+	c.Clean = grv.Env.Bool("CANONICAL_CLEAN", false)
+
+	if c.Host == "" && !c.HTTPS && !c.Lowercase && !c.Clean {
+		return nil
+	}
+	return c
+}
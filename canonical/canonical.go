@@ -0,0 +1,203 @@
+package canonical
+
+import (
+	"net"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Canonical redirects requests to the one URL of each page, so visitors, caches and search
+// engines don't see the same page under several addresses
+type Canonical struct {
+	// Host is the canonical host, e.g. "www.example.com"; requests for its www or apex
+	// counterpart are redirected to it, other hosts are left alone. Empty keeps the host.
+	Host string
+	// HTTPS redirects plain http requests to https
+	HTTPS bool
+	// Lowercase redirects paths with upper case letters to their lower case form
+	Lowercase bool
+	// Clean redirects paths with duplicate slashes and dot segments to their clean form
+	Clean bool
+	// TrustedProxies are the networks whose X-Forwarded-Proto and X-Forwarded-Host headers
+	// are believed; the headers of other clients are ignored
+	TrustedProxies []*net.IPNet
+}
+
+// New returns a Canonical for host trusting the proxies given as addresses or CIDR ranges
+func New(host string, proxies []string) *Canonical {
+	return &Canonical{
+		Host:           strings.ToLower(host),
+		TrustedProxies: ParseNetworks(proxies),
+	}
+}
+
+// ParseNetworks parses addresses and CIDR ranges, skipping the ones which are invalid
+func ParseNetworks(values []string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		if !strings.Contains(v, "/") {
+			if ip := net.ParseIP(v); ip != nil {
+				bits := 8 * net.IPv6len
+				if ip.To4() != nil {
+					ip, bits = ip.To4(), 8*net.IPv4len
+				}
+				networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			}
+			continue
+		}
+
+		if _, network, err := net.ParseCIDR(v); err == nil {
+			networks = append(networks, network)
+		}
+	}
+	return networks
+}
+
+// trusted reports whether the request came straight from one of the trusted proxies. It
+// must run before RealIP, which replaces RemoteAddr with the forwarded address.
+func (c *Canonical) trusted(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range c.TrustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwarded returns the first value of a header set by a trusted proxy
+func (c *Canonical) forwarded(r *http.Request, name string) string {
+	if !c.trusted(r) {
+		return ""
+	}
+
+	value := r.Header.Get(name)
+	if i := strings.IndexByte(value, ','); i >= 0 {
+		value = value[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// Scheme returns the scheme the client used, which is https behind a proxy terminating TLS
+func (c *Canonical) Scheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	if proto := c.forwarded(r, "X-Forwarded-Proto"); proto == "https" || proto == "http" {
+		return proto
+	}
+	return "http"
+}
+
+// RequestHost returns the host the client asked for, with its port
+func (c *Canonical) RequestHost(r *http.Request) string {
+	if host := c.forwarded(r, "X-Forwarded-Host"); host != "" {
+		return host
+	}
+	return strings.ToLower(r.Host)
+}
+
+// Target returns the canonical URL of r, or an empty string when r is already canonical
+func (c *Canonical) Target(r *http.Request) string {
+	scheme := c.Scheme(r)
+	host := c.RequestHost(r)
+	p := r.URL.EscapedPath()
+	if p == "" {
+		p = "/"
+	}
+
+	changed := false
+
+	if c.HTTPS && scheme != "https" {
+		scheme = "https"
+		changed = true
+		// the http port means nothing for https
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+	}
+
+	if c.Host != "" {
+		name, port, err := net.SplitHostPort(host)
+		if err != nil {
+			name, port = host, ""
+		}
+		if name != c.Host && counterpart(name) == c.Host {
+			host = c.Host
+			if port != "" {
+				host = net.JoinHostPort(c.Host, port)
+			}
+			changed = true
+		}
+	}
+
+	if c.Clean {
+		cleaned := path.Clean(p)
+		// keep the trailing slash, which the router settings deal with
+		if strings.HasSuffix(p, "/") && cleaned != "/" {
+			cleaned += "/"
+		}
+		if cleaned != p {
+			p = cleaned
+			changed = true
+		}
+	}
+
+	if c.Lowercase {
+		if lower := strings.ToLower(p); lower != p {
+			p = lower
+			changed = true
+		}
+	}
+
+	if !changed {
+		return ""
+	}
+
+	target := scheme + "://" + host + p
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	return target
+}
+
+// counterpart returns the apex of a www host, or the www host of an apex
+func counterpart(host string) string {
+	if strings.HasPrefix(host, "www.") {
+		return strings.TrimPrefix(host, "www.")
+	}
+	return "www." + host
+}
+
+// Middleware redirects requests for other URLs than the canonical one. GET and HEAD
+// requests are moved permanently; other methods get a 308 so clients repeat the body.
+func (c *Canonical) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		target := c.Target(r)
+		if target == "" {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		status := http.StatusMovedPermanently
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			status = http.StatusPermanentRedirect
+		}
+		http.Redirect(rw, r, target, status)
+	})
+}
@@ -0,0 +1,61 @@
+package canonical
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTarget(t *testing.T) {
+	c := New("www.example.com", []string{"10.0.0.0/8", "127.0.0.1"})
+	c.HTTPS = true
+	c.Lowercase = true
+	c.Clean = true
+
+	tests := []struct {
+		url, remote, proto, target string
+	}{
+		{"https://www.example.com/about", "1.2.3.4:1000", "", ""},
+		{"http://www.example.com/about?a=1", "1.2.3.4:1000", "", "https://www.example.com/about?a=1"},
+		{"https://example.com/about", "1.2.3.4:1000", "", "https://www.example.com/about"},
+		{"http://example.com:8080/", "1.2.3.4:1000", "", "https://www.example.com/"},
+		{"https://api.example.com/v1", "1.2.3.4:1000", "", ""},
+		{"https://www.example.com//blog/../About/", "1.2.3.4:1000", "", "https://www.example.com/about/"},
+		// behind a proxy terminating TLS
+		{"http://www.example.com/about", "10.1.2.3:1000", "https", ""},
+		{"http://www.example.com/about", "127.0.0.1:1000", "https", ""},
+		// a client claiming https itself is not believed
+		{"http://www.example.com/about", "1.2.3.4:1000", "https", "https://www.example.com/about"},
+	}
+
+	for _, e := range tests {
+		r := httptest.NewRequest(http.MethodGet, e.url, nil)
+		r.RemoteAddr = e.remote
+		if e.proto != "" {
+			r.Header.Set("X-Forwarded-Proto", e.proto)
+		}
+
+		if got := c.Target(r); got != e.target {
+			t.Errorf("%s: expected %q, got %q", e.url, e.target, got)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	c := New("example.com", nil)
+	h := c.Middleware(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "http://www.example.com/form", nil))
+	if rw.Code != http.StatusPermanentRedirect || rw.Header().Get("Location") != "http://example.com/form" {
+		t.Errorf("expected a 308 to the apex, got %d %s", rw.Code, rw.Header().Get("Location"))
+	}
+
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "http://example.com/Form", nil))
+	if rw.Code != http.StatusNoContent {
+		t.Errorf("expected canonical requests to pass, got %d", rw.Code)
+	}
+}
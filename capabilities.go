@@ -0,0 +1,110 @@
+package goravel
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Capabilities are the stores and services an app may run without, checked with grv.Has
+const (
+	CapabilityDB     = "db"
+	CapabilityCache  = "cache"
+	CapabilityRedis  = "redis"
+	CapabilityBadger = "badger"
+	CapabilityKV     = "kv"
+	CapabilityMail   = "mail"
+	CapabilitySMS    = "sms"
+)
+
+// Has reports whether the app was configured with a capability, e.g. grv.Has("db"), so
+// features can be skipped rather than fail on a nil pool
+func (grv *Goravel) Has(capability string) bool {
+	switch capability {
+	case CapabilityDB:
+		return grv.DB.Pool != nil
+	case CapabilityCache:
+		return grv.Cache != nil
+	case CapabilityRedis:
+		return redisPool != nil
+	case CapabilityBadger:
+		return badgerConn != nil
+	case CapabilityKV:
+		return grv.KV != nil
+	case CapabilityMail:
+		return grv.Mail.Host != "" || (grv.Mail.API != "" && grv.Mail.API != "smtp")
+	case CapabilitySMS:
+		return grv.SMS != nil
+	}
+	return false
+}
+
+// Require returns an error naming the capabilities feature needs but the app does not have,
+// for apps to fail at boot instead of on the first request:
+//
+//	if err := app.Require("reports", goravel.CapabilityDB, goravel.CapabilityRedis); err != nil {
+//		log.Fatal(err)
+//	}
+func (grv *Goravel) Require(feature string, capabilities ...string) error {
+	var missing []string
+	for _, c := range capabilities {
+		if !grv.Has(c) {
+			missing = append(missing, c)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%s needs %s, which is not configured", feature, strings.Join(missing, " and "))
+	}
+	return nil
+}
+
+// checkCapabilities fails the boot when a setting needs a store the app does not have, and
+// logs the features which fall back to something else
+func (grv *Goravel) checkCapabilities() error {
+	var problems []string
+
+	switch grv.config.sessionType {
+	case "redis":
+		if !grv.Has(CapabilityRedis) || !grv.config.redis.configured() {
+			problems = append(problems, "SESSION_TYPE=redis needs REDIS_HOST")
+		}
+	case "mysql", "mariadb", "postgres", "postgresql":
+		if !grv.Has(CapabilityDB) {
+			problems = append(problems, fmt.Sprintf("SESSION_TYPE=%s needs DATABASE_TYPE", grv.config.sessionType))
+		}
+	}
+
+	switch os.Getenv("CACHE") {
+	case "redis":
+		if !grv.config.redis.configured() {
+			problems = append(problems, "CACHE=redis needs REDIS_HOST")
+		}
+	case "memcached":
+		if len(grv.memcachedServers()) == 0 {
+			problems = append(problems, "CACHE=memcached needs MEMCACHED_SERVERS")
+		}
+	}
+
+	switch os.Getenv("SCHEDULER_LEADER") {
+	case "redis":
+		if !grv.Has(CapabilityRedis) {
+			problems = append(problems, "SCHEDULER_LEADER=redis needs CACHE or SESSION_TYPE set to redis")
+		}
+	case "database", "db":
+		if !grv.Has(CapabilityDB) {
+			problems = append(problems, "SCHEDULER_LEADER=database needs DATABASE_TYPE")
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("goravel: %s", strings.Join(problems, "; "))
+	}
+
+	// these fall back, so the app still boots
+	if strings.ToLower(os.Getenv("ANALYTICS")) == "true" && !grv.Has(CapabilityDB) {
+		grv.ErrorLog.Println("analytics: ANALYTICS=true needs DATABASE_TYPE, page views are not recorded")
+	}
+
+	return nil
+}
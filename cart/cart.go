@@ -0,0 +1,283 @@
+package cart
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+	"github.com/namnguyen191/goravel/money"
+)
+
+// sessionKey holds the cart of visitors who are not logged in
+const sessionKey = "_cart"
+
+// ErrCurrency is returned when an item is priced in another currency than the cart
+var ErrCurrency = errors.New("cart: item currency differs from the cart")
+
+// Item is a line in the cart; ID identifies the product (and variant) being bought
+type Item struct {
+	ID       string      `json:"id"`
+	Name     string      `json:"name"`
+	Price    money.Money `json:"price"`
+	Quantity int         `json:"quantity"`
+}
+
+// Total is the item price times its quantity
+func (i Item) Total() money.Money {
+	return i.Price.Mul(float64(i.Quantity))
+}
+
+// Cart is the set of items a visitor is about to buy
+type Cart struct {
+	UserID   int    `json:"-"`
+	Currency string `json:"currency"`
+	Items    []Item `json:"items"`
+}
+
+// Count is the number of units in the cart
+func (c *Cart) Count() int {
+	n := 0
+	for _, i := range c.Items {
+		n += i.Quantity
+	}
+
+	return n
+}
+
+func (c *Cart) find(id string) int {
+	for i := range c.Items {
+		if c.Items[i].ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Totals are the amounts of a cart
+type Totals struct {
+	Subtotal money.Money
+	Discount money.Money
+	Tax      money.Money
+	Total    money.Money
+}
+
+// Carts keeps carts in the session, or in the cart_items table once the visitor logs in
+type Carts struct {
+	Session      *scs.SessionManager
+	DB           *sql.DB
+	DatabaseType string
+	Currency     string
+	// Discount returns the amount taken off the subtotal, e.g. for a coupon in the session
+	Discount func(ctx context.Context, c *Cart, subtotal money.Money) money.Money
+	// Tax returns the tax due on the discounted subtotal
+	Tax func(ctx context.Context, c *Cart, taxable money.Money) money.Money
+	// Clock tells when the carts are saved; the time of the machine when nil
+	Clock clock.Clock
+}
+
+// New returns carts priced in currency
+func New(session *scs.SessionManager, db *sql.DB, dbType, currency string) *Carts {
+	return &Carts{Session: session, DB: db, DatabaseType: dbType, Currency: currency}
+}
+
+// Get returns the current visitor's cart
+func (cs *Carts) Get(ctx context.Context) (*Cart, error) {
+	userID := cs.Session.GetInt(ctx, "userID")
+	if userID != 0 && cs.DB != nil {
+		return cs.load(ctx, userID)
+	}
+
+	c := &Cart{Currency: cs.Currency}
+	if raw := cs.Session.GetString(ctx, sessionKey); raw != "" {
+		if err := json.Unmarshal([]byte(raw), c); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// Add puts quantity of item in the cart, adding to the quantity already there
+func (cs *Carts) Add(ctx context.Context, item Item) error {
+	if item.Price.Currency != cs.Currency {
+		return ErrCurrency
+	}
+	if item.Quantity < 1 {
+		item.Quantity = 1
+	}
+
+	return cs.change(ctx, func(c *Cart) {
+		if i := c.find(item.ID); i >= 0 {
+			c.Items[i].Quantity += item.Quantity
+			c.Items[i].Price = item.Price
+			return
+		}
+		c.Items = append(c.Items, item)
+	})
+}
+
+// Update sets the quantity of an item; zero removes it
+func (cs *Carts) Update(ctx context.Context, id string, quantity int) error {
+	return cs.change(ctx, func(c *Cart) {
+		i := c.find(id)
+		if i < 0 {
+			return
+		}
+		if quantity < 1 {
+			c.Items = append(c.Items[:i], c.Items[i+1:]...)
+			return
+		}
+		c.Items[i].Quantity = quantity
+	})
+}
+
+// Remove takes an item out of the cart
+func (cs *Carts) Remove(ctx context.Context, id string) error {
+	return cs.Update(ctx, id, 0)
+}
+
+// Clear empties the cart, e.g. once the order is placed
+func (cs *Carts) Clear(ctx context.Context) error {
+	return cs.change(ctx, func(c *Cart) { c.Items = nil })
+}
+
+// Totals calculates the subtotal, then applies the discount and tax hooks
+func (cs *Carts) Totals(ctx context.Context, c *Cart) (Totals, error) {
+	t := Totals{
+		Subtotal: money.New(0, c.Currency),
+		Discount: money.New(0, c.Currency),
+		Tax:      money.New(0, c.Currency),
+	}
+
+	var err error
+	for _, i := range c.Items {
+		if t.Subtotal, err = t.Subtotal.Add(i.Total()); err != nil {
+			return t, err
+		}
+	}
+
+	if cs.Discount != nil {
+		t.Discount = cs.Discount(ctx, c, t.Subtotal)
+		// a discount never makes the cart pay out
+		if t.Discount.Amount > t.Subtotal.Amount {
+			t.Discount.Amount = t.Subtotal.Amount
+		}
+	}
+
+	taxable, err := t.Subtotal.Sub(t.Discount)
+	if err != nil {
+		return t, err
+	}
+
+	if cs.Tax != nil {
+		t.Tax = cs.Tax(ctx, c, taxable)
+	}
+
+	t.Total, err = taxable.Add(t.Tax)
+
+	return t, err
+}
+
+// Merge moves the session cart into the stored cart of a user who just logged in; call it
+// right after putting userID in the session. Quantities of items in both carts are added up.
+func (cs *Carts) Merge(ctx context.Context, userID int) error {
+	raw := cs.Session.GetString(ctx, sessionKey)
+	if raw == "" || cs.DB == nil {
+		return nil
+	}
+
+	guest := &Cart{}
+	if err := json.Unmarshal([]byte(raw), guest); err != nil {
+		return err
+	}
+
+	c, err := cs.load(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range guest.Items {
+		if i := c.find(item.ID); i >= 0 {
+			c.Items[i].Quantity += item.Quantity
+			continue
+		}
+		c.Items = append(c.Items, item)
+	}
+
+	if err := cs.save(ctx, c); err != nil {
+		return err
+	}
+
+	cs.Session.Remove(ctx, sessionKey)
+
+	return nil
+}
+
+func (cs *Carts) change(ctx context.Context, fn func(c *Cart)) error {
+	c, err := cs.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	fn(c)
+
+	if c.UserID != 0 {
+		return cs.save(ctx, c)
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	cs.Session.Put(ctx, sessionKey, string(b))
+
+	return nil
+}
+
+func (cs *Carts) load(ctx context.Context, userID int) (*Cart, error) {
+	rows, err := cs.DB.QueryContext(ctx, database.Rebind(cs.DatabaseType, "select item_id, name, price, currency, quantity from cart_items where user_id = ? order by id"), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	c := &Cart{UserID: userID, Currency: cs.Currency}
+	for rows.Next() {
+		var i Item
+		if err := rows.Scan(&i.ID, &i.Name, &i.Price.Amount, &i.Price.Currency, &i.Quantity); err != nil {
+			return nil, err
+		}
+		c.Items = append(c.Items, i)
+	}
+
+	return c, rows.Err()
+}
+
+// save replaces the stored items of the user's cart
+func (cs *Carts) save(ctx context.Context, c *Cart) error {
+	tx, err := cs.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, database.Rebind(cs.DatabaseType, "delete from cart_items where user_id = ?"), c.UserID); err != nil {
+		return err
+	}
+
+	now := clock.Now(cs.Clock)
+	for _, i := range c.Items {
+		_, err := tx.ExecContext(ctx, database.Rebind(cs.DatabaseType, "insert into cart_items (user_id, item_id, name, price, currency, quantity, updated_at) values (?, ?, ?, ?, ?, ?, ?)"),
+			c.UserID, i.ID, i.Name, i.Price.Amount, i.Price.Currency, i.Quantity, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
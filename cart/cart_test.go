@@ -0,0 +1,51 @@
+package cart
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/namnguyen191/goravel/money"
+)
+
+func TestSessionCart(t *testing.T) {
+	session := scs.New()
+	cs := New(session, nil, "postgres", "USD")
+	cs.Discount = func(ctx context.Context, c *Cart, subtotal money.Money) money.Money { return subtotal.Percent(10) }
+	cs.Tax = func(ctx context.Context, c *Cart, taxable money.Money) money.Money { return taxable.Percent(5) }
+
+	h := session.LoadAndSave(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		_ = cs.Add(ctx, Item{ID: "shirt", Price: money.New(2000, "USD")})
+		_ = cs.Add(ctx, Item{ID: "shirt", Price: money.New(2000, "USD"), Quantity: 2})
+		_ = cs.Add(ctx, Item{ID: "mug", Price: money.New(1000, "USD")})
+		_ = cs.Update(ctx, "mug", 2)
+		_ = cs.Add(ctx, Item{ID: "hat", Price: money.New(1500, "USD")})
+		_ = cs.Remove(ctx, "hat")
+
+		if err := cs.Add(ctx, Item{ID: "scarf", Price: money.New(1500, "EUR")}); err != ErrCurrency {
+			t.Error("expected currency error, got", err)
+		}
+
+		c, err := cs.Get(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(c.Items) != 2 || c.Count() != 5 {
+			t.Errorf("unexpected cart %+v", c)
+		}
+
+		totals, err := cs.Totals(ctx, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if totals.Subtotal.Amount != 8000 || totals.Discount.Amount != 800 || totals.Tax.Amount != 360 || totals.Total.Amount != 7560 {
+			t.Errorf("unexpected totals %+v", totals)
+		}
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
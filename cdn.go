@@ -0,0 +1,30 @@
+package goravel
+
+import (
+	"os"
+	"strings"
+
+	"github.com/namnguyen191/goravel/cdn"
+)
+
+// createCDN builds the CDN from CDN_DRIVER (cloudflare, fastly or cloudfront) and CDN_HOST.
+// A host without a driver still rewrites asset urls.
+func (grv *Goravel) createCDN() *cdn.CDN {
+	var driver cdn.Driver
+
+	switch strings.ToLower(os.Getenv("CDN_DRIVER")) {
+	case "cloudflare":
+		driver = &cdn.Cloudflare{ZoneID: os.Getenv("CDN_ZONE"), Token: os.Getenv("CDN_TOKEN"), Client: grv.HTTPClient("cdn")}
+	case "fastly":
+		driver = &cdn.Fastly{ServiceID: os.Getenv("CDN_ZONE"), Token: os.Getenv("CDN_TOKEN"), Client: grv.HTTPClient("cdn")}
+	case "cloudfront":
+		driver = &cdn.CloudFront{
+			DistributionID:  os.Getenv("CDN_ZONE"),
+			AccessKeyID:     os.Getenv("CDN_KEY_ID"),
+			SecretAccessKey: os.Getenv("CDN_TOKEN"),
+			Client:          grv.HTTPClient("cdn"),
+		}
+	}
+
+	return cdn.New(driver, os.Getenv("CDN_HOST"), grv.Server.URL)
+}
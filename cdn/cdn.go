@@ -0,0 +1,82 @@
+package cdn
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// ErrUnsupported is returned when a driver can't purge the requested way
+var ErrUnsupported = errors.New("cdn: operation not supported by driver")
+
+// Driver purges content from a CDN
+type Driver interface {
+	PurgeURLs(ctx context.Context, urls []string) error
+	PurgeTags(ctx context.Context, tags []string) error
+}
+
+// CDN rewrites asset urls to the CDN host and purges content through the driver
+type CDN struct {
+	Driver Driver
+	// Host is the CDN origin assets are served from, e.g. https://cdn.example.com
+	Host string
+	// BaseURL turns relative paths into the absolute urls purge requests need
+	BaseURL string
+}
+
+// New returns a CDN serving assets from host
+func New(driver Driver, host, baseURL string) *CDN {
+	return &CDN{
+		Driver:  driver,
+		Host:    strings.TrimSuffix(host, "/"),
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// URL returns the address of an asset on the CDN; without a host the path is returned unchanged
+func (c *CDN) URL(path string) string {
+	if c == nil || c.Host == "" || isAbsolute(path) {
+		return path
+	}
+
+	return c.Host + "/" + strings.TrimPrefix(path, "/")
+}
+
+// Purge removes the given paths or urls from the CDN
+func (c *CDN) Purge(ctx context.Context, paths ...string) error {
+	if c.Driver == nil || len(paths) == 0 {
+		return nil
+	}
+
+	urls := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if !isAbsolute(p) {
+			p = c.BaseURL + "/" + strings.TrimPrefix(p, "/")
+		}
+		urls = append(urls, p)
+	}
+
+	return c.Driver.PurgeURLs(ctx, urls)
+}
+
+// PurgeTags removes every response labelled with one of the tags (see Goravel.CacheTags)
+func (c *CDN) PurgeTags(ctx context.Context, tags ...string) error {
+	if c.Driver == nil || len(tags) == 0 {
+		return nil
+	}
+
+	return c.Driver.PurgeTags(ctx, tags)
+}
+
+// TemplateFuncs provides the cdn helper to views
+func (c *CDN) TemplateFuncs(r *http.Request) template.FuncMap {
+	return template.FuncMap{
+		"cdn": c.URL,
+	}
+}
+
+func isAbsolute(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "//")
+}
@@ -0,0 +1,95 @@
+package cdn
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestURL(t *testing.T) {
+	c := New(nil, "https://cdn.example.com/", "https://example.com")
+
+	tests := []struct {
+		in, want string
+	}{
+		{"/public/css/app.css", "https://cdn.example.com/public/css/app.css"},
+		{"img/logo.png", "https://cdn.example.com/img/logo.png"},
+		{"https://other.com/x.js", "https://other.com/x.js"},
+	}
+
+	for _, e := range tests {
+		if got := c.URL(e.in); got != e.want {
+			t.Errorf("%s: expected %s, got %s", e.in, e.want, got)
+		}
+	}
+
+	if got := New(nil, "", "").URL("/a.css"); got != "/a.css" {
+		t.Error("expected path unchanged without a cdn host, got", got)
+	}
+}
+
+func TestCloudflare(t *testing.T) {
+	var payload map[string][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/zones/zone1/purge_cache" || r.Header.Get("Authorization") != "Bearer secret" {
+			rw.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer srv.Close()
+
+	c := New(&Cloudflare{ZoneID: "zone1", Token: "secret", Endpoint: srv.URL}, "", "https://example.com")
+
+	if err := c.Purge(context.Background(), "/blog/1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload["files"]) != 1 || payload["files"][0] != "https://example.com/blog/1" {
+		t.Error("unexpected purge payload", payload)
+	}
+}
+
+func TestFastlyTags(t *testing.T) {
+	var key string
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		key = r.Header.Get("Surrogate-Key")
+	}))
+	defer srv.Close()
+
+	c := New(&Fastly{ServiceID: "svc", Token: "t", Endpoint: srv.URL}, "", "")
+	if err := c.PurgeTags(context.Background(), "posts", "post-1"); err != nil {
+		t.Fatal(err)
+	}
+	if key != "posts post-1" {
+		t.Error("unexpected surrogate key header", key)
+	}
+}
+
+func TestCloudFront(t *testing.T) {
+	var body, auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body, auth = string(b), r.Header.Get("Authorization")
+		rw.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	driver := &CloudFront{DistributionID: "D1", AccessKeyID: "AKID", SecretAccessKey: "s", Endpoint: srv.URL}
+	if err := driver.PurgeURLs(context.Background(), []string{"https://example.com/a.css"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(body, "<Path>/a.css</Path>") || !strings.Contains(body, "<Quantity>1</Quantity>") {
+		t.Error("unexpected invalidation batch", body)
+	}
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") {
+		t.Error("request was not signed", auth)
+	}
+	if driver.PurgeTags(context.Background(), []string{"x"}) != ErrUnsupported {
+		t.Error("expected tag purging to be unsupported")
+	}
+}
@@ -0,0 +1,60 @@
+package cdn
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Cloudflare purges through the Cloudflare API; tag purging needs an Enterprise zone
+type Cloudflare struct {
+	ZoneID string
+	Token  string
+	Client *http.Client
+	// Endpoint is the address of the Cloudflare API, https://api.cloudflare.com/client/v4 by default
+	Endpoint string
+}
+
+func (cf *Cloudflare) PurgeURLs(ctx context.Context, urls []string) error {
+	// the API accepts at most 30 files per request
+	for len(urls) > 0 {
+		n := len(urls)
+		if n > 30 {
+			n = 30
+		}
+
+		if err := cf.purge(ctx, map[string][]string{"files": urls[:n]}); err != nil {
+			return err
+		}
+		urls = urls[n:]
+	}
+
+	return nil
+}
+
+func (cf *Cloudflare) PurgeTags(ctx context.Context, tags []string) error {
+	return cf.purge(ctx, map[string][]string{"tags": tags})
+}
+
+func (cf *Cloudflare) purge(ctx context.Context, payload map[string][]string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	endpoint := cf.Endpoint
+	if endpoint == "" {
+		endpoint = "https://api.cloudflare.com/client/v4"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/zones/%s/purge_cache", endpoint, cf.ZoneID), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+cf.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	return send(client(cf.Client), req)
+}
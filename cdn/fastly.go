@@ -0,0 +1,52 @@
+package cdn
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Fastly purges single urls and surrogate keys through the Fastly API
+type Fastly struct {
+	ServiceID string
+	Token     string
+	Client    *http.Client
+	Endpoint  string
+}
+
+func (f *Fastly) endpoint() string {
+	if f.Endpoint == "" {
+		return "https://api.fastly.com"
+	}
+	return f.Endpoint
+}
+
+func (f *Fastly) PurgeURLs(ctx context.Context, urls []string) error {
+	for _, u := range urls {
+		u = strings.TrimPrefix(strings.TrimPrefix(u, "https://"), "http://")
+
+		req, err := http.NewRequestWithContext(ctx, "POST", f.endpoint()+"/purge/"+u, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Fastly-Key", f.Token)
+
+		if err := send(client(f.Client), req); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *Fastly) PurgeTags(ctx context.Context, tags []string) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/service/%s/purge", f.endpoint(), f.ServiceID), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Fastly-Key", f.Token)
+	req.Header.Set("Surrogate-Key", strings.Join(tags, " "))
+
+	return send(client(f.Client), req)
+}
@@ -0,0 +1,33 @@
+package cdn
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+var defaultClient = &http.Client{Timeout: 30 * time.Second}
+
+func client(c *http.Client) *http.Client {
+	if c == nil {
+		return defaultClient
+	}
+	return c
+}
+
+func send(c *http.Client, req *http.Request) error {
+	res, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("cdn: purge failed with %s: %s", res.Status, body)
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package goravel
+
+import (
+	"os"
+	"strings"
+
+	"github.com/namnguyen191/goravel/clientinfo"
+)
+
+// createClientInfo picks the geolocation driver from GEOIP_DRIVER: "maxmind-db" reads the
+// database at GEOIP_DATABASE, "maxmind" calls the GeoIP2 web service. Without one, only the
+// CF-IPCountry header is used.
+func (grv *Goravel) createClientInfo() (*clientinfo.ClientInfo, error) {
+	var resolver clientinfo.Resolver
+
+	switch strings.ToLower(os.Getenv("GEOIP_DRIVER")) {
+	case "maxmind-db":
+		path := os.Getenv("GEOIP_DATABASE")
+		if path == "" {
+			path = grv.RootPath + "/data/GeoLite2-Country.mmdb"
+		}
+
+		db, err := clientinfo.OpenMaxMindDB(path)
+		if err != nil {
+			return nil, err
+		}
+		resolver = db
+	case "maxmind":
+		resolver = clientinfo.NewCached(&clientinfo.MaxMindAPI{
+			AccountID:  os.Getenv("GEOIP_ACCOUNT"),
+			LicenseKey: os.Getenv("GEOIP_KEY"),
+		})
+	}
+
+	return clientinfo.New(resolver), nil
+}
@@ -0,0 +1,104 @@
+package clientinfo
+
+import (
+	"context"
+	"html/template"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+type contextKey struct{}
+
+// Info describes the client behind a request
+type Info struct {
+	IP    net.IP
+	Agent UserAgent
+
+	request  *http.Request
+	resolver Resolver
+	once     sync.Once
+	location Location
+}
+
+// Location resolves the client's location on first use, so requests that never ask don't pay for it
+func (i *Info) Location() Location {
+	i.once.Do(func() {
+		if i.resolver != nil && i.IP != nil {
+			i.location, _ = i.resolver.Resolve(i.request.Context(), i.IP)
+		}
+
+		if code := countryFromHeader(i.request); code != "" && i.location.CountryCode == "" {
+			i.location.CountryCode = code
+		}
+	})
+
+	return i.location
+}
+
+// Country returns the ISO country code of the client, or an empty string when unknown
+func (i *Info) Country() string {
+	return i.Location().CountryCode
+}
+
+// ClientInfo attaches an Info to every request
+type ClientInfo struct {
+	// Resolver looks up locations; without one only the CF-IPCountry header is used
+	Resolver Resolver
+}
+
+// New returns a ClientInfo using resolver, which may be nil
+func New(resolver Resolver) *ClientInfo {
+	return &ClientInfo{Resolver: resolver}
+}
+
+// Middleware stores the client info in the request context; it expects RealIP to have run
+func (c *ClientInfo) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		info := c.info(r)
+		ctx := context.WithValue(r.Context(), contextKey{}, info)
+		r = r.WithContext(ctx)
+		info.request = r
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+func (c *ClientInfo) info(r *http.Request) *Info {
+	return &Info{
+		IP:       remoteIP(r),
+		Agent:    ParseUserAgent(r.UserAgent()),
+		request:  r,
+		resolver: c.Resolver,
+	}
+}
+
+// FromRequest returns the info stored by the middleware, parsing r directly when it didn't run
+func FromRequest(r *http.Request) *Info {
+	if info, ok := r.Context().Value(contextKey{}).(*Info); ok {
+		return info
+	}
+
+	return (&ClientInfo{}).info(r)
+}
+
+// TemplateFuncs provides client, clientCountry and isMobile to views
+func (c *ClientInfo) TemplateFuncs(r *http.Request) template.FuncMap {
+	info := FromRequest(r)
+
+	return template.FuncMap{
+		"client":        func() *Info { return info },
+		"clientCountry": info.Country,
+		"isMobile":      info.Agent.IsMobile,
+	}
+}
+
+func remoteIP(r *http.Request) net.IP {
+	host := r.RemoteAddr
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return net.ParseIP(strings.TrimSpace(host))
+}
@@ -0,0 +1,123 @@
+package clientinfo
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		ua, browser, os, device string
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 Safari/537.36", "Chrome", "Windows", Desktop},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 Safari/537.36 Edg/118.0.2088.46", "Edge", "Windows", Desktop},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1", "Safari", "iOS", Mobile},
+		{"Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/117.0.0.0 Safari/537.36", "Chrome", "Android", Tablet},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:109.0) Gecko/20100101 Firefox/118.0", "Firefox", "macOS", Desktop},
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", "", "", Bot},
+	}
+
+	for _, e := range tests {
+		ua := ParseUserAgent(e.ua)
+		if ua.Browser != e.browser || ua.OS != e.os || ua.Device != e.device {
+			t.Errorf("%s: got %s/%s/%s", e.ua, ua.Browser, ua.OS, ua.Device)
+		}
+	}
+}
+
+// buildMMDB writes an IPv4 database with a single record for 1.2.3.0/24
+func buildMMDB() []byte {
+	const nodeCount = 24
+	prefix := []byte{1, 2, 3}
+
+	var tree []byte
+	for i := 0; i < nodeCount; i++ {
+		next := uint32(i + 1)
+		if i == nodeCount-1 {
+			next = nodeCount + 16
+		}
+
+		left, right := uint32(nodeCount), uint32(nodeCount)
+		if (prefix[i/8]>>(7-uint(i%8)))&1 == 1 {
+			right = next
+		} else {
+			left = next
+		}
+		tree = append(tree, byte(left>>16), byte(left>>8), byte(left), byte(right>>16), byte(right>>8), byte(right))
+	}
+
+	str := func(s string) []byte { return append([]byte{2<<5 | byte(len(s))}, s...) }
+	m := func(n int) []byte { return []byte{7<<5 | byte(n)} }
+
+	var data []byte
+	data = append(data, m(1)...)
+	data = append(data, str("country")...)
+	data = append(data, m(2)...)
+	data = append(data, str("iso_code")...)
+	data = append(data, str("VN")...)
+	data = append(data, str("names")...)
+	data = append(data, m(1)...)
+	data = append(data, str("en")...)
+	data = append(data, str("Vietnam")...)
+
+	var meta []byte
+	meta = append(meta, m(3)...)
+	meta = append(meta, str("node_count")...)
+	meta = append(meta, 6<<5|1, nodeCount)
+	meta = append(meta, str("record_size")...)
+	meta = append(meta, 5<<5|1, 24)
+	meta = append(meta, str("ip_version")...)
+	meta = append(meta, 5<<5|1, 4)
+
+	db := append(tree, make([]byte, 16)...)
+	db = append(db, data...)
+	db = append(db, metadataMarker...)
+
+	return append(db, meta...)
+}
+
+func TestMaxMindDB(t *testing.T) {
+	db, err := newMMDB(buildMMDB())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver := &MaxMindDB{db: db}
+
+	loc, err := resolver.Resolve(context.Background(), net.ParseIP("1.2.3.4"))
+	if err != nil || loc.CountryCode != "VN" || loc.Country != "Vietnam" {
+		t.Error("unexpected location", loc, err)
+	}
+
+	loc, err = resolver.Resolve(context.Background(), net.ParseIP("8.8.8.8"))
+	if err != nil || loc.CountryCode != "" {
+		t.Error("expected no location for unknown address", loc, err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	db, _ := newMMDB(buildMMDB())
+	c := New(NewCached(&MaxMindDB{db: db}))
+
+	var info *Info
+	h := c.Middleware(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		info = FromRequest(r)
+	}))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "1.2.3.9:5123"
+	r.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) Mobile/15E148")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	if info.Country() != "VN" || !info.Agent.IsMobile() {
+		t.Error("unexpected client info", info.Location(), info.Agent)
+	}
+
+	r = httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(countryHeader, "de")
+	if FromRequest(r).Country() != "DE" {
+		t.Error("expected country from the CF-IPCountry header")
+	}
+}
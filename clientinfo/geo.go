@@ -0,0 +1,190 @@
+package clientinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Location is where an IP address is registered
+type Location struct {
+	CountryCode string
+	Country     string
+	RegionCode  string
+	Region      string
+	City        string
+}
+
+// Resolver turns an IP address into a location
+type Resolver interface {
+	Resolve(ctx context.Context, ip net.IP) (Location, error)
+}
+
+// MaxMindDB resolves addresses from a local GeoLite2/GeoIP2 Country or City database
+type MaxMindDB struct {
+	db *mmdb
+}
+
+// OpenMaxMindDB loads a .mmdb file into memory
+func OpenMaxMindDB(path string) (*MaxMindDB, error) {
+	db, err := openMMDB(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MaxMindDB{db: db}, nil
+}
+
+func (m *MaxMindDB) Resolve(ctx context.Context, ip net.IP) (Location, error) {
+	record, err := m.db.lookup(ip)
+	if err != nil || record == nil {
+		return Location{}, err
+	}
+
+	return locationFromRecord(record), nil
+}
+
+// MaxMindAPI resolves addresses with the GeoIP2 web service
+type MaxMindAPI struct {
+	AccountID  string
+	LicenseKey string
+	// Service is "country" (default) or "city"
+	Service  string
+	Client   *http.Client
+	Endpoint string
+}
+
+func (m *MaxMindAPI) Resolve(ctx context.Context, ip net.IP) (Location, error) {
+	endpoint := m.Endpoint
+	if endpoint == "" {
+		endpoint = "https://geoip.maxmind.com/geoip/v2.1"
+	}
+
+	service := m.Service
+	if service == "" {
+		service = "country"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/%s/%s", endpoint, service, ip), nil)
+	if err != nil {
+		return Location{}, err
+	}
+	req.SetBasicAuth(m.AccountID, m.LicenseKey)
+
+	client := m.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return Location{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return Location{}, fmt.Errorf("clientinfo: geoip lookup failed with %s", res.Status)
+	}
+
+	var record map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&record); err != nil {
+		return Location{}, err
+	}
+
+	return locationFromRecord(record), nil
+}
+
+// countryHeader is set by Cloudflare with the visitor's country and is trusted before asking the resolver
+const countryHeader = "CF-IPCountry"
+
+// Cached remembers lookups of another resolver for TTL
+type Cached struct {
+	Resolver Resolver
+	TTL      time.Duration
+	// Size caps the number of remembered addresses
+	Size int
+
+	mu      sync.Mutex
+	entries map[string]cachedLocation
+}
+
+type cachedLocation struct {
+	location Location
+	expires  time.Time
+}
+
+// NewCached wraps r with an hour long cache of up to 10000 addresses
+func NewCached(r Resolver) *Cached {
+	return &Cached{Resolver: r, TTL: time.Hour, Size: 10000, entries: make(map[string]cachedLocation)}
+}
+
+func (c *Cached) Resolve(ctx context.Context, ip net.IP) (Location, error) {
+	key := ip.String()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if ok && time.Now().Before(entry.expires) {
+		return entry.location, nil
+	}
+
+	loc, err := c.Resolver.Resolve(ctx, ip)
+	if err != nil {
+		return loc, err
+	}
+
+	c.mu.Lock()
+	if len(c.entries) >= c.Size {
+		// dropping everything is crude, but keeps memory bounded without bookkeeping
+		c.entries = make(map[string]cachedLocation)
+	}
+	c.entries[key] = cachedLocation{location: loc, expires: time.Now().Add(c.TTL)}
+	c.mu.Unlock()
+
+	return loc, nil
+}
+
+// locationFromRecord reads the GeoIP2 record layout shared by the database and the web service
+func locationFromRecord(record map[string]interface{}) Location {
+	var loc Location
+
+	if country, ok := record["country"].(map[string]interface{}); ok {
+		loc.CountryCode, _ = country["iso_code"].(string)
+		loc.Country = englishName(country)
+	}
+
+	if subdivisions, ok := record["subdivisions"].([]interface{}); ok && len(subdivisions) > 0 {
+		if region, ok := subdivisions[0].(map[string]interface{}); ok {
+			loc.RegionCode, _ = region["iso_code"].(string)
+			loc.Region = englishName(region)
+		}
+	}
+
+	if city, ok := record["city"].(map[string]interface{}); ok {
+		loc.City = englishName(city)
+	}
+
+	return loc
+}
+
+func englishName(m map[string]interface{}) string {
+	names, _ := m["names"].(map[string]interface{})
+	name, _ := names["en"].(string)
+	return name
+}
+
+func countryFromHeader(r *http.Request) string {
+	code := strings.ToUpper(strings.TrimSpace(r.Header.Get(countryHeader)))
+	// Cloudflare uses XX for unknown and T1 for Tor
+	if len(code) != 2 || code == "XX" || code == "T1" {
+		return ""
+	}
+
+	return code
+}
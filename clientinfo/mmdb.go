@@ -0,0 +1,292 @@
+package clientinfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
+)
+
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// errCorrupt is returned when the database doesn't follow the MaxMind DB format
+var errCorrupt = errors.New("clientinfo: invalid MaxMind database")
+
+// mmdb is a minimal reader for MaxMind DB files (GeoLite2/GeoIP2 Country and City)
+type mmdb struct {
+	buf        []byte
+	data       []byte
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	ipv4Start  uint
+}
+
+func openMMDB(path string) (*mmdb, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return newMMDB(buf)
+}
+
+func newMMDB(buf []byte) (*mmdb, error) {
+	i := bytes.LastIndex(buf, metadataMarker)
+	if i < 0 {
+		return nil, errCorrupt
+	}
+
+	d := decoder{buf: buf[i+len(metadataMarker):]}
+	v, _, err := d.decode(0)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errCorrupt
+	}
+
+	db := &mmdb{
+		buf:        buf,
+		nodeCount:  toUint(meta["node_count"]),
+		recordSize: toUint(meta["record_size"]),
+		ipVersion:  toUint(meta["ip_version"]),
+	}
+
+	if db.recordSize != 24 && db.recordSize != 28 && db.recordSize != 32 {
+		return nil, fmt.Errorf("clientinfo: unsupported record size %d", db.recordSize)
+	}
+
+	treeSize := db.nodeCount * db.recordSize / 4
+	if treeSize+16 > uint(len(buf)) {
+		return nil, errCorrupt
+	}
+	db.data = buf[treeSize+16 : i]
+
+	// IPv4 addresses live under ::/96 in IPv6 databases
+	if db.ipVersion == 6 {
+		node := uint(0)
+		for j := 0; j < 96 && node < db.nodeCount; j++ {
+			node = db.record(node, 0)
+		}
+		db.ipv4Start = node
+	}
+
+	return db, nil
+}
+
+// lookup returns the record for ip, or nil when the database has none
+func (db *mmdb) lookup(ip net.IP) (map[string]interface{}, error) {
+	node := uint(0)
+	bits := 128
+
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+		bits = 32
+		node = db.ipv4Start
+	} else if db.ipVersion == 4 {
+		return nil, nil
+	}
+
+	for i := 0; i < bits && node < db.nodeCount; i++ {
+		bit := (ip[i>>3] >> (7 - uint(i&7))) & 1
+		node = db.record(node, uint(bit))
+	}
+
+	if node == db.nodeCount {
+		return nil, nil
+	}
+	if node < db.nodeCount {
+		return nil, errCorrupt
+	}
+
+	offset := node - db.nodeCount - 16
+	d := decoder{buf: db.data}
+	v, _, err := d.decode(offset)
+	if err != nil {
+		return nil, err
+	}
+
+	m, _ := v.(map[string]interface{})
+
+	return m, nil
+}
+
+// record reads the left (0) or right (1) record of a search tree node
+func (db *mmdb) record(node, side uint) uint {
+	switch db.recordSize {
+	case 24:
+		b := db.buf[node*6+side*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		b := db.buf[node*7:]
+		if side == 0 {
+			return uint(b[3]&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		return uint(binary.BigEndian.Uint32(db.buf[node*8+side*4:]))
+	}
+}
+
+// decoder reads values from the data section
+type decoder struct {
+	buf []byte
+}
+
+func (d *decoder) decode(offset uint) (interface{}, uint, error) {
+	if offset >= uint(len(d.buf)) {
+		return nil, 0, errCorrupt
+	}
+
+	ctrl := d.buf[offset]
+	offset++
+	typ := uint(ctrl >> 5)
+
+	if typ == 1 {
+		pointer, next, err := d.pointer(ctrl, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		v, _, err := d.decode(pointer)
+		return v, next, err
+	}
+
+	if typ == 0 {
+		if offset >= uint(len(d.buf)) {
+			return nil, 0, errCorrupt
+		}
+		typ = 7 + uint(d.buf[offset])
+		offset++
+	}
+
+	size := uint(ctrl & 0x1f)
+	if size >= 29 {
+		n := size - 28
+		if offset+n > uint(len(d.buf)) {
+			return nil, 0, errCorrupt
+		}
+		extra := uint(0)
+		for _, b := range d.buf[offset : offset+n] {
+			extra = extra<<8 | uint(b)
+		}
+		offset += n
+		switch size {
+		case 29:
+			size = 29 + extra
+		case 30:
+			size = 285 + extra
+		default:
+			size = 65821 + extra
+		}
+	}
+
+	switch typ {
+	case 7:
+		m := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			k, next, err := d.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			v, next, err := d.decode(next)
+			if err != nil {
+				return nil, 0, err
+			}
+			key, _ := k.(string)
+			m[key] = v
+			offset = next
+		}
+		return m, offset, nil
+	case 11:
+		a := make([]interface{}, 0, size)
+		for i := uint(0); i < size; i++ {
+			v, next, err := d.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			a = append(a, v)
+			offset = next
+		}
+		return a, offset, nil
+	case 14:
+		return size != 0, offset, nil
+	}
+
+	if offset+size > uint(len(d.buf)) {
+		return nil, 0, errCorrupt
+	}
+	b := d.buf[offset : offset+size]
+	offset += size
+
+	switch typ {
+	case 2:
+		return string(b), offset, nil
+	case 3:
+		if size != 8 {
+			return nil, 0, errCorrupt
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), offset, nil
+	case 4:
+		return b, offset, nil
+	case 5, 6, 9, 10:
+		n := uint64(0)
+		for _, x := range b {
+			n = n<<8 | uint64(x)
+		}
+		return n, offset, nil
+	case 8:
+		n := int32(0)
+		for _, x := range b {
+			n = n<<8 | int32(x)
+		}
+		return n, offset, nil
+	case 15:
+		if size != 4 {
+			return nil, 0, errCorrupt
+		}
+		return math.Float32frombits(binary.BigEndian.Uint32(b)), offset, nil
+	}
+
+	return nil, 0, fmt.Errorf("clientinfo: unknown data type %d", typ)
+}
+
+func (d *decoder) pointer(ctrl byte, offset uint) (uint, uint, error) {
+	size := uint(ctrl>>3) & 0x3
+	n := size + 1
+	if offset+n > uint(len(d.buf)) {
+		return 0, 0, errCorrupt
+	}
+
+	p := uint(0)
+	if size < 3 {
+		p = uint(ctrl & 0x7)
+	}
+	for _, b := range d.buf[offset : offset+n] {
+		p = p<<8 | uint(b)
+	}
+
+	switch size {
+	case 1:
+		p += 2048
+	case 2:
+		p += 526336
+	}
+
+	return p, offset + n, nil
+}
+
+func toUint(v interface{}) uint {
+	switch n := v.(type) {
+	case uint64:
+		return uint(n)
+	case int32:
+		return uint(n)
+	}
+	return 0
+}
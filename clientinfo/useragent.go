@@ -0,0 +1,88 @@
+package clientinfo
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Device types
+const (
+	Desktop = "desktop"
+	Mobile  = "mobile"
+	Tablet  = "tablet"
+	Bot     = "bot"
+)
+
+// UserAgent is the parsed User-Agent header
+type UserAgent struct {
+	Raw            string
+	Browser        string
+	BrowserVersion string
+	OS             string
+	OSVersion      string
+	Device         string
+}
+
+// IsMobile reports whether the client is a phone or a tablet
+func (ua UserAgent) IsMobile() bool {
+	return ua.Device == Mobile || ua.Device == Tablet
+}
+
+// browsers are checked in order, since most user agents mention several engines
+var browsers = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"Edge", regexp.MustCompile(`(?:Edg|Edge|EdgA|EdgiOS)/([\d.]+)`)},
+	{"Opera", regexp.MustCompile(`(?:OPR|Opera)/([\d.]+)`)},
+	{"Samsung Internet", regexp.MustCompile(`SamsungBrowser/([\d.]+)`)},
+	{"Firefox", regexp.MustCompile(`(?:Firefox|FxiOS)/([\d.]+)`)},
+	{"Chrome", regexp.MustCompile(`(?:Chrome|CriOS)/([\d.]+)`)},
+	{"Safari", regexp.MustCompile(`Version/([\d.]+).*Safari/`)},
+	{"Internet Explorer", regexp.MustCompile(`(?:MSIE |Trident/.*rv:)([\d.]+)`)},
+}
+
+var systems = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"Windows", regexp.MustCompile(`Windows NT ([\d.]+)`)},
+	{"iOS", regexp.MustCompile(`(?:iPhone|iPad|iPod).*OS ([\d_]+)`)},
+	{"macOS", regexp.MustCompile(`Mac OS X ([\d_.]+)`)},
+	{"Android", regexp.MustCompile(`Android ([\d.]+)`)},
+	{"Chrome OS", regexp.MustCompile(`CrOS \S+ ([\d.]+)`)},
+	{"Linux", regexp.MustCompile(`Linux()`)},
+}
+
+var botPattern = regexp.MustCompile(`(?i)bot|crawl|spider|slurp|preview|curl|wget|python|go-http-client`)
+
+// ParseUserAgent extracts the browser, operating system and device type from a User-Agent header
+func ParseUserAgent(raw string) UserAgent {
+	ua := UserAgent{Raw: raw, Device: Desktop}
+
+	for _, b := range browsers {
+		if m := b.pattern.FindStringSubmatch(raw); m != nil {
+			ua.Browser, ua.BrowserVersion = b.name, m[1]
+			break
+		}
+	}
+
+	for _, s := range systems {
+		if m := s.pattern.FindStringSubmatch(raw); m != nil {
+			ua.OS, ua.OSVersion = s.name, strings.ReplaceAll(m[1], "_", ".")
+			break
+		}
+	}
+
+	switch {
+	case raw == "" || botPattern.MatchString(raw):
+		ua.Device = Bot
+	case strings.Contains(raw, "iPad") || strings.Contains(raw, "Tablet") ||
+		(ua.OS == "Android" && !strings.Contains(raw, "Mobile")):
+		ua.Device = Tablet
+	case strings.Contains(raw, "Mobi") || strings.Contains(raw, "iPhone") || strings.Contains(raw, "iPod"):
+		ua.Device = Mobile
+	}
+
+	return ua
+}
@@ -0,0 +1,28 @@
+package goravel
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+)
+
+// bootClock tells the time in APP_TIMEZONE, the timezone of the server by default. Tests stop
+// the clock with WithClock(clock.NewTest(t)).
+func (grv *Goravel) bootClock() error {
+	loc := time.Local
+	if name := grv.Env.String("APP_TIMEZONE", ""); name != "" {
+		var err error
+		if loc, err = time.LoadLocation(name); err != nil {
+			return fmt.Errorf("APP_TIMEZONE: %w", err)
+		}
+	}
+
+	grv.Clock = clock.System{Location: loc}
+	return nil
+}
+
+// Now is the time of the app, in APP_TIMEZONE, read from grv.Clock
+func (grv *Goravel) Now() time.Time {
+	return clock.Now(grv.Clock)
+}
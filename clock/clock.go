@@ -0,0 +1,79 @@
+// Package clock is where the app reads the time from, so the code depending on it can be
+// tested at any moment instead of whenever the tests happen to run
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time
+type Clock interface {
+	Now() time.Time
+}
+
+// System is the clock of the machine, telling the time in Location; the local time when nil
+type System struct {
+	Location *time.Location
+}
+
+func (s System) Now() time.Time {
+	if s.Location == nil {
+		return time.Now()
+	}
+	return time.Now().In(s.Location)
+}
+
+// Now returns the time of c, or of the machine when c is nil, so a Clock can be an optional
+// field of a struct
+func Now(c Clock) time.Time {
+	if c == nil {
+		return time.Now()
+	}
+	return c.Now()
+}
+
+// Since is time.Since on c
+func Since(c Clock, t time.Time) time.Duration {
+	return Now(c).Sub(t)
+}
+
+// Location returns the location of the times of c, which is the local one unless c is a System
+// in another location or a Test clock set to a time in one
+func Location(c Clock) *time.Location {
+	return Now(c).Location()
+}
+
+// Test is a clock which only moves when told to, for deterministic tests
+type Test struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewTest returns a clock stopped at now
+func NewTest(now time.Time) *Test {
+	return &Test{now: now}
+}
+
+func (t *Test) Now() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.now
+}
+
+// Set moves the clock to now, which may be in the past
+func (t *Test) Set(now time.Time) {
+	t.mu.Lock()
+	t.now = now
+	t.mu.Unlock()
+}
+
+// Advance moves the clock forward by d and returns the new time
+func (t *Test) Advance(d time.Duration) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.now = t.now.Add(d)
+	return t.now
+}
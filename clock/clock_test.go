@@ -0,0 +1,50 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSystem(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	c := System{Location: paris}
+	if loc := c.Now().Location(); loc != paris {
+		t.Errorf("expected the time in Europe/Paris, got %v", loc)
+	}
+	if Location(c) != paris {
+		t.Error("expected Location to be the one of the clock")
+	}
+	if d := time.Since(c.Now()); d < 0 || d > time.Second {
+		t.Errorf("expected the time of the machine, off by %v", d)
+	}
+}
+
+func TestNil(t *testing.T) {
+	if d := time.Since(Now(nil)); d < 0 || d > time.Second {
+		t.Errorf("expected a nil clock to be the machine's, off by %v", d)
+	}
+}
+
+func TestTest(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := NewTest(start)
+
+	if !c.Now().Equal(start) {
+		t.Errorf("expected %v, got %v", start, c.Now())
+	}
+	if got := c.Advance(90 * time.Minute); !got.Equal(start.Add(90 * time.Minute)) {
+		t.Errorf("expected the clock to advance, got %v", got)
+	}
+	if d := Since(c, start); d != 90*time.Minute {
+		t.Errorf("expected 90m since the start, got %v", d)
+	}
+
+	c.Set(start.Add(-time.Hour))
+	if !c.Now().Equal(start.Add(-time.Hour)) {
+		t.Errorf("expected the clock to be set back, got %v", c.Now())
+	}
+}
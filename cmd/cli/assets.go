@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/namnguyen191/goravel/assets"
+	"github.com/namnguyen191/goravel/static"
+)
+
+// doAssetsBuild writes the bundles of the manifest, assets.json by default, ahead of a deploy
+func doAssetsBuild(manifest string) error {
+	if manifest == "" {
+		manifest = os.Getenv("ASSETS_MANIFEST")
+	}
+	if manifest == "" {
+		manifest = "assets.json"
+	}
+
+	bundles, err := assets.ReadManifest(manifest)
+	if err != nil {
+		return err
+	}
+
+	return assets.New(grv.RootPath).Build(bundles)
+}
+
+// doAssetsCompress pre-compresses the files of public, after assets:build in a deploy
+func doAssetsCompress(dir string) error {
+	if dir == "" {
+		dir = filepath.Join(grv.RootPath, "public")
+	}
+
+	c := static.NewCompressor()
+	if c.Brotli == "" {
+		color.Yellow("brotli is not installed, only writing .gz files")
+	}
+
+	n, err := c.Compress(dir)
+	if err != nil {
+		return err
+	}
+
+	color.Green("%d compressed files written to %s", n, dir)
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/joho/godotenv"
+	"github.com/namnguyen191/goravel/env"
+)
+
+// doConfigDoc prints the reference of the environment variables as markdown, or as json.
+// Any other argument names a file to write the markdown to, e.g. docs/env.md.
+func doConfigDoc(arg string) error {
+	switch arg {
+	case "":
+		return env.Markdown(os.Stdout, env.Registry)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(env.Registry)
+	}
+
+	f, err := os.Create(arg)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := env.Markdown(f, env.Registry); err != nil {
+		return err
+	}
+	color.Green("Wrote %s", arg)
+	return nil
+}
+
+// doConfigCheck validates an env file, .env by default, against the registry
+func doConfigCheck(file string) error {
+	if file == "" {
+		file = ".env"
+	}
+
+	values, err := godotenv.Read(file)
+	if err != nil {
+		return err
+	}
+
+	errs := 0
+	for _, p := range env.Check(values, env.Registry) {
+		if p.Warning {
+			color.Yellow("  warning: %s", p)
+			continue
+		}
+		errs++
+		color.Red("  error: %s", p)
+	}
+
+	if errs > 0 {
+		return fmt.Errorf("%s has %d problem(s)", file, errs)
+	}
+	color.Green("%s is valid", file)
+	return nil
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,7 +10,7 @@ import (
 )
 
 func setup(arg1, arg2 string) {
-	if arg1 != "new" && arg1 != "version" && arg1 != "help" {
+	if arg1 != "new" && arg1 != "version" && arg1 != "help" && !strings.HasPrefix(arg1, "config:") {
 
 		err := godotenv.Load()
 		if err != nil {
@@ -28,39 +27,6 @@ func setup(arg1, arg2 string) {
 	}
 }
 
-func getDSN() string {
-	dbType := grv.DB.DataBaseType
-
-	if dbType == "pgx" {
-		dbType = "postgres"
-	}
-
-	if dbType == "postgres" {
-		var dsn string
-		if os.Getenv("DATABASE_PASS") != "" {
-			dsn = fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
-				os.Getenv("DATABASE_USER"),
-				os.Getenv("DATABASE_PASS"),
-				os.Getenv("DATABASE_HOST"),
-				os.Getenv("DATABASE_PORT"),
-				os.Getenv("DATABASE_NAME"),
-				os.Getenv("DATABASE_SSL_MODE"))
-		} else {
-			dsn = fmt.Sprintf("postgres://%s@%s:%s/%s?sslmode=%s",
-				os.Getenv("DATABASE_USER"),
-				os.Getenv("DATABASE_HOST"),
-				os.Getenv("DATABASE_PORT"),
-				os.Getenv("DATABASE_NAME"),
-				os.Getenv("DATABASE_SSL_MODE"))
-		}
-
-		fmt.Println("DSN: ", dsn)
-		return dsn
-	}
-	return "mysql://" + grv.BuildDSN()
-
-}
-
 func showHelp() {
 	color.Yellow(
 		`
@@ -70,12 +36,44 @@ func showHelp() {
 		migrate               - runs all up migratios that have not been run previously
 		migrate down          - reverses the most recent migration
 		migrate reset         - run all down migrations in reverse order, and then all up migrations
+		migrate to <version>  - migrate up or down to a version
+		migrate force <version> - mark a version as applied after fixing a failed migration
 		make migration <name> - create 2 new up and down migrations in the migrations folder
 		make auth             - create and run migrations for authentication tables, and create models and middlewares
 		make handler <name>   - creates a stub handler in the handlers directory
+		make controller <name> - creates a controller with injected dependencies in the handlers directory
 		make model <name>     - creates a new model in the data directory 
 		make session          - creates a table in the database as a session store
+		make settings         - creates a table in the database for runtime settings
+		make activity         - creates a table in the database for activity feeds
+		make analytics        - creates tables in the database for page views and events
+		make announcements    - creates a table in the database for sitewide announcements
+		make exports          - creates a table in the database for background data exports
+		make invoices         - creates a table in the database for invoice numbering sequences
+		make payments         - creates tables in the database for payment customers, subscriptions and events
+		make cart             - creates a table in the database for the carts of logged in users
+		make comments         - creates a table in the database for threaded comments
+		make tags             - creates tables in the database for tags and categories
+		make media            - creates a table in the database for the media library
+		make sms              - creates a table in the database for the sms opt-out list
+		make push             - creates a table in the database for push notification subscriptions
+		make vapid            - generates a VAPID key pair for web push notifications
+		make links            - creates a table in the database for short links
+		make leader           - creates a table in the database for scheduler leader election
+		make workflow         - creates a table in the database for workflow state
+		make reports          - creates a table in the database for the history of report emails, and their mail templates
+		make teams            - creates tables in the database for teams and their members
+		make apikeys          - creates tables in the database for api keys and their daily usage
+		make invitations      - creates a table in the database for invitations, their mail templates and accept page
+		make oidc             - creates tables in the database for openid connect clients, consents and codes, and the consent page
+		make oidc-key         - generates oidc.key, an RSA key signing the openid connect tokens
+		make errors           - creates views/errors pages for 403, 404, 500 and 503 to customize
 		make mail <name>      - creates 2 starter mail templates in the mail directory
+		mail:test <address>   - checks the mail settings and sends a test message to the address
+		assets:build [manifest] - writes the minified bundles of assets.json to public/bundles
+		assets:compress [dir]   - writes .gz, and .br with the brotli command, next to the files of public
+		config:doc [json|file] - prints the environment variables the framework reads as markdown or json
+		config:check [file]   - checks an env file, .env by default, for missing, invalid and unknown values
 		`)
 }
 
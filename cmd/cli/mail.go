@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strconv"
+
+	"github.com/fatih/color"
+	"github.com/namnguyen191/goravel/env"
+	"github.com/namnguyen191/goravel/mailer"
+)
+
+// doMailTest checks the mail settings in .env and sends a test message to the address
+func doMailTest(to string) error {
+	if to == "" {
+		return errors.New("mail:test requires an email address")
+	}
+
+	port, _ := strconv.Atoi(os.Getenv("SMTP_PORT"))
+	m := mailer.Mail{
+		Domain:      os.Getenv("MAIL_DOMAIN"),
+		Templates:   grv.RootPath + "/mail",
+		Host:        os.Getenv("SMTP_HOST"),
+		Port:        port,
+		Username:    os.Getenv("SMTP_USERNAME"),
+		Password:    os.Getenv("SMTP_PASSWORD"),
+		Encryption:  os.Getenv("SMTP_ENCRYPTION"),
+		FromName:    os.Getenv("FROM_NAME"),
+		FromAddress: env.Get("FROM_ADDRESS"),
+		API:         os.Getenv("MAILER_API"),
+		APIKey:      os.Getenv("MAILER_KEY"),
+		APIUrl:      os.Getenv("MAILER_URL"),
+	}
+
+	if m.FromAddress == "" {
+		return errors.New("FROM_ADDRESS is not set")
+	}
+
+	color.Yellow("Checking mail settings...")
+	if err := m.Verify(); err != nil {
+		return err
+	}
+	color.Green("Mail settings are valid")
+
+	color.Yellow("Sending a test message to %s...", to)
+	if err := m.SendTest(to); err != nil {
+		return err
+	}
+	color.Green("Test message sent")
+
+	return nil
+}
@@ -49,6 +49,36 @@ func main() {
 		if err != nil {
 			exitGracefully(err)
 		}
+	case "mail:test":
+		err = doMailTest(arg2)
+		if err != nil {
+			exitGracefully(err)
+		}
+	case "assets:build":
+		err = doAssetsBuild(arg2)
+		if err != nil {
+			exitGracefully(err)
+		}
+		message = "Bundles written to public/bundles"
+	case "assets:compress":
+		err = doAssetsCompress(arg2)
+		if err != nil {
+			exitGracefully(err)
+		}
+	case "config:doc":
+		err = doConfigDoc(arg2)
+		if err != nil {
+			exitGracefully(err)
+		}
+		// keep the output clean for redirecting it to a file
+		if arg2 == "" || arg2 == "json" {
+			os.Exit(0)
+		}
+	case "config:check":
+		err = doConfigCheck(arg2)
+		if err != nil {
+			exitGracefully(err)
+		}
 	default:
 		showHelp()
 	}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"runtime"
 	"strings"
 	"time"
@@ -11,6 +12,8 @@ import (
 	"github.com/fatih/color"
 	"github.com/gertd/go-pluralize"
 	"github.com/iancoleman/strcase"
+	"github.com/namnguyen191/goravel/oidc"
+	"github.com/namnguyen191/goravel/push"
 )
 
 func doMake(arg2, arg3 string) error {
@@ -81,6 +84,30 @@ func doMake(arg2, arg3 string) error {
 				exitGracefully(err)
 			}
 		}
+	case "controller":
+		{
+			if arg3 == "" {
+				exitGracefully(errors.New("you must give the controller a name"))
+			}
+
+			fileName := grv.RootPath + "/handlers/" + strings.ToLower(arg3) + "-controller.go"
+			if fileExist(fileName) {
+				exitGracefully(errors.New(fileName + " already exists!"))
+			}
+
+			data, err := templateFS.ReadFile("templates/handlers/controller.go.txt")
+			if err != nil {
+				exitGracefully(err)
+			}
+
+			controller := string(data)
+			controller = strings.ReplaceAll(controller, "$CONTROLLERNAME$", strcase.ToCamel(arg3))
+
+			err = copyDataToFile([]byte(controller), fileName)
+			if err != nil {
+				exitGracefully(err)
+			}
+		}
 	case "model":
 		{
 			if arg3 == "" {
@@ -126,6 +153,226 @@ func doMake(arg2, arg3 string) error {
 				exitGracefully(err)
 			}
 		}
+	case "settings":
+		{
+			err := doTables("settings", "drop table if exists settings;")
+			if err != nil {
+				exitGracefully(err)
+			}
+		}
+	case "activity":
+		{
+			err := doTables("activity", "drop table if exists activities;")
+			if err != nil {
+				exitGracefully(err)
+			}
+		}
+	case "analytics":
+		{
+			err := doTables("analytics", "drop table if exists analytics_daily; drop table if exists analytics_events;")
+			if err != nil {
+				exitGracefully(err)
+			}
+		}
+	case "announcements":
+		{
+			err := doTables("announcements", "drop table if exists announcements;")
+			if err != nil {
+				exitGracefully(err)
+			}
+		}
+	case "exports":
+		{
+			err := doTables("exports", "drop table if exists exports;")
+			if err != nil {
+				exitGracefully(err)
+			}
+		}
+	case "invoices":
+		{
+			err := doTables("invoices", "drop table if exists invoice_sequences;")
+			if err != nil {
+				exitGracefully(err)
+			}
+		}
+	case "payments":
+		{
+			err := doTables("payments", "drop table if exists payment_events; drop table if exists payment_subscriptions; drop table if exists payment_customers;")
+			if err != nil {
+				exitGracefully(err)
+			}
+		}
+	case "cart":
+		{
+			err := doTables("cart", "drop table if exists cart_items;")
+			if err != nil {
+				exitGracefully(err)
+			}
+		}
+	case "comments":
+		{
+			err := doTables("comments", "drop table if exists comments;")
+			if err != nil {
+				exitGracefully(err)
+			}
+		}
+	case "tags":
+		{
+			err := doTables("tags", "drop table if exists taggables; drop table if exists tags;")
+			if err != nil {
+				exitGracefully(err)
+			}
+		}
+	case "media":
+		{
+			err := doTables("media", "drop table if exists media;")
+			if err != nil {
+				exitGracefully(err)
+			}
+		}
+	case "sms":
+		{
+			err := doTables("sms", "drop table if exists sms_opt_outs;")
+			if err != nil {
+				exitGracefully(err)
+			}
+		}
+	case "push":
+		{
+			err := doTables("push", "drop table if exists push_subscriptions;")
+			if err != nil {
+				exitGracefully(err)
+			}
+		}
+	case "vapid":
+		{
+			public, private, err := push.GenerateVAPIDKeys()
+			if err != nil {
+				exitGracefully(err)
+			}
+			color.Yellow("VAPID_PUBLIC_KEY=%s", public)
+			color.Yellow("VAPID_PRIVATE_KEY=%s", private)
+		}
+	case "links":
+		{
+			err := doTables("links", "drop table if exists links;")
+			if err != nil {
+				exitGracefully(err)
+			}
+		}
+	case "leader":
+		{
+			err := doTables("leader", "drop table if exists scheduler_leases;")
+			if err != nil {
+				exitGracefully(err)
+			}
+		}
+	case "workflow":
+		{
+			err := doTables("workflow", "drop table if exists workflows;")
+			if err != nil {
+				exitGracefully(err)
+			}
+		}
+	case "reports":
+		{
+			err := doTables("reports", "drop table if exists report_runs;")
+			if err != nil {
+				exitGracefully(err)
+			}
+
+			for _, ext := range []string{"html", "plain"} {
+				err := copyFileFromTemplate("templates/mailer/report."+ext+".tmpl", grv.RootPath+"/mail/report."+ext+".tmpl")
+				if err != nil {
+					color.Yellow("%v", err)
+				}
+			}
+		}
+	case "apikeys":
+		{
+			err := doTables("apikeys", "drop table if exists api_key_usage; drop table if exists api_keys;")
+			if err != nil {
+				exitGracefully(err)
+			}
+		}
+	case "teams":
+		{
+			err := doTables("teams", "drop table if exists team_members; drop table if exists teams;")
+			if err != nil {
+				exitGracefully(err)
+			}
+		}
+	case "oidc":
+		{
+			err := doTables("oidc", "drop table if exists oidc_codes; drop table if exists oidc_consents; drop table if exists oidc_clients;")
+			if err != nil {
+				exitGracefully(err)
+			}
+
+			err = os.MkdirAll(grv.RootPath+"/views/oidc", 0755)
+			if err != nil {
+				exitGracefully(err)
+			}
+			err = copyFileFromTemplate("templates/views/oidc/consent.jet", grv.RootPath+"/views/oidc/consent.jet")
+			if err != nil {
+				color.Yellow("%v", err)
+			}
+		}
+	case "oidc-key":
+		{
+			path := grv.RootPath + "/oidc.key"
+			if fileExist(path) {
+				exitGracefully(errors.New(path + " already exists!"))
+			}
+
+			key, err := oidc.GenerateKey()
+			if err != nil {
+				exitGracefully(err)
+			}
+			err = os.WriteFile(path, key, 0600)
+			if err != nil {
+				exitGracefully(err)
+			}
+			color.Yellow("OIDC_KEYS=oidc.key")
+			color.Yellow("keep oidc.key out of version control; to rotate, put a new key first and keep the old one after it")
+		}
+	case "invitations":
+		{
+			err := doTables("invitations", "drop table if exists invitations;")
+			if err != nil {
+				exitGracefully(err)
+			}
+
+			for _, ext := range []string{"html", "plain"} {
+				err := copyFileFromTemplate("templates/mailer/invitation."+ext+".tmpl", grv.RootPath+"/mail/invitation."+ext+".tmpl")
+				if err != nil {
+					color.Yellow("%v", err)
+				}
+			}
+
+			err = os.MkdirAll(grv.RootPath+"/views/invitations", 0755)
+			if err != nil {
+				exitGracefully(err)
+			}
+			err = copyFileFromTemplate("templates/views/invitations/accept.jet", grv.RootPath+"/views/invitations/accept.jet")
+			if err != nil {
+				color.Yellow("%v", err)
+			}
+		}
+	case "errors":
+		{
+			err := os.MkdirAll(grv.RootPath+"/views/errors", 0755)
+			if err != nil {
+				exitGracefully(err)
+			}
+
+			for _, status := range []string{"403", "404", "500", "503"} {
+				err := copyFileFromTemplate("templates/views/errors/error.jet", grv.RootPath+"/views/errors/"+status+".jet")
+				if err != nil {
+					color.Yellow("%v", err)
+				}
+			}
+		}
 	case "mail":
 		{
 			if arg3 == "" {
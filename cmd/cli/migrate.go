@@ -1,13 +1,24 @@
 package main
 
+import (
+	"errors"
+	"strconv"
+)
+
 func doMigrate(arg2, arg3 string) error {
-	dsn := getDSN()
+	// the migrations run on a pool opened like the one of the app
+	db, err := grv.OpenDB(grv.DB.DataBaseType, grv.BuildDSN())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	grv.DB.Pool = db
 
 	// run the migration commands
 	switch arg2 {
 	case "up":
 		{
-			err := grv.MigrateUp(dsn)
+			err := grv.MigrateUp()
 			if err != nil {
 				return err
 			}
@@ -15,12 +26,12 @@ func doMigrate(arg2, arg3 string) error {
 	case "down":
 		{
 			if arg3 == "all" {
-				err := grv.MigrateDownAll(dsn)
+				err := grv.MigrateReset()
 				if err != nil {
 					return err
 				}
 			} else {
-				err := grv.Steps(-1, dsn)
+				err := grv.MigrateDown()
 				if err != nil {
 					return err
 				}
@@ -29,11 +40,26 @@ func doMigrate(arg2, arg3 string) error {
 		}
 	case "reset":
 		{
-			err := grv.MigrateDownAll(dsn)
+			err := grv.MigrateReset()
+			if err != nil {
+				return err
+			}
+			err = grv.MigrateUp()
 			if err != nil {
 				return err
 			}
-			err = grv.MigrateUp(dsn)
+		}
+	case "to", "force":
+		{
+			version, err := strconv.ParseInt(arg3, 10, 64)
+			if err != nil {
+				return errors.New("migrate " + arg2 + " needs a version, e.g. migrate " + arg2 + " 20220101120000")
+			}
+			if arg2 == "to" {
+				err = grv.MigrateTo(version)
+			} else {
+				err = grv.MigrateForce(version)
+			}
 			if err != nil {
 				return err
 			}
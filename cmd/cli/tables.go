@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// doTables creates and runs the migration for the tables of a framework module, using the
+// templates/migrations/<name>_tables.<dbType>.sql template
+func doTables(name, down string) error {
+	dbType := grv.DB.DataBaseType
+
+	if dbType == "mariadb" {
+		dbType = "mysql"
+	}
+
+	if dbType == "postgresql" {
+		dbType = "postgres"
+	}
+
+	fileName := fmt.Sprintf("%d_create_%s_tables", time.Now().UnixMicro(), name)
+
+	upFile := grv.RootPath + "/migrations/" + fileName + "." + dbType + ".up.sql"
+	downFile := grv.RootPath + "/migrations/" + fileName + "." + dbType + ".down.sql"
+
+	err := copyFileFromTemplate("templates/migrations/"+name+"_tables."+dbType+".sql", upFile)
+	if err != nil {
+		exitGracefully(err)
+	}
+
+	err = copyDataToFile([]byte(down), downFile)
+	if err != nil {
+		exitGracefully(err)
+	}
+
+	err = doMigrate("up", "")
+	if err != nil {
+		exitGracefully(err)
+	}
+
+	return nil
+}
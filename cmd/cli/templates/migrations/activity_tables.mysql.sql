@@ -0,0 +1,13 @@
+CREATE TABLE `activities` (
+    `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+    `actor_type` varchar(255) NOT NULL,
+    `actor_id` int(10) unsigned NOT NULL,
+    `verb` varchar(255) NOT NULL,
+    `object_type` varchar(255) NOT NULL,
+    `object_id` int(10) unsigned NOT NULL,
+    `data` text NOT NULL,
+    `created_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`id`),
+    KEY `activities_actor_idx` (`actor_type`, `actor_id`, `created_at`),
+    KEY `activities_object_idx` (`object_type`, `object_id`, `created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,13 @@
+CREATE TABLE activities (
+    id serial PRIMARY KEY,
+    actor_type VARCHAR(255) NOT NULL,
+    actor_id INTEGER NOT NULL,
+    verb VARCHAR(255) NOT NULL,
+    object_type VARCHAR(255) NOT NULL,
+    object_id INTEGER NOT NULL,
+    data TEXT NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX activities_actor_idx ON activities (actor_type, actor_id, created_at);
+CREATE INDEX activities_object_idx ON activities (object_type, object_id, created_at);
@@ -0,0 +1,21 @@
+CREATE TABLE `analytics_events` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+    `name` varchar(255) NOT NULL,
+    `path` varchar(1024) NOT NULL,
+    `referrer` varchar(255) NOT NULL DEFAULT '',
+    `visitor` varchar(64) NOT NULL,
+    `country` varchar(2) NOT NULL DEFAULT '',
+    `device` varchar(16) NOT NULL DEFAULT '',
+    `data` text NOT NULL,
+    `created_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`id`),
+    KEY `analytics_events_created_idx` (`created_at`, `name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `analytics_daily` (
+    `day` date NOT NULL,
+    `path` varchar(1024) NOT NULL,
+    `views` int unsigned NOT NULL DEFAULT 0,
+    `visitors` int unsigned NOT NULL DEFAULT 0,
+    KEY `analytics_daily_day_idx` (`day`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
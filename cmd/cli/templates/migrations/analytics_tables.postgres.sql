@@ -0,0 +1,22 @@
+CREATE TABLE analytics_events (
+    id bigserial PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    path VARCHAR(1024) NOT NULL,
+    referrer VARCHAR(255) NOT NULL DEFAULT '',
+    visitor VARCHAR(64) NOT NULL,
+    country VARCHAR(2) NOT NULL DEFAULT '',
+    device VARCHAR(16) NOT NULL DEFAULT '',
+    data TEXT NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX analytics_events_created_idx ON analytics_events (created_at, name);
+
+CREATE TABLE analytics_daily (
+    day DATE NOT NULL,
+    path VARCHAR(1024) NOT NULL,
+    views INTEGER NOT NULL DEFAULT 0,
+    visitors INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX analytics_daily_day_idx ON analytics_daily (day);
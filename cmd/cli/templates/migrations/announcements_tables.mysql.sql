@@ -0,0 +1,11 @@
+CREATE TABLE `announcements` (
+    `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+    `message` text NOT NULL,
+    `level` varchar(32) NOT NULL DEFAULT 'info',
+    `audience` varchar(64) NOT NULL DEFAULT 'all',
+    `starts_at` datetime NOT NULL DEFAULT current_timestamp(),
+    `ends_at` datetime NOT NULL DEFAULT '1000-01-01 00:00:00',
+    `created_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`id`),
+    KEY `announcements_ends_at_idx` (`ends_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
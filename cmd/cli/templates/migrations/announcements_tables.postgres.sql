@@ -0,0 +1,11 @@
+CREATE TABLE announcements (
+    id serial PRIMARY KEY,
+    message TEXT NOT NULL,
+    level VARCHAR(32) NOT NULL DEFAULT 'info',
+    audience VARCHAR(64) NOT NULL DEFAULT 'all',
+    starts_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    ends_at TIMESTAMP NOT NULL DEFAULT '1000-01-01 00:00:00',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX announcements_ends_at_idx ON announcements (ends_at);
@@ -0,0 +1,28 @@
+CREATE TABLE `api_keys` (
+    `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+    `name` varchar(255) NOT NULL,
+    `key_id` varchar(64) NOT NULL,
+    `secret` text NOT NULL,
+    `previous_secret` text NOT NULL,
+    `previous_until` timestamp NULL DEFAULT NULL,
+    `scopes` text NOT NULL,
+    `owner_id` int(10) unsigned NOT NULL DEFAULT 0,
+    `rate_limit` int(10) unsigned NOT NULL DEFAULT 0,
+    `requests` bigint(20) unsigned NOT NULL DEFAULT 0,
+    `last_used_at` timestamp NULL DEFAULT NULL,
+    `rotated_at` timestamp NULL DEFAULT NULL,
+    `revoked_at` timestamp NULL DEFAULT NULL,
+    `created_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    `updated_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `api_keys_key_id_idx` (`key_id`),
+    KEY `api_keys_owner_idx` (`owner_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `api_key_usage` (
+    `api_key_id` int(10) unsigned NOT NULL,
+    `day` date NOT NULL,
+    `requests` bigint(20) unsigned NOT NULL DEFAULT 0,
+    PRIMARY KEY (`api_key_id`, `day`),
+    CONSTRAINT `api_key_usage_key_fk` FOREIGN KEY (`api_key_id`) REFERENCES `api_keys` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
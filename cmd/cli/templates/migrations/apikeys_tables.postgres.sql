@@ -0,0 +1,26 @@
+CREATE TABLE api_keys (
+    id serial PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    key_id VARCHAR(64) NOT NULL UNIQUE,
+    secret TEXT NOT NULL,
+    previous_secret TEXT NOT NULL DEFAULT '',
+    previous_until TIMESTAMP NULL,
+    scopes TEXT NOT NULL DEFAULT '',
+    owner_id INTEGER NOT NULL DEFAULT 0,
+    rate_limit INTEGER NOT NULL DEFAULT 0,
+    requests BIGINT NOT NULL DEFAULT 0,
+    last_used_at TIMESTAMP NULL,
+    rotated_at TIMESTAMP NULL,
+    revoked_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX api_keys_owner_idx ON api_keys (owner_id);
+
+CREATE TABLE api_key_usage (
+    api_key_id INTEGER NOT NULL REFERENCES api_keys (id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (api_key_id, day)
+);
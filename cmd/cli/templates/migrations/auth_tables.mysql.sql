@@ -37,6 +37,7 @@ CREATE TABLE `tokens` (
     `email` varchar(255) NOT NULL,
     `token` varchar(255) NOT NULL,
     `token_hash` varbinary(255) DEFAULT NULL,
+    `scopes` varchar(255) NOT NULL DEFAULT '',
     `created_at` datetime NOT NULL DEFAULT current_timestamp(),
     `updated_at` datetime NOT NULL DEFAULT current_timestamp(),
     `expiry` datetime NOT NULL,
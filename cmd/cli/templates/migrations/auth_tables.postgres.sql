@@ -48,6 +48,7 @@ CREATE TABLE tokens (
     email character varying(255) NOT NULL,
     token character varying(255) NOT NULL,
     token_hash bytea NOT NULL,
+    scopes character varying(255) NOT NULL DEFAULT '',
     created_at timestamp without time zone NOT NULL DEFAULT now(),
     updated_at timestamp without time zone NOT NULL DEFAULT now(),
     expiry timestamp without time zone NOT NULL
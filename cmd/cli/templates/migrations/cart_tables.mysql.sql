@@ -0,0 +1,12 @@
+CREATE TABLE `cart_items` (
+    `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+    `user_id` int(10) unsigned NOT NULL,
+    `item_id` varchar(255) NOT NULL,
+    `name` varchar(255) NOT NULL DEFAULT '',
+    `price` bigint NOT NULL,
+    `currency` char(3) NOT NULL,
+    `quantity` int NOT NULL,
+    `updated_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`id`),
+    KEY `cart_items_user_idx` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,12 @@
+CREATE TABLE cart_items (
+    id serial PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    item_id VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    price BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    quantity INTEGER NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX cart_items_user_idx ON cart_items (user_id);
@@ -0,0 +1,17 @@
+CREATE TABLE `comments` (
+    `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+    `commentable_type` varchar(64) NOT NULL,
+    `commentable_id` int(10) unsigned NOT NULL,
+    `parent_id` int(10) unsigned NOT NULL DEFAULT 0,
+    `root_id` int(10) unsigned NOT NULL DEFAULT 0,
+    `depth` int NOT NULL DEFAULT 0,
+    `user_id` int(10) unsigned NOT NULL,
+    `body` text NOT NULL,
+    `status` varchar(16) NOT NULL,
+    `created_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    `updated_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`id`),
+    KEY `comments_commentable_idx` (`commentable_type`, `commentable_id`, `status`, `created_at`),
+    KEY `comments_root_idx` (`root_id`),
+    KEY `comments_status_idx` (`status`, `created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
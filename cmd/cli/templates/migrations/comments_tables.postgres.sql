@@ -0,0 +1,17 @@
+CREATE TABLE comments (
+    id serial PRIMARY KEY,
+    commentable_type VARCHAR(64) NOT NULL,
+    commentable_id INTEGER NOT NULL,
+    parent_id INTEGER NOT NULL DEFAULT 0,
+    root_id INTEGER NOT NULL DEFAULT 0,
+    depth INTEGER NOT NULL DEFAULT 0,
+    user_id INTEGER NOT NULL,
+    body TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX comments_commentable_idx ON comments (commentable_type, commentable_id, status, created_at);
+CREATE INDEX comments_root_idx ON comments (root_id);
+CREATE INDEX comments_status_idx ON comments (status, created_at);
@@ -0,0 +1,16 @@
+CREATE TABLE `exports` (
+    `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+    `user_id` int(10) unsigned NOT NULL,
+    `name` varchar(255) NOT NULL,
+    `format` varchar(16) NOT NULL,
+    `status` varchar(32) NOT NULL,
+    `progress` int NOT NULL DEFAULT 0,
+    `rows_count` int NOT NULL DEFAULT 0,
+    `file` varchar(255) NOT NULL DEFAULT '',
+    `error` text NOT NULL,
+    `request` text NOT NULL,
+    `created_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    `completed_at` timestamp NULL DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `exports_user_idx` (`user_id`, `created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,16 @@
+CREATE TABLE exports (
+    id serial PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    format VARCHAR(16) NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    progress INTEGER NOT NULL DEFAULT 0,
+    rows_count INTEGER NOT NULL DEFAULT 0,
+    file VARCHAR(255) NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    request TEXT NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP NULL
+);
+
+CREATE INDEX exports_user_idx ON exports (user_id, created_at);
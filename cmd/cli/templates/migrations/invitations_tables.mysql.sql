@@ -0,0 +1,17 @@
+CREATE TABLE `invitations` (
+    `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+    `email` varchar(255) NOT NULL,
+    `role` varchar(64) NOT NULL DEFAULT '',
+    `metadata` text NOT NULL,
+    `invited_by` int(10) unsigned NOT NULL DEFAULT 0,
+    `status` varchar(16) NOT NULL DEFAULT 'pending',
+    `nonce` varchar(64) NOT NULL DEFAULT '',
+    `user_id` int(10) unsigned NOT NULL DEFAULT 0,
+    `expires_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    `sent_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    `accepted_at` timestamp NULL DEFAULT NULL,
+    `created_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    `updated_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`id`),
+    KEY `invitations_email_idx` (`email`, `status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,17 @@
+CREATE TABLE invitations (
+    id serial PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(64) NOT NULL DEFAULT '',
+    metadata TEXT NOT NULL DEFAULT '',
+    invited_by INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    nonce VARCHAR(64) NOT NULL DEFAULT '',
+    user_id INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    sent_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    accepted_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX invitations_email_idx ON invitations (lower(email), status);
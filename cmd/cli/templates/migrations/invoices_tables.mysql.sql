@@ -0,0 +1,5 @@
+CREATE TABLE `invoice_sequences` (
+    `name` varchar(64) NOT NULL,
+    `value` bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,4 @@
+CREATE TABLE invoice_sequences (
+    name VARCHAR(64) PRIMARY KEY,
+    value BIGINT NOT NULL DEFAULT 0
+);
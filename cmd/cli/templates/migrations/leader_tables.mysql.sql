@@ -0,0 +1,6 @@
+CREATE TABLE `scheduler_leases` (
+    `name` varchar(128) NOT NULL,
+    `holder` varchar(255) NOT NULL,
+    `expires_at` timestamp(3) NOT NULL,
+    PRIMARY KEY (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
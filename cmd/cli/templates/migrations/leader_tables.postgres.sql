@@ -0,0 +1,5 @@
+CREATE TABLE scheduler_leases (
+    name VARCHAR(128) PRIMARY KEY,
+    holder VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
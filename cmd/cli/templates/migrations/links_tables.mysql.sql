@@ -0,0 +1,17 @@
+CREATE TABLE `links` (
+    `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+    `code` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL,
+    `url` text NOT NULL,
+    `campaign` varchar(128) NOT NULL DEFAULT '',
+    `source` varchar(128) NOT NULL DEFAULT '',
+    `medium` varchar(128) NOT NULL DEFAULT '',
+    `user_id` int(10) unsigned NOT NULL DEFAULT 0,
+    `clicks` int(10) unsigned NOT NULL DEFAULT 0,
+    `expires_at` timestamp NULL DEFAULT NULL,
+    `last_clicked_at` timestamp NULL DEFAULT NULL,
+    `created_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `links_code_idx` (`code`),
+    KEY `links_user_idx` (`user_id`),
+    KEY `links_campaign_idx` (`campaign`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,16 @@
+CREATE TABLE links (
+    id serial PRIMARY KEY,
+    code VARCHAR(64) NOT NULL UNIQUE,
+    url TEXT NOT NULL,
+    campaign VARCHAR(128) NOT NULL DEFAULT '',
+    source VARCHAR(128) NOT NULL DEFAULT '',
+    medium VARCHAR(128) NOT NULL DEFAULT '',
+    user_id INTEGER NOT NULL DEFAULT 0,
+    clicks INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NULL,
+    last_clicked_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX links_user_idx ON links (user_id);
+CREATE INDEX links_campaign_idx ON links (campaign);
@@ -0,0 +1,16 @@
+CREATE TABLE `media` (
+    `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+    `model_type` varchar(64) NOT NULL,
+    `model_id` int(10) unsigned NOT NULL,
+    `collection` varchar(64) NOT NULL DEFAULT '',
+    `name` varchar(255) NOT NULL,
+    `file` varchar(255) NOT NULL,
+    `mime` varchar(128) NOT NULL,
+    `size` bigint NOT NULL,
+    `width` int NOT NULL DEFAULT 0,
+    `height` int NOT NULL DEFAULT 0,
+    `conversions` text NOT NULL,
+    `created_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`id`),
+    KEY `media_model_idx` (`model_type`, `model_id`, `collection`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
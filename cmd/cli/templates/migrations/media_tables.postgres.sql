@@ -0,0 +1,16 @@
+CREATE TABLE media (
+    id serial PRIMARY KEY,
+    model_type VARCHAR(64) NOT NULL,
+    model_id INTEGER NOT NULL,
+    collection VARCHAR(64) NOT NULL DEFAULT '',
+    name VARCHAR(255) NOT NULL,
+    file VARCHAR(255) NOT NULL,
+    mime VARCHAR(128) NOT NULL,
+    size BIGINT NOT NULL,
+    width INTEGER NOT NULL DEFAULT 0,
+    height INTEGER NOT NULL DEFAULT 0,
+    conversions TEXT NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX media_model_idx ON media (model_type, model_id, collection);
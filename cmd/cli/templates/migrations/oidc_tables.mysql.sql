@@ -0,0 +1,38 @@
+CREATE TABLE `oidc_clients` (
+    `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+    `client_id` varchar(64) NOT NULL,
+    `name` varchar(255) NOT NULL,
+    `secret_hash` varchar(64) NOT NULL DEFAULT '',
+    `redirect_uris` text NOT NULL,
+    `public` tinyint(1) NOT NULL DEFAULT 0,
+    `trusted` tinyint(1) NOT NULL DEFAULT 0,
+    `created_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    `updated_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `oidc_clients_client_id_idx` (`client_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `oidc_consents` (
+    `user_id` int(10) unsigned NOT NULL,
+    `client_id` varchar(64) NOT NULL,
+    `scopes` text NOT NULL,
+    `created_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    `updated_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`user_id`, `client_id`),
+    CONSTRAINT `oidc_consents_client_fk` FOREIGN KEY (`client_id`) REFERENCES `oidc_clients` (`client_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `oidc_codes` (
+    `code_hash` varchar(64) NOT NULL,
+    `client_id` varchar(64) NOT NULL,
+    `user_id` int(10) unsigned NOT NULL,
+    `redirect_uri` text NOT NULL,
+    `scopes` text NOT NULL,
+    `nonce` varchar(255) NOT NULL DEFAULT '',
+    `challenge` varchar(128) NOT NULL,
+    `expires_at` timestamp NOT NULL,
+    `created_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`code_hash`),
+    KEY `oidc_codes_expires_idx` (`expires_at`),
+    CONSTRAINT `oidc_codes_client_fk` FOREIGN KEY (`client_id`) REFERENCES `oidc_clients` (`client_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
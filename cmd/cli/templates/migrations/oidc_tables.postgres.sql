@@ -0,0 +1,34 @@
+CREATE TABLE oidc_clients (
+    id serial PRIMARY KEY,
+    client_id VARCHAR(64) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    secret_hash VARCHAR(64) NOT NULL DEFAULT '',
+    redirect_uris TEXT NOT NULL,
+    public BOOLEAN NOT NULL DEFAULT FALSE,
+    trusted BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE oidc_consents (
+    user_id INTEGER NOT NULL,
+    client_id VARCHAR(64) NOT NULL REFERENCES oidc_clients (client_id) ON DELETE CASCADE,
+    scopes TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, client_id)
+);
+
+CREATE TABLE oidc_codes (
+    code_hash VARCHAR(64) PRIMARY KEY,
+    client_id VARCHAR(64) NOT NULL REFERENCES oidc_clients (client_id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL,
+    redirect_uri TEXT NOT NULL,
+    scopes TEXT NOT NULL,
+    nonce VARCHAR(255) NOT NULL DEFAULT '',
+    challenge VARCHAR(128) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX oidc_codes_expires_idx ON oidc_codes (expires_at);
@@ -0,0 +1,37 @@
+CREATE TABLE `payment_customers` (
+    `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+    `user_id` int(10) unsigned NOT NULL,
+    `provider` varchar(32) NOT NULL,
+    `customer_id` varchar(255) NOT NULL,
+    `email` varchar(255) NOT NULL DEFAULT '',
+    `created_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `payment_customers_customer_idx` (`provider`, `customer_id`),
+    KEY `payment_customers_user_idx` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `payment_subscriptions` (
+    `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+    `user_id` int(10) unsigned NOT NULL,
+    `provider` varchar(32) NOT NULL,
+    `customer_id` varchar(255) NOT NULL,
+    `subscription_id` varchar(255) NOT NULL,
+    `status` varchar(32) NOT NULL,
+    `price` varchar(255) NOT NULL DEFAULT '',
+    `quantity` int NOT NULL DEFAULT 1,
+    `period_end` timestamp NULL DEFAULT NULL,
+    `updated_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `payment_subscriptions_subscription_idx` (`provider`, `subscription_id`),
+    KEY `payment_subscriptions_user_idx` (`user_id`, `updated_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `payment_events` (
+    `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+    `provider` varchar(32) NOT NULL,
+    `event_id` varchar(255) NOT NULL,
+    `kind` varchar(64) NOT NULL,
+    `created_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `payment_events_event_idx` (`provider`, `event_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,37 @@
+CREATE TABLE payment_customers (
+    id serial PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    provider VARCHAR(32) NOT NULL,
+    customer_id VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX payment_customers_customer_idx ON payment_customers (provider, customer_id);
+CREATE INDEX payment_customers_user_idx ON payment_customers (user_id);
+
+CREATE TABLE payment_subscriptions (
+    id serial PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    provider VARCHAR(32) NOT NULL,
+    customer_id VARCHAR(255) NOT NULL,
+    subscription_id VARCHAR(255) NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    price VARCHAR(255) NOT NULL DEFAULT '',
+    quantity INTEGER NOT NULL DEFAULT 1,
+    period_end TIMESTAMP NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX payment_subscriptions_subscription_idx ON payment_subscriptions (provider, subscription_id);
+CREATE INDEX payment_subscriptions_user_idx ON payment_subscriptions (user_id, updated_at);
+
+CREATE TABLE payment_events (
+    id serial PRIMARY KEY,
+    provider VARCHAR(32) NOT NULL,
+    event_id VARCHAR(255) NOT NULL,
+    kind VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX payment_events_event_idx ON payment_events (provider, event_id);
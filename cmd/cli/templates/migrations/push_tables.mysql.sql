@@ -0,0 +1,13 @@
+CREATE TABLE `push_subscriptions` (
+    `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+    `user_id` int(10) unsigned NOT NULL,
+    `driver` varchar(16) NOT NULL,
+    `endpoint` varchar(768) NOT NULL,
+    `p256dh` varchar(255) NOT NULL DEFAULT '',
+    `auth` varchar(64) NOT NULL DEFAULT '',
+    `user_agent` varchar(255) NOT NULL DEFAULT '',
+    `created_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `push_subscriptions_endpoint_idx` (`endpoint`),
+    KEY `push_subscriptions_user_idx` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,12 @@
+CREATE TABLE push_subscriptions (
+    id serial PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    driver VARCHAR(16) NOT NULL,
+    endpoint VARCHAR(1024) NOT NULL UNIQUE,
+    p256dh VARCHAR(255) NOT NULL DEFAULT '',
+    auth VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX push_subscriptions_user_idx ON push_subscriptions (user_id);
@@ -0,0 +1,12 @@
+CREATE TABLE `report_runs` (
+    `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+    `report` varchar(255) NOT NULL,
+    `status` varchar(32) NOT NULL,
+    `rows_count` int NOT NULL DEFAULT 0,
+    `recipients` text NOT NULL,
+    `error` text NOT NULL,
+    `started_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    `finished_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`id`),
+    KEY `report_runs_report_idx` (`report`, `started_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,12 @@
+CREATE TABLE report_runs (
+    id serial PRIMARY KEY,
+    report VARCHAR(255) NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    rows_count INTEGER NOT NULL DEFAULT 0,
+    recipients TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX report_runs_report_idx ON report_runs (report, started_at);
@@ -0,0 +1,9 @@
+CREATE TABLE `settings` (
+    `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+    `name` varchar(255) NOT NULL,
+    `value` text NOT NULL,
+    `created_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    `updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp(),
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `settings_name_unique` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,7 @@
+CREATE TABLE settings (
+    id serial PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    value TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,5 @@
+CREATE TABLE `sms_opt_outs` (
+    `phone` varchar(32) NOT NULL,
+    `created_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`phone`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,4 @@
+CREATE TABLE sms_opt_outs (
+    phone VARCHAR(32) PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,20 @@
+CREATE TABLE `tags` (
+    `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+    `kind` varchar(32) NOT NULL,
+    `name` varchar(255) NOT NULL,
+    `slug` varchar(191) NOT NULL,
+    `parent_id` int(10) unsigned NOT NULL DEFAULT 0,
+    `created_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `tags_slug_idx` (`kind`, `slug`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `taggables` (
+    `tag_id` int(10) unsigned NOT NULL,
+    `taggable_type` varchar(64) NOT NULL,
+    `taggable_id` int(10) unsigned NOT NULL,
+    `created_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    PRIMARY KEY (`tag_id`, `taggable_type`, `taggable_id`),
+    KEY `taggables_taggable_idx` (`taggable_type`, `taggable_id`),
+    CONSTRAINT `taggables_tag_fk` FOREIGN KEY (`tag_id`) REFERENCES `tags` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,20 @@
+CREATE TABLE tags (
+    id serial PRIMARY KEY,
+    kind VARCHAR(32) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    slug VARCHAR(255) NOT NULL,
+    parent_id INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX tags_slug_idx ON tags (kind, slug);
+
+CREATE TABLE taggables (
+    tag_id INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    taggable_type VARCHAR(64) NOT NULL,
+    taggable_id INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tag_id, taggable_type, taggable_id)
+);
+
+CREATE INDEX taggables_taggable_idx ON taggables (taggable_type, taggable_id);
@@ -0,0 +1,14 @@
+CREATE TABLE `workflows` (
+    `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
+    `workflow` varchar(255) NOT NULL,
+    `status` varchar(20) NOT NULL,
+    `step` int(10) unsigned NOT NULL DEFAULT 0,
+    `state` text NOT NULL,
+    `error` text NOT NULL,
+    `holder` varchar(32) NOT NULL DEFAULT '',
+    `lease_until` timestamp NULL DEFAULT NULL,
+    `created_at` timestamp NOT NULL DEFAULT current_timestamp(),
+    `updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp(),
+    PRIMARY KEY (`id`),
+    KEY `workflows_status_idx` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
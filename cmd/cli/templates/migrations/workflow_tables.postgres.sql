@@ -0,0 +1,14 @@
+CREATE TABLE workflows (
+    id serial PRIMARY KEY,
+    workflow VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    step INTEGER NOT NULL DEFAULT 0,
+    state TEXT NOT NULL DEFAULT '{}',
+    error TEXT NOT NULL DEFAULT '',
+    holder VARCHAR(32) NOT NULL DEFAULT '',
+    lease_until TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX workflows_status_idx ON workflows (status);
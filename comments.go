@@ -0,0 +1,37 @@
+package goravel
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/namnguyen191/goravel/comments"
+	"github.com/namnguyen191/goravel/jobs"
+)
+
+// createComments hands the events of the comments to Notify in jobs of the queue, so the
+// notifications of a comment are sent off the request which posted or approved it
+func (grv *Goravel) createComments() *comments.Comments {
+	cs := comments.New(grv.DB.Pool, grv.DB.DataBaseType)
+	cs.Moderate = strings.ToLower(os.Getenv("COMMENTS_MODERATE")) == "true"
+	cs.ErrorLog = grv.ErrorLog.Println
+	cs.Clock = grv.Clock
+	cs.Dispatch = func(ctx context.Context, e comments.Event) error {
+		_, err := grv.Jobs.Push(ctx, comments.Job, e)
+		return err
+	}
+
+	grv.Jobs.HandleFunc(comments.Job, func(ctx context.Context, job *jobs.Job) error {
+		var e comments.Event
+		if err := job.Decode(&e); err != nil {
+			return err
+		}
+		// Notify is set by the app once it booted
+		if cs.Notify != nil {
+			cs.Notify(e)
+		}
+		return nil
+	})
+
+	return cs
+}
@@ -0,0 +1,400 @@
+package comments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+)
+
+// Moderation states of a comment
+const (
+	Pending  = "pending"
+	Approved = "approved"
+	Rejected = "rejected"
+	Spam     = "spam"
+)
+
+// Kinds of events sent to Notify
+const (
+	Created  = "created"
+	Replied  = "replied"
+	Approval = "approved"
+)
+
+var (
+	// ErrEmpty is returned for comments without a body
+	ErrEmpty = errors.New("comments: body is empty")
+	// ErrTooDeep is returned for replies nested deeper than MaxDepth
+	ErrTooDeep = errors.New("comments: reply nested too deep")
+	// ErrParent is returned when the parent is missing or belongs to another commentable
+	ErrParent = errors.New("comments: invalid parent")
+)
+
+// Comment is attached to any commentable record by type and id, e.g. ("post", 12)
+type Comment struct {
+	ID              int
+	CommentableType string
+	CommentableID   int
+	ParentID        int
+	// RootID is the top level comment of the thread, the comment itself for top level ones
+	RootID    int
+	Depth     int
+	UserID    int
+	Body      string
+	Status    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Replies   []*Comment
+}
+
+// Event is sent to Notify when a comment becomes visible; Parent is set for replies
+type Event struct {
+	Kind    string
+	Comment Comment
+	Parent  *Comment
+}
+
+// Comments stores threaded comments
+type Comments struct {
+	DB           *sql.DB
+	DatabaseType string
+	// MaxDepth limits nesting; top level comments have depth 0
+	MaxDepth int
+	// PerPage is the number of top level comments per page
+	PerPage int
+	// Moderate holds new comments as pending until approved
+	Moderate bool
+	// SpamCheck flags a comment as spam before it is stored, e.g. through Akismet
+	SpamCheck func(ctx context.Context, c *Comment) (bool, error)
+	// Notify fans out events; by default nothing is sent
+	Notify func(Event)
+	// Dispatch has Notify receive an event in the background; by default in a goroutine, and
+	// with a job queue by pushing a Job with the event
+	Dispatch func(ctx context.Context, e Event) error
+	ErrorLog func(v ...interface{})
+	// Clock tells when comments are posted and changed; the time of the machine when nil
+	Clock clock.Clock
+}
+
+// Job is the type of the queued jobs handing an event to Notify, their payload the event
+const Job = "comment-event"
+
+// New returns comments stored in the comments table
+func New(db *sql.DB, dbType string) *Comments {
+	cs := &Comments{
+		DB:           db,
+		DatabaseType: dbType,
+		MaxDepth:     3,
+		PerPage:      20,
+	}
+	cs.Dispatch = func(ctx context.Context, e Event) error {
+		go cs.Notify(e)
+		return nil
+	}
+
+	return cs
+}
+
+// Post stores a comment or reply and returns it with its id and moderation status
+func (cs *Comments) Post(ctx context.Context, c Comment) (*Comment, error) {
+	c.Body = strings.TrimSpace(c.Body)
+	if c.Body == "" {
+		return nil, ErrEmpty
+	}
+
+	var parent *Comment
+	if c.ParentID != 0 {
+		p, err := cs.Find(ctx, c.ParentID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrParent
+		}
+		if err != nil {
+			return nil, err
+		}
+		if p.CommentableType != c.CommentableType || p.CommentableID != c.CommentableID {
+			return nil, ErrParent
+		}
+		if p.Depth+1 > cs.MaxDepth {
+			return nil, ErrTooDeep
+		}
+		parent = p
+		c.RootID = p.RootID
+		c.Depth = p.Depth + 1
+	}
+
+	status, err := cs.status(ctx, &c)
+	if err != nil {
+		return nil, err
+	}
+	c.Status = status
+	c.CreatedAt = clock.Now(cs.Clock)
+	c.UpdatedAt = c.CreatedAt
+
+	if err := cs.insert(ctx, &c); err != nil {
+		return nil, err
+	}
+
+	if c.Status == Approved {
+		cs.published(ctx, c, parent)
+	}
+
+	return &c, nil
+}
+
+// status decides where a new comment starts: spam, pending moderation or approved
+func (cs *Comments) status(ctx context.Context, c *Comment) (string, error) {
+	if cs.SpamCheck != nil {
+		spam, err := cs.SpamCheck(ctx, c)
+		if err != nil {
+			return "", err
+		}
+		if spam {
+			return Spam, nil
+		}
+	}
+
+	if cs.Moderate {
+		return Pending, nil
+	}
+
+	return Approved, nil
+}
+
+func (cs *Comments) insert(ctx context.Context, c *Comment) error {
+	tx, err := cs.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `insert into comments (commentable_type, commentable_id, parent_id, root_id, depth, user_id, body, status, created_at, updated_at)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	args := []interface{}{c.CommentableType, c.CommentableID, c.ParentID, c.RootID, c.Depth, c.UserID, c.Body, c.Status, c.CreatedAt, c.UpdatedAt}
+
+	if database.IsPostgres(cs.DatabaseType) {
+		err = tx.QueryRowContext(ctx, database.Rebind(cs.DatabaseType, query+" returning id"), args...).Scan(&c.ID)
+	} else {
+		var res sql.Result
+		res, err = tx.ExecContext(ctx, query, args...)
+		if err == nil {
+			var id int64
+			id, err = res.LastInsertId()
+			c.ID = int(id)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	// top level comments are the root of their own thread
+	if c.RootID == 0 {
+		c.RootID = c.ID
+		if _, err := tx.ExecContext(ctx, database.Rebind(cs.DatabaseType, "update comments set root_id = ? where id = ?"), c.ID, c.ID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// published dispatches the events of a comment which became visible; the comment is stored by
+// then, so a failure to dispatch is only logged
+func (cs *Comments) published(ctx context.Context, c Comment, parent *Comment) {
+	if cs.Notify == nil {
+		return
+	}
+
+	events := []Event{{Kind: Created, Comment: c, Parent: parent}}
+	if parent != nil && parent.UserID != c.UserID {
+		events = append(events, Event{Kind: Replied, Comment: c, Parent: parent})
+	}
+	for _, e := range events {
+		if err := cs.Dispatch(ctx, e); err != nil && cs.ErrorLog != nil {
+			cs.ErrorLog("comments:", err)
+		}
+	}
+}
+
+const columns = "id, commentable_type, commentable_id, parent_id, root_id, depth, user_id, body, status, created_at, updated_at"
+
+func scan(row interface{ Scan(...interface{}) error }) (*Comment, error) {
+	var c Comment
+	err := row.Scan(&c.ID, &c.CommentableType, &c.CommentableID, &c.ParentID, &c.RootID, &c.Depth, &c.UserID, &c.Body, &c.Status, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (cs *Comments) query(ctx context.Context, query string, args ...interface{}) ([]*Comment, error) {
+	rows, err := cs.DB.QueryContext(ctx, database.Rebind(cs.DatabaseType, query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*Comment
+	for rows.Next() {
+		c, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+
+	return list, rows.Err()
+}
+
+// Find returns a comment by id
+func (cs *Comments) Find(ctx context.Context, id int) (*Comment, error) {
+	return scan(cs.DB.QueryRowContext(ctx, database.Rebind(cs.DatabaseType, "select "+columns+" from comments where id = ?"), id))
+}
+
+// Thread returns a page (starting at 1) of approved top level comments, oldest first,
+// with their approved replies nested under them
+func (cs *Comments) Thread(ctx context.Context, commentableType string, commentableID, page int) ([]*Comment, error) {
+	if page < 1 {
+		page = 1
+	}
+
+	roots, err := cs.query(ctx, fmt.Sprintf("select %s from comments where commentable_type = ? and commentable_id = ? and parent_id = 0 and status = ? order by created_at, id limit %d offset %d",
+		columns, cs.PerPage, (page-1)*cs.PerPage), commentableType, commentableID, Approved)
+	if err != nil || len(roots) == 0 {
+		return roots, err
+	}
+
+	ids := make([]interface{}, len(roots))
+	for i, r := range roots {
+		ids[i] = r.ID
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	replies, err := cs.query(ctx, "select "+columns+" from comments where parent_id <> 0 and status = ? and root_id in ("+placeholders+") order by created_at, id",
+		append([]interface{}{Approved}, ids...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return nest(roots, replies), nil
+}
+
+// nest hangs replies under their parents; replies whose parent is hidden are dropped
+func nest(roots, replies []*Comment) []*Comment {
+	byID := map[int]*Comment{}
+	for _, r := range roots {
+		byID[r.ID] = r
+	}
+
+	// replies are ordered by creation, so a parent is always seen before its replies
+	for _, r := range replies {
+		if parent, ok := byID[r.ParentID]; ok {
+			parent.Replies = append(parent.Replies, r)
+			byID[r.ID] = r
+		}
+	}
+
+	return roots
+}
+
+// Count returns the number of approved comments on a commentable
+func (cs *Comments) Count(ctx context.Context, commentableType string, commentableID int) (int, error) {
+	var n int
+	err := cs.DB.QueryRowContext(ctx, database.Rebind(cs.DatabaseType, "select count(*) from comments where commentable_type = ? and commentable_id = ? and status = ?"),
+		commentableType, commentableID, Approved).Scan(&n)
+
+	return n, err
+}
+
+// Queue returns a page of comments waiting for moderation, oldest first
+func (cs *Comments) Queue(ctx context.Context, page int) ([]*Comment, error) {
+	if page < 1 {
+		page = 1
+	}
+
+	return cs.query(ctx, fmt.Sprintf("select %s from comments where status = ? order by created_at, id limit %d offset %d", columns, cs.PerPage, (page-1)*cs.PerPage), Pending)
+}
+
+// Approve publishes a pending comment and notifies as if it was just posted
+func (cs *Comments) Approve(ctx context.Context, id int) error {
+	c, err := cs.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := cs.setStatus(ctx, id, Approved); err != nil {
+		return err
+	}
+
+	if c.Status != Approved {
+		var parent *Comment
+		if c.ParentID != 0 {
+			parent, _ = cs.Find(ctx, c.ParentID)
+		}
+		c.Status = Approved
+		cs.published(ctx, *c, parent)
+	}
+
+	return nil
+}
+
+// Reject hides a comment
+func (cs *Comments) Reject(ctx context.Context, id int) error {
+	return cs.setStatus(ctx, id, Rejected)
+}
+
+// MarkSpam hides a comment as spam
+func (cs *Comments) MarkSpam(ctx context.Context, id int) error {
+	return cs.setStatus(ctx, id, Spam)
+}
+
+func (cs *Comments) setStatus(ctx context.Context, id int, status string) error {
+	_, err := cs.DB.ExecContext(ctx, database.Rebind(cs.DatabaseType, "update comments set status = ?, updated_at = ? where id = ?"), status, clock.Now(cs.Clock), id)
+	return err
+}
+
+// Edit replaces the body of a comment
+func (cs *Comments) Edit(ctx context.Context, id int, body string) error {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return ErrEmpty
+	}
+
+	_, err := cs.DB.ExecContext(ctx, database.Rebind(cs.DatabaseType, "update comments set body = ?, updated_at = ? where id = ?"), body, clock.Now(cs.Clock), id)
+	return err
+}
+
+// Delete removes a comment together with its replies
+func (cs *Comments) Delete(ctx context.Context, id int) error {
+	c, err := cs.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// replies are deleted level by level since they only point at their direct parent
+	ids := []interface{}{c.ID}
+	for len(ids) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+		children, err := cs.query(ctx, "select "+columns+" from comments where parent_id in ("+placeholders+")", ids...)
+		if err != nil {
+			return err
+		}
+
+		if _, err := cs.DB.ExecContext(ctx, database.Rebind(cs.DatabaseType, "delete from comments where id in ("+placeholders+")"), ids...); err != nil {
+			return err
+		}
+
+		ids = ids[:0]
+		for _, child := range children {
+			ids = append(ids, child.ID)
+		}
+	}
+
+	return nil
+}
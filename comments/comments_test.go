@@ -0,0 +1,74 @@
+package comments
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNest(t *testing.T) {
+	roots := []*Comment{{ID: 1}, {ID: 2}}
+	replies := []*Comment{
+		{ID: 3, ParentID: 1, RootID: 1},
+		{ID: 4, ParentID: 3, RootID: 1},
+		{ID: 5, ParentID: 9, RootID: 2},
+		{ID: 6, ParentID: 2, RootID: 2},
+	}
+
+	thread := nest(roots, replies)
+
+	if len(thread[0].Replies) != 1 || thread[0].Replies[0].Replies[0].ID != 4 {
+		t.Errorf("expected 4 nested under 3 under 1, got %+v", thread[0].Replies)
+	}
+	if len(thread[1].Replies) != 1 || thread[1].Replies[0].ID != 6 {
+		t.Errorf("expected a reply to a hidden comment to be dropped, got %+v", thread[1].Replies)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	cs := New(nil, "postgres")
+	ctx := context.Background()
+
+	if s, _ := cs.status(ctx, &Comment{Body: "hi"}); s != Approved {
+		t.Error("expected approved, got", s)
+	}
+
+	cs.Moderate = true
+	if s, _ := cs.status(ctx, &Comment{Body: "hi"}); s != Pending {
+		t.Error("expected pending, got", s)
+	}
+
+	cs.SpamCheck = func(ctx context.Context, c *Comment) (bool, error) { return c.Body == "buy now", nil }
+	if s, _ := cs.status(ctx, &Comment{Body: "buy now"}); s != Spam {
+		t.Error("expected spam, got", s)
+	}
+
+	if _, err := cs.Post(ctx, Comment{Body: "  "}); !errors.Is(err, ErrEmpty) {
+		t.Error("expected empty body error, got", err)
+	}
+}
+
+func TestPublished(t *testing.T) {
+	cs := New(nil, "postgres")
+	cs.Notify = func(Event) {}
+
+	var kinds []string
+	cs.Dispatch = func(ctx context.Context, e Event) error {
+		kinds = append(kinds, e.Kind)
+		return errors.New("queue down")
+	}
+
+	var logged int
+	cs.ErrorLog = func(v ...interface{}) { logged++ }
+
+	cs.published(context.Background(), Comment{ID: 2, UserID: 1}, &Comment{ID: 1, UserID: 3})
+	if len(kinds) != 2 || kinds[0] != Created || kinds[1] != Replied || logged != 2 {
+		t.Errorf("expected a created and a replied event, each failure logged, got %v %d", kinds, logged)
+	}
+
+	kinds = nil
+	cs.published(context.Background(), Comment{ID: 2, UserID: 1}, &Comment{ID: 1, UserID: 1})
+	if len(kinds) != 1 {
+		t.Errorf("expected no reply event for a reply to oneself, got %v", kinds)
+	}
+}
@@ -0,0 +1,269 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// ErrPoolClosed is returned by Submit once the pool is closed
+var ErrPoolClosed = errors.New("concurrency: pool closed")
+
+// PanicError is a panic recovered from a goroutine started by these helpers
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", p.Value)
+}
+
+// Concurrency starts goroutines which cannot take the process down: a panic is recovered,
+// passed to OnPanic and, where the work returns an error, returned as a *PanicError
+type Concurrency struct {
+	OnPanic func(p *PanicError)
+}
+
+// New returns helpers reporting panics to onPanic, or to the standard logger when it is nil
+func New(onPanic func(p *PanicError)) *Concurrency {
+	if onPanic == nil {
+		onPanic = func(p *PanicError) {
+			log.Println(p, string(p.Stack))
+		}
+	}
+	return &Concurrency{OnPanic: onPanic}
+}
+
+// run calls fn, turning a panic into a reported *PanicError
+func (c *Concurrency) run(fn func() error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			p := &PanicError{Value: v, Stack: debug.Stack()}
+			if c.OnPanic != nil {
+				c.OnPanic(p)
+			}
+			err = p
+		}
+	}()
+
+	return fn()
+}
+
+// Go runs fn in the background, for fire and forget work such as sending a webhook
+func (c *Concurrency) Go(fn func()) {
+	go func() {
+		_ = c.run(func() error {
+			fn()
+			return nil
+		})
+	}()
+}
+
+// Group runs functions in parallel, cancelling the context of the others as soon as one
+// fails, like errgroup. At most limit run at a time; zero or less means no limit.
+type Group struct {
+	c      *Concurrency
+	ctx    context.Context
+	cancel context.CancelFunc
+	sem    chan struct{}
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    error
+}
+
+// Group returns a group whose functions get a context derived from ctx
+func (c *Concurrency) Group(ctx context.Context, limit int) *Group {
+	ctx, cancel := context.WithCancel(ctx)
+	g := &Group{c: c, ctx: ctx, cancel: cancel}
+	if limit > 0 {
+		g.sem = make(chan struct{}, limit)
+	}
+	return g
+}
+
+// Go runs fn once a slot is free; it is skipped when the group was already cancelled
+func (g *Group) Go(fn func(ctx context.Context) error) {
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		case <-g.ctx.Done():
+			g.fail(g.ctx.Err())
+			return
+		}
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if g.sem != nil {
+			defer func() { <-g.sem }()
+		}
+
+		if err := g.c.run(func() error { return fn(g.ctx) }); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+func (g *Group) fail(err error) {
+	g.once.Do(func() {
+		g.err = err
+		g.cancel()
+	})
+}
+
+// Wait blocks until every function returned and returns the first error
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
+
+// ForEach calls fn for the indexes 0 to n-1 with at most limit calls at a time, stopping at
+// the first error. fn usually fills in results[i], which makes it a parallel map:
+//
+//	prices := make([]float64, len(items))
+//	err := grv.Concurrency.ForEach(ctx, len(items), 4, func(ctx context.Context, i int) (err error) {
+//		prices[i], err = quote(ctx, items[i])
+//		return err
+//	})
+func (c *Concurrency) ForEach(ctx context.Context, n, limit int, fn func(ctx context.Context, i int) error) error {
+	g := c.Group(ctx, limit)
+	for i := 0; i < n; i++ {
+		i := i
+		g.Go(func(ctx context.Context) error {
+			return fn(ctx, i)
+		})
+	}
+	return g.Wait()
+}
+
+// Pool is a fixed number of workers taking tasks from a bounded queue, for work that should
+// not open an unbounded number of goroutines, e.g. resizing uploaded images
+type Pool struct {
+	c     *Concurrency
+	tasks chan func()
+	wg    sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// Pool starts workers taking tasks from a queue holding up to queue tasks
+func (c *Concurrency) Pool(workers, queue int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+
+	p := &Pool{c: c, tasks: make(chan func(), queue)}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer p.wg.Done()
+			for task := range p.tasks {
+				task := task
+				_ = p.c.run(func() error {
+					task()
+					return nil
+				})
+			}
+		}()
+	}
+
+	return p
+}
+
+// Submit queues task, waiting for room in the queue until ctx is done
+func (p *Pool) Submit(ctx context.Context, task func()) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrPoolClosed
+	}
+
+	select {
+	case p.tasks <- task:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TrySubmit queues task unless the queue is full, reporting whether it was queued
+func (p *Pool) TrySubmit(task func()) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return false
+	}
+
+	select {
+	case p.tasks <- task:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops taking tasks and waits for the queued ones to finish
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.tasks)
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+}
+
+// Debounce returns a function which calls fn once calls have stopped for wait, e.g. to
+// rebuild a search index after a burst of edits
+func (c *Concurrency) Debounce(wait time.Duration, fn func()) func() {
+	var mu sync.Mutex
+	var timer *time.Timer
+
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if timer != nil {
+			timer.Stop()
+		}
+		timer = time.AfterFunc(wait, func() {
+			_ = c.run(func() error {
+				fn()
+				return nil
+			})
+		})
+	}
+}
+
+// Throttle returns a function which calls fn at most once every interval, dropping the calls
+// in between
+func (c *Concurrency) Throttle(interval time.Duration, fn func()) func() {
+	var mu sync.Mutex
+	var last time.Time
+
+	return func() {
+		mu.Lock()
+		if !last.IsZero() && time.Since(last) < interval {
+			mu.Unlock()
+			return
+		}
+		last = time.Now()
+		mu.Unlock()
+
+		_ = c.run(func() error {
+			fn()
+			return nil
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEach(t *testing.T) {
+	c := New(nil)
+
+	var running, peak int32
+	out := make([]int, 20)
+	err := c.ForEach(context.Background(), len(out), 3, func(ctx context.Context, i int) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&running, -1)
+
+		out[i] = i * i
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peak > 3 {
+		t.Errorf("expected at most 3 at a time, got %d", peak)
+	}
+	if out[19] != 361 {
+		t.Errorf("unexpected results %v", out)
+	}
+}
+
+func TestGroupCancelsOnError(t *testing.T) {
+	c := New(nil)
+	g := c.Group(context.Background(), 0)
+
+	boom := errors.New("boom")
+	g.Go(func(ctx context.Context) error { return boom })
+	g.Go(func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return errors.New("not cancelled")
+		}
+	})
+
+	if err := g.Wait(); err != boom {
+		t.Errorf("expected the first error, got %v", err)
+	}
+}
+
+func TestPanicsAreReported(t *testing.T) {
+	var mu sync.Mutex
+	var reported []*PanicError
+	c := New(func(p *PanicError) {
+		mu.Lock()
+		reported = append(reported, p)
+		mu.Unlock()
+	})
+
+	err := c.ForEach(context.Background(), 1, 1, func(ctx context.Context, i int) error {
+		panic("nil map")
+	})
+	var p *PanicError
+	if !errors.As(err, &p) || p.Value != "nil map" || len(p.Stack) == 0 {
+		t.Errorf("expected a panic error, got %v", err)
+	}
+
+	pool := c.Pool(2, 4)
+	if err := pool.Submit(context.Background(), func() { panic("task") }); err != nil {
+		t.Fatal(err)
+	}
+	var ran int32
+	_ = pool.Submit(context.Background(), func() { atomic.AddInt32(&ran, 1) })
+	pool.Close()
+
+	if ran != 1 {
+		t.Error("expected the pool to keep running after a panic")
+	}
+	if pool.TrySubmit(func() {}) {
+		t.Error("expected a closed pool to refuse tasks")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 2 {
+		t.Errorf("expected 2 reported panics, got %d", len(reported))
+	}
+}
+
+func TestDebounceAndThrottle(t *testing.T) {
+	c := New(nil)
+
+	var debounced int32
+	d := c.Debounce(20*time.Millisecond, func() { atomic.AddInt32(&debounced, 1) })
+	for i := 0; i < 5; i++ {
+		d()
+	}
+	time.Sleep(60 * time.Millisecond)
+	if n := atomic.LoadInt32(&debounced); n != 1 {
+		t.Errorf("expected one debounced call, got %d", n)
+	}
+
+	throttled := 0
+	th := c.Throttle(time.Hour, func() { throttled++ })
+	th()
+	th()
+	if throttled != 1 {
+		t.Errorf("expected one throttled call, got %d", throttled)
+	}
+}
@@ -0,0 +1,68 @@
+package contact
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		in, want string
+		ok       bool
+	}{
+		{"  John.Doe@Example.COM ", "john.doe@example.com", true},
+		{"user@bücher.de", "user@xn--bcher-kva.de", true},
+		{"no-at-sign", "", false},
+		{"a..b@example.com", "", false},
+		{"user@localhost", "", false},
+	}
+
+	for _, e := range tests {
+		got, err := NormalizeEmail(e.in)
+		if (err == nil) != e.ok || got != e.want {
+			t.Errorf("%q: expected %q (ok=%v), got %q, %v", e.in, e.want, e.ok, got, err)
+		}
+	}
+
+	if got, _ := CanonicalEmail("J.Doe+newsletter@googlemail.com"); got != "jdoe@gmail.com" {
+		t.Error("unexpected canonical email", got)
+	}
+}
+
+func TestParsePhone(t *testing.T) {
+	tests := []struct {
+		in, region, want string
+	}{
+		{"+84 912 345 678", "", "+84912345678"},
+		{"0912-345-678", "VN", "+84912345678"},
+		{"0044 20 7946 0958", "", "+442079460958"},
+		{"(415) 555-2671", "US", "+14155552671"},
+		{"1-415-555-2671", "us", "+14155552671"},
+		{"06 1234 5678", "IT", "+390612345678"},
+		{"+49 30 123456 ext. 12", "", "+4930123456"},
+	}
+
+	for _, e := range tests {
+		got, err := NormalizePhone(e.in, e.region)
+		if err != nil || got != e.want {
+			t.Errorf("%q: expected %s, got %s %v", e.in, e.want, got, err)
+		}
+	}
+
+	for _, bad := range []string{"12", "+999 1234", "555-2671"} {
+		if _, err := ParsePhone(bad, "US"); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+
+	p, _ := ParsePhone("+84912345678", "")
+	if p.String() != "+84 912 345 678" {
+		t.Error("unexpected display format", p.String())
+	}
+}
+
+func TestValidateEmailSyntaxOnly(t *testing.T) {
+	if err := ValidateEmail(context.Background(), "bad@", false); err != ErrInvalidEmail {
+		t.Error("expected invalid email, got", err)
+	}
+}
@@ -0,0 +1,102 @@
+package contact
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+var (
+	// ErrInvalidEmail is returned for addresses that can't be delivered to
+	ErrInvalidEmail = errors.New("contact: invalid email address")
+	// ErrNoMailServer is returned when the domain of an address accepts no mail
+	ErrNoMailServer = errors.New("contact: email domain has no mail server")
+)
+
+var localPart = regexp.MustCompile(`^[a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+$`)
+
+// Resolver is used for MX lookups and can be replaced in tests
+var Resolver = net.DefaultResolver
+
+// NormalizeEmail trims the address, lowercases it and converts an international domain to punycode
+func NormalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+
+	at := strings.LastIndex(email, "@")
+	if at < 1 || at == len(email)-1 || len(email) > 254 {
+		return "", ErrInvalidEmail
+	}
+
+	local, domain := email[:at], strings.TrimSuffix(email[at+1:], ".")
+
+	if len(local) > 64 || !localPart.MatchString(local) ||
+		strings.HasPrefix(local, ".") || strings.HasSuffix(local, ".") || strings.Contains(local, "..") {
+		return "", ErrInvalidEmail
+	}
+
+	domain, err := idna.Lookup.ToASCII(strings.ToLower(domain))
+	if err != nil || !strings.Contains(domain, ".") {
+		return "", ErrInvalidEmail
+	}
+
+	return strings.ToLower(local) + "@" + domain, nil
+}
+
+// CanonicalEmail normalizes the address and removes what providers ignore: +tags everywhere and
+// dots for Gmail. It is meant for spotting duplicate sign ups, not for sending mail.
+func CanonicalEmail(email string) (string, error) {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return "", err
+	}
+
+	at := strings.LastIndex(email, "@")
+	local, domain := email[:at], email[at+1:]
+
+	if i := strings.Index(local, "+"); i > 0 {
+		local = local[:i]
+	}
+
+	if domain == "gmail.com" || domain == "googlemail.com" {
+		local = strings.ReplaceAll(local, ".", "")
+		domain = "gmail.com"
+	}
+
+	return local + "@" + domain, nil
+}
+
+// ValidateEmail checks the syntax of the address and, when checkMX is true, that its domain has a mail server
+func ValidateEmail(ctx context.Context, email string, checkMX bool) error {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return err
+	}
+
+	if !checkMX {
+		return nil
+	}
+
+	return lookupMX(ctx, email[strings.LastIndex(email, "@")+1:])
+}
+
+// lookupMX falls back to the address records of the domain, as mail servers do when there is no MX
+func lookupMX(ctx context.Context, domain string) error {
+	mx, err := Resolver.LookupMX(ctx, domain)
+	if err == nil && len(mx) > 0 {
+		// a single "." record means the domain explicitly accepts no mail
+		if len(mx) == 1 && mx[0].Host == "." {
+			return ErrNoMailServer
+		}
+		return nil
+	}
+
+	if addrs, err := Resolver.LookupHost(ctx, domain); err == nil && len(addrs) > 0 {
+		return nil
+	}
+
+	return ErrNoMailServer
+}
@@ -0,0 +1,162 @@
+package contact
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidPhone is returned for numbers that can't be turned into E.164
+var ErrInvalidPhone = errors.New("contact: invalid phone number")
+
+// callingCodes maps ISO region codes to their international calling code
+var callingCodes = map[string]int{
+	"US": 1, "CA": 1, "PR": 1, "JM": 1, "BS": 1, "BB": 1, "TT": 1, "DO": 1,
+	"RU": 7, "KZ": 7,
+	"EG": 20, "ZA": 27, "GR": 30, "NL": 31, "BE": 32, "FR": 33, "ES": 34, "HU": 36,
+	"IT": 39, "RO": 40, "CH": 41, "AT": 43, "GB": 44, "DK": 45, "SE": 46, "NO": 47,
+	"PL": 48, "DE": 49, "PE": 51, "MX": 52, "CU": 53, "AR": 54, "BR": 55, "CL": 56,
+	"CO": 57, "VE": 58, "MY": 60, "AU": 61, "ID": 62, "PH": 63, "NZ": 64, "SG": 65,
+	"TH": 66, "JP": 81, "KR": 82, "VN": 84, "CN": 86, "TR": 90, "IN": 91, "PK": 92,
+	"AF": 93, "LK": 94, "MM": 95, "IR": 98,
+	"MA": 212, "DZ": 213, "TN": 216, "LY": 218, "SN": 221, "GH": 233, "NG": 234,
+	"ET": 251, "KE": 254, "TZ": 255, "UG": 256, "ZW": 263,
+	"PT": 351, "LU": 352, "IE": 353, "IS": 354, "AL": 355, "MT": 356, "CY": 357,
+	"FI": 358, "BG": 359, "LT": 370, "LV": 371, "EE": 372, "MD": 373, "AM": 374,
+	"BY": 375, "UA": 380, "RS": 381, "HR": 385, "SI": 386, "BA": 387, "MK": 389,
+	"CZ": 420, "SK": 421,
+	"GT": 502, "SV": 503, "HN": 504, "NI": 505, "CR": 506, "PA": 507, "BO": 591,
+	"EC": 593, "PY": 595, "UY": 598,
+	"HK": 852, "MO": 853, "KH": 855, "LA": 856, "BD": 880, "TW": 886,
+	"MV": 960, "LB": 961, "JO": 962, "SY": 963, "IQ": 964, "KW": 965, "SA": 966,
+	"YE": 967, "OM": 968, "AE": 971, "IL": 972, "BH": 973, "QA": 974, "NP": 977,
+	"MN": 976, "GE": 995, "UZ": 998,
+}
+
+// keepTrunkZero lists regions where the leading 0 of national numbers is part of the number
+var keepTrunkZero = map[string]bool{"IT": true}
+
+// Phone is a parsed international phone number
+type Phone struct {
+	CountryCode int
+	// National is the subscriber number without trunk prefix
+	National string
+}
+
+// E164 formats the number as +<country code><national number>
+func (p Phone) E164() string {
+	return "+" + strconv.Itoa(p.CountryCode) + p.National
+}
+
+// String formats the number for display, e.g. +84 912 345 678
+func (p Phone) String() string {
+	var groups []string
+	n := p.National
+
+	for len(n) > 4 {
+		groups = append(groups, n[:3])
+		n = n[3:]
+	}
+	groups = append(groups, n)
+
+	return "+" + strconv.Itoa(p.CountryCode) + " " + strings.Join(groups, " ")
+}
+
+// ParsePhone reads a number in international form (+44..., 0044...) or, when region is given,
+// in national form ("0912 345 678" with region "VN")
+func ParsePhone(number, region string) (Phone, error) {
+	international := false
+	number = strings.TrimSpace(number)
+
+	// extensions are not part of E.164
+	if i := strings.IndexAny(strings.ToLower(number), "x#"); i > 0 {
+		return ParsePhone(number[:i], region)
+	}
+
+	if strings.HasPrefix(number, "+") {
+		international = true
+	}
+
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, number)
+
+	if !international && strings.HasPrefix(digits, "00") {
+		international = true
+		digits = digits[2:]
+	}
+
+	var p Phone
+
+	if international {
+		cc, rest, ok := splitCallingCode(digits)
+		if !ok {
+			return Phone{}, ErrInvalidPhone
+		}
+		p = Phone{CountryCode: cc, National: rest}
+	} else {
+		region = strings.ToUpper(region)
+		cc, ok := callingCodes[region]
+		if !ok {
+			return Phone{}, ErrInvalidPhone
+		}
+
+		if !keepTrunkZero[region] {
+			digits = strings.TrimPrefix(digits, "0")
+		}
+
+		// NANP numbers are often written with the leading 1
+		if cc == 1 && len(digits) == 11 && digits[0] == '1' {
+			digits = digits[1:]
+		}
+
+		p = Phone{CountryCode: cc, National: digits}
+	}
+
+	total := len(strconv.Itoa(p.CountryCode)) + len(p.National)
+	if len(p.National) < 4 || total > 15 || (p.CountryCode == 1 && len(p.National) != 10) {
+		return Phone{}, ErrInvalidPhone
+	}
+
+	return p, nil
+}
+
+// NormalizePhone returns the E.164 form of a number
+func NormalizePhone(number, region string) (string, error) {
+	p, err := ParsePhone(number, region)
+	if err != nil {
+		return "", err
+	}
+
+	return p.E164(), nil
+}
+
+// Regions returns the ISO region codes using a calling code
+func Regions(callingCode int) []string {
+	var regions []string
+	for region, cc := range callingCodes {
+		if cc == callingCode {
+			regions = append(regions, region)
+		}
+	}
+	sort.Strings(regions)
+
+	return regions
+}
+
+// splitCallingCode finds the one to three digit calling code at the start of digits; calling
+// codes are prefix free, so the first match is the right one
+func splitCallingCode(digits string) (int, string, bool) {
+	for n := 1; n <= 3 && n < len(digits); n++ {
+		cc, _ := strconv.Atoi(digits[:n])
+		if len(Regions(cc)) > 0 {
+			return cc, digits[n:], true
+		}
+	}
+
+	return 0, "", false
+}
@@ -0,0 +1,110 @@
+package goravel
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Container holds the services shared across the application, keyed by name
+type Container struct {
+	mu       sync.RWMutex
+	services map[string]interface{}
+}
+
+// NewContainer returns an empty service container
+func NewContainer() *Container {
+	return &Container{
+		services: make(map[string]interface{}),
+	}
+}
+
+// Bind registers a service under the given name, replacing any previous binding
+func (c *Container) Bind(name string, service interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.services[name] = service
+}
+
+// Resolve returns the service registered under name
+func (c *Container) Resolve(name string) (interface{}, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	service, ok := c.services[name]
+	if !ok {
+		return nil, fmt.Errorf("no service bound to %q", name)
+	}
+
+	return service, nil
+}
+
+// Has reports whether a service is registered under name
+func (c *Container) Has(name string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, ok := c.services[name]
+
+	return ok
+}
+
+// Inject sets every field of the struct pointed to by target that carries an `inject:"name"` tag
+// to the service bound under that name
+func (c *Container) Inject(target interface{}) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("inject target must be a pointer to a struct, got %T", target)
+	}
+
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		name, ok := field.Tag.Lookup("inject")
+		if !ok || name == "" {
+			continue
+		}
+
+		service, err := c.Resolve(name)
+		if err != nil {
+			return err
+		}
+
+		fv := rv.Field(i)
+		if !fv.CanSet() {
+			return fmt.Errorf("field %s tagged for injection must be exported", field.Name)
+		}
+
+		sv := reflect.ValueOf(service)
+		if !sv.Type().AssignableTo(field.Type) {
+			return fmt.Errorf("service %q of type %s cannot be assigned to field %s of type %s", name, sv.Type(), field.Name, field.Type)
+		}
+
+		fv.Set(sv)
+	}
+
+	return nil
+}
+
+func (grv *Goravel) createContainer() *Container {
+	c := NewContainer()
+	c.Bind("app", grv)
+	c.Bind("db", grv.DB)
+	c.Bind("mail", grv.Mail)
+	c.Bind("scheduler", grv.Scheduler)
+	c.Bind("session", grv.Session)
+	c.Bind("render", grv.Render)
+
+	if grv.Cache != nil {
+		c.Bind("cache", grv.Cache)
+	}
+
+	if grv.Jobs != nil {
+		c.Bind("jobs", grv.Jobs)
+	}
+
+	return c
+}
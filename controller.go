@@ -0,0 +1,87 @@
+package goravel
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/namnguyen191/goravel/cache"
+	"github.com/namnguyen191/goravel/mailer"
+	"github.com/namnguyen191/goravel/render"
+)
+
+// Controller is embedded in application controllers to give them access to the framework services
+type Controller struct {
+	App     *Goravel
+	DB      Database
+	Cache   cache.Cache
+	Mail    mailer.Mail
+	Render  *render.Render
+	Session *scs.SessionManager
+}
+
+// Booter is implemented by controllers that need to run setup code once their dependencies are in place
+type Booter interface {
+	Boot() error
+}
+
+var controllerType = reflect.TypeOf(Controller{})
+
+// RegisterController wires a controller once: the embedded Controller is filled from the app,
+// fields tagged with `inject:"name"` are resolved from the service container, and Boot is called if present
+func (grv *Goravel) RegisterController(c interface{}) error {
+	rv := reflect.ValueOf(c)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("controller must be a pointer to a struct, got %T", c)
+	}
+
+	elem := rv.Elem()
+	for i := 0; i < elem.NumField(); i++ {
+		field := elem.Type().Field(i)
+		if field.Anonymous && field.Type == controllerType {
+			elem.Field(i).Set(reflect.ValueOf(Controller{
+				App:     grv,
+				DB:      grv.DB,
+				Cache:   grv.Cache,
+				Mail:    grv.Mail,
+				Render:  grv.Render,
+				Session: grv.Session,
+			}))
+		}
+	}
+
+	if err := grv.Container.Inject(c); err != nil {
+		return err
+	}
+
+	if b, ok := c.(Booter); ok {
+		return b.Boot()
+	}
+
+	return nil
+}
+
+// Action adapts the named controller method to an http.HandlerFunc. Methods may have the plain
+// handler signature or return an error, in which case the error is logged and a 500 is sent.
+// It panics when the method does not exist, so mistakes surface while routes are being declared.
+func (grv *Goravel) Action(c interface{}, method string) http.HandlerFunc {
+	m := reflect.ValueOf(c).MethodByName(method)
+	if !m.IsValid() {
+		panic(fmt.Sprintf("controller %T has no method %s", c, method))
+	}
+
+	switch fn := m.Interface().(type) {
+	case func(http.ResponseWriter, *http.Request):
+		return fn
+	case func(http.ResponseWriter, *http.Request) error:
+		return func(rw http.ResponseWriter, r *http.Request) {
+			if err := fn(rw, r); err != nil {
+				grv.ErrorLog.Println(err)
+				grv.Error500(rw, r)
+			}
+		}
+	default:
+		panic(fmt.Sprintf("controller method %T.%s has an unsupported signature %s", c, method, m.Type()))
+	}
+}
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Config are the DATABASE_* settings a data source name is built from
+type Config struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+	SSLMode  string
+}
+
+// Driver describes a DATABASE_TYPE
+type Driver struct {
+	// SQLDriver is the name the driver registered with database/sql, e.g. "pgx"
+	SQLDriver string
+	// Dialect is the sql the database speaks; "postgres" gets $n placeholders from Rebind
+	Dialect string
+	// DSN builds the data source name from the settings
+	DSN func(cfg Config) string
+}
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]Driver{}
+)
+
+func init() {
+	postgres := Driver{SQLDriver: "pgx", Dialect: "postgres", DSN: postgresDSN}
+	Register("postgres", postgres)
+	Register("postgresql", postgres)
+	Register("pgx", postgres)
+
+	mysql := Driver{SQLDriver: "mysql", Dialect: "mysql", DSN: mysqlDSN}
+	Register("mysql", mysql)
+	Register("mariadb", mysql)
+}
+
+// Register makes a driver available as DATABASE_TYPE=name, e.g. one for SQL Server whose
+// database/sql driver is imported by the app. It panics when name is taken or d has no DSN.
+func Register(name string, d Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	if d.DSN == nil || d.SQLDriver == "" {
+		panic("database: Register driver " + name + " needs a SQLDriver and a DSN")
+	}
+	if _, dup := drivers[name]; dup {
+		panic("database: Register called twice for driver " + name)
+	}
+	drivers[name] = d
+}
+
+// Lookup returns the driver registered as name
+func Lookup(name string) (Driver, bool) {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	d, ok := drivers[strings.ToLower(name)]
+	return d, ok
+}
+
+// Drivers returns the names of the registered drivers, sorted
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Dialect returns the sql dialect of a database type, the type itself when it is not registered
+func Dialect(dbType string) string {
+	if d, ok := Lookup(dbType); ok && d.Dialect != "" {
+		return d.Dialect
+	}
+	return strings.ToLower(dbType)
+}
+
+func postgresDSN(cfg Config) string {
+	dsn := fmt.Sprintf("host=%s port=%s user=%s dbname=%s sslmode=%s timezone=UTC connect_timeout=5",
+		cfg.Host, cfg.Port, cfg.User, cfg.Name, cfg.SSLMode)
+
+	if cfg.Password != "" {
+		dsn = fmt.Sprintf("%s password=%s", dsn, cfg.Password)
+	}
+
+	return dsn
+}
+
+// mysqlDSN reads times as UTC time.Time values, matching the timezone of postgres
+// connections, and allows the multi statement files of migrations
+func mysqlDSN(cfg Config) string {
+	c := mysql.NewConfig()
+	c.User = cfg.User
+	c.Passwd = cfg.Password
+	c.Net = "tcp"
+	c.Addr = net.JoinHostPort(cfg.Host, cfg.Port)
+	c.DBName = cfg.Name
+	c.Params = map[string]string{"charset": "utf8mb4"}
+	c.ParseTime = true
+	c.Loc = time.UTC
+	c.Timeout = 5 * time.Second
+	c.MultiStatements = true
+
+	// the sslmode of postgres: require encrypts, verify-ca and verify-full also check the server
+	switch cfg.SSLMode {
+	case "require", "prefer":
+		c.TLSConfig = "skip-verify"
+	case "verify-ca", "verify-full":
+		c.TLSConfig = "true"
+	}
+
+	return c.FormatDSN()
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	Register("cockroachdb", Driver{
+		SQLDriver: "pgx",
+		Dialect:   "postgres",
+		DSN: func(cfg Config) string {
+			return "postgresql://" + cfg.User + "@" + cfg.Host + ":" + cfg.Port + "/" + cfg.Name + "?sslmode=" + cfg.SSLMode
+		},
+	})
+
+	d, ok := Lookup("CockroachDB")
+	if !ok || d.SQLDriver != "pgx" {
+		t.Fatal("expected the cockroachdb driver")
+	}
+	if dsn := d.DSN(Config{User: "root", Host: "localhost", Port: "26257", Name: "app", SSLMode: "disable"}); dsn != "postgresql://root@localhost:26257/app?sslmode=disable" {
+		t.Errorf("unexpected dsn %s", dsn)
+	}
+
+	// registered drivers of the postgres dialect get its placeholders
+	if q := Rebind("cockroachdb", "select * from users where id = ?"); !strings.HasSuffix(q, "$1") {
+		t.Errorf("expected $n placeholders, got %s", q)
+	}
+	if Dialect("sqlserver") != "sqlserver" {
+		t.Error("expected unknown types to be their own dialect")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected registering postgres again to panic")
+		}
+	}()
+	Register("postgres", d)
+}
+
+func TestPostgresDSN(t *testing.T) {
+	dsn := postgresDSN(Config{Host: "db", Port: "5432", User: "app", Name: "app", SSLMode: "disable", Password: "secret"})
+	if !strings.HasPrefix(dsn, "host=db port=5432 user=app dbname=app sslmode=disable") || !strings.HasSuffix(dsn, "password=secret") {
+		t.Errorf("unexpected dsn %s", dsn)
+	}
+}
+
+func TestMySQLDSN(t *testing.T) {
+	d, _ := Lookup("mariadb")
+	dsn := d.DSN(Config{Host: "db", Port: "3306", User: "app", Password: "p@ss", Name: "app", SSLMode: "require"})
+
+	for _, want := range []string{"app:p@ss@tcp(db:3306)/app?", "charset=utf8mb4", "parseTime=true", "multiStatements=true", "tls=skip-verify"} {
+		if !strings.Contains(dsn, want) {
+			t.Errorf("expected %s in %s", want, dsn)
+		}
+	}
+	if strings.Contains(dsn, "loc=") {
+		t.Errorf("expected the default UTC location, got %s", dsn)
+	}
+}
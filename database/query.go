@@ -0,0 +1,68 @@
+package database
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgconn"
+)
+
+// IsPostgres reports whether the database type speaks the postgres dialect, which includes
+// registered drivers such as cockroachdb
+func IsPostgres(dbType string) bool {
+	return Dialect(dbType) == "postgres"
+}
+
+// Rebind converts the ? placeholders of a query into the $n form expected by postgres; queries
+// for other databases are returned unchanged
+func Rebind(dbType, query string) string {
+	if !IsPostgres(dbType) {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	inQuote := false
+
+	for _, c := range query {
+		switch {
+		case c == '\'':
+			inQuote = !inQuote
+			b.WriteRune(c)
+		case c == '?' && !inQuote:
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+		default:
+			b.WriteRune(c)
+		}
+	}
+
+	return b.String()
+}
+
+// Like returns the case insensitive pattern matching operator for the database type
+func Like(dbType string) string {
+	if IsPostgres(dbType) {
+		return "ILIKE"
+	}
+
+	return "LIKE"
+}
+
+// IsDuplicate reports whether err is the violation of a primary key or unique index, e.g. when
+// another instance inserted the same row first
+func IsDuplicate(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505"
+	}
+
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		return myErr.Number == 1062
+	}
+
+	return false
+}
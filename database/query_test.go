@@ -0,0 +1,49 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgconn"
+)
+
+var rebindData = []struct {
+	name     string
+	dbType   string
+	query    string
+	expected string
+}{
+	{"postgres", "postgres", "select * from users where id = ? and email = ?", "select * from users where id = $1 and email = $2"},
+	{"postgresql", "postgresql", "insert into t (a) values (?)", "insert into t (a) values ($1)"},
+	{"quoted", "postgres", "select '?' from t where a = ?", "select '?' from t where a = $1"},
+	{"mysql", "mysql", "select * from users where id = ?", "select * from users where id = ?"},
+}
+
+func TestRebind(t *testing.T) {
+	for _, e := range rebindData {
+		if got := Rebind(e.dbType, e.query); got != e.expected {
+			t.Errorf("%s: expected %q, got %q", e.name, e.expected, got)
+		}
+	}
+}
+
+func TestLike(t *testing.T) {
+	if Like("postgres") != "ILIKE" {
+		t.Error("expected ILIKE for postgres")
+	}
+
+	if Like("mariadb") != "LIKE" {
+		t.Error("expected LIKE for mariadb")
+	}
+}
+
+func TestIsDuplicate(t *testing.T) {
+	if !IsDuplicate(fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23505"})) || !IsDuplicate(&mysql.MySQLError{Number: 1062}) {
+		t.Error("expected the unique violations of postgres and mysql")
+	}
+	if IsDuplicate(&pgconn.PgError{Code: "42P01"}) || IsDuplicate(&mysql.MySQLError{Number: 1146}) || IsDuplicate(errors.New("connection refused")) {
+		t.Error("expected other errors not to be duplicates")
+	}
+}
@@ -1,16 +1,24 @@
 package goravel
 
 import (
+	"context"
 	"database/sql"
+	"time"
 
+	"github.com/namnguyen191/goravel/database"
+
+	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/jackc/pgconn"
 	_ "github.com/jackc/pgx/v4"
 	_ "github.com/jackc/pgx/v4/stdlib"
 )
 
+// OpenDB opens and pings a database; dbType is a driver registered with database.Register,
+// or else the name of a database/sql driver. The pool is sized with the DATABASE_MAX_*
+// settings, and connections are recycled before a proxy or the server drops them.
 func (grv *Goravel) OpenDB(dbType, dsn string) (*sql.DB, error) {
-	if dbType == "postgres" || dbType == "postgresql" {
-		dbType = "pgx"
+	if d, ok := database.Lookup(dbType); ok {
+		dbType = d.SQLDriver
 	}
 
 	db, err := sql.Open(dbType, dsn)
@@ -19,6 +27,11 @@ func (grv *Goravel) OpenDB(dbType, dsn string) (*sql.DB, error) {
 		return nil, err
 	}
 
+	db.SetMaxOpenConns(grv.Env.Int("DATABASE_MAX_OPEN_CONNS", 25))
+	db.SetMaxIdleConns(grv.Env.Int("DATABASE_MAX_IDLE_CONNS", 25))
+	db.SetConnMaxLifetime(grv.Env.Duration("DATABASE_CONN_MAX_LIFETIME", 5*time.Minute))
+	db.SetConnMaxIdleTime(grv.Env.Duration("DATABASE_CONN_MAX_IDLE_TIME", 0))
+
 	err = db.Ping()
 
 	if err != nil {
@@ -27,3 +40,34 @@ func (grv *Goravel) OpenDB(dbType, dsn string) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// Query runs a query written with ? placeholders, stopping when ctx is done
+func (db Database) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.Pool.QueryContext(ctx, database.Rebind(db.DataBaseType, query), args...)
+}
+
+// QueryRow runs a query written with ? placeholders expected to return at most one row
+func (db Database) QueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return db.Pool.QueryRowContext(ctx, database.Rebind(db.DataBaseType, query), args...)
+}
+
+// Exec runs a statement written with ? placeholders, stopping when ctx is done
+func (db Database) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.Pool.ExecContext(ctx, database.Rebind(db.DataBaseType, query), args...)
+}
+
+// Tx runs fn in a transaction, committed when fn returns nil and rolled back otherwise,
+// including when ctx is done before the commit
+func (db Database) Tx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := db.Pool.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,277 @@
+package env
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types of variables
+const (
+	String = "string"
+	Int    = "int"
+	Float  = "float"
+	Bool   = "bool"
+	URL    = "url"
+	// List is a comma separated list
+	List = "list"
+	// Enum is one of Values
+	Enum = "enum"
+	// Duration is a duration such as "90s" or "168h", or a whole number of seconds
+	Duration = "duration"
+)
+
+// Var describes an environment variable read by the framework
+type Var struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Default     string   `json:"default,omitempty"`
+	Description string   `json:"description"`
+	Group       string   `json:"group"`
+	Values      []string `json:"values,omitempty"`
+	// Aliases are older names still read when Name is not set
+	Aliases []string `json:"aliases,omitempty"`
+	// RequiredWhen says when the variable must be set, e.g. "DATABASE_TYPE is set", or "always"
+	RequiredWhen string `json:"required_when,omitempty"`
+	// Required decides it for a set of values; nil means optional
+	Required func(get func(string) string) bool `json:"-"`
+	// Secret values are never printed
+	Secret bool `json:"secret,omitempty"`
+}
+
+// Lookup returns the variable registered as name or as one of its aliases
+func Lookup(name string) (Var, bool) {
+	for _, v := range Registry {
+		if v.Name == name {
+			return v, true
+		}
+		for _, alias := range v.Aliases {
+			if alias == name {
+				return v, true
+			}
+		}
+	}
+	return Var{}, false
+}
+
+// Get reads a registered variable from the environment, falling back to its aliases
+func Get(name string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+
+	v, _ := Lookup(name)
+	for _, alias := range v.Aliases {
+		if value := os.Getenv(alias); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// Problem is a value that does not match the registry
+type Problem struct {
+	Name    string
+	Message string
+	// Warning problems do not stop the app from working, e.g. unknown variables
+	Warning bool
+}
+
+func (p Problem) String() string {
+	return p.Name + ": " + p.Message
+}
+
+// Check validates values, e.g. read from a .env file, against vars. Variables which are
+// not registered are reported as warnings, as apps read their own.
+func Check(values map[string]string, vars []Var) []Problem {
+	get := func(name string) string {
+		if value := values[name]; value != "" {
+			return value
+		}
+		for _, v := range vars {
+			if v.Name == name {
+				for _, alias := range v.Aliases {
+					if value := values[alias]; value != "" {
+						return value
+					}
+				}
+			}
+		}
+		return ""
+	}
+
+	var problems []Problem
+	known := map[string]bool{}
+
+	for _, v := range vars {
+		known[v.Name] = true
+		for _, alias := range v.Aliases {
+			known[alias] = true
+			if values[alias] != "" && values[v.Name] == "" {
+				problems = append(problems, Problem{Name: alias, Message: "is deprecated, use " + v.Name, Warning: true})
+			}
+		}
+
+		value := get(v.Name)
+		if value == "" {
+			if v.Required != nil && v.Required(get) {
+				problems = append(problems, Problem{Name: v.Name, Message: required(v)})
+			}
+			continue
+		}
+
+		if msg := checkType(v, value); msg != "" {
+			problems = append(problems, Problem{Name: v.Name, Message: msg})
+		}
+	}
+
+	var unknown []string
+	for name := range values {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		problems = append(problems, Problem{Name: name, Message: "is not read by the framework" + suggest(name, vars), Warning: true})
+	}
+
+	return problems
+}
+
+func required(v Var) string {
+	if v.RequiredWhen == "" || v.RequiredWhen == "always" {
+		return "is required"
+	}
+	return "is required when " + v.RequiredWhen
+}
+
+func checkType(v Var, value string) string {
+	switch v.Type {
+	case Int:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Sprintf("must be a whole number, got %q", value)
+		}
+	case Duration:
+		if _, err := strconv.Atoi(value); err == nil {
+			return ""
+		}
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Sprintf("must be a duration such as 30s, got %q", value)
+		}
+	case Float:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Sprintf("must be a number, got %q", value)
+		}
+	case Bool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Sprintf("must be true or false, got %q", value)
+		}
+	case URL:
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Sprintf("must be an absolute url, got %q", value)
+		}
+	case Enum:
+		for _, allowed := range v.Values {
+			if strings.EqualFold(value, allowed) {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of %s, got %q", strings.Join(v.Values, ", "), value)
+	}
+	return ""
+}
+
+// suggest names a registered variable a couple of edits away from name, for typos
+func suggest(name string, vars []Var) string {
+	for _, v := range vars {
+		if distance(name, v.Name) <= 2 {
+			return ", did you mean " + v.Name + "?"
+		}
+	}
+	return ""
+}
+
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+
+	return prev[len(b)]
+}
+
+func min(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+// Markdown writes a reference of vars, grouped in the order the groups first appear
+func Markdown(w io.Writer, vars []Var) error {
+	var groups []string
+	byGroup := map[string][]Var{}
+	for _, v := range vars {
+		if _, ok := byGroup[v.Group]; !ok {
+			groups = append(groups, v.Group)
+		}
+		byGroup[v.Group] = append(byGroup[v.Group], v)
+	}
+
+	if _, err := fmt.Fprint(w, "# Environment variables\n"); err != nil {
+		return err
+	}
+
+	for _, g := range groups {
+		fmt.Fprintf(w, "\n## %s\n\n| Name | Type | Default | Description |\n| --- | --- | --- | --- |\n", g)
+		for _, v := range byGroup[g] {
+			typ := v.Type
+			if v.Type == Enum {
+				typ = strings.Join(v.Values, " \\| ")
+			}
+
+			desc := v.Description
+			if v.RequiredWhen == "always" {
+				desc += " Required."
+			} else if v.RequiredWhen != "" {
+				desc += " Required when " + v.RequiredWhen + "."
+			}
+			if len(v.Aliases) > 0 {
+				desc += " Also read as " + strings.Join(v.Aliases, ", ") + "."
+			}
+
+			def := ""
+			if v.Default != "" {
+				def = "`" + v.Default + "`"
+			}
+
+			if _, err := fmt.Fprintf(w, "| `%s` | %s | %s | %s |\n", v.Name, typ, def, strings.TrimSpace(desc)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package env
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testVars = []Var{
+	{Name: "PORT", Type: Int, Group: "Server", RequiredWhen: "always", Required: always},
+	{Name: "SERVER_NAME", Type: String, Group: "Server", Aliases: []string{"SEVER_NAME"}},
+	{Name: "APP_URL", Type: URL, Group: "App"},
+	{Name: "CACHE", Type: Enum, Group: "Cache", Values: []string{"redis", "badger"}},
+	{Name: "REDIS_HOST", Type: String, Group: "Cache", RequiredWhen: "CACHE is redis", Required: is("CACHE", "redis")},
+	{Name: "CACHE_TTL", Type: Duration, Group: "Cache"},
+}
+
+func problems(values map[string]string) map[string]Problem {
+	found := map[string]Problem{}
+	for _, p := range Check(values, testVars) {
+		found[p.Name] = p
+	}
+	return found
+}
+
+func TestCheck(t *testing.T) {
+	found := problems(map[string]string{
+		"PORT":    "eighty",
+		"APP_URL": "example.com",
+		"CACHE":   "redis",
+		"CACHEE":  "x",
+	})
+
+	if p := found["PORT"]; p.Warning || !strings.Contains(p.Message, "whole number") {
+		t.Errorf("expected an invalid port, got %+v", p)
+	}
+	if _, ok := found["APP_URL"]; !ok {
+		t.Error("expected a relative url to be rejected")
+	}
+	if p := found["REDIS_HOST"]; p.Message != "is required when CACHE is redis" {
+		t.Errorf("expected REDIS_HOST to be required, got %+v", p)
+	}
+	if p := found["CACHEE"]; !p.Warning || !strings.Contains(p.Message, "did you mean CACHE?") {
+		t.Errorf("expected a suggestion for the typo, got %+v", p)
+	}
+
+	found = problems(map[string]string{"PORT": "80", "CACHE_TTL": "a week"})
+	if p := found["CACHE_TTL"]; !strings.Contains(p.Message, "duration") {
+		t.Errorf("expected an invalid duration, got %+v", p)
+	}
+	for _, ttl := range []string{"168h", "3600"} {
+		if p, ok := problems(map[string]string{"PORT": "80", "CACHE_TTL": ttl})["CACHE_TTL"]; ok {
+			t.Errorf("expected %s to be a duration, got %+v", ttl, p)
+		}
+	}
+
+	found = problems(map[string]string{"PORT": "80", "CACHE": "memcached"})
+	if p := found["CACHE"]; !strings.Contains(p.Message, "redis, badger") {
+		t.Errorf("expected the allowed values, got %+v", p)
+	}
+	if _, ok := found["REDIS_HOST"]; ok {
+		t.Error("expected REDIS_HOST to be optional without redis")
+	}
+
+	found = problems(map[string]string{})
+	if p := found["PORT"]; p.Message != "is required" {
+		t.Errorf("expected PORT to be required, got %+v", p)
+	}
+}
+
+func TestCheckAliases(t *testing.T) {
+	found := problems(map[string]string{"PORT": "80", "SEVER_NAME": "example.com"})
+	if len(found) != 1 {
+		t.Errorf("expected only the deprecation, got %v", found)
+	}
+	if p := found["SEVER_NAME"]; !p.Warning || p.Message != "is deprecated, use SERVER_NAME" {
+		t.Errorf("expected the alias to be deprecated, got %+v", p)
+	}
+}
+
+func TestGet(t *testing.T) {
+	t.Setenv("SEVER_NAME", "old.example.com")
+	if got := Get("SERVER_NAME"); got != "old.example.com" {
+		t.Errorf("expected the alias to be read, got %q", got)
+	}
+
+	t.Setenv("SERVER_NAME", "example.com")
+	if got := Get("SERVER_NAME"); got != "example.com" {
+		t.Errorf("expected the name to win over the alias, got %q", got)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Markdown(&buf, testVars); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"## Server\n",
+		"| `PORT` | int |  | Required. |",
+		"Also read as SEVER_NAME.",
+		"| `CACHE` | redis \\| badger |",
+		"Required when CACHE is redis.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "## Server") > strings.Index(out, "## App") {
+		t.Error("expected groups in the order they first appear")
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	seen := map[string]bool{}
+	for _, v := range Registry {
+		if seen[v.Name] {
+			t.Errorf("%s is registered twice", v.Name)
+		}
+		seen[v.Name] = true
+
+		if v.Type == Enum && len(v.Values) == 0 {
+			t.Errorf("%s has no values", v.Name)
+		}
+		if (v.Required == nil) != (v.RequiredWhen == "") {
+			t.Errorf("%s needs both Required and RequiredWhen", v.Name)
+		}
+	}
+}
+
+func TestReader(t *testing.T) {
+	t.Setenv("SMTP_PORT", "twenty-five")
+	t.Setenv("DRAIN_TIMEOUT", "45")
+	t.Setenv("PAGE_CACHE_STALE", "2m")
+	t.Setenv("MAIL_VERIFY", "true")
+	t.Setenv("SEVER_NAME", "example.com")
+
+	var logged []string
+	e := NewReader()
+	e.ErrorLog = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	if port := e.Int("SMTP_PORT", 587); port != 587 {
+		t.Errorf("expected the default for an invalid number, got %d", port)
+	}
+	if len(logged) != 1 || !strings.Contains(logged[0], "SMTP_PORT") {
+		t.Errorf("expected the invalid value to be logged, got %v", logged)
+	}
+	if d := e.Duration("DRAIN_TIMEOUT", time.Second); d != 45*time.Second {
+		t.Errorf("expected plain numbers to be seconds, got %s", d)
+	}
+	if d := e.Duration("PAGE_CACHE_STALE", time.Second); d != 2*time.Minute {
+		t.Errorf("expected a duration, got %s", d)
+	}
+	if !e.Bool("MAIL_VERIFY", false) || e.Bool("DEBUG", false) {
+		t.Error("unexpected booleans")
+	}
+	if name := e.String("SERVER_NAME", "localhost"); name != "example.com" {
+		t.Errorf("expected the alias to be read, got %q", name)
+	}
+
+	want := []string{"DEBUG", "DRAIN_TIMEOUT", "MAIL_VERIFY", "PAGE_CACHE_STALE", "SERVER_NAME", "SMTP_PORT"}
+	if read := e.Read(); !reflect.DeepEqual(read, want) {
+		t.Errorf("expected %v to be recorded, got %v", want, read)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected MustString to panic for a missing variable")
+		}
+	}()
+	e.MustString("KEY")
+}
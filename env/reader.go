@@ -0,0 +1,145 @@
+package env
+
+import (
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reader reads variables with a default, remembering which were read so startup can report
+// the configuration an app actually depends on. A value which does not parse is logged and
+// the default used, instead of silently becoming zero. A nil Reader reads without recording.
+type Reader struct {
+	ErrorLog func(v ...interface{})
+
+	mu   sync.Mutex
+	read map[string]bool
+}
+
+// NewReader returns a reader logging invalid values to the standard logger
+func NewReader() *Reader {
+	return &Reader{ErrorLog: log.Println, read: map[string]bool{}}
+}
+
+func (e *Reader) lookup(key string) string {
+	if e != nil {
+		e.mu.Lock()
+		if e.read == nil {
+			e.read = map[string]bool{}
+		}
+		e.read[key] = true
+		e.mu.Unlock()
+	}
+	return strings.TrimSpace(Get(key))
+}
+
+func (e *Reader) invalid(key, value, want string) {
+	logf := log.Println
+	if e != nil && e.ErrorLog != nil {
+		logf = e.ErrorLog
+	}
+	logf("env:", key, "must be", want+", got", strconv.Quote(value)+"; using the default")
+}
+
+// String returns the variable key, or def when it is not set
+func (e *Reader) String(key, def string) string {
+	if value := e.lookup(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// MustString returns the variable key and panics when it is not set, for configuration the
+// app cannot start without
+func (e *Reader) MustString(key string) string {
+	value := e.lookup(key)
+	if value == "" {
+		panic("env: " + key + " is required")
+	}
+	return value
+}
+
+// Int returns the variable key as a whole number, or def when it is not set or invalid
+func (e *Reader) Int(key string, def int) int {
+	value := e.lookup(key)
+	if value == "" {
+		return def
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		e.invalid(key, value, "a whole number")
+		return def
+	}
+	return i
+}
+
+// Float returns the variable key as a number, or def when it is not set or invalid
+func (e *Reader) Float(key string, def float64) float64 {
+	value := e.lookup(key)
+	if value == "" {
+		return def
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		e.invalid(key, value, "a number")
+		return def
+	}
+	return f
+}
+
+// Bool returns the variable key as true or false, or def when it is not set or invalid
+func (e *Reader) Bool(key string, def bool) bool {
+	value := e.lookup(key)
+	if value == "" {
+		return def
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		e.invalid(key, value, "true or false")
+		return def
+	}
+	return b
+}
+
+// Duration returns the variable key as a duration such as "90s" or "2h"; a plain number is
+// seconds, which is how the existing *_TIMEOUT and *_STALE variables are written
+func (e *Reader) Duration(key string, def time.Duration) time.Duration {
+	value := e.lookup(key)
+	if value == "" {
+		return def
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		e.invalid(key, value, "a duration such as 30s")
+		return def
+	}
+	return d
+}
+
+// Read returns the variables read so far in alphabetical order
+func (e *Reader) Read() []string {
+	if e == nil {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	keys := make([]string, 0, len(e.read))
+	for key := range e.read {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
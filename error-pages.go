@@ -0,0 +1,136 @@
+package goravel
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"github.com/CloudyKit/jet/v6"
+	"github.com/namnguyen191/goravel/render"
+	"github.com/namnguyen191/goravel/trace"
+)
+
+// ErrorPage is what error views are rendered with: the Data of the TemplateData for go
+// templates, and also the variables of jet templates
+type ErrorPage struct {
+	Status    int
+	Title     string
+	Message   string
+	RequestID string
+}
+
+// fallbackErrorPage is sent when the app has no view for the status, or the view fails
+var fallbackErrorPage = template.Must(template.New("error").Parse(`<!doctype html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Status}} {{.Title}}</title>
+<style>
+body{margin:0;min-height:100vh;display:flex;align-items:center;justify-content:center;font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Roboto,sans-serif;background:#f8f9fa;color:#343a40}
+main{text-align:center;padding:2rem}
+h1{font-size:5rem;margin:0;color:#adb5bd}
+h2{font-weight:400;margin:.5rem 0 1rem}
+p{color:#6c757d}
+small{color:#adb5bd}
+</style>
+</head>
+<body>
+<main>
+<h1>{{.Status}}</h1>
+<h2>{{.Title}}</h2>
+{{if .Message}}<p>{{.Message}}</p>{{end}}
+<p><a href="/">Back to the home page</a></p>
+{{if .RequestID}}<small>Request {{.RequestID}}</small>{{end}}
+</main>
+</body>
+</html>
+`))
+
+// errorPage sends status to the client: a JSON payload for API requests, otherwise the
+// views/errors/<status> view, views/errors/default, or the built in page. message is shown
+// to the client, so it must not carry details of server errors.
+func (grv *Goravel) errorPage(rw http.ResponseWriter, r *http.Request, status int, message string, errs map[string]string) {
+	if wantsJSON(r) {
+		var payload struct {
+			Error   bool              `json:"error"`
+			Message string            `json:"message"`
+			Errors  map[string]string `json:"errors,omitempty"`
+		}
+		payload.Error = true
+		payload.Message = message
+		if payload.Message == "" {
+			payload.Message = http.StatusText(status)
+		}
+		payload.Errors = errs
+
+		_ = grv.WriteJSON(rw, status, payload)
+		return
+	}
+
+	page := ErrorPage{
+		Status:    status,
+		Title:     http.StatusText(status),
+		Message:   message,
+		RequestID: trace.ID(r.Context()),
+	}
+	if page.Message == page.Title {
+		page.Message = ""
+	}
+
+	// render into a buffer so a broken view still ends in a complete page
+	var buf bytes.Buffer
+	if grv.renderErrorView(&buf, r, page) {
+		rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+		rw.WriteHeader(status)
+		_, _ = buf.WriteTo(rw)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	rw.Header().Set("X-Content-Type-Options", "nosniff")
+	rw.WriteHeader(status)
+	if err := fallbackErrorPage.Execute(rw, page); err != nil {
+		grv.ErrorLog.Println(err)
+	}
+}
+
+func (grv *Goravel) renderErrorView(buf *bytes.Buffer, r *http.Request, page ErrorPage) bool {
+	if grv.Render == nil {
+		return false
+	}
+
+	for _, view := range []string{fmt.Sprintf("errors/%d", page.Status), "errors/default"} {
+		if !grv.Render.Exists(view) {
+			continue
+		}
+
+		vars := make(jet.VarMap)
+		vars.Set("status", page.Status)
+		vars.Set("title", page.Title)
+		vars.Set("message", page.Message)
+		vars.Set("requestID", page.RequestID)
+		td := &render.TemplateData{Data: map[string]interface{}{"error": page}}
+
+		w := &bufferedResponse{header: http.Header{}, buf: buf}
+		if err := grv.Render.Page(w, r, view, vars, td); err != nil {
+			grv.ErrorLog.Println("error page:", view, err)
+			buf.Reset()
+			return false
+		}
+		return true
+	}
+
+	return false
+}
+
+// bufferedResponse collects what a view writes
+type bufferedResponse struct {
+	header http.Header
+	buf    *bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header         { return b.header }
+func (b *bufferedResponse) Write(p []byte) (int, error) { return b.buf.Write(p) }
+func (b *bufferedResponse) WriteHeader(int)             {}
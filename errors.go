@@ -0,0 +1,130 @@
+package goravel
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/namnguyen191/goravel/trace"
+)
+
+// StatusCoder is implemented by errors, and responses of typed handlers, which carry their own status
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// StatusError is an error sent to the client with its status
+type StatusError struct {
+	Status  int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return e.Message
+}
+
+func (e *StatusError) StatusCode() int {
+	return e.Status
+}
+
+// NewStatusError returns an error sent with status and message; an empty message uses the status text
+func NewStatusError(status int, message string) *StatusError {
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return &StatusError{Status: status, Message: message}
+}
+
+// Errors handlers, jobs and middleware return for HandleError to send with their status.
+// Wrap them to add detail, e.g. fmt.Errorf("invoice %d: %w", id, goravel.ErrNotFound).
+var (
+	ErrBadRequest   = NewStatusError(http.StatusBadRequest, "")
+	ErrUnauthorized = NewStatusError(http.StatusUnauthorized, "")
+	ErrForbidden    = NewStatusError(http.StatusForbidden, "")
+	ErrUnavailable  = NewStatusError(http.StatusServiceUnavailable, "")
+	ErrNotFound     = NewStatusError(http.StatusNotFound, "")
+)
+
+// ValidationError is sent as a 422 with the error of every field
+type ValidationError struct {
+	Errors map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	return "The given data was invalid"
+}
+
+func (e *ValidationError) StatusCode() int {
+	return http.StatusUnprocessableEntity
+}
+
+// ConflictError is sent as a 409, e.g. when a record was changed by someone else or already exists
+type ConflictError struct {
+	Message string
+}
+
+func (e *ConflictError) Error() string {
+	if e.Message == "" {
+		return http.StatusText(http.StatusConflict)
+	}
+	return e.Message
+}
+
+func (e *ConflictError) StatusCode() int {
+	return http.StatusConflict
+}
+
+// ErrorStatusOf is the status an error is sent with: its own for a StatusCoder, 404 for
+// sql.ErrNoRows, 504 for deadlines and 500 for anything else
+func ErrorStatusOf(err error) int {
+	var sc StatusCoder
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case errors.As(err, &sc):
+		return sc.StatusCode()
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// HandleError sends err with its status; API requests get a JSON payload and pages the
+// views/errors/<status> view, like routing errors. Messages of server errors are logged
+// rather than sent, and nothing is sent when the client went away.
+func (grv *Goravel) HandleError(rw http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, context.Canceled) && r.Context().Err() != nil {
+		return
+	}
+
+	status := ErrorStatusOf(err)
+	if status >= http.StatusInternalServerError {
+		grv.ErrorLog.Println(r.Method, r.URL.Path, trace.ID(r.Context()), err)
+	}
+
+	var message string
+	var sc StatusCoder
+	if status < http.StatusInternalServerError && errors.As(err, &sc) {
+		message = err.Error()
+	}
+
+	var errs map[string]string
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		errs = ve.Errors
+	}
+
+	grv.errorPage(rw, r, status, message, errs)
+}
+
+// ErrorHandler adapts a handler returning an error, sending the error with HandleError
+func (grv *Goravel) ErrorHandler(h func(rw http.ResponseWriter, r *http.Request) error) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if err := h(rw, r); err != nil {
+			grv.HandleError(rw, r, err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package goravel
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		message string
+		logged  bool
+	}{
+		{"bad request", ErrBadRequest, http.StatusBadRequest, "Bad Request", false},
+		{"unauthorized", ErrUnauthorized, http.StatusUnauthorized, "Unauthorized", false},
+		{"forbidden", ErrForbidden, http.StatusForbidden, "Forbidden", false},
+		{"not found", ErrNotFound, http.StatusNotFound, "Not Found", false},
+		{"unavailable", ErrUnavailable, http.StatusServiceUnavailable, "Service Unavailable", true},
+		{"status error", NewStatusError(http.StatusTeapot, "short and stout"), http.StatusTeapot, "short and stout", false},
+		{"validation", &ValidationError{Errors: map[string]string{"email": "This field cannot be blank"}}, http.StatusUnprocessableEntity, "The given data was invalid", false},
+		{"conflict", &ConflictError{}, http.StatusConflict, "Conflict", false},
+		{"conflict with a message", &ConflictError{Message: "the invoice was changed"}, http.StatusConflict, "the invoice was changed", false},
+		{"no rows", sql.ErrNoRows, http.StatusNotFound, "Not Found", false},
+		{"deadline", context.DeadlineExceeded, http.StatusGatewayTimeout, "Gateway Timeout", true},
+		{"other", errors.New(`pq: relation "invoices" does not exist`), http.StatusInternalServerError, "Internal Server Error", true},
+		{"wrapped status error", fmt.Errorf("invoice 3: %w", ErrNotFound), http.StatusNotFound, "invoice 3: Not Found", false},
+		{"wrapped validation", fmt.Errorf("signup: %w", &ValidationError{Errors: map[string]string{"email": "This field cannot be blank"}}), http.StatusUnprocessableEntity, "signup: The given data was invalid", false},
+		{"wrapped no rows", fmt.Errorf("invoice 3: %w", sql.ErrNoRows), http.StatusNotFound, "Not Found", false},
+		{"wrapped deadline", fmt.Errorf("payments: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, "Gateway Timeout", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorStatusOf(tt.err); got != tt.status {
+				t.Errorf("expected the status %d, got %d", tt.status, got)
+			}
+
+			var logged bytes.Buffer
+			rw := httptest.NewRecorder()
+			testApp(&logged).HandleError(rw, httptest.NewRequest("GET", "/api/invoices/3", nil), tt.err)
+
+			if rw.Code != tt.status {
+				t.Errorf("expected %d, got %d", tt.status, rw.Code)
+			}
+			body := decodeError(t, rw)
+			if !body.Error || body.Message != tt.message {
+				t.Errorf("expected the message %q, got %+v", tt.message, body)
+			}
+
+			var ve *ValidationError
+			if errors.As(tt.err, &ve) && body.Errors["email"] != "This field cannot be blank" {
+				t.Errorf("expected the errors of the fields, got %v", body.Errors)
+			}
+			if (logged.Len() > 0) != tt.logged {
+				t.Errorf("expected logged to be %v, got %q", tt.logged, logged.String())
+			}
+			if tt.logged && !strings.Contains(logged.String(), tt.err.Error()) {
+				t.Errorf("expected the error in the log, got %q", logged.String())
+			}
+		})
+	}
+}
+
+func TestHandleError_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rw := httptest.NewRecorder()
+	testApp(&bytes.Buffer{}).HandleError(rw, httptest.NewRequest("GET", "/api/invoices", nil).WithContext(ctx), fmt.Errorf("query: %w", context.Canceled))
+	if rw.Body.Len() != 0 {
+		t.Errorf("expected nothing sent to a client which went away, got %s", rw.Body)
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	h := testApp(&bytes.Buffer{}).ErrorHandler(func(rw http.ResponseWriter, r *http.Request) error {
+		return fmt.Errorf("invoice %s: %w", r.URL.Query().Get("id"), ErrForbidden)
+	})
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "/api/invoices?id=3", nil))
+	if body := decodeError(t, rw); rw.Code != http.StatusForbidden || body.Message != "invoice 3: Forbidden" {
+		t.Errorf("expected the wrapped error sent as a 403, got %d %+v", rw.Code, body)
+	}
+}
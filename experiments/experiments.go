@@ -0,0 +1,279 @@
+package experiments
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/namnguyen191/goravel/analytics"
+)
+
+const (
+	assignmentsKey = "_experiments"
+	subjectKey     = "_experiment_subject"
+)
+
+func init() {
+	gob.Register(map[string]string{})
+}
+
+// Variant is one arm of an experiment; Weight is relative to the other variants
+type Variant struct {
+	Name   string
+	Weight int
+}
+
+// Experiment splits visitors between variants
+type Experiment struct {
+	Name     string
+	Variants []Variant
+	// Active experiments assign variants; inactive ones always return the first variant
+	Active bool
+}
+
+// Result is the number of visitors exposed to and converted by a variant
+type Result struct {
+	Variant     string
+	Exposures   int
+	Conversions int
+}
+
+// Rate returns the conversion rate of the variant
+func (r Result) Rate() float64 {
+	if r.Exposures == 0 {
+		return 0
+	}
+	return float64(r.Conversions) / float64(r.Exposures)
+}
+
+// Experiments assigns visitors to variants and records exposures and conversions
+type Experiments struct {
+	Session   *scs.SessionManager
+	Analytics *analytics.Analytics
+	// Subject returns a stable id for the visitor, such as the user id; by default a random id
+	// kept in the session is used
+	Subject func(r *http.Request) string
+
+	mu          sync.RWMutex
+	experiments map[string]*Experiment
+}
+
+// New returns an empty registry; analytics may be nil, in which case nothing is recorded
+func New(session *scs.SessionManager, a *analytics.Analytics) *Experiments {
+	return &Experiments{
+		Session:     session,
+		Analytics:   a,
+		experiments: make(map[string]*Experiment),
+	}
+}
+
+// Define registers an active experiment
+func (e *Experiments) Define(name string, variants ...Variant) *Experiment {
+	exp := &Experiment{Name: name, Variants: variants, Active: true}
+
+	e.mu.Lock()
+	e.experiments[name] = exp
+	e.mu.Unlock()
+
+	return exp
+}
+
+// Get returns a registered experiment
+func (e *Experiments) Get(name string) (*Experiment, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	exp, ok := e.experiments[name]
+
+	return exp, ok
+}
+
+// Variant returns the variant of the experiment for the visitor, assigning one on first use.
+// Unknown experiments return an empty string.
+func (e *Experiments) Variant(r *http.Request, name string) string {
+	exp, ok := e.Get(name)
+	if !ok || len(exp.Variants) == 0 {
+		return ""
+	}
+
+	if !exp.Active {
+		return exp.Variants[0].Name
+	}
+
+	ctx := r.Context()
+	assignments := e.assignments(ctx)
+	if v, ok := assignments[name]; ok && exp.has(v) {
+		return v
+	}
+
+	v := exp.pick(e.subject(r))
+
+	if e.Session != nil {
+		assignments[name] = v
+		e.Session.Put(ctx, assignmentsKey, assignments)
+	}
+
+	if e.Analytics != nil {
+		e.Analytics.Track(r, eventName("exposure", name, v), nil)
+	}
+
+	return v
+}
+
+// Is reports whether the visitor is in the given variant
+func (e *Experiments) Is(r *http.Request, name, variant string) bool {
+	return e.Variant(r, name) == variant
+}
+
+// Convert records that the visitor reached a goal of the experiment
+func (e *Experiments) Convert(r *http.Request, name string) {
+	if e.Analytics == nil {
+		return
+	}
+
+	if v, ok := e.assignments(r.Context())[name]; ok {
+		e.Analytics.Track(r, eventName("conversion", name, v), nil)
+	}
+}
+
+// Results counts distinct visitors exposed to and converted by each variant since the given time
+func (e *Experiments) Results(ctx context.Context, name string, since time.Time) ([]Result, error) {
+	if e.Analytics == nil {
+		return nil, fmt.Errorf("experiments: results need analytics")
+	}
+
+	rows, err := e.Analytics.EventsLike(ctx, "experiment:%:"+name+":%", since)
+	if err != nil {
+		return nil, err
+	}
+
+	results := map[string]*Result{}
+	for _, row := range rows {
+		// experiment:<kind>:<name>:<variant>
+		parts := strings.SplitN(row.Label, ":", 4)
+		if len(parts) != 4 || parts[2] != name {
+			continue
+		}
+
+		r, ok := results[parts[3]]
+		if !ok {
+			r = &Result{Variant: parts[3]}
+			results[parts[3]] = r
+		}
+
+		if parts[1] == "exposure" {
+			r.Exposures = row.Visitors
+		} else {
+			r.Conversions = row.Visitors
+		}
+	}
+
+	list := make([]Result, 0, len(results))
+	for _, r := range results {
+		list = append(list, *r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Variant < list[j].Variant })
+
+	return list, nil
+}
+
+// TemplateFuncs provides variant and inVariant to views
+func (e *Experiments) TemplateFuncs(r *http.Request) template.FuncMap {
+	return template.FuncMap{
+		"variant": func(name string) string {
+			return e.Variant(r, name)
+		},
+		"inVariant": func(name, variant string) bool {
+			return e.Is(r, name, variant)
+		},
+	}
+}
+
+func (e *Experiments) assignments(ctx context.Context) map[string]string {
+	if e.Session != nil {
+		if m, ok := e.Session.Get(ctx, assignmentsKey).(map[string]string); ok {
+			return m
+		}
+	}
+
+	return map[string]string{}
+}
+
+func (e *Experiments) subject(r *http.Request) string {
+	if e.Subject != nil {
+		if s := e.Subject(r); s != "" {
+			return s
+		}
+	}
+
+	if e.Session == nil {
+		return randomID()
+	}
+
+	ctx := r.Context()
+	if s := e.Session.GetString(ctx, subjectKey); s != "" {
+		return s
+	}
+
+	s := randomID()
+	e.Session.Put(ctx, subjectKey, s)
+
+	return s
+}
+
+func (exp *Experiment) has(variant string) bool {
+	for _, v := range exp.Variants {
+		if v.Name == variant {
+			return true
+		}
+	}
+	return false
+}
+
+// pick hashes the subject into the weighted variants, so the same subject always lands in the same one
+func (exp *Experiment) pick(subject string) string {
+	total := 0
+	for _, v := range exp.Variants {
+		total += weight(v)
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(exp.Name + ":" + subject))
+	n := int(h.Sum32() % uint32(total))
+
+	for _, v := range exp.Variants {
+		n -= weight(v)
+		if n < 0 {
+			return v.Name
+		}
+	}
+
+	return exp.Variants[len(exp.Variants)-1].Name
+}
+
+func weight(v Variant) int {
+	if v.Weight < 1 {
+		return 1
+	}
+	return v.Weight
+}
+
+func eventName(kind, name, variant string) string {
+	return "experiment:" + kind + ":" + name + ":" + variant
+}
+
+func randomID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
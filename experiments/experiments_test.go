@@ -0,0 +1,56 @@
+package experiments
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+)
+
+func TestPickIsDeterministicAndWeighted(t *testing.T) {
+	exp := &Experiment{Name: "checkout", Variants: []Variant{{"a", 9}, {"b", 1}}, Active: true}
+
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		subject := fmt.Sprintf("user-%d", i)
+		v := exp.pick(subject)
+		if exp.pick(subject) != v {
+			t.Fatal("expected the same subject to get the same variant")
+		}
+		counts[v]++
+	}
+
+	if counts["b"] < 700 || counts["b"] > 1300 {
+		t.Errorf("expected roughly 10%% in b, got %d", counts["b"])
+	}
+}
+
+func TestVariantIsStoredInSession(t *testing.T) {
+	session := scs.New()
+	e := New(session, nil)
+	e.Define("hero", Variant{Name: "old"}, Variant{Name: "new"})
+
+	var first string
+	h := session.LoadAndSave(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		first = e.Variant(r, "hero")
+
+		// changing the weights later doesn't move visitors already assigned
+		exp, _ := e.Get("hero")
+		exp.Variants = []Variant{{Name: "new"}, {Name: "old"}}
+		if e.Variant(r, "hero") != first {
+			t.Error("expected assignment to stick for the session")
+		}
+
+		if e.Variant(r, "unknown") != "" {
+			t.Error("expected unknown experiments to have no variant")
+		}
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if first != "old" && first != "new" {
+		t.Error("unexpected variant", first)
+	}
+}
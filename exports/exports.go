@@ -0,0 +1,384 @@
+package exports
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+	"github.com/namnguyen191/goravel/urlsigner"
+)
+
+// Job is the type of the queued job generating an export, its payload the id of the export
+const Job = "export"
+
+// Statuses of an export
+const (
+	Pending   = "pending"
+	Running   = "running"
+	Completed = "completed"
+	Failed    = "failed"
+)
+
+// Request describes the export a user asked for
+type Request struct {
+	UserID int
+	Name   string
+	Format string
+	// Query and Args select the exported rows; column names become the header
+	Query string        `json:"query,omitempty"`
+	Args  []interface{} `json:"args,omitempty"`
+	// Generator names the Generator writing the file instead of Query
+	Generator string `json:"generator,omitempty"`
+}
+
+// Generator writes the file of an export, returning the number of rows written, e.g. for a zip
+// bundling several files
+type Generator func(ctx context.Context, e Export, w io.Writer) (int, error)
+
+// Export is the stored state of a requested export
+type Export struct {
+	ID          int
+	UserID      int
+	Name        string
+	Format      string
+	Status      string
+	Progress    int
+	Rows        int
+	File        string
+	Error       string
+	CreatedAt   time.Time
+	CompletedAt sql.NullTime
+}
+
+// Exporter generates exports in the background and hands out signed download links
+type Exporter struct {
+	DB           *sql.DB
+	DatabaseType string
+	Store        Store
+	Signer       *urlsigner.Signer
+	// BaseURL and Prefix build download links: BaseURL + Prefix + "/{id}"
+	BaseURL string
+	Prefix  string
+	// TTL is how long, in minutes, a download link stays valid
+	TTL int
+	// Dispatch has Generate run in the background; by default in a goroutine, and with a job
+	// queue by pushing a Job with the id of the export
+	Dispatch func(ctx context.Context, id int) error
+	// Notify is called once an export is done, with its download link when it succeeded
+	Notify   func(e Export, url string)
+	ErrorLog func(v ...interface{})
+	// Clock dates the exports and their files; the time of the machine when nil
+	Clock clock.Clock
+
+	mu         sync.RWMutex
+	generators map[string]Generator
+}
+
+// New returns an exporter storing files in store
+func New(db *sql.DB, dbType string, store Store, signer *urlsigner.Signer, baseURL string) *Exporter {
+	x := &Exporter{
+		DB:           db,
+		DatabaseType: dbType,
+		Store:        store,
+		Signer:       signer,
+		BaseURL:      baseURL,
+		Prefix:       "/exports",
+		TTL:          24 * 60,
+		generators:   map[string]Generator{},
+	}
+	x.Dispatch = func(ctx context.Context, id int) error {
+		go func() {
+			if err := x.Generate(context.Background(), id); err != nil {
+				x.logError("exports:", err)
+			}
+		}()
+		return nil
+	}
+
+	return x
+}
+
+// Define registers a generator under name, for the requests naming it; define it at every boot,
+// as the export is generated by whichever instance runs its job
+func (x *Exporter) Define(name string, g Generator) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.generators[name] = g
+}
+
+func (x *Exporter) generator(name string) (Generator, error) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	g, ok := x.generators[name]
+	if !ok {
+		return nil, fmt.Errorf("exports: no generator %q", name)
+	}
+	return g, nil
+}
+
+// Request records the export and schedules its generation
+func (x *Exporter) Request(ctx context.Context, req Request) (Export, error) {
+	if req.Generator != "" {
+		if _, err := x.generator(req.Generator); err != nil {
+			return Export{}, err
+		}
+	} else if _, err := newRowWriter(req.Format, io.Discard, nil); err != nil {
+		return Export{}, err
+	}
+
+	// the request is kept with the export for the job generating it
+	data, err := json.Marshal(req)
+	if err != nil {
+		return Export{}, err
+	}
+
+	e := Export{UserID: req.UserID, Name: req.Name, Format: req.Format, Status: Pending, CreatedAt: clock.Now(x.Clock)}
+
+	query := "insert into exports (user_id, name, format, status, progress, rows_count, file, error, request, created_at) values (?, ?, ?, ?, 0, 0, '', '', ?, ?)"
+	if database.IsPostgres(x.DatabaseType) {
+		err := x.DB.QueryRowContext(ctx, database.Rebind(x.DatabaseType, query+" returning id"),
+			e.UserID, e.Name, e.Format, e.Status, string(data), e.CreatedAt).Scan(&e.ID)
+		if err != nil {
+			return Export{}, err
+		}
+	} else {
+		res, err := x.DB.ExecContext(ctx, query, e.UserID, e.Name, e.Format, e.Status, string(data), e.CreatedAt)
+		if err != nil {
+			return Export{}, err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return Export{}, err
+		}
+		e.ID = int(id)
+	}
+
+	return e, x.Dispatch(ctx, e.ID)
+}
+
+// Generate writes the file of a pending export, claiming it first so an export whose job is
+// delivered twice is only generated once
+func (x *Exporter) Generate(ctx context.Context, id int) error {
+	res, err := x.DB.ExecContext(ctx, database.Rebind(x.DatabaseType, "update exports set status = ? where id = ? and status = ?"), Running, id, Pending)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+
+	e, err := x.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	var data string
+	if err := x.DB.QueryRowContext(ctx, database.Rebind(x.DatabaseType, "select request from exports where id = ?"), id).Scan(&data); err != nil {
+		return err
+	}
+	var req Request
+	if err := json.Unmarshal([]byte(data), &req); err != nil {
+		return err
+	}
+
+	x.generate(ctx, e, req)
+	return nil
+}
+
+// Find returns an export by id
+func (x *Exporter) Find(ctx context.Context, id int) (Export, error) {
+	var e Export
+
+	query := "select id, user_id, name, format, status, progress, rows_count, file, error, created_at, completed_at from exports where id = ?"
+	err := x.DB.QueryRowContext(ctx, database.Rebind(x.DatabaseType, query), id).Scan(
+		&e.ID, &e.UserID, &e.Name, &e.Format, &e.Status, &e.Progress, &e.Rows, &e.File, &e.Error, &e.CreatedAt, &e.CompletedAt)
+
+	return e, err
+}
+
+// URL returns a signed, expiring download link for an export
+func (x *Exporter) URL(e Export) string {
+	return x.Signer.GenerateTokenFromString(fmt.Sprintf("%s%s/%d", x.BaseURL, x.Prefix, e.ID))
+}
+
+// DownloadHandler serves completed exports to holders of a valid link; mount it on Prefix + "/{id}"
+func (x *Exporter) DownloadHandler(rw http.ResponseWriter, r *http.Request) {
+	link := x.BaseURL + r.RequestURI
+	if !x.Signer.VerifyToken(link) || x.Signer.Expired(link, x.TTL) {
+		http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	e, err := x.Find(r.Context(), id)
+	if err != nil || e.Status != Completed {
+		http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	f, err := x.Store.Open(e.File)
+	if err != nil {
+		http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+
+	rw.Header().Set("Content-Type", contentType(e.Format))
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", e.File))
+	_, _ = io.Copy(rw, f)
+}
+
+func (x *Exporter) generate(ctx context.Context, e Export, req Request) {
+	e.File = fmt.Sprintf("export-%d-%s.%s", e.ID, clock.Now(x.Clock).Format("20060102-150405"), e.Format)
+
+	err := x.write(ctx, &e, req)
+	if err != nil {
+		e.Status = Failed
+		e.Error = err.Error()
+		x.logError("exports:", err)
+	} else {
+		e.Status = Completed
+		e.Progress = 100
+	}
+
+	query := "update exports set status = ?, progress = ?, rows_count = ?, file = ?, error = ?, completed_at = ? where id = ?"
+	if _, err := x.DB.ExecContext(ctx, database.Rebind(x.DatabaseType, query), e.Status, e.Progress, e.Rows, e.File, e.Error, clock.Now(x.Clock), e.ID); err != nil {
+		x.logError("exports:", err)
+	}
+
+	if x.Notify != nil {
+		link := ""
+		if e.Status == Completed {
+			link = x.URL(e)
+		}
+		x.Notify(e, link)
+	}
+}
+
+// write runs the query into a temporary file, reporting progress, then hands the file to the store
+func (x *Exporter) write(ctx context.Context, e *Export, req Request) error {
+	x.progress(ctx, e.ID, Running, 0)
+
+	if req.Generator != "" {
+		return x.generated(ctx, e, req)
+	}
+
+	var total int
+	countQuery := fmt.Sprintf("select count(*) from (%s) export_count", req.Query)
+	if err := x.DB.QueryRowContext(ctx, database.Rebind(x.DatabaseType, countQuery), req.Args...).Scan(&total); err != nil {
+		total = 0
+	}
+
+	rows, err := x.DB.QueryContext(ctx, database.Rebind(x.DatabaseType, req.Query), req.Args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp("", "export-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	w, err := newRowWriter(e.Format, tmp, columns)
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	lastReport := clock.Now(x.Clock)
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		if err := w.Write(values); err != nil {
+			return err
+		}
+		e.Rows++
+
+		if total > 0 && clock.Since(x.Clock, lastReport) > time.Second {
+			x.progress(ctx, e.ID, Running, e.Rows*100/total)
+			lastReport = clock.Now(x.Clock)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	return x.Store.Put(e.File, tmp)
+}
+
+// generated hands the file written by the Generator of req to the store
+func (x *Exporter) generated(ctx context.Context, e *Export, req Request) error {
+	g, err := x.generator(req.Generator)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp("", "export-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if e.Rows, err = g(ctx, *e, tmp); err != nil {
+		return err
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	return x.Store.Put(e.File, tmp)
+}
+
+func (x *Exporter) progress(ctx context.Context, id int, status string, progress int) {
+	if progress > 99 {
+		progress = 99
+	}
+
+	query := "update exports set status = ?, progress = ? where id = ?"
+	if _, err := x.DB.ExecContext(ctx, database.Rebind(x.DatabaseType, query), status, progress, id); err != nil {
+		x.logError("exports:", err)
+	}
+}
+
+func (x *Exporter) logError(v ...interface{}) {
+	if x.ErrorLog != nil {
+		x.ErrorLog(v...)
+	}
+}
@@ -0,0 +1,67 @@
+package exports
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func write(t *testing.T, format string) string {
+	var buf bytes.Buffer
+
+	w, err := newRowWriter(format, &buf, []string{"id", "name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = w.Write([]interface{}{int64(1), []byte("Ann, Jr.")})
+	_ = w.Write([]interface{}{int64(2), nil})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String()
+}
+
+func TestFormats(t *testing.T) {
+	if got := write(t, CSV); got != "id,name\n1,\"Ann, Jr.\"\n2,\n" {
+		t.Errorf("unexpected csv %q", got)
+	}
+
+	var rows []map[string]interface{}
+	if err := json.Unmarshal([]byte(write(t, JSON)), &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0]["name"] != "Ann, Jr." || rows[1]["name"] != nil {
+		t.Errorf("unexpected json %v", rows)
+	}
+
+	if _, err := newRowWriter("pdf", &bytes.Buffer{}, nil); err == nil {
+		t.Error("expected unknown format to be rejected")
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exports")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := &Local{Dir: dir + "/nested"}
+	if err := s.Put("../escape.csv", bytes.NewBufferString("a,b")); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := s.Open("escape.csv")
+	if err != nil {
+		t.Fatal("expected file to be kept inside the store directory:", err)
+	}
+	b, _ := ioutil.ReadAll(f)
+	f.Close()
+
+	if string(b) != "a,b" {
+		t.Error("unexpected content", string(b))
+	}
+}
@@ -0,0 +1,140 @@
+package exports
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/namnguyen191/goravel/xlsx"
+)
+
+// Formats an export can be generated in
+const (
+	CSV  = "csv"
+	XLSX = "xlsx"
+	JSON = "json"
+	// ZIP is only written by the Generate func of a request
+	ZIP = "zip"
+)
+
+// rowWriter writes the rows of an export in one format
+type rowWriter interface {
+	Write(values []interface{}) error
+	Close() error
+}
+
+func newRowWriter(format string, w io.Writer, columns []string) (rowWriter, error) {
+	header := make([]interface{}, len(columns))
+	for i, c := range columns {
+		header[i] = c
+	}
+
+	switch format {
+	case CSV:
+		cw := &csvWriter{w: csv.NewWriter(w)}
+		return cw, cw.Write(header)
+	case XLSX:
+		xw, err := xlsx.NewWriter(w, "Export")
+		if err != nil {
+			return nil, err
+		}
+		return xw, xw.Write(header)
+	case JSON:
+		return &jsonWriter{w: w, columns: columns}, nil
+	}
+
+	return nil, fmt.Errorf("exports: unknown format %q", format)
+}
+
+// contentType returns the media type of a format
+func contentType(format string) string {
+	switch format {
+	case CSV:
+		return "text/csv"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case ZIP:
+		return "application/zip"
+	default:
+		return "application/json"
+	}
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) Write(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = cell(v)
+	}
+
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonWriter streams an array of objects keyed by column
+type jsonWriter struct {
+	w       io.Writer
+	columns []string
+	rows    int
+}
+
+func (j *jsonWriter) Write(values []interface{}) error {
+	obj := make(map[string]interface{}, len(values))
+	for i, v := range values {
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		obj[j.columns[i]] = v
+	}
+
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n"
+	if j.rows == 0 {
+		sep = "[\n"
+	}
+	j.rows++
+
+	if _, err := io.WriteString(j.w, sep); err != nil {
+		return err
+	}
+	_, err = j.w.Write(b)
+
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	end := "\n]\n"
+	if j.rows == 0 {
+		end = "[]\n"
+	}
+
+	_, err := io.WriteString(j.w, end)
+
+	return err
+}
+
+func cell(v interface{}) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(s)
+	case time.Time:
+		return s.Format(time.RFC3339)
+	}
+
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,47 @@
+package exports
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Store keeps generated files. Local is the built-in implementation; any filesystem
+// abstraction exposing the same methods can be used instead.
+type Store interface {
+	Put(name string, r io.Reader) error
+	Open(name string) (io.ReadCloser, error)
+	Delete(name string) error
+}
+
+// Local stores files in a directory
+type Local struct {
+	Dir string
+}
+
+func (l *Local) Put(name string, r io.Reader) error {
+	path := filepath.Join(l.Dir, filepath.Base(name))
+	if err := os.MkdirAll(l.Dir, 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func (l *Local) Open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(l.Dir, filepath.Base(name)))
+}
+
+func (l *Local) Delete(name string) error {
+	return os.Remove(filepath.Join(l.Dir, filepath.Base(name)))
+}
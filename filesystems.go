@@ -0,0 +1,109 @@
+package goravel
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/namnguyen191/goravel/filesystems"
+)
+
+// createFileSystems returns the file systems configured in .env: "local" in STORAGE_DIR and
+// "public" in public/storage are always there, "s3", "minio", "sftp" and "webdav" once their
+// bucket or host is set. Remote stores are called through the "filesystems" circuit breaker.
+func (grv *Goravel) createFileSystems() (map[string]filesystems.FileSystem, error) {
+	dir := grv.Env.String("STORAGE_DIR", "storage")
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(grv.RootPath, dir)
+	}
+
+	fs := map[string]filesystems.FileSystem{
+		"local":  &filesystems.Local{Root: dir},
+		"public": &filesystems.Local{Root: grv.RootPath + "/public/storage", BaseURL: "/public/storage"},
+	}
+	client := grv.HTTPClient("filesystems")
+
+	if bucket := os.Getenv("S3_BUCKET"); bucket != "" {
+		fs["s3"] = &filesystems.S3{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          bucket,
+			AccessKeyID:     os.Getenv("S3_KEY"),
+			SecretAccessKey: os.Getenv("S3_SECRET"),
+			BaseURL:         os.Getenv("S3_URL"),
+			Client:          client,
+		}
+	}
+
+	if bucket := os.Getenv("MINIO_BUCKET"); bucket != "" {
+		fs["minio"] = &filesystems.S3{
+			Endpoint:        os.Getenv("MINIO_ENDPOINT"),
+			Region:          os.Getenv("MINIO_REGION"),
+			Bucket:          bucket,
+			AccessKeyID:     os.Getenv("MINIO_KEY"),
+			SecretAccessKey: os.Getenv("MINIO_SECRET"),
+			PathStyle:       true,
+			BaseURL:         os.Getenv("MINIO_URL"),
+			Client:          client,
+		}
+	}
+
+	if host := os.Getenv("SFTP_HOST"); host != "" {
+		if !strings.Contains(host, ":") {
+			host += ":" + grv.Env.String("SFTP_PORT", "22")
+		}
+		s := &filesystems.SFTP{
+			Host:     host,
+			User:     os.Getenv("SFTP_USER"),
+			Password: os.Getenv("SFTP_PASS"),
+			HostKey:  os.Getenv("SFTP_HOST_KEY"),
+			Root:     os.Getenv("SFTP_ROOT"),
+			BaseURL:  os.Getenv("SFTP_URL"),
+		}
+		if path := os.Getenv("SFTP_KEY"); path != "" {
+			key, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			s.PrivateKey = key
+		}
+		fs["sftp"] = s
+	}
+
+	if host := os.Getenv("WEBDAV_HOST"); host != "" {
+		fs["webdav"] = &filesystems.WebDAV{
+			Endpoint: host,
+			User:     os.Getenv("WEBDAV_USER"),
+			Password: os.Getenv("WEBDAV_PASS"),
+			BaseURL:  os.Getenv("WEBDAV_URL"),
+			Client:   client,
+		}
+	}
+
+	return fs, nil
+}
+
+// UploadFile stores the file uploaded in field under dir of the named file system, e.g.
+// grv.UploadFile(r, "avatar", "public", "avatars"). It is checked against UPLOAD_LIMIT_MB and
+// UPLOAD_TYPES, and stored under a random name.
+func (grv *Goravel) UploadFile(r *http.Request, field, fileSystem, dir string) (*filesystems.Uploaded, error) {
+	fs, ok := grv.FileSystems[fileSystem]
+	if !ok {
+		return nil, fmt.Errorf("no file system %q", fileSystem)
+	}
+
+	var types []string
+	for _, t := range strings.Split(os.Getenv("UPLOAD_TYPES"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+
+	return filesystems.UploadFile(r, field, fs, dir, filesystems.Upload{
+		MaxSize: int64(grv.Env.Int("UPLOAD_LIMIT_MB", 10)) << 20,
+		Types:   types,
+	})
+}
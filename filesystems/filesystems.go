@@ -0,0 +1,67 @@
+package filesystems
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotExist is returned by Get for a file which is not in the file system
+	ErrNotExist = errors.New("filesystems: file does not exist")
+	// ErrBadName is returned for names escaping the root of the file system
+	ErrBadName = errors.New("filesystems: invalid file name")
+)
+
+// Entry is a file or directory returned by List
+type Entry struct {
+	// Path is slash separated and relative to the root, e.g. "avatars/12.png"
+	Path    string
+	Size    int64
+	ModTime time.Time
+	IsDir   bool
+}
+
+// FileSystem keeps files under slash separated names such as "avatars/12.png", on the local disk
+// or a remote store. Deleting a file which does not exist is not an error.
+type FileSystem interface {
+	Put(ctx context.Context, name string, r io.Reader) error
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	// List returns the files and directories directly in dir, "" being the root
+	List(ctx context.Context, dir string) ([]Entry, error)
+	Delete(ctx context.Context, name string) error
+	// URL is where browsers fetch the file, or "" when the file system is not served
+	URL(name string) string
+}
+
+// clean returns name relative to the root, refusing names escaping it
+func clean(name string) (string, error) {
+	if strings.Contains(name, "..") {
+		return "", ErrBadName
+	}
+
+	c := strings.TrimPrefix(path.Clean("/"+name), "/")
+	if c == "" {
+		return "", ErrBadName
+	}
+	return c, nil
+}
+
+// cleanDir is clean for the dir of List, where the root is ""
+func cleanDir(dir string) (string, error) {
+	if strings.Trim(dir, "/") == "" || dir == "." {
+		return "", nil
+	}
+	return clean(dir)
+}
+
+// join returns the URL of name under base, or "" without a base
+func join(base, name string) string {
+	if base == "" {
+		return ""
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(name, "/")
+}
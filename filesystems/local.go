@@ -0,0 +1,115 @@
+package filesystems
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// Local keeps files in a directory, served at BaseURL when it is set, e.g. public/storage at
+// /public/storage
+type Local struct {
+	Root    string
+	BaseURL string
+}
+
+func (l *Local) path(name string) (string, error) {
+	c, err := clean(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.Root, filepath.FromSlash(c)), nil
+}
+
+// Put writes the file next to its name first, so a failed upload never leaves half a file
+func (l *Local) Put(ctx context.Context, name string, r io.Reader) error {
+	p, err := l.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), p)
+}
+
+func (l *Local) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	p, err := l.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(p)
+	if os.IsNotExist(err) {
+		return nil, ErrNotExist
+	}
+	return f, err
+}
+
+func (l *Local) List(ctx context.Context, dir string) ([]Entry, error) {
+	d, err := cleanDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := os.ReadDir(filepath.Join(l.Root, filepath.FromSlash(d)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, item := range items {
+		info, err := item.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, Entry{
+			Path:    path.Join(d, item.Name()),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+			IsDir:   item.IsDir(),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries, nil
+}
+
+func (l *Local) Delete(ctx context.Context, name string) error {
+	p, err := l.path(name)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(p)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (l *Local) URL(name string) string {
+	return join(l.BaseURL, name)
+}
@@ -0,0 +1,360 @@
+package filesystems
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3 keeps files in a bucket of Amazon S3 or a compatible store such as MinIO, signing its
+// requests with AWS signature version 4
+type S3 struct {
+	// Endpoint is the address of the store, https://s3.<region>.amazonaws.com by default
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PathStyle puts the bucket in the path instead of the host name, as MinIO expects
+	PathStyle bool
+	// BaseURL is where the bucket is served publicly, e.g. through a CDN; without it URL returns
+	// presigned links valid for URLExpiry
+	BaseURL   string
+	URLExpiry time.Duration
+	Client    *http.Client
+}
+
+// unsignedPayload is signed in place of the hash of the body of presigned links
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+func (s *S3) endpoint() *url.URL {
+	e := s.Endpoint
+	if e == "" {
+		e = "https://s3." + s.region() + ".amazonaws.com"
+	}
+	u, err := url.Parse(e)
+	if err != nil || u.Host == "" {
+		u = &url.URL{Scheme: "https", Host: strings.TrimPrefix(e, "//")}
+	}
+	return u
+}
+
+func (s *S3) region() string {
+	if s.Region == "" {
+		return "us-east-1"
+	}
+	return s.Region
+}
+
+// object returns the url of a key of the bucket, with its path escaped the way it is signed
+func (s *S3) object(key string, query url.Values) *url.URL {
+	u := s.endpoint()
+	p := "/" + escapePath(key)
+	if s.PathStyle {
+		p = "/" + s.Bucket + p
+	} else {
+		u.Host = s.Bucket + "." + u.Host
+	}
+
+	u.Path, _ = url.PathUnescape(p)
+	u.RawPath = p
+	u.RawQuery = canonicalQuery(query)
+	return u
+}
+
+func (s *S3) do(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.object(key, query).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, sha256Hex(body), time.Now().UTC())
+
+	c := s.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+	return c.Do(req)
+}
+
+// Put uploads the file in one request; S3 needs its length up front, so it is read in memory
+func (s *S3) Put(ctx context.Context, name string, r io.Reader) error {
+	key, err := clean(name)
+	if err != nil {
+		return err
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.do(ctx, http.MethodPut, key, nil, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return s3Error(res)
+}
+
+func (s *S3) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	key, err := clean(name)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.do(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return nil, ErrNotExist
+	}
+	if err := s3Error(res); err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+type listResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List pages through ListObjectsV2, the directories being the common prefixes of the keys
+func (s *S3) List(ctx context.Context, dir string) ([]Entry, error) {
+	d, err := cleanDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	prefix := ""
+	if d != "" {
+		prefix = d + "/"
+	}
+
+	var entries []Entry
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}, "delimiter": {"/"}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		res, err := s.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		var list listResult
+		err = s3Error(res)
+		if err == nil {
+			err = xml.NewDecoder(res.Body).Decode(&list)
+		}
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range list.Contents {
+			if c.Key == prefix {
+				continue
+			}
+			entries = append(entries, Entry{Path: c.Key, Size: c.Size, ModTime: c.LastModified})
+		}
+		for _, p := range list.CommonPrefixes {
+			entries = append(entries, Entry{Path: strings.TrimSuffix(p.Prefix, "/"), IsDir: true})
+		}
+
+		if !list.IsTruncated || list.NextContinuationToken == "" {
+			break
+		}
+		token = list.NextContinuationToken
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries, nil
+}
+
+func (s *S3) Delete(ctx context.Context, name string) error {
+	key, err := clean(name)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.do(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return s3Error(res)
+}
+
+func (s *S3) URL(name string) string {
+	if s.BaseURL != "" {
+		return join(s.BaseURL, name)
+	}
+
+	key, err := clean(name)
+	if err != nil {
+		return ""
+	}
+	expiry := s.URLExpiry
+	if expiry <= 0 {
+		expiry = time.Hour
+	}
+	return s.presign(key, time.Now().UTC(), expiry)
+}
+
+// presign returns a link to GET key until expiry, signed in its query
+func (s *S3) presign(key string, now time.Time, expiry time.Duration) string {
+	scope := s.scope(now)
+	query := url.Values{
+		"X-Amz-Algorithm":     {"AWS4-HMAC-SHA256"},
+		"X-Amz-Credential":    {s.AccessKeyID + "/" + scope},
+		"X-Amz-Date":          {now.Format("20060102T150405Z")},
+		"X-Amz-Expires":       {fmt.Sprint(int(expiry.Seconds()))},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	u := s.object(key, query)
+
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		u.RawQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+
+	u.RawQuery += "&X-Amz-Signature=" + s.signature(now, canonical)
+	return u.String()
+}
+
+// sign adds the Authorization header of a request whose body hashes to payloadHash
+func (s *S3) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	canonicalHeaders := fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n", req.URL.Host, payloadHash, amzDate)
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, s.scope(now), signedHeaders, s.signature(now, canonical)))
+}
+
+func (s *S3) scope(now time.Time) string {
+	return fmt.Sprintf("%s/%s/s3/aws4_request", now.Format("20060102"), s.region())
+}
+
+func (s *S3) signature(now time.Time, canonicalRequest string) string {
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		now.Format("20060102T150405Z"),
+		s.scope(now),
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.region())
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// s3Error returns the code and message of an error response
+func s3Error(res *http.Response) error {
+	if res.StatusCode < 300 {
+		return nil
+	}
+
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	_ = xml.NewDecoder(io.LimitReader(res.Body, 1<<16)).Decode(&e)
+	if e.Code == "" {
+		e.Code = res.Status
+	}
+	return fmt.Errorf("filesystems: s3: %s %s", e.Code, e.Message)
+}
+
+// escape encodes s the way AWS signs it: everything but the unreserved characters
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func escapePath(p string) string {
+	segments := strings.Split(path.Clean("/" + p)[1:], "/")
+	for i, seg := range segments {
+		segments[i] = escape(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery encodes query sorted by key, as it is signed
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, escape(k)+"="+escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
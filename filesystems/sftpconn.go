@@ -0,0 +1,347 @@
+package filesystems
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// packet types and flags of version 3 of the SFTP protocol, the one every server speaks
+const (
+	fxpInit     = 1
+	fxpVersion  = 2
+	fxpOpen     = 3
+	fxpClose    = 4
+	fxpRead     = 5
+	fxpWrite    = 6
+	fxpOpendir  = 11
+	fxpReaddir  = 12
+	fxpRemove   = 13
+	fxpMkdir    = 14
+	fxpStat     = 17
+	fxpStatus   = 101
+	fxpHandle   = 102
+	fxpData     = 103
+	fxpName     = 104
+	fxpAttrs    = 105
+	fxfRead     = 0x01
+	fxfWrite    = 0x02
+	fxfCreat    = 0x08
+	fxfTrunc    = 0x10
+	attrSize    = 0x01
+	attrUIDGID  = 0x02
+	attrPerms   = 0x04
+	attrTimes   = 0x08
+	attrExtends = 0x80000000
+
+	fxOK         = 0
+	fxEOF        = 1
+	fxNoSuchFile = 2
+)
+
+// chunk is the size of the reads and writes, which every server accepts
+const chunk = 32 * 1024
+
+// statusError is a failure reported by the server
+type statusError struct {
+	code uint32
+	msg  string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("filesystems: sftp: %s (%d)", e.msg, e.code)
+}
+
+var errShortPacket = errors.New("filesystems: sftp: short packet")
+
+// sftpConn speaks the SFTP protocol over the stdin and stdout of the "sftp" subsystem, one
+// request at a time
+type sftpConn struct {
+	w  io.Writer
+	r  io.Reader
+	id uint32
+}
+
+func newSFTPConn(w io.Writer, r io.Reader) (*sftpConn, error) {
+	c := &sftpConn{w: w, r: r}
+	if err := c.write(fxpInit, uint32(3)); err != nil {
+		return nil, err
+	}
+
+	typ, _, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+	if typ != fxpVersion {
+		return nil, fmt.Errorf("filesystems: sftp: unexpected packet %d", typ)
+	}
+	return c, nil
+}
+
+// write sends a packet of fields, each a byte, uint32, uint64, string or []byte
+func (c *sftpConn) write(typ byte, fields ...interface{}) error {
+	b := []byte{0, 0, 0, 0, typ}
+	for _, f := range fields {
+		switch v := f.(type) {
+		case byte:
+			b = append(b, v)
+		case uint32:
+			b = appendUint32(b, v)
+		case uint64:
+			b = appendUint32(appendUint32(b, uint32(v>>32)), uint32(v))
+		case string:
+			b = append(appendUint32(b, uint32(len(v))), v...)
+		case []byte:
+			b = append(appendUint32(b, uint32(len(v))), v...)
+		default:
+			return fmt.Errorf("filesystems: sftp: cannot send %T", f)
+		}
+	}
+	binary.BigEndian.PutUint32(b, uint32(len(b)-4))
+
+	_, err := c.w.Write(b)
+	return err
+}
+
+func (c *sftpConn) read() (byte, []byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(c.r, size[:]); err != nil {
+		return 0, nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n < 1 || n > 256*1024 {
+		return 0, nil, fmt.Errorf("filesystems: sftp: packet of %d bytes", n)
+	}
+
+	b := make([]byte, n)
+	if _, err := io.ReadFull(c.r, b); err != nil {
+		return 0, nil, err
+	}
+	return b[0], b[1:], nil
+}
+
+// request sends a packet with a new request id, returning the type and data of the answer
+func (c *sftpConn) request(typ byte, fields ...interface{}) (byte, []byte, error) {
+	c.id++
+	if err := c.write(typ, append([]interface{}{c.id}, fields...)...); err != nil {
+		return 0, nil, err
+	}
+
+	rtyp, data, err := c.read()
+	if err != nil {
+		return 0, nil, err
+	}
+	id, data, ok := readUint32(data)
+	if !ok || id != c.id {
+		return 0, nil, fmt.Errorf("filesystems: sftp: answer to another request")
+	}
+	if rtyp == fxpStatus {
+		return rtyp, data, status(data)
+	}
+	return rtyp, data, nil
+}
+
+// status returns the error of a status packet, nil for OK
+func status(data []byte) error {
+	code, data, ok := readUint32(data)
+	if !ok {
+		return errShortPacket
+	}
+	switch code {
+	case fxOK:
+		return nil
+	case fxEOF:
+		return io.EOF
+	case fxNoSuchFile:
+		return ErrNotExist
+	}
+	msg, _, _ := readString(data)
+	return &statusError{code: code, msg: msg}
+}
+
+// expect sends a request whose answer is of type want, turning a status into an error
+func (c *sftpConn) expect(want byte, typ byte, fields ...interface{}) ([]byte, error) {
+	rtyp, data, err := c.request(typ, fields...)
+	if err != nil {
+		return nil, err
+	}
+	if rtyp != want {
+		return nil, fmt.Errorf("filesystems: sftp: unexpected packet %d", rtyp)
+	}
+	return data, nil
+}
+
+func (c *sftpConn) open(path string, flags uint32) (string, error) {
+	data, err := c.expect(fxpHandle, fxpOpen, path, flags, uint32(0))
+	if err != nil {
+		return "", err
+	}
+	h, _, ok := readString(data)
+	if !ok {
+		return "", errShortPacket
+	}
+	return h, nil
+}
+
+func (c *sftpConn) close(handle string) error {
+	_, _, err := c.request(fxpClose, handle)
+	return err
+}
+
+func (c *sftpConn) writeAt(handle string, offset uint64, b []byte) error {
+	_, _, err := c.request(fxpWrite, handle, offset, b)
+	return err
+}
+
+// readAt returns the next bytes of the file from offset, or io.EOF
+func (c *sftpConn) readAt(handle string, offset uint64) ([]byte, error) {
+	data, err := c.expect(fxpData, fxpRead, handle, offset, uint32(chunk))
+	if err != nil {
+		return nil, err
+	}
+	b, _, ok := readString(data)
+	if !ok {
+		return nil, errShortPacket
+	}
+	return []byte(b), nil
+}
+
+func (c *sftpConn) remove(path string) error {
+	_, _, err := c.request(fxpRemove, path)
+	return err
+}
+
+func (c *sftpConn) mkdir(path string) error {
+	_, _, err := c.request(fxpMkdir, path, uint32(0))
+	return err
+}
+
+func (c *sftpConn) stat(path string) (sftpAttrs, error) {
+	data, err := c.expect(fxpAttrs, fxpStat, path)
+	if err != nil {
+		return sftpAttrs{}, err
+	}
+	a, _, ok := readAttrs(data)
+	if !ok {
+		return sftpAttrs{}, errShortPacket
+	}
+	return a, nil
+}
+
+type sftpName struct {
+	name  string
+	attrs sftpAttrs
+}
+
+// readDir returns the entries of the directory at path, "." and ".." included
+func (c *sftpConn) readDir(path string) ([]sftpName, error) {
+	data, err := c.expect(fxpHandle, fxpOpendir, path)
+	if err != nil {
+		return nil, err
+	}
+	handle, _, ok := readString(data)
+	if !ok {
+		return nil, errShortPacket
+	}
+	defer c.close(handle)
+
+	var names []sftpName
+	for {
+		data, err := c.expect(fxpName, fxpReaddir, handle)
+		if err == io.EOF {
+			return names, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		count, data, ok := readUint32(data)
+		for i := uint32(0); ok && i < count; i++ {
+			var n sftpName
+			if n.name, data, ok = readString(data); !ok {
+				break
+			}
+			if _, data, ok = readString(data); !ok { // the long name, as ls -l prints it
+				break
+			}
+			n.attrs, data, ok = readAttrs(data)
+			names = append(names, n)
+		}
+		if !ok {
+			return nil, errShortPacket
+		}
+	}
+}
+
+type sftpAttrs struct {
+	size  int64
+	mode  uint32
+	mtime time.Time
+}
+
+func (a sftpAttrs) isDir() bool {
+	return a.mode&0170000 == 0040000
+}
+
+func readAttrs(b []byte) (sftpAttrs, []byte, bool) {
+	var a sftpAttrs
+	flags, b, ok := readUint32(b)
+	if !ok {
+		return a, b, false
+	}
+
+	if flags&attrSize != 0 {
+		var hi, lo uint32
+		hi, b, ok = readUint32(b)
+		if ok {
+			lo, b, ok = readUint32(b)
+		}
+		a.size = int64(hi)<<32 | int64(lo)
+	}
+	if ok && flags&attrUIDGID != 0 {
+		_, b, ok = readUint32(b)
+		if ok {
+			_, b, ok = readUint32(b)
+		}
+	}
+	if ok && flags&attrPerms != 0 {
+		a.mode, b, ok = readUint32(b)
+	}
+	if ok && flags&attrTimes != 0 {
+		var mtime uint32
+		_, b, ok = readUint32(b)
+		if ok {
+			mtime, b, ok = readUint32(b)
+		}
+		a.mtime = time.Unix(int64(mtime), 0)
+	}
+	if ok && flags&attrExtends != 0 {
+		var count uint32
+		count, b, ok = readUint32(b)
+		for i := uint32(0); ok && i < 2*count; i++ {
+			_, b, ok = readString(b)
+		}
+	}
+	return a, b, ok
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func readUint32(b []byte) (uint32, []byte, bool) {
+	if len(b) < 4 {
+		return 0, b, false
+	}
+	return binary.BigEndian.Uint32(b), b[4:], true
+}
+
+func readString(b []byte) (string, []byte, bool) {
+	n, b, ok := readUint32(b)
+	if !ok || uint32(len(b)) < n {
+		return "", b, false
+	}
+	return string(b[:n]), b[n:], true
+}
@@ -0,0 +1,111 @@
+package filesystems
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrTooLarge is returned for an upload over the MaxSize of its rules
+	ErrTooLarge = errors.New("filesystems: the file is too large")
+	// ErrType is returned for an upload whose content is not of an allowed type
+	ErrType = errors.New("filesystems: the file type is not allowed")
+)
+
+// Upload are the rules an uploaded file is checked against
+type Upload struct {
+	// MaxSize is the largest file accepted, in bytes; 10 MB by default
+	MaxSize int64
+	// Types are the media types accepted, detected from the content rather than trusted from
+	// the browser, e.g. "image/png" or "image/*"; any type when empty
+	Types []string
+}
+
+// Uploaded is a file stored by UploadFile
+type Uploaded struct {
+	// Name is the name of the file on the computer of the user
+	Name string
+	// Path is where it was stored in the file system
+	Path        string
+	Size        int64
+	ContentType string
+}
+
+// RandomName returns a random file name with the extension of name, so uploads never collide
+// nor pick where they end up
+func RandomName(name string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b) + strings.ToLower(filepath.Ext(filepath.Base(name))), nil
+}
+
+// UploadFile checks the file uploaded in the multipart field of r against rules, and stores it
+// in dir of fs under a random name
+func UploadFile(r *http.Request, field string, fs FileSystem, dir string, rules Upload) (*Uploaded, error) {
+	if rules.MaxSize <= 0 {
+		rules.MaxSize = 10 << 20
+	}
+	if err := r.ParseMultipartForm(rules.MaxSize); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return nil, err
+	}
+
+	f, header, err := r.FormFile(field)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if header.Size > rules.MaxSize {
+		return nil, ErrTooLarge
+	}
+
+	// the type is sniffed from the first bytes, which are then put back in front of the rest
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	if !allowed(contentType, rules.Types) {
+		return nil, fmt.Errorf("%w: %s", ErrType, contentType)
+	}
+
+	name, err := RandomName(header.Filename)
+	if err != nil {
+		return nil, err
+	}
+	up := &Uploaded{Name: header.Filename, Path: path.Join(dir, name), Size: header.Size, ContentType: contentType}
+
+	body := io.MultiReader(bytes.NewReader(head), io.LimitReader(f, rules.MaxSize-int64(n)))
+	if err := fs.Put(r.Context(), up.Path, body); err != nil {
+		return nil, err
+	}
+	return up, nil
+}
+
+// allowed reports whether contentType is one of types, which may end with a wildcard
+func allowed(contentType string, types []string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	mediaType := strings.TrimSpace(strings.Split(contentType, ";")[0])
+	for _, t := range types {
+		t = strings.TrimSpace(t)
+		if t == mediaType || strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(t, "*")) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+package filesystems
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebDAV keeps files on a WebDAV server, e.g. Nextcloud: https://cloud.example.com/remote.php/dav/files/app
+type WebDAV struct {
+	// Endpoint is the url of the directory of the files
+	Endpoint string
+	User     string
+	Password string
+	BaseURL  string
+	Client   *http.Client
+}
+
+func (w *WebDAV) url(name string) string {
+	segments := strings.Split(name, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.TrimSuffix(w.Endpoint, "/") + "/" + strings.Join(segments, "/")
+}
+
+func (w *WebDAV) do(ctx context.Context, method, name string, body io.Reader, header map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, w.url(name), body)
+	if err != nil {
+		return nil, err
+	}
+	if w.User != "" {
+		req.SetBasicAuth(w.User, w.Password)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	c := w.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+	return c.Do(req)
+}
+
+// Put creates the missing collections of name, then uploads the file
+func (w *WebDAV) Put(ctx context.Context, name string, r io.Reader) error {
+	n, err := clean(name)
+	if err != nil {
+		return err
+	}
+
+	if dir := path.Dir(n); dir != "." {
+		p := ""
+		for _, part := range strings.Split(dir, "/") {
+			p = path.Join(p, part)
+			res, err := w.do(ctx, "MKCOL", p+"/", nil, nil)
+			if err != nil {
+				return err
+			}
+			res.Body.Close()
+			// 405 is the answer for a collection which exists
+			if res.StatusCode >= 300 && res.StatusCode != http.StatusMethodNotAllowed {
+				return fmt.Errorf("filesystems: webdav: creating %s: %s", p, res.Status)
+			}
+		}
+	}
+
+	res, err := w.do(ctx, http.MethodPut, n, r, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("filesystems: webdav: %s", res.Status)
+	}
+	return nil
+}
+
+func (w *WebDAV) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	n, err := clean(name)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := w.do(ctx, http.MethodGet, n, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return nil, ErrNotExist
+	}
+	if res.StatusCode >= 300 {
+		res.Body.Close()
+		return nil, fmt.Errorf("filesystems: webdav: %s", res.Status)
+	}
+	return res.Body, nil
+}
+
+const propfind = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/><d:getcontentlength/><d:getlastmodified/></d:prop></d:propfind>`
+
+type multistatus struct {
+	Responses []struct {
+		Href string `xml:"href"`
+		Prop struct {
+			Collection   *struct{} `xml:"resourcetype>collection"`
+			Length       string    `xml:"getcontentlength"`
+			LastModified string    `xml:"getlastmodified"`
+		} `xml:"propstat>prop"`
+	} `xml:"response"`
+}
+
+// List asks for the properties of dir and its members with PROPFIND, skipping dir itself
+func (w *WebDAV) List(ctx context.Context, dir string) ([]Entry, error) {
+	d, err := cleanDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	target := ""
+	if d != "" {
+		target = d + "/"
+	}
+	res, err := w.do(ctx, "PROPFIND", target, strings.NewReader(propfind), map[string]string{
+		"Depth":        "1",
+		"Content-Type": "application/xml; charset=utf-8",
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if res.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("filesystems: webdav: %s", res.Status)
+	}
+
+	var ms multistatus
+	if err := xml.NewDecoder(res.Body).Decode(&ms); err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, r := range ms.Responses {
+		href, err := url.PathUnescape(r.Href)
+		if err != nil {
+			continue
+		}
+		if u, err := url.Parse(href); err == nil && u.Host != "" {
+			href = u.Path
+		}
+
+		name := path.Base(strings.TrimSuffix(href, "/"))
+		if strings.TrimSuffix(href, "/") == strings.TrimSuffix(w.path(d), "/") {
+			continue
+		}
+
+		e := Entry{Path: path.Join(d, name), IsDir: r.Prop.Collection != nil}
+		e.Size, _ = strconv.ParseInt(r.Prop.Length, 10, 64)
+		e.ModTime, _ = time.Parse(http.TimeFormat, r.Prop.LastModified)
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries, nil
+}
+
+// path returns the path of name on the server, as hrefs are written
+func (w *WebDAV) path(name string) string {
+	u, err := url.Parse(w.Endpoint)
+	if err != nil {
+		return "/" + name
+	}
+	return path.Join("/", u.Path, name)
+}
+
+func (w *WebDAV) Delete(ctx context.Context, name string) error {
+	n, err := clean(name)
+	if err != nil {
+		return err
+	}
+
+	res, err := w.do(ctx, http.MethodDelete, n, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 && res.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("filesystems: webdav: %s", res.Status)
+	}
+	return nil
+}
+
+func (w *WebDAV) URL(name string) string {
+	return join(w.BaseURL, name)
+}
@@ -0,0 +1,132 @@
+package goravel
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+
+	"github.com/namnguyen191/goravel/concurrency"
+	"github.com/namnguyen191/goravel/env"
+	"github.com/namnguyen191/goravel/flags"
+)
+
+// Flags turning on behavior which becomes the default in a later version; apps opt in with
+// GORAVEL_FLAGS, e.g. GORAVEL_FLAGS=strict_config,router_defaults, and check grv.Flags.Enabled
+const (
+	// FlagStrictConfig stops New when the environment fails "goravel config:check"
+	FlagStrictConfig = "strict_config"
+	// FlagStrictErrors answers panics in handlers like HandleError, as JSON to API clients,
+	// and reports them to the monitor
+	FlagStrictErrors = "strict_errors"
+	// FlagRouterDefaults redirects trailing slashes and answers HEAD requests unless
+	// ROUTER_TRAILING_SLASH and ROUTER_AUTO_HEAD say otherwise
+	FlagRouterDefaults = "router_defaults"
+	// FlagSecureCookies makes cookies secure unless COOKIE_SECURE or SECURE is false
+	FlagSecureCookies = "secure_cookies"
+)
+
+var frameworkFlags = []flags.Flag{
+	{Name: FlagStrictConfig, Description: "fail to start when the environment is invalid", Default: "2.0.0"},
+	{Name: FlagStrictErrors, Description: "answer panics like HandleError and report them", Default: "2.0.0"},
+	{Name: FlagRouterDefaults, Description: "redirect trailing slashes and answer HEAD requests by default", Default: "2.0.0"},
+	{Name: FlagSecureCookies, Description: "secure cookies unless the app is served over http", Default: "2.0.0"},
+}
+
+// createFlags reads GORAVEL_FLAGS; unknown names are logged by reportFlags once the loggers exist
+func (grv *Goravel) createFlags() {
+	grv.Flags = flags.Parse(os.Getenv("GORAVEL_FLAGS"), frameworkFlags)
+}
+
+func (grv *Goravel) reportFlags() {
+	for _, name := range grv.Flags.Unknown {
+		grv.ErrorLog.Println("flags: unknown flag", name, "in GORAVEL_FLAGS")
+	}
+	if enabled := grv.Flags.List(); len(enabled) > 0 {
+		grv.InfoLog.Println("flags:", strings.Join(enabled, ", "))
+	}
+
+	// variables renamed in the registry are still read under their old names for now
+	for _, v := range env.Registry {
+		for _, alias := range v.Aliases {
+			if os.Getenv(alias) != "" && os.Getenv(v.Name) == "" {
+				grv.Deprecated(alias, v.Name)
+			}
+		}
+	}
+}
+
+// Deprecated logs once that what is deprecated in favor of instead, which may be empty
+func (grv *Goravel) Deprecated(what, instead string) {
+	grv.Flags.Deprecated(grv.ErrorLog.Println, what, instead)
+}
+
+// checkConfig validates the registered variables of the environment, for FlagStrictConfig
+func checkConfig() error {
+	values := map[string]string{}
+	for _, v := range env.Registry {
+		for _, name := range append([]string{v.Name}, v.Aliases...) {
+			if value := os.Getenv(name); value != "" {
+				values[name] = value
+			}
+		}
+	}
+
+	var problems []string
+	for _, p := range env.Check(values, env.Registry) {
+		if !p.Warning {
+			problems = append(problems, p.String())
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration (unset %s to start anyway): %s", FlagStrictConfig, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// recoverer answers a panic in a handler with HandleError, for FlagStrictErrors
+func (grv *Goravel) recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			p := &concurrency.PanicError{Value: v, Stack: debug.Stack()}
+			grv.reportPanic(p)
+			grv.HandleError(rw, r, errors.New(p.Error()))
+		}()
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+func (grv *Goravel) cookieSecure() string {
+	secure := os.Getenv("COOKIE_SECURE")
+	if secure == "" && grv.Flags.Enabled(FlagSecureCookies) && strings.ToLower(os.Getenv("SECURE")) != "false" {
+		return "true"
+	}
+	return secure
+}
+
+func (grv *Goravel) routerTrailingSlash() string {
+	mode := strings.ToLower(os.Getenv("ROUTER_TRAILING_SLASH"))
+	if mode == "" && grv.Flags.Enabled(FlagRouterDefaults) {
+		return "redirect"
+	}
+	return mode
+}
+
+func (grv *Goravel) routerAutoHead() bool {
+	value := strings.ToLower(os.Getenv("ROUTER_AUTO_HEAD"))
+	if value == "" {
+		return grv.Flags.Enabled(FlagRouterDefaults)
+	}
+	return value == "true"
+}
@@ -0,0 +1,115 @@
+package flags
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Flag is a change of behavior apps opt into before it becomes the default, so upgrading the
+// framework does not change how an existing app works
+type Flag struct {
+	Name        string
+	Description string
+	// Default is the version in which the flag is expected to become the default behavior
+	Default string
+}
+
+// Flags are the flags enabled for this app, e.g. from GORAVEL_FLAGS=strict_config,router_defaults
+type Flags struct {
+	known   map[string]Flag
+	enabled map[string]bool
+	// Unknown lists the names which are not a known flag, usually a typo or a flag which was
+	// removed once its behavior became the default
+	Unknown []string
+
+	mu     sync.Mutex
+	warned map[string]bool
+}
+
+// Parse enables the comma or space separated flags of value; "all" enables every known flag
+func Parse(value string, known []Flag) *Flags {
+	f := &Flags{known: map[string]Flag{}, enabled: map[string]bool{}}
+	for _, flag := range known {
+		f.known[flag.Name] = flag
+	}
+
+	for _, name := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "all" {
+			for n := range f.known {
+				f.enabled[n] = true
+			}
+			continue
+		}
+		if _, ok := f.known[name]; !ok {
+			f.Unknown = append(f.Unknown, name)
+			continue
+		}
+		f.enabled[name] = true
+	}
+
+	return f
+}
+
+// Enabled reports whether the flag name is on; a nil Flags has every flag off
+func (f *Flags) Enabled(name string) bool {
+	if f == nil {
+		return false
+	}
+	return f.enabled[name]
+}
+
+// Enable turns a flag on, e.g. from tests
+func (f *Flags) Enable(names ...string) {
+	for _, name := range names {
+		f.enabled[name] = true
+	}
+}
+
+// List returns the enabled flags in alphabetical order
+func (f *Flags) List() []string {
+	if f == nil {
+		return nil
+	}
+
+	var names []string
+	for name := range f.enabled {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Pending returns the known flags which are off, the behavior changes the app has not opted into yet
+func (f *Flags) Pending() []Flag {
+	var pending []Flag
+	for name, flag := range f.known {
+		if !f.enabled[name] {
+			pending = append(pending, flag)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Name < pending[j].Name })
+	return pending
+}
+
+// Deprecated calls warn the first time what is used, telling to use instead; later uses are
+// silent so a deprecated call in a handler does not flood the logs
+func (f *Flags) Deprecated(warn func(v ...interface{}), what, instead string) {
+	f.mu.Lock()
+	if f.warned == nil {
+		f.warned = map[string]bool{}
+	}
+	seen := f.warned[what]
+	f.warned[what] = true
+	f.mu.Unlock()
+
+	if seen {
+		return
+	}
+	if instead == "" {
+		warn("deprecated:", what, "will be removed")
+		return
+	}
+	warn("deprecated:", what, "will be removed, use", instead)
+}
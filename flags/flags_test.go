@@ -0,0 +1,57 @@
+package flags
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+var known = []Flag{
+	{Name: "strict_config", Description: "fail on invalid config"},
+	{Name: "router_defaults", Description: "redirect trailing slashes"},
+}
+
+func TestParse(t *testing.T) {
+	f := Parse("Strict_Config, typo_flag", known)
+
+	if !f.Enabled("strict_config") {
+		t.Error("expected strict_config to be enabled")
+	}
+	if f.Enabled("router_defaults") {
+		t.Error("expected router_defaults to stay off")
+	}
+	if !reflect.DeepEqual(f.Unknown, []string{"typo_flag"}) {
+		t.Errorf("expected the unknown flag, got %v", f.Unknown)
+	}
+	if p := f.Pending(); len(p) != 1 || p[0].Name != "router_defaults" {
+		t.Errorf("expected router_defaults to be pending, got %v", p)
+	}
+
+	all := Parse("all", known)
+	if !reflect.DeepEqual(all.List(), []string{"router_defaults", "strict_config"}) {
+		t.Errorf("expected every flag, got %v", all.List())
+	}
+
+	var none *Flags
+	if none.Enabled("strict_config") {
+		t.Error("expected a nil set to have every flag off")
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	f := Parse("", known)
+
+	var warnings []string
+	warn := func(v ...interface{}) { warnings = append(warnings, fmt.Sprintln(v...)) }
+
+	f.Deprecated(warn, "SEVER_NAME", "SERVER_NAME")
+	f.Deprecated(warn, "SEVER_NAME", "SERVER_NAME")
+	f.Deprecated(warn, "grv.Old", "")
+
+	if len(warnings) != 2 {
+		t.Fatalf("expected one warning each, got %v", warnings)
+	}
+	if warnings[0] != "deprecated: SEVER_NAME will be removed, use SERVER_NAME\n" {
+		t.Errorf("unexpected warning %q", warnings[0])
+	}
+}
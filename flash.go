@@ -0,0 +1,49 @@
+package goravel
+
+import (
+	"net/http"
+
+	"github.com/namnguyen191/goravel/render"
+)
+
+// fields that are never flashed back to the browser
+var flashInputExcept = []string{"csrf_token", "_method", "password", "password_confirmation"}
+
+// FlashInput stores the submitted form values in the session so forms can be repopulated with
+// old("field") after redirecting back on a validation failure; extra field names can be excluded
+func (grv *Goravel) FlashInput(r *http.Request, except ...string) {
+	if r.Form == nil {
+		_ = r.ParseForm()
+	}
+
+	skip := make(map[string]bool)
+	for _, field := range append(flashInputExcept, except...) {
+		skip[field] = true
+	}
+
+	old := make(map[string]string)
+	for field, values := range r.Form {
+		if skip[field] || len(values) == 0 {
+			continue
+		}
+		old[field] = values[0]
+	}
+
+	grv.Session.Put(r.Context(), render.OldInputKey, old)
+}
+
+// FlashErrors stores the validation errors in the session error bag read by errorsFor()
+func (grv *Goravel) FlashErrors(r *http.Request, v *Validation) {
+	errs := make(map[string]string, len(v.Error))
+	for field, message := range v.Error {
+		errs[field] = message
+	}
+
+	grv.Session.Put(r.Context(), render.ErrorBagKey, errs)
+}
+
+// FlashValidation flashes both the submitted input and the validation errors
+func (grv *Goravel) FlashValidation(r *http.Request, v *Validation) {
+	grv.FlashInput(r)
+	grv.FlashErrors(r, v)
+}
@@ -1,12 +1,13 @@
 package goravel
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/CloudyKit/jet/v6"
@@ -15,10 +16,64 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/gomodule/redigo/redis"
 	"github.com/joho/godotenv"
+	"github.com/namnguyen191/goravel/activity"
+	"github.com/namnguyen191/goravel/admin"
+	"github.com/namnguyen191/goravel/analytics"
+	"github.com/namnguyen191/goravel/announcements"
+	"github.com/namnguyen191/goravel/apikeys"
+	"github.com/namnguyen191/goravel/assets"
+	"github.com/namnguyen191/goravel/auth"
+	"github.com/namnguyen191/goravel/backup"
+	"github.com/namnguyen191/goravel/billing"
+	"github.com/namnguyen191/goravel/boot"
+	"github.com/namnguyen191/goravel/bots"
+	"github.com/namnguyen191/goravel/breaker"
 	"github.com/namnguyen191/goravel/cache"
+	"github.com/namnguyen191/goravel/canonical"
+	"github.com/namnguyen191/goravel/cart"
+	"github.com/namnguyen191/goravel/cdn"
+	"github.com/namnguyen191/goravel/clientinfo"
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/comments"
+	"github.com/namnguyen191/goravel/concurrency"
+	"github.com/namnguyen191/goravel/database"
+	"github.com/namnguyen191/goravel/env"
+	"github.com/namnguyen191/goravel/experiments"
+	"github.com/namnguyen191/goravel/exports"
+	"github.com/namnguyen191/goravel/filesystems"
+	"github.com/namnguyen191/goravel/flags"
+	"github.com/namnguyen191/goravel/graceful"
+	"github.com/namnguyen191/goravel/inbound"
+	"github.com/namnguyen191/goravel/invitations"
+	"github.com/namnguyen191/goravel/invoices"
+	"github.com/namnguyen191/goravel/jobs"
+	"github.com/namnguyen191/goravel/kv"
+	"github.com/namnguyen191/goravel/leader"
+	"github.com/namnguyen191/goravel/links"
+	"github.com/namnguyen191/goravel/livereload"
+	"github.com/namnguyen191/goravel/logging"
 	"github.com/namnguyen191/goravel/mailer"
+	"github.com/namnguyen191/goravel/maintenance"
+	"github.com/namnguyen191/goravel/media"
+	"github.com/namnguyen191/goravel/meta"
+	"github.com/namnguyen191/goravel/monitor"
+	"github.com/namnguyen191/goravel/navigation"
+	"github.com/namnguyen191/goravel/oidc"
+	"github.com/namnguyen191/goravel/pagecache"
+	"github.com/namnguyen191/goravel/payments"
+	"github.com/namnguyen191/goravel/privacy"
+	"github.com/namnguyen191/goravel/push"
+	"github.com/namnguyen191/goravel/redisconn"
 	"github.com/namnguyen191/goravel/render"
-	"github.com/namnguyen191/goravel/session"
+	"github.com/namnguyen191/goravel/reports"
+	"github.com/namnguyen191/goravel/semaphore"
+	"github.com/namnguyen191/goravel/settings"
+	"github.com/namnguyen191/goravel/sms"
+	"github.com/namnguyen191/goravel/static"
+	"github.com/namnguyen191/goravel/tags"
+	"github.com/namnguyen191/goravel/teams"
+	"github.com/namnguyen191/goravel/tokens"
+	"github.com/namnguyen191/goravel/workflow"
 	"github.com/robfig/cron/v3"
 )
 
@@ -30,11 +85,13 @@ var redisPool *redis.Pool
 var badgerConn *badger.DB
 
 type Goravel struct {
-	AppName       string
-	Debug         bool
-	Version       string
-	ErrorLog      *log.Logger
-	InfoLog       *log.Logger
+	AppName  string
+	Debug    bool
+	Version  string
+	ErrorLog *log.Logger
+	InfoLog  *log.Logger
+	// Logger writes the records of InfoLog and ErrorLog, and the ones with levels and fields
+	Logger        *logging.Logger
 	RootPath      string
 	Routes        *chi.Mux
 	Render        *render.Render
@@ -43,10 +100,84 @@ type Goravel struct {
 	JetViews      *jet.Set
 	config        config
 	EncryptionKey string
+	// Namespace prefixes the redis and badger keys of the app so apps and environments can
+	// share one server; it is NAMESPACE, or REDIS_PREFIX, or APP_NAME
+	Namespace     string
 	Cache         cache.Cache
 	Scheduler     *cron.Cron
+	Clock         clock.Clock
 	Mail          mailer.Mail
 	Server        Server
+	Container     *Container
+	Navigation    *navigation.Navigation
+	Admin         *admin.Admin
+	Auth          *auth.Auth
+	Settings      *settings.Settings
+	Activity      *activity.Feed
+	Workflows     *workflow.Engine
+	Backup        *backup.Backup
+	Maintenance   *maintenance.Maintenance
+	Monitor       *monitor.Monitor
+	CDN           *cdn.CDN
+	BotGuard      *bots.Guard
+	ClientInfo    *clientinfo.ClientInfo
+	Analytics     *analytics.Analytics
+	Experiments   *experiments.Experiments
+	Announcements *announcements.Board
+	Exports       *exports.Exporter
+	Privacy       *privacy.Privacy
+	Invoices      *invoices.Invoices
+	Invitations   *invitations.Invitations
+	Teams         *teams.Teams
+	APIKeys       *apikeys.Keys
+	OIDC          *oidc.Provider
+	Reports       *reports.Reports
+	Payments      *payments.Payments
+	Billing       *billing.Billing
+	Cart          *cart.Carts
+	Comments      *comments.Comments
+	Tags          *tags.Taxonomy
+	Categories    *tags.Taxonomy
+	Media         *media.Library
+	Inbound       *inbound.Inbound
+	SMS           *sms.SMS
+	Push          *push.Push
+	Links         *links.Links
+	PageCache     *pagecache.PageCache
+	Leader        *leader.Elector
+	Concurrency   *concurrency.Concurrency
+	Tokens        *tokens.Tokens
+	// FileSystems are the stores of files by name, e.g. grv.FileSystems["s3"]
+	FileSystems map[string]filesystems.FileSystem
+	// Env reads configuration with defaults, e.g. grv.Env.Int("UPLOAD_LIMIT_MB", 10)
+	Env        *env.Reader
+	Assets     *assets.Assets
+	LiveReload *livereload.LiveReload
+	Canonical  *canonical.Canonical
+	Public     *static.Files
+	KV         *kv.KV
+	// Jobs runs work off the request path, e.g. app.Jobs.Push(ctx, "welcome-email", user)
+	Jobs *jobs.Jobs
+	// Boot started the subsystems, and reports how each went
+	Boot *boot.Boot
+	meta *meta.Meta
+	// Flags are the behavior changes opted into with GORAVEL_FLAGS
+	Flags      *flags.Flags
+	breakers   map[string]*breaker.Breaker
+	breakersMu sync.Mutex
+	// hooks run when the process starts working and when it stops, see OnBoot and OnShutdown
+	hooksMu       sync.Mutex
+	bootHooks     []func() error
+	shutdownHooks []func(ctx context.Context) error
+	// semaphoreStore keeps the permits of Semaphore, set up on first use
+	semaphoreStore semaphore.Store
+	semaphoreOnce  sync.Once
+	// jobsKV is the store of QUEUE=badger when KV is off
+	jobsKV *kv.KV
+	// NotFoundHandler, when set, replaces the default 404 response for unmatched routes
+	NotFoundHandler http.HandlerFunc
+	// MethodNotAllowedHandler, when set, replaces the default 405 response
+	MethodNotAllowedHandler http.HandlerFunc
 }
 
 type config struct {
@@ -58,6 +189,7 @@ type config struct {
 	sessionType string
 	database    databaseConfig
 	redis       redisConfig
+	router      routerConfig
 }
 
 type Server struct {
@@ -67,7 +199,9 @@ type Server struct {
 	URL        string
 }
 
-func (grv *Goravel) New(rootPath string) error {
+// New configures the app in rootPath from its .env and starts its subsystems; options leave
+// some out or replace them
+func (grv *Goravel) New(rootPath string, options ...Option) error {
 	pathConfig := initPaths{
 		rootPath:    rootPath,
 		folderNames: []string{"handlers", "migrations", "views", "mail", "data", "public", "tmp", "logs", "middleware"},
@@ -90,38 +224,10 @@ func (grv *Goravel) New(rootPath string) error {
 		return err
 	}
 
-	// connect to db
-	if os.Getenv("DATABASE_TYPE") != "" {
-		db, err := grv.OpenDB(os.Getenv("DATABASE_TYPE"), grv.BuildDSN())
-		if err != nil {
-			grv.ErrorLog.Println(err)
-			os.Exit(1)
-		}
-
-		grv.DB = Database{
-			DataBaseType: os.Getenv("DATABASE_TYPE"),
-			Pool:         db,
-		}
-	}
-
-	scheduler := cron.New()
-	grv.Scheduler = scheduler
-
-	// create cache
-	if os.Getenv("CACHE") == "redis" || os.Getenv("SESSION_TYPE") == "redis" {
-		myRedisCache = grv.createClientRedisCache()
-		grv.Cache = myRedisCache
-		redisPool = myRedisCache.Conn
-	}
-	if os.Getenv("CACHE") == "badger" {
-		myBadgerCache = grv.createClientBadgerCache()
-		grv.Cache = myBadgerCache
-		badgerConn = myBadgerCache.Conn
-
-		_, err := grv.Scheduler.AddFunc("@daily", func() {
-			myBadgerCache.Conn.RunValueLogGC(0.7)
-		})
-		if err != nil {
+	grv.Env = env.NewReader()
+	grv.createFlags()
+	if grv.Flags.Enabled(FlagStrictConfig) {
+		if err := checkConfig(); err != nil {
 			return err
 		}
 	}
@@ -130,94 +236,28 @@ func (grv *Goravel) New(rootPath string) error {
 	infoLog, errorLog := grv.startLoggers()
 	grv.InfoLog = infoLog
 	grv.ErrorLog = errorLog
+	grv.Env.ErrorLog = grv.ErrorLog.Println
+	grv.Concurrency = concurrency.New(grv.reportPanic)
+	grv.reportFlags()
 
-	grv.Debug, _ = strconv.ParseBool(os.Getenv("DEBUG"))
+	grv.Debug = grv.Env.Bool("DEBUG", false)
 	grv.Version = version
 	grv.RootPath = rootPath
+	grv.Namespace = namespace()
 
-	// create mail
-	grv.Mail = grv.createMailer()
-
-	grv.Routes = grv.routes().(*chi.Mux)
-
-	grv.config = config{
-		port:     os.Getenv("PORT"),
-		renderer: os.Getenv("RENDERER"),
-		cookie: cookieConfig{
-			name:     os.Getenv("COOKIE_NAME"),
-			lifetime: os.Getenv("COOKIE_LIFETIME"),
-			persist:  os.Getenv("COOKIE_PERSISTS"),
-			secure:   os.Getenv("COOKIE_SECURE"),
-			domain:   os.Getenv("COOKIE_DOMAIN"),
-		},
-		sessionType: os.Getenv("SESSION_TYPE"),
-		database: databaseConfig{
-			database: os.Getenv("DATABASE_TYPE"),
-			dsn:      grv.BuildDSN(),
-		},
-		redis: redisConfig{
-			host:     os.Getenv("REDIS_HOST"),
-			password: os.Getenv("REDIS_PASSWORD"),
-			prefix:   os.Getenv("REDIS_PREFIX"),
-		},
+	// the subsystems are started in the order of their dependencies, see bootSteps
+	grv.Boot = boot.New()
+	grv.Boot.Add(grv.bootSteps()...)
+	for _, option := range options {
+		option(grv)
 	}
 
-	secure := true
-	if strings.ToLower(os.Getenv("SECURE")) == "false" {
-		secure = false
-	}
-
-	grv.Server = Server{
-		ServerName: os.Getenv("SEVER_NAME"),
-		Port:       os.Getenv("PORT"),
-		Secure:     secure,
-		URL:        os.Getenv("APP_URL"),
-	}
-
-	// create a Session
-	sess := session.Session{
-		CookieLifeTime: grv.config.cookie.lifetime,
-		CookiePersist:  grv.config.cookie.persist,
-		CookieName:     grv.config.cookie.name,
-		SessionType:    grv.config.sessionType,
-		CookieDomain:   grv.config.cookie.domain,
-	}
-
-	switch grv.config.sessionType {
-	case "redis":
-		{
-			sess.RedisPool = myRedisCache.Conn
-		}
-	case "mysql", "postgres", "mariadb", "postgresql":
-		{
-			sess.DBPool = grv.DB.Pool
-		}
+	err = grv.Boot.Run(context.Background())
+	if err != nil || grv.Debug {
+		grv.logBootReport()
 	}
 
-	grv.Session = sess.InitSession()
-
-	grv.EncryptionKey = os.Getenv("KEY")
-
-	if grv.Debug {
-		var views = jet.NewSet(
-			jet.NewOSFileSystemLoader(fmt.Sprintf("%s/views", rootPath)),
-			jet.InDevelopmentMode(),
-		)
-
-		grv.JetViews = views
-	} else {
-		var views = jet.NewSet(
-			jet.NewOSFileSystemLoader(fmt.Sprintf("%s/views", rootPath)),
-		)
-
-		grv.JetViews = views
-	}
-
-	grv.createRenderer()
-
-	go grv.Mail.ListenForMail()
-
-	return nil
+	return err
 }
 
 func (grv *Goravel) Init(p initPaths) error {
@@ -246,22 +286,28 @@ func (grv *Goravel) ListenAndServe() {
 		WriteTimeout: 600 * time.Second,
 	}
 
-	// close DB when app close
-	if grv.DB.Pool != nil {
-		defer grv.DB.Pool.Close()
-	}
+	// SIGUSR2 starts the new binary on the same socket, which then drains this process
+	upgrader := graceful.New(srv.Addr)
+	upgrader.ReusePort = strings.ToLower(os.Getenv("REUSE_PORT")) == "true"
+	upgrader.PIDFile = os.Getenv("PID_FILE")
+	upgrader.Drain = grv.Env.Duration("DRAIN_TIMEOUT", 30*time.Second)
+	upgrader.ErrorLog = grv.InfoLog.Println
 
-	if redisPool != nil {
-		defer redisPool.Close()
-	}
+	grv.start()
 
-	if badgerConn != nil {
-		defer badgerConn.Close()
+	if graceful.Inherited() {
+		grv.InfoLog.Printf("Taking over port %s", os.Getenv("PORT"))
+	} else {
+		grv.InfoLog.Printf("Listening on port %s", os.Getenv("PORT"))
 	}
 
-	grv.InfoLog.Printf("Listening on port %s", os.Getenv("PORT"))
-	err := srv.ListenAndServe()
-	grv.ErrorLog.Fatal(err)
+	// the open requests are drained first, then the scheduler, mail and stores are shut down
+	err := upgrader.Serve(srv.Serve, srv.Shutdown)
+	grv.shutdown()
+	if err != nil && err != http.ErrServerClosed {
+		grv.ErrorLog.Fatal(err)
+	}
+	grv.InfoLog.Println("Server stopped")
 }
 
 func (grv *Goravel) checkDotEnv(path string) error {
@@ -292,16 +338,6 @@ func (grv *Goravel) CreateFileIfNotExist(path string) error {
 	return nil
 }
 
-func (grv *Goravel) startLoggers() (*log.Logger, *log.Logger) {
-	var infoLog *log.Logger
-	var errorLog *log.Logger
-
-	infoLog = log.New(os.Stdout, "INFO\t", log.Ldate|log.Ltime)
-	errorLog = log.New(os.Stdout, "ERROR\t", log.Ldate|log.Ltime|log.Lshortfile)
-
-	return infoLog, errorLog
-}
-
 func (grv *Goravel) createRenderer() {
 	myRenderer := render.Render{
 		Renderer: grv.config.renderer,
@@ -314,93 +350,142 @@ func (grv *Goravel) createRenderer() {
 	grv.Render = &myRenderer
 }
 
-func (grv *Goravel) createMailer() mailer.Mail {
-	port, _ := strconv.Atoi(os.Getenv("SMTP_PORT"))
+func (grv *Goravel) createMailer() (mailer.Mail, error) {
 	m := mailer.Mail{
-		Domain:      os.Getenv("MAIL_DOMAIN"),
+		Domain:      grv.Env.String("MAIL_DOMAIN", ""),
 		Templates:   grv.RootPath + "/mail",
-		Host:        os.Getenv("SMTP_HOST"),
-		Port:        port,
-		Username:    os.Getenv("SMTP_USERNAME"),
-		Password:    os.Getenv("SMTP_PASSWORD"),
-		Encryption:  os.Getenv("SMTP_ENCRYPTION"),
-		FromName:    os.Getenv("FROM_NAME"),
-		FromAddress: os.Getenv("FROM_ADDRESS"),
+		Host:        grv.Env.String("SMTP_HOST", ""),
+		Port:        grv.Env.Int("SMTP_PORT", 0),
+		Username:    grv.Env.String("SMTP_USERNAME", ""),
+		Password:    grv.Env.String("SMTP_PASSWORD", ""),
+		Encryption:  grv.Env.String("SMTP_ENCRYPTION", ""),
+		FromName:    grv.Env.String("FROM_NAME", ""),
+		FromAddress: grv.Env.String("FROM_ADDRESS", ""),
 		Jobs:        make(chan mailer.Message, 20),
 		Results:     make(chan mailer.Result, 20),
-		API:         os.Getenv("MAILER_API"),
-		APIKey:      os.Getenv("MAILER_KEY"),
-		APIUrl:      os.Getenv("MAILER_URL"),
+		API:         grv.Env.String("MAILER_API", ""),
+		APIKey:      grv.Env.String("MAILER_KEY", ""),
+		APIUrl:      grv.Env.String("MAILER_URL", ""),
 	}
 
-	return m
-}
+	if m.API != "" && m.API != "smtp" {
+		m.Breaker = grv.Breaker("mail-api")
+	}
 
-func (grv *Goravel) createClientRedisCache() *cache.RedisCache {
-	cacheClient := cache.RedisCache{
-		Conn:   grv.createRedisPool(),
-		Prefix: grv.config.redis.prefix,
+	if name := grv.Env.String("MAIL_TRANSPORT", ""); name != "" {
+		if err := m.UseTransport(name); err != nil {
+			return m, err
+		}
+	}
+
+	perSecond := grv.Env.Float("MAIL_RATE_PER_SECOND", 0)
+	perDay := grv.Env.Int("MAIL_DAILY_QUOTA", 0)
+	if perSecond > 0 || perDay > 0 {
+		m.Limits = map[string]*mailer.RateLimit{
+			m.Transport(): {PerSecond: perSecond, PerDay: perDay, OnQuota: grv.mailQuotaAlert},
+		}
 	}
 
-	return &cacheClient
+	return m, nil
 }
 
-func (grv *Goravel) createClientBadgerCache() *cache.BadgerCache {
-	cacheClient := cache.BadgerCache{
-		Conn:   grv.createBadgerConn(),
-		Prefix: grv.config.redis.prefix,
+// createBotGuard reads BOTS_FILTER: "off", "tag" (default, bots get no session), "throttle"
+// (bots are also limited to BOTS_RATE_LIMIT requests a minute) or "block" (bad bots get 403 too)
+func (grv *Goravel) createBotGuard() *bots.Guard {
+	mode := strings.ToLower(os.Getenv("BOTS_FILTER"))
+	if mode == "off" {
+		return nil
+	}
+
+	guard := bots.NewGuard()
+
+	switch mode {
+	case "block":
+		guard.Block = true
+		guard.Limit = grv.Env.Int("BOTS_RATE_LIMIT", 60)
+	case "throttle":
+		guard.Limit = grv.Env.Int("BOTS_RATE_LIMIT", 60)
 	}
 
-	return &cacheClient
+	return guard
 }
 
-func (grv *Goravel) createRedisPool() *redis.Pool {
-	return &redis.Pool{
-		MaxIdle:     50,
-		MaxActive:   10000,
-		IdleTimeout: 240 * time.Second,
-		Dial: func() (redis.Conn, error) {
-			return redis.Dial(
-				"tcp",
-				grv.config.redis.host,
-				redis.DialPassword(grv.config.redis.password),
-			)
-		},
-		TestOnBorrow: func(c redis.Conn, t time.Time) error {
-			_, err := c.Do("PING")
+func (grv *Goravel) createClientRedisCache() (*cache.RedisCache, error) {
+	pool, err := grv.createRedisPool()
+	if err != nil {
+		return nil, err
+	}
+
+	cacheClient := cache.RedisCache{
+		Conn:   pool,
+		Prefix: grv.Namespace,
+	}
 
-			return err
-		},
+	return &cacheClient, nil
+}
+
+// logFailover reports a redis backed component switching to or from its in-memory fallback
+func (grv *Goravel) logFailover(component string) func(bool, error) {
+	return func(degraded bool, err error) {
+		if degraded {
+			grv.ErrorLog.Printf("REDIS UNAVAILABLE: %s running in degraded mode (in-memory fallback): %v", component, err)
+			return
+		}
+		grv.InfoLog.Printf("redis is back, %s restored", component)
 	}
 }
 
-func (grv *Goravel) createBadgerConn() *badger.DB {
-	db, err := badger.Open(badger.DefaultOptions(grv.RootPath + "/tmp/badger"))
+func (grv *Goravel) createClientBadgerCache() (*cache.BadgerCache, error) {
+	conn, err := grv.createBadgerConn()
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
-	return db
+	cacheClient := cache.BadgerCache{
+		Conn:   conn,
+		Prefix: grv.Namespace,
+	}
+
+	return &cacheClient, nil
+}
+
+// createRedisPool connects to REDIS_HOST, a host:port or a redis:// or rediss:// url, or to
+// the master named by REDIS_SENTINELS, or to the cluster of REDIS_CLUSTER_NODES
+func (grv *Goravel) createRedisPool() (*redis.Pool, error) {
+	return redisconn.NewPool(redisconn.Config{
+		Addr:             grv.config.redis.host,
+		Username:         grv.Env.String("REDIS_USERNAME", ""),
+		Password:         grv.config.redis.password,
+		TLS:              grv.Env.Bool("REDIS_TLS", false),
+		TLSSkipVerify:    grv.Env.Bool("REDIS_TLS_SKIP_VERIFY", false),
+		Sentinels:        grv.config.redis.sentinels,
+		MasterName:       grv.Env.String("REDIS_SENTINEL_MASTER", "mymaster"),
+		SentinelPassword: grv.Env.String("REDIS_SENTINEL_PASSWORD", ""),
+		Cluster:          grv.config.redis.cluster,
+		MaxIdle:          grv.Env.Int("REDIS_MAX_IDLE", 50),
+		MaxActive:        grv.Env.Int("REDIS_MAX_ACTIVE", 10000),
+		IdleTimeout:      time.Duration(grv.Env.Int("REDIS_IDLE_TIMEOUT", 240)) * time.Second,
+	})
+}
+
+func (grv *Goravel) createBadgerConn() (*badger.DB, error) {
+	return badger.Open(badger.DefaultOptions(grv.RootPath + "/tmp/badger"))
 }
 
+// BuildDSN builds the data source name of DATABASE_TYPE from the DATABASE_* settings, with
+// the DSN builder of the driver registered with database.Register
 func (grv *Goravel) BuildDSN() string {
-	var dsn string
-
-	switch os.Getenv("DATABASE_TYPE") {
-	case "postgres", "postgresql":
-		dsn = fmt.Sprintf("host=%s port=%s user=%s dbname=%s sslmode=%s timezone=UTC connect_timeout=5",
-			os.Getenv("DATABASE_HOST"),
-			os.Getenv("DATABASE_PORT"),
-			os.Getenv("DATABASE_USER"),
-			os.Getenv("DATABASE_NAME"),
-			os.Getenv("DATABASE_SSL_MODE"),
-		)
-
-		if os.Getenv("DATABASE_PASS") != "" {
-			dsn = fmt.Sprintf("%s password=%s", dsn, os.Getenv("DATABASE_PASS"))
-		}
-	default:
+	d, ok := database.Lookup(os.Getenv("DATABASE_TYPE"))
+	if !ok {
+		return ""
 	}
 
-	return dsn
+	return d.DSN(database.Config{
+		Host:     os.Getenv("DATABASE_HOST"),
+		Port:     os.Getenv("DATABASE_PORT"),
+		User:     os.Getenv("DATABASE_USER"),
+		Password: os.Getenv("DATABASE_PASS"),
+		Name:     os.Getenv("DATABASE_NAME"),
+		SSLMode:  os.Getenv("DATABASE_SSL_MODE"),
+	})
 }
@@ -0,0 +1,183 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+const (
+	// envFD names the descriptor of the listener handed down by the previous process
+	envFD = "GORAVEL_LISTEN_FD"
+	// envParent is the pid of the previous process, told to drain once the new one serves
+	envParent = "GORAVEL_PARENT_PID"
+)
+
+// ErrUnsupported is returned on platforms without the signals or socket options needed
+var ErrUnsupported = errors.New("graceful: not supported on this platform")
+
+// Upgrader replaces the running binary without dropping connections. On Upgrade the new
+// binary is started with the listening socket; once it serves, it asks the old process to
+// stop accepting and finish its open requests. With ReusePort both can also bind the port
+// on their own, e.g. when the new process is started by a deploy script.
+type Upgrader struct {
+	Addr string
+	// ReusePort sets SO_REUSEPORT on new listeners
+	ReusePort bool
+	// PIDFile, when set, is updated with the pid of the serving process, for service
+	// managers which track the main process with a pid file
+	PIDFile string
+	// Drain is how long the old process waits for open requests
+	Drain    time.Duration
+	ErrorLog func(v ...interface{})
+
+	ln   *net.TCPListener
+	done chan struct{}
+}
+
+// New returns an upgrader for a server listening on addr
+func New(addr string) *Upgrader {
+	return &Upgrader{Addr: addr, Drain: 30 * time.Second, ErrorLog: log.Println, done: make(chan struct{})}
+}
+
+// Inherited reports whether this process was started by Upgrade
+func Inherited() bool {
+	return os.Getenv(envFD) != ""
+}
+
+// Listen returns the listener handed down by the previous process, or binds Addr
+func (u *Upgrader) Listen() (net.Listener, error) {
+	if fd := os.Getenv(envFD); fd != "" {
+		n, err := strconv.Atoi(fd)
+		if err != nil {
+			return nil, fmt.Errorf("graceful: %s: %w", envFD, err)
+		}
+
+		f := os.NewFile(uintptr(n), "listener")
+		ln, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("graceful: inherited listener: %w", err)
+		}
+
+		tcp, ok := ln.(*net.TCPListener)
+		if !ok {
+			return nil, fmt.Errorf("graceful: inherited listener is %T, not tcp", ln)
+		}
+		u.ln = tcp
+		return tcp, nil
+	}
+
+	lc := net.ListenConfig{}
+	if u.ReusePort {
+		lc.Control = reusePort
+	}
+
+	ln, err := lc.Listen(context.Background(), "tcp", u.Addr)
+	if err != nil {
+		return nil, err
+	}
+	u.ln = ln.(*net.TCPListener)
+
+	return u.ln, nil
+}
+
+// Ready is called once the server accepts connections; it records the pid and tells the
+// previous process, if any, to drain
+func (u *Upgrader) Ready() error {
+	if u.PIDFile != "" {
+		if err := os.WriteFile(u.PIDFile, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+			return err
+		}
+	}
+
+	parent, _ := strconv.Atoi(os.Getenv(envParent))
+	if parent <= 0 {
+		return nil
+	}
+
+	// children of this process must not mistake it for an upgrade in progress
+	_ = os.Unsetenv(envFD)
+	_ = os.Unsetenv(envParent)
+
+	return stopParent(parent)
+}
+
+// Upgrade starts the current executable again with the listening socket. The new process
+// tells this one to drain through Ready; if it fails to start, this one keeps serving.
+func (u *Upgrader) Upgrade() (int, error) {
+	if u.ln == nil {
+		return 0, errors.New("graceful: not listening")
+	}
+
+	f, err := u.ln.File()
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, err
+	}
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// ExtraFiles start at descriptor 3, after stdin, stdout and stderr
+	cmd.ExtraFiles = []*os.File{f}
+	cmd.Env = append(os.Environ(), envFD+"=3", envParent+"="+strconv.Itoa(os.Getpid()))
+
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+
+	// the child is not waited on; this process exits once it drained, leaving it running
+	go func() { _ = cmd.Wait() }()
+
+	return cmd.Process.Pid, nil
+}
+
+// Serve runs serve with the listener, upgrading on SIGUSR2 and draining with shutdown on
+// SIGTERM or SIGINT, which the new process sends once it took over. It returns when the
+// server stopped and open requests finished or Drain ran out.
+func (u *Upgrader) Serve(serve func(net.Listener) error, shutdown func(context.Context) error) error {
+	ln, err := u.Listen()
+	if err != nil {
+		return err
+	}
+
+	errs := make(chan error, 1)
+	go func() { errs <- serve(ln) }()
+
+	if err := u.Ready(); err != nil {
+		u.ErrorLog("graceful:", err)
+	}
+
+	upgrade, stop, cancel := notify()
+	defer cancel()
+
+	for {
+		select {
+		case err := <-errs:
+			return err
+		case <-upgrade:
+			if pid, err := u.Upgrade(); err != nil {
+				u.ErrorLog("graceful: upgrade failed, still serving:", err)
+			} else {
+				u.ErrorLog("graceful: started", pid, "to take over")
+			}
+		case <-stop:
+			ctx, done := context.WithTimeout(context.Background(), u.Drain)
+			err := shutdown(ctx)
+			done()
+			return err
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package graceful
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestReusePort(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SO_REUSEPORT is not available")
+	}
+
+	a := New("127.0.0.1:0")
+	a.ReusePort = true
+	ln, err := a.Listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	b := New(ln.Addr().String())
+	b.ReusePort = true
+	ln2, err := b.Listen()
+	if err != nil {
+		t.Fatalf("expected a second listener on the same port, got %v", err)
+	}
+	ln2.Close()
+}
+
+func TestInheritedListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	f, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	os.Setenv(envFD, strconv.Itoa(int(f.Fd())))
+	defer os.Unsetenv(envFD)
+
+	if !Inherited() {
+		t.Fatal("expected the listener to be inherited")
+	}
+
+	u := New(":0")
+	got, err := u.Listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Close()
+
+	if got.Addr().String() != ln.Addr().String() {
+		t.Errorf("expected %s, got %s", ln.Addr(), got.Addr())
+	}
+}
+
+func TestReadyWritesPIDFile(t *testing.T) {
+	u := New(":0")
+	u.PIDFile = filepath.Join(t.TempDir(), "app.pid")
+	if err := u.Ready(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(u.PIDFile)
+	if err != nil || strings.TrimSpace(string(b)) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("unexpected pid file %q %v", b, err)
+	}
+}
@@ -0,0 +1,27 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package graceful
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func reusePort(network, address string, c syscall.RawConn) error {
+	return ErrUnsupported
+}
+
+func stopParent(pid int) error {
+	return ErrUnsupported
+}
+
+// without SIGUSR2 there is no upgrade signal, but interrupts still drain the server
+func notify() (upgrade, stop chan os.Signal, cancel func()) {
+	upgrade = make(chan os.Signal)
+	stop = make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+
+	return upgrade, stop, func() { signal.Stop(stop) }
+}
@@ -0,0 +1,38 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package graceful
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+func reusePort(network, address string, c syscall.RawConn) error {
+	var err error
+	if cerr := c.Control(func(fd uintptr) {
+		err = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+	}); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+func stopParent(pid int) error {
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
+
+func notify() (upgrade, stop chan os.Signal, cancel func()) {
+	upgrade = make(chan os.Signal, 1)
+	stop = make(chan os.Signal, 1)
+	signal.Notify(upgrade, syscall.SIGUSR2)
+	signal.Notify(stop, syscall.SIGTERM, os.Interrupt)
+
+	return upgrade, stop, func() {
+		signal.Stop(upgrade)
+		signal.Stop(stop)
+	}
+}
@@ -0,0 +1,422 @@
+package ical
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Methods of a calendar sent by email (RFC 5546); Publish is for plain downloads
+const (
+	Publish = "PUBLISH"
+	Request = "REQUEST"
+	Cancel  = "CANCEL"
+)
+
+// Event statuses
+const (
+	Confirmed = "CONFIRMED"
+	Tentative = "TENTATIVE"
+	Cancelled = "CANCELLED"
+)
+
+// Recurrence frequencies
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+// Calendar is a VCALENDAR of events
+type Calendar struct {
+	ProdID string
+	Name   string
+	Method string
+	Events []Event
+}
+
+// Person is an organizer or attendee
+type Person struct {
+	Name  string
+	Email string
+	// RSVP asks an attendee to reply
+	RSVP bool
+}
+
+// Rule is a recurrence rule, e.g. Rule{Freq: Weekly, ByDay: []string{"MO", "WE"}, Count: 10}
+type Rule struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []string
+	ByMonthDay []int
+}
+
+// Alarm reminds attendees Before the start of an event
+type Alarm struct {
+	Before      time.Duration
+	Description string
+}
+
+// Event is a VEVENT. Times keep their location: UTC and local times are written in UTC, other
+// locations with their TZID and a VTIMEZONE. All day events only use the dates of Start and End.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	Status      string
+	// Sequence is incremented for every update of an invite already sent
+	Sequence  int
+	Organizer *Person
+	Attendees []Person
+	Rule      *Rule
+	// Exclude lists occurrences of the rule which do not take place
+	Exclude []time.Time
+	Alarms  []Alarm
+	Stamp   time.Time
+}
+
+// New returns a calendar of events
+func New(name string, events ...Event) *Calendar {
+	return &Calendar{ProdID: "-//goravel//ical//EN", Name: name, Method: Publish, Events: events}
+}
+
+// Add appends an event, giving it a UID when it has none
+func (c *Calendar) Add(e Event) *Event {
+	if e.UID == "" {
+		b := make([]byte, 16)
+		rand.Read(b)
+		e.UID = hex.EncodeToString(b)
+	}
+	c.Events = append(c.Events, e)
+	return &c.Events[len(c.Events)-1]
+}
+
+// ContentType is the media type of the calendar, with its method as mail clients expect
+func (c *Calendar) ContentType() string {
+	return "text/calendar; charset=utf-8; method=" + c.method()
+}
+
+func (c *Calendar) method() string {
+	if c.Method == "" {
+		return Publish
+	}
+	return c.Method
+}
+
+// Bytes returns the calendar in iCalendar format
+func (c *Calendar) Bytes() []byte {
+	var buf bytes.Buffer
+	c.Encode(&buf)
+	return buf.Bytes()
+}
+
+// Encode writes the calendar in iCalendar format
+func (c *Calendar) Encode(w io.Writer) error {
+	l := &lines{w: w}
+
+	l.add("BEGIN:VCALENDAR")
+	l.add("VERSION:2.0")
+	prodID := c.ProdID
+	if prodID == "" {
+		prodID = "-//goravel//ical//EN"
+	}
+	l.add("PRODID:" + prodID)
+	l.add("CALSCALE:GREGORIAN")
+	l.add("METHOD:" + c.method())
+	if c.Name != "" {
+		l.add("X-WR-CALNAME:" + escape(c.Name))
+	}
+
+	for _, tz := range c.zones() {
+		tz.encode(l)
+	}
+
+	for _, e := range c.Events {
+		e.encode(l)
+	}
+
+	l.add("END:VCALENDAR")
+
+	return l.err
+}
+
+// Write sends the calendar as a response; with a filename it is downloaded as an attachment
+func (c *Calendar) Write(rw http.ResponseWriter, filename string) error {
+	rw.Header().Set("Content-Type", c.ContentType())
+	if filename != "" {
+		rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	}
+
+	_, err := rw.Write(c.Bytes())
+	return err
+}
+
+// zones returns the time zones used by the events, spanning the years of their times
+func (c *Calendar) zones() []zone {
+	found := map[string]*zone{}
+
+	use := func(t time.Time) {
+		if t.IsZero() || !zoned(t) {
+			return
+		}
+		name := t.Location().String()
+		z, ok := found[name]
+		if !ok {
+			z = &zone{loc: t.Location(), from: t.Year(), to: t.Year()}
+			found[name] = z
+		}
+		if t.Year() < z.from {
+			z.from = t.Year()
+		}
+		if t.Year() > z.to {
+			z.to = t.Year()
+		}
+	}
+
+	for _, e := range c.Events {
+		if e.AllDay {
+			continue
+		}
+		use(e.Start)
+		use(e.End)
+		for _, t := range e.Exclude {
+			use(t)
+		}
+		if e.Rule != nil && !e.Rule.Until.IsZero() {
+			use(e.Rule.Until.In(e.Start.Location()))
+		}
+	}
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	zones := make([]zone, 0, len(names))
+	for _, name := range names {
+		zones = append(zones, *found[name])
+	}
+
+	return zones
+}
+
+func (e Event) encode(l *lines) {
+	stamp := e.Stamp
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+
+	l.add("BEGIN:VEVENT")
+	l.add("UID:" + escape(e.UID))
+	l.add("DTSTAMP:" + stamp.UTC().Format(utcFormat))
+
+	if e.AllDay {
+		end := e.End
+		if end.IsZero() || !end.After(e.Start) {
+			end = e.Start.AddDate(0, 0, 1)
+		}
+		l.add("DTSTART;VALUE=DATE:" + e.Start.Format(dateFormat))
+		l.add("DTEND;VALUE=DATE:" + end.Format(dateFormat))
+	} else {
+		l.add(datetime("DTSTART", e.Start))
+		if !e.End.IsZero() {
+			l.add(datetime("DTEND", e.End))
+		}
+	}
+
+	if e.Rule != nil {
+		l.add("RRULE:" + e.Rule.String())
+	}
+	for _, t := range e.Exclude {
+		if e.AllDay {
+			l.add("EXDATE;VALUE=DATE:" + t.Format(dateFormat))
+		} else {
+			l.add(datetime("EXDATE", t.In(e.Start.Location())))
+		}
+	}
+
+	l.add("SUMMARY:" + escape(e.Summary))
+	if e.Description != "" {
+		l.add("DESCRIPTION:" + escape(e.Description))
+	}
+	if e.Location != "" {
+		l.add("LOCATION:" + escape(e.Location))
+	}
+	if e.URL != "" {
+		l.add("URL:" + e.URL)
+	}
+	if e.Status != "" {
+		l.add("STATUS:" + e.Status)
+	}
+	l.add(fmt.Sprintf("SEQUENCE:%d", e.Sequence))
+
+	if e.Organizer != nil {
+		l.add("ORGANIZER" + cn(e.Organizer.Name) + ":mailto:" + e.Organizer.Email)
+	}
+	for _, a := range e.Attendees {
+		line := "ATTENDEE" + cn(a.Name) + ";ROLE=REQ-PARTICIPANT;PARTSTAT=NEEDS-ACTION"
+		if a.RSVP {
+			line += ";RSVP=TRUE"
+		}
+		l.add(line + ":mailto:" + a.Email)
+	}
+
+	for _, a := range e.Alarms {
+		description := a.Description
+		if description == "" {
+			description = e.Summary
+		}
+		l.add("BEGIN:VALARM")
+		l.add("ACTION:DISPLAY")
+		l.add("DESCRIPTION:" + escape(description))
+		l.add("TRIGGER:" + duration(-a.Before))
+		l.add("END:VALARM")
+	}
+
+	l.add("END:VEVENT")
+}
+
+// String formats the rule as an RRULE value
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	} else if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(utcFormat))
+	}
+	if len(r.ByDay) > 0 {
+		parts = append(parts, "BYDAY="+strings.ToUpper(strings.Join(r.ByDay, ",")))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			days[i] = fmt.Sprint(d)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+const (
+	utcFormat   = "20060102T150405Z"
+	localFormat = "20060102T150405"
+	dateFormat  = "20060102"
+)
+
+// zoned reports whether a time is written with its TZID rather than in UTC
+func zoned(t time.Time) bool {
+	loc := t.Location()
+	return loc != time.UTC && loc != time.Local && loc.String() != "UTC"
+}
+
+func datetime(name string, t time.Time) string {
+	if !zoned(t) {
+		return name + ":" + t.UTC().Format(utcFormat)
+	}
+	return name + ";TZID=" + t.Location().String() + ":" + t.Format(localFormat)
+}
+
+// duration formats a duration as an iCalendar value, e.g. -PT15M or -P1D
+func duration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+
+	if d == 0 {
+		return "PT0S"
+	}
+
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	out := sign + "P"
+	if days > 0 {
+		out += fmt.Sprintf("%dD", days)
+	}
+	if d > 0 {
+		out += "T"
+		if h := d / time.Hour; h > 0 {
+			out += fmt.Sprintf("%dH", h)
+			d -= h * time.Hour
+		}
+		if m := d / time.Minute; m > 0 {
+			out += fmt.Sprintf("%dM", m)
+			d -= m * time.Minute
+		}
+		if s := d / time.Second; s > 0 {
+			out += fmt.Sprintf("%dS", s)
+		}
+	}
+
+	return out
+}
+
+func cn(name string) string {
+	if name == "" {
+		return ""
+	}
+	return ";CN=" + quote(name)
+}
+
+// quote makes a parameter value safe, quoting it when it holds separators
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `"`, "'")
+	if strings.ContainsAny(s, ";:,") {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// lines writes content lines, folded at 75 octets and ended by CRLF
+type lines struct {
+	w   io.Writer
+	err error
+}
+
+func (l *lines) add(line string) {
+	if l.err != nil {
+		return
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		n := utf8.RuneLen(r)
+		if width+n > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += n
+	}
+	b.WriteString("\r\n")
+
+	_, l.err = io.WriteString(l.w, b.String())
+}
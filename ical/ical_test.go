@@ -0,0 +1,94 @@
+package ical
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncode(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("no time zone database")
+	}
+
+	start := time.Date(2021, 7, 1, 10, 0, 0, 0, paris)
+	c := New("Bookings")
+	c.Method = Request
+	c.Add(Event{
+		UID:         "booking-1@example.com",
+		Summary:     "Haircut, with Ann",
+		Description: "Bring your card;\nsee you soon",
+		Start:       start,
+		End:         start.Add(time.Hour),
+		Organizer:   &Person{Name: "Shop", Email: "shop@example.com"},
+		Attendees:   []Person{{Name: "Lee, Bo", Email: "bo@example.com", RSVP: true}},
+		Rule:        &Rule{Freq: Weekly, Interval: 2, Count: 5, ByDay: []string{"th"}},
+		Exclude:     []time.Time{start.AddDate(0, 0, 14)},
+		Alarms:      []Alarm{{Before: 90 * time.Minute}},
+		Stamp:       time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+	})
+
+	// unfold before matching
+	out := strings.ReplaceAll(string(c.Bytes()), "\r\n ", "")
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"METHOD:REQUEST\r\n",
+		"BEGIN:VTIMEZONE\r\nTZID:Europe/Paris\r\n",
+		"BEGIN:DAYLIGHT\r\nDTSTART:20210328T020000\r\nTZOFFSETFROM:+0100\r\nTZOFFSETTO:+0200\r\n",
+		"BEGIN:STANDARD\r\nDTSTART:20211031T030000\r\nTZOFFSETFROM:+0200\r\nTZOFFSETTO:+0100\r\n",
+		"DTSTAMP:20210601T000000Z\r\n",
+		"DTSTART;TZID=Europe/Paris:20210701T100000\r\n",
+		"DTEND;TZID=Europe/Paris:20210701T110000\r\n",
+		"RRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=5;BYDAY=TH\r\n",
+		"EXDATE;TZID=Europe/Paris:20210715T100000\r\n",
+		"SUMMARY:Haircut\\, with Ann\r\n",
+		"DESCRIPTION:Bring your card\\;\\nsee you soon\r\n",
+		"ORGANIZER;CN=Shop:mailto:shop@example.com\r\n",
+		"ATTENDEE;CN=\"Lee, Bo\";ROLE=REQ-PARTICIPANT;PARTSTAT=NEEDS-ACTION;RSVP=TRUE:mailto:bo@example.com\r\n",
+		"BEGIN:VALARM\r\nACTION:DISPLAY\r\nDESCRIPTION:Haircut\\, with Ann\r\nTRIGGER:-PT1H30M\r\nEND:VALARM\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+}
+
+func TestAllDayAndUTC(t *testing.T) {
+	c := New("")
+	e := c.Add(Event{Summary: "Holiday", Start: time.Date(2021, 12, 25, 0, 0, 0, 0, time.UTC), AllDay: true})
+	c.Add(Event{Summary: "Call", Start: time.Date(2021, 12, 1, 9, 30, 0, 0, time.UTC)})
+
+	out := string(c.Bytes())
+	if e.UID == "" || strings.Contains(out, "VTIMEZONE") {
+		t.Errorf("unexpected calendar\n%s", out)
+	}
+	if !strings.Contains(out, "DTSTART;VALUE=DATE:20211225\r\nDTEND;VALUE=DATE:20211226\r\n") || !strings.Contains(out, "DTSTART:20211201T093000Z\r\n") {
+		t.Errorf("unexpected times\n%s", out)
+	}
+}
+
+func TestFolding(t *testing.T) {
+	c := New("")
+	c.Add(Event{Summary: strings.Repeat("é", 60), Start: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)})
+
+	for _, line := range strings.Split(string(c.Bytes()), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line of %d octets: %q", len(line), line)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	rr := httptest.NewRecorder()
+	c := New("")
+	if err := c.Write(rr, "invite.ics"); err != nil {
+		t.Fatal(err)
+	}
+
+	if rr.Header().Get("Content-Type") != "text/calendar; charset=utf-8; method=PUBLISH" || rr.Header().Get("Content-Disposition") != `attachment; filename="invite.ics"` {
+		t.Errorf("unexpected headers %v", rr.Header())
+	}
+}
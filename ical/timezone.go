@@ -0,0 +1,98 @@
+package ical
+
+import (
+	"fmt"
+	"time"
+)
+
+// zone is a VTIMEZONE built from the Go location database for the years an event spans
+type zone struct {
+	loc      *time.Location
+	from, to int
+}
+
+type transition struct {
+	at         time.Time
+	fromOffset int
+	toOffset   int
+	name       string
+	dst        bool
+}
+
+// transitions returns the offset changes of the location between the start of from and the end of to
+func (z zone) transitions() []transition {
+	var out []transition
+
+	t := time.Date(z.from, 1, 1, 0, 0, 0, 0, z.loc)
+	end := time.Date(z.to+1, 1, 1, 0, 0, 0, 0, z.loc)
+	_, offset := t.Zone()
+
+	for t.Before(end) {
+		next := t.Add(24 * time.Hour)
+		if _, o := next.Zone(); o != offset {
+			// narrow the change down to the second
+			lo, hi := t, next
+			for hi.Sub(lo) > time.Second {
+				mid := lo.Add(hi.Sub(lo) / 2)
+				if _, o := mid.Zone(); o == offset {
+					lo = mid
+				} else {
+					hi = mid
+				}
+			}
+
+			name, o := hi.Zone()
+			out = append(out, transition{at: hi, fromOffset: offset, toOffset: o, name: name, dst: hi.IsDST()})
+			offset = o
+		}
+		t = next
+	}
+
+	return out
+}
+
+func (z zone) encode(l *lines) {
+	l.add("BEGIN:VTIMEZONE")
+	l.add("TZID:" + z.loc.String())
+
+	list := z.transitions()
+	if len(list) == 0 {
+		name, offset := time.Date(z.from, 1, 1, 0, 0, 0, 0, z.loc).Zone()
+		list = []transition{{at: time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), fromOffset: offset, toOffset: offset, name: name}}
+	}
+
+	for _, tr := range list {
+		kind := "STANDARD"
+		if tr.dst {
+			kind = "DAYLIGHT"
+		}
+
+		// DTSTART is the wall clock time of the change in the offset in use before it
+		start := tr.at.UTC().Add(time.Duration(tr.fromOffset) * time.Second)
+
+		l.add("BEGIN:" + kind)
+		l.add("DTSTART:" + start.Format(localFormat))
+		l.add("TZOFFSETFROM:" + utcOffset(tr.fromOffset))
+		l.add("TZOFFSETTO:" + utcOffset(tr.toOffset))
+		l.add("TZNAME:" + escape(tr.name))
+		l.add("END:" + kind)
+	}
+
+	l.add("END:VTIMEZONE")
+}
+
+// utcOffset formats seconds east of UTC, e.g. +0100 or -0330
+func utcOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+
+	out := fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds%3600/60)
+	if s := seconds % 60; s != 0 {
+		out += fmt.Sprintf("%02d", s)
+	}
+
+	return out
+}
@@ -0,0 +1,42 @@
+package goravel
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/namnguyen191/goravel/imports"
+	"github.com/namnguyen191/goravel/jobs"
+)
+
+// Importer returns an importer into table whose background imports are jobs of the queue; create
+// it at every boot, so the workers have the handler of its jobs
+func (grv *Goravel) Importer(table string, mapping map[string]string) *imports.Importer {
+	im := imports.New(grv.DB.Pool, grv.DB.DataBaseType, table, mapping)
+	im.Dispatch = func(ctx context.Context, src *imports.Source) error {
+		// the chunks inserted before a failure are kept, so the import is not tried again
+		_, err := grv.Jobs.Push(ctx, im.Job(), src, jobs.Attempts(1))
+		return err
+	}
+
+	grv.Jobs.HandleFunc(im.Job(), func(ctx context.Context, job *jobs.Job) error {
+		var src imports.Source
+		if err := job.Decode(&src); err != nil {
+			return err
+		}
+		return im.Import(ctx, &src)
+	})
+
+	return im
+}
+
+// ImportValidator adapts validation rules to the imports package, so rows are checked
+// with the same helpers as forms; each row is presented as the form of r.
+func (grv *Goravel) ImportValidator(rules func(v *Validation, r *http.Request)) func(url.Values) map[string]string {
+	return func(values url.Values) map[string]string {
+		v := grv.Validator(values)
+		rules(v, &http.Request{Form: values})
+
+		return v.Error
+	}
+}
@@ -0,0 +1,286 @@
+package imports
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/namnguyen191/goravel/database"
+	"github.com/namnguyen191/goravel/xlsx"
+)
+
+// ErrUnknownColumn is returned when the mapping names a column missing from the file
+var ErrUnknownColumn = errors.New("imports: mapped column not found in file")
+
+// Source holds the parsed rows of an uploaded file
+type Source struct {
+	Header []string
+	Rows   [][]string
+}
+
+// RowError is a validation failure of a single row; Row counts from 1 after the header
+type RowError struct {
+	Row     int
+	Field   string
+	Message string
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("row %d: %s: %s", e.Row, e.Field, e.Message)
+}
+
+// Report summarizes an import run
+type Report struct {
+	Total    int
+	Imported int
+	Failed   int
+	DryRun   bool
+	Errors   []RowError
+}
+
+// Importer validates rows of a file and inserts the valid ones into Table
+type Importer struct {
+	DB           *sql.DB
+	DatabaseType string
+	Table        string
+	// Mapping maps file headers to table columns; headers not listed are ignored
+	Mapping map[string]string
+	// Validate returns field errors for a row keyed by column; see Goravel.ImportValidator
+	Validate func(values url.Values) map[string]string
+	// ChunkSize rows are inserted per transaction
+	ChunkSize int
+	// Dispatch has Import run the sources of Start in the background; by default in a
+	// goroutine, and with a job queue by pushing a job of Job with the source
+	Dispatch func(ctx context.Context, src *Source) error
+	// Done receives the report of every background import
+	Done func(Report, error)
+	// Progress is called after each chunk with the rows processed so far
+	Progress func(done, total int)
+}
+
+// New returns an importer into table with the given column mapping
+func New(db *sql.DB, dbType, table string, mapping map[string]string) *Importer {
+	im := &Importer{
+		DB:           db,
+		DatabaseType: dbType,
+		Table:        table,
+		Mapping:      mapping,
+		ChunkSize:    500,
+	}
+	im.Dispatch = func(ctx context.Context, src *Source) error {
+		go func() { _ = im.Import(context.Background(), src) }()
+		return nil
+	}
+
+	return im
+}
+
+// Job is the type of the queued jobs importing into the table of the importer, their payload
+// the source
+func (im *Importer) Job() string {
+	return "import:" + im.Table
+}
+
+// Open parses a CSV or XLSX file, picking the format from the file name
+func Open(name string, r io.Reader) (*Source, error) {
+	var rows [][]string
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".xlsx":
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		rows, err = xlsx.ReadAll(bytes.NewReader(b), int64(len(b)))
+		if err != nil {
+			return nil, err
+		}
+	case ".csv", ".txt":
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		cr.TrimLeadingSpace = true
+
+		var err error
+		rows, err = cr.ReadAll()
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("imports: unsupported file type %q", filepath.Ext(name))
+	}
+
+	if len(rows) == 0 {
+		return &Source{}, nil
+	}
+
+	header := rows[0]
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+
+	return &Source{Header: header, Rows: rows[1:]}, nil
+}
+
+// FromRequest parses the file uploaded in a multipart form field, at most maxBytes large
+func FromRequest(r *http.Request, field string, maxBytes int64) (*Source, error) {
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		return nil, err
+	}
+
+	f, header, err := r.FormFile(field)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Open(header.Filename, io.LimitReader(f, maxBytes))
+}
+
+// Run validates every row and, unless dryRun is set, inserts the valid ones chunk by chunk.
+// Invalid rows are reported and skipped; a database error stops the run.
+func (im *Importer) Run(ctx context.Context, src *Source, dryRun bool) (Report, error) {
+	report := Report{Total: len(src.Rows), DryRun: dryRun}
+
+	columns, index, err := im.columns(src.Header)
+	if err != nil {
+		return report, err
+	}
+
+	chunk := make([][]interface{}, 0, im.ChunkSize)
+
+	flush := func(done int) error {
+		if len(chunk) > 0 && !dryRun {
+			if err := im.insert(ctx, columns, chunk); err != nil {
+				return err
+			}
+		}
+		report.Imported += len(chunk)
+		chunk = chunk[:0]
+
+		if im.Progress != nil {
+			im.Progress(done, report.Total)
+		}
+
+		return nil
+	}
+
+	for i, row := range src.Rows {
+		values := url.Values{}
+		args := make([]interface{}, len(columns))
+
+		for j, col := range columns {
+			v := ""
+			if index[j] < len(row) {
+				v = strings.TrimSpace(row[index[j]])
+			}
+			values.Set(col, v)
+			args[j] = v
+		}
+
+		if rowErrors := im.validate(values); len(rowErrors) > 0 {
+			report.Failed++
+			for _, col := range columns {
+				if msg, ok := rowErrors[col]; ok {
+					report.Errors = append(report.Errors, RowError{Row: i + 1, Field: col, Message: msg})
+				}
+			}
+			continue
+		}
+
+		chunk = append(chunk, args)
+		if len(chunk) >= im.ChunkSize {
+			if err := flush(i + 1); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	return report, flush(len(src.Rows))
+}
+
+// Start has the import of src run in the background, its report handed to Done
+func (im *Importer) Start(ctx context.Context, src *Source) error {
+	return im.Dispatch(ctx, src)
+}
+
+// Import runs the import of a source of Start and hands its report to Done
+func (im *Importer) Import(ctx context.Context, src *Source) error {
+	report, err := im.Run(ctx, src, false)
+	if im.Done != nil {
+		im.Done(report, err)
+	}
+	return err
+}
+
+// columns resolves the mapping against the header, in header order; headers match case-insensitively
+func (im *Importer) columns(header []string) ([]string, []int, error) {
+	mapping := map[string]string{}
+	for h, col := range im.Mapping {
+		mapping[strings.ToLower(h)] = col
+	}
+
+	var columns []string
+	var index []int
+	found := map[string]bool{}
+
+	for i, h := range header {
+		col, ok := mapping[strings.ToLower(h)]
+		if !ok {
+			continue
+		}
+		columns = append(columns, col)
+		index = append(index, i)
+		found[strings.ToLower(h)] = true
+	}
+
+	for h := range im.Mapping {
+		if !found[strings.ToLower(h)] {
+			return nil, nil, fmt.Errorf("%w: %s", ErrUnknownColumn, h)
+		}
+	}
+
+	return columns, index, nil
+}
+
+func (im *Importer) validate(values url.Values) map[string]string {
+	if im.Validate == nil {
+		return nil
+	}
+
+	return im.Validate(values)
+}
+
+// insert writes a chunk of rows in one transaction, so a failed chunk leaves nothing behind
+func (im *Importer) insert(ctx context.Context, columns []string, rows [][]interface{}) error {
+	tx, err := im.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	query := database.Rebind(im.DatabaseType, fmt.Sprintf("insert into %s (%s) values (%s)", im.Table, strings.Join(columns, ", "), placeholders))
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, args := range rows {
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
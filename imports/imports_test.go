@@ -0,0 +1,59 @@
+package imports
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	src, err := Open("users.csv", strings.NewReader("\ufeffEmail,Name,Ignored\nann@example.com,Ann,x\nnot-an-email,Bob,y\n,Carl,z\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	im := New(nil, "postgres", "users", map[string]string{"Email": "email", "Name": "first_name"})
+	im.ChunkSize = 1
+	im.Validate = func(v url.Values) map[string]string {
+		errs := map[string]string{}
+		if v.Get("email") == "" {
+			errs["email"] = "This field cannot be blank"
+		} else if !strings.Contains(v.Get("email"), "@") {
+			errs["email"] = "Invalid email address"
+		}
+		return errs
+	}
+
+	var progress []int
+	im.Progress = func(done, total int) { progress = append(progress, done) }
+
+	report, err := im.Run(context.Background(), src, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Total != 3 || report.Imported != 1 || report.Failed != 2 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if len(report.Errors) != 2 || report.Errors[0].Row != 2 || report.Errors[1].Message != "This field cannot be blank" {
+		t.Errorf("unexpected row errors %v", report.Errors)
+	}
+	if len(progress) == 0 || progress[len(progress)-1] != 3 {
+		t.Errorf("expected progress to end at 3, got %v", progress)
+	}
+}
+
+func TestUnknownColumn(t *testing.T) {
+	src, _ := Open("users.csv", strings.NewReader("email\nann@example.com\n"))
+
+	im := New(nil, "postgres", "users", map[string]string{"Phone": "phone"})
+	if _, err := im.Run(context.Background(), src, true); !errors.Is(err, ErrUnknownColumn) {
+		t.Error("expected unknown column error, got", err)
+	}
+
+	if _, err := Open("users.pdf", strings.NewReader("")); err == nil {
+		t.Error("expected unsupported file type")
+	}
+}
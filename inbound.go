@@ -0,0 +1,28 @@
+package goravel
+
+import (
+	"os"
+	"strings"
+
+	"github.com/namnguyen191/goravel/inbound"
+)
+
+// createInbound configures the inbound mail webhooks from INBOUND_* variables; attachments
+// are saved in storage/inbound
+func (grv *Goravel) createInbound() *inbound.Inbound {
+	in := inbound.New(&inbound.Local{Dir: grv.RootPath + "/storage/inbound"}, nil)
+	in.ErrorLog = grv.ErrorLog.Println
+	in.Clock = grv.Clock
+	in.MailgunKey = os.Getenv("INBOUND_MAILGUN_KEY")
+	in.PostmarkUser = os.Getenv("INBOUND_POSTMARK_USER")
+	in.PostmarkPassword = os.Getenv("INBOUND_POSTMARK_PASSWORD")
+	in.Secret = os.Getenv("INBOUND_SECRET")
+
+	for _, topic := range strings.Split(os.Getenv("INBOUND_SES_TOPICS"), ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			in.SESTopics = append(in.SESTopics, topic)
+		}
+	}
+
+	return in
+}
@@ -0,0 +1,317 @@
+package inbound
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/text"
+)
+
+// ErrUnauthorized is returned for webhook requests failing the provider's verification
+var ErrUnauthorized = errors.New("inbound: request not signed by the provider")
+
+// Store keeps attachments. Local is the built-in implementation; any filesystem
+// abstraction exposing the same method can be used instead.
+type Store interface {
+	Put(name string, r io.Reader) error
+}
+
+// Local stores attachments in a directory
+type Local struct {
+	Dir string
+}
+
+func (l *Local) Put(name string, r io.Reader) error {
+	path := filepath.Join(l.Dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Inbound receives email through provider webhooks and hands each message to Handle
+type Inbound struct {
+	Store Store
+	// Handle is called for every message once its attachments are saved
+	Handle func(m *Message)
+	// MailgunKey is the webhook signing key of the Mailgun account
+	MailgunKey string
+	// PostmarkUser and PostmarkPassword are the basic auth credentials put in the Postmark webhook url
+	PostmarkUser     string
+	PostmarkPassword string
+	// SESTopics lists the SNS topic ARNs accepted from SES; SNS signatures are always checked
+	SESTopics []string
+	// Secret authenticates raw MIME posts sent with an X-Inbound-Secret header
+	Secret string
+	// MaxSize in bytes of a request
+	MaxSize  int64
+	ErrorLog func(v ...interface{})
+	// Clock tells the dated directory attachments are saved in; the time of the machine when nil
+	Clock  clock.Clock
+	client *http.Client
+}
+
+// New returns inbound mail saving attachments to store
+func New(store Store, handle func(m *Message)) *Inbound {
+	return &Inbound{
+		Store:    store,
+		Handle:   handle,
+		MaxSize:  25 << 20,
+		ErrorLog: log.Println,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// deliver saves the attachments and calls Handle
+func (in *Inbound) deliver(m *Message) error {
+	if len(m.Attachments) > 0 && in.Store != nil {
+		dir := clock.Now(in.Clock).Format("2006/01/02") + "/" + text.HexToken(8)
+		for i := range m.Attachments {
+			a := &m.Attachments[i]
+			name := dir + "/" + safeName(a.Filename)
+			if err := in.Store.Put(name, bytes.NewReader(a.data)); err != nil {
+				return err
+			}
+			a.Name = name
+			a.data = nil
+		}
+	}
+
+	if in.Handle != nil {
+		in.Handle(m)
+	}
+
+	return nil
+}
+
+func safeName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	ext := filepath.Ext(name)
+	base := text.Slugify(strings.TrimSuffix(name, ext))
+	if base == "" {
+		base = "attachment"
+	}
+
+	return base + strings.ToLower(ext)
+}
+
+func (in *Inbound) respond(rw http.ResponseWriter, err error) {
+	switch {
+	case err == nil:
+		rw.WriteHeader(http.StatusOK)
+	case errors.Is(err, ErrUnauthorized):
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	default:
+		in.ErrorLog("inbound:", err)
+		// a 4xx tells providers not to retry a message that will never parse
+		http.Error(rw, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+	}
+}
+
+// Mailgun receives messages forwarded by a Mailgun route, either parsed or as raw mime (the url ending in "mime")
+func (in *Inbound) Mailgun(rw http.ResponseWriter, r *http.Request) {
+	in.respond(rw, in.mailgun(rw, r))
+}
+
+func (in *Inbound) mailgun(rw http.ResponseWriter, r *http.Request) error {
+	r.Body = http.MaxBytesReader(rw, r.Body, in.MaxSize)
+	if err := r.ParseMultipartForm(32 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return err
+	}
+	if r.PostForm == nil {
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+	}
+
+	mac := hmac.New(sha256.New, []byte(in.MailgunKey))
+	mac.Write([]byte(r.FormValue("timestamp") + r.FormValue("token")))
+	sig, _ := hex.DecodeString(r.FormValue("signature"))
+	if in.MailgunKey == "" || !hmac.Equal(sig, mac.Sum(nil)) {
+		return ErrUnauthorized
+	}
+
+	if raw := r.FormValue("body-mime"); raw != "" {
+		m, err := ParseMIME(strings.NewReader(raw))
+		if err != nil {
+			return err
+		}
+		m.Provider = "mailgun"
+		return in.deliver(m)
+	}
+
+	m := &Message{
+		ID:        strings.Trim(r.FormValue("Message-Id"), "<>"),
+		From:      address(r.FormValue("from")),
+		To:        addresses(r.FormValue("To")),
+		Cc:        addresses(r.FormValue("Cc")),
+		Subject:   r.FormValue("subject"),
+		Text:      r.FormValue("body-plain"),
+		HTML:      r.FormValue("body-html"),
+		InReplyTo: strings.Trim(r.FormValue("In-Reply-To"), "<>"),
+		Headers:   map[string]string{},
+		Provider:  "mailgun",
+	}
+	if len(m.To) == 0 {
+		m.To = addresses(r.FormValue("recipient"))
+	}
+	for _, ref := range strings.Fields(r.FormValue("References")) {
+		m.References = append(m.References, strings.Trim(ref, "<>"))
+	}
+
+	var headers [][2]string
+	if err := json.Unmarshal([]byte(r.FormValue("message-headers")), &headers); err == nil {
+		for _, h := range headers {
+			m.Headers[h[0]] = h[1]
+		}
+	}
+	if d, err := http.ParseTime(m.Headers["Date"]); err == nil {
+		m.Date = d
+	}
+
+	if r.MultipartForm != nil {
+		for field, files := range r.MultipartForm.File {
+			if !strings.HasPrefix(field, "attachment") {
+				continue
+			}
+			for _, fh := range files {
+				f, err := fh.Open()
+				if err != nil {
+					return err
+				}
+				data, err := ioutil.ReadAll(f)
+				f.Close()
+				if err != nil {
+					return err
+				}
+				m.Attachments = append(m.Attachments, Attachment{
+					Filename:    fh.Filename,
+					ContentType: fh.Header.Get("Content-Type"),
+					Size:        len(data),
+					data:        data,
+				})
+			}
+		}
+	}
+
+	return in.deliver(m)
+}
+
+// Postmark receives messages from a Postmark inbound webhook
+func (in *Inbound) Postmark(rw http.ResponseWriter, r *http.Request) {
+	in.respond(rw, in.postmark(r))
+}
+
+func (in *Inbound) postmark(r *http.Request) error {
+	user, pass, _ := r.BasicAuth()
+	if in.PostmarkUser == "" ||
+		subtle.ConstantTimeCompare([]byte(user), []byte(in.PostmarkUser)) != 1 ||
+		subtle.ConstantTimeCompare([]byte(pass), []byte(in.PostmarkPassword)) != 1 {
+		return ErrUnauthorized
+	}
+
+	var p struct {
+		MessageID string
+		FromFull  struct{ Email string }
+		ToFull    []struct{ Email string }
+		CcFull    []struct{ Email string }
+		Subject   string
+		TextBody  string
+		HtmlBody  string
+		Date      string
+		Headers   []struct {
+			Name  string
+			Value string
+		}
+		Attachments []struct {
+			Name          string
+			Content       string
+			ContentType   string
+			ContentLength int
+		}
+	}
+
+	if err := json.NewDecoder(io.LimitReader(r.Body, in.MaxSize)).Decode(&p); err != nil {
+		return err
+	}
+
+	m := &Message{
+		ID:       p.MessageID,
+		From:     p.FromFull.Email,
+		Subject:  p.Subject,
+		Text:     p.TextBody,
+		HTML:     p.HtmlBody,
+		Headers:  map[string]string{},
+		Provider: "postmark",
+	}
+	for _, to := range p.ToFull {
+		m.To = append(m.To, to.Email)
+	}
+	for _, cc := range p.CcFull {
+		m.Cc = append(m.Cc, cc.Email)
+	}
+	for _, h := range p.Headers {
+		m.Headers[h.Name] = h.Value
+	}
+	m.InReplyTo = strings.Trim(m.Headers["In-Reply-To"], "<>")
+	for _, ref := range strings.Fields(m.Headers["References"]) {
+		m.References = append(m.References, strings.Trim(ref, "<>"))
+	}
+	if d, err := http.ParseTime(p.Date); err == nil {
+		m.Date = d
+	} else if d, err := time.Parse(time.RFC1123Z, p.Date); err == nil {
+		m.Date = d
+	}
+
+	for _, a := range p.Attachments {
+		data, err := base64.StdEncoding.DecodeString(a.Content)
+		if err != nil {
+			return err
+		}
+		m.Attachments = append(m.Attachments, Attachment{Filename: a.Name, ContentType: a.ContentType, Size: len(data), data: data})
+	}
+
+	return in.deliver(m)
+}
+
+// MIME receives a raw message posted as the request body, e.g. from a pipe on the mail server;
+// the X-Inbound-Secret header must match Secret
+func (in *Inbound) MIME(rw http.ResponseWriter, r *http.Request) {
+	if in.Secret == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Inbound-Secret")), []byte(in.Secret)) != 1 {
+		in.respond(rw, ErrUnauthorized)
+		return
+	}
+
+	m, err := ParseMIME(io.LimitReader(r.Body, in.MaxSize))
+	if err == nil {
+		err = in.deliver(m)
+	}
+
+	in.respond(rw, err)
+}
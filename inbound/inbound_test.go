@@ -0,0 +1,202 @@
+package inbound
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const raw = "From: =?UTF-8?Q?Ann_L=C3=A9a?= <ann@example.com>\r\n" +
+	"To: support+42@example.org\r\n" +
+	"Subject: Re: Your order\r\n" +
+	"Message-Id: <abc@example.com>\r\n" +
+	"In-Reply-To: <order-42@example.org>\r\n" +
+	"Date: Thu, 01 Jul 2021 10:00:00 +0000\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Thanks, it arrived=21\r\n" +
+	"\r\n" +
+	"On Wed, Jun 30, 2021 Shop wrote:\r\n" +
+	"> Your order shipped\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>Thanks, it arrived!</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: image/png; name=\"photo.png\"\r\n" +
+	"Content-Disposition: attachment; filename=\"My Photo.png\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"aGVsbG8g\r\n" +
+	"d29ybGQ=\r\n" +
+	"--outer--\r\n"
+
+func TestParseMIME(t *testing.T) {
+	m, err := ParseMIME(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.From != "ann@example.com" || m.To[0] != "support+42@example.org" || m.InReplyTo != "order-42@example.org" || m.Date.Day() != 1 {
+		t.Errorf("unexpected headers %+v", m)
+	}
+	if m.Reply() != "Thanks, it arrived!" || !strings.Contains(m.HTML, "<p>") {
+		t.Errorf("unexpected bodies %q %q", m.Reply(), m.HTML)
+	}
+	if len(m.Attachments) != 1 || string(m.Attachments[0].data) != "hello world" || m.Attachments[0].Filename != "My Photo.png" {
+		t.Errorf("unexpected attachments %+v", m.Attachments)
+	}
+}
+
+func TestMIMEHandler(t *testing.T) {
+	dir := t.TempDir()
+
+	var got *Message
+	in := New(&Local{Dir: dir}, func(m *Message) { got = m })
+	in.Secret = "s3cret"
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(raw))
+	rw := httptest.NewRecorder()
+	in.MIME(rw, req)
+	if rw.Code != http.StatusUnauthorized {
+		t.Error("expected a post without secret to be refused, got", rw.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(raw))
+	req.Header.Set("X-Inbound-Secret", "s3cret")
+	rw = httptest.NewRecorder()
+	in.MIME(rw, req)
+
+	if rw.Code != http.StatusOK || got == nil {
+		t.Fatal("expected the message to be delivered, got", rw.Code)
+	}
+
+	name := got.Attachments[0].Name
+	if !strings.HasSuffix(name, "/my-photo.png") {
+		t.Error("unexpected stored name", name)
+	}
+	if b, _ := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name))); string(b) != "hello world" {
+		t.Errorf("unexpected stored attachment %q", b)
+	}
+}
+
+func TestMailgun(t *testing.T) {
+	var got *Message
+	in := New(nil, func(m *Message) { got = m })
+	in.MailgunKey = "key"
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	ts, token := "1625133600", "tok"
+	mac := hmac.New(sha256.New, []byte("key"))
+	mac.Write([]byte(ts + token))
+	_ = w.WriteField("timestamp", ts)
+	_ = w.WriteField("token", token)
+	_ = w.WriteField("signature", hex.EncodeToString(mac.Sum(nil)))
+	_ = w.WriteField("from", "Ann <ann@example.com>")
+	_ = w.WriteField("recipient", "support@example.org")
+	_ = w.WriteField("subject", "Hello")
+	_ = w.WriteField("body-plain", "Hi there")
+	f, _ := w.CreateFormFile("attachment-1", "notes.txt")
+	_, _ = f.Write([]byte("notes"))
+	_ = w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	rw := httptest.NewRecorder()
+	in.Mailgun(rw, req)
+
+	if rw.Code != http.StatusOK || got == nil {
+		t.Fatal("expected the message to be delivered, got", rw.Code)
+	}
+	if got.From != "ann@example.com" || got.To[0] != "support@example.org" || got.Text != "Hi there" || len(got.Attachments) != 1 {
+		t.Errorf("unexpected message %+v", got)
+	}
+}
+
+func TestPostmark(t *testing.T) {
+	var got *Message
+	in := New(nil, func(m *Message) { got = m })
+	in.PostmarkUser, in.PostmarkPassword = "pm", "pw"
+
+	payload := `{"MessageID":"m1","FromFull":{"Email":"ann@example.com"},"ToFull":[{"Email":"support@example.org"}],
+		"Subject":"Hello","TextBody":"Hi","Headers":[{"Name":"In-Reply-To","Value":"<t1@example.org>"}],
+		"Attachments":[{"Name":"a.txt","Content":"` + base64.StdEncoding.EncodeToString([]byte("abc")) + `","ContentType":"text/plain"}]}`
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload))
+	req.SetBasicAuth("pm", "pw")
+	rw := httptest.NewRecorder()
+	in.Postmark(rw, req)
+
+	if rw.Code != http.StatusOK || got == nil {
+		t.Fatal("expected the message to be delivered, got", rw.Code)
+	}
+	if got.InReplyTo != "t1@example.org" || got.Attachments[0].Size != 3 {
+		t.Errorf("unexpected message %+v", got)
+	}
+}
+
+func TestSES(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "sns"}, NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour)}
+	der, _ := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	cert, _ := x509.ParseCertificate(der)
+
+	certURL := "https://sns.us-east-1.amazonaws.com/cert.pem"
+	certs.Store(certURL, cert)
+
+	content, _ := json.Marshal(map[string]string{"notificationType": "Received", "content": base64.StdEncoding.EncodeToString([]byte(raw))})
+	n := snsMessage{
+		Type: "Notification", MessageId: "1", TopicArn: "arn:aws:sns:us-east-1:1:inbound", Message: string(content),
+		Timestamp: "2021-07-01T10:00:00Z", SignatureVersion: "2", SigningCertURL: certURL,
+	}
+	digest := sha256.Sum256([]byte(n.signed()))
+	sig, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	n.Signature = base64.StdEncoding.EncodeToString(sig)
+
+	var got *Message
+	in := New(nil, func(m *Message) { got = m })
+	in.SESTopics = []string{n.TopicArn}
+
+	post := func(n snsMessage) int {
+		b, _ := json.Marshal(n)
+		rw := httptest.NewRecorder()
+		in.SES(rw, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(b)))
+		return rw.Code
+	}
+
+	if code := post(n); code != http.StatusOK || got == nil || got.Provider != "ses" || got.Subject != "Re: Your order" {
+		t.Fatalf("expected the message to be delivered, got %d %+v", code, got)
+	}
+
+	n.Message = `{"content":"forged"}`
+	if code := post(n); code != http.StatusUnauthorized {
+		t.Error("expected a tampered message to be refused, got", code)
+	}
+}
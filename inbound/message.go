@@ -0,0 +1,223 @@
+package inbound
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Message is an incoming email normalized across providers
+type Message struct {
+	ID         string
+	From       string
+	To         []string
+	Cc         []string
+	Subject    string
+	Text       string
+	HTML       string
+	InReplyTo  string
+	References []string
+	Date       time.Time
+	Headers    map[string]string
+	// Provider names how the message was received: mailgun, postmark, ses or mime
+	Provider    string
+	Attachments []Attachment
+}
+
+// Attachment is a file of a message; Name is where it was saved in the store
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Size        int
+	Name        string
+	data        []byte
+}
+
+// Reply returns the text above the quoted original in a reply, for reply-by-email features
+func (m *Message) Reply() string {
+	var lines []string
+
+	for _, line := range strings.Split(strings.ReplaceAll(m.Text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, ">") || trimmed == "-- " || (strings.HasPrefix(trimmed, "On ") && strings.HasSuffix(trimmed, "wrote:")) {
+			break
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+var decoder = &mime.WordDecoder{}
+
+func decodeHeader(s string) string {
+	if d, err := decoder.DecodeHeader(s); err == nil {
+		return d
+	}
+	return s
+}
+
+func addresses(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+
+	list, err := mail.ParseAddressList(s)
+	if err != nil {
+		var out []string
+		for _, a := range strings.Split(s, ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				out = append(out, a)
+			}
+		}
+		return out
+	}
+
+	out := make([]string, len(list))
+	for i, a := range list {
+		out[i] = a.Address
+	}
+
+	return out
+}
+
+func address(s string) string {
+	if a, err := mail.ParseAddress(s); err == nil {
+		return a.Address
+	}
+	return strings.TrimSpace(s)
+}
+
+// ParseMIME reads a raw RFC 5322 message
+func ParseMIME(r io.Reader) (*Message, error) {
+	raw, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, err
+	}
+
+	h := raw.Header
+	m := &Message{
+		ID:        strings.Trim(h.Get("Message-Id"), "<>"),
+		From:      address(decodeHeader(h.Get("From"))),
+		To:        addresses(h.Get("To")),
+		Cc:        addresses(h.Get("Cc")),
+		Subject:   decodeHeader(h.Get("Subject")),
+		InReplyTo: strings.Trim(h.Get("In-Reply-To"), "<>"),
+		Headers:   map[string]string{},
+		Provider:  "mime",
+	}
+	for _, ref := range strings.Fields(h.Get("References")) {
+		m.References = append(m.References, strings.Trim(ref, "<>"))
+	}
+	if d, err := h.Date(); err == nil {
+		m.Date = d
+	}
+	for k, v := range h {
+		m.Headers[k] = decodeHeader(strings.Join(v, ", "))
+	}
+
+	if err := m.readPart(h.Get("Content-Type"), h.Get("Content-Transfer-Encoding"), h.Get("Content-Disposition"), raw.Body); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// readPart walks the mime tree, keeping the first text and html bodies and every attachment
+func (m *Message) readPart(contentType, encoding, disposition string, body io.Reader) error {
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "application/octet-stream"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			err = m.readPart(p.Header.Get("Content-Type"), p.Header.Get("Content-Transfer-Encoding"), p.Header.Get("Content-Disposition"), p)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := ioutil.ReadAll(decode(encoding, body))
+	if err != nil {
+		return err
+	}
+
+	disp, dparams, _ := mime.ParseMediaType(disposition)
+	filename := dparams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+
+	switch {
+	case disp != "attachment" && filename == "" && mediaType == "text/plain" && m.Text == "":
+		m.Text = string(data)
+	case disp != "attachment" && filename == "" && mediaType == "text/html" && m.HTML == "":
+		m.HTML = string(data)
+	default:
+		if filename == "" {
+			filename = fmt.Sprintf("attachment-%d", len(m.Attachments)+1)
+		}
+		m.Attachments = append(m.Attachments, Attachment{
+			Filename:    decodeHeader(filename),
+			ContentType: mediaType,
+			Size:        len(data),
+			data:        data,
+		})
+	}
+
+	return nil
+}
+
+func decode(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &newlineStripper{r: r})
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	}
+
+	return r
+}
+
+// newlineStripper drops the line breaks base64 bodies are wrapped with
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (n *newlineStripper) Read(p []byte) (int, error) {
+	for {
+		c, err := n.r.Read(p)
+		out := bytes.NewBuffer(p[:0])
+		for _, b := range p[:c] {
+			if b != '\r' && b != '\n' {
+				out.WriteByte(b)
+			}
+		}
+		if out.Len() > 0 || err != nil {
+			return out.Len(), err
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package inbound
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// snsHost matches the hosts SNS signing certificates and subscription urls are served from
+var snsHost = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+var certs sync.Map
+
+type snsMessage struct {
+	Type             string
+	MessageId        string
+	Token            string
+	TopicArn         string
+	Subject          string
+	Message          string
+	Timestamp        string
+	SignatureVersion string
+	Signature        string
+	SigningCertURL   string
+	SubscribeURL     string
+}
+
+// SES receives messages from an SES receipt rule publishing to SNS (with Base64 encoding).
+// Subscription confirmations of the accepted topics are confirmed automatically.
+func (in *Inbound) SES(rw http.ResponseWriter, r *http.Request) {
+	in.respond(rw, in.ses(r))
+}
+
+func (in *Inbound) ses(r *http.Request) error {
+	var n snsMessage
+	if err := json.NewDecoder(io.LimitReader(r.Body, in.MaxSize)).Decode(&n); err != nil {
+		return err
+	}
+
+	if !in.acceptedTopic(n.TopicArn) {
+		return ErrUnauthorized
+	}
+	if err := in.verifySNS(&n); err != nil {
+		return err
+	}
+
+	switch n.Type {
+	case "SubscriptionConfirmation":
+		u, err := url.Parse(n.SubscribeURL)
+		if err != nil || u.Scheme != "https" || !snsHost.MatchString(u.Host) {
+			return ErrUnauthorized
+		}
+		res, err := in.client.Get(n.SubscribeURL)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		return nil
+	case "Notification":
+	default:
+		return nil
+	}
+
+	var notification struct {
+		NotificationType string `json:"notificationType"`
+		Content          string `json:"content"`
+	}
+	if err := json.Unmarshal([]byte(n.Message), &notification); err != nil {
+		return err
+	}
+	if notification.Content == "" {
+		// bounce and complaint notifications share the topic but carry no message
+		return nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(notification.Content)
+	if err != nil {
+		// receipt rules without Base64 encoding publish the message as is
+		raw = []byte(notification.Content)
+	}
+
+	m, err := ParseMIME(strings.NewReader(string(raw)))
+	if err != nil {
+		return err
+	}
+	m.Provider = "ses"
+
+	return in.deliver(m)
+}
+
+func (in *Inbound) acceptedTopic(arn string) bool {
+	for _, t := range in.SESTopics {
+		if t == arn {
+			return true
+		}
+	}
+
+	return false
+}
+
+// verifySNS checks the signature of an SNS message against its signing certificate
+func (in *Inbound) verifySNS(n *snsMessage) error {
+	u, err := url.Parse(n.SigningCertURL)
+	if err != nil || u.Scheme != "https" || !snsHost.MatchString(u.Host) {
+		return ErrUnauthorized
+	}
+
+	cert, err := in.cert(n.SigningCertURL)
+	if err != nil {
+		return err
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(n.Signature)
+	if err != nil {
+		return ErrUnauthorized
+	}
+
+	algorithm := x509.SHA1WithRSA
+	if n.SignatureVersion == "2" {
+		algorithm = x509.SHA256WithRSA
+	}
+
+	if err := cert.CheckSignature(algorithm, []byte(n.signed()), sig); err != nil {
+		return ErrUnauthorized
+	}
+
+	return nil
+}
+
+// signed builds the string SNS signs: selected fields as name/value lines in a fixed order
+func (n *snsMessage) signed() string {
+	fields := [][2]string{{"Message", n.Message}, {"MessageId", n.MessageId}}
+
+	if n.Type == "Notification" {
+		if n.Subject != "" {
+			fields = append(fields, [2]string{"Subject", n.Subject})
+		}
+	} else {
+		fields = append(fields, [2]string{"SubscribeURL", n.SubscribeURL})
+	}
+
+	fields = append(fields, [2]string{"Timestamp", n.Timestamp})
+	if n.Type != "Notification" {
+		fields = append(fields, [2]string{"Token", n.Token})
+	}
+	fields = append(fields, [2]string{"TopicArn", n.TopicArn}, [2]string{"Type", n.Type})
+
+	var b strings.Builder
+	for _, f := range fields {
+		b.WriteString(f[0] + "\n" + f[1] + "\n")
+	}
+
+	return b.String()
+}
+
+func (in *Inbound) cert(certURL string) (*x509.Certificate, error) {
+	if c, ok := certs.Load(certURL); ok {
+		return c.(*x509.Certificate), nil
+	}
+
+	res, err := in.client.Get(certURL)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("inbound: fetching sns certificate: %s", res.Status)
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(res.Body, 64<<10))
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("inbound: invalid sns certificate")
+	}
+
+	c, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	certs.Store(certURL, c)
+
+	return c, nil
+}
@@ -0,0 +1,27 @@
+package goravel
+
+import (
+	"os"
+	"time"
+
+	"github.com/namnguyen191/goravel/invitations"
+)
+
+// createInvitations mails the invitations through the mailer of the app, with links to
+// INVITATIONS_URL signed by the tokens of the app; without KEY none can be sent
+func (grv *Goravel) createInvitations() *invitations.Invitations {
+	url := os.Getenv("INVITATIONS_URL")
+	if url == "" {
+		url = grv.Server.URL + "/invitations/accept"
+	}
+
+	is := invitations.New(grv.DB.Pool, grv.DB.DataBaseType, grv.Auth, grv.Tokens, url)
+	is.Send = grv.Mail.SendContext
+	is.From = grv.Mail.FromAddress
+	is.TTL = grv.Env.Duration("INVITATIONS_TTL", 7*24*time.Hour)
+	is.Render = grv.Render
+	is.HomeURL = grv.Auth.HomeURL
+	is.Clock = grv.Clock
+
+	return is
+}
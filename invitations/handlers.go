@@ -0,0 +1,139 @@
+package invitations
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/CloudyKit/jet/v6"
+	"github.com/namnguyen191/goravel/auth"
+	"github.com/namnguyen191/goravel/render"
+)
+
+// messages are shown to the invitee for the errors of a link
+var messages = map[error]string{
+	ErrInvalid:    "This invitation link is not valid",
+	ErrExpired:    "This invitation has expired, ask for a new one",
+	ErrAccepted:   "This invitation was already accepted",
+	ErrRevoked:    "This invitation was cancelled",
+	ErrRegistered: "You already have an account, log in to accept the invitation",
+	ErrWrongUser:  "This invitation is for another account",
+}
+
+func message(err error) (string, bool) {
+	for e, msg := range messages {
+		if errors.Is(err, e) {
+			return msg, true
+		}
+	}
+	return "", false
+}
+
+// AcceptPage renders View for the invitation of the token query parameter, as invitation, with
+// the token and the logged in user as user, or nil; the form posts them to AcceptHandler. Jet
+// views get them as variables, go templates in .Data.
+func (is *Invitations) AcceptPage(rw http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	inv, err := is.Check(r.Context(), token)
+	if msg, ok := message(err); ok {
+		is.page(rw, r, nil, token, nil, msg)
+		return
+	}
+	if err != nil {
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	u, err := is.Auth.User(r)
+	if err != nil {
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	is.page(rw, r, inv, token, u, "")
+}
+
+func (is *Invitations) page(rw http.ResponseWriter, r *http.Request, inv *Invitation, token string, u *auth.User, problem string) {
+	if problem != "" {
+		rw.WriteHeader(http.StatusGone)
+	}
+
+	vars := make(jet.VarMap)
+	vars.Set("invitation", inv)
+	vars.Set("token", token)
+	vars.Set("user", u)
+	vars.Set("problem", problem)
+	data := &render.TemplateData{Data: map[string]interface{}{"invitation": inv, "token": token, "user": u, "problem": problem}}
+
+	if err := is.Render.Page(rw, r, is.View, vars, data); err != nil {
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// AcceptHandler accepts the invitation of the posted token. A logged in user joins with it;
+// anyone else registers with the posted first_name, last_name and password, and is logged in.
+// A failure goes back to AcceptPage with the message in the "error" of the session.
+func (is *Invitations) AcceptHandler(rw http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	token := r.Form.Get("token")
+
+	u, err := is.Auth.User(r)
+	if err != nil {
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if u != nil {
+		_, err = is.Join(ctx, token, u)
+	} else {
+		err = is.register(rw, r, token)
+	}
+
+	if msg, ok := message(err); ok {
+		is.back(ctx, rw, r, token, msg)
+		return
+	}
+	var invalid formError
+	if errors.As(err, &invalid) {
+		is.back(ctx, rw, r, token, string(invalid))
+		return
+	}
+	if err != nil {
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(rw, r, is.HomeURL, http.StatusSeeOther)
+}
+
+// formError is a problem with the posted form, shown as is
+type formError string
+
+func (e formError) Error() string {
+	return string(e)
+}
+
+// register accepts the invitation of token with a new user, and logs them in
+func (is *Invitations) register(rw http.ResponseWriter, r *http.Request, token string) error {
+	password := r.Form.Get("password")
+	if len(password) < 8 {
+		return formError("The password must be at least 8 characters")
+	}
+	if confirm, ok := r.Form["password_confirmation"]; ok && confirm[0] != password {
+		return formError("The passwords do not match")
+	}
+
+	u := &auth.User{FirstName: r.Form.Get("first_name"), LastName: r.Form.Get("last_name")}
+	if _, err := is.Accept(r.Context(), token, u, password); err != nil {
+		return err
+	}
+	return is.Auth.Login(rw, r, u, false)
+}
+
+func (is *Invitations) back(ctx context.Context, rw http.ResponseWriter, r *http.Request, token, msg string) {
+	is.Auth.Session.Put(ctx, "error", msg)
+	http.Redirect(rw, r, is.Link(token), http.StatusSeeOther)
+}
@@ -0,0 +1,444 @@
+// Package invitations invites people to the app by email: an invitation carries a role and
+// metadata, and is accepted through a signed link which registers the invitee, or lets a user
+// who already has an account join with it.
+package invitations
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/namnguyen191/goravel/auth"
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+	"github.com/namnguyen191/goravel/mailer"
+	"github.com/namnguyen191/goravel/render"
+	"github.com/namnguyen191/goravel/tokens"
+)
+
+// States of an invitation; an expired one is still pending, and can be sent again
+const (
+	Pending  = "pending"
+	Accepted = "accepted"
+	Revoked  = "revoked"
+)
+
+var (
+	// ErrInvalid is returned for a link which is malformed, forged, or was replaced by a resend
+	ErrInvalid = errors.New("invitations: invalid invitation link")
+	// ErrExpired is returned for a link past the expiry of its invitation
+	ErrExpired = errors.New("invitations: invitation expired")
+	// ErrAccepted is returned for an invitation which was already accepted
+	ErrAccepted = errors.New("invitations: invitation already accepted")
+	// ErrRevoked is returned for an invitation which was revoked
+	ErrRevoked = errors.New("invitations: invitation revoked")
+	// ErrPending is returned by Create for an address which already has a pending invitation
+	// with the same metadata
+	ErrPending = errors.New("invitations: an invitation is already pending for this address")
+	// ErrEmail is returned by Create for an invalid address
+	ErrEmail = errors.New("invitations: invalid email address")
+	// ErrRegistered is returned by Accept for an address which already has an account, whose
+	// user joins with Join after logging in
+	ErrRegistered = errors.New("invitations: a user with this address already exists")
+	// ErrWrongUser is returned by Join for a user whose address is not the invited one
+	ErrWrongUser = errors.New("invitations: the invitation is for another address")
+)
+
+// Invitation is a row of the invitations table
+type Invitation struct {
+	ID       int
+	Email    string
+	Role     string
+	Metadata map[string]string
+	// InvitedBy is the id of the user who sent the invitation, 0 for the app itself
+	InvitedBy int
+	Status    string
+	// UserID is the user who accepted the invitation, 0 until then
+	UserID     int
+	ExpiresAt  time.Time
+	SentAt     time.Time
+	AcceptedAt time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+
+	// nonce is the one of the last link sent, an older link being worthless
+	nonce string
+}
+
+// Expired reports whether the invitation can no longer be accepted at t
+func (inv *Invitation) Expired(t time.Time) bool {
+	return !t.Before(inv.ExpiresAt)
+}
+
+// Invite is what Create needs to invite someone
+type Invite struct {
+	Email     string
+	Role      string
+	Metadata  map[string]string
+	InvitedBy int
+}
+
+// Invitations keeps the invitations in the invitations table, mailing their links and
+// registering the invitees with Auth
+type Invitations struct {
+	DB           *sql.DB
+	DatabaseType string
+	Auth         *auth.Auth
+	// Tokens signs the links, whose subject is the id of the invitation
+	Tokens *tokens.Tokens
+	// Send delivers a message, through the mailer of the app
+	Send func(ctx context.Context, msg mailer.Message) error
+	From string
+	// Subject is the subject of the emails, and Template their mail template, getting the Link,
+	// the Invitation and its ExpiresAt
+	Subject  string
+	Template string
+	// URL is the public url of AcceptPage, the links adding the token to it
+	URL string
+	// TTL is how long an invitation can be accepted after it was sent
+	TTL time.Duration
+	// Render renders View, the page showing the invitation with the form of AcceptHandler
+	Render *render.Render
+	View   string
+	// HomeURL is where AcceptHandler sends the invitee once they are in
+	HomeURL string
+	// OnAccept runs once the invitation is accepted by u, e.g. to give them its role
+	OnAccept func(ctx context.Context, inv *Invitation, u *auth.User) error
+	// Clock tells when the invitations expire; the time of the machine when nil
+	Clock clock.Clock
+}
+
+// New returns the invitations of db, whose invitees register with a
+func New(db *sql.DB, dbType string, a *auth.Auth, tk *tokens.Tokens, url string) *Invitations {
+	return &Invitations{
+		DB:           db,
+		DatabaseType: dbType,
+		Auth:         a,
+		Tokens:       tk,
+		Subject:      "You have been invited",
+		Template:     "invitation",
+		URL:          url,
+		TTL:          7 * 24 * time.Hour,
+		View:         "invitations/accept",
+		HomeURL:      "/",
+	}
+}
+
+func (is *Invitations) rebind(query string) string {
+	return database.Rebind(is.DatabaseType, query)
+}
+
+const columns = "id, email, role, metadata, invited_by, status, nonce, user_id, expires_at, sent_at, accepted_at, created_at, updated_at"
+
+func scan(row interface{ Scan(...interface{}) error }) (*Invitation, error) {
+	var inv Invitation
+	var metadata string
+	var accepted sql.NullTime
+	err := row.Scan(&inv.ID, &inv.Email, &inv.Role, &metadata, &inv.InvitedBy, &inv.Status, &inv.nonce,
+		&inv.UserID, &inv.ExpiresAt, &inv.SentAt, &accepted, &inv.CreatedAt, &inv.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if metadata != "" {
+		if err := json.Unmarshal([]byte(metadata), &inv.Metadata); err != nil {
+			return nil, err
+		}
+	}
+	inv.AcceptedAt = accepted.Time
+	return &inv, nil
+}
+
+// Find returns the invitation with id, or sql.ErrNoRows
+func (is *Invitations) Find(ctx context.Context, id int) (*Invitation, error) {
+	return scan(is.DB.QueryRowContext(ctx, is.rebind("select "+columns+" from invitations where id = ?"), id))
+}
+
+// Pending returns the invitations which were not accepted nor revoked, the latest first
+func (is *Invitations) Pending(ctx context.Context) ([]*Invitation, error) {
+	rows, err := is.DB.QueryContext(ctx, is.rebind("select "+columns+" from invitations where status = ? order by created_at desc"), Pending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*Invitation
+	for rows.Next() {
+		inv, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, inv)
+	}
+	return list, rows.Err()
+}
+
+// Create stores an invitation for in and mails its link. An address can only have one
+// pending invitation with the same metadata, e.g. to the same team, sent again with Resend.
+func (is *Invitations) Create(ctx context.Context, in Invite) (*Invitation, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(in.Email))
+	if err != nil || addr.Address != strings.TrimSpace(in.Email) {
+		return nil, ErrEmail
+	}
+
+	// the keys of a map are marshaled in order, so the same metadata is the same text
+	metadata, err := json.Marshal(in.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	var id int
+	query := is.rebind("select id from invitations where lower(email) = lower(?) and status = ? and metadata = ?")
+	err = is.DB.QueryRowContext(ctx, query, addr.Address, Pending, string(metadata)).Scan(&id)
+	if err == nil {
+		return nil, ErrPending
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	now := clock.Now(is.Clock)
+	inv := &Invitation{
+		Email:     addr.Address,
+		Role:      in.Role,
+		Metadata:  in.Metadata,
+		InvitedBy: in.InvitedBy,
+		Status:    Pending,
+		ExpiresAt: now.Add(is.TTL),
+		SentAt:    now,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	query = `insert into invitations (email, role, metadata, invited_by, status, nonce, user_id, expires_at, sent_at, created_at, updated_at)
+		values (?, ?, ?, ?, ?, '', 0, ?, ?, ?, ?)`
+	args := []interface{}{inv.Email, inv.Role, string(metadata), inv.InvitedBy, inv.Status, inv.ExpiresAt, inv.SentAt, inv.CreatedAt, inv.UpdatedAt}
+
+	if database.IsPostgres(is.DatabaseType) {
+		err = is.DB.QueryRowContext(ctx, is.rebind(query+" returning id"), args...).Scan(&inv.ID)
+	} else {
+		var res sql.Result
+		if res, err = is.DB.ExecContext(ctx, query, args...); err == nil {
+			var id int64
+			id, err = res.LastInsertId()
+			inv.ID = int(id)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return inv, is.send(ctx, inv)
+}
+
+// Resend mails a new link for the pending invitation with id, which can be accepted for TTL
+// again; the links sent before no longer work
+func (is *Invitations) Resend(ctx context.Context, id int) (*Invitation, error) {
+	inv, err := is.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := state(inv); err != nil {
+		return nil, err
+	}
+
+	now := clock.Now(is.Clock)
+	inv.SentAt, inv.ExpiresAt, inv.UpdatedAt = now, now.Add(is.TTL), now
+	return inv, is.send(ctx, inv)
+}
+
+// send signs a new link for inv, keeping its nonce and expiry, and mails it
+func (is *Invitations) send(ctx context.Context, inv *Invitation) error {
+	if is.Tokens == nil {
+		return errors.New("invitations: signing links needs KEY")
+	}
+	token, err := is.Tokens.Sign(tokens.Invite, strconv.Itoa(inv.ID), inv.ExpiresAt.Sub(clock.Now(is.Clock)))
+	if err != nil {
+		return err
+	}
+	claims, err := is.Tokens.Verify(token, tokens.Invite)
+	if err != nil {
+		return err
+	}
+	inv.nonce = claims.Nonce
+
+	query := is.rebind("update invitations set nonce = ?, expires_at = ?, sent_at = ?, updated_at = ? where id = ?")
+	if _, err := is.DB.ExecContext(ctx, query, inv.nonce, inv.ExpiresAt, inv.SentAt, inv.UpdatedAt, inv.ID); err != nil {
+		return err
+	}
+
+	if is.Send == nil {
+		return nil
+	}
+	return is.Send(ctx, mailer.Message{
+		From:     is.From,
+		To:       inv.Email,
+		Subject:  is.Subject,
+		Template: is.Template,
+		Data: map[string]interface{}{
+			"Link":       is.Link(token),
+			"Invitation": inv,
+			"ExpiresAt":  inv.ExpiresAt,
+		},
+	})
+}
+
+// Link returns the url of AcceptPage for token
+func (is *Invitations) Link(token string) string {
+	sep := "?"
+	if strings.Contains(is.URL, "?") {
+		sep = "&"
+	}
+	return is.URL + sep + "token=" + url.QueryEscape(token)
+}
+
+// Revoke cancels the pending invitation with id, so its link no longer works
+func (is *Invitations) Revoke(ctx context.Context, id int) error {
+	query := is.rebind("update invitations set status = ?, updated_at = ? where id = ? and status = ?")
+	res, err := is.DB.ExecContext(ctx, query, Revoked, clock.Now(is.Clock), id, Pending)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		inv, err := is.Find(ctx, id)
+		if err != nil {
+			return err
+		}
+		return state(inv)
+	}
+	return nil
+}
+
+// state is the error of an invitation which cannot be accepted any more, whatever the link
+func state(inv *Invitation) error {
+	switch inv.Status {
+	case Accepted:
+		return ErrAccepted
+	case Revoked:
+		return ErrRevoked
+	}
+	return nil
+}
+
+// Check returns the pending invitation of token
+func (is *Invitations) Check(ctx context.Context, token string) (*Invitation, error) {
+	if is.Tokens == nil {
+		return nil, ErrInvalid
+	}
+	claims, err := is.Tokens.Verify(token, tokens.Invite)
+	switch {
+	case errors.Is(err, tokens.ErrExpired):
+		return nil, ErrExpired
+	case err != nil:
+		return nil, ErrInvalid
+	}
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+
+	inv, err := is.Find(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	return inv, is.usable(inv, claims.Nonce)
+}
+
+// usable tells whether inv can be accepted with the link of nonce
+func (is *Invitations) usable(inv *Invitation, nonce string) error {
+	if err := state(inv); err != nil {
+		return err
+	}
+	if inv.nonce == "" || inv.nonce != nonce {
+		return ErrInvalid
+	}
+	if inv.Expired(clock.Now(is.Clock)) {
+		return ErrExpired
+	}
+	return nil
+}
+
+// claim marks inv accepted by the user with id, failing when another request accepted or
+// revoked it first
+func (is *Invitations) claim(ctx context.Context, inv *Invitation, userID int) error {
+	now := clock.Now(is.Clock)
+	query := is.rebind("update invitations set status = ?, user_id = ?, accepted_at = ?, updated_at = ? where id = ? and status = ? and nonce = ?")
+	res, err := is.DB.ExecContext(ctx, query, Accepted, userID, now, now, inv.ID, Pending, inv.nonce)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrAccepted
+	}
+	inv.Status, inv.UserID, inv.AcceptedAt, inv.UpdatedAt = Accepted, userID, now, now
+	return nil
+}
+
+// Accept registers u with the invited address and password, and accepts the invitation of
+// token with them
+func (is *Invitations) Accept(ctx context.Context, token string, u *auth.User, password string) (*Invitation, error) {
+	inv, err := is.Check(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = is.Auth.FindByEmail(ctx, inv.Email)
+	if err == nil {
+		return nil, ErrRegistered
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	// the invitation is claimed first so two requests with the link cannot both register
+	if err := is.claim(ctx, inv, 0); err != nil {
+		return nil, err
+	}
+	u.Email = inv.Email
+	u.Active = true
+	if err := is.Auth.Register(ctx, u, password); err != nil {
+		query := is.rebind("update invitations set status = ?, accepted_at = null where id = ?")
+		_, _ = is.DB.ExecContext(ctx, query, Pending, inv.ID)
+		return nil, err
+	}
+
+	query := is.rebind("update invitations set user_id = ? where id = ?")
+	if _, err := is.DB.ExecContext(ctx, query, u.ID, inv.ID); err != nil {
+		return nil, err
+	}
+	inv.UserID = u.ID
+
+	return inv, is.accepted(ctx, inv, u)
+}
+
+// Join accepts the invitation of token with u, a user who already has an account with the
+// invited address
+func (is *Invitations) Join(ctx context.Context, token string, u *auth.User) (*Invitation, error) {
+	inv, err := is.Check(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(inv.Email, u.Email) {
+		return nil, ErrWrongUser
+	}
+
+	if err := is.claim(ctx, inv, u.ID); err != nil {
+		return nil, err
+	}
+	return inv, is.accepted(ctx, inv, u)
+}
+
+func (is *Invitations) accepted(ctx context.Context, inv *Invitation, u *auth.User) error {
+	if is.OnAccept == nil {
+		return nil
+	}
+	return is.OnAccept(ctx, inv, u)
+}
@@ -0,0 +1,119 @@
+package invitations
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/namnguyen191/goravel/auth"
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/tokens"
+)
+
+func newInvitations() (*Invitations, *clock.Test) {
+	c := clock.NewTest(time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC))
+	tk := tokens.New([]byte("0123456789abcdef0123456789abcdef"), nil)
+	tk.Clock = c
+
+	is := New(nil, "postgres", auth.New(nil, "postgres", scs.New(), "myapp"), tk, "https://example.com/invitations/accept")
+	is.Clock = c
+	return is, c
+}
+
+func TestUsable(t *testing.T) {
+	is, c := newInvitations()
+	inv := &Invitation{ID: 7, Status: Pending, nonce: "abc", ExpiresAt: c.Now().Add(time.Hour)}
+
+	if err := is.usable(inv, "abc"); err != nil {
+		t.Errorf("expected the invitation to be usable, got %v", err)
+	}
+	if err := is.usable(inv, "older"); err != ErrInvalid {
+		t.Errorf("expected the link of an earlier send to be invalid, got %v", err)
+	}
+
+	c.Advance(time.Hour)
+	if err := is.usable(inv, "abc"); err != ErrExpired {
+		t.Errorf("expected the invitation to have expired, got %v", err)
+	}
+
+	inv.Status = Revoked
+	if err := is.usable(inv, "abc"); err != ErrRevoked {
+		t.Errorf("expected a revoked invitation, got %v", err)
+	}
+	inv.Status = Accepted
+	if err := is.usable(inv, "abc"); err != ErrAccepted {
+		t.Errorf("expected an accepted invitation, got %v", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	is, c := newInvitations()
+	ctx := context.Background()
+
+	if _, err := is.Check(ctx, "nonsense"); err != ErrInvalid {
+		t.Errorf("expected a malformed link to be invalid, got %v", err)
+	}
+
+	other, _ := is.Tokens.Sign(tokens.ResetPassword, "7", time.Hour)
+	if _, err := is.Check(ctx, other); err != ErrInvalid {
+		t.Errorf("expected a token of another purpose to be invalid, got %v", err)
+	}
+
+	token, _ := is.Tokens.Sign(tokens.Invite, "7", time.Hour)
+	c.Advance(2 * time.Hour)
+	if _, err := is.Check(ctx, token); err != ErrExpired {
+		t.Errorf("expected an expired link, got %v", err)
+	}
+}
+
+func TestLink(t *testing.T) {
+	is, _ := newInvitations()
+
+	link, err := url.Parse(is.Link("a+b/c"))
+	if err != nil || link.Query().Get("token") != "a+b/c" || link.Path != "/invitations/accept" {
+		t.Errorf("unexpected link %v (%v)", link, err)
+	}
+
+	is.URL = "https://example.com/join?team=3"
+	if !strings.HasPrefix(is.Link("x"), "https://example.com/join?team=3&token=") {
+		t.Errorf("expected the token to be added to the query, got %s", is.Link("x"))
+	}
+}
+
+func TestAcceptHandlerForm(t *testing.T) {
+	is, _ := newInvitations()
+	session := is.Auth.Session
+
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/invitations/accept", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rw := httptest.NewRecorder()
+		session.LoadAndSave(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			is.AcceptHandler(rw, r)
+			if msg := session.GetString(r.Context(), "error"); msg == "" {
+				t.Error("expected the problem in the session")
+			}
+		})).ServeHTTP(rw, r)
+		return rw
+	}
+
+	rw := post(url.Values{"token": {"tok"}, "password": {"short"}})
+	if rw.Code != http.StatusSeeOther || !strings.Contains(rw.Header().Get("Location"), "token=tok") {
+		t.Errorf("expected to be sent back to the invitation, got %d %s", rw.Code, rw.Header().Get("Location"))
+	}
+
+	rw = post(url.Values{"token": {"tok"}, "password": {"long enough"}, "password_confirmation": {"different"}})
+	if rw.Code != http.StatusSeeOther {
+		t.Errorf("expected mismatched passwords to be sent back, got %d", rw.Code)
+	}
+
+	rw = post(url.Values{"token": {"forged"}, "password": {"long enough"}})
+	if rw.Code != http.StatusSeeOther {
+		t.Errorf("expected an invalid link to be sent back, got %d", rw.Code)
+	}
+}
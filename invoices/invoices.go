@@ -0,0 +1,296 @@
+package invoices
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+	"github.com/namnguyen191/goravel/money"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// The document kinds with a template of their own
+const (
+	Invoice = "invoice"
+	Receipt = "receipt"
+)
+
+// ErrNoLines is returned when a document without line items is rendered
+var ErrNoLines = errors.New("invoices: document has no line items")
+
+// Party is the seller or the customer printed on a document
+type Party struct {
+	Name    string
+	Email   string
+	Address []string
+	TaxID   string
+}
+
+// Line is one line item; TaxRate is a percentage applied to the line total
+type Line struct {
+	Description string
+	Quantity    float64
+	UnitPrice   money.Money
+	TaxRate     float64
+}
+
+// Total is the line amount before tax
+func (l Line) Total() money.Money {
+	return l.UnitPrice.Mul(l.Quantity)
+}
+
+// Tax is the tax due on the line, rounded per line as most tax authorities expect
+func (l Line) Tax() money.Money {
+	return l.Total().Percent(l.TaxRate)
+}
+
+// Document is an invoice or a receipt
+type Document struct {
+	Kind     string
+	Number   string
+	Currency string
+	From     Party
+	To       Party
+	Lines    []Line
+	IssuedAt time.Time
+	DueAt    time.Time
+	PaidAt   time.Time
+	Notes    string
+}
+
+// Totals are the amounts of a document; Taxes are grouped by rate
+type Totals struct {
+	Subtotal money.Money
+	Tax      money.Money
+	Total    money.Money
+	Taxes    map[float64]money.Money
+}
+
+// Totals adds up the line items
+func (d *Document) Totals() (Totals, error) {
+	t := Totals{
+		Subtotal: money.New(0, d.Currency),
+		Tax:      money.New(0, d.Currency),
+		Taxes:    map[float64]money.Money{},
+	}
+
+	var err error
+	for _, l := range d.Lines {
+		if t.Subtotal, err = t.Subtotal.Add(l.Total()); err != nil {
+			return t, err
+		}
+
+		tax := l.Tax()
+		if t.Tax, err = t.Tax.Add(tax); err != nil {
+			return t, err
+		}
+		if l.TaxRate != 0 {
+			sum, ok := t.Taxes[l.TaxRate]
+			if !ok {
+				sum = money.New(0, d.Currency)
+			}
+			t.Taxes[l.TaxRate], _ = sum.Add(tax)
+		}
+	}
+
+	t.Total, err = t.Subtotal.Add(t.Tax)
+
+	return t, err
+}
+
+// Invoices numbers and renders documents
+type Invoices struct {
+	DB           *sql.DB
+	DatabaseType string
+	// Views is checked for invoice.html.tmpl and receipt.html.tmpl overriding the built-in templates
+	Views string
+	// Format builds a document number from its kind and sequence value
+	Format func(kind string, n int64) string
+	// PDF converts rendered HTML to PDF; it defaults to running wkhtmltopdf
+	PDF func(html []byte) ([]byte, error)
+	// Clock tells the year of the default document numbers; the time of the machine when nil
+	Clock clock.Clock
+}
+
+// New returns invoices numbered from the invoice_sequences table
+func New(db *sql.DB, dbType, views string) *Invoices {
+	inv := &Invoices{
+		DB:           db,
+		DatabaseType: dbType,
+		Views:        views,
+		PDF:          wkhtmltopdf,
+	}
+	inv.Format = func(kind string, n int64) string {
+		prefix := "INV"
+		if kind == Receipt {
+			prefix = "RCT"
+		}
+		return fmt.Sprintf("%s-%d-%05d", prefix, clock.Now(inv.Clock).Year(), n)
+	}
+	return inv
+}
+
+// Next returns the next value of the named sequence. The row is locked by the update,
+// so concurrent callers never get the same value.
+func (inv *Invoices) Next(ctx context.Context, sequence string) (int64, error) {
+	tx, err := inv.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, database.Rebind(inv.DatabaseType, "update invoice_sequences set value = value + 1 where name = ?"), sequence)
+	if err != nil {
+		return 0, err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := tx.ExecContext(ctx, database.Rebind(inv.DatabaseType, "insert into invoice_sequences (name, value) values (?, 1)"), sequence); err != nil {
+			return 0, err
+		}
+	}
+
+	var value int64
+	if err := tx.QueryRowContext(ctx, database.Rebind(inv.DatabaseType, "select value from invoice_sequences where name = ?"), sequence).Scan(&value); err != nil {
+		return 0, err
+	}
+
+	return value, tx.Commit()
+}
+
+// Number assigns the next number of the document kind, when it does not have one yet
+func (inv *Invoices) Number(ctx context.Context, d *Document) error {
+	if d.Number != "" {
+		return nil
+	}
+
+	n, err := inv.Next(ctx, d.Kind)
+	if err != nil {
+		return err
+	}
+	d.Number = inv.Format(d.Kind, n)
+
+	return nil
+}
+
+type page struct {
+	*Document
+	Totals Totals
+}
+
+// HTML renders the document through the template of its kind
+func (inv *Invoices) HTML(d *Document) ([]byte, error) {
+	if len(d.Lines) == 0 {
+		return nil, ErrNoLines
+	}
+	if d.Kind == "" {
+		d.Kind = Invoice
+	}
+
+	totals, err := d.Totals()
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := inv.templates()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, d.Kind+".html.tmpl", page{Document: d, Totals: totals}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// PDFBytes renders the document to PDF
+func (inv *Invoices) PDFBytes(d *Document) ([]byte, error) {
+	html, err := inv.HTML(d)
+	if err != nil {
+		return nil, err
+	}
+
+	return inv.PDF(html)
+}
+
+// Write sends the document as pdf or html; PDFs are sent as attachments named after the number
+func (inv *Invoices) Write(rw http.ResponseWriter, d *Document, format string) error {
+	if strings.ToLower(format) == "pdf" {
+		b, err := inv.PDFBytes(d)
+		if err != nil {
+			return err
+		}
+		rw.Header().Set("Content-Type", "application/pdf")
+		rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", d.Number+".pdf"))
+		_, err = rw.Write(b)
+		return err
+	}
+
+	b, err := inv.HTML(d)
+	if err != nil {
+		return err
+	}
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, err = rw.Write(b)
+
+	return err
+}
+
+// templates parses the built-in templates, then any overrides found in Views
+func (inv *Invoices) templates() (*template.Template, error) {
+	tmpl, err := template.New("invoices").Funcs(template.FuncMap{
+		"date": func(t time.Time) string {
+			if t.IsZero() {
+				return ""
+			}
+			return t.Format("January 2, 2006")
+		},
+		"qty": func(f float64) string { return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.3f", f), "0"), ".") },
+	}).ParseFS(templateFS, "templates/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	if inv.Views == "" {
+		return tmpl, nil
+	}
+
+	files, _ := filepath.Glob(filepath.Join(inv.Views, "*.tmpl"))
+	if len(files) == 0 {
+		return tmpl, nil
+	}
+
+	return tmpl.ParseFiles(files...)
+}
+
+func wkhtmltopdf(html []byte) ([]byte, error) {
+	var out, stderr bytes.Buffer
+
+	cmd := exec.Command("wkhtmltopdf", "--quiet", "-", "-")
+	cmd.Stdin = bytes.NewReader(html)
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	cmd.Env = os.Environ()
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("invoices: wkhtmltopdf: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return out.Bytes(), nil
+}
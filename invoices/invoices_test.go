@@ -0,0 +1,82 @@
+package invoices
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/namnguyen191/goravel/money"
+)
+
+func document() *Document {
+	return &Document{
+		Number:   "INV-2021-00001",
+		Currency: "USD",
+		From:     Party{Name: "Acme Inc."},
+		To:       Party{Name: "Ann <Customer>"},
+		IssuedAt: time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC),
+		Lines: []Line{
+			{Description: "Pro plan", Quantity: 1, UnitPrice: money.New(4900, "USD"), TaxRate: 10},
+			{Description: "Extra seats", Quantity: 3, UnitPrice: money.New(999, "USD"), TaxRate: 10},
+			{Description: "Setup fee", Quantity: 1, UnitPrice: money.New(2500, "USD")},
+		},
+	}
+}
+
+func TestTotals(t *testing.T) {
+	totals, err := document().Totals()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if totals.Subtotal.Amount != 10397 || totals.Tax.Amount != 790 || totals.Total.Amount != 11187 {
+		t.Errorf("unexpected totals %+v", totals)
+	}
+	if len(totals.Taxes) != 1 || totals.Taxes[10].Amount != 790 {
+		t.Errorf("unexpected taxes %v", totals.Taxes)
+	}
+
+	d := document()
+	d.Lines[0].UnitPrice = money.New(4900, "EUR")
+	if _, err := d.Totals(); err != money.ErrCurrencyMismatch {
+		t.Error("expected currency mismatch, got", err)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	inv := New(nil, "postgres", "")
+
+	b, err := inv.HTML(document())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	html := string(b)
+	for _, want := range []string{"Invoice INV-2021-00001", "$111.87", "July 1, 2021", "Ann &lt;Customer&gt;"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %q in invoice", want)
+		}
+	}
+
+	if _, err := inv.HTML(&Document{}); err != ErrNoLines {
+		t.Error("expected no lines error, got", err)
+	}
+}
+
+func TestOverride(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "receipt.html.tmpl"), []byte(`{{define "receipt.html.tmpl"}}paid {{.Totals.Total}}{{end}}`), 0644)
+
+	d := document()
+	d.Kind = Receipt
+
+	b, err := New(nil, "postgres", dir).HTML(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "paid $111.87" {
+		t.Errorf("unexpected receipt %q", b)
+	}
+}
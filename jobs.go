@@ -0,0 +1,246 @@
+package goravel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/namnguyen191/goravel/jobs"
+	"github.com/namnguyen191/goravel/kv"
+	"github.com/namnguyen191/goravel/queuedash"
+)
+
+// createJobs returns the job queue of QUEUE. Redis jobs are shared by every instance and
+// run by RunWorker; badger and memory jobs only exist in this process, so it runs them
+// itself while it serves. The workflows, exports, imports and comment events of the models
+// step are jobs of this queue as well, each registering its handler as it is set up.
+func (grv *Goravel) createJobs() (*jobs.Jobs, error) {
+	var queue jobs.Queue
+	var store jobs.Store = &jobs.MemoryStore{Clock: grv.Clock}
+	local := true
+
+	switch driver := strings.ToLower(grv.Env.String("QUEUE", "memory")); driver {
+	case "redis":
+		if redisPool == nil {
+			return nil, fmt.Errorf("QUEUE=redis needs REDIS_HOST")
+		}
+		// the queue updates several keys at once, which a cluster only allows within a slot
+		prefix := grv.Namespace
+		if len(grv.config.redis.cluster) > 0 {
+			prefix = "{" + prefix + "}"
+		}
+		queue = &jobs.RedisQueue{Pool: redisPool, Prefix: prefix, Clock: grv.Clock}
+		store = &jobs.RedisStore{Pool: redisPool, Prefix: prefix}
+		local = false
+	case "badger":
+		kvStore := grv.KV
+		if kvStore == nil {
+			var err error
+			grv.jobsKV, err = kv.Open(filepath.Join(grv.RootPath, "tmp", "jobs"), grv.Namespace)
+			if err != nil {
+				return nil, err
+			}
+			grv.jobsKV.Clock = grv.Clock
+			kvStore = grv.jobsKV
+		}
+		queue = &jobs.BadgerQueue{KV: kvStore, Clock: grv.Clock}
+		store = &jobs.KVStore{KV: kvStore}
+	case "", "memory":
+		memory := jobs.NewMemoryQueue()
+		memory.Clock = grv.Clock
+		queue = memory
+	default:
+		return nil, fmt.Errorf("unknown QUEUE %q", driver)
+	}
+
+	j := jobs.New(queue)
+	j.Workers = grv.Env.Int("QUEUE_WORKERS", 5)
+	pools, err := jobs.ParsePools(grv.Env.String("QUEUE_POOLS", ""))
+	if err != nil {
+		return nil, err
+	}
+	j.Pools = pools
+	j.MaxAttempts = grv.Env.Int("QUEUE_TRIES", 3)
+	j.Store = store
+	j.ErrorLog = grv.ErrorLog.Println
+	j.Clock = grv.Clock
+
+	if local {
+		grv.workLocally(j)
+	} else {
+		grv.Worker("jobs", j.Work)
+	}
+
+	return j, nil
+}
+
+// workLocally runs the jobs from the moment the process starts serving until it stops
+func (grv *Goravel) workLocally(j *jobs.Jobs) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	grv.OnBoot(func() error {
+		go func() {
+			defer close(done)
+			_ = j.Work(ctx)
+		}()
+		return nil
+	})
+
+	grv.OnShutdown(func(shutdown context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-shutdown.Done():
+			return fmt.Errorf("jobs: still running: %w", shutdown.Err())
+		}
+	})
+}
+
+// HandleJob registers the handler of a job type, wrapped in mw, e.g. in the init of the app:
+//
+//	app.HandleJob("welcome-email", jobs.HandlerFunc(func(ctx context.Context, job *jobs.Job) error {
+//		var user data.User
+//		if err := job.Decode(&user); err != nil {
+//			return err
+//		}
+//		return sendWelcome(ctx, user)
+//	}), app.Jobs.UniqueFor(time.Hour))
+//
+// and pushed from a handler with app.Jobs.Push(r.Context(), "welcome-email", user).
+func (grv *Goravel) HandleJob(jobType string, h jobs.Handler, mw ...jobs.Middleware) {
+	grv.Jobs.Handle(jobType, h, mw...)
+}
+
+// pushID returns a Dispatch func pushing a job of jobType with the id of what it works on
+func (grv *Goravel) pushID(jobType string) func(ctx context.Context, id int) error {
+	return func(ctx context.Context, id int) error {
+		_, err := grv.Jobs.Push(ctx, jobType, id)
+		return err
+	}
+}
+
+// handleID registers run as the handler of the jobs of pushID
+func (grv *Goravel) handleID(jobType string, run func(ctx context.Context, id int) error) {
+	grv.Jobs.HandleFunc(jobType, func(ctx context.Context, job *jobs.Job) error {
+		var id int
+		if err := job.Decode(&id); err != nil {
+			return err
+		}
+		return run(ctx, id)
+	})
+}
+
+// QueueDashboard returns the dashboard of the job queue, to be mounted with
+// app.Routes.Mount("/queues", app.QueueDashboard().Routes()). Besides the overview it answers
+// the progress of a job at /queues/jobs/{id} and cancels it with a post to
+// /queues/jobs/{id}/cancel.
+func (grv *Goravel) QueueDashboard() *queuedash.Dashboard {
+	return queuedash.New(dashSource{grv.Jobs}, grv.Session)
+}
+
+// dashSource feeds the queue dashboard from the jobs
+type dashSource struct {
+	jobs *jobs.Jobs
+}
+
+func (s dashSource) Stats() ([]queuedash.QueueStats, error) {
+	stats, err := s.jobs.Queue.Stats(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]queuedash.QueueStats, 0, len(stats))
+	for _, q := range stats {
+		list = append(list, queuedash.QueueStats{
+			Name:      q.Queue,
+			Pending:   q.Pending + q.Reserved,
+			Delayed:   q.Delayed,
+			Processed: q.Processed,
+			Failed:    q.Failed,
+		})
+	}
+	return list, nil
+}
+
+func (s dashSource) Failed(limit int) ([]queuedash.FailedJob, error) {
+	failed, err := s.jobs.Queue.Failed(context.Background(), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]queuedash.FailedJob, 0, len(failed))
+	for _, job := range failed {
+		// a panic has its stack after the first line of the error
+		msg, stack := job.Error, ""
+		if i := strings.Index(msg, "\n"); i >= 0 {
+			msg, stack = msg[:i], msg[i+1:]
+		}
+		list = append(list, queuedash.FailedJob{
+			ID:       job.ID,
+			Queue:    job.Queue,
+			Type:     job.Type,
+			Payload:  string(job.Payload),
+			Error:    msg,
+			Stack:    stack,
+			Attempts: job.Attempts,
+			FailedAt: job.FailedAt,
+		})
+	}
+	return list, nil
+}
+
+func (s dashSource) Retry(id string) error {
+	return s.jobs.Queue.Revive(context.Background(), id)
+}
+
+func (s dashSource) Forget(id string) error {
+	return s.jobs.Queue.Forget(context.Background(), id)
+}
+
+func (s dashSource) Workers() ([]queuedash.WorkerStatus, error) {
+	statuses := s.jobs.Statuses()
+	list := make([]queuedash.WorkerStatus, 0, len(statuses))
+	for _, w := range statuses {
+		status := queuedash.WorkerStatus{ID: w.ID, Queue: w.Queue, Busy: w.Busy, Job: w.Job, JobID: w.JobID, LastSeen: w.LastSeen}
+		if w.Busy {
+			status.Progress, _ = s.Progress(w.JobID)
+		}
+		list = append(list, status)
+	}
+	return list, nil
+}
+
+func (s dashSource) Progress(id string) (*queuedash.JobProgress, error) {
+	p, err := s.jobs.Progress(context.Background(), id)
+	if errors.Is(err, jobs.ErrNotFound) {
+		return nil, queuedash.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &queuedash.JobProgress{
+		ID:              p.ID,
+		Type:            p.Type,
+		State:           p.State,
+		Percent:         p.Percent,
+		Message:         p.Message,
+		CancelRequested: p.CancelRequested,
+		UpdatedAt:       p.UpdatedAt,
+	}, nil
+}
+
+func (s dashSource) Cancel(id string) error {
+	err := s.jobs.Cancel(context.Background(), id)
+	if errors.Is(err, jobs.ErrNotFound) {
+		return queuedash.ErrNotFound
+	}
+	// a job which finished in the meantime has nothing left to cancel
+	if errors.Is(err, jobs.ErrFinished) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,282 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/kv"
+)
+
+// errStop ends a scan early
+var errStop = errors.New("stop")
+
+// BadgerQueue keeps jobs in a badger key-value store, which survives restarts but is only
+// open in one process, so the jobs are worked by the web process itself. Ready jobs are
+// keyed by queue and time, so they are scanned in the order they are due.
+type BadgerQueue struct {
+	KV *kv.KV
+	// Clock tells when the delayed jobs are due; the time of the machine when nil
+	Clock clock.Clock
+}
+
+func dataKey(id string) string {
+	return "jobs:data:" + id
+}
+
+func readyKey(job *Job) string {
+	return fmt.Sprintf("jobs:ready:%s:%020d:%s", job.Queue, job.RunAt.UnixNano(), job.ID)
+}
+
+func reservedKey(queue, id string) string {
+	return "jobs:reserved:" + queue + ":" + id
+}
+
+func failedKey(queue, id string) string {
+	return "jobs:failed:" + queue + ":" + id
+}
+
+func (q *BadgerQueue) Push(ctx context.Context, job *Job) error {
+	return q.KV.Update(func(tx *kv.Tx) error {
+		if err := tx.Set(dataKey(job.ID), job, 0); err != nil {
+			return err
+		}
+		if err := tx.Set("jobs:queues:"+job.Queue, true, 0); err != nil {
+			return err
+		}
+		return tx.Set(readyKey(job), job.ID, 0)
+	})
+}
+
+func (q *BadgerQueue) Reserve(ctx context.Context, queue string, lease time.Duration) (*Job, error) {
+	var reserved *Job
+
+	err := q.KV.Update(func(tx *kv.Tx) error {
+		reserved = nil
+		now := clock.Now(q.Clock)
+
+		// jobs whose worker did not finish in time are due again
+		var expired []string
+		err := tx.Scan("jobs:reserved:"+queue+":", func(key string, v kv.Value) error {
+			var until time.Time
+			if err := v.Decode(&until); err != nil {
+				return err
+			}
+			if until.Before(now) {
+				expired = append(expired, strings.TrimPrefix(key, "jobs:reserved:"+queue+":"))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, id := range expired {
+			if err := tx.Delete(reservedKey(queue, id)); err != nil {
+				return err
+			}
+			var job Job
+			if found, err := tx.Get(dataKey(id), &job); err != nil || !found {
+				continue
+			}
+			job.RunAt = now
+			if err := tx.Set(readyKey(&job), job.ID, 0); err != nil {
+				return err
+			}
+		}
+
+		var key, id string
+		err = tx.Scan("jobs:ready:"+queue+":", func(k string, v kv.Value) error {
+			parts := strings.Split(strings.TrimPrefix(k, "jobs:ready:"+queue+":"), ":")
+			if at, err := strconv.ParseInt(parts[0], 10, 64); err == nil && at <= now.UnixNano() && len(parts) == 2 {
+				key, id = k, parts[1]
+			}
+			return errStop
+		})
+		if err != nil && err != errStop {
+			return err
+		}
+		if key == "" {
+			return nil
+		}
+
+		if err := tx.Delete(key); err != nil {
+			return err
+		}
+
+		var job Job
+		found, err := tx.Get(dataKey(id), &job)
+		if err != nil || !found {
+			return err
+		}
+		job.Attempts++
+		if err := tx.Set(dataKey(id), &job, 0); err != nil {
+			return err
+		}
+		if err := tx.Set(reservedKey(queue, id), now.Add(lease), 0); err != nil {
+			return err
+		}
+		reserved = &job
+		return nil
+	})
+
+	return reserved, err
+}
+
+func (q *BadgerQueue) Ack(ctx context.Context, job *Job) error {
+	return q.KV.Update(func(tx *kv.Tx) error {
+		if err := tx.Delete(reservedKey(job.Queue, job.ID)); err != nil {
+			return err
+		}
+		if err := tx.Delete(dataKey(job.ID)); err != nil {
+			return err
+		}
+		_, err := tx.Incr("jobs:processed:"+job.Queue, 1)
+		return err
+	})
+}
+
+func (q *BadgerQueue) Retry(ctx context.Context, job *Job) error {
+	return q.KV.Update(func(tx *kv.Tx) error {
+		if err := tx.Delete(reservedKey(job.Queue, job.ID)); err != nil {
+			return err
+		}
+		if err := tx.Set(dataKey(job.ID), job, 0); err != nil {
+			return err
+		}
+		return tx.Set(readyKey(job), job.ID, 0)
+	})
+}
+
+func (q *BadgerQueue) Bury(ctx context.Context, job *Job) error {
+	return q.KV.Update(func(tx *kv.Tx) error {
+		if err := tx.Delete(reservedKey(job.Queue, job.ID)); err != nil {
+			return err
+		}
+		if err := tx.Set(dataKey(job.ID), job, 0); err != nil {
+			return err
+		}
+		return tx.Set(failedKey(job.Queue, job.ID), job.FailedAt, 0)
+	})
+}
+
+func (q *BadgerQueue) Stats(ctx context.Context) ([]Stats, error) {
+	byQueue := map[string]*Stats{}
+
+	err := q.KV.View(func(tx *kv.Tx) error {
+		var names []string
+		err := tx.Scan("jobs:queues:", func(key string, v kv.Value) error {
+			names = append(names, strings.TrimPrefix(key, "jobs:queues:"))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		now := clock.Now(q.Clock).UnixNano()
+		for _, name := range names {
+			s := &Stats{Queue: name}
+			byQueue[name] = s
+
+			err := tx.Scan("jobs:ready:"+name+":", func(key string, v kv.Value) error {
+				at, _ := strconv.ParseInt(strings.SplitN(strings.TrimPrefix(key, "jobs:ready:"+name+":"), ":", 2)[0], 10, 64)
+				if at <= now {
+					s.Pending++
+				} else {
+					s.Delayed++
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if err := tx.Scan("jobs:reserved:"+name+":", func(string, kv.Value) error { s.Reserved++; return nil }); err != nil {
+				return err
+			}
+			if err := tx.Scan("jobs:failed:"+name+":", func(string, kv.Value) error { s.Failed++; return nil }); err != nil {
+				return err
+			}
+
+			var processed int64
+			if _, err := tx.Get("jobs:processed:"+name, &processed); err != nil {
+				return err
+			}
+			s.Processed = int(processed)
+		}
+		return nil
+	})
+
+	return sortStats(byQueue), err
+}
+
+func (q *BadgerQueue) Failed(ctx context.Context, limit int) ([]*Job, error) {
+	var list []*Job
+
+	err := q.KV.View(func(tx *kv.Tx) error {
+		var ids []string
+		err := tx.Scan("jobs:failed:", func(key string, v kv.Value) error {
+			ids = append(ids, key[strings.LastIndex(key, ":")+1:])
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			var job Job
+			if found, err := tx.Get(dataKey(id), &job); err != nil {
+				return err
+			} else if found {
+				list = append(list, &job)
+			}
+		}
+		return nil
+	})
+
+	return latest(list, limit), err
+}
+
+// takeFailed removes the dead letter id in tx, returning it
+func takeFailed(tx *kv.Tx, id string) (*Job, error) {
+	var job Job
+	found, err := tx.Get(dataKey(id), &job)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+	if ok, err := tx.Get(failedKey(job.Queue, id), nil); err != nil || !ok {
+		if err == nil {
+			err = ErrNotFound
+		}
+		return nil, err
+	}
+	return &job, tx.Delete(failedKey(job.Queue, id))
+}
+
+func (q *BadgerQueue) Revive(ctx context.Context, id string) error {
+	return q.KV.Update(func(tx *kv.Tx) error {
+		job, err := takeFailed(tx, id)
+		if err != nil {
+			return err
+		}
+		job = revived(job, clock.Now(q.Clock))
+		if err := tx.Set(dataKey(id), job, 0); err != nil {
+			return err
+		}
+		return tx.Set(readyKey(job), id, 0)
+	})
+}
+
+func (q *BadgerQueue) Forget(ctx context.Context, id string) error {
+	return q.KV.Update(func(tx *kv.Tx) error {
+		if _, err := takeFailed(tx, id); err != nil {
+			return err
+		}
+		return tx.Delete(dataKey(id))
+	})
+}
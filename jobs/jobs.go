@@ -0,0 +1,489 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/concurrency"
+	"github.com/namnguyen191/goravel/trace"
+)
+
+// Default is the queue jobs are pushed to unless OnQueue says otherwise
+const Default = "default"
+
+// Job is a unit of work pushed by a request and run later by a worker
+type Job struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Queue       string          `json:"queue"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	// Error is the error of the last attempt
+	Error    string    `json:"error,omitempty"`
+	FailedAt time.Time `json:"failed_at,omitempty"`
+	// RequestID is the id of the request which pushed the job, so its logs can be traced back
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Decode unmarshals the payload into dst
+func (j *Job) Decode(dst interface{}) error {
+	return json.Unmarshal(j.Payload, dst)
+}
+
+// Handler runs the jobs of a type
+type Handler interface {
+	Handle(ctx context.Context, job *Job) error
+}
+
+// HandlerFunc is a function used as a Handler
+type HandlerFunc func(ctx context.Context, job *Job) error
+
+func (f HandlerFunc) Handle(ctx context.Context, job *Job) error {
+	return f(ctx, job)
+}
+
+// Stats describes one queue
+type Stats struct {
+	Queue string
+	// Pending jobs are due and waiting for a worker
+	Pending int
+	// Delayed jobs wait for their RunAt, including retries waiting for their backoff
+	Delayed   int
+	Reserved  int
+	Processed int
+	Failed    int
+}
+
+// Queue stores jobs. Implementations hand a job to a single worker at a time and give it
+// back to the others when the worker does not finish within its lease, e.g. when the process
+// was killed.
+type Queue interface {
+	// Push stores job, to be reserved once its RunAt has passed
+	Push(ctx context.Context, job *Job) error
+	// Reserve takes the job of queue which has been due the longest and counts the attempt;
+	// it returns nil when no job is due
+	Reserve(ctx context.Context, queue string, lease time.Duration) (*Job, error)
+	// Ack removes a reserved job which ran
+	Ack(ctx context.Context, job *Job) error
+	// Retry gives a reserved job back, to run again at its RunAt
+	Retry(ctx context.Context, job *Job) error
+	// Bury moves a reserved job which ran out of attempts to the dead letters
+	Bury(ctx context.Context, job *Job) error
+	Stats(ctx context.Context) ([]Stats, error)
+	// Failed returns the dead letters, the most recent first
+	Failed(ctx context.Context, limit int) ([]*Job, error)
+	// Revive pushes a dead letter again with fresh attempts
+	Revive(ctx context.Context, id string) error
+	// Forget deletes a dead letter
+	Forget(ctx context.Context, id string) error
+}
+
+// ErrNotFound is returned for a dead letter which does not exist
+var ErrNotFound = errors.New("jobs: job not found")
+
+var errNoHandler = errors.New("jobs: no handler")
+
+// Option changes a job as it is pushed
+type Option func(job *Job)
+
+// OnQueue pushes the job to the named queue
+func OnQueue(name string) Option {
+	return func(job *Job) { job.Queue = name }
+}
+
+// Attempts overrides how many times the job is tried before it is buried
+func Attempts(n int) Option {
+	return func(job *Job) { job.MaxAttempts = n }
+}
+
+// WorkerStatus describes a worker of this process
+type WorkerStatus struct {
+	ID       string
+	Queue    string
+	Busy     bool
+	Job      string
+	JobID    string
+	LastSeen time.Time
+}
+
+// Jobs pushes jobs and runs them with the registered handlers
+type Jobs struct {
+	Queue Queue
+	// Queues are the queues Work takes jobs from, the first having priority over the next
+	Queues []string
+	// Workers is how many jobs run at once
+	Workers int
+	// Pools, when set, replace Queues and Workers with groups of workers of their own, so
+	// slow jobs on one queue cannot hold up the others
+	Pools []Pool
+	// MaxAttempts is how many times a job is tried, unless it was pushed with Attempts
+	MaxAttempts int
+	// Lease is how long a job is hidden from other workers while it runs
+	Lease time.Duration
+	// Poll is how long an idle worker waits before looking for jobs again
+	Poll time.Duration
+	// Backoff is how long a failed job waits before its next attempt
+	Backoff func(attempts int) time.Duration
+	// Store keeps the state of UniqueFor and Throttle; it should be shared by every process
+	// working the queue
+	Store Store
+	// ProgressTTL is how long the progress of a job is kept after its last change
+	ProgressTTL time.Duration
+	ErrorLog    func(v ...interface{})
+	// Clock tells when delayed and retried jobs are due; the time of the machine when nil
+	Clock clock.Clock
+
+	mu         sync.RWMutex
+	handlers   map[string]Handler
+	middleware []Middleware
+	status     map[string]*WorkerStatus
+}
+
+// New returns jobs stored in queue, run by 5 workers and tried 3 times
+func New(queue Queue) *Jobs {
+	return &Jobs{
+		Queue:       queue,
+		Queues:      []string{Default},
+		Workers:     5,
+		MaxAttempts: 3,
+		Lease:       5 * time.Minute,
+		Poll:        time.Second,
+		Backoff:     Exponential(5*time.Second, time.Hour),
+		Store:       &MemoryStore{},
+		ProgressTTL: 24 * time.Hour,
+		ErrorLog:    log.Println,
+		handlers:    map[string]Handler{},
+		status:      map[string]*WorkerStatus{},
+	}
+}
+
+// Exponential doubles the wait after each attempt, from base up to max
+func Exponential(base, max time.Duration) func(attempts int) time.Duration {
+	return func(attempts int) time.Duration {
+		d := base
+		for i := 1; i < attempts && d < max; i++ {
+			d *= 2
+		}
+		if d > max {
+			d = max
+		}
+		return d
+	}
+}
+
+// Handle registers the handler of a job type, wrapped in mw within the middleware of Use,
+// e.g. j.Handle("sync-crm", h, j.UniqueFor(time.Hour), jobs.Timeout(time.Minute))
+func (j *Jobs) Handle(jobType string, h Handler, mw ...Middleware) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.handlers[jobType] = Chain(h, mw...)
+}
+
+// HandleFunc registers a function as the handler of a job type
+func (j *Jobs) HandleFunc(jobType string, fn func(ctx context.Context, job *Job) error, mw ...Middleware) {
+	j.Handle(jobType, HandlerFunc(fn), mw...)
+}
+
+func (j *Jobs) handler(jobType string) Handler {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	h := j.handlers[jobType]
+	if h == nil {
+		return nil
+	}
+	return Chain(h, j.middleware...)
+}
+
+// Push queues a job of jobType with payload encoded as json, keeping the request id of ctx
+func (j *Jobs) Push(ctx context.Context, jobType string, payload interface{}, opts ...Option) (*Job, error) {
+	return j.PushDelayed(ctx, 0, jobType, payload, opts...)
+}
+
+// PushDelayed queues a job which runs once delay has passed
+func (j *Jobs) PushDelayed(ctx context.Context, delay time.Duration, jobType string, payload interface{}, opts ...Option) (*Job, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("jobs: %s payload: %w", jobType, err)
+	}
+
+	now := clock.Now(j.Clock)
+	job := &Job{
+		ID:          NewID(),
+		Type:        jobType,
+		Queue:       Default,
+		Payload:     body,
+		MaxAttempts: j.MaxAttempts,
+		RunAt:       now.Add(delay),
+		CreatedAt:   now,
+		RequestID:   trace.ID(ctx),
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	if err := j.Queue.Push(ctx, job); err != nil {
+		return nil, err
+	}
+	j.progress(ctx, job, Queued, 0)
+	return job, nil
+}
+
+// PruneFailed forgets the dead letters which failed more than olderThan ago, returning how
+// many it removed. A dead letter revived or forgotten meanwhile, e.g. by another instance,
+// is skipped.
+func (j *Jobs) PruneFailed(ctx context.Context, olderThan time.Duration) (int, error) {
+	failed, err := j.Queue.Failed(ctx, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	before := clock.Now(j.Clock).Add(-olderThan)
+	removed := 0
+	for _, job := range failed {
+		if !job.FailedAt.Before(before) {
+			continue
+		}
+		if err := j.Queue.Forget(ctx, job.ID); err != nil && err != ErrNotFound {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// NewID returns a random id which sorts by creation time
+func NewID() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%016x%s", time.Now().UnixNano(), hex.EncodeToString(b))
+}
+
+// Pool is a group of workers taking jobs from Queues, in order of priority
+type Pool struct {
+	Queues  []string
+	Workers int
+}
+
+// ParsePools reads pools written as queues by priority and a worker count, a pool per
+// semicolon, e.g. "emails,default:5;exports:1" for five workers on emails then default
+// and one on exports. An empty s has no pools.
+func ParsePools(s string) ([]Pool, error) {
+	var pools []Pool
+	for _, def := range strings.Split(s, ";") {
+		def = strings.TrimSpace(def)
+		if def == "" {
+			continue
+		}
+
+		pool := Pool{Workers: 1}
+		if i := strings.LastIndex(def, ":"); i >= 0 {
+			n, err := strconv.Atoi(strings.TrimSpace(def[i+1:]))
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("jobs: pool %q: bad worker count", def)
+			}
+			pool.Workers, def = n, def[:i]
+		}
+		for _, q := range strings.Split(def, ",") {
+			if q = strings.TrimSpace(q); q != "" {
+				pool.Queues = append(pool.Queues, q)
+			}
+		}
+		if len(pool.Queues) == 0 {
+			return nil, fmt.Errorf("jobs: pool %q has no queues", def)
+		}
+
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
+
+// Work runs jobs with the workers of Pools, or of Workers, until ctx is done, then waits for
+// the jobs running
+func (j *Jobs) Work(ctx context.Context) error {
+	pools := j.Pools
+	if len(pools) == 0 {
+		pools = []Pool{{Queues: j.Queues, Workers: j.Workers}}
+	}
+
+	var wg sync.WaitGroup
+	n := 0
+	for _, pool := range pools {
+		for i := 0; i < pool.Workers; i++ {
+			n++
+			wg.Add(1)
+			go func(id string, queues []string) {
+				defer wg.Done()
+				j.work(ctx, id, queues)
+			}(fmt.Sprintf("%s-%d", NewID()[16:], n), pool.Queues)
+		}
+	}
+	wg.Wait()
+	return nil
+}
+
+func (j *Jobs) work(ctx context.Context, id string, queues []string) {
+	status := &WorkerStatus{ID: id}
+	j.mu.Lock()
+	j.status[id] = status
+	j.mu.Unlock()
+
+	defer func() {
+		j.mu.Lock()
+		delete(j.status, id)
+		j.mu.Unlock()
+	}()
+
+	for ctx.Err() == nil {
+		ran := false
+		for _, queue := range queues {
+			job, err := j.Queue.Reserve(ctx, queue, j.Lease)
+			if err != nil {
+				if ctx.Err() == nil {
+					j.ErrorLog("jobs:", queue, err)
+				}
+				break
+			}
+			if job == nil {
+				continue
+			}
+
+			j.setStatus(status, job)
+			j.process(job)
+			j.setStatus(status, nil)
+			ran = true
+			break
+		}
+
+		if !ran {
+			j.setStatus(status, nil)
+			select {
+			case <-ctx.Done():
+			case <-time.After(j.Poll):
+			}
+		}
+	}
+}
+
+func (j *Jobs) setStatus(status *WorkerStatus, job *Job) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	status.LastSeen = clock.Now(j.Clock)
+	status.Busy = job != nil
+	status.Job, status.JobID, status.Queue = "", "", ""
+	if job != nil {
+		status.Job, status.JobID, status.Queue = job.Type, job.ID, job.Queue
+	}
+}
+
+// Statuses returns the workers of this process, sorted by id
+func (j *Jobs) Statuses() []WorkerStatus {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	list := make([]WorkerStatus, 0, len(j.status))
+	for _, s := range j.status {
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].ID < list[b].ID })
+	return list
+}
+
+// process runs a reserved job, then acks, retries or buries it. The job runs to the end
+// once started, even when the worker is stopping; Lease bounds how long that may take.
+func (j *Jobs) process(job *Job) {
+	ctx, cancel := context.WithTimeout(trace.WithID(context.Background(), job.RequestID), j.Lease)
+	defer cancel()
+
+	// a job cancelled while it waited is dropped without running
+	if requested, err := j.cancelRequested(ctx, job.ID); err == nil && requested {
+		j.cancelled(ctx, job)
+		return
+	}
+	j.progress(ctx, job, Running, 0)
+
+	t := &tracker{jobs: j, job: job}
+	runCtx, cancelRun := context.WithCancel(context.WithValue(ctx, trackerKey{}, t))
+	stop := j.watch(runCtx, t, cancelRun)
+	err := j.run(runCtx, job)
+	stop()
+	cancelRun()
+
+	// whatever a cancelled job returned, it should not run again
+	if t.isCancelled() || errors.Is(err, ErrCancelled) {
+		j.cancelled(ctx, job)
+		return
+	}
+
+	if err == nil {
+		j.progress(ctx, job, Done, 100)
+		if err := j.Queue.Ack(ctx, job); err != nil {
+			j.ErrorLog("jobs: ack", job.Type, job.ID, err)
+		}
+		return
+	}
+
+	var release *ReleaseError
+	if errors.As(err, &release) {
+		// a released job did not really run, so the attempt does not count
+		job.Attempts--
+		job.RunAt = clock.Now(j.Clock).Add(release.Delay)
+		if err := j.Queue.Retry(ctx, job); err != nil {
+			j.ErrorLog("jobs:", job.Type, job.ID, err)
+		}
+		return
+	}
+
+	job.Error = err.Error()
+	if p, ok := err.(*concurrency.PanicError); ok {
+		job.Error += "\n" + string(p.Stack)
+	}
+
+	// nothing can run a job without handler, retrying it would not help
+	if job.Attempts >= job.MaxAttempts || errors.Is(err, errNoHandler) {
+		job.FailedAt = clock.Now(j.Clock)
+		j.ErrorLog("jobs:", job.Type, job.ID, "failed after", job.Attempts, "attempts:", err)
+		j.progress(ctx, job, Failed, -1)
+		err = j.Queue.Bury(ctx, job)
+	} else {
+		job.RunAt = clock.Now(j.Clock).Add(j.Backoff(job.Attempts))
+		j.progress(ctx, job, Retrying, -1)
+		err = j.Queue.Retry(ctx, job)
+	}
+	if err != nil {
+		j.ErrorLog("jobs:", job.Type, job.ID, err)
+	}
+}
+
+// run calls the handler of job, turning a panic into a *concurrency.PanicError
+func (j *Jobs) run(ctx context.Context, job *Job) (err error) {
+	h := j.handler(job.Type)
+	if h == nil {
+		return fmt.Errorf("%w for %s", errNoHandler, job.Type)
+	}
+
+	defer func() {
+		if v := recover(); v != nil {
+			err = &concurrency.PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+
+	return h.Handle(ctx, job)
+}
@@ -0,0 +1,336 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/dgraph-io/badger/v3"
+	"github.com/gomodule/redigo/redis"
+	"github.com/namnguyen191/goravel/kv"
+	"github.com/namnguyen191/goravel/trace"
+)
+
+func queues(t *testing.T) map[string]Queue {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	pool := &redis.Pool{Dial: func() (redis.Conn, error) { return redis.Dial("tcp", s.Addr()) }}
+	return map[string]Queue{
+		"memory": NewMemoryQueue(),
+		"redis":  &RedisQueue{Pool: pool, Prefix: "test"},
+		"badger": &BadgerQueue{KV: kv.New(db, "test")},
+	}
+}
+
+func newJobs(q Queue) *Jobs {
+	j := New(q)
+	j.Workers = 2
+	j.Poll = time.Millisecond
+	j.Backoff = func(int) time.Duration { return 0 }
+	j.ErrorLog = func(...interface{}) {}
+	return j
+}
+
+// work runs j until done returns true or a second has passed
+func work(t *testing.T, j *Jobs, done func() bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	go func() {
+		for ctx.Err() == nil && !done() {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+	_ = j.Work(ctx)
+}
+
+func stats(t *testing.T, q Queue) Stats {
+	list, err := q.Stats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range list {
+		if s.Queue == Default {
+			return s
+		}
+	}
+	return Stats{}
+}
+
+func TestWork(t *testing.T) {
+	for name, q := range queues(t) {
+		j := newJobs(q)
+
+		var sum int64
+		j.HandleFunc("add", func(ctx context.Context, job *Job) error {
+			var n int64
+			if err := job.Decode(&n); err != nil {
+				return err
+			}
+			if trace.ID(ctx) != "req-1" {
+				t.Error(name, "request id not kept:", trace.ID(ctx))
+			}
+			atomic.AddInt64(&sum, n)
+			return nil
+		})
+
+		ctx := trace.WithID(context.Background(), "req-1")
+		for i := 1; i <= 4; i++ {
+			if _, err := j.Push(ctx, "add", i); err != nil {
+				t.Fatal(name, err)
+			}
+		}
+		if s := stats(t, q); s.Pending != 4 {
+			t.Errorf("%s: expected 4 pending, got %+v", name, s)
+		}
+
+		work(t, j, func() bool { return atomic.LoadInt64(&sum) == 10 })
+		if sum != 10 {
+			t.Errorf("%s: expected a sum of 10, got %d", name, sum)
+		}
+		if s := stats(t, q); s.Processed != 4 || s.Pending != 0 || s.Reserved != 0 {
+			t.Errorf("%s: unexpected stats %+v", name, s)
+		}
+	}
+}
+
+func TestRetryAndBury(t *testing.T) {
+	for name, q := range queues(t) {
+		j := newJobs(q)
+
+		var calls int32
+		j.HandleFunc("flaky", func(ctx context.Context, job *Job) error {
+			if atomic.AddInt32(&calls, 1) < 2 {
+				return errors.New("try again")
+			}
+			return nil
+		})
+		j.HandleFunc("broken", func(ctx context.Context, job *Job) error {
+			panic("boom")
+		})
+
+		ctx := context.Background()
+		_, _ = j.Push(ctx, "flaky", nil)
+		broken, _ := j.Push(ctx, "broken", nil, Attempts(2))
+		unknown, _ := j.Push(ctx, "unknown", nil)
+
+		work(t, j, func() bool {
+			s := stats(t, q)
+			return s.Processed == 1 && s.Failed == 2
+		})
+
+		s := stats(t, q)
+		if s.Processed != 1 || s.Failed != 2 || s.Pending != 0 {
+			t.Fatalf("%s: unexpected stats %+v", name, s)
+		}
+
+		failed, err := q.Failed(ctx, 0)
+		if err != nil || len(failed) != 2 {
+			t.Fatalf("%s: expected 2 dead letters, got %v %v", name, failed, err)
+		}
+		for _, job := range failed {
+			switch job.ID {
+			case broken.ID:
+				if job.Attempts != 2 || !strings.Contains(job.Error, "boom") || !strings.Contains(job.Error, "goroutine") {
+					t.Errorf("%s: unexpected broken job %+v", name, job)
+				}
+			case unknown.ID:
+				if job.Attempts != 1 {
+					t.Errorf("%s: a job without handler should not be retried, got %d attempts", name, job.Attempts)
+				}
+			default:
+				t.Errorf("%s: unexpected dead letter %+v", name, job)
+			}
+		}
+
+		if err := q.Forget(ctx, unknown.ID); err != nil {
+			t.Error(name, err)
+		}
+		if err := q.Forget(ctx, unknown.ID); err != ErrNotFound {
+			t.Errorf("%s: expected ErrNotFound, got %v", name, err)
+		}
+
+		j.HandleFunc("broken", func(ctx context.Context, job *Job) error { return nil })
+		if err := q.Revive(ctx, broken.ID); err != nil {
+			t.Fatal(name, err)
+		}
+		work(t, j, func() bool { return stats(t, q).Processed == 2 })
+		if s := stats(t, q); s.Processed != 2 || s.Failed != 0 {
+			t.Errorf("%s: unexpected stats after revive %+v", name, s)
+		}
+	}
+}
+
+func TestPruneFailed(t *testing.T) {
+	for name, q := range queues(t) {
+		j := newJobs(q)
+		ctx := context.Background()
+
+		// bury reserves a job and buries it as failed at failedAt
+		bury := func(failedAt time.Time) *Job {
+			pushed, _ := j.Push(ctx, "broken", nil)
+			job, err := q.Reserve(ctx, Default, time.Minute)
+			if err != nil || job == nil || job.ID != pushed.ID {
+				t.Fatalf("%s: expected to reserve the job, got %v %v", name, job, err)
+			}
+			job.FailedAt = failedAt
+			if err := q.Bury(ctx, job); err != nil {
+				t.Fatal(name, err)
+			}
+			return job
+		}
+		bury(time.Now().Add(-40 * 24 * time.Hour))
+		recent := bury(time.Now().Add(-time.Hour))
+
+		n, err := j.PruneFailed(ctx, 30*24*time.Hour)
+		if err != nil || n != 1 {
+			t.Fatalf("%s: expected the old dead letter pruned, got %d %v", name, n, err)
+		}
+		failed, _ := q.Failed(ctx, 0)
+		if len(failed) != 1 || failed[0].ID != recent.ID {
+			t.Errorf("%s: expected the recent dead letter kept, got %v", name, failed)
+		}
+	}
+}
+
+func TestDelayedAndLease(t *testing.T) {
+	for name, q := range queues(t) {
+		j := newJobs(q)
+		ctx := context.Background()
+
+		if _, err := j.PushDelayed(ctx, time.Hour, "later", nil); err != nil {
+			t.Fatal(name, err)
+		}
+		if job, err := q.Reserve(ctx, Default, time.Minute); err != nil || job != nil {
+			t.Errorf("%s: a delayed job should not be reserved, got %v %v", name, job, err)
+		}
+		if s := stats(t, q); s.Delayed != 1 {
+			t.Errorf("%s: expected 1 delayed, got %+v", name, s)
+		}
+
+		pushed, _ := j.Push(ctx, "now", nil)
+		job, err := q.Reserve(ctx, Default, 20*time.Millisecond)
+		if err != nil || job == nil || job.ID != pushed.ID || job.Attempts != 1 {
+			t.Fatalf("%s: unexpected reserved job %+v %v", name, job, err)
+		}
+		if again, _ := q.Reserve(ctx, Default, time.Minute); again != nil {
+			t.Errorf("%s: a reserved job should be hidden, got %+v", name, again)
+		}
+
+		// the worker holding the job went away, so once the lease ran out it is due again
+		time.Sleep(30 * time.Millisecond)
+		again, err := q.Reserve(ctx, Default, time.Minute)
+		if err != nil || again == nil || again.ID != pushed.ID || again.Attempts != 2 {
+			t.Errorf("%s: expected the job back after its lease, got %+v %v", name, again, err)
+		}
+	}
+}
+
+func TestQueuePriority(t *testing.T) {
+	q := NewMemoryQueue()
+	j := newJobs(q)
+	j.Workers = 1
+	j.Queues = []string{"high", Default}
+
+	var order []string
+	var ran int32
+	j.HandleFunc("record", func(ctx context.Context, job *Job) error {
+		order = append(order, job.Queue)
+		atomic.AddInt32(&ran, 1)
+		return nil
+	})
+
+	ctx := context.Background()
+	_, _ = j.Push(ctx, "record", nil)
+	_, _ = j.Push(ctx, "record", nil, OnQueue("high"))
+
+	work(t, j, func() bool { return atomic.LoadInt32(&ran) == 2 })
+	if len(order) != 2 || order[0] != "high" {
+		t.Errorf("expected the high queue first, got %v", order)
+	}
+}
+
+func TestExponential(t *testing.T) {
+	b := Exponential(time.Second, 5*time.Second)
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if got := b(attempts); got != want {
+			t.Errorf("attempt %d: expected %s, got %s", attempts, want, got)
+		}
+	}
+}
+
+func TestParsePools(t *testing.T) {
+	pools, err := ParsePools("emails, default:5; exports:1;reports")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pools) != 3 ||
+		len(pools[0].Queues) != 2 || pools[0].Queues[1] != Default || pools[0].Workers != 5 ||
+		pools[1].Queues[0] != "exports" || pools[1].Workers != 1 ||
+		pools[2].Queues[0] != "reports" || pools[2].Workers != 1 {
+		t.Errorf("unexpected pools %+v", pools)
+	}
+
+	for _, bad := range []string{"emails:0", "emails:many", ":3"} {
+		if _, err := ParsePools(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+	if pools, err := ParsePools(""); err != nil || len(pools) != 0 {
+		t.Errorf("expected no pools, got %v %v", pools, err)
+	}
+}
+
+func TestPools(t *testing.T) {
+	q := NewMemoryQueue()
+	j := newJobs(q)
+	j.Pools = []Pool{{Queues: []string{"exports"}, Workers: 1}, {Queues: []string{"emails", Default}, Workers: 1}}
+
+	release := make(chan struct{})
+	var sent int32
+	j.HandleFunc("export", func(ctx context.Context, job *Job) error {
+		select {
+		case <-release:
+		case <-time.After(time.Second):
+		}
+		return nil
+	})
+	j.HandleFunc("email", func(ctx context.Context, job *Job) error {
+		atomic.AddInt32(&sent, 1)
+		return nil
+	})
+
+	ctx := context.Background()
+	_, _ = j.Push(ctx, "export", nil, OnQueue("exports"))
+	_, _ = j.Push(ctx, "export", nil, OnQueue("exports"))
+	for i := 0; i < 3; i++ {
+		_, _ = j.Push(ctx, "email", nil, OnQueue("emails"))
+	}
+
+	// the emails go out while the export worker is stuck
+	work(t, j, func() bool {
+		if atomic.LoadInt32(&sent) == 3 {
+			close(release)
+			return true
+		}
+		return false
+	})
+	if sent != 3 {
+		t.Errorf("expected the emails to be sent during the export, got %d", sent)
+	}
+}
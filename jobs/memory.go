@@ -0,0 +1,196 @@
+package jobs
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+)
+
+// MemoryQueue keeps jobs in the process, for tests and apps without redis; queued jobs are
+// lost when the process stops
+type MemoryQueue struct {
+	mu        sync.Mutex
+	jobs      map[string]*Job
+	reserved  map[string]time.Time
+	failed    map[string]*Job
+	processed map[string]int
+	// Clock tells when the delayed jobs are due; the time of the machine when nil
+	Clock clock.Clock
+}
+
+// NewMemoryQueue returns an empty queue
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{
+		jobs:      map[string]*Job{},
+		reserved:  map[string]time.Time{},
+		failed:    map[string]*Job{},
+		processed: map[string]int{},
+	}
+}
+
+func (q *MemoryQueue) Push(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	copied := *job
+	q.jobs[job.ID] = &copied
+	return nil
+}
+
+func (q *MemoryQueue) Reserve(ctx context.Context, queue string, lease time.Duration) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := clock.Now(q.Clock)
+	var next *Job
+	for id, job := range q.jobs {
+		if job.Queue != queue || job.RunAt.After(now) {
+			continue
+		}
+		if until, ok := q.reserved[id]; ok && until.After(now) {
+			continue
+		}
+		if next == nil || job.RunAt.Before(next.RunAt) || (job.RunAt.Equal(next.RunAt) && job.ID < next.ID) {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+
+	next.Attempts++
+	q.reserved[next.ID] = now.Add(lease)
+
+	copied := *next
+	return &copied, nil
+}
+
+func (q *MemoryQueue) Ack(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.jobs, job.ID)
+	delete(q.reserved, job.ID)
+	q.processed[job.Queue]++
+	return nil
+}
+
+func (q *MemoryQueue) Retry(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	copied := *job
+	q.jobs[job.ID] = &copied
+	delete(q.reserved, job.ID)
+	return nil
+}
+
+func (q *MemoryQueue) Bury(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	copied := *job
+	q.failed[job.ID] = &copied
+	delete(q.jobs, job.ID)
+	delete(q.reserved, job.ID)
+	return nil
+}
+
+func (q *MemoryQueue) Stats(ctx context.Context) ([]Stats, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := clock.Now(q.Clock)
+	byQueue := map[string]*Stats{}
+	stats := func(name string) *Stats {
+		if byQueue[name] == nil {
+			byQueue[name] = &Stats{Queue: name}
+		}
+		return byQueue[name]
+	}
+
+	for id, job := range q.jobs {
+		s := stats(job.Queue)
+		switch {
+		case q.reserved[id].After(now):
+			s.Reserved++
+		case job.RunAt.After(now):
+			s.Delayed++
+		default:
+			s.Pending++
+		}
+	}
+	for _, job := range q.failed {
+		stats(job.Queue).Failed++
+	}
+	for name, n := range q.processed {
+		stats(name).Processed = n
+	}
+
+	return sortStats(byQueue), nil
+}
+
+func (q *MemoryQueue) Failed(ctx context.Context, limit int) ([]*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	list := make([]*Job, 0, len(q.failed))
+	for _, job := range q.failed {
+		copied := *job
+		list = append(list, &copied)
+	}
+	return latest(list, limit), nil
+}
+
+func (q *MemoryQueue) Revive(ctx context.Context, id string) error {
+	q.mu.Lock()
+	job := q.failed[id]
+	delete(q.failed, id)
+	q.mu.Unlock()
+
+	if job == nil {
+		return ErrNotFound
+	}
+	return q.Push(ctx, revived(job, clock.Now(q.Clock)))
+}
+
+func (q *MemoryQueue) Forget(ctx context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.failed[id] == nil {
+		return ErrNotFound
+	}
+	delete(q.failed, id)
+	return nil
+}
+
+// revived resets a dead letter to be tried again from scratch, from now
+func revived(job *Job, now time.Time) *Job {
+	copied := *job
+	copied.Attempts = 0
+	copied.RunAt = now
+	copied.FailedAt = time.Time{}
+	return &copied
+}
+
+// latest sorts dead letters the most recent first and keeps limit of them
+func latest(list []*Job, limit int) []*Job {
+	sort.Slice(list, func(i, j int) bool { return list[i].FailedAt.After(list[j].FailedAt) })
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list
+}
+
+func sortStats(byQueue map[string]*Stats) []Stats {
+	list := make([]Stats, 0, len(byQueue))
+	for _, s := range byQueue {
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Queue < list[j].Queue })
+	return list
+}
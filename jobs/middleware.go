@@ -0,0 +1,117 @@
+package jobs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+)
+
+// Middleware wraps the handler of a job, like HTTP middleware, to run code before and after
+// it or instead of it
+type Middleware func(next Handler) Handler
+
+// Chain wraps h in mw, the first being the outermost
+func Chain(h Handler, mw ...Middleware) Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// Use adds middleware around the handlers of every job type
+func (j *Jobs) Use(mw ...Middleware) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.middleware = append(j.middleware, mw...)
+}
+
+// ReleaseError gives a job back to the queue to run after Delay, without counting the attempt
+type ReleaseError struct {
+	Delay time.Duration
+}
+
+func (e *ReleaseError) Error() string {
+	return fmt.Sprintf("jobs: released for %s", e.Delay)
+}
+
+// Release returns the error of a handler giving its job back for later, e.g. while the API it
+// calls is busy
+func Release(delay time.Duration) error {
+	return &ReleaseError{Delay: delay}
+}
+
+// Timeout cancels the context of the job after d, for handlers passing it to their calls
+func Timeout(d time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, job *Job) error {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+
+			return next.Handle(ctx, job)
+		})
+	}
+}
+
+// SkipIf drops the jobs for which skip returns true without running them, e.g. a reminder
+// for an order cancelled since it was queued
+func SkipIf(skip func(ctx context.Context, job *Job) bool) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, job *Job) error {
+			if skip(ctx, job) {
+				return nil
+			}
+			return next.Handle(ctx, job)
+		})
+	}
+}
+
+// UniqueFor runs a job once in d: a job of the same type and payload reserved in the
+// meantime, e.g. queued twice by a double click, is dropped
+func (j *Jobs) UniqueFor(d time.Duration) Middleware {
+	return j.UniqueBy(d, func(job *Job) string {
+		sum := sha256.Sum256(job.Payload)
+		return hex.EncodeToString(sum[:])
+	})
+}
+
+// UniqueBy is UniqueFor with the key of a job, e.g. the user id of its payload
+func (j *Jobs) UniqueBy(d time.Duration, key func(job *Job) string) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, job *Job) error {
+			// the job itself coming back, retried or released, is not a duplicate
+			claimed, err := j.Store.Claim(ctx, "unique:"+job.Type+":"+key(job), job.ID, d)
+			if err != nil {
+				return err
+			}
+			if !claimed {
+				return nil
+			}
+			return next.Handle(ctx, job)
+		})
+	}
+}
+
+// Throttle runs at most limit jobs of the type per period across the workers sharing Store,
+// releasing the others until the next period
+func (j *Jobs) Throttle(limit int, per time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, job *Job) error {
+			now := clock.Now(j.Clock)
+			window := now.Truncate(per)
+
+			n, err := j.Store.Incr(ctx, fmt.Sprintf("throttle:%s:%d", job.Type, window.UnixNano()), per)
+			if err != nil {
+				return err
+			}
+			if n > limit {
+				return Release(window.Add(per).Sub(now))
+			}
+			return next.Handle(ctx, job)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/dgraph-io/badger/v3"
+	"github.com/gomodule/redigo/redis"
+	"github.com/namnguyen191/goravel/kv"
+)
+
+func stores(t *testing.T) map[string]Store {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	pool := &redis.Pool{Dial: func() (redis.Conn, error) { return redis.Dial("tcp", s.Addr()) }}
+	return map[string]Store{
+		"memory": &MemoryStore{},
+		"redis":  &RedisStore{Pool: pool, Prefix: "test"},
+		"kv":     &KVStore{KV: kv.New(db, "test")},
+	}
+}
+
+func TestStores(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range stores(t) {
+		if ok, err := s.Claim(ctx, "k", "a", time.Hour); err != nil || !ok {
+			t.Errorf("%s: expected the first claim, got %v %v", name, ok, err)
+		}
+		if ok, _ := s.Claim(ctx, "k", "a", time.Hour); !ok {
+			t.Errorf("%s: the holder should keep its claim", name)
+		}
+		if ok, _ := s.Claim(ctx, "k", "b", time.Hour); ok {
+			t.Errorf("%s: another holder should not get the claim", name)
+		}
+
+		for want := 1; want <= 3; want++ {
+			if n, err := s.Incr(ctx, "n", time.Hour); err != nil || n != want {
+				t.Errorf("%s: expected %d, got %d %v", name, want, n, err)
+			}
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	j := newJobs(NewMemoryQueue())
+
+	var order []string
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return HandlerFunc(func(ctx context.Context, job *Job) error {
+				order = append(order, name)
+				return next.Handle(ctx, job)
+			})
+		}
+	}
+	j.Use(record("global"))
+	j.HandleFunc("job", func(ctx context.Context, job *Job) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("expected the timeout on the context")
+		}
+		order = append(order, "handler")
+		return nil
+	}, record("first"), record("second"), Timeout(time.Second))
+
+	if err := j.run(context.Background(), &Job{Type: "job"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 4 || order[0] != "global" || order[1] != "first" || order[2] != "second" || order[3] != "handler" {
+		t.Errorf("unexpected order %v", order)
+	}
+}
+
+func TestSkipIfAndUnique(t *testing.T) {
+	q := NewMemoryQueue()
+	j := newJobs(q)
+
+	var ran int32
+	j.HandleFunc("sync", func(ctx context.Context, job *Job) error {
+		atomic.AddInt32(&ran, 1)
+		return nil
+	}, SkipIf(func(ctx context.Context, job *Job) bool { return string(job.Payload) == `"skip"` }), j.UniqueFor(time.Hour))
+
+	ctx := context.Background()
+	_, _ = j.Push(ctx, "sync", "a")
+	_, _ = j.Push(ctx, "sync", "a")
+	_, _ = j.Push(ctx, "sync", "b")
+	_, _ = j.Push(ctx, "sync", "skip")
+
+	work(t, j, func() bool { return stats(t, q).Processed == 4 })
+	if ran != 2 {
+		t.Errorf("expected a and b to run once, got %d runs", ran)
+	}
+}
+
+func TestUniqueKeepsRetries(t *testing.T) {
+	q := NewMemoryQueue()
+	j := newJobs(q)
+
+	var calls int32
+	j.HandleFunc("flaky", func(ctx context.Context, job *Job) error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return errors.New("try again")
+		}
+		return nil
+	}, j.UniqueFor(time.Hour))
+
+	_, _ = j.Push(context.Background(), "flaky", nil)
+	work(t, j, func() bool { return stats(t, q).Processed == 1 })
+	if calls != 2 {
+		t.Errorf("expected the retry to run, got %d calls", calls)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	q := NewMemoryQueue()
+	j := newJobs(q)
+
+	var ran int32
+	j.HandleFunc("call-api", func(ctx context.Context, job *Job) error {
+		atomic.AddInt32(&ran, 1)
+		return nil
+	}, j.Throttle(2, time.Hour))
+
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		_, _ = j.Push(ctx, "call-api", i)
+	}
+
+	work(t, j, func() bool {
+		s := stats(t, q)
+		return s.Processed == 2 && s.Delayed == 2
+	})
+
+	s := stats(t, q)
+	if ran != 2 || s.Delayed != 2 || s.Failed != 0 {
+		t.Errorf("expected 2 runs and 2 released jobs, got %d runs and %+v", ran, s)
+	}
+
+	// a release does not count as an attempt
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, job := range q.jobs {
+		if job.Attempts != 0 {
+			t.Errorf("expected no attempts for a released job, got %d", job.Attempts)
+		}
+	}
+}
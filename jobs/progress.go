@@ -0,0 +1,210 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+)
+
+// States of the progress of a job
+const (
+	Queued    = "queued"
+	Running   = "running"
+	Retrying  = "retrying"
+	Done      = "done"
+	Failed    = "failed"
+	Cancelled = "cancelled"
+)
+
+var (
+	// ErrCancelled is returned by Report once the job was asked to stop
+	ErrCancelled = errors.New("jobs: job cancelled")
+	// ErrFinished is returned by Cancel for a job which is not running anymore
+	ErrFinished = errors.New("jobs: job has finished")
+)
+
+// Progress is how far a job got, as reported by its handler
+type Progress struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	State   string `json:"state"`
+	Percent int    `json:"percent"`
+	Message string `json:"message,omitempty"`
+	// CancelRequested is set once Cancel was called, until the job stops
+	CancelRequested bool      `json:"cancel_requested,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// finished reports whether the job will not run again
+func (p *Progress) finished() bool {
+	return p.State == Done || p.State == Failed || p.State == Cancelled
+}
+
+type trackerKey struct{}
+
+// tracker is the job of a context given to a handler
+type tracker struct {
+	jobs *Jobs
+	job  *Job
+
+	mu        sync.Mutex
+	cancelled bool
+}
+
+// Report records how far the job of ctx got, e.g. 40 and "Resized 400 of 1000 images". It
+// returns ErrCancelled once the job was asked to stop, for handlers to return; the context of
+// the job is cancelled too. Outside of a job Report does nothing.
+func Report(ctx context.Context, percent int, message string) error {
+	t, _ := ctx.Value(trackerKey{}).(*tracker)
+	if t == nil {
+		return nil
+	}
+
+	if percent < 0 {
+		percent = 0
+	}
+	if percent > 100 {
+		percent = 100
+	}
+	// the context is cancelled by then, so the store would not be reached anyway
+	if t.isCancelled() {
+		return ErrCancelled
+	}
+	if err := t.jobs.track(ctx, t.job, Running, percent, message); err != nil {
+		return err
+	}
+
+	requested, err := t.jobs.cancelRequested(ctx, t.job.ID)
+	if err != nil {
+		return err
+	}
+	if requested {
+		return ErrCancelled
+	}
+	return nil
+}
+
+func (t *tracker) isCancelled() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cancelled
+}
+
+func (t *tracker) cancel() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cancelled = true
+}
+
+// Progress returns the progress of the job with id, or ErrNotFound once it expired
+func (j *Jobs) Progress(ctx context.Context, id string) (*Progress, error) {
+	b, err := j.Store.Get(ctx, "progress:"+id)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, ErrNotFound
+	}
+
+	var p Progress
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, err
+	}
+	if !p.finished() {
+		if p.CancelRequested, err = j.cancelRequested(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return &p, nil
+}
+
+// Cancel asks the job with id to stop. A job waiting in the queue is dropped when a worker
+// reserves it; a running job sees its context cancelled and ErrCancelled from Report within
+// Poll, and is not retried whatever it returns.
+func (j *Jobs) Cancel(ctx context.Context, id string) error {
+	p, err := j.Progress(ctx, id)
+	if err != nil {
+		return err
+	}
+	if p.finished() {
+		return ErrFinished
+	}
+	return j.Store.Put(ctx, "cancel:"+id, []byte("1"), j.ProgressTTL)
+}
+
+func (j *Jobs) cancelRequested(ctx context.Context, id string) (bool, error) {
+	b, err := j.Store.Get(ctx, "cancel:"+id)
+	return b != nil, err
+}
+
+// track stores the progress of job; a percent below zero keeps the percent and message
+// last reported
+func (j *Jobs) track(ctx context.Context, job *Job, state string, percent int, message string) error {
+	p := Progress{ID: job.ID, Type: job.Type, State: state, Percent: percent, Message: message, UpdatedAt: clock.Now(j.Clock)}
+	if percent < 0 {
+		p.Percent = 0
+		if old, err := j.Progress(ctx, job.ID); err == nil {
+			p.Percent, p.Message = old.Percent, old.Message
+		}
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return j.Store.Put(ctx, "progress:"+job.ID, b, j.ProgressTTL)
+}
+
+// watch cancels the context of job once a cancellation is requested, checking every Poll
+// until the returned stop is called
+func (j *Jobs) watch(ctx context.Context, t *tracker, cancel context.CancelFunc) (stop func()) {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+
+		ticker := time.NewTicker(j.Poll)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				requested, err := j.cancelRequested(ctx, t.job.ID)
+				if err != nil || !requested {
+					continue
+				}
+				t.cancel()
+				cancel()
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+// progress tracks job, logging a store failure rather than failing the job over it
+func (j *Jobs) progress(ctx context.Context, job *Job, state string, percent int) {
+	if err := j.track(ctx, job, state, percent, ""); err != nil {
+		j.ErrorLog("jobs: progress", job.Type, job.ID, err)
+	}
+}
+
+// cancelled drops a cancelled job from the queue
+func (j *Jobs) cancelled(ctx context.Context, job *Job) {
+	j.progress(ctx, job, Cancelled, -1)
+	if err := j.Queue.Ack(ctx, job); err != nil {
+		j.ErrorLog("jobs: ack", job.Type, job.ID, err)
+	}
+}
@@ -0,0 +1,112 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	for name, store := range stores(t) {
+		j := newJobs(NewMemoryQueue())
+		j.Store = store
+		ctx := context.Background()
+
+		reported, release := make(chan struct{}), make(chan struct{})
+		j.HandleFunc("resize", func(ctx context.Context, job *Job) error {
+			if err := Report(ctx, 50, "half way"); err != nil {
+				return err
+			}
+			close(reported)
+			<-release
+			return nil
+		})
+
+		job, err := j.Push(ctx, "resize", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p, err := j.Progress(ctx, job.ID); err != nil || p.State != Queued {
+			t.Fatalf("%s: expected a queued job, got %+v %v", name, p, err)
+		}
+
+		go func() {
+			<-reported
+			if p, err := j.Progress(ctx, job.ID); err != nil || p.State != Running || p.Percent != 50 || p.Message != "half way" {
+				t.Errorf("%s: expected the reported progress, got %+v %v", name, p, err)
+			}
+			close(release)
+		}()
+		work(t, j, func() bool {
+			p, _ := j.Progress(ctx, job.ID)
+			return p != nil && p.State == Done
+		})
+
+		if p, _ := j.Progress(ctx, job.ID); p == nil || p.State != Done || p.Percent != 100 {
+			t.Errorf("%s: expected a done job, got %+v", name, p)
+		}
+		if err := j.Cancel(ctx, job.ID); !errors.Is(err, ErrFinished) {
+			t.Errorf("%s: expected a finished job not to be cancelled, got %v", name, err)
+		}
+		if _, err := j.Progress(ctx, "nope"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: expected an unknown job not to be found, got %v", name, err)
+		}
+	}
+}
+
+func TestCancel(t *testing.T) {
+	for name, store := range stores(t) {
+		q := NewMemoryQueue()
+		j := newJobs(q)
+		j.Store = store
+		ctx := context.Background()
+
+		var runs int64
+		started := make(chan string, 1)
+		j.HandleFunc("export", func(ctx context.Context, job *Job) error {
+			atomic.AddInt64(&runs, 1)
+			started <- job.ID
+			for i := 0; ; i++ {
+				if err := Report(ctx, i%100, ""); err != nil {
+					return err
+				}
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(time.Millisecond):
+				}
+			}
+		})
+
+		// cancelled while it waits, the job never starts
+		waiting, _ := j.Push(ctx, "export", nil)
+		if err := j.Cancel(ctx, waiting.ID); err != nil {
+			t.Fatal(err)
+		}
+		running, _ := j.Push(ctx, "export", nil)
+
+		go func() {
+			if err := j.Cancel(ctx, <-started); err != nil {
+				t.Errorf("%s: %v", name, err)
+			}
+		}()
+		work(t, j, func() bool {
+			p, _ := j.Progress(ctx, running.ID)
+			return p != nil && p.State == Cancelled
+		})
+
+		for _, id := range []string{waiting.ID, running.ID} {
+			if p, _ := j.Progress(ctx, id); p == nil || p.State != Cancelled {
+				t.Errorf("%s: expected %s to be cancelled, got %+v", name, id, p)
+			}
+		}
+		if n := atomic.LoadInt64(&runs); n != 1 {
+			t.Errorf("%s: expected only the second job to run once, ran %d times", name, n)
+		}
+		if s := stats(t, q); s.Pending != 0 || s.Failed != 0 || s.Reserved != 0 {
+			t.Errorf("%s: expected the cancelled jobs to leave the queue, got %+v", name, s)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/namnguyen191/goravel/clock"
+)
+
+// reserve gives the jobs whose lease ran out back to the queue, then moves the job which has
+// been due the longest to the reserved set, scored by the end of its lease
+var reserve = redis.NewScript(2, `
+for _, id in ipairs(redis.call("ZRANGEBYSCORE", KEYS[2], "-inf", ARGV[1])) do
+	redis.call("ZREM", KEYS[2], id)
+	redis.call("ZADD", KEYS[1], ARGV[1], id)
+end
+local ids = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, 1)
+if #ids == 0 then
+	return false
+end
+redis.call("ZREM", KEYS[1], ids[1])
+redis.call("ZADD", KEYS[2], ARGV[2], ids[1])
+return ids[1]`)
+
+// RedisQueue keeps jobs in redis, shared by every instance and worker process. The jobs are
+// json in a hash, and each queue has sorted sets of ids for the ready, reserved and failed jobs.
+type RedisQueue struct {
+	Pool   *redis.Pool
+	Prefix string
+	// Clock tells when the delayed jobs are due; the time of the machine when nil
+	Clock clock.Clock
+}
+
+func (q *RedisQueue) key(parts ...string) string {
+	key := q.Prefix + ":jobs"
+	for _, p := range parts {
+		key += ":" + p
+	}
+	return key
+}
+
+func ms(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func (q *RedisQueue) conn(ctx context.Context) (redis.Conn, error) {
+	return q.Pool.GetContext(ctx)
+}
+
+// exec runs commands in a transaction
+func (q *RedisQueue) exec(ctx context.Context, cmds func(conn redis.Conn) error) error {
+	conn, err := q.conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.Send("MULTI"); err != nil {
+		return err
+	}
+	if err := cmds(conn); err != nil {
+		return err
+	}
+	_, err = redis.DoContext(conn, ctx, "EXEC")
+	return err
+}
+
+func (q *RedisQueue) Push(ctx context.Context, job *Job) error {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	return q.exec(ctx, func(conn redis.Conn) error {
+		_ = conn.Send("HSET", q.key("data"), job.ID, body)
+		_ = conn.Send("ZADD", q.key("ready", job.Queue), ms(job.RunAt), job.ID)
+		return conn.Send("SADD", q.key("queues"), job.Queue)
+	})
+}
+
+func (q *RedisQueue) Reserve(ctx context.Context, queue string, lease time.Duration) (*Job, error) {
+	conn, err := q.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	now := clock.Now(q.Clock)
+	id, err := redis.String(reserve.DoContext(ctx, conn, q.key("ready", queue), q.key("reserved", queue), ms(now), ms(now.Add(lease))))
+	if err == redis.ErrNil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := q.get(ctx, conn, id)
+	if err != nil || job == nil {
+		return nil, err
+	}
+
+	job.Attempts++
+	body, err := json.Marshal(job)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := redis.DoContext(conn, ctx, "HSET", q.key("data"), job.ID, body); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (q *RedisQueue) get(ctx context.Context, conn redis.Conn, id string) (*Job, error) {
+	body, err := redis.Bytes(redis.DoContext(conn, ctx, "HGET", q.key("data"), id))
+	if err == redis.ErrNil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var job Job
+	if err := json.Unmarshal(body, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (q *RedisQueue) Ack(ctx context.Context, job *Job) error {
+	return q.exec(ctx, func(conn redis.Conn) error {
+		_ = conn.Send("ZREM", q.key("reserved", job.Queue), job.ID)
+		_ = conn.Send("HDEL", q.key("data"), job.ID)
+		return conn.Send("HINCRBY", q.key("processed"), job.Queue, 1)
+	})
+}
+
+func (q *RedisQueue) Retry(ctx context.Context, job *Job) error {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	return q.exec(ctx, func(conn redis.Conn) error {
+		_ = conn.Send("HSET", q.key("data"), job.ID, body)
+		_ = conn.Send("ZREM", q.key("reserved", job.Queue), job.ID)
+		return conn.Send("ZADD", q.key("ready", job.Queue), ms(job.RunAt), job.ID)
+	})
+}
+
+func (q *RedisQueue) Bury(ctx context.Context, job *Job) error {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	return q.exec(ctx, func(conn redis.Conn) error {
+		_ = conn.Send("HSET", q.key("data"), job.ID, body)
+		_ = conn.Send("ZREM", q.key("reserved", job.Queue), job.ID)
+		return conn.Send("ZADD", q.key("failed", job.Queue), ms(job.FailedAt), job.ID)
+	})
+}
+
+func (q *RedisQueue) queues(ctx context.Context, conn redis.Conn) ([]string, error) {
+	return redis.Strings(redis.DoContext(conn, ctx, "SMEMBERS", q.key("queues")))
+}
+
+func (q *RedisQueue) Stats(ctx context.Context) ([]Stats, error) {
+	conn, err := q.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	names, err := q.queues(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	now := strconv.FormatInt(ms(clock.Now(q.Clock)), 10)
+	byQueue := map[string]*Stats{}
+	for _, name := range names {
+		_ = conn.Send("ZCOUNT", q.key("ready", name), "-inf", now)
+		_ = conn.Send("ZCOUNT", q.key("ready", name), "("+now, "+inf")
+		_ = conn.Send("ZCARD", q.key("reserved", name))
+		_ = conn.Send("ZCARD", q.key("failed", name))
+		_ = conn.Send("HGET", q.key("processed"), name)
+	}
+	if err := conn.Flush(); err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		var counts [5]int
+		for i := range counts {
+			n, err := redis.Int(conn.Receive())
+			if err != nil && err != redis.ErrNil {
+				return nil, err
+			}
+			counts[i] = n
+		}
+		byQueue[name] = &Stats{
+			Queue:     name,
+			Pending:   counts[0],
+			Delayed:   counts[1],
+			Reserved:  counts[2],
+			Failed:    counts[3],
+			Processed: counts[4],
+		}
+	}
+
+	return sortStats(byQueue), nil
+}
+
+func (q *RedisQueue) Failed(ctx context.Context, limit int) ([]*Job, error) {
+	conn, err := q.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	names, err := q.queues(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	stop := -1
+	if limit > 0 {
+		stop = limit - 1
+	}
+
+	var list []*Job
+	for _, name := range names {
+		ids, err := redis.Strings(redis.DoContext(conn, ctx, "ZREVRANGE", q.key("failed", name), 0, stop))
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			job, err := q.get(ctx, conn, id)
+			if err != nil {
+				return nil, err
+			}
+			if job != nil {
+				list = append(list, job)
+			}
+		}
+	}
+
+	return latest(list, limit), nil
+}
+
+// takeFailed removes the dead letter id, returning it
+func (q *RedisQueue) takeFailed(ctx context.Context, id string) (*Job, error) {
+	conn, err := q.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	job, err := q.get(ctx, conn, id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrNotFound
+	}
+
+	n, err := redis.Int(redis.DoContext(conn, ctx, "ZREM", q.key("failed", job.Queue), id))
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrNotFound
+	}
+	return job, nil
+}
+
+func (q *RedisQueue) Revive(ctx context.Context, id string) error {
+	job, err := q.takeFailed(ctx, id)
+	if err != nil {
+		return err
+	}
+	return q.Push(ctx, revived(job, clock.Now(q.Clock)))
+}
+
+func (q *RedisQueue) Forget(ctx context.Context, id string) error {
+	if _, err := q.takeFailed(ctx, id); err != nil {
+		return err
+	}
+
+	conn, err := q.conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = redis.DoContext(conn, ctx, "HDEL", q.key("data"), id)
+	return err
+}
@@ -0,0 +1,245 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/kv"
+)
+
+// Store keeps the state of the UniqueFor and Throttle middleware and the progress of the
+// jobs, shared by the workers which should see each other's jobs
+type Store interface {
+	// Claim sets key to holder for ttl unless another holder has it, reporting whether
+	// holder has it now
+	Claim(ctx context.Context, key, holder string, ttl time.Duration) (bool, error)
+	// Incr adds one to the counter under key, which expires ttl after it was created, and
+	// returns its new value
+	Incr(ctx context.Context, key string, ttl time.Duration) (int, error)
+	// Put sets key to value for ttl
+	Put(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Get returns the value of key, or nil when it is not set
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// claim sets the key to the holder, or keeps the holder which has it
+var claim = redis.NewScript(1, `
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return 1
+end
+return 0`)
+
+// incr counts in a key expiring from the first count
+var incr = redis.NewScript(1, `
+local n = redis.call("INCR", KEYS[1])
+if n == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n`)
+
+// RedisStore keeps the middleware state in redis, shared by every instance
+type RedisStore struct {
+	Pool   *redis.Pool
+	Prefix string
+}
+
+func (s *RedisStore) key(name string) string {
+	return s.Prefix + ":jobs:" + name
+}
+
+func (s *RedisStore) Claim(ctx context.Context, key, holder string, ttl time.Duration) (bool, error) {
+	conn, err := s.Pool.GetContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	n, err := redis.Int(claim.DoContext(ctx, conn, s.key(key), holder, ttl.Milliseconds()))
+	return n == 1, err
+}
+
+func (s *RedisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int, error) {
+	conn, err := s.Pool.GetContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	return redis.Int(incr.DoContext(ctx, conn, s.key(key), ttl.Milliseconds()))
+}
+
+func (s *RedisStore) Put(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	conn, err := s.Pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = redis.DoContext(conn, ctx, "SET", s.key(key), value, "PX", ttl.Milliseconds())
+	return err
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	conn, err := s.Pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	value, err := redis.Bytes(redis.DoContext(conn, ctx, "GET", s.key(key)))
+	if err == redis.ErrNil {
+		return nil, nil
+	}
+	return value, err
+}
+
+// KVStore keeps the middleware state in the badger store of the process
+type KVStore struct {
+	KV *kv.KV
+}
+
+func (s *KVStore) Claim(ctx context.Context, key, holder string, ttl time.Duration) (bool, error) {
+	claimed := false
+	err := s.KV.Update(func(tx *kv.Tx) error {
+		var current string
+		found, err := tx.Get("jobs:"+key, &current)
+		if err != nil {
+			return err
+		}
+		if found {
+			claimed = current == holder
+			return nil
+		}
+
+		claimed = true
+		return tx.Set("jobs:"+key, holder, ttl)
+	})
+	return claimed, err
+}
+
+func (s *KVStore) Incr(ctx context.Context, key string, ttl time.Duration) (int, error) {
+	var n int64
+	err := s.KV.Update(func(tx *kv.Tx) error {
+		found, err := tx.Get("jobs:"+key, &n)
+		if err != nil {
+			return err
+		}
+		if !found {
+			n = 1
+			return tx.Set("jobs:"+key, n, ttl)
+		}
+
+		n, err = tx.Incr("jobs:"+key, 1)
+		return err
+	})
+	return int(n), err
+}
+
+func (s *KVStore) Put(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.KV.Set("jobs:"+key, value, ttl)
+}
+
+func (s *KVStore) Get(ctx context.Context, key string) ([]byte, error) {
+	var value []byte
+	if _, err := s.KV.Get("jobs:"+key, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// MemoryStore keeps the middleware state in the process; the zero value is ready to use
+type MemoryStore struct {
+	mu      sync.Mutex
+	claims  map[string]holding
+	counts  map[string]int
+	expires map[string]time.Time
+	values  map[string]holding
+	// Clock tells when the claims and values expire; the time of the machine when nil
+	Clock clock.Clock
+}
+
+// holding is a claim, or a value put in the store
+type holding struct {
+	holder  string
+	expires time.Time
+}
+
+func (s *MemoryStore) Claim(ctx context.Context, key, holder string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.claims == nil {
+		s.claims = map[string]holding{}
+	}
+
+	now := clock.Now(s.Clock)
+	if c, ok := s.claims[key]; ok && c.expires.After(now) {
+		return c.holder == holder, nil
+	}
+
+	// forget what expired while the map is locked anyway
+	for k, c := range s.claims {
+		if !c.expires.After(now) {
+			delete(s.claims, k)
+		}
+	}
+	s.claims[key] = holding{holder: holder, expires: now.Add(ttl)}
+	return true, nil
+}
+
+func (s *MemoryStore) Incr(ctx context.Context, key string, ttl time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.counts == nil {
+		s.counts, s.expires = map[string]int{}, map[string]time.Time{}
+	}
+
+	now := clock.Now(s.Clock)
+	if !s.expires[key].After(now) {
+		for k, at := range s.expires {
+			if !at.After(now) {
+				delete(s.counts, k)
+				delete(s.expires, k)
+			}
+		}
+		s.expires[key] = now.Add(ttl)
+	}
+
+	s.counts[key]++
+	return s.counts[key], nil
+}
+
+func (s *MemoryStore) Put(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.values == nil {
+		s.values = map[string]holding{}
+	}
+
+	now := clock.Now(s.Clock)
+	for k, v := range s.values {
+		if !v.expires.After(now) {
+			delete(s.values, k)
+		}
+	}
+	s.values[key] = holding{holder: string(value), expires: now.Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if v, ok := s.values[key]; ok && v.expires.After(clock.Now(s.Clock)) {
+		return []byte(v.holder), nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,32 @@
+package goravel
+
+import (
+	"path/filepath"
+
+	"github.com/namnguyen191/goravel/kv"
+	"github.com/namnguyen191/goravel/leader"
+)
+
+// createKV opens the key-value store at KV_PATH, tmp/kv by default, when KV is true. It is
+// kept apart from the badger cache, so emptying the cache never loses state.
+func (grv *Goravel) createKV() (*kv.KV, error) {
+	if !grv.Env.Bool("KV", false) {
+		return nil, nil
+	}
+
+	dir := grv.Env.String("KV_PATH", filepath.Join(grv.RootPath, "tmp", "kv"))
+	store, err := kv.Open(dir, grv.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	store.Clock = grv.Clock
+
+	_, err = grv.Scheduler.AddJob("@daily", leader.Everywhere(func() {
+		_ = store.DB.RunValueLogGC(0.7)
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
@@ -0,0 +1,283 @@
+package kv
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+
+	"github.com/namnguyen191/goravel/clock"
+)
+
+// KV persists small local state, such as counters, queues and dedupe sets, in an embedded
+// badger database. Values are stored as JSON and read back into the type the caller asks for.
+type KV struct {
+	DB *badger.DB
+	// Prefix namespaces the keys, so apps can share a directory
+	Prefix string
+	// Clock tells how long keys have left; the time of the machine when nil
+	Clock clock.Clock
+}
+
+// New returns a store over db
+func New(db *badger.DB, prefix string) *KV {
+	return &KV{DB: db, Prefix: prefix}
+}
+
+// Open opens, or creates, the store in dir
+func Open(dir, prefix string) (*KV, error) {
+	db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	if err != nil {
+		return nil, err
+	}
+	return New(db, prefix), nil
+}
+
+// Close closes the database
+func (kv *KV) Close() error {
+	return kv.DB.Close()
+}
+
+func (kv *KV) key(key string) []byte {
+	if kv.Prefix == "" {
+		return []byte(key)
+	}
+	return []byte(kv.Prefix + ":" + key)
+}
+
+// name strips the prefix from a stored key
+func (kv *KV) name(key []byte) string {
+	if kv.Prefix == "" {
+		return string(key)
+	}
+	return strings.TrimPrefix(string(key), kv.Prefix+":")
+}
+
+// View runs fn in a read only transaction
+func (kv *KV) View(fn func(tx *Tx) error) error {
+	return kv.DB.View(func(txn *badger.Txn) error {
+		return fn(&Tx{kv: kv, txn: txn})
+	})
+}
+
+// Update runs fn in a read write transaction which is committed when fn returns nil. It is
+// retried when another transaction changed the keys it read in the meantime.
+func (kv *KV) Update(fn func(tx *Tx) error) error {
+	for {
+		err := kv.DB.Update(func(txn *badger.Txn) error {
+			return fn(&Tx{kv: kv, txn: txn})
+		})
+		if !errors.Is(err, badger.ErrConflict) {
+			return err
+		}
+	}
+}
+
+// Get reads key into dst, reporting whether it was found
+func (kv *KV) Get(key string, dst interface{}) (found bool, err error) {
+	err = kv.View(func(tx *Tx) error {
+		found, err = tx.Get(key, dst)
+		return err
+	})
+	return found, err
+}
+
+// Set stores value under key; a ttl of zero keeps it until deleted
+func (kv *KV) Set(key string, value interface{}, ttl time.Duration) error {
+	return kv.Update(func(tx *Tx) error {
+		return tx.Set(key, value, ttl)
+	})
+}
+
+// Add stores value unless key exists, reporting whether it did, e.g. to process a webhook once:
+//
+//	if first, err := grv.KV.Add("seen:"+event.ID, true, 24*time.Hour); err != nil || !first {
+//		return err
+//	}
+func (kv *KV) Add(key string, value interface{}, ttl time.Duration) (added bool, err error) {
+	err = kv.Update(func(tx *Tx) error {
+		added, err = tx.Add(key, value, ttl)
+		return err
+	})
+	return added, err
+}
+
+// Has reports whether key exists
+func (kv *KV) Has(key string) (bool, error) {
+	return kv.Get(key, nil)
+}
+
+// Delete removes key
+func (kv *KV) Delete(key string) error {
+	return kv.Update(func(tx *Tx) error {
+		return tx.Delete(key)
+	})
+}
+
+// Incr adds delta to the counter under key, which starts at zero, and returns its new value
+func (kv *KV) Incr(key string, delta int64) (n int64, err error) {
+	err = kv.Update(func(tx *Tx) error {
+		n, err = tx.Incr(key, delta)
+		return err
+	})
+	return n, err
+}
+
+// TTL returns how long key is kept, zero when it never expires, and false when it is missing
+func (kv *KV) TTL(key string) (ttl time.Duration, found bool, err error) {
+	err = kv.View(func(tx *Tx) error {
+		ttl, found, err = tx.TTL(key)
+		return err
+	})
+	return ttl, found, err
+}
+
+// Scan calls fn for the keys starting with prefix, in order, until fn returns an error
+func (kv *KV) Scan(prefix string, fn func(key string, value Value) error) error {
+	return kv.View(func(tx *Tx) error {
+		return tx.Scan(prefix, fn)
+	})
+}
+
+// Keys returns the keys starting with prefix
+func (kv *KV) Keys(prefix string) ([]string, error) {
+	var keys []string
+	err := kv.View(func(tx *Tx) error {
+		return tx.scan(prefix, false, func(key string, value Value) error {
+			keys = append(keys, key)
+			return nil
+		})
+	})
+	return keys, err
+}
+
+// DeletePrefix removes the keys starting with prefix
+func (kv *KV) DeletePrefix(prefix string) error {
+	return kv.DB.DropPrefix(kv.key(prefix))
+}
+
+// Value is a stored value, decoded on demand while scanning
+type Value struct {
+	item *badger.Item
+}
+
+// Decode reads the value into dst
+func (v Value) Decode(dst interface{}) error {
+	return v.item.Value(func(b []byte) error {
+		return json.Unmarshal(b, dst)
+	})
+}
+
+// ExpiresAt returns when the value expires, or the zero time when it does not
+func (v Value) ExpiresAt() time.Time {
+	if at := v.item.ExpiresAt(); at > 0 {
+		return time.Unix(int64(at), 0)
+	}
+	return time.Time{}
+}
+
+// Tx is a transaction; the reads of an Update transaction see its own writes
+type Tx struct {
+	kv  *KV
+	txn *badger.Txn
+}
+
+// Get reads key into dst, which may be nil to check it exists, reporting whether it was found
+func (tx *Tx) Get(key string, dst interface{}) (bool, error) {
+	item, err := tx.txn.Get(tx.kv.key(key))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if dst == nil {
+		return true, nil
+	}
+	return true, Value{item}.Decode(dst)
+}
+
+// Set stores value under key; a ttl of zero keeps it until deleted
+func (tx *Tx) Set(key string, value interface{}, ttl time.Duration) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	e := badger.NewEntry(tx.kv.key(key), b)
+	if ttl > 0 {
+		e = e.WithTTL(ttl)
+	}
+	return tx.txn.SetEntry(e)
+}
+
+// Add stores value unless key exists, reporting whether it did
+func (tx *Tx) Add(key string, value interface{}, ttl time.Duration) (bool, error) {
+	found, err := tx.Get(key, nil)
+	if err != nil || found {
+		return false, err
+	}
+	return true, tx.Set(key, value, ttl)
+}
+
+// Delete removes key
+func (tx *Tx) Delete(key string) error {
+	return tx.txn.Delete(tx.kv.key(key))
+}
+
+// Incr adds delta to the counter under key and returns its new value, keeping its expiry
+func (tx *Tx) Incr(key string, delta int64) (int64, error) {
+	var n int64
+	if _, err := tx.Get(key, &n); err != nil {
+		return 0, err
+	}
+	ttl, _, err := tx.TTL(key)
+	if err != nil {
+		return 0, err
+	}
+
+	n += delta
+	return n, tx.Set(key, n, ttl)
+}
+
+// TTL returns how long key is kept, zero when it never expires, and false when it is missing
+func (tx *Tx) TTL(key string) (time.Duration, bool, error) {
+	item, err := tx.txn.Get(tx.kv.key(key))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	at := Value{item}.ExpiresAt()
+	if at.IsZero() {
+		return 0, true, nil
+	}
+	return at.Sub(clock.Now(tx.kv.Clock)), true, nil
+}
+
+// Scan calls fn for the keys starting with prefix, in order, until fn returns an error
+func (tx *Tx) Scan(prefix string, fn func(key string, value Value) error) error {
+	return tx.scan(prefix, true, fn)
+}
+
+func (tx *Tx) scan(prefix string, values bool, fn func(key string, value Value) error) error {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = values
+	opts.Prefix = tx.kv.key(prefix)
+
+	it := tx.txn.NewIterator(opts)
+	defer it.Close()
+
+	for it.Rewind(); it.Valid(); it.Next() {
+		item := it.Item()
+		if err := fn(tx.kv.name(item.Key()), Value{item}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package kv
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+func openTest(t *testing.T) *KV {
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return New(db, "test")
+}
+
+func TestGetSet(t *testing.T) {
+	kv := openTest(t)
+
+	type visit struct {
+		Path  string
+		Count int
+	}
+	if err := kv.Set("visit:home", visit{"/", 3}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	var v visit
+	if found, err := kv.Get("visit:home", &v); err != nil || !found || v.Count != 3 {
+		t.Errorf("expected the stored struct, got %v %v %v", v, found, err)
+	}
+	if found, _ := kv.Get("missing", &v); found {
+		t.Error("expected missing keys to be reported")
+	}
+
+	if err := kv.Set("session", "abc", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	ttl, found, err := kv.TTL("session")
+	if err != nil || !found || ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Errorf("expected a ttl of an hour, got %s", ttl)
+	}
+	if ttl, _, _ := kv.TTL("visit:home"); ttl != 0 {
+		t.Errorf("expected no ttl, got %s", ttl)
+	}
+
+	_ = kv.Delete("session")
+	if has, _ := kv.Has("session"); has {
+		t.Error("expected the key to be deleted")
+	}
+}
+
+func TestIncrAndAdd(t *testing.T) {
+	kv := openTest(t)
+
+	for i := 0; i < 3; i++ {
+		if _, err := kv.Incr("hits", 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, _ := kv.Incr("hits", -1); n != 5 {
+		t.Errorf("expected 5, got %d", n)
+	}
+
+	if added, _ := kv.Add("seen:1", true, time.Minute); !added {
+		t.Error("expected the first add to store the key")
+	}
+	if added, _ := kv.Add("seen:1", true, time.Minute); added {
+		t.Error("expected the second add to be refused")
+	}
+}
+
+func TestScanAndTransactions(t *testing.T) {
+	kv := openTest(t)
+
+	err := kv.Update(func(tx *Tx) error {
+		for _, k := range []string{"queue:2", "queue:1", "other"} {
+			if err := tx.Set(k, k, 0); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var seen []string
+	err = kv.Scan("queue:", func(key string, value Value) error {
+		var s string
+		if err := value.Decode(&s); err != nil {
+			return err
+		}
+		seen = append(seen, key+"="+s)
+		return nil
+	})
+	if err != nil || len(seen) != 2 || seen[0] != "queue:1=queue:1" {
+		t.Errorf("expected the queue keys in order, got %v %v", seen, err)
+	}
+
+	// a failed transaction leaves nothing behind
+	boom := errors.New("boom")
+	err = kv.Update(func(tx *Tx) error {
+		_ = tx.Set("queue:3", "x", 0)
+		return boom
+	})
+	if err != boom {
+		t.Errorf("expected the error of fn, got %v", err)
+	}
+	if keys, _ := kv.Keys("queue:"); len(keys) != 2 {
+		t.Errorf("expected the transaction to be discarded, got %v", keys)
+	}
+
+	if err := kv.DeletePrefix("queue:"); err != nil {
+		t.Fatal(err)
+	}
+	if keys, _ := kv.Keys(""); len(keys) != 1 || keys[0] != "other" {
+		t.Errorf("expected only other to be left, got %v", keys)
+	}
+}
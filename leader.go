@@ -0,0 +1,66 @@
+package goravel
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/namnguyen191/goravel/leader"
+)
+
+// createLeader starts the election deciding which instance runs the scheduled jobs when
+// several share a database or redis; SCHEDULER_LEADER is "redis" or "database" (run
+// "goravel make leader" first). Without it every instance runs every job.
+func (grv *Goravel) createLeader() {
+	var lease leader.Lease
+
+	switch os.Getenv("SCHEDULER_LEADER") {
+	case "redis":
+		if redisPool == nil {
+			grv.ErrorLog.Println("leader: SCHEDULER_LEADER=redis needs CACHE or SESSION_TYPE set to redis")
+			return
+		}
+		lease = &leader.RedisLease{Pool: redisPool, Prefix: grv.Namespace}
+	case "database", "db":
+		if grv.DB.Pool == nil {
+			grv.ErrorLog.Println("leader: SCHEDULER_LEADER=database needs DATABASE_TYPE")
+			return
+		}
+		lease = &leader.DBLease{DB: grv.DB.Pool, DatabaseType: grv.DB.DataBaseType, Clock: grv.Clock}
+	default:
+		return
+	}
+
+	grv.Leader = leader.New(lease, "scheduler")
+	grv.Leader.ErrorLog = grv.ErrorLog.Println
+	grv.Leader.OnChange = func(leading bool) {
+		if leading {
+			grv.InfoLog.Println("leader: this instance runs the scheduled jobs")
+		} else {
+			grv.InfoLog.Println("leader: another instance runs the scheduled jobs")
+		}
+	}
+
+	// the lease is released at shutdown, so another instance takes over right away
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		grv.Leader.Run(ctx)
+	}()
+
+	grv.OnShutdown(func(shutdown context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-shutdown.Done():
+			return fmt.Errorf("leader: lease not released: %w", shutdown.Err())
+		}
+	})
+}
+
+// leading reports whether this instance runs the scheduled jobs
+func (grv *Goravel) leading() bool {
+	return grv.Leader == nil || grv.Leader.IsLeader()
+}
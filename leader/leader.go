@@ -0,0 +1,136 @@
+package leader
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Lease is a named lock held by one instance until it expires or is released
+type Lease interface {
+	// Acquire takes the lease, or extends it when holder already has it, reporting whether holder holds it now
+	Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	// Release gives the lease up if holder has it
+	Release(ctx context.Context, name, holder string) error
+}
+
+// Elector keeps trying to hold a lease so one instance of a cluster leads at a time. The
+// leader renews the lease well before it expires; when it dies, another instance takes
+// over once the lease ran out.
+type Elector struct {
+	Lease Lease
+	Name  string
+	// ID identifies this instance; by default its hostname, pid and a random suffix
+	ID  string
+	TTL time.Duration
+	// OnChange is called when this instance becomes or stops being the leader
+	OnChange func(leader bool)
+	ErrorLog func(v ...interface{})
+
+	mu     sync.RWMutex
+	leader bool
+}
+
+// New returns an elector for the lease name with a TTL of 30 seconds
+func New(lease Lease, name string) *Elector {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+
+	return &Elector{
+		Lease:    lease,
+		Name:     name,
+		ID:       fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b)),
+		TTL:      30 * time.Second,
+		ErrorLog: log.Println,
+	}
+}
+
+// IsLeader reports whether this instance held the lease at the last attempt
+func (e *Elector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.leader
+}
+
+func (e *Elector) set(leader bool) {
+	e.mu.Lock()
+	changed := e.leader != leader
+	e.leader = leader
+	e.mu.Unlock()
+
+	if changed && e.OnChange != nil {
+		e.OnChange(leader)
+	}
+}
+
+// Campaign tries to take or renew the lease once
+func (e *Elector) Campaign(ctx context.Context) {
+	ok, err := e.Lease.Acquire(ctx, e.Name, e.ID, e.TTL)
+	if err != nil {
+		// without an answer from the store another instance may take over at any time
+		e.ErrorLog("leader:", err)
+		ok = false
+	}
+	e.set(ok)
+}
+
+// Run campaigns every third of the TTL until ctx is done, then releases the lease so another
+// instance can take over right away
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.TTL / 3)
+	defer ticker.Stop()
+
+	for {
+		e.Campaign(ctx)
+
+		select {
+		case <-ctx.Done():
+			if e.IsLeader() {
+				release, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				if err := e.Lease.Release(release, e.Name, e.ID); err != nil {
+					e.ErrorLog("leader: release:", err)
+				}
+				cancel()
+			}
+			e.set(false)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// everywhere marks a job which runs on every instance
+type everywhere struct {
+	cron.Job
+}
+
+// Everywhere opts a job out of leader election, for tasks which are local to each instance
+// or safe to repeat, e.g. pruning log files
+func Everywhere(fn func()) cron.Job {
+	return everywhere{cron.FuncJob(fn)}
+}
+
+// Only is a cron.JobWrapper running jobs only while leading returns true. A nil leading
+// runs every job, for single instance apps.
+func Only(leading func() bool) cron.JobWrapper {
+	return func(j cron.Job) cron.Job {
+		if _, ok := j.(everywhere); ok {
+			return j
+		}
+
+		return cron.FuncJob(func() {
+			if leading == nil || leading() {
+				j.Run()
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package leader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+)
+
+func TestFailover(t *testing.T) {
+	lease := &MemoryLease{}
+	a, b := New(lease, "scheduler"), New(lease, "scheduler")
+	a.TTL, b.TTL = 50*time.Millisecond, 50*time.Millisecond
+
+	ctx := context.Background()
+	a.Campaign(ctx)
+	b.Campaign(ctx)
+	if !a.IsLeader() || b.IsLeader() {
+		t.Fatal("expected the first instance to lead")
+	}
+
+	// a dies without releasing; b takes over once the lease ran out
+	time.Sleep(60 * time.Millisecond)
+	b.Campaign(ctx)
+	if !b.IsLeader() {
+		t.Fatal("expected the second instance to take over")
+	}
+	a.Campaign(ctx)
+	if a.IsLeader() {
+		t.Error("expected the old leader to step down")
+	}
+}
+
+func TestRunReleases(t *testing.T) {
+	lease := &MemoryLease{}
+	a, b := New(lease, "scheduler"), New(lease, "scheduler")
+
+	changes := make(chan bool, 2)
+	a.OnChange = func(leader bool) { changes <- leader }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		a.Run(ctx)
+		close(done)
+	}()
+
+	if !<-changes {
+		t.Fatal("expected to become leader")
+	}
+	cancel()
+	<-done
+
+	b.Campaign(context.Background())
+	if !b.IsLeader() {
+		t.Error("expected the released lease to be taken right away")
+	}
+}
+
+func TestOnly(t *testing.T) {
+	leading := false
+	wrap := Only(func() bool { return leading })
+
+	ran, everywhere := 0, 0
+	job := wrap(everywhereOrNot(false, &ran))
+	local := wrap(everywhereOrNot(true, &everywhere))
+
+	job.Run()
+	local.Run()
+	leading = true
+	job.Run()
+
+	if ran != 1 || everywhere != 1 {
+		t.Errorf("unexpected runs %d %d", ran, everywhere)
+	}
+}
+
+func everywhereOrNot(local bool, n *int) interface{ Run() } {
+	if local {
+		return Everywhere(func() { *n++ })
+	}
+	return funcJob(func() { *n++ })
+}
+
+type funcJob func()
+
+func (f funcJob) Run() { f() }
+
+func TestRedisLease(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	lease := &RedisLease{Pool: &redis.Pool{Dial: func() (redis.Conn, error) { return redis.Dial("tcp", s.Addr()) }}, Prefix: "app"}
+	ctx := context.Background()
+
+	if ok, err := lease.Acquire(ctx, "scheduler", "a", time.Minute); !ok || err != nil {
+		t.Fatalf("expected a to acquire, got %v %v", ok, err)
+	}
+	if ok, _ := lease.Acquire(ctx, "scheduler", "b", time.Minute); ok {
+		t.Error("expected b to be refused")
+	}
+	if ok, err := lease.Acquire(ctx, "scheduler", "a", time.Minute); !ok || err != nil {
+		t.Errorf("expected a to renew, got %v %v", ok, err)
+	}
+
+	s.FastForward(2 * time.Minute)
+	if ok, _ := lease.Acquire(ctx, "scheduler", "b", time.Minute); !ok {
+		t.Error("expected b to take the expired lease")
+	}
+
+	_ = lease.Release(ctx, "scheduler", "a")
+	if v, _ := s.Get("app:leader:scheduler"); v != "b" {
+		t.Errorf("expected a release by a to leave b's lease, got %q", v)
+	}
+}
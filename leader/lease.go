@@ -0,0 +1,155 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+)
+
+// renew extends the lease only while it still belongs to the holder
+var renew = redis.NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+var release = redis.NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// RedisLease keeps leases as expiring redis keys
+type RedisLease struct {
+	Pool   *redis.Pool
+	Prefix string
+}
+
+func (l *RedisLease) key(name string) string {
+	return l.Prefix + ":leader:" + name
+}
+
+func (l *RedisLease) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	conn, err := l.Pool.GetContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	ms := ttl.Milliseconds()
+	_, err = redis.String(redis.DoContext(conn, ctx, "SET", l.key(name), holder, "NX", "PX", ms))
+	if err == nil {
+		return true, nil
+	}
+	if err != redis.ErrNil {
+		return false, err
+	}
+
+	// someone holds it already; it may be us
+	n, err := redis.Int(renew.Do(conn, l.key(name), holder, ms))
+	return n == 1, err
+}
+
+func (l *RedisLease) Release(ctx context.Context, name, holder string) error {
+	conn, err := l.Pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = release.Do(conn, l.key(name), holder)
+	return err
+}
+
+// DBLease keeps leases in the scheduler_leases table
+type DBLease struct {
+	DB           *sql.DB
+	DatabaseType string
+	// Clock tells when a lease expires; the time of the machine when nil
+	Clock clock.Clock
+}
+
+func (l *DBLease) rebind(query string) string {
+	return database.Rebind(l.DatabaseType, query)
+}
+
+func (l *DBLease) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	now := clock.Now(l.Clock)
+
+	// take over an expired lease, or extend our own
+	res, err := l.DB.ExecContext(ctx, l.rebind("update scheduler_leases set holder = ?, expires_at = ? where name = ? and (holder = ? or expires_at < ?)"),
+		holder, now.Add(ttl), name, holder, now)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return false, err
+	} else if n > 0 {
+		return true, nil
+	}
+
+	var held int
+	if err := l.DB.QueryRowContext(ctx, l.rebind("select count(*) from scheduler_leases where name = ?"), name).Scan(&held); err != nil {
+		return false, err
+	}
+	if held > 0 {
+		return false, nil
+	}
+
+	// first instance to run; when another inserts at the same time the primary key lets one win
+	_, err = l.DB.ExecContext(ctx, l.rebind("insert into scheduler_leases (name, holder, expires_at) values (?, ?, ?)"), name, holder, now.Add(ttl))
+	if database.IsDuplicate(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (l *DBLease) Release(ctx context.Context, name, holder string) error {
+	_, err := l.DB.ExecContext(ctx, l.rebind("delete from scheduler_leases where name = ? and holder = ?"), name, holder)
+	return err
+}
+
+// MemoryLease keeps leases in the process, for tests and instances sharing nothing
+type MemoryLease struct {
+	mu     sync.Mutex
+	leases map[string]memoryLease
+	// Clock tells when a lease expires; the time of the machine when nil
+	Clock clock.Clock
+}
+
+type memoryLease struct {
+	holder  string
+	expires time.Time
+}
+
+func (l *MemoryLease) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.leases == nil {
+		l.leases = map[string]memoryLease{}
+	}
+
+	cur, ok := l.leases[name]
+	if ok && cur.holder != holder && clock.Now(l.Clock).Before(cur.expires) {
+		return false, nil
+	}
+
+	l.leases[name] = memoryLease{holder: holder, expires: clock.Now(l.Clock).Add(ttl)}
+	return true, nil
+}
+
+func (l *MemoryLease) Release(ctx context.Context, name, holder string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.leases[name].holder == holder {
+		delete(l.leases, name)
+	}
+	return nil
+}
@@ -0,0 +1,329 @@
+package links
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/namnguyen191/goravel/analytics"
+	"github.com/namnguyen191/goravel/bots"
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+)
+
+// Click is the name of the analytics event recorded for every redirect
+const Click = "link_click"
+
+var (
+	// ErrExpired is returned for links past their expiry
+	ErrExpired = errors.New("links: link expired")
+	// ErrInvalidCode is returned for custom codes with characters other than letters, digits, - and _
+	ErrInvalidCode = errors.New("links: invalid code")
+	// ErrCodeTaken is returned when a custom code is already in use
+	ErrCodeTaken = errors.New("links: code already in use")
+)
+
+// alphabet leaves out characters easily mistaken for one another: 0/O, 1/l/I
+const alphabet = "23456789abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+
+var validCode = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Link is a short code redirecting to URL. Campaign, Source and Medium are added to the
+// destination as utm_ parameters and recorded with every click.
+type Link struct {
+	ID            int
+	Code          string
+	URL           string
+	Campaign      string
+	Source        string
+	Medium        string
+	UserID        int
+	Clicks        int
+	ExpiresAt     time.Time
+	LastClickedAt time.Time
+	CreatedAt     time.Time
+}
+
+// Expired reports whether the link no longer redirects at now
+func (l *Link) Expired(now time.Time) bool {
+	return !l.ExpiresAt.IsZero() && now.After(l.ExpiresAt)
+}
+
+// Destination is the target url with the campaign parameters the link was made with
+func (l *Link) Destination() string {
+	if l.Campaign == "" && l.Source == "" && l.Medium == "" {
+		return l.URL
+	}
+
+	u, err := url.Parse(l.URL)
+	if err != nil {
+		return l.URL
+	}
+
+	q := u.Query()
+	for k, v := range map[string]string{"utm_campaign": l.Campaign, "utm_source": l.Source, "utm_medium": l.Medium} {
+		if v != "" && q.Get(k) == "" {
+			q.Set(k, v)
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+// Campaign is the number of links and clicks of a campaign
+type Campaign struct {
+	Name   string
+	Links  int
+	Clicks int
+}
+
+// Links creates short links and redirects them
+type Links struct {
+	DB           *sql.DB
+	DatabaseType string
+	// BaseURL is prepended to codes by URL, e.g. "https://example.com/l"
+	BaseURL string
+	// Length of generated codes; it grows by one when codes keep colliding
+	Length int
+	// Analytics, when set, records a Click event for every redirect
+	Analytics *analytics.Analytics
+	ErrorLog  func(v ...interface{})
+	// Clock tells when the links expire; the time of the machine when nil
+	Clock clock.Clock
+}
+
+// New returns short links served under baseURL
+func New(db *sql.DB, dbType, baseURL string) *Links {
+	return &Links{
+		DB:           db,
+		DatabaseType: dbType,
+		BaseURL:      strings.TrimRight(baseURL, "/"),
+		Length:       7,
+		ErrorLog:     log.Println,
+	}
+}
+
+func (ls *Links) rebind(query string) string {
+	return database.Rebind(ls.DatabaseType, query)
+}
+
+const columns = "id, code, url, campaign, source, medium, user_id, clicks, expires_at, last_clicked_at, created_at"
+
+func scan(row interface{ Scan(...interface{}) error }) (*Link, error) {
+	var l Link
+	var expires, clicked sql.NullTime
+	if err := row.Scan(&l.ID, &l.Code, &l.URL, &l.Campaign, &l.Source, &l.Medium, &l.UserID, &l.Clicks, &expires, &clicked, &l.CreatedAt); err != nil {
+		return nil, err
+	}
+	l.ExpiresAt = expires.Time
+	l.LastClickedAt = clicked.Time
+	return &l, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+func code(length int) (string, error) {
+	b := make([]byte, length)
+	max := big.NewInt(int64(len(alphabet)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = alphabet[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// Create saves a link, generating a random code unless one is given
+func (ls *Links) Create(ctx context.Context, l Link) (*Link, error) {
+	u, err := url.Parse(l.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("links: url must be absolute http or https")
+	}
+
+	if l.Code != "" {
+		if !validCode.MatchString(l.Code) {
+			return nil, ErrInvalidCode
+		}
+		if taken, err := ls.taken(ctx, l.Code); err != nil {
+			return nil, err
+		} else if taken {
+			return nil, ErrCodeTaken
+		}
+	} else {
+		length := ls.Length
+		if length <= 0 {
+			length = 7
+		}
+		for attempt := 0; ; attempt++ {
+			// a few collisions in a row mean the code space is getting crowded
+			if attempt > 0 && attempt%3 == 0 {
+				length++
+			}
+			if l.Code, err = code(length); err != nil {
+				return nil, err
+			}
+			taken, err := ls.taken(ctx, l.Code)
+			if err != nil {
+				return nil, err
+			}
+			if !taken {
+				break
+			}
+		}
+	}
+
+	l.Clicks = 0
+	l.CreatedAt = clock.Now(ls.Clock)
+
+	query := "insert into links (code, url, campaign, source, medium, user_id, clicks, expires_at, created_at) values (?, ?, ?, ?, ?, ?, 0, ?, ?)"
+	args := []interface{}{l.Code, l.URL, l.Campaign, l.Source, l.Medium, l.UserID, nullTime(l.ExpiresAt), l.CreatedAt}
+
+	if database.IsPostgres(ls.DatabaseType) {
+		if err := ls.DB.QueryRowContext(ctx, ls.rebind(query+" returning id"), args...).Scan(&l.ID); err != nil {
+			return nil, err
+		}
+		return &l, nil
+	}
+
+	res, err := ls.DB.ExecContext(ctx, ls.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	l.ID = int(id)
+
+	return &l, nil
+}
+
+func (ls *Links) taken(ctx context.Context, code string) (bool, error) {
+	var n int
+	err := ls.DB.QueryRowContext(ctx, ls.rebind("select count(*) from links where code = ?"), code).Scan(&n)
+	return n > 0, err
+}
+
+// Find returns a link by code, expired or not
+func (ls *Links) Find(ctx context.Context, code string) (*Link, error) {
+	return scan(ls.DB.QueryRowContext(ctx, ls.rebind("select "+columns+" from links where code = ?"), code))
+}
+
+// For returns the links created by a user, newest first
+func (ls *Links) For(ctx context.Context, userID int) ([]Link, error) {
+	rows, err := ls.DB.QueryContext(ctx, ls.rebind("select "+columns+" from links where user_id = ? order by id desc"), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Link
+	for rows.Next() {
+		l, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *l)
+	}
+
+	return list, rows.Err()
+}
+
+// Expire sets when a link stops redirecting; the zero time makes it permanent
+func (ls *Links) Expire(ctx context.Context, code string, at time.Time) error {
+	_, err := ls.DB.ExecContext(ctx, ls.rebind("update links set expires_at = ? where code = ?"), nullTime(at), code)
+	return err
+}
+
+// Delete removes a link
+func (ls *Links) Delete(ctx context.Context, code string) error {
+	_, err := ls.DB.ExecContext(ctx, ls.rebind("delete from links where code = ?"), code)
+	return err
+}
+
+// Prune deletes links which expired more than olderThan ago
+func (ls *Links) Prune(ctx context.Context, olderThan time.Duration) (int64, error) {
+	res, err := ls.DB.ExecContext(ctx, ls.rebind("delete from links where expires_at is not null and expires_at < ?"), clock.Now(ls.Clock).Add(-olderThan))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// URL returns the short url of a code
+func (ls *Links) URL(code string) string {
+	return ls.BaseURL + "/" + code
+}
+
+// Campaigns returns the links and clicks of every campaign, most clicked first
+func (ls *Links) Campaigns(ctx context.Context) ([]Campaign, error) {
+	rows, err := ls.DB.QueryContext(ctx, "select campaign, count(*), coalesce(sum(clicks), 0) from links where campaign <> '' group by campaign order by 3 desc")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Campaign
+	for rows.Next() {
+		var c Campaign
+		if err := rows.Scan(&c.Name, &c.Links, &c.Clicks); err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+
+	return list, rows.Err()
+}
+
+// RedirectHandler sends visitors of /{code} to the destination of the link. Clicks by bots,
+// such as link previews, are not counted.
+func (ls *Links) RedirectHandler(w http.ResponseWriter, r *http.Request) {
+	l, err := ls.Find(r.Context(), chi.URLParam(r, "code"))
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		ls.ErrorLog("links:", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if l.Expired(clock.Now(ls.Clock)) {
+		http.Error(w, http.StatusText(http.StatusGone), http.StatusGone)
+		return
+	}
+
+	if !bots.IsBot(r) {
+		_, err := ls.DB.ExecContext(r.Context(), ls.rebind("update links set clicks = clicks + 1, last_clicked_at = ? where id = ?"), clock.Now(ls.Clock), l.ID)
+		if err != nil {
+			ls.ErrorLog("links: click", l.Code, err)
+		}
+
+		if ls.Analytics != nil {
+			ls.Analytics.Track(r, Click, map[string]interface{}{
+				"code":     l.Code,
+				"campaign": l.Campaign,
+				"source":   l.Source,
+				"medium":   l.Medium,
+			})
+		}
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer-when-downgrade")
+	http.Redirect(w, r, l.Destination(), http.StatusFound)
+}
@@ -0,0 +1,56 @@
+package links
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDestination(t *testing.T) {
+	l := Link{URL: "https://example.com/sale?utm_source=mail&ref=1", Campaign: "spring", Source: "sms"}
+
+	got := l.Destination()
+	if !strings.Contains(got, "utm_campaign=spring") || !strings.Contains(got, "utm_source=mail") || !strings.Contains(got, "ref=1") {
+		t.Errorf("unexpected destination %s", got)
+	}
+
+	if (&Link{URL: "https://example.com"}).Destination() != "https://example.com" {
+		t.Error("expected links without a campaign to keep their url")
+	}
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Now()
+	if (&Link{}).Expired(now) || !(&Link{ExpiresAt: now.Add(-time.Minute)}).Expired(now) {
+		t.Error("unexpected expiry")
+	}
+}
+
+func TestCode(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		c, err := code(7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(c) != 7 || strings.ContainsAny(c, "01lIO") || seen[c] {
+			t.Fatalf("unexpected code %s", c)
+		}
+		seen[c] = true
+	}
+}
+
+func TestCreate_Validation(t *testing.T) {
+	ls := New(nil, "postgres", "https://example.com/l/")
+
+	if _, err := ls.Create(context.Background(), Link{URL: "javascript:alert(1)"}); err == nil {
+		t.Error("expected non http urls to be refused")
+	}
+	if _, err := ls.Create(context.Background(), Link{URL: "https://example.com", Code: "a b"}); err != ErrInvalidCode {
+		t.Errorf("expected invalid code, got %v", err)
+	}
+	if ls.URL("abc") != "https://example.com/l/abc" {
+		t.Errorf("unexpected url %s", ls.URL("abc"))
+	}
+}
@@ -0,0 +1,36 @@
+package goravel
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/namnguyen191/goravel/livereload"
+)
+
+// createLiveReload reloads the browser when views, mail templates, public files or asset
+// sources change, in debug mode unless LIVE_RELOAD is false. Changed bundles are rebuilt first.
+func (grv *Goravel) createLiveReload() *livereload.LiveReload {
+	if !grv.Debug || !grv.Env.Bool("LIVE_RELOAD", true) {
+		return nil
+	}
+
+	dirs := []string{"views", "mail", "public", "assets"}
+	for i, dir := range dirs {
+		dirs[i] = filepath.Join(grv.RootPath, dir)
+	}
+
+	l := livereload.New(dirs...)
+	l.Skip = []string{filepath.Join(grv.RootPath, "public", "bundles")}
+	l.OnChange = func(changed []string) {
+		if grv.Assets == nil {
+			return
+		}
+		if err := grv.buildAssets(grv.Assets); err != nil {
+			grv.ErrorLog.Println("assets:", err)
+		}
+	}
+
+	go l.Run(context.Background())
+
+	return l
+}
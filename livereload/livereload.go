@@ -0,0 +1,244 @@
+package livereload
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Path is where browsers listen for reloads
+const Path = "/__livereload"
+
+// script reloads the page on a change and once the server is back after a restart, e.g. by
+// a tool rebuilding the app on save
+const script = `<script>(function(){var lost=false,es=new EventSource(%q);` +
+	`es.addEventListener("reload",function(){location.reload()});` +
+	`es.onerror=function(){lost=true};es.onopen=function(){if(lost)location.reload()}})();</script>`
+
+// LiveReload watches directories and tells the browsers showing the app to reload when a file
+// changes. Files are polled, so it works the same on every system and in containers with
+// mounted volumes. It is meant for development only.
+type LiveReload struct {
+	Dirs []string
+	// Skip lists directories not watched, e.g. where build output is written
+	Skip     []string
+	Interval time.Duration
+	// OnChange runs before browsers reload, e.g. to rebuild assets
+	OnChange func(changed []string)
+
+	mu      sync.Mutex
+	clients map[chan struct{}]bool
+	files   map[string]time.Time
+}
+
+// New watches dirs every half a second
+func New(dirs ...string) *LiveReload {
+	return &LiveReload{
+		Dirs:     dirs,
+		Interval: 500 * time.Millisecond,
+		clients:  map[chan struct{}]bool{},
+	}
+}
+
+// Run polls the directories until ctx is done
+func (l *LiveReload) Run(ctx context.Context) {
+	l.files = l.scan()
+
+	ticker := time.NewTicker(l.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if changed := l.Check(); len(changed) > 0 {
+				if l.OnChange != nil {
+					l.OnChange(changed)
+				}
+				l.Reload()
+			}
+		}
+	}
+}
+
+// Check scans the directories once, returning the files added, changed or removed since the last scan
+func (l *LiveReload) Check() []string {
+	files := l.scan()
+
+	var changed []string
+	for path, mod := range files {
+		if old, ok := l.files[path]; !ok || !old.Equal(mod) {
+			changed = append(changed, path)
+		}
+	}
+	for path := range l.files {
+		if _, ok := files[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+
+	l.files = files
+	return changed
+}
+
+func (l *LiveReload) scan() map[string]time.Time {
+	files := map[string]time.Time{}
+	for _, dir := range l.Dirs {
+		_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if l.skipped(path) || (path != dir && strings.HasPrefix(info.Name(), ".")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			files[path] = info.ModTime()
+			return nil
+		})
+	}
+	return files
+}
+
+func (l *LiveReload) skipped(path string) bool {
+	for _, skip := range l.Skip {
+		if filepath.Clean(skip) == filepath.Clean(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reload tells every connected browser to reload
+func (l *LiveReload) Reload() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for ch := range l.clients {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// ServeHTTP streams reload events to a browser
+func (l *LiveReload) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		http.Error(rw, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := make(chan struct{}, 1)
+	l.mu.Lock()
+	l.clients[ch] = true
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		delete(l.clients, ch)
+		l.mu.Unlock()
+	}()
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprint(rw, "retry: 1000\n\n")
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ch:
+			fmt.Fprint(rw, "event: reload\ndata: {}\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// Middleware adds the reload script to the pages browsers ask for
+func (l *LiveReload) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path == Path || !strings.Contains(r.Header.Get("Accept"), "text/html") {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		w := &injector{ResponseWriter: rw, status: http.StatusOK}
+		next.ServeHTTP(w, r)
+		w.finish()
+	})
+}
+
+// injector holds back html responses to add the script before </body>
+type injector struct {
+	http.ResponseWriter
+	status  int
+	decided bool
+	html    bool
+	buf     bytes.Buffer
+}
+
+func (w *injector) decide(p []byte) {
+	if w.decided {
+		return
+	}
+	w.decided = true
+
+	h := w.Header()
+	ct := h.Get("Content-Type")
+	if ct == "" && len(p) > 0 {
+		ct = http.DetectContentType(p)
+	}
+	w.html = strings.HasPrefix(ct, "text/html") && h.Get("Content-Encoding") == ""
+	if !w.html {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+func (w *injector) WriteHeader(status int) {
+	if w.decided {
+		return
+	}
+	w.status = status
+	if w.Header().Get("Content-Type") != "" {
+		w.decide(nil)
+	}
+}
+
+func (w *injector) Write(p []byte) (int, error) {
+	w.decide(p)
+	if w.html {
+		return w.buf.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *injector) finish() {
+	if !w.decided {
+		w.decide(nil)
+	}
+	if !w.html {
+		return
+	}
+
+	body := w.buf.Bytes()
+	tag := []byte(fmt.Sprintf(script, Path))
+	if i := bytes.LastIndex(bytes.ToLower(body), []byte("</body>")); i >= 0 {
+		body = append(body[:i:i], append(tag, body[i:]...)...)
+	} else {
+		body = append(body, tag...)
+	}
+
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+	_, _ = w.ResponseWriter.Write(body)
+}
@@ -0,0 +1,95 @@
+package livereload
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	view := filepath.Join(dir, "home.jet")
+	_ = ioutil.WriteFile(view, []byte("v1"), 0644)
+	_ = os.MkdirAll(filepath.Join(dir, "bundles"), 0755)
+
+	l := New(dir)
+	l.Skip = []string{filepath.Join(dir, "bundles")}
+	l.files = l.scan()
+
+	_ = ioutil.WriteFile(filepath.Join(dir, "bundles", "app.css"), []byte("a{}"), 0644)
+	if changed := l.Check(); len(changed) != 0 {
+		t.Errorf("expected skipped directories to be ignored, got %v", changed)
+	}
+
+	later := time.Now().Add(time.Second)
+	_ = os.Chtimes(view, later, later)
+	if changed := l.Check(); len(changed) != 1 || changed[0] != view {
+		t.Errorf("expected the view to have changed, got %v", changed)
+	}
+
+	_ = os.Remove(view)
+	if changed := l.Check(); len(changed) != 1 {
+		t.Errorf("expected the removed view, got %v", changed)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	l := New()
+	page := l.Middleware(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Length", "27")
+		_, _ = rw.Write([]byte("<html><body>hi</body></html>"))
+	}))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept", "text/html")
+	rw := httptest.NewRecorder()
+	page.ServeHTTP(rw, r)
+
+	body := rw.Body.String()
+	if !strings.Contains(body, `new EventSource("/__livereload")`) || !strings.HasSuffix(body, "</script></body></html>") {
+		t.Errorf("expected the script before </body>, got %s", body)
+	}
+	if rw.Header().Get("Content-Length") != "" {
+		t.Error("expected the stale length to be dropped")
+	}
+
+	api := l.Middleware(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusCreated)
+		_, _ = rw.Write([]byte(`{}`))
+	}))
+	rw = httptest.NewRecorder()
+	api.ServeHTTP(rw, r)
+	if rw.Code != http.StatusCreated || rw.Body.String() != "{}" {
+		t.Errorf("expected other responses untouched, got %d %s", rw.Code, rw.Body.String())
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	l := New()
+	srv := httptest.NewServer(l)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	lines := bufio.NewReader(res.Body)
+	if line, _ := lines.ReadString('\n'); line != "retry: 1000\n" {
+		t.Fatalf("unexpected first line %q", line)
+	}
+	_, _ = lines.ReadString('\n')
+
+	l.Reload()
+	if line, _ := lines.ReadString('\n'); line != "event: reload\n" {
+		t.Errorf("expected a reload event, got %q", line)
+	}
+}
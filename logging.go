@@ -0,0 +1,44 @@
+package goravel
+
+import (
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/namnguyen191/goravel/logging"
+)
+
+// logFile is the file of LOG_FILE, closed when the process stops
+var logFile *logging.File
+
+// startLoggers creates grv.Logger, writing the records of LOG_LEVEL and above as LOG_FORMAT to
+// LOG_FILE, rotated past LOG_MAX_SIZE megabytes, or to standard output, and the info and error
+// loggers writing through it
+func (grv *Goravel) startLoggers() (*log.Logger, *log.Logger) {
+	level, levelErr := logging.ParseLevel(grv.Env.String("LOG_LEVEL", ""))
+	if levelErr == nil && grv.Env.String("LOG_LEVEL", "") == "" && grv.Env.Bool("DEBUG", false) {
+		level = logging.Debug
+	}
+
+	format := strings.ToLower(grv.Env.String("LOG_FORMAT", logging.Text))
+	if format != logging.JSON {
+		format = logging.Text
+	}
+
+	grv.Logger = logging.New(os.Stdout, level, format)
+	if path := grv.Env.String("LOG_FILE", ""); path != "" {
+		logFile = logging.NewFile(path, int64(grv.Env.Int("LOG_MAX_SIZE", 100))<<20)
+		logFile.MaxBackups = grv.Env.Int("LOG_MAX_BACKUPS", 7)
+		logFile.MaxAge = time.Duration(grv.Env.Int("LOG_MAX_AGE", 30)) * 24 * time.Hour
+		grv.Logger.Out = logFile
+	}
+
+	infoLog := grv.Logger.Std(logging.Info, false)
+	errorLog := grv.Logger.Std(logging.Error, true)
+	if levelErr != nil {
+		errorLog.Println(levelErr)
+	}
+
+	return infoLog, errorLog
+}
@@ -0,0 +1,163 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+)
+
+// File is a log file which is rotated past MaxSize: it is renamed with the time of the
+// rotation, e.g. app-20260314T091500.log, and a new one is started. Rotated files past
+// MaxBackups or older than MaxAge are removed.
+type File struct {
+	Path string
+	// MaxSize is the size in bytes a file is rotated at; 100MB by default
+	MaxSize int64
+	// MaxBackups is how many rotated files are kept, all of them when 0
+	MaxBackups int
+	// MaxAge is how long rotated files are kept, forever when 0
+	MaxAge time.Duration
+	// Clock names the rotated files and tells their age; the time of the machine when nil
+	Clock clock.Clock
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// NewFile returns the file at path, rotated past maxSize bytes
+func NewFile(path string, maxSize int64) *File {
+	return &File{Path: path, MaxSize: maxSize}
+}
+
+func (f *File) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.f, f.size = file, info.Size()
+	return nil
+}
+
+// Write appends p, rotating the file first when p would take it past MaxSize
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.f == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	max := f.MaxSize
+	if max <= 0 {
+		max = 100 << 20
+	}
+	if f.size > 0 && f.size+int64(len(p)) > max {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.f.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate starts a new file now, e.g. from a scheduled job rotating the logs every day
+func (f *File) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.f == nil {
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+	return f.rotate()
+}
+
+// rotate renames the current file and opens a new one. The caller holds mu.
+func (f *File) rotate() error {
+	if err := f.f.Close(); err != nil {
+		return err
+	}
+	f.f = nil
+
+	ext := filepath.Ext(f.Path)
+	base := strings.TrimSuffix(f.Path, ext)
+	stamp := clock.Now(f.Clock).Format("20060102T150405")
+	name := base + "-" + stamp + ext
+	// two rotations within a second get a counter
+	for i := 1; fileExists(name); i++ {
+		name = fmt.Sprintf("%s-%s.%d%s", base, stamp, i, ext)
+	}
+	if err := os.Rename(f.Path, name); err != nil {
+		return err
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.prune()
+	return nil
+}
+
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+// backups returns the rotated files, the latest first
+func (f *File) backups() []string {
+	ext := filepath.Ext(f.Path)
+	matches, _ := filepath.Glob(strings.TrimSuffix(f.Path, ext) + "-*" + ext)
+
+	// the names sort by the time of their rotation
+	sort.Sort(sort.Reverse(sort.StringSlice(matches)))
+	return matches
+}
+
+// prune removes the rotated files past MaxBackups or MaxAge
+func (f *File) prune() {
+	now := clock.Now(f.Clock)
+	for i, name := range f.backups() {
+		if f.MaxBackups > 0 && i >= f.MaxBackups {
+			os.Remove(name)
+			continue
+		}
+		if f.MaxAge > 0 {
+			if info, err := os.Stat(name); err == nil && now.Sub(info.ModTime()) > f.MaxAge {
+				os.Remove(name)
+			}
+		}
+	}
+}
+
+// Close closes the current file; a later Write opens it again
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.f == nil {
+		return nil
+	}
+	err := f.f.Close()
+	f.f = nil
+	return err
+}
@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+)
+
+func TestFileRotation(t *testing.T) {
+	dir := t.TempDir()
+	c := clock.NewTest(time.Date(2026, 3, 14, 9, 15, 0, 0, time.UTC))
+	f := &File{Path: filepath.Join(dir, "logs", "app.log"), MaxSize: 10, MaxBackups: 2, Clock: c}
+	defer f.Close()
+
+	for i := 0; i < 4; i++ {
+		if _, err := f.Write([]byte("12345678\n")); err != nil {
+			t.Fatal(err)
+		}
+		c.Advance(time.Minute)
+	}
+
+	names, _ := filepath.Glob(filepath.Join(dir, "logs", "*"))
+	for i := range names {
+		names[i] = filepath.Base(names[i])
+	}
+	want := "app-20260314T091700.log app-20260314T091800.log app.log"
+	if strings.Join(names, " ") != want {
+		t.Errorf("expected %s, got %v", want, names)
+	}
+
+	data, _ := os.ReadFile(f.Path)
+	if string(data) != "12345678\n" {
+		t.Errorf("expected the last write alone in the current file, got %q", data)
+	}
+}
+
+func TestFileReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	os.WriteFile(path, []byte("before\n"), 0644)
+
+	f := NewFile(path, 1<<20)
+	f.Write([]byte("after\n"))
+	f.Close()
+
+	if data, _ := os.ReadFile(path); string(data) != "before\nafter\n" {
+		t.Errorf("expected the file to be appended to, got %q", data)
+	}
+
+	c := clock.NewTest(time.Date(2026, 3, 14, 9, 15, 0, 0, time.UTC))
+	f.Clock = c
+	if err := f.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if _, err := os.Stat(filepath.Join(filepath.Dir(path), "app-20260314T091500.1.log")); err != nil {
+		t.Error("expected two rotations in a second to get distinct names")
+	}
+}
@@ -0,0 +1,272 @@
+// Package logging writes the logs of the app: records with a level and fields, as text or
+// JSON lines, to any writer, e.g. a File rotated by size. The *log.Logger of the app write
+// through it with Std, so the code logging with them keeps working.
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+)
+
+// Level is how important a record is
+type Level int
+
+// Levels of the records, from the least important
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+)
+
+var levelNames = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+func (l Level) String() string {
+	if l < Debug || l > Error {
+		return "LEVEL(" + strconv.Itoa(int(l)) + ")"
+	}
+	return levelNames[l]
+}
+
+// ParseLevel reads debug, info, warn (or warning) or error, in any case
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return Debug, nil
+	case "info", "":
+		return Info, nil
+	case "warn", "warning":
+		return Warn, nil
+	case "error":
+		return Error, nil
+	}
+	return Info, fmt.Errorf("logging: unknown level %q", s)
+}
+
+// Formats of the records
+const (
+	// Text writes a record as a line of its time, level, message and key=value fields
+	Text = "text"
+	// JSON writes a record as a JSON object per line, with time, level and msg keys
+	JSON = "json"
+)
+
+// Logger writes the records of Level and above to Out
+type Logger struct {
+	// Level is the least important level written
+	Level Level
+	// Format is Text or JSON; Text by default
+	Format string
+	// Out receives the records, standard output when nil
+	Out io.Writer
+	// Clock tells the time of the records; the time of the machine when nil
+	Clock clock.Clock
+
+	// mu is shared by the loggers of With, so their records do not interleave
+	mu     *sync.Mutex
+	fields []interface{}
+}
+
+// New returns a logger writing the records of level and above to out in format
+func New(out io.Writer, level Level, format string) *Logger {
+	return &Logger{Out: out, Level: level, Format: format, mu: &sync.Mutex{}}
+}
+
+// With returns a logger adding the key, value pairs of fields to every record, writing to the
+// same output
+func (l *Logger) With(fields ...interface{}) *Logger {
+	child := *l
+	child.fields = append(append([]interface{}{}, l.fields...), fields...)
+	return &child
+}
+
+// Enabled reports whether records of level are written
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.Level
+}
+
+func (l *Logger) Debug(msg string, fields ...interface{}) { l.Log(Debug, msg, fields...) }
+func (l *Logger) Info(msg string, fields ...interface{})  { l.Log(Info, msg, fields...) }
+func (l *Logger) Warn(msg string, fields ...interface{})  { l.Log(Warn, msg, fields...) }
+func (l *Logger) Error(msg string, fields ...interface{}) { l.Log(Error, msg, fields...) }
+
+// Log writes a record of level with msg and the key, value pairs of fields; an error value is
+// written as its message
+func (l *Logger) Log(level Level, msg string, fields ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	all := fields
+	if len(l.fields) > 0 {
+		all = append(append([]interface{}{}, l.fields...), fields...)
+	}
+	t := clock.Now(l.Clock)
+
+	var line []byte
+	if l.Format == JSON {
+		line = l.json(t, level, msg, all)
+	} else {
+		line = l.text(t, level, msg, all)
+	}
+
+	out := l.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	if l.mu != nil {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+	_, _ = out.Write(line)
+}
+
+// pairs walks the key, value pairs of fields; a lone value gets the key "!BADKEY" as with slog
+func pairs(fields []interface{}, fn func(key string, value interface{})) {
+	for i := 0; i < len(fields); i += 2 {
+		key, ok := fields[i].(string)
+		if !ok || i+1 == len(fields) {
+			fn("!BADKEY", fields[i])
+			i--
+			continue
+		}
+		value := fields[i+1]
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		if s, ok := value.(fmt.Stringer); ok {
+			value = s.String()
+		}
+		fn(key, value)
+	}
+}
+
+func (l *Logger) text(t time.Time, level Level, msg string, fields []interface{}) []byte {
+	var b strings.Builder
+	b.WriteString(t.Format(time.RFC3339))
+	b.WriteByte(' ')
+	b.WriteString(fmt.Sprintf("%-5s", level))
+	b.WriteByte(' ')
+	b.WriteString(strings.TrimRight(msg, "\n"))
+
+	pairs(fields, func(key string, value interface{}) {
+		b.WriteByte(' ')
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(quote(fmt.Sprint(value)))
+	})
+	b.WriteByte('\n')
+	return []byte(b.String())
+}
+
+// quote quotes values with spaces, quotes or an equal sign, so a line splits back into its fields
+func quote(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func (l *Logger) json(t time.Time, level Level, msg string, fields []interface{}) []byte {
+	record := map[string]interface{}{}
+	var keys []string
+	pairs(fields, func(key string, value interface{}) {
+		if _, ok := record[key]; !ok {
+			keys = append(keys, key)
+		}
+		record[key] = value
+	})
+	sort.Strings(keys)
+
+	// time, level and msg come first, the fields afterwards in order of their keys
+	var b strings.Builder
+	b.WriteString(`{"time":`)
+	writeJSON(&b, t.Format(time.RFC3339Nano))
+	b.WriteString(`,"level":`)
+	writeJSON(&b, level.String())
+	b.WriteString(`,"msg":`)
+	writeJSON(&b, strings.TrimRight(msg, "\n"))
+	for _, key := range keys {
+		if key == "time" || key == "level" || key == "msg" {
+			continue
+		}
+		b.WriteByte(',')
+		writeJSON(&b, key)
+		b.WriteByte(':')
+		writeJSON(&b, record[key])
+	}
+	b.WriteString("}\n")
+	return []byte(b.String())
+}
+
+func writeJSON(b *strings.Builder, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(v))
+	}
+	b.Write(data)
+}
+
+// stdWriter turns the lines of a *log.Logger into records
+type stdWriter struct {
+	l      *Logger
+	level  Level
+	caller bool
+}
+
+func (w stdWriter) Write(p []byte) (int, error) {
+	if !w.l.Enabled(w.level) {
+		return len(p), nil
+	}
+
+	var fields []interface{}
+	if w.caller {
+		// the frames are Write, then log.(*Logger).Output, the Print function, and its caller,
+		// past the wrapper of a method value like ErrorLog.Println
+		for skip := 3; skip < 6; skip++ {
+			_, file, line, ok := runtime.Caller(skip)
+			if !ok {
+				break
+			}
+			if file != "<autogenerated>" {
+				fields = append(fields, "caller", file[strings.LastIndex(file, "/")+1:]+":"+strconv.Itoa(line))
+				break
+			}
+		}
+	}
+	w.l.Log(w.level, string(p), fields...)
+	return len(p), nil
+}
+
+// Std returns a *log.Logger writing its lines as records of level, with the file and line
+// they were logged from when caller is true
+func (l *Logger) Std(level Level, caller bool) *log.Logger {
+	return log.New(stdWriter{l: l, level: level, caller: caller}, "", 0)
+}
+
+type contextKey struct{}
+
+// WithContext returns a context carrying l, e.g. the logger of a request with its id
+func WithContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// From returns the logger of ctx, or fallback when it has none
+func From(ctx context.Context, fallback *Logger) *Logger {
+	if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return l
+	}
+	return fallback
+}
@@ -0,0 +1,117 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/namnguyen191/goravel/clock"
+)
+
+func newLogger(format string) (*Logger, *bytes.Buffer) {
+	var b bytes.Buffer
+	l := New(&b, Info, format)
+	l.Clock = clock.NewTest(time.Date(2026, 3, 14, 9, 15, 0, 0, time.UTC))
+	return l, &b
+}
+
+func TestText(t *testing.T) {
+	l, b := newLogger(Text)
+
+	l.Debug("hidden")
+	l.With("user", 7).Warn("slow query", "table", "users", "took", 1500*time.Millisecond, "err", errors.New("timed out"), "sql", "select a = b")
+
+	want := `2026-03-14T09:15:00Z WARN  slow query user=7 table=users took=1.5s err="timed out" sql="select a = b"` + "\n"
+	if b.String() != want {
+		t.Errorf("expected %q, got %q", want, b.String())
+	}
+}
+
+func TestJSON(t *testing.T) {
+	l, b := newLogger(JSON)
+
+	l.With("request_id", "abc", "n", 1).Error("failed", "n", 2, "lone")
+
+	if !strings.HasPrefix(b.String(), `{"time":"2026-03-14T09:15:00Z","level":"ERROR","msg":"failed",`) {
+		t.Errorf("expected time, level and msg first, got %s", b.String())
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["request_id"] != "abc" || record["n"] != 2.0 || record["!BADKEY"] != "lone" {
+		t.Errorf("unexpected record %v", record)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for s, want := range map[string]Level{"debug": Debug, "INFO": Info, "warning": Warn, "error": Error, "": Info} {
+		if got, err := ParseLevel(s); err != nil || got != want {
+			t.Errorf("%q: expected %s, got %s (%v)", s, want, got, err)
+		}
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("expected an unknown level to fail")
+	}
+}
+
+func TestStd(t *testing.T) {
+	l, b := newLogger(Text)
+
+	l.Std(Error, true).Println("boom")
+	if !strings.HasPrefix(b.String(), "2026-03-14T09:15:00Z ERROR boom caller=logging_test.go:") {
+		t.Errorf("expected the line as a record with its caller, got %q", b.String())
+	}
+
+	b.Reset()
+	l.Level = Error
+	l.Std(Info, false).Printf("listening on %d", 4000)
+	if b.Len() != 0 {
+		t.Errorf("expected info to be left out, got %q", b.String())
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	l, b := newLogger(JSON)
+
+	var fromHandler *Logger
+	h := middleware.RequestID(l.Middleware(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		fromHandler = From(r.Context(), nil)
+		http.Error(rw, "nope", http.StatusNotFound)
+	})))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/missing?q=1", nil))
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["level"] != "WARN" || record["method"] != "GET" || record["path"] != "/missing" || record["status"] != 404.0 {
+		t.Errorf("unexpected record %v", record)
+	}
+	if id, _ := record["request_id"].(string); id == "" {
+		t.Error("expected the request id")
+	}
+	if fromHandler == nil || fromHandler == l {
+		t.Error("expected the handler to get the logger of the request")
+	}
+
+	b.Reset()
+	panicking := l.Middleware(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) { panic("boom") }))
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected the panic to be passed on")
+			}
+		}()
+		panicking.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", nil))
+	}()
+	if !strings.Contains(b.String(), `"status":500`) {
+		t.Errorf("expected a panic to be logged as a 500, got %s", b.String())
+	}
+}
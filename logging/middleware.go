@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/trace"
+)
+
+// Middleware logs every request once it was served, with its method, path, status, duration,
+// size and request id: as Info, Warn for a 4xx status and Error for a 5xx one. The handlers
+// get the logger of the request, carrying its id, with From. It goes after chi's RequestID
+// middleware.
+func (l *Logger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		start := clock.Now(l.Clock)
+		reqLog := l
+		if id := trace.ID(r.Context()); id != "" {
+			reqLog = l.With("request_id", id)
+		}
+
+		ww := middleware.NewWrapResponseWriter(rw, r.ProtoMajor)
+		defer func() {
+			status := ww.Status()
+			p := recover()
+			switch {
+			case p != nil:
+				// logged as the error the recoverer answers with, then passed on to it
+				status = http.StatusInternalServerError
+				defer panic(p)
+			case status == 0:
+				// nothing was written, which net/http answers with a 200
+				status = http.StatusOK
+			}
+
+			level := Info
+			switch {
+			case status >= 500:
+				level = Error
+			case status >= 400:
+				level = Warn
+			}
+			reqLog.Log(level, "request",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", status,
+				"duration_ms", float64(clock.Since(l.Clock, start).Microseconds())/1000,
+				"bytes", ww.BytesWritten(),
+				"remote", r.RemoteAddr,
+			)
+		}()
+
+		next.ServeHTTP(ww, r.WithContext(WithContext(r.Context(), reqLog)))
+	})
+}
@@ -0,0 +1,141 @@
+package mailer
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimit caps what is sent through one provider: a steady rate per second and a daily
+// quota. Messages over the quota are deferred to the next day (UTC) instead of failing.
+type RateLimit struct {
+	PerSecond float64
+	PerDay    int
+	// WarnAt is the share of PerDay after which OnQuota is called, once a day; 0.8 by default
+	WarnAt float64
+	// OnQuota is called when the daily count reaches WarnAt, and again when it reaches PerDay
+	OnQuota func(sent, limit int)
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	day    string
+	sent   int
+	warned bool
+	full   bool
+	now    func() time.Time
+}
+
+// Sent returns the number of messages sent today
+func (l *RateLimit) Sent() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rollover(l.clock())
+	return l.sent
+}
+
+func (l *RateLimit) clock() time.Time {
+	if l.now != nil {
+		return l.now()
+	}
+	return time.Now()
+}
+
+func (l *RateLimit) rollover(now time.Time) {
+	if day := now.UTC().Format("2006-01-02"); day != l.day {
+		l.day, l.sent, l.warned, l.full = day, 0, false, false
+	}
+}
+
+// reserve takes a slot for one message. It returns how long to wait before sending to keep
+// under PerSecond, or, when the daily quota is used up, the time the message may be sent.
+func (l *RateLimit) reserve() (wait time.Duration, deferUntil time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock()
+	l.rollover(now)
+
+	if l.PerDay > 0 && l.sent >= l.PerDay {
+		if !l.full {
+			l.full = true
+			l.alert(l.sent)
+		}
+		y, m, d := now.UTC().Date()
+		return 0, time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+	}
+
+	if l.PerSecond > 0 {
+		if l.last.IsZero() {
+			l.tokens = l.PerSecond
+		} else {
+			l.tokens += now.Sub(l.last).Seconds() * l.PerSecond
+		}
+		// bursts are limited to one second worth of messages
+		if l.tokens > l.PerSecond {
+			l.tokens = l.PerSecond
+		}
+		l.last = now
+
+		l.tokens--
+		if l.tokens < 0 {
+			wait = time.Duration(-l.tokens / l.PerSecond * float64(time.Second))
+		}
+	}
+
+	l.sent++
+
+	warnAt := l.WarnAt
+	if warnAt == 0 {
+		warnAt = 0.8
+	}
+	if l.PerDay > 0 && !l.warned && float64(l.sent) >= warnAt*float64(l.PerDay) {
+		l.warned = true
+		l.alert(l.sent)
+	}
+
+	return wait, time.Time{}
+}
+
+func (l *RateLimit) alert(sent int) {
+	if l.OnQuota != nil {
+		go l.OnQuota(sent, l.PerDay)
+	}
+}
+
+// Transport names the provider messages currently go through, the key of Mail.Limits
+func (m *Mail) Transport() string {
+	if m.Custom != nil {
+		if m.CustomName == "" {
+			return "custom"
+		}
+		return m.CustomName
+	}
+
+	if m.usesAPI() {
+		return m.API
+	}
+
+	return "smtp"
+}
+
+// throttle applies the rate limit of the current provider to a queued message. It reports
+// false when the message was deferred; it is queued again once the quota resets.
+func (m *Mail) throttle(msg Message) bool {
+	l := m.Limits[m.Transport()]
+	if l == nil {
+		return true
+	}
+
+	wait, until := l.reserve()
+	if !until.IsZero() {
+		time.AfterFunc(time.Until(until), func() { m.Jobs <- msg })
+		return false
+	}
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+
+	return true
+}
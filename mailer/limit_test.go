@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimit_reserve(t *testing.T) {
+	now := time.Date(2021, 7, 1, 23, 59, 0, 0, time.UTC)
+	alerts := make(chan int, 2)
+
+	l := &RateLimit{PerSecond: 2, PerDay: 5, OnQuota: func(sent, limit int) { alerts <- sent }}
+	l.now = func() time.Time { return now }
+
+	var waits []time.Duration
+	for i := 0; i < 5; i++ {
+		wait, until := l.reserve()
+		if !until.IsZero() {
+			t.Fatalf("message %d deferred", i)
+		}
+		waits = append(waits, wait)
+	}
+
+	if waits[0] != 0 || waits[1] != 0 || waits[2] != 500*time.Millisecond || waits[3] != time.Second {
+		t.Errorf("unexpected waits %v", waits)
+	}
+	if sent := <-alerts; sent != 4 {
+		t.Error("expected a warning at 80% of the quota, got", sent)
+	}
+
+	if _, until := l.reserve(); !until.Equal(time.Date(2021, 7, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Error("expected the message to be deferred to midnight, got", until)
+	}
+	if sent := <-alerts; sent != 5 {
+		t.Error("expected an alert once the quota is reached, got", sent)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, until := l.reserve(); !until.IsZero() || l.Sent() != 1 {
+		t.Error("expected the quota to reset the next day")
+	}
+}
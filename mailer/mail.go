@@ -2,6 +2,7 @@ package mailer
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"html/template"
 	"io/ioutil"
@@ -10,6 +11,8 @@ import (
 
 	apimaildriver "github.com/ainsleyclark/go-mail/drivers"
 	apimail "github.com/ainsleyclark/go-mail/mail"
+	"github.com/namnguyen191/goravel/breaker"
+	"github.com/namnguyen191/goravel/trace"
 	"github.com/vanng822/go-premailer/premailer"
 	mail "github.com/xhit/go-simple-mail/v2"
 )
@@ -29,6 +32,14 @@ type Mail struct {
 	API         string
 	APIKey      string
 	APIUrl      string
+	// Breaker, when set, stops calling the mail API while it keeps failing
+	Breaker *breaker.Breaker
+	// Limits caps the messages sent from Jobs per provider ("smtp", "mailgun", ...)
+	Limits map[string]*RateLimit
+	// Custom, when set, delivers the messages instead of SMTP or the API; CustomName is the
+	// name it was registered as, see UseTransport
+	Custom     Transport
+	CustomName string
 }
 
 type Message struct {
@@ -38,36 +49,112 @@ type Message struct {
 	Subject     string
 	Template    string
 	Attachments []string
-	Data        interface{}
+	// Files are attachments built in memory, e.g. a calendar invite
+	Files []File
+	Data  interface{}
+	// RequestID is the id of the request which sent or queued the message, passed on in the
+	// X-Request-Id header; Queue and SendContext take it from the context
+	RequestID string
+}
+
+// File is an in-memory attachment
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
 }
 
 type Result struct {
-	Success bool
-	Error   error
+	Success   bool
+	Error     error
+	RequestID string
 }
 
 func (m *Mail) ListenForMail() {
 	for {
 		msg := <-m.Jobs
-		err := m.Send(msg)
-		if err != nil {
-			m.Results <- Result{false, err}
-		} else {
-			m.Results <- Result{true, nil}
+		if !m.throttle(msg) {
+			continue
+		}
+
+		err := m.SendContext(trace.WithID(context.Background(), msg.RequestID), msg)
+		m.Results <- Result{Success: err == nil, Error: err, RequestID: msg.RequestID}
+	}
+}
+
+// Drain sends the messages left on Jobs, for a process shutting down, skipping the rate
+// limits. It returns once Jobs is empty or ctx is done; a message ListenForMail took off Jobs
+// just before is not waited on.
+func (m *Mail) Drain(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg := <-m.Jobs:
+			err := m.SendContext(trace.WithID(ctx, msg.RequestID), msg)
+			select {
+			case m.Results <- Result{Success: err == nil, Error: err, RequestID: msg.RequestID}:
+			default:
+			}
+		default:
+			return nil
 		}
 	}
 }
 
+// Queue puts msg on Jobs, keeping the request id of ctx so the delivery can be traced back
+// to the request which queued it
+func (m *Mail) Queue(ctx context.Context, msg Message) {
+	if msg.RequestID == "" {
+		msg.RequestID = trace.ID(ctx)
+	}
+	m.Jobs <- msg
+}
+
 func (m *Mail) Send(msg Message) error {
+	return m.SendContext(context.Background(), msg)
+}
+
+// SendContext sends msg unless ctx is done first. The templates are rendered and the SMTP
+// connection is set up within the deadline of ctx; once the message is handed to the server
+// or API it is not abandoned half way, which could otherwise deliver it twice on a retry.
+func (m *Mail) SendContext(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if msg.RequestID == "" {
+		msg.RequestID = trace.ID(ctx)
+	}
+
+	if m.Custom != nil {
+		return m.sendCustom(msg)
+	}
+
 	// TODO: are we using an API or SMTP
 	if len(m.API) > 0 && len(m.APIKey) > 0 && len(m.APIUrl) > 0 && m.API != "smtp" {
-		return m.ChooseAPI(msg)
+		return m.chooseAPI(ctx, msg)
 	}
 
-	return m.SendSMTPMessage(msg)
+	return m.sendSMTP(ctx, msg)
+}
+
+func (m *Mail) sendCustom(msg Message) error {
+	res := m.Custom.Send(msg)
+	if res.Error != nil {
+		return res.Error
+	}
+	if !res.Success {
+		return fmt.Errorf("mailer: %s did not send the message", m.Transport())
+	}
+	return nil
 }
 
 func (m *Mail) SendSMTPMessage(msg Message) error {
+	return m.sendSMTP(context.Background(), msg)
+}
+
+func (m *Mail) sendSMTP(ctx context.Context, msg Message) error {
 	formattedMessage, err := m.buildHTMLMessage(msg)
 	if err != nil {
 		return err
@@ -87,6 +174,19 @@ func (m *Mail) SendSMTPMessage(msg Message) error {
 	server.KeepAlive = false
 	server.ConnectTimeout = 10 * time.Second
 	server.SendTimeout = 10 * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		left := time.Until(deadline)
+		if left < server.ConnectTimeout {
+			server.ConnectTimeout = left
+		}
+		if left < server.SendTimeout {
+			server.SendTimeout = left
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	smtpClient, err := server.Connect()
 	if err != nil {
@@ -99,6 +199,10 @@ func (m *Mail) SendSMTPMessage(msg Message) error {
 		AddTo(msg.To).
 		SetSubject(msg.Subject)
 
+	if msg.RequestID != "" {
+		email.AddHeader(trace.Header, msg.RequestID)
+	}
+
 	email.SetBody(mail.TextHTML, formattedMessage)
 	email.AddAlternative(mail.TextPlain, plainMessage)
 
@@ -107,6 +211,9 @@ func (m *Mail) SendSMTPMessage(msg Message) error {
 			email.AddAttachment(x)
 		}
 	}
+	for _, f := range msg.Files {
+		email.Attach(&mail.File{Name: f.Name, MimeType: f.ContentType, Data: f.Data})
+	}
 
 	err = email.Send(smtpClient)
 	if err != nil {
@@ -190,10 +297,19 @@ func (m *Mail) inlineCSS(s string) (string, error) {
 }
 
 func (m *Mail) ChooseAPI(msg Message) error {
+	return m.chooseAPI(context.Background(), msg)
+}
+
+func (m *Mail) chooseAPI(ctx context.Context, msg Message) error {
 	switch m.API {
 	case "mailgun", "sparkpost", "sendgrid":
 		{
-			return m.SendUsingAPI(msg, m.API)
+			if m.Breaker != nil {
+				return m.Breaker.Execute(func() error {
+					return m.sendUsingAPI(ctx, msg, m.API)
+				})
+			}
+			return m.sendUsingAPI(ctx, msg, m.API)
 		}
 	default:
 		{
@@ -203,6 +319,10 @@ func (m *Mail) ChooseAPI(msg Message) error {
 }
 
 func (m *Mail) SendUsingAPI(msg Message, transport string) error {
+	return m.sendUsingAPI(context.Background(), msg, transport)
+}
+
+func (m *Mail) sendUsingAPI(ctx context.Context, msg Message, transport string) error {
 	if msg.From == "" {
 		msg.From = m.FromAddress
 	}
@@ -259,6 +379,9 @@ func (m *Mail) SendUsingAPI(msg Message, transport string) error {
 		HTML:       formattedMessage,
 		PlainText:  plainMessage,
 	}
+	if msg.RequestID != "" {
+		tx.Headers = map[string]string{trace.Header: msg.RequestID}
+	}
 
 	// add attachments
 	err = m.addAPIAttachments(msg, tx)
@@ -266,6 +389,10 @@ func (m *Mail) SendUsingAPI(msg Message, transport string) error {
 		return err
 	}
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	_, err = driver.Send(tx)
 	if err != nil {
 		return err
@@ -275,7 +402,7 @@ func (m *Mail) SendUsingAPI(msg Message, transport string) error {
 }
 
 func (m *Mail) addAPIAttachments(msg Message, tx *apimail.Transmission) error {
-	if len(msg.Attachments) > 0 {
+	if len(msg.Attachments) > 0 || len(msg.Files) > 0 {
 		var attachments []apimail.Attachment
 
 		for _, x := range msg.Attachments {
@@ -290,6 +417,9 @@ func (m *Mail) addAPIAttachments(msg Message, tx *apimail.Transmission) error {
 			attach.Filename = fileName
 			attachments = append(attachments, attach)
 		}
+		for _, f := range msg.Files {
+			attachments = append(attachments, apimail.Attachment{Filename: f.Name, Bytes: f.Data})
+		}
 
 		tx.Attachments = attachments
 	}
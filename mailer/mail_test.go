@@ -1,7 +1,11 @@
 package mailer
 
 import (
+	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -145,3 +149,69 @@ func TestMail_ChooseAPI(t *testing.T) {
 		t.Error("choose an unknown API did not return error")
 	}
 }
+
+func TestMail_Verify(t *testing.T) {
+	if err := mailer.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	m := mailer
+	m.Port = 1
+	if err := m.Verify(); err == nil {
+		t.Error("expected an error for a closed port")
+	}
+}
+
+func TestMail_verifyAPI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer good" {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	m := Mail{API: "sendgrid", APIKey: "good", APIUrl: srv.URL}
+	if err := m.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	m.APIKey = "bad"
+	if err := m.Verify(); err == nil || !strings.Contains(err.Error(), "rejected") {
+		t.Error("expected the key to be rejected, got", err)
+	}
+}
+
+func TestMail_SendTest(t *testing.T) {
+	if err := mailer.SendTest("you@there.com"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMail_Drain(t *testing.T) {
+	m := mailer
+	m.Jobs = make(chan Message, 2)
+	m.Results = make(chan Result, 1)
+
+	msg := Message{
+		From:     "me@here.com",
+		To:       "you@there.com",
+		Subject:  "Test",
+		Template: "test",
+	}
+	m.Jobs <- msg
+	m.Jobs <- msg
+
+	if err := m.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Jobs) != 0 {
+		t.Error("expected the queued messages to be sent")
+	}
+
+	// the results which nobody reads are dropped rather than blocking the drain
+	if res := <-m.Results; res.Error != nil {
+		t.Error(res.Error)
+	}
+}
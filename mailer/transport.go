@@ -0,0 +1,126 @@
+package mailer
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Transport delivers messages instead of SMTP or the mail APIs, e.g. an internal relay, a
+// hand off to a message queue or a capture for tests. It gets the message as it was sent;
+// Render turns its template into the HTML and plain text bodies.
+type Transport interface {
+	Send(msg Message) Result
+}
+
+// TransportFunc is a function used as a Transport
+type TransportFunc func(msg Message) Result
+
+func (f TransportFunc) Send(msg Message) Result {
+	return f(msg)
+}
+
+// TransportFactory creates a transport for a Mail, reading its settings or the environment
+type TransportFactory func(m *Mail) (Transport, error)
+
+var (
+	transportsMu sync.RWMutex
+	transports   = map[string]TransportFactory{}
+)
+
+// builtin are the providers of SMTP and the mail APIs, which are not transports
+var builtin = map[string]bool{"smtp": true, "mailgun": true, "sparkpost": true, "sendgrid": true, "postal": true, "postmark": true}
+
+func init() {
+	RegisterTransport("capture", func(m *Mail) (Transport, error) { return &Capture{}, nil })
+}
+
+// RegisterTransport makes a transport available as MAIL_TRANSPORT=name, usually from the
+// init function of the package implementing it. It panics when name is taken, including by
+// smtp and the mail APIs, or factory is nil.
+func RegisterTransport(name string, factory TransportFactory) {
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+
+	if factory == nil {
+		panic("mailer: RegisterTransport factory is nil")
+	}
+	if _, dup := transports[name]; dup || builtin[name] {
+		panic("mailer: RegisterTransport called twice for transport " + name)
+	}
+	transports[name] = factory
+}
+
+// Transports returns the names of the registered transports, sorted
+func Transports() []string {
+	transportsMu.RLock()
+	defer transportsMu.RUnlock()
+
+	names := make([]string, 0, len(transports))
+	for name := range transports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UseTransport sends the messages of m through the transport registered as name
+func (m *Mail) UseTransport(name string) error {
+	transportsMu.RLock()
+	factory := transports[name]
+	transportsMu.RUnlock()
+
+	if factory == nil {
+		return fmt.Errorf("mailer: unknown transport %q", name)
+	}
+
+	t, err := factory(m)
+	if err != nil {
+		return fmt.Errorf("mailer: %s: %w", name, err)
+	}
+
+	m.Custom, m.CustomName = t, name
+	return nil
+}
+
+// Render returns the HTML and plain text bodies of msg, for transports which send them
+func (m *Mail) Render(msg Message) (html, plain string, err error) {
+	if html, err = m.buildHTMLMessage(msg); err != nil {
+		return "", "", err
+	}
+	if plain, err = m.buildPlainTextMessage(msg); err != nil {
+		return "", "", err
+	}
+	return html, plain, nil
+}
+
+// Capture keeps the messages sent instead of delivering them, for tests and local
+// development
+type Capture struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func (c *Capture) Send(msg Message) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.messages = append(c.messages, msg)
+	return Result{Success: true, RequestID: msg.RequestID}
+}
+
+// Messages returns the messages sent so far
+func (c *Capture) Messages() []Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Message{}, c.messages...)
+}
+
+// Reset forgets the messages sent so far
+func (c *Capture) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.messages = nil
+}
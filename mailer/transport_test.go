@@ -0,0 +1,60 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/namnguyen191/goravel/trace"
+)
+
+func TestMail_UseTransport(t *testing.T) {
+	m := Mail{FromAddress: "me@here.com"}
+	if err := m.UseTransport("capture"); err != nil {
+		t.Fatal(err)
+	}
+	if m.Transport() != "capture" {
+		t.Errorf("expected the capture transport, got %s", m.Transport())
+	}
+
+	ctx := trace.WithID(context.Background(), "req-1")
+	msg := Message{To: "you@there.com", Subject: "test"}
+	if err := m.SendContext(ctx, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := m.Custom.(*Capture).Messages()
+	if len(sent) != 1 || sent[0].To != "you@there.com" || sent[0].RequestID != "req-1" {
+		t.Errorf("unexpected captured messages %+v", sent)
+	}
+
+	if err := m.UseTransport("missing"); err == nil {
+		t.Error("expected an error for an unknown transport")
+	}
+}
+
+func TestMail_CustomTransportError(t *testing.T) {
+	failed := errors.New("relay down")
+	m := Mail{Custom: TransportFunc(func(msg Message) Result { return Result{Error: failed} })}
+	if err := m.Send(Message{To: "you@there.com"}); err != failed {
+		t.Errorf("expected the error of the transport, got %v", err)
+	}
+
+	m.Custom = TransportFunc(func(msg Message) Result { return Result{} })
+	if err := m.Send(Message{To: "you@there.com"}); err == nil {
+		t.Error("expected an error for a message the transport did not send")
+	}
+}
+
+func TestRegisterTransport(t *testing.T) {
+	for _, name := range []string{"smtp", "mailgun", "capture"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected registering %s to panic", name)
+				}
+			}()
+			RegisterTransport(name, func(m *Mail) (Transport, error) { return &Capture{}, nil })
+		}()
+	}
+}
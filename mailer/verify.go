@@ -0,0 +1,213 @@
+package mailer
+
+import (
+	"crypto/tls"
+	"embed"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// usesAPI reports whether messages go through the mail API rather than SMTP, as Send decides
+func (m *Mail) usesAPI() bool {
+	return len(m.API) > 0 && len(m.APIKey) > 0 && len(m.APIUrl) > 0 && m.API != "smtp"
+}
+
+// Verify checks that mail can be sent: the SMTP server accepts a connection, TLS and the
+// credentials, or the API key is valid. Nothing is sent. A custom transport is checked when
+// it has a Verify() error method.
+func (m *Mail) Verify() error {
+	if m.Custom != nil {
+		if v, ok := m.Custom.(interface{ Verify() error }); ok {
+			return v.Verify()
+		}
+		return nil
+	}
+
+	if m.usesAPI() {
+		return m.verifyAPI()
+	}
+
+	return m.verifySMTP()
+}
+
+func (m *Mail) verifySMTP() error {
+	if m.Host == "" {
+		return fmt.Errorf("smtp: no host configured")
+	}
+
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	tlsConfig := &tls.Config{ServerName: m.Host}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+
+	var conn net.Conn
+	var err error
+	if strings.ToLower(m.Encryption) == "ssl" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("smtp: cannot connect to %s: %w", addr, err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(20 * time.Second))
+
+	c, err := smtp.NewClient(conn, m.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp: %s did not greet: %w", addr, err)
+	}
+	defer c.Close()
+
+	if err := c.Hello(helloName(m.Domain)); err != nil {
+		return fmt.Errorf("smtp: EHLO refused: %w", err)
+	}
+
+	if strings.ToLower(m.Encryption) == "tls" {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp: %s does not support STARTTLS", addr)
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("smtp: STARTTLS failed: %w", err)
+		}
+	}
+
+	if m.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return fmt.Errorf("smtp: %s does not accept authentication", addr)
+		}
+		if err := c.Auth(&plainAuth{username: m.Username, password: m.Password}); err != nil {
+			return fmt.Errorf("smtp: authentication as %s failed: %w", m.Username, err)
+		}
+	}
+
+	return c.Quit()
+}
+
+// plainAuth is smtp.PlainAuth without its refusal to send credentials to localhost over
+// plain connections, which breaks local relays like mailhog; the encryption setting decides
+type plainAuth struct {
+	username, password string
+}
+
+func (a *plainAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	return "PLAIN", []byte("\x00" + a.username + "\x00" + a.password), nil
+}
+
+func (a *plainAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if more {
+		return nil, fmt.Errorf("unexpected server challenge")
+	}
+	return nil, nil
+}
+
+func helloName(domain string) string {
+	if domain != "" {
+		return domain
+	}
+	if host, err := os.Hostname(); err == nil {
+		return host
+	}
+	return "localhost"
+}
+
+// verifyAPI calls a read only endpoint of the provider with the configured key
+func (m *Mail) verifyAPI() error {
+	base := strings.TrimSuffix(m.APIUrl, "/")
+
+	var req *http.Request
+	var err error
+
+	switch m.API {
+	case "mailgun":
+		req, err = http.NewRequest(http.MethodGet, base+"/v3/domains/"+m.Domain, nil)
+		if err == nil {
+			req.SetBasicAuth("api", m.APIKey)
+		}
+	case "sendgrid":
+		req, err = http.NewRequest(http.MethodGet, base+"/v3/scopes", nil)
+		if err == nil {
+			req.Header.Set("Authorization", "Bearer "+m.APIKey)
+		}
+	case "sparkpost":
+		req, err = http.NewRequest(http.MethodGet, base+"/api/v1/account", nil)
+		if err == nil {
+			req.Header.Set("Authorization", m.APIKey)
+		}
+	default:
+		return fmt.Errorf("unknown api %s; only mailgun, sparkpost or sendgrid accepted", m.API)
+	}
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: cannot reach %s: %w", m.API, base, err)
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%s: the api key was rejected (%s)", m.API, res.Status)
+	case res.StatusCode == http.StatusNotFound && m.API == "mailgun":
+		return fmt.Errorf("mailgun: domain %q is not set up for this key", m.Domain)
+	case res.StatusCode >= 300:
+		return fmt.Errorf("%s: unexpected response %s", m.API, res.Status)
+	}
+
+	return nil
+}
+
+// SendTest sends a short built-in message to an address through the configured transport,
+// so delivery can be checked without any application templates
+func (m *Mail) SendTest(to string) error {
+	dir, err := ioutil.TempDir("", "goravel-mail")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"test.html.tmpl", "test.plain.tmpl"} {
+		b, err := templateFS.ReadFile("templates/" + name)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), b, 0644); err != nil {
+			return err
+		}
+	}
+
+	transport := "smtp " + m.Host
+	if m.usesAPI() {
+		transport = m.API
+	}
+
+	test := *m
+	test.Templates = dir
+
+	return test.Send(Message{
+		From:     m.FromAddress,
+		FromName: m.FromName,
+		To:       to,
+		Subject:  "Test message",
+		Template: "test",
+		Data: map[string]string{
+			"Sender":    m.FromAddress,
+			"Sent":      time.Now().Format(time.RFC1123),
+			"Transport": transport,
+		},
+	})
+}
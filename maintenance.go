@@ -0,0 +1,89 @@
+package goravel
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/namnguyen191/goravel/leader"
+	"github.com/namnguyen191/goravel/maintenance"
+)
+
+// scheduleMaintenance registers the built-in housekeeping tasks and runs them on
+// MAINTENANCE_SCHEDULE (daily by default, "off" disables them)
+func (grv *Goravel) scheduleMaintenance() error {
+	grv.Maintenance = maintenance.New()
+	grv.Maintenance.Clock = grv.Clock
+
+	logDays := grv.Env.Int("PRUNE_LOGS_DAYS", 14)
+	tmpHours := grv.Env.Int("PRUNE_TMP_HOURS", 24)
+
+	if logDays > 0 {
+		grv.Maintenance.Add("prune logs", func() (int, error) {
+			return grv.Maintenance.PruneDir(grv.RootPath+"/logs", time.Duration(logDays)*24*time.Hour)
+		})
+	}
+
+	if tmpHours > 0 {
+		grv.Maintenance.Add("clear tmp", func() (int, error) {
+			// the badger cache, the kv store and the badger queue keep their data files in
+			// tmp/badger, tmp/kv and tmp/jobs
+			return grv.Maintenance.PruneDir(grv.RootPath+"/tmp", time.Duration(tmpHours)*time.Hour, "badger", "kv", "jobs")
+		})
+	}
+
+	switch grv.config.sessionType {
+	case "mysql", "postgres", "mariadb", "postgresql":
+		grv.Maintenance.Add("purge expired sessions", func() (int, error) {
+			return grv.Maintenance.PurgeExpiredSessions(grv.DB.Pool, grv.DB.DataBaseType)
+		})
+	}
+
+	if retain := grv.Env.Int("ANALYTICS_RETAIN_DAYS", 90); grv.Analytics != nil && retain > 0 {
+		grv.Maintenance.Add("prune analytics events", func() (int, error) {
+			n, err := grv.Analytics.Prune(context.Background(), time.Duration(retain)*24*time.Hour)
+			return int(n), err
+		})
+	}
+
+	if days := grv.Env.Int("PRUNE_FAILED_JOBS_DAYS", 30); grv.Jobs != nil && days > 0 {
+		grv.Maintenance.Add("prune failed jobs", func() (int, error) {
+			return grv.Jobs.PruneFailed(context.Background(), time.Duration(days)*24*time.Hour)
+		})
+	}
+
+	if grv.OIDC != nil {
+		grv.Maintenance.Add("purge expired oidc codes", func() (int, error) {
+			n, err := grv.OIDC.PurgeCodes(context.Background())
+			return int(n), err
+		})
+	}
+
+	if grv.Privacy != nil {
+		grv.Maintenance.Add("enforce retention policies", func() (int, error) {
+			return grv.Privacy.Enforce(context.Background())
+		})
+	}
+
+	schedule := os.Getenv("MAINTENANCE_SCHEDULE")
+	if schedule == "off" {
+		return nil
+	}
+
+	if schedule == "" {
+		schedule = "@daily"
+	}
+
+	// logs and tmp are local to every instance, and purging expired rows twice is harmless
+	_, err := grv.Scheduler.AddJob(schedule, leader.Everywhere(func() {
+		for _, report := range grv.Maintenance.Run() {
+			if report.Error != nil {
+				grv.ErrorLog.Printf("maintenance task %s failed: %v", report.Task, report.Error)
+				continue
+			}
+			grv.InfoLog.Printf("maintenance task %s removed %d items", report.Task, report.Removed)
+		}
+	}))
+
+	return err
+}
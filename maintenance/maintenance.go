@@ -0,0 +1,130 @@
+package maintenance
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+)
+
+// Task is a housekeeping job run on the maintenance schedule
+type Task struct {
+	Name string
+	Run  func() (int, error)
+}
+
+// Report is the outcome of a single task
+type Report struct {
+	Task    string
+	Removed int
+	Error   error
+}
+
+// Maintenance holds the registered housekeeping tasks
+type Maintenance struct {
+	// Clock tells how old the files and sessions are; the time of the machine when nil
+	Clock clock.Clock
+
+	mu    sync.Mutex
+	tasks []Task
+}
+
+// New returns an empty task list
+func New() *Maintenance {
+	return &Maintenance{}
+}
+
+// Add registers a task; Run reports how many items it removed
+func (m *Maintenance) Add(name string, run func() (int, error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tasks = append(m.tasks, Task{Name: name, Run: run})
+}
+
+// Tasks returns the registered tasks
+func (m *Maintenance) Tasks() []Task {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Task{}, m.tasks...)
+}
+
+// Run executes every task, continuing after failures
+func (m *Maintenance) Run() []Report {
+	var reports []Report
+	for _, t := range m.Tasks() {
+		n, err := t.Run()
+		reports = append(reports, Report{Task: t.Name, Removed: n, Error: err})
+	}
+
+	return reports
+}
+
+// PruneDir removes the files below dir last modified before olderThan ago, skipping the
+// top-level entries named in exclude, and then removes directories left empty
+func (m *Maintenance) PruneDir(dir string, olderThan time.Duration, exclude ...string) (int, error) {
+	skip := make(map[string]bool)
+	for _, e := range exclude {
+		skip[filepath.Join(dir, e)] = true
+	}
+
+	cutoff := clock.Now(m.Clock).Add(-olderThan)
+	removed := 0
+	var dirs []string
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if skip[path] {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.IsDir() {
+			if path != dir {
+				dirs = append(dirs, path)
+			}
+			return nil
+		}
+
+		if info.ModTime().Before(cutoff) {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			removed++
+		}
+
+		return nil
+	})
+
+	// deepest directories first so parents can become empty
+	for i := len(dirs) - 1; i >= 0; i-- {
+		_ = os.Remove(dirs[i])
+	}
+
+	return removed, err
+}
+
+// PurgeExpiredSessions deletes expired rows from the sessions table used by the database session stores
+func (m *Maintenance) PurgeExpiredSessions(db *sql.DB, dbType string) (int, error) {
+	res, err := db.Exec(database.Rebind(dbType, "delete from sessions where expiry < ?"), clock.Now(m.Clock))
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+
+	return int(n), err
+}
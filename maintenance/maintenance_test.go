@@ -0,0 +1,102 @@
+package maintenance
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/namnguyen191/goravel/kv"
+)
+
+func TestPruneDir(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+
+	files := map[string]bool{
+		"old.log":         true,
+		"new.log":         false,
+		"nested/old.txt":  true,
+		"badger/000.vlog": false,
+	}
+
+	for name, isOld := range files {
+		path := filepath.Join(dir, name)
+		_ = os.MkdirAll(filepath.Dir(path), 0755)
+		_ = os.WriteFile(path, []byte("x"), 0644)
+		if isOld || name == "badger/000.vlog" {
+			_ = os.Chtimes(path, old, old)
+		}
+	}
+
+	removed, err := New().PruneDir(dir, 24*time.Hour, "badger")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if removed != 2 {
+		t.Errorf("expected 2 files removed, got %d", removed)
+	}
+
+	for _, kept := range []string{"new.log", "badger/000.vlog"} {
+		if _, err := os.Stat(filepath.Join(dir, kept)); err != nil {
+			t.Errorf("%s should have been kept", kept)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "nested")); !os.IsNotExist(err) {
+		t.Error("empty directories should be removed")
+	}
+}
+
+func TestPruneDirKeepsStore(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := badger.Open(badger.DefaultOptions(filepath.Join(dir, "kv")).WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := kv.New(db, "test").Set("visits", 3, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the files of the store are as old as those of a store left alone for days
+	old := time.Now().Add(-48 * time.Hour)
+	_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil {
+			_ = os.Chtimes(path, old, old)
+		}
+		return err
+	})
+
+	if removed, err := New().PruneDir(dir, 24*time.Hour, "badger", "kv"); err != nil || removed != 0 {
+		t.Fatalf("expected nothing removed, got %d %v", removed, err)
+	}
+
+	db, err = badger.Open(badger.DefaultOptions(filepath.Join(dir, "kv")).WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var visits int
+	if found, err := kv.New(db, "test").Get("visits", &visits); err != nil || !found || visits != 3 {
+		t.Errorf("expected the store intact, got %d %v %v", visits, found, err)
+	}
+}
+
+func TestMaintenance_Run(t *testing.T) {
+	m := New()
+	m.Add("ok", func() (int, error) { return 3, nil })
+	m.Add("fails", func() (int, error) { return 0, errors.New("boom") })
+
+	reports := m.Run()
+	if len(reports) != 2 || reports[0].Removed != 3 || reports[1].Error == nil {
+		t.Errorf("unexpected reports %+v", reports)
+	}
+}
@@ -0,0 +1,135 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+
+	// registered for decoding only; conversions are written as jpeg or png
+	_ "image/gif"
+)
+
+// Fit modes of a conversion
+const (
+	// Contain scales the image to fit inside the box, keeping its aspect ratio
+	Contain = "contain"
+	// Crop fills the box and cuts the overflow from the center
+	Crop = "crop"
+)
+
+// Conversion is a derived image generated on upload; a zero Height keeps the aspect ratio
+type Conversion struct {
+	Name    string
+	Width   int
+	Height  int
+	Fit     string
+	Quality int
+}
+
+// Converted is a generated conversion as recorded with the media
+type Converted struct {
+	Name   string `json:"name"`
+	File   string `json:"file"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// convert renders src according to c and encodes it; images with transparency stay png
+func convert(src image.Image, c Conversion) ([]byte, int, int, string, error) {
+	b := src.Bounds()
+	w, h := c.Width, c.Height
+	crop := b
+
+	switch {
+	case h == 0:
+		h = b.Dy() * w / b.Dx()
+	case w == 0:
+		w = b.Dx() * h / b.Dy()
+	case c.Fit == Crop:
+		// cut the source to the aspect ratio of the box
+		if b.Dx()*h > b.Dy()*w {
+			cw := b.Dy() * w / h
+			crop = image.Rect(b.Min.X+(b.Dx()-cw)/2, b.Min.Y, b.Min.X+(b.Dx()-cw)/2+cw, b.Max.Y)
+		} else {
+			ch := b.Dx() * h / w
+			crop = image.Rect(b.Min.X, b.Min.Y+(b.Dy()-ch)/2, b.Max.X, b.Min.Y+(b.Dy()-ch)/2+ch)
+		}
+	default:
+		if b.Dx()*h > b.Dy()*w {
+			h = b.Dy() * w / b.Dx()
+		} else {
+			w = b.Dx() * h / b.Dy()
+		}
+	}
+
+	// conversions never upscale
+	if w > crop.Dx() || h > crop.Dy() {
+		w, h = crop.Dx(), crop.Dy()
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+
+	dst := resize(src, crop, w, h)
+
+	var buf bytes.Buffer
+	if opaque(src) {
+		q := c.Quality
+		if q == 0 {
+			q = 82
+		}
+		err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: q})
+		return buf.Bytes(), w, h, "jpg", err
+	}
+
+	err := png.Encode(&buf, dst)
+	return buf.Bytes(), w, h, "png", err
+}
+
+// resize scales the r part of src to w x h, averaging the source pixels under each
+// destination pixel so downscaled photos don't alias
+func resize(src image.Image, r image.Rectangle, w, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	for y := 0; y < h; y++ {
+		y0 := r.Min.Y + y*r.Dy()/h
+		y1 := r.Min.Y + (y+1)*r.Dy()/h
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+
+		for x := 0; x < w; x++ {
+			x0 := r.Min.X + x*r.Dx()/w
+			x1 := r.Min.X + (x+1)*r.Dx()/w
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+
+			var sr, sg, sb, sa, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					sr, sg, sb, sa = sr+uint64(cr), sg+uint64(cg), sb+uint64(cb), sa+uint64(ca)
+					n++
+				}
+			}
+
+			dst.SetRGBA64(x, y, color.RGBA64{R: uint16(sr / n), G: uint16(sg / n), B: uint16(sb / n), A: uint16(sa / n)})
+		}
+	}
+
+	return dst
+}
+
+func opaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+
+	return false
+}
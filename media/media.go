@@ -0,0 +1,409 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"image"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+	"github.com/namnguyen191/goravel/text"
+)
+
+// ErrTooLarge is returned for uploads over MaxSize
+var ErrTooLarge = errors.New("media: file too large")
+
+// ErrType is returned for uploads whose type is not allowed in the collection
+var ErrType = errors.New("media: file type not allowed")
+
+// Media is a file attached to a model by type and id, e.g. ("user", 3) in the "avatar" collection
+type Media struct {
+	ID          int
+	ModelType   string
+	ModelID     int
+	Collection  string
+	Name        string
+	File        string
+	Mime        string
+	Size        int64
+	Width       int
+	Height      int
+	Conversions []Converted
+	CreatedAt   time.Time
+}
+
+// IsImage reports whether the file is an image conversions can be made from
+func (m *Media) IsImage() bool {
+	return strings.HasPrefix(m.Mime, "image/") && m.Width > 0
+}
+
+// Collection configures the files kept under one collection name
+type Collection struct {
+	// Accept lists the allowed mime type prefixes, e.g. "image/"; empty accepts anything
+	Accept      []string
+	Conversions []Conversion
+	// Single collections keep only the latest file, e.g. an avatar
+	Single bool
+}
+
+// Library stores media files and their metadata in the media table
+type Library struct {
+	DB           *sql.DB
+	DatabaseType string
+	Store        Store
+	// MaxSize in bytes of an upload
+	MaxSize int64
+	// Clock tells when files are added; the time of the machine when nil
+	Clock clock.Clock
+
+	collections map[string]Collection
+}
+
+// New returns a library keeping files in store
+func New(db *sql.DB, dbType string, store Store) *Library {
+	return &Library{
+		DB:           db,
+		DatabaseType: dbType,
+		Store:        store,
+		MaxSize:      20 << 20,
+		collections:  map[string]Collection{},
+	}
+}
+
+// Define configures a collection, e.g. Define("gallery", Collection{Accept: []string{"image/"}, ...})
+func (l *Library) Define(name string, c Collection) {
+	l.collections[name] = c
+}
+
+func (l *Library) rebind(query string) string {
+	return database.Rebind(l.DatabaseType, query)
+}
+
+// Add stores a file for a model, records its metadata and generates the collection's conversions
+func (l *Library) Add(ctx context.Context, modelType string, modelID int, collection, filename string, r io.Reader) (*Media, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, l.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > l.MaxSize {
+		return nil, ErrTooLarge
+	}
+
+	c := l.collections[collection]
+
+	m := &Media{
+		ModelType:  modelType,
+		ModelID:    modelID,
+		Collection: collection,
+		Name:       strings.TrimSuffix(path.Base(filename), path.Ext(filename)),
+		Mime:       http.DetectContentType(data),
+		Size:       int64(len(data)),
+		CreatedAt:  clock.Now(l.Clock),
+	}
+
+	if !accepted(c.Accept, m.Mime) {
+		return nil, ErrType
+	}
+
+	ext := strings.ToLower(path.Ext(filename))
+	m.File = text.Slugify(m.Name) + ext
+	if m.File == ext {
+		m.File = "file" + ext
+	}
+
+	var img image.Image
+	if strings.HasPrefix(m.Mime, "image/") {
+		if decoded, _, err := image.Decode(bytes.NewReader(data)); err == nil {
+			img = decoded
+			m.Width, m.Height = decoded.Bounds().Dx(), decoded.Bounds().Dy()
+		}
+	}
+
+	if err := l.insert(ctx, m); err != nil {
+		return nil, err
+	}
+
+	if err := l.Store.Put(l.name(m, m.File), bytes.NewReader(data)); err != nil {
+		_ = l.deleteRow(ctx, m.ID)
+		return nil, err
+	}
+
+	if img != nil && len(c.Conversions) > 0 {
+		if err := l.convert(ctx, m, img, c.Conversions); err != nil {
+			_ = l.Delete(ctx, m.ID)
+			return nil, err
+		}
+	}
+
+	if c.Single {
+		if err := l.clearExcept(ctx, m); err != nil {
+			return m, err
+		}
+	}
+
+	return m, nil
+}
+
+// AddFromRequest stores the file uploaded in a multipart form field
+func (l *Library) AddFromRequest(r *http.Request, field, modelType string, modelID int, collection string) (*Media, error) {
+	f, header, err := r.FormFile(field)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return l.Add(r.Context(), modelType, modelID, collection, header.Filename, f)
+}
+
+func accepted(accept []string, mime string) bool {
+	if len(accept) == 0 {
+		return true
+	}
+
+	for _, a := range accept {
+		if strings.HasPrefix(mime, a) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// name is where a file of m lives in the store; each media gets its own directory
+func (l *Library) name(m *Media, file string) string {
+	return fmt.Sprintf("%d/%s", m.ID, file)
+}
+
+func (l *Library) convert(ctx context.Context, m *Media, img image.Image, conversions []Conversion) error {
+	for _, c := range conversions {
+		data, w, h, ext, err := convert(img, c)
+		if err != nil {
+			return err
+		}
+
+		file := fmt.Sprintf("conversions/%s-%s.%s", strings.TrimSuffix(m.File, path.Ext(m.File)), c.Name, ext)
+		if err := l.Store.Put(l.name(m, file), bytes.NewReader(data)); err != nil {
+			return err
+		}
+
+		m.Conversions = append(m.Conversions, Converted{Name: c.Name, File: file, Width: w, Height: h})
+	}
+
+	b, err := json.Marshal(m.Conversions)
+	if err != nil {
+		return err
+	}
+
+	_, err = l.DB.ExecContext(ctx, l.rebind("update media set conversions = ? where id = ?"), string(b), m.ID)
+
+	return err
+}
+
+func (l *Library) insert(ctx context.Context, m *Media) error {
+	query := `insert into media (model_type, model_id, collection, name, file, mime, size, width, height, conversions, created_at)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	args := []interface{}{m.ModelType, m.ModelID, m.Collection, m.Name, m.File, m.Mime, m.Size, m.Width, m.Height, "[]", m.CreatedAt}
+
+	if database.IsPostgres(l.DatabaseType) {
+		return l.DB.QueryRowContext(ctx, l.rebind(query+" returning id"), args...).Scan(&m.ID)
+	}
+
+	res, err := l.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	m.ID = int(id)
+
+	return err
+}
+
+const columns = "id, model_type, model_id, collection, name, file, mime, size, width, height, conversions, created_at"
+
+func scan(row interface{ Scan(...interface{}) error }) (*Media, error) {
+	var m Media
+	var conversions string
+
+	err := row.Scan(&m.ID, &m.ModelType, &m.ModelID, &m.Collection, &m.Name, &m.File, &m.Mime, &m.Size, &m.Width, &m.Height, &conversions, &m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(conversions), &m.Conversions); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// Find returns a media by id
+func (l *Library) Find(ctx context.Context, id int) (*Media, error) {
+	return scan(l.DB.QueryRowContext(ctx, l.rebind("select "+columns+" from media where id = ?"), id))
+}
+
+// For returns the media of a model in a collection, oldest first; an empty collection returns all
+func (l *Library) For(ctx context.Context, modelType string, modelID int, collection string) ([]*Media, error) {
+	query := "select " + columns + " from media where model_type = ? and model_id = ?"
+	args := []interface{}{modelType, modelID}
+	if collection != "" {
+		query += " and collection = ?"
+		args = append(args, collection)
+	}
+
+	rows, err := l.DB.QueryContext(ctx, l.rebind(query+" order by created_at, id"), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*Media
+	for rows.Next() {
+		m, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, m)
+	}
+
+	return list, rows.Err()
+}
+
+// First returns the latest media of a model in a collection, nil when there is none
+func (l *Library) First(ctx context.Context, modelType string, modelID int, collection string) (*Media, error) {
+	list, err := l.For(ctx, modelType, modelID, collection)
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+
+	return list[len(list)-1], nil
+}
+
+// Delete removes a media with its files
+func (l *Library) Delete(ctx context.Context, id int) error {
+	m, err := l.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range m.Conversions {
+		if err := l.Store.Delete(l.name(m, c.File)); err != nil {
+			return err
+		}
+	}
+	if err := l.Store.Delete(l.name(m, m.File)); err != nil {
+		return err
+	}
+
+	return l.deleteRow(ctx, id)
+}
+
+func (l *Library) deleteRow(ctx context.Context, id int) error {
+	_, err := l.DB.ExecContext(ctx, l.rebind("delete from media where id = ?"), id)
+	return err
+}
+
+// clearExcept deletes the other media of a single file collection
+func (l *Library) clearExcept(ctx context.Context, keep *Media) error {
+	list, err := l.For(ctx, keep.ModelType, keep.ModelID, keep.Collection)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range list {
+		if m.ID != keep.ID {
+			if err := l.Delete(ctx, m.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// URL returns the url of the original, or of a conversion when its name is given
+func (l *Library) URL(m *Media, conversion ...string) string {
+	if m == nil {
+		return ""
+	}
+
+	if len(conversion) > 0 && conversion[0] != "" {
+		for _, c := range m.Conversions {
+			if c.Name == conversion[0] {
+				return l.Store.URL(l.name(m, c.File))
+			}
+		}
+	}
+
+	return l.Store.URL(l.name(m, m.File))
+}
+
+// SrcSet lists the conversions and the original by width for the srcset attribute
+func (l *Library) SrcSet(m *Media) string {
+	if m == nil || !m.IsImage() {
+		return ""
+	}
+
+	type candidate struct {
+		url   string
+		width int
+	}
+
+	candidates := []candidate{{l.URL(m), m.Width}}
+	for _, c := range m.Conversions {
+		candidates = append(candidates, candidate{l.Store.URL(l.name(m, c.File)), c.Width})
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].width < candidates[j].width })
+
+	var parts []string
+	seen := map[int]bool{}
+	for _, c := range candidates {
+		if seen[c.width] {
+			continue
+		}
+		seen[c.width] = true
+		parts = append(parts, fmt.Sprintf("%s %dw", c.url, c.width))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// Image returns a responsive img tag; sizes defaults to the full viewport width
+func (l *Library) Image(m *Media, alt string, sizes ...string) template.HTML {
+	if m == nil {
+		return ""
+	}
+
+	size := "100vw"
+	if len(sizes) > 0 && sizes[0] != "" {
+		size = sizes[0]
+	}
+
+	esc := template.HTMLEscapeString
+	if !m.IsImage() {
+		return template.HTML(fmt.Sprintf(`<img src="%s" alt="%s" loading="lazy">`, esc(l.URL(m)), esc(alt)))
+	}
+
+	return template.HTML(fmt.Sprintf(`<img src="%s" srcset="%s" sizes="%s" width="%d" height="%d" alt="%s" loading="lazy">`,
+		esc(l.URL(m)), esc(l.SrcSet(m)), esc(size), m.Width, m.Height, esc(alt)))
+}
+
+// TemplateFuncs adds mediaURL, srcset and responsiveImage to templates; pass it to Render.AddFuncs
+func (l *Library) TemplateFuncs(r *http.Request) template.FuncMap {
+	return template.FuncMap{
+		"mediaURL":        l.URL,
+		"srcset":          l.SrcSet,
+		"responsiveImage": l.Image,
+	}
+}
@@ -0,0 +1,90 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func photo(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func TestConvert(t *testing.T) {
+	src := photo(400, 200)
+
+	tests := []struct {
+		c    Conversion
+		w, h int
+	}{
+		{Conversion{Width: 100}, 100, 50},
+		{Conversion{Width: 100, Height: 100}, 100, 50},
+		{Conversion{Width: 100, Height: 100, Fit: Crop}, 100, 100},
+		{Conversion{Width: 800}, 400, 200},
+	}
+
+	for _, tt := range tests {
+		data, w, h, ext, err := convert(src, tt.c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w != tt.w || h != tt.h || ext != "jpg" {
+			t.Errorf("%+v: expected %dx%d jpg, got %dx%d %s", tt.c, tt.w, tt.h, w, h, ext)
+		}
+
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil || img.Bounds().Dx() != tt.w {
+			t.Errorf("%+v: unexpected encoded image %v", tt.c, err)
+		}
+	}
+
+	if _, _, _, ext, _ := convert(image.NewNRGBA(image.Rect(0, 0, 10, 10)), Conversion{Width: 5}); ext != "png" {
+		t.Error("expected transparent images to stay png, got", ext)
+	}
+}
+
+func TestLocalAndHelpers(t *testing.T) {
+	store := &Local{Dir: t.TempDir(), BaseURL: "/public/media/"}
+
+	if err := store.Put("3/conversions/a.jpg", strings.NewReader("x")); err != nil {
+		t.Fatal(err)
+	}
+	f, err := store.Open("3/conversions/a.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadAll(f)
+	f.Close()
+	if string(b) != "x" {
+		t.Errorf("unexpected content %q", b)
+	}
+
+	if err := store.Put("../escape.txt", strings.NewReader("x")); err != ErrBadName {
+		t.Error("expected bad name, got", err)
+	}
+
+	l := New(nil, "postgres", store)
+	m := &Media{ID: 3, File: "a.jpg", Mime: "image/jpeg", Width: 1200, Height: 800, Conversions: []Converted{
+		{Name: "small", File: "conversions/a-small.jpg", Width: 320, Height: 213},
+	}}
+
+	if got := l.URL(m, "small"); got != "/public/media/3/conversions/a-small.jpg" {
+		t.Error("unexpected url", got)
+	}
+	if got := l.SrcSet(m); got != "/public/media/3/conversions/a-small.jpg 320w, /public/media/3/a.jpg 1200w" {
+		t.Error("unexpected srcset", got)
+	}
+	if got := string(l.Image(m, `A "cat"`, "50vw")); !strings.Contains(got, `sizes="50vw"`) || !strings.Contains(got, `alt="A &#34;cat&#34;"`) {
+		t.Error("unexpected img tag", got)
+	}
+}
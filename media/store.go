@@ -0,0 +1,88 @@
+package media
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrBadName is returned for stored names escaping the store root
+var ErrBadName = errors.New("media: invalid file name")
+
+// Store keeps media files under slash separated names such as "12/photo.jpg". Local is the
+// built-in implementation; any filesystem abstraction exposing the same methods can be used.
+type Store interface {
+	Put(name string, r io.Reader) error
+	Open(name string) (io.ReadCloser, error)
+	Delete(name string) error
+	// URL is where browsers fetch the file
+	URL(name string) string
+}
+
+// Local stores files in a directory served at BaseURL, e.g. public/media at /public/media
+type Local struct {
+	Dir     string
+	BaseURL string
+}
+
+func (l *Local) path(name string) (string, error) {
+	clean := path.Clean("/" + name)
+	if clean == "/" || strings.Contains(name, "..") {
+		return "", ErrBadName
+	}
+
+	return filepath.Join(l.Dir, filepath.FromSlash(clean)), nil
+}
+
+func (l *Local) Put(name string, r io.Reader) error {
+	p, err := l.path(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func (l *Local) Open(name string) (io.ReadCloser, error) {
+	p, err := l.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(p)
+}
+
+func (l *Local) Delete(name string) error {
+	p, err := l.path(name)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(p)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (l *Local) URL(name string) string {
+	return strings.TrimSuffix(l.BaseURL, "/") + "/" + strings.TrimPrefix(name, "/")
+}
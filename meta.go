@@ -0,0 +1,25 @@
+package goravel
+
+import (
+	"net/http"
+
+	"github.com/namnguyen191/goravel/meta"
+)
+
+// createMeta sets the defaults of the title, description and social cards of pages from
+// APP_NAME, APP_URL, META_DESCRIPTION, META_IMAGE and META_TWITTER
+func (grv *Goravel) createMeta() *meta.Meta {
+	return meta.New(meta.Defaults{
+		SiteName:    grv.Env.String("APP_NAME", ""),
+		BaseURL:     grv.Env.String("APP_URL", ""),
+		Description: grv.Env.String("META_DESCRIPTION", ""),
+		Image:       grv.Env.String("META_IMAGE", ""),
+		TwitterSite: grv.Env.String("META_TWITTER", ""),
+	})
+}
+
+// Meta returns the tags of the page rendered for r, for handlers to set, e.g.
+// grv.Meta(r).Title(product.Name).Image(product.Photo); views write them with metaTags
+func (grv *Goravel) Meta(r *http.Request) *meta.Tags {
+	return grv.meta.For(r)
+}
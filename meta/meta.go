@@ -0,0 +1,234 @@
+package meta
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Defaults apply to every page unless its handler sets something else
+type Defaults struct {
+	// SiteName is appended to titles, "Pricing | Shop", and sent as og:site_name
+	SiteName string
+	// BaseURL makes canonical and image urls absolute, e.g. https://example.com
+	BaseURL     string
+	Description string
+	Image       string
+	// TwitterSite is the @handle of the site
+	TwitterSite string
+}
+
+// Meta keeps the tags of the page being rendered for each request
+type Meta struct {
+	Defaults Defaults
+}
+
+type contextKey struct{}
+
+// New returns the tags of pages falling back to defaults
+func New(defaults Defaults) *Meta {
+	return &Meta{Defaults: defaults}
+}
+
+// Middleware gives every request its own tags, for handlers to fill with For
+func (m *Meta) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		t := &Tags{defaults: m.Defaults, path: r.URL.Path, props: map[string]string{}}
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), contextKey{}, t)))
+	})
+}
+
+// For returns the tags of the page rendered for r; without Middleware changes are discarded
+func (m *Meta) For(r *http.Request) *Tags {
+	if t, ok := r.Context().Value(contextKey{}).(*Tags); ok {
+		return t
+	}
+	return &Tags{defaults: m.Defaults, path: r.URL.Path, props: map[string]string{}}
+}
+
+// TemplateFuncs provides metaTags to views, writing the tags of the page in the head
+func (m *Meta) TemplateFuncs(r *http.Request) template.FuncMap {
+	return template.FuncMap{
+		"metaTags": func() template.HTML { return m.For(r).HTML() },
+	}
+}
+
+// Tags are the title, description and social cards of a page. The setters return the tags so
+// they chain: grv.Meta(r).Title("Pricing").Image("/public/og/pricing.png")
+type Tags struct {
+	defaults Defaults
+	path     string
+
+	mu          sync.Mutex
+	title       string
+	description string
+	canonical   string
+	image       string
+	kind        string
+	robots      string
+	card        string
+	props       map[string]string
+}
+
+// Title sets the title of the page, followed by the site name
+func (t *Tags) Title(title string) *Tags {
+	t.mu.Lock()
+	t.title = title
+	t.mu.Unlock()
+	return t
+}
+
+func (t *Tags) Description(description string) *Tags {
+	t.mu.Lock()
+	t.description = description
+	t.mu.Unlock()
+	return t
+}
+
+// Canonical sets the preferred url of the page; by default it is the path without the query
+func (t *Tags) Canonical(url string) *Tags {
+	t.mu.Lock()
+	t.canonical = url
+	t.mu.Unlock()
+	return t
+}
+
+// Image sets the picture of the social cards
+func (t *Tags) Image(url string) *Tags {
+	t.mu.Lock()
+	t.image = url
+	t.mu.Unlock()
+	return t
+}
+
+// Type sets og:type, "website" by default, e.g. "article" or "product"
+func (t *Tags) Type(kind string) *Tags {
+	t.mu.Lock()
+	t.kind = kind
+	t.mu.Unlock()
+	return t
+}
+
+// Robots sets the robots directives, e.g. "noindex, nofollow"
+func (t *Tags) Robots(directives string) *Tags {
+	t.mu.Lock()
+	t.robots = directives
+	t.mu.Unlock()
+	return t
+}
+
+// Card sets the twitter card, "summary_large_image" when there is an image and "summary" otherwise
+func (t *Tags) Card(card string) *Tags {
+	t.mu.Lock()
+	t.card = card
+	t.mu.Unlock()
+	return t
+}
+
+// Set adds any other property, e.g. Set("article:author", "Nam")
+func (t *Tags) Set(property, content string) *Tags {
+	t.mu.Lock()
+	t.props[property] = content
+	t.mu.Unlock()
+	return t
+}
+
+// FullTitle is the title with the site name, or the site name alone
+func (t *Tags) FullTitle() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.fullTitle()
+}
+
+func (t *Tags) fullTitle() string {
+	switch {
+	case t.title == "":
+		return t.defaults.SiteName
+	case t.defaults.SiteName == "" || t.title == t.defaults.SiteName:
+		return t.title
+	}
+	return t.title + " | " + t.defaults.SiteName
+}
+
+func (t *Tags) absolute(url string) string {
+	if url == "" || strings.Contains(url, "://") || strings.HasPrefix(url, "//") {
+		return url
+	}
+	return strings.TrimSuffix(t.defaults.BaseURL, "/") + "/" + strings.TrimPrefix(url, "/")
+}
+
+// HTML returns the tags to write in the head of the page
+func (t *Tags) HTML() template.HTML {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	title := t.fullTitle()
+	description := first(t.description, t.defaults.Description)
+	image := t.absolute(first(t.image, t.defaults.Image))
+	canonical := t.absolute(first(t.canonical, t.path))
+
+	card := t.card
+	if card == "" {
+		card = "summary"
+		if image != "" {
+			card = "summary_large_image"
+		}
+	}
+
+	var b strings.Builder
+	if title != "" {
+		b.WriteString("<title>" + template.HTMLEscapeString(title) + "</title>\n")
+	}
+	tag := func(attr, key, value string) {
+		if value != "" {
+			b.WriteString(`<meta ` + attr + `="` + key + `" content="` + template.HTMLEscapeString(value) + "\">\n")
+		}
+	}
+
+	tag("name", "description", description)
+	tag("name", "robots", t.robots)
+	if canonical != "" {
+		b.WriteString(`<link rel="canonical" href="` + template.HTMLEscapeString(canonical) + "\">\n")
+	}
+
+	tag("property", "og:title", title)
+	tag("property", "og:description", description)
+	tag("property", "og:type", first(t.kind, "website"))
+	tag("property", "og:url", canonical)
+	tag("property", "og:image", image)
+	tag("property", "og:site_name", t.defaults.SiteName)
+
+	tag("name", "twitter:card", card)
+	tag("name", "twitter:site", t.defaults.TwitterSite)
+	tag("name", "twitter:title", title)
+	tag("name", "twitter:description", description)
+	tag("name", "twitter:image", image)
+
+	for _, key := range sortedKeys(t.props) {
+		tag("property", key, t.props[key])
+	}
+
+	return template.HTML(b.String())
+}
+
+func first(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
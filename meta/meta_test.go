@@ -0,0 +1,50 @@
+package meta
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	m := New(Defaults{SiteName: "Shop", BaseURL: "https://example.com/", Description: "Things to buy", TwitterSite: "@shop"})
+
+	var html string
+	handler := m.Middleware(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		m.For(r).Title(`Tea & "cups"`).Image("/public/og/tea.png").Type("product").Set("product:price:amount", "12")
+		html = string(m.TemplateFuncs(r)["metaTags"].(func() template.HTML)())
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/tea?ref=mail", nil))
+
+	for _, want := range []string{
+		"<title>Tea &amp; &#34;cups&#34; | Shop</title>",
+		`<meta name="description" content="Things to buy">`,
+		`<link rel="canonical" href="https://example.com/tea">`,
+		`<meta property="og:type" content="product">`,
+		`<meta property="og:image" content="https://example.com/public/og/tea.png">`,
+		`<meta name="twitter:card" content="summary_large_image">`,
+		`<meta name="twitter:site" content="@shop">`,
+		`<meta property="product:price:amount" content="12">`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %s in\n%s", want, html)
+		}
+	}
+	if strings.Contains(html, "robots") {
+		t.Error("expected no robots tag by default")
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	m := New(Defaults{SiteName: "Shop"})
+	tags := m.For(httptest.NewRequest("GET", "/", nil))
+
+	if tags.FullTitle() != "Shop" {
+		t.Errorf("expected the site name, got %q", tags.FullTitle())
+	}
+	if html := string(tags.HTML()); !strings.Contains(html, `content="summary"`) || strings.Contains(html, "og:image") {
+		t.Errorf("unexpected tags without an image\n%s", html)
+	}
+}
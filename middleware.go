@@ -3,19 +3,70 @@ package goravel
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/justinas/nosurf"
+	"github.com/namnguyen191/goravel/bots"
+	"github.com/namnguyen191/goravel/session"
 )
 
 func (grv *Goravel) SessionLoad(next http.Handler) http.Handler {
-	return grv.Session.LoadAndSave(next)
+	// WithCookies lets the redis store fall back to sessions kept in cookies
+	withSession := session.WithCookies(grv.Session.LoadAndSave(next))
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if grv.BotGuard == nil || !bots.IsBot(r) {
+			withSession.ServeHTTP(rw, r)
+			return
+		}
+
+		// bots get a throwaway session that is never written to the store
+		ctx, err := grv.Session.Load(r.Context(), "")
+		if err != nil {
+			grv.Error500(rw, r)
+			return
+		}
+
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// overridable are the methods a form can ask for in its _method field
+var overridable = map[string]bool{"PUT": true, "PATCH": true, "DELETE": true}
+
+// MethodOverride routes a POST as the PUT, PATCH or DELETE of its _method field, which
+// Form.Open adds since browsers only send forms as GET and POST. Other methods, and values of
+// _method outside those three, are left alone.
+func (grv *Goravel) MethodOverride(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if m := strings.ToUpper(r.PostFormValue("_method")); overridable[m] {
+				r.Method = m
+			}
+		}
+
+		next.ServeHTTP(rw, r)
+	})
 }
 
+// NoSurf rejects requests other than GET, HEAD, OPTIONS and TRACE which do not carry the CSRF
+// token of their cookie, in the csrf_token field or the X-CSRF-Token header. /api/* and the
+// globs of CSRF_EXEMPT, e.g. /webhooks/*, are left out; CSRF=false turns it off.
 func (grv *Goravel) NoSurf(next http.Handler) http.Handler {
+	if !grv.Env.Bool("CSRF", true) {
+		return next
+	}
+
 	csrfHandler := nosurf.New(next)
 	secure, _ := strconv.ParseBool(grv.config.cookie.secure)
 
 	csrfHandler.ExemptGlob("/api/*")
+	for _, glob := range strings.Split(grv.Env.String("CSRF_EXEMPT", ""), ",") {
+		if glob = strings.TrimSpace(glob); glob != "" {
+			csrfHandler.ExemptGlob(glob)
+		}
+	}
+	csrfHandler.SetFailureHandler(http.HandlerFunc(grv.csrfFailed))
 
 	csrfHandler.SetBaseCookie(http.Cookie{
 		HttpOnly: true,
@@ -27,3 +78,10 @@ func (grv *Goravel) NoSurf(next http.Handler) http.Handler {
 
 	return csrfHandler
 }
+
+// csrfFailed sends the 403 page, telling the visitor to reload the form rather than what
+// was wrong with the token
+func (grv *Goravel) csrfFailed(rw http.ResponseWriter, r *http.Request) {
+	grv.InfoLog.Println("csrf:", r.Method, r.URL.Path, nosurf.Reason(r))
+	grv.errorPage(rw, r, http.StatusForbidden, "The form has expired. Reload the page and try again.", nil)
+}
@@ -0,0 +1,51 @@
+package goravel
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestMethodOverride(t *testing.T) {
+	mux := chi.NewRouter()
+	mux.Use(testApp(&bytes.Buffer{}).MethodOverride)
+	for _, method := range []string{"POST", "PUT", "PATCH", "DELETE"} {
+		method := method
+		mux.MethodFunc(method, "/users/1", func(rw http.ResponseWriter, r *http.Request) {
+			rw.Write([]byte(method + " " + r.PostFormValue("name")))
+		})
+	}
+
+	tests := []struct {
+		name, method, override, routed string
+	}{
+		{"put", "POST", "put", "PUT ann"},
+		{"patch", "POST", "PATCH", "PATCH ann"},
+		{"delete", "POST", "delete", "DELETE ann"},
+		{"not allowed", "POST", "GET", "POST ann"},
+		{"unknown", "POST", "TRACE", "POST ann"},
+		{"without _method", "POST", "", "POST ann"},
+		{"not a post", "PATCH", "DELETE", "PATCH ann"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"name": {"ann"}}
+			if tt.override != "" {
+				form.Set("_method", tt.override)
+			}
+			r := httptest.NewRequest(tt.method, "/users/1", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rw := httptest.NewRecorder()
+			mux.ServeHTTP(rw, r)
+
+			if rw.Body.String() != tt.routed {
+				t.Errorf("expected %q, got %d %q", tt.routed, rw.Code, rw.Body)
+			}
+		})
+	}
+}
@@ -1,72 +1,75 @@
 package goravel
 
 import (
-	"log"
+	"context"
+	"errors"
+	"os"
 
-	_ "github.com/go-sql-driver/mysql"
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/mysql"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/namnguyen191/goravel/migrations"
 )
 
-func (grv *Goravel) MigrateUp(dsn string) error {
-	m, err := migrate.New("file://migrations", dsn)
-	if err != nil {
-		return err
+// Migrator runs the files of the migrations folder on the database of the app. Files named
+// <version>_<name>.<driver>.up.sql only run on that driver, so one app can ship the sql of
+// both postgres and mysql.
+func (grv *Goravel) Migrator() (*migrations.Migrator, error) {
+	if grv.DB.Pool == nil {
+		return nil, errors.New("migrations: no database, set DATABASE_TYPE")
 	}
 
-	defer m.Close()
-
-	if err := m.Up(); err != nil {
-		log.Println("Error running migration: ", err)
-		return err
+	m := migrations.New(grv.DB.Pool, grv.DB.DataBaseType, os.DirFS(grv.RootPath+"/migrations"))
+	if grv.InfoLog != nil {
+		m.Log = grv.InfoLog.Println
 	}
 
-	return nil
+	return m, nil
 }
 
-func (grv *Goravel) MigrateDownAll(dsn string) error {
-	m, err := migrate.New("file://migrations", dsn)
+// MigrateUp applies every pending migration
+func (grv *Goravel) MigrateUp() error {
+	m, err := grv.Migrator()
 	if err != nil {
 		return err
 	}
 
-	defer m.Close()
-
-	if err = m.Down(); err != nil {
-		return err
-	}
-
-	return nil
+	return m.Up(context.Background())
 }
 
-func (grv *Goravel) Steps(n int, dsn string) error {
-	m, err := migrate.New("file://migrations", dsn)
+// MigrateDown rolls back the last migration
+func (grv *Goravel) MigrateDown() error {
+	m, err := grv.Migrator()
 	if err != nil {
 		return err
 	}
 
-	defer m.Close()
+	return m.Down(context.Background())
+}
 
-	if err = m.Steps(n); err != nil {
+// MigrateTo migrates up or down to version
+func (grv *Goravel) MigrateTo(version int64) error {
+	m, err := grv.Migrator()
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return m.To(context.Background(), version)
 }
 
-func (grv *Goravel) MigrateForce(dsn string) error {
-	m, err := migrate.New("file://migrations", dsn)
+// MigrateReset rolls back every migration
+func (grv *Goravel) MigrateReset() error {
+	m, err := grv.Migrator()
 	if err != nil {
 		return err
 	}
 
-	defer m.Close()
+	return m.Reset(context.Background())
+}
 
-	if err = m.Force(-1); err != nil {
+// MigrateForce marks version as applied, after fixing a migration which failed half way
+func (grv *Goravel) MigrateForce(version int64) error {
+	m, err := grv.Migrator()
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return m.Force(context.Background(), version)
 }
@@ -0,0 +1,367 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/namnguyen191/goravel/database"
+)
+
+// ErrDirty is returned when a migration failed half way; the schema has to be fixed by hand,
+// then marked with Force
+var ErrDirty = errors.New("migrations: database is dirty")
+
+// Migration is one version, with the sql of the driver it runs on
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+	// HasDown is false when the version has no down file and cannot be rolled back
+	HasDown bool
+}
+
+// files are named <version>_<name>.up.sql and <version>_<name>.down.sql, for every driver, or
+// <version>_<name>.<driver>.up.sql for one driver only, as created by make migration
+var file = regexp.MustCompile(`^(\d+)_([^.]+)(?:\.(\w+))?\.(up|down)\.sql$`)
+
+// Family names the sql dialect of a database type: postgres or mysql
+func Family(dbType string) string {
+	switch strings.ToLower(dbType) {
+	case "mysql", "mariadb":
+		return "mysql"
+	}
+	return database.Dialect(dbType)
+}
+
+// Load reads the migrations of fsys for a database type, sorted by version. A file for the
+// driver wins over the file for every driver of the same version.
+func Load(fsys fs.FS, dbType string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	family := Family(dbType)
+	byVersion := map[int64]*Migration{}
+	specific := map[string]bool{}
+
+	for _, e := range entries {
+		m := file.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil {
+			continue
+		}
+		if m[3] != "" && Family(m[3]) != family {
+			continue
+		}
+
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migrations: %s: %w", e.Name(), err)
+		}
+
+		// a generic file never replaces the one written for the driver
+		slot := m[1] + "." + m[4]
+		if m[3] == "" && specific[slot] {
+			continue
+		}
+		if m[3] != "" {
+			specific[slot] = true
+		}
+
+		body, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mig := byVersion[version]
+		if mig == nil {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migrations: version %d is used by %s and %s", version, mig.Name, m[2])
+		}
+
+		if m[4] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+			mig.HasDown = true
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		list = append(list, *mig)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+
+	return list, nil
+}
+
+// Migrator applies migrations to a database, keeping the current version in Table in the
+// same layout as golang-migrate, so databases migrated before keep their state
+type Migrator struct {
+	DB     *sql.DB
+	DBType string
+	Files  fs.FS
+	Table  string
+	// Log reports each migration as it runs
+	Log func(v ...interface{})
+}
+
+// New returns a migrator running the files of dir on db
+func New(db *sql.DB, dbType string, files fs.FS) *Migrator {
+	return &Migrator{
+		DB:     db,
+		DBType: dbType,
+		Files:  files,
+		Table:  "schema_migrations",
+		Log:    func(v ...interface{}) {},
+	}
+}
+
+// Up applies every migration newer than the current version
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.migrate(ctx, func(list []Migration, current int64) (int64, error) {
+		if len(list) == 0 || list[len(list)-1].Version < current {
+			return current, nil
+		}
+		return list[len(list)-1].Version, nil
+	})
+}
+
+// Down rolls back the current version
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.migrate(ctx, func(list []Migration, current int64) (int64, error) {
+		return previous(list, current), nil
+	})
+}
+
+// To migrates up or down to version; 0 rolls everything back
+func (m *Migrator) To(ctx context.Context, version int64) error {
+	return m.migrate(ctx, func(list []Migration, current int64) (int64, error) {
+		if version == 0 {
+			return 0, nil
+		}
+		for _, mig := range list {
+			if mig.Version == version {
+				return version, nil
+			}
+		}
+		return 0, fmt.Errorf("migrations: no migration %d", version)
+	})
+}
+
+// Reset rolls back every migration
+func (m *Migrator) Reset(ctx context.Context) error {
+	return m.To(ctx, 0)
+}
+
+// Version returns the current version, 0 when nothing was applied
+func (m *Migrator) Version(ctx context.Context) (version int64, dirty bool, err error) {
+	if err := m.ensureTable(ctx, m.DB); err != nil {
+		return 0, false, err
+	}
+	return m.version(ctx, m.DB)
+}
+
+// Force records version as applied and clean, once a failed migration was fixed by hand
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	if err := m.ensureTable(ctx, m.DB); err != nil {
+		return err
+	}
+	return m.setVersion(ctx, m.DB, version, false)
+}
+
+// Pending returns the migrations newer than the current version
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	list, err := Load(m.Files, m.DBType)
+	if err != nil {
+		return nil, err
+	}
+	current, _, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, mig := range list {
+		if mig.Version > current {
+			pending = append(pending, mig)
+		}
+	}
+	return pending, nil
+}
+
+// execer is a *sql.Conn or *sql.DB
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// migrate runs the migrations between the current version and the one target picks, holding
+// a lock so instances booting together do not migrate twice
+func (m *Migrator) migrate(ctx context.Context, target func(list []Migration, current int64) (int64, error)) error {
+	list, err := Load(m.Files, m.DBType)
+	if err != nil {
+		return err
+	}
+
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	unlock, err := m.lock(ctx, conn)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+
+	current, dirty, err := m.version(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w at version %d", ErrDirty, current)
+	}
+
+	to, err := target(list, current)
+	if err != nil {
+		return err
+	}
+
+	for _, step := range plan(list, current, to) {
+		if err := m.run(ctx, conn, step, list); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// step applies the up or down sql of a migration
+type step struct {
+	Migration
+	up bool
+}
+
+// plan lists the steps from current to target: ups in order, or downs in reverse
+func plan(list []Migration, current, target int64) []step {
+	var steps []step
+	if target >= current {
+		for _, mig := range list {
+			if mig.Version > current && mig.Version <= target {
+				steps = append(steps, step{mig, true})
+			}
+		}
+		return steps
+	}
+
+	for i := len(list) - 1; i >= 0; i-- {
+		if mig := list[i]; mig.Version <= current && mig.Version > target {
+			steps = append(steps, step{mig, false})
+		}
+	}
+	return steps
+}
+
+// previous returns the version applied before version, 0 for the first one
+func previous(list []Migration, version int64) int64 {
+	prev := int64(0)
+	for _, mig := range list {
+		if mig.Version >= version {
+			break
+		}
+		prev = mig.Version
+	}
+	return prev
+}
+
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, s step, list []Migration) error {
+	query, after, direction := s.Up, s.Version, "up"
+	if !s.up {
+		if !s.HasDown {
+			return fmt.Errorf("migrations: %d_%s has no down migration", s.Version, s.Name)
+		}
+		query, after, direction = s.Down, previous(list, s.Version), "down"
+	}
+
+	m.Log(fmt.Sprintf("migrating %s %d_%s", direction, s.Version, s.Name))
+
+	// dirty until the sql ran, since mysql commits schema changes as it goes
+	if err := m.setVersion(ctx, conn, s.Version, true); err != nil {
+		return err
+	}
+	if strings.TrimSpace(query) != "" {
+		if _, err := conn.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("migrations: %d_%s %s: %w", s.Version, s.Name, direction, err)
+		}
+	}
+	return m.setVersion(ctx, conn, after, false)
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, db execer) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(
+		"create table if not exists %s (version bigint not null primary key, dirty boolean not null)", m.Table))
+	return err
+}
+
+func (m *Migrator) version(ctx context.Context, db execer) (int64, bool, error) {
+	var version int64
+	var dirty bool
+	err := db.QueryRowContext(ctx, fmt.Sprintf("select version, dirty from %s limit 1", m.Table)).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// setVersion keeps a single row, as golang-migrate does; version 0 leaves the table empty
+func (m *Migrator) setVersion(ctx context.Context, db execer, version int64, dirty bool) error {
+	if _, err := db.ExecContext(ctx, "delete from "+m.Table); err != nil {
+		return err
+	}
+	if version == 0 && !dirty {
+		return nil
+	}
+	_, err := db.ExecContext(ctx, database.Rebind(m.DBType, fmt.Sprintf("insert into %s (version, dirty) values (?, ?)", m.Table)), version, dirty)
+	return err
+}
+
+// lock takes a database wide lock on conn, released by the returned function
+func (m *Migrator) lock(ctx context.Context, conn *sql.Conn) (func(), error) {
+	id := int64(crc32.ChecksumIEEE([]byte(m.Table)))
+
+	switch Family(m.DBType) {
+	case "postgres":
+		if _, err := conn.ExecContext(ctx, "select pg_advisory_lock($1)", id); err != nil {
+			return nil, err
+		}
+		return func() { _, _ = conn.ExecContext(context.Background(), "select pg_advisory_unlock($1)", id) }, nil
+	case "mysql":
+		name := fmt.Sprintf("migrations-%d", id)
+		var ok sql.NullInt64
+		if err := conn.QueryRowContext(ctx, "select get_lock(?, 60)", name).Scan(&ok); err != nil {
+			return nil, err
+		}
+		if ok.Int64 != 1 {
+			return nil, errors.New("migrations: timed out waiting for the lock")
+		}
+		return func() { _, _ = conn.ExecContext(context.Background(), "select release_lock(?)", name) }, nil
+	}
+	return func() {}, nil
+}
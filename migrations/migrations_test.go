@@ -0,0 +1,59 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+var files = fstest.MapFS{
+	"1_users.up.sql":              {Data: []byte("create table users")},
+	"1_users.down.sql":            {Data: []byte("drop table users")},
+	"2_search.postgres.up.sql":    {Data: []byte("create index using gin")},
+	"2_search.postgres.down.sql":  {Data: []byte("drop index")},
+	"2_search.mysql.up.sql":       {Data: []byte("create fulltext index")},
+	"3_sessions.up.sql":           {Data: []byte("create table sessions")},
+	"3_sessions.mariadb.up.sql":   {Data: []byte("create table sessions engine=innodb")},
+	"3_sessions.mariadb.down.sql": {Data: []byte("drop table sessions")},
+	"README.md":                   {Data: []byte("not a migration")},
+}
+
+func TestLoad(t *testing.T) {
+	pg, err := Load(files, "pgx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pg) != 3 || pg[1].Up != "create index using gin" || pg[2].Up != "create table sessions" || pg[2].HasDown {
+		t.Errorf("unexpected postgres migrations %+v", pg)
+	}
+
+	my, err := Load(files, "mariadb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if my[1].Up != "create fulltext index" || my[1].HasDown || my[2].Up != "create table sessions engine=innodb" {
+		t.Errorf("unexpected mysql migrations %+v", my)
+	}
+
+	clash := fstest.MapFS{"1_a.up.sql": {}, "1_b.up.sql": {}}
+	if _, err := Load(clash, "postgres"); err == nil {
+		t.Error("expected an error for a version used twice")
+	}
+}
+
+func TestPlan(t *testing.T) {
+	list, _ := Load(files, "postgres")
+
+	up := plan(list, 1, 3)
+	if len(up) != 2 || !up[0].up || up[0].Version != 2 || up[1].Version != 3 {
+		t.Errorf("unexpected up plan %+v", up)
+	}
+
+	down := plan(list, 3, 0)
+	if len(down) != 3 || down[0].up || down[0].Version != 3 || down[2].Version != 1 {
+		t.Errorf("unexpected down plan %+v", down)
+	}
+
+	if previous(list, 3) != 2 || previous(list, 1) != 0 {
+		t.Error("unexpected previous versions")
+	}
+}
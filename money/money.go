@@ -0,0 +1,213 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrCurrencyMismatch is returned when amounts in different currencies are combined
+var ErrCurrencyMismatch = errors.New("money: currency mismatch")
+
+// zeroDecimal lists the currencies without minor units
+var zeroDecimal = map[string]bool{
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "ISK": true, "JPY": true, "KMF": true, "KRW": true,
+	"PYG": true, "RWF": true, "UGX": true, "VND": true, "VUV": true, "XAF": true, "XOF": true, "XPF": true,
+}
+
+var symbols = map[string]string{
+	"USD": "$", "CAD": "CA$", "AUD": "A$", "EUR": "€", "GBP": "£", "JPY": "¥", "INR": "₹", "VND": "₫", "KRW": "₩",
+}
+
+// Money is an amount in the minor unit of its currency (cents for USD), so sums never drift
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// New returns amount minor units of currency
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// Parse reads a decimal string such as "12.50" as an amount of currency
+func Parse(s, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+	}
+
+	digits := Decimals(currency)
+	if len(frac) > digits {
+		return Money{}, fmt.Errorf("money: %q has more than %d decimals", s, digits)
+	}
+	frac += strings.Repeat("0", digits-len(frac))
+
+	if whole == "" {
+		whole = "0"
+	}
+	amount, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("money: invalid amount %q", s)
+	}
+
+	if negative {
+		amount = -amount
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// Decimals returns the number of minor unit digits of currency
+func Decimals(currency string) int {
+	if zeroDecimal[strings.ToUpper(currency)] {
+		return 0
+	}
+
+	return 2
+}
+
+// Add returns m + o
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+// Sub returns m - o
+func (m Money) Sub(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+
+	return Money{Amount: m.Amount - o.Amount, Currency: m.Currency}, nil
+}
+
+// Mul returns m multiplied by n, rounded half away from zero to the minor unit
+func (m Money) Mul(n float64) Money {
+	return Money{Amount: int64(math.Round(float64(m.Amount) * n)), Currency: m.Currency}
+}
+
+// Percent returns rate percent of m, e.g. Percent(8.25) for a sales tax
+func (m Money) Percent(rate float64) Money {
+	return m.Mul(rate / 100)
+}
+
+// Allocate splits m by the given ratios without losing a minor unit; the remainder goes
+// to the first parts
+func (m Money) Allocate(ratios ...int) []Money {
+	total := 0
+	for _, r := range ratios {
+		total += r
+	}
+
+	parts := make([]Money, len(ratios))
+	if total == 0 {
+		return parts
+	}
+
+	left := m.Amount
+	for i, r := range ratios {
+		parts[i] = Money{Amount: m.Amount * int64(r) / int64(total), Currency: m.Currency}
+		left -= parts[i].Amount
+	}
+
+	for i := 0; left != 0; i = (i + 1) % len(parts) {
+		step := int64(1)
+		if left < 0 {
+			step = -1
+		}
+		parts[i].Amount += step
+		left -= step
+	}
+
+	return parts
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Decimal formats the amount without a currency, e.g. "1234.50"
+func (m Money) Decimal() string {
+	digits := Decimals(m.Currency)
+
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+
+	if digits == 0 {
+		return sign + strconv.FormatInt(amount, 10)
+	}
+
+	unit := int64(math.Pow10(digits))
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/unit, digits, amount%unit)
+}
+
+// String formats the amount for display, e.g. "$1,234.50" or "1,234.50 CHF"
+func (m Money) String() string {
+	s := m.Decimal()
+
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i:]
+	}
+
+	var b strings.Builder
+	for i, c := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+
+	if symbol, ok := symbols[m.Currency]; ok {
+		return sign + symbol + b.String() + frac
+	}
+
+	return sign + b.String() + frac + " " + m.Currency
+}
+
+// Value stores the amount as its minor units, the currency lives in its own column
+func (m Money) Value() (driver.Value, error) {
+	return m.Amount, nil
+}
+
+// Scan reads minor units stored by Value; the currency must be set separately
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case int64:
+		m.Amount = v
+	case []byte:
+		n, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return err
+		}
+		m.Amount = n
+	case nil:
+		m.Amount = 0
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package money
+
+import "testing"
+
+func TestParseAndFormat(t *testing.T) {
+	m, err := Parse("1234.5", "usd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Amount != 123450 || m.String() != "$1,234.50" {
+		t.Errorf("unexpected %d %s", m.Amount, m)
+	}
+
+	if s := New(-1500, "JPY").String(); s != "-¥1,500" {
+		t.Error("unexpected yen format", s)
+	}
+	if s := New(99, "CHF").String(); s != "0.99 CHF" {
+		t.Error("unexpected franc format", s)
+	}
+	if _, err := Parse("1.234", "USD"); err == nil {
+		t.Error("expected too many decimals")
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	if tax := New(1999, "USD").Percent(8.25); tax.Amount != 165 {
+		t.Error("expected tax of 165, got", tax.Amount)
+	}
+
+	if _, err := New(1, "USD").Add(New(1, "EUR")); err != ErrCurrencyMismatch {
+		t.Error("expected currency mismatch")
+	}
+
+	parts := New(100, "USD").Allocate(1, 1, 1)
+	if parts[0].Amount != 34 || parts[1].Amount != 33 || parts[2].Amount != 33 {
+		t.Error("unexpected allocation", parts)
+	}
+}
@@ -0,0 +1,81 @@
+package goravel
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/namnguyen191/goravel/concurrency"
+	"github.com/namnguyen191/goravel/monitor"
+)
+
+// startMonitor creates the uptime monitor with the checks and notifiers configured in .env;
+// apps can add their own checks to grv.Monitor. Checks only run when MONITOR_SCHEDULE is set.
+func (grv *Goravel) startMonitor() error {
+	grv.Monitor = monitor.New()
+	grv.Monitor.ErrorLog = func(err error) { grv.ErrorLog.Println("monitor:", err) }
+
+	if hook := os.Getenv("MONITOR_SLACK_WEBHOOK"); hook != "" {
+		grv.Monitor.Notifiers = append(grv.Monitor.Notifiers, &monitor.SlackNotifier{WebhookURL: hook, Client: grv.HTTPClient("slack")})
+	}
+
+	if to := os.Getenv("MONITOR_MAIL_TO"); to != "" {
+		grv.Monitor.Notifiers = append(grv.Monitor.Notifiers, &monitor.MailNotifier{Mail: &grv.Mail, To: to, Template: "monitor"})
+	}
+
+	// every url has its own breaker, so one site which is down neither fails the checks of
+	// the others nor the alerts sent about it
+	certDays := grv.Env.Int("MONITOR_CERT_DAYS", 14)
+	for _, u := range strings.Split(os.Getenv("MONITOR_URLS"), ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			client := grv.HTTPClient("monitor " + u)
+			client.Timeout = 10 * time.Second
+			grv.Monitor.Add(&monitor.HTTPCheck{URL: u, CertWarning: time.Duration(certDays) * 24 * time.Hour, Client: client})
+		}
+	}
+
+	if grv.DB.Pool != nil {
+		grv.Monitor.Add(&monitor.FuncCheck{CheckName: "database", Fn: grv.DB.Pool.Ping})
+	}
+
+	if redisPool != nil {
+		grv.Monitor.Add(&monitor.FuncCheck{CheckName: "redis", Fn: func() error {
+			conn := redisPool.Get()
+			defer conn.Close()
+			_, err := conn.Do("PING")
+			return err
+		}})
+	}
+
+	schedule := os.Getenv("MONITOR_SCHEDULE")
+	if schedule == "" {
+		return nil
+	}
+
+	_, err := grv.Scheduler.AddFunc(schedule, grv.Monitor.Run)
+
+	return err
+}
+
+// mailQuotaAlert reports a provider nearing or reaching its daily mail quota
+func (grv *Goravel) mailQuotaAlert(sent, limit int) {
+	msg := fmt.Sprintf("%d of %d messages sent today through %s", sent, limit, grv.Mail.Transport())
+	if sent >= limit {
+		msg += "; further messages are deferred until tomorrow (UTC)"
+	}
+	grv.ErrorLog.Println("mail quota:", msg)
+
+	if grv.Monitor != nil {
+		grv.Monitor.Notify(monitor.Alert{Check: "mail quota", Healthy: sent < limit, Message: msg})
+	}
+}
+
+// reportPanic logs a panic recovered by grv.Concurrency and alerts the monitor's notifiers
+func (grv *Goravel) reportPanic(p *concurrency.PanicError) {
+	grv.ErrorLog.Println(p, "\n"+string(p.Stack))
+
+	if grv.Monitor != nil {
+		grv.Monitor.Notify(monitor.Alert{Check: "panic", Healthy: false, Message: p.Error()})
+	}
+}
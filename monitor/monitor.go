@@ -0,0 +1,235 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/namnguyen191/goravel/mailer"
+)
+
+// Check probes a dependency; a nil error means it is healthy
+type Check interface {
+	Name() string
+	Run() error
+}
+
+// Alert is sent when a check changes state or a certificate is about to expire
+type Alert struct {
+	Check   string
+	Healthy bool
+	Message string
+	Time    time.Time
+}
+
+// Notifier delivers alerts
+type Notifier interface {
+	Notify(a Alert) error
+}
+
+// Monitor runs the registered checks and notifies when one goes down or recovers
+type Monitor struct {
+	Notifiers []Notifier
+	// ErrorLog receives notification delivery errors
+	ErrorLog func(err error)
+
+	mu     sync.Mutex
+	checks []Check
+	status map[string]error
+}
+
+// New returns a monitor without checks
+func New(notifiers ...Notifier) *Monitor {
+	return &Monitor{
+		Notifiers: notifiers,
+		ErrorLog:  func(err error) {},
+		status:    make(map[string]error),
+	}
+}
+
+// Add registers checks
+func (m *Monitor) Add(checks ...Check) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.checks = append(m.checks, checks...)
+}
+
+// Status returns the last result of every check, keyed by name
+func (m *Monitor) Status() map[string]error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := make(map[string]error, len(m.status))
+	for k, v := range m.status {
+		status[k] = v
+	}
+
+	return status
+}
+
+// Run executes every check once and sends alerts for state changes
+func (m *Monitor) Run() {
+	m.mu.Lock()
+	checks := append([]Check{}, m.checks...)
+	m.mu.Unlock()
+
+	for _, c := range checks {
+		err := c.Run()
+
+		m.mu.Lock()
+		prev, seen := m.status[c.Name()]
+		m.status[c.Name()] = err
+		m.mu.Unlock()
+
+		wasHealthy := !seen || prev == nil
+		switch {
+		case err != nil && wasHealthy:
+			m.notify(Alert{Check: c.Name(), Healthy: false, Message: err.Error(), Time: time.Now()})
+		case err == nil && !wasHealthy:
+			m.notify(Alert{Check: c.Name(), Healthy: true, Message: "recovered", Time: time.Now()})
+		}
+	}
+}
+
+// Notify sends an alert raised outside of the checks, e.g. a quota running out
+func (m *Monitor) Notify(a Alert) {
+	if a.Time.IsZero() {
+		a.Time = time.Now()
+	}
+
+	m.notify(a)
+}
+
+func (m *Monitor) notify(a Alert) {
+	for _, n := range m.Notifiers {
+		if err := n.Notify(a); err != nil {
+			m.ErrorLog(err)
+		}
+	}
+}
+
+// HTTPCheck requests a URL and fails on errors, unexpected status codes, or a TLS certificate
+// expiring within CertWarning
+type HTTPCheck struct {
+	URL         string
+	Status      int
+	CertWarning time.Duration
+	Client      *http.Client
+}
+
+// Name returns the checked URL
+func (c *HTTPCheck) Name() string {
+	return c.URL
+}
+
+// Run performs the request
+func (c *HTTPCheck) Run() error {
+	client := c.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := client.Get(c.URL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	expected := c.Status
+	if expected == 0 {
+		expected = http.StatusOK
+	}
+
+	if resp.StatusCode != expected {
+		return fmt.Errorf("expected status %d, got %d", expected, resp.StatusCode)
+	}
+
+	if c.CertWarning > 0 && resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		expires := resp.TLS.PeerCertificates[0].NotAfter
+		if time.Until(expires) < c.CertWarning {
+			return fmt.Errorf("certificate expires on %s", expires.Format("2006-01-02"))
+		}
+	}
+
+	return nil
+}
+
+// FuncCheck adapts a function, e.g. a database ping, to a Check
+type FuncCheck struct {
+	CheckName string
+	Fn        func() error
+}
+
+// Name returns the check name
+func (c *FuncCheck) Name() string {
+	return c.CheckName
+}
+
+// Run calls the function
+func (c *FuncCheck) Run() error {
+	return c.Fn()
+}
+
+// SlackNotifier posts alerts to a Slack incoming webhook
+type SlackNotifier struct {
+	WebhookURL string
+	Client     *http.Client
+}
+
+// Notify posts the alert
+func (s *SlackNotifier) Notify(a Alert) error {
+	body, err := json.Marshal(map[string]string{"text": a.String()})
+	if err != nil {
+		return err
+	}
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := client.Post(s.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slack webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// MailNotifier queues alerts on the mailer using the given template (e.g. "monitor")
+type MailNotifier struct {
+	Mail     *mailer.Mail
+	To       string
+	Template string
+}
+
+// Notify queues the alert email
+func (n *MailNotifier) Notify(a Alert) error {
+	n.Mail.Jobs <- mailer.Message{
+		To:       n.To,
+		Subject:  a.String(),
+		Template: n.Template,
+		Data:     a,
+	}
+
+	return nil
+}
+
+// String returns a one line description of the alert
+func (a Alert) String() string {
+	state := "DOWN"
+	if a.Healthy {
+		state = "UP"
+	}
+
+	return fmt.Sprintf("[%s] %s: %s", state, a.Check, a.Message)
+}
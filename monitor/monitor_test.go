@@ -0,0 +1,79 @@
+package monitor
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type recorder struct {
+	alerts []Alert
+}
+
+func (r *recorder) Notify(a Alert) error {
+	r.alerts = append(r.alerts, a)
+	return nil
+}
+
+func TestMonitor_Run(t *testing.T) {
+	rec := &recorder{}
+	m := New(rec)
+
+	var fail error
+	m.Add(&FuncCheck{CheckName: "db", Fn: func() error { return fail }})
+
+	m.Run()
+	if len(rec.alerts) != 0 {
+		t.Error("no alert expected for a healthy check")
+	}
+
+	fail = errors.New("connection refused")
+	m.Run()
+	m.Run()
+	if len(rec.alerts) != 1 || rec.alerts[0].Healthy {
+		t.Fatalf("expected a single down alert, got %+v", rec.alerts)
+	}
+
+	fail = nil
+	m.Run()
+	if len(rec.alerts) != 2 || !rec.alerts[1].Healthy {
+		t.Errorf("expected a recovery alert, got %+v", rec.alerts)
+	}
+}
+
+func TestHTTPCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	if err := (&HTTPCheck{URL: srv.URL}).Run(); err != nil {
+		t.Error(err)
+	}
+
+	if err := (&HTTPCheck{URL: srv.URL + "/down"}).Run(); err == nil {
+		t.Error("expected an error for a 503 response")
+	}
+}
+
+func TestSlackNotifier(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		buf := make([]byte, 1024)
+		n, _ := r.Body.Read(buf)
+		got = string(buf[:n])
+	}))
+	defer srv.Close()
+
+	err := (&SlackNotifier{WebhookURL: srv.URL}).Notify(Alert{Check: "db", Message: "down"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != `{"text":"[DOWN] db: down"}` {
+		t.Errorf("unexpected payload %s", got)
+	}
+}
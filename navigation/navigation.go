@@ -0,0 +1,241 @@
+package navigation
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Navigation holds the named menus of an application
+type Navigation struct {
+	mu    sync.RWMutex
+	menus map[string]*Menu
+}
+
+// Menu is an ordered list of navigation items
+type Menu struct {
+	Name  string
+	Items []*Item
+
+	// mu is the lock of the navigation of the menu, nil for menus built by hand
+	mu *sync.RWMutex
+}
+
+// Item is a single entry of a menu. Route is the chi route pattern (e.g. /users/{id}) that marks
+// the item as active; when empty the item is active if the request path equals URL.
+type Item struct {
+	Label    string
+	URL      string
+	Route    string
+	Children []*Item
+
+	mu *sync.RWMutex
+}
+
+// Crumb is a single breadcrumb entry
+type Crumb struct {
+	Label  string
+	URL    string
+	Active bool
+}
+
+// New returns an empty navigation registry
+func New() *Navigation {
+	return &Navigation{
+		menus: make(map[string]*Menu),
+	}
+}
+
+// Menu returns the menu with the given name, creating it on first use
+func (n *Navigation) Menu(name string) *Menu {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	m, ok := n.menus[name]
+	if !ok {
+		m = &Menu{Name: name, mu: &n.mu}
+		n.menus[name] = m
+	}
+
+	return m
+}
+
+// Add appends an item to the menu and returns it so children can be added
+func (m *Menu) Add(label, url string, route ...string) *Item {
+	defer lock(m.mu)()
+
+	item := newItem(m.mu, label, url, route...)
+	m.Items = append(m.Items, item)
+
+	return item
+}
+
+// Add appends a child item and returns it
+func (i *Item) Add(label, url string, route ...string) *Item {
+	defer lock(i.mu)()
+
+	item := newItem(i.mu, label, url, route...)
+	i.Children = append(i.Children, item)
+
+	return item
+}
+
+func newItem(mu *sync.RWMutex, label, url string, route ...string) *Item {
+	item := &Item{Label: label, URL: url, mu: mu}
+	if len(route) > 0 {
+		item.Route = route[0]
+	}
+
+	return item
+}
+
+// lock locks mu, when there is one, and returns its unlock
+func lock(mu *sync.RWMutex) func() {
+	if mu == nil {
+		return func() {}
+	}
+	mu.Lock()
+	return mu.Unlock
+}
+
+// rlock read-locks mu, when there is one, and returns its unlock
+func rlock(mu *sync.RWMutex) func() {
+	if mu == nil {
+		return func() {}
+	}
+	mu.RLock()
+	return mu.RUnlock
+}
+
+// IsCurrent reports whether the item itself matches the request
+func (i *Item) IsCurrent(r *http.Request) bool {
+	if i.Route != "" {
+		return i.Route == CurrentRoute(r)
+	}
+
+	return i.URL == r.URL.Path
+}
+
+// IsActive reports whether the item or one of its children matches the request
+func (i *Item) IsActive(r *http.Request) bool {
+	defer rlock(i.mu)()
+
+	return i.isActive(r)
+}
+
+func (i *Item) isActive(r *http.Request) bool {
+	if i.IsCurrent(r) {
+		return true
+	}
+
+	for _, child := range i.Children {
+		if child.isActive(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Trail returns the breadcrumbs leading to the active item of the menu
+func (m *Menu) Trail(r *http.Request) []Crumb {
+	defer rlock(m.mu)()
+
+	var crumbs []Crumb
+
+	items := m.Items
+	for len(items) > 0 {
+		var next []*Item
+		for _, item := range items {
+			if item.isActive(r) {
+				crumbs = append(crumbs, Crumb{Label: item.Label, URL: item.URL, Active: item.IsCurrent(r)})
+				next = item.Children
+				break
+			}
+		}
+		items = next
+	}
+
+	return crumbs
+}
+
+// HTML renders the menu as nested lists, marking active items with the "active" class
+func (m *Menu) HTML(r *http.Request) template.HTML {
+	defer rlock(m.mu)()
+
+	var b strings.Builder
+	writeItems(&b, m.Items, r)
+
+	return template.HTML(b.String())
+}
+
+func writeItems(b *strings.Builder, items []*Item, r *http.Request) {
+	if len(items) == 0 {
+		return
+	}
+
+	b.WriteString("<ul>")
+	for _, item := range items {
+		class := ""
+		if item.isActive(r) {
+			class = ` class="active"`
+		}
+		fmt.Fprintf(b, `<li%s><a href="%s">%s</a>`, class, template.HTMLEscapeString(item.URL), template.HTMLEscapeString(item.Label))
+		writeItems(b, item.Children, r)
+		b.WriteString("</li>")
+	}
+	b.WriteString("</ul>")
+}
+
+// Breadcrumbs renders the trail of a menu as an ordered list
+func Breadcrumbs(crumbs []Crumb) template.HTML {
+	var b strings.Builder
+
+	b.WriteString(`<ol class="breadcrumb">`)
+	for _, c := range crumbs {
+		if c.Active {
+			fmt.Fprintf(&b, `<li class="breadcrumb-item active" aria-current="page">%s</li>`, template.HTMLEscapeString(c.Label))
+		} else {
+			fmt.Fprintf(&b, `<li class="breadcrumb-item"><a href="%s">%s</a></li>`, template.HTMLEscapeString(c.URL), template.HTMLEscapeString(c.Label))
+		}
+	}
+	b.WriteString("</ol>")
+
+	return template.HTML(b.String())
+}
+
+// CurrentRoute returns the chi route pattern matched for the request, or the path when no
+// route has been matched yet
+func CurrentRoute(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+
+	return r.URL.Path
+}
+
+// TemplateFuncs returns the navigation helpers bound to a request
+func (n *Navigation) TemplateFuncs(r *http.Request) template.FuncMap {
+	return template.FuncMap{
+		"menu": func(name string) template.HTML {
+			return n.Menu(name).HTML(r)
+		},
+		"breadcrumbs": func(name string) template.HTML {
+			return Breadcrumbs(n.Menu(name).Trail(r))
+		},
+		"isActive": func(url string) bool {
+			if r.URL.Path == url {
+				return true
+			}
+			// every path is below the home page, which is only active on itself
+			prefix := strings.TrimSuffix(url, "/") + "/"
+			return prefix != "/" && strings.HasPrefix(r.URL.Path, prefix)
+		},
+	}
+}
@@ -0,0 +1,121 @@
+package navigation
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func testNavigation() *Navigation {
+	nav := New()
+	main := nav.Menu("main")
+	main.Add("Home", "/")
+	users := main.Add("Users", "/users")
+	users.Add("Profile", "/users/1", "/users/{id}")
+
+	return nav
+}
+
+func requestFor(path, pattern string) *http.Request {
+	r, _ := http.NewRequest("GET", path, nil)
+	rctx := chi.NewRouteContext()
+	if pattern != "" {
+		rctx.RoutePatterns = []string{pattern}
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestItem_IsActive(t *testing.T) {
+	nav := testNavigation()
+	r := requestFor("/users/42", "/users/{id}")
+
+	items := nav.Menu("main").Items
+	if items[0].IsActive(r) {
+		t.Error("home should not be active")
+	}
+
+	if !items[1].IsActive(r) {
+		t.Error("users should be active when a child route matches")
+	}
+
+	if items[1].IsCurrent(r) {
+		t.Error("users should not be the current item")
+	}
+}
+
+func TestMenu_Trail(t *testing.T) {
+	nav := testNavigation()
+	crumbs := nav.Menu("main").Trail(requestFor("/users/42", "/users/{id}"))
+
+	if len(crumbs) != 2 {
+		t.Fatalf("expected 2 crumbs, got %d", len(crumbs))
+	}
+
+	if crumbs[0].Label != "Users" || crumbs[0].Active {
+		t.Error("first crumb should be the inactive parent")
+	}
+
+	if crumbs[1].Label != "Profile" || !crumbs[1].Active {
+		t.Error("last crumb should be the active page")
+	}
+}
+
+func TestMenu_HTML(t *testing.T) {
+	nav := testNavigation()
+	html := string(nav.Menu("main").HTML(requestFor("/", "")))
+
+	if !strings.Contains(html, `<li class="active"><a href="/">Home</a>`) {
+		t.Errorf("expected home to be rendered active, got %s", html)
+	}
+}
+
+func TestTemplateFuncs_isActive(t *testing.T) {
+	isActive := func(path, url string) bool {
+		return New().TemplateFuncs(requestFor(path, ""))["isActive"].(func(string) bool)(url)
+	}
+
+	tests := []struct {
+		path, url string
+		active    bool
+	}{
+		{"/", "/", true},
+		{"/users", "/", false},
+		{"/users", "/users", true},
+		{"/users/42", "/users", true},
+		{"/users/42", "/users/", true},
+		{"/usersettings", "/users", false},
+	}
+	for _, tt := range tests {
+		if got := isActive(tt.path, tt.url); got != tt.active {
+			t.Errorf("isActive(%q) on %s: expected %v, got %v", tt.url, tt.path, tt.active, got)
+		}
+	}
+}
+
+func TestMenu_AddConcurrently(t *testing.T) {
+	nav := testNavigation()
+	r := requestFor("/users/42", "/users/{id}")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			nav.Menu("main").Add("Orders", "/orders").Add("Order", "/orders/1")
+		}()
+		go func() {
+			defer wg.Done()
+			_ = nav.Menu("main").HTML(r)
+		}()
+	}
+	wg.Wait()
+
+	if n := len(nav.Menu("main").Items); n != 12 {
+		t.Errorf("expected 12 items, got %d", n)
+	}
+}
@@ -0,0 +1,47 @@
+package goravel
+
+import (
+	"crypto/rsa"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/namnguyen191/goravel/oidc"
+)
+
+// createOIDC makes the app an OpenID Connect provider when OIDC is true, signing the tokens
+// with the first of the PEM key files of OIDC_KEYS, made with "goravel make oidc-key" and
+// relative to the root of the app; the keys after it are the ones of before a rotation, kept
+// until the tokens they signed expire
+func (grv *Goravel) createOIDC() (*oidc.Provider, error) {
+	if !grv.Env.Bool("OIDC", false) {
+		return nil, nil
+	}
+
+	var keys []*rsa.PrivateKey
+	for _, path := range grv.envList("OIDC_KEYS") {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(grv.RootPath, path)
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := oidc.ParseKey(data)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("oidc: OIDC_KEYS names no signing key")
+	}
+
+	p := oidc.New(grv.DB.Pool, grv.DB.DataBaseType, grv.Auth, grv.Env.String("OIDC_ISSUER", grv.Server.URL), keys...)
+	p.TokenTTL = grv.Env.Duration("OIDC_TOKEN_TTL", time.Hour)
+	p.Render = grv.Render
+	p.Clock = grv.Clock
+
+	return p, nil
+}
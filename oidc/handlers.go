@@ -0,0 +1,388 @@
+package oidc
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/CloudyKit/jet/v6"
+	"github.com/namnguyen191/goravel/auth"
+	"github.com/namnguyen191/goravel/render"
+)
+
+// authError is an error of the authorization or token endpoint, sent to the client as an
+// OAuth error code with its description
+type authError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *authError) Error() string {
+	return "oidc: " + e.Code + ": " + e.Description
+}
+
+// request is a validated authorization request
+type request struct {
+	Client      *Client
+	RedirectURI string
+	Scopes      []string
+	State       string
+	Nonce       string
+	Challenge   string
+}
+
+// params are the parameters of the request, posted back by the consent form
+func (req *request) params() map[string]string {
+	return map[string]string{
+		"response_type":         "code",
+		"client_id":             req.Client.ClientID,
+		"redirect_uri":          req.RedirectURI,
+		"scope":                 strings.Join(req.Scopes, " "),
+		"state":                 req.State,
+		"nonce":                 req.Nonce,
+		"code_challenge":        req.Challenge,
+		"code_challenge_method": "S256",
+	}
+}
+
+// authorization validates the authorization request of values. Without a known client and one
+// of its redirect uris it returns a nil request, the error being shown to the user; otherwise
+// an authError is sent back to the client.
+func (p *Provider) authorization(ctx context.Context, values url.Values) (*request, error) {
+	c, err := p.Client(ctx, values.Get("client_id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrClient
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !c.AllowsRedirect(values.Get("redirect_uri")) {
+		return nil, ErrRedirect
+	}
+
+	req := &request{
+		Client:      c,
+		RedirectURI: values.Get("redirect_uri"),
+		Scopes:      strings.Fields(values.Get("scope")),
+		State:       values.Get("state"),
+		Nonce:       values.Get("nonce"),
+		Challenge:   values.Get("code_challenge"),
+	}
+
+	if values.Get("response_type") != "code" {
+		return req, &authError{"unsupported_response_type", "only the authorization code flow is supported"}
+	}
+	if !covers(req.Scopes, []string{OpenID}) {
+		return req, &authError{"invalid_scope", "the openid scope is required"}
+	}
+	for _, s := range req.Scopes {
+		if _, ok := p.Scopes[s]; !ok {
+			return req, &authError{"invalid_scope", "unknown scope " + s}
+		}
+	}
+	// PKCE is required of every client, confidential ones included
+	if req.Challenge == "" {
+		return req, &authError{"invalid_request", "code_challenge is required"}
+	}
+	if values.Get("code_challenge_method") != "S256" {
+		return req, &authError{"invalid_request", "code_challenge_method must be S256"}
+	}
+	return req, nil
+}
+
+// redirect sends the user back to the client with query
+func redirect(rw http.ResponseWriter, r *http.Request, req *request, query url.Values) {
+	if req.State != "" {
+		query.Set("state", req.State)
+	}
+	sep := "?"
+	if strings.Contains(req.RedirectURI, "?") {
+		sep = "&"
+	}
+	http.Redirect(rw, r, req.RedirectURI+sep+query.Encode(), http.StatusFound)
+}
+
+// fail answers a failed authorization request: to the user for an unknown client or redirect
+// uri, which must not be redirected to, and to the client otherwise
+func (p *Provider) fail(rw http.ResponseWriter, r *http.Request, req *request, err error) {
+	var ae *authError
+	switch {
+	case errors.Is(err, ErrClient), errors.Is(err, ErrRedirect):
+		http.Error(rw, "The application asking you to sign in is not registered", http.StatusBadRequest)
+	case req != nil && errors.As(err, &ae):
+		query := url.Values{"error": {ae.Code}}
+		if ae.Description != "" {
+			query.Set("error_description", ae.Description)
+		}
+		redirect(rw, r, req, query)
+	default:
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// AuthorizeHandler is the authorization endpoint. The user is asked on ConsentView to let the
+// client sign them in, unless the client is trusted or they already consented to its scopes;
+// then they are sent back to the client with a code. It goes after grv.Auth.Require, which
+// brings them back to it after logging in, e.g.
+// Routes.With(grv.Auth.Require).Get("/oauth/authorize", grv.OIDC.AuthorizeHandler).
+func (p *Provider) AuthorizeHandler(rw http.ResponseWriter, r *http.Request) {
+	req, err := p.authorization(r.Context(), r.URL.Query())
+	if err != nil {
+		p.fail(rw, r, req, err)
+		return
+	}
+
+	u, err := p.Auth.User(r)
+	if err != nil {
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if u == nil {
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	consented := req.Client.Trusted
+	if !consented {
+		if consented, err = p.Consented(r.Context(), u.ID, req.Client.ClientID, req.Scopes); err != nil {
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+	}
+	if consented {
+		p.approve(rw, r, req, u)
+		return
+	}
+
+	if r.URL.Query().Get("prompt") == "none" {
+		p.fail(rw, r, req, &authError{Code: "consent_required"})
+		return
+	}
+	p.consentPage(rw, r, req, u)
+}
+
+// Scope is a scope shown on the consent page, with what it lets the client do
+type Scope struct {
+	Name        string
+	Description string
+}
+
+// consentPage renders ConsentView with the client, the scopes it asks for, the user, and the
+// params of the request as hidden fields of the form posted to ConsentHandler. Jet views get
+// them as variables, go templates in .Data.
+func (p *Provider) consentPage(rw http.ResponseWriter, r *http.Request, req *request, u *auth.User) {
+	scopes := make([]Scope, 0, len(req.Scopes))
+	for _, s := range req.Scopes {
+		scopes = append(scopes, Scope{Name: s, Description: p.Scopes[s]})
+	}
+
+	vars := make(jet.VarMap)
+	vars.Set("client", req.Client)
+	vars.Set("scopes", scopes)
+	vars.Set("params", req.params())
+	vars.Set("user", u)
+	data := &render.TemplateData{Data: map[string]interface{}{"client": req.Client, "scopes": scopes, "params": req.params(), "user": u}}
+
+	if err := p.Render.Page(rw, r, p.ConsentView, vars, data); err != nil {
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// ConsentHandler answers the consent form: the client is sent a code when it has the approve
+// field, access_denied otherwise. It goes after grv.Auth.Require, e.g.
+// Routes.With(grv.Auth.Require).Post("/oauth/authorize", grv.OIDC.ConsentHandler).
+func (p *Provider) ConsentHandler(rw http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	req, err := p.authorization(r.Context(), r.PostForm)
+	if err != nil {
+		p.fail(rw, r, req, err)
+		return
+	}
+	if r.PostForm.Get("approve") == "" {
+		p.fail(rw, r, req, &authError{"access_denied", "the user did not let the application sign them in"})
+		return
+	}
+
+	u, err := p.Auth.User(r)
+	if err != nil {
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if u == nil {
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	if err := p.consent(r.Context(), u.ID, req.Client.ClientID, req.Scopes); err != nil {
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	p.approve(rw, r, req, u)
+}
+
+// approve sends the user back to the client with a new code of req
+func (p *Provider) approve(rw http.ResponseWriter, r *http.Request, req *request, u *auth.User) {
+	code, err := p.issueCode(r.Context(), &grant{
+		ClientID:    req.Client.ClientID,
+		UserID:      u.ID,
+		RedirectURI: req.RedirectURI,
+		Scopes:      req.Scopes,
+		Nonce:       req.Nonce,
+		Challenge:   req.Challenge,
+	})
+	if err != nil {
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	redirect(rw, r, req, url.Values{"code": {code}})
+}
+
+// writeJSON answers the json of the endpoints called by the clients, which must not be cached
+func writeJSON(rw http.ResponseWriter, status int, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.Header().Set("Pragma", "no-cache")
+	rw.WriteHeader(status)
+	_ = json.NewEncoder(rw).Encode(v)
+}
+
+// TokenHandler is the token endpoint, exchanging a code with its PKCE verifier for tokens.
+// Confidential clients authenticate with HTTP Basic or client_secret in the form. Apps mount it
+// under the CSRF-exempt /api prefix, e.g. Routes.Post("/api/oauth/token", grv.OIDC.TokenHandler).
+func (p *Provider) TokenHandler(rw http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(rw, http.StatusBadRequest, &authError{Code: "invalid_request"})
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(rw, http.StatusBadRequest, &authError{"unsupported_grant_type", "only authorization_code is supported"})
+		return
+	}
+
+	clientID, secret, ok := r.BasicAuth()
+	if ok {
+		// the credentials of Basic are form encoded
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	ctx := r.Context()
+	c, err := p.authenticate(ctx, clientID, secret)
+	if errors.Is(err, ErrClient) {
+		rw.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		writeJSON(rw, http.StatusUnauthorized, &authError{Code: "invalid_client"})
+		return
+	}
+	if err != nil {
+		writeJSON(rw, http.StatusInternalServerError, &authError{Code: "server_error"})
+		return
+	}
+
+	g, err := p.redeem(ctx, r.PostForm.Get("code"))
+	if err == nil && (g.ClientID != c.ClientID || g.RedirectURI != r.PostForm.Get("redirect_uri") || !g.verifies(r.PostForm.Get("code_verifier"))) {
+		err = ErrCode
+	}
+	var u *auth.User
+	if err == nil {
+		u, err = p.Auth.Find(ctx, g.UserID)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && !u.Active) {
+			err = ErrCode
+		}
+	}
+	if errors.Is(err, ErrCode) {
+		writeJSON(rw, http.StatusBadRequest, &authError{"invalid_grant", "the code is invalid, expired or was already used"})
+		return
+	}
+	if err != nil {
+		writeJSON(rw, http.StatusInternalServerError, &authError{Code: "server_error"})
+		return
+	}
+
+	tokens, err := p.issue(ctx, g, u)
+	if err != nil {
+		writeJSON(rw, http.StatusInternalServerError, &authError{Code: "server_error"})
+		return
+	}
+	writeJSON(rw, http.StatusOK, tokens)
+}
+
+// UserInfoHandler is the userinfo endpoint, answering the claims of the user of the access
+// token for its scopes, e.g. Routes.Get("/api/oauth/userinfo", grv.OIDC.UserInfoHandler)
+func (p *Provider) UserInfoHandler(rw http.ResponseWriter, r *http.Request) {
+	t, err := p.VerifyAccessToken(bearer(r))
+	if err != nil || !t.Can(OpenID) {
+		rw.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		writeJSON(rw, http.StatusUnauthorized, &authError{Code: "invalid_token"})
+		return
+	}
+
+	u, err := p.Auth.Find(r.Context(), t.UserID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !u.Active) {
+		rw.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		writeJSON(rw, http.StatusUnauthorized, &authError{Code: "invalid_token"})
+		return
+	}
+	if err != nil {
+		writeJSON(rw, http.StatusInternalServerError, &authError{Code: "server_error"})
+		return
+	}
+
+	claims, err := p.userClaims(r.Context(), u, t.Scopes)
+	if err != nil {
+		writeJSON(rw, http.StatusInternalServerError, &authError{Code: "server_error"})
+		return
+	}
+	writeJSON(rw, http.StatusOK, claims)
+}
+
+// Discovery returns the metadata of the provider the clients configure themselves with
+func (p *Provider) Discovery() map[string]interface{} {
+	scopes := make([]string, 0, len(p.Scopes))
+	for s := range p.Scopes {
+		scopes = append(scopes, s)
+	}
+	sort.Strings(scopes)
+
+	return map[string]interface{}{
+		"issuer":                                p.Issuer,
+		"authorization_endpoint":                p.AuthorizeURL,
+		"token_endpoint":                        p.TokenURL,
+		"userinfo_endpoint":                     p.UserInfoURL,
+		"jwks_uri":                              p.JWKSURL,
+		"scopes_supported":                      scopes,
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"claims_supported":                      []string{"sub", "iss", "aud", "exp", "iat", "nonce", "name", "given_name", "family_name", "email", "updated_at"},
+	}
+}
+
+// DiscoveryHandler answers Discovery, e.g.
+// Routes.Get("/.well-known/openid-configuration", grv.OIDC.DiscoveryHandler)
+func (p *Provider) DiscoveryHandler(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Cache-Control", "public, max-age=3600")
+	_ = json.NewEncoder(rw).Encode(p.Discovery())
+}
+
+// JWKSHandler answers the JWKS the clients verify the tokens with, e.g.
+// Routes.Get("/.well-known/jwks.json", grv.OIDC.JWKSHandler)
+func (p *Provider) JWKSHandler(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Cache-Control", "public, max-age=3600")
+	_ = json.NewEncoder(rw).Encode(p.JWKS())
+}
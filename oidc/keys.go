@@ -0,0 +1,161 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// ErrInvalidToken is returned for a token which is malformed, forged, or signed by a key the
+// provider no longer has
+var ErrInvalidToken = errors.New("oidc: invalid token")
+
+// GenerateKey returns a new 2048 bit RSA key as PKCS #8 PEM, the format ParseKey reads
+func GenerateKey() ([]byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// ParseKey reads an RSA private key in PKCS #8 or PKCS #1 PEM
+func ParseKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("oidc: invalid PEM key")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("oidc: the key is not an RSA key")
+	}
+	return key, nil
+}
+
+// KeyID is the kid of a key in the JWKS and the headers of its tokens, derived from its public
+// key so it is the same on every instance
+func KeyID(key *rsa.PublicKey) string {
+	der, _ := x509.MarshalPKIXPublicKey(key)
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+// jwk is a public key of the JWKS
+type jwk struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JWKS returns the public keys of the provider as a JSON Web Key Set
+func (p *Provider) JWKS() map[string]interface{} {
+	keys := make([]jwk, 0, len(p.Keys))
+	for _, key := range p.Keys {
+		keys = append(keys, jwk{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: "RS256",
+			Kid: KeyID(&key.PublicKey),
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	return map[string]interface{}{"keys": keys}
+}
+
+// sign returns the claims as a JWT of type typ signed with RS256 by the first key, the ones
+// after it only verifying the tokens they signed before a rotation
+func (p *Provider) sign(typ string, claims map[string]interface{}) (string, error) {
+	if len(p.Keys) == 0 {
+		return "", errors.New("oidc: no signing key")
+	}
+	key := p.Keys[0]
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": typ, "kid": KeyID(&key.PublicKey)})
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+	sum := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// verify checks the signature of a JWT of sign of type typ and returns its claims, leaving
+// their values to the caller
+func (p *Provider) verify(typ, token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Typ string `json:"typ"`
+		Kid string `json:"kid"`
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || json.Unmarshal(raw, &header) != nil || header.Alg != "RS256" || header.Typ != typ {
+		return nil, ErrInvalidToken
+	}
+
+	var key *rsa.PublicKey
+	for _, k := range p.Keys {
+		if KeyID(&k.PublicKey) == header.Kid {
+			key = &k.PublicKey
+			break
+		}
+	}
+	if key == nil {
+		return nil, ErrInvalidToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], sig) != nil {
+		return nil, ErrInvalidToken
+	}
+
+	raw, err = base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(raw, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
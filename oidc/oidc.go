@@ -0,0 +1,433 @@
+// Package oidc lets an app be an OpenID Connect identity provider for other apps: its users
+// sign in to registered clients with the authorization code flow and PKCE, consenting to the
+// scopes a client asks for, and the clients get ID and access tokens signed with RS256, whose
+// keys are published as a JWKS.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/namnguyen191/goravel/auth"
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+	"github.com/namnguyen191/goravel/render"
+)
+
+// The scopes of OpenID Connect the provider knows; apps add their own to Scopes
+const (
+	OpenID  = "openid"
+	Profile = "profile"
+	Email   = "email"
+)
+
+var (
+	// ErrClient is returned for an unknown client, or a confidential one with the wrong secret
+	ErrClient = errors.New("oidc: invalid client")
+	// ErrRedirect is returned by Register for a redirect uri which is not an absolute url
+	ErrRedirect = errors.New("oidc: invalid redirect uri")
+	// ErrCode is returned for a code which is unknown, expired, used before, or exchanged by
+	// another client or with the wrong verifier
+	ErrCode = errors.New("oidc: invalid authorization code")
+)
+
+// Client is a row of the oidc_clients table, an app its users sign in to
+type Client struct {
+	ID       int
+	ClientID string
+	Name     string
+	// RedirectURIs are the only urls the codes are sent to, matched exactly
+	RedirectURIs []string
+	// Public clients, e.g. single page and mobile apps, have no secret and rely on PKCE alone
+	Public bool
+	// Trusted clients, e.g. the own apps of the company, are not shown the consent page
+	Trusted   bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	secretHash string
+}
+
+// AllowsRedirect reports whether uri is one of the RedirectURIs of the client
+func (c *Client) AllowsRedirect(uri string) bool {
+	for _, u := range c.RedirectURIs {
+		if u == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// NewClient is what Register needs to add a client
+type NewClient struct {
+	Name         string
+	RedirectURIs []string
+	Public       bool
+	Trusted      bool
+}
+
+// Provider keeps the clients in oidc_clients, the consents of the users in oidc_consents and
+// the pending codes in oidc_codes, signing in the users of Auth
+type Provider struct {
+	DB           *sql.DB
+	DatabaseType string
+	Auth         *auth.Auth
+	// Issuer is the public url of the app, the iss of the tokens, which the clients discover
+	// the endpoints from at Issuer/.well-known/openid-configuration
+	Issuer string
+	// Keys sign the tokens with the first one, the others still verifying the tokens signed
+	// before a rotation and being published until they expire
+	Keys []*rsa.PrivateKey
+	// The urls of the endpoints, under Issuer by default; the token and userinfo endpoints
+	// are posted to by other apps, so they go under the CSRF-exempt /api prefix
+	AuthorizeURL string
+	TokenURL     string
+	UserInfoURL  string
+	JWKSURL      string
+	// Scopes are the scopes clients may ask for, with what they let a client do as shown on
+	// the consent page
+	Scopes map[string]string
+	// Render renders ConsentView, the page asking the user to let a client sign them in
+	Render      *render.Render
+	ConsentView string
+	// CodeTTL is how long a code can be exchanged, TokenTTL how long the tokens are valid
+	CodeTTL  time.Duration
+	TokenTTL time.Duration
+	// Claims adds claims of the app to the ID tokens and userinfo of u for scopes, e.g. roles
+	Claims func(ctx context.Context, u *auth.User, scopes []string) (map[string]interface{}, error)
+	// Clock tells when the codes and tokens expire; the time of the machine when nil
+	Clock clock.Clock
+}
+
+// New returns the provider of db at issuer, signing in the users of a
+func New(db *sql.DB, dbType string, a *auth.Auth, issuer string, keys ...*rsa.PrivateKey) *Provider {
+	issuer = strings.TrimSuffix(issuer, "/")
+	return &Provider{
+		DB:           db,
+		DatabaseType: dbType,
+		Auth:         a,
+		Issuer:       issuer,
+		Keys:         keys,
+		AuthorizeURL: issuer + "/oauth/authorize",
+		TokenURL:     issuer + "/api/oauth/token",
+		UserInfoURL:  issuer + "/api/oauth/userinfo",
+		JWKSURL:      issuer + "/.well-known/jwks.json",
+		Scopes: map[string]string{
+			OpenID:  "Sign you in with your account",
+			Profile: "See your name",
+			Email:   "See your email address",
+		},
+		ConsentView: "oidc/consent",
+		CodeTTL:     time.Minute,
+		TokenTTL:    time.Hour,
+	}
+}
+
+func (p *Provider) rebind(query string) string {
+	return database.Rebind(p.DatabaseType, query)
+}
+
+// random returns n random bytes as url safe text
+func random(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hash is what is kept of the secrets and codes, so a leaked table does not let anyone in
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+const clientColumns = "id, client_id, name, secret_hash, redirect_uris, public, trusted, created_at, updated_at"
+
+func scanClient(row interface{ Scan(...interface{}) error }) (*Client, error) {
+	var c Client
+	var uris string
+	err := row.Scan(&c.ID, &c.ClientID, &c.Name, &c.secretHash, &uris, &c.Public, &c.Trusted, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	c.RedirectURIs = strings.Fields(uris)
+	return &c, nil
+}
+
+// Client returns the client with the public id clientID, or sql.ErrNoRows
+func (p *Provider) Client(ctx context.Context, clientID string) (*Client, error) {
+	query := p.rebind("select " + clientColumns + " from oidc_clients where client_id = ?")
+	return scanClient(p.DB.QueryRowContext(ctx, query, clientID))
+}
+
+// Clients returns the registered clients by name
+func (p *Provider) Clients(ctx context.Context) ([]*Client, error) {
+	rows, err := p.DB.QueryContext(ctx, "select "+clientColumns+" from oidc_clients order by name, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*Client
+	for rows.Next() {
+		c, err := scanClient(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+// Register adds a client, returning its secret, which is given to the client once and never
+// shown again; a public client has none
+func (p *Provider) Register(ctx context.Context, in NewClient) (*Client, string, error) {
+	if len(in.RedirectURIs) == 0 {
+		return nil, "", ErrRedirect
+	}
+	for _, uri := range in.RedirectURIs {
+		u, err := url.Parse(uri)
+		if err != nil || !u.IsAbs() || u.Fragment != "" || strings.ContainsAny(uri, " \t\n") {
+			return nil, "", ErrRedirect
+		}
+	}
+
+	clientID, err := random(16)
+	if err != nil {
+		return nil, "", err
+	}
+	var secret string
+	if !in.Public {
+		if secret, err = random(32); err != nil {
+			return nil, "", err
+		}
+	}
+
+	now := clock.Now(p.Clock)
+	c := &Client{
+		ClientID:     clientID,
+		Name:         in.Name,
+		RedirectURIs: in.RedirectURIs,
+		Public:       in.Public,
+		Trusted:      in.Trusted,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if secret != "" {
+		c.secretHash = hash(secret)
+	}
+
+	query := `insert into oidc_clients (client_id, name, secret_hash, redirect_uris, public, trusted, created_at, updated_at)
+		values (?, ?, ?, ?, ?, ?, ?, ?)`
+	args := []interface{}{c.ClientID, c.Name, c.secretHash, strings.Join(c.RedirectURIs, " "), c.Public, c.Trusted, c.CreatedAt, c.UpdatedAt}
+
+	if database.IsPostgres(p.DatabaseType) {
+		err = p.DB.QueryRowContext(ctx, p.rebind(query+" returning id"), args...).Scan(&c.ID)
+	} else {
+		var res sql.Result
+		if res, err = p.DB.ExecContext(ctx, query, args...); err == nil {
+			var id int64
+			id, err = res.LastInsertId()
+			c.ID = int(id)
+		}
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return c, secret, nil
+}
+
+// DeleteClient removes the client with clientID with the consents given to it; the tokens it
+// was issued stay valid until they expire
+func (p *Provider) DeleteClient(ctx context.Context, clientID string) error {
+	for _, query := range []string{
+		"delete from oidc_codes where client_id = ?",
+		"delete from oidc_consents where client_id = ?",
+		"delete from oidc_clients where client_id = ?",
+	} {
+		if _, err := p.DB.ExecContext(ctx, p.rebind(query), clientID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// authenticate returns the client of clientID, checking the secret of a confidential one
+func (p *Provider) authenticate(ctx context.Context, clientID, secret string) (*Client, error) {
+	c, err := p.Client(ctx, clientID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrClient
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !c.Public && subtle.ConstantTimeCompare([]byte(hash(secret)), []byte(c.secretHash)) != 1 {
+		return nil, ErrClient
+	}
+	return c, nil
+}
+
+// Consented reports whether the user with userID let the client sign them in with every one
+// of scopes before
+func (p *Provider) Consented(ctx context.Context, userID int, clientID string, scopes []string) (bool, error) {
+	var granted string
+	query := p.rebind("select scopes from oidc_consents where user_id = ? and client_id = ?")
+	err := p.DB.QueryRowContext(ctx, query, userID, clientID).Scan(&granted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return covers(strings.Fields(granted), scopes), nil
+}
+
+func covers(granted, scopes []string) bool {
+	for _, s := range scopes {
+		found := false
+		for _, g := range granted {
+			if g == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// consent keeps the scopes the user with userID let the client have, with the ones before
+func (p *Provider) consent(ctx context.Context, userID int, clientID string, scopes []string) error {
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var granted string
+	err = tx.QueryRowContext(ctx, p.rebind("select scopes from oidc_consents where user_id = ? and client_id = ?"), userID, clientID).Scan(&granted)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	all := strings.Fields(granted)
+	for _, s := range scopes {
+		if !covers(all, []string{s}) {
+			all = append(all, s)
+		}
+	}
+
+	now := clock.Now(p.Clock)
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err = tx.ExecContext(ctx, p.rebind("insert into oidc_consents (user_id, client_id, scopes, created_at, updated_at) values (?, ?, ?, ?, ?)"),
+			userID, clientID, strings.Join(all, " "), now, now)
+	} else {
+		_, err = tx.ExecContext(ctx, p.rebind("update oidc_consents set scopes = ?, updated_at = ? where user_id = ? and client_id = ?"),
+			strings.Join(all, " "), now, userID, clientID)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RevokeConsent forgets that the user with userID let the client sign them in, which asks them
+// again next time
+func (p *Provider) RevokeConsent(ctx context.Context, userID int, clientID string) error {
+	_, err := p.DB.ExecContext(ctx, p.rebind("delete from oidc_consents where user_id = ? and client_id = ?"), userID, clientID)
+	return err
+}
+
+// grant is an authorization code waiting to be exchanged
+type grant struct {
+	ClientID    string
+	UserID      int
+	RedirectURI string
+	Scopes      []string
+	Nonce       string
+	Challenge   string
+	ExpiresAt   time.Time
+}
+
+// issueCode stores g under a new code, returned to be sent to the redirect uri of the client
+func (p *Provider) issueCode(ctx context.Context, g *grant) (string, error) {
+	code, err := random(32)
+	if err != nil {
+		return "", err
+	}
+
+	now := clock.Now(p.Clock)
+	g.ExpiresAt = now.Add(p.CodeTTL)
+	query := p.rebind(`insert into oidc_codes (code_hash, client_id, user_id, redirect_uri, scopes, nonce, challenge, expires_at, created_at)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	_, err = p.DB.ExecContext(ctx, query, hash(code), g.ClientID, g.UserID, g.RedirectURI, strings.Join(g.Scopes, " "),
+		g.Nonce, g.Challenge, g.ExpiresAt, now)
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// redeem takes the grant of code out of oidc_codes, so a code is only ever exchanged once
+func (p *Provider) redeem(ctx context.Context, code string) (*grant, error) {
+	var g grant
+	var scopes string
+	query := p.rebind("select client_id, user_id, redirect_uri, scopes, nonce, challenge, expires_at from oidc_codes where code_hash = ?")
+	err := p.DB.QueryRowContext(ctx, query, hash(code)).Scan(&g.ClientID, &g.UserID, &g.RedirectURI, &scopes,
+		&g.Nonce, &g.Challenge, &g.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCode
+	}
+	if err != nil {
+		return nil, err
+	}
+	g.Scopes = strings.Fields(scopes)
+
+	// two exchanges of the same code race to delete it, and only one wins
+	res, err := p.DB.ExecContext(ctx, p.rebind("delete from oidc_codes where code_hash = ?"), hash(code))
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return nil, ErrCode
+	}
+	if !clock.Now(p.Clock).Before(g.ExpiresAt) {
+		return nil, ErrCode
+	}
+	return &g, nil
+}
+
+// PurgeCodes removes the codes which expired without being exchanged, from the maintenance tasks
+func (p *Provider) PurgeCodes(ctx context.Context) (int64, error) {
+	res, err := p.DB.ExecContext(ctx, p.rebind("delete from oidc_codes where expires_at < ?"), clock.Now(p.Clock))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// challenge is the S256 PKCE challenge of verifier
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// verifies reports whether verifier is a valid PKCE code verifier matching the challenge of g
+func (g *grant) verifies(verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(challenge(verifier)), []byte(g.Challenge)) == 1
+}
@@ -0,0 +1,263 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/namnguyen191/goravel/auth"
+	"github.com/namnguyen191/goravel/clock"
+)
+
+var (
+	keysOnce sync.Once
+	keys     [2]*rsa.PrivateKey
+)
+
+// testKeys generates two keys once, which takes a while
+func testKeys(t *testing.T) [2]*rsa.PrivateKey {
+	keysOnce.Do(func() {
+		for i := range keys {
+			data, err := GenerateKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if keys[i], err = ParseKey(data); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+	return keys
+}
+
+func newProvider(t *testing.T) *Provider {
+	k := testKeys(t)
+	p := New(nil, "postgres", nil, "https://id.example.com/", k[0])
+	p.Clock = clock.NewTest(time.Unix(1700000000, 0))
+	return p
+}
+
+func TestNew(t *testing.T) {
+	p := newProvider(t)
+	if p.Issuer != "https://id.example.com" || p.TokenURL != "https://id.example.com/api/oauth/token" {
+		t.Errorf("expected the endpoints under the issuer, got %s %s", p.Issuer, p.TokenURL)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	if _, err := ParseKey([]byte("not a key")); err == nil {
+		t.Error("expected a key which is not PEM to fail")
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	p := newProvider(t)
+
+	token, err := p.sign(accessTokenType, map[string]interface{}{"sub": "7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := p.verify(accessTokenType, token)
+	if err != nil || claims["sub"] != "7" {
+		t.Errorf("expected the claims back, got %v %v", claims, err)
+	}
+
+	if _, err := p.verify(idTokenType, token); err != ErrInvalidToken {
+		t.Errorf("expected an access token not to pass for an ID token, got %v", err)
+	}
+	parts := strings.Split(token, ".")
+	forged := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"1"}`)) + "." + parts[2]
+	if _, err := p.verify(accessTokenType, forged); err != ErrInvalidToken {
+		t.Errorf("expected forged claims to fail, got %v", err)
+	}
+
+	// after a rotation the previous key still verifies, and the new one signs
+	k := testKeys(t)
+	p.Keys = []*rsa.PrivateKey{k[1], k[0]}
+	if _, err := p.verify(accessTokenType, token); err != nil {
+		t.Errorf("expected a token of the previous key to verify, got %v", err)
+	}
+	rotated, _ := p.sign(accessTokenType, map[string]interface{}{"sub": "7"})
+	p.Keys = []*rsa.PrivateKey{k[0]}
+	if _, err := p.verify(accessTokenType, rotated); err != ErrInvalidToken {
+		t.Errorf("expected a token of an unknown key to fail, got %v", err)
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	p := newProvider(t)
+
+	rw := httptest.NewRecorder()
+	p.JWKSHandler(rw, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+
+	var set struct{ Keys []jwk }
+	if err := json.Unmarshal(rw.Body.Bytes(), &set); err != nil || len(set.Keys) != 1 {
+		t.Fatalf("expected a key set of one key, got %s %v", rw.Body, err)
+	}
+	key := set.Keys[0]
+	if key.Kty != "RSA" || key.Alg != "RS256" || key.Kid != KeyID(&p.Keys[0].PublicKey) {
+		t.Errorf("expected an RS256 key with its id, got %+v", key)
+	}
+	n, _ := base64.RawURLEncoding.DecodeString(key.N)
+	e, _ := base64.RawURLEncoding.DecodeString(key.E)
+	if new(big.Int).SetBytes(n).Cmp(p.Keys[0].N) != 0 || new(big.Int).SetBytes(e).Int64() != int64(p.Keys[0].E) {
+		t.Error("expected the modulus and exponent of the public key")
+	}
+}
+
+func TestChallenge(t *testing.T) {
+	// the example of RFC 7636, appendix B
+	g := &grant{Challenge: "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"}
+	if !g.verifies("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk") {
+		t.Error("expected the verifier of the challenge")
+	}
+	if g.verifies("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXx") || g.verifies("short") {
+		t.Error("expected other verifiers to fail")
+	}
+}
+
+func TestIssue(t *testing.T) {
+	p := newProvider(t)
+	p.Claims = func(ctx context.Context, u *auth.User, scopes []string) (map[string]interface{}, error) {
+		return map[string]interface{}{"roles": []string{"admin"}, "iss": "https://evil.example.com"}, nil
+	}
+	u := &auth.User{ID: 7, FirstName: "Ann", LastName: "Lee", Email: "ann@example.com"}
+	g := &grant{ClientID: "client", UserID: 7, Scopes: []string{OpenID, Email}, Nonce: "n-0S6"}
+
+	tokens, err := p.issue(context.Background(), g, u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens.TokenType != "Bearer" || tokens.ExpiresIn != 3600 || tokens.Scope != "openid email" {
+		t.Errorf("unexpected tokens %+v", tokens)
+	}
+
+	id, err := p.verify(idTokenType, tokens.IDToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id["iss"] != "https://id.example.com" || id["aud"] != "client" || id["sub"] != "7" || id["nonce"] != "n-0S6" {
+		t.Errorf("expected the registered claims, got %v", id)
+	}
+	if id["email"] != "ann@example.com" || id["name"] != nil || id["roles"] == nil {
+		t.Errorf("expected the claims of the scopes and the app, got %v", id)
+	}
+
+	at, err := p.VerifyAccessToken(tokens.AccessToken)
+	if err != nil || at.UserID != 7 || at.ClientID != "client" || !at.Can(Email) || at.Can(Profile) {
+		t.Errorf("expected the access token of the grant, got %+v %v", at, err)
+	}
+	if _, err := p.VerifyAccessToken(tokens.IDToken); err != ErrInvalidToken {
+		t.Errorf("expected an ID token not to be taken for an access token, got %v", err)
+	}
+
+	p.Clock.(*clock.Test).Advance(time.Hour)
+	if _, err := p.VerifyAccessToken(tokens.AccessToken); err != ErrExpiredToken {
+		t.Errorf("expected the token to expire, got %v", err)
+	}
+
+	other := New(nil, "postgres", nil, "https://other.example.com", p.Keys...)
+	if _, err := other.VerifyAccessToken(tokens.AccessToken); err != ErrInvalidToken {
+		t.Errorf("expected the token of another issuer to fail, got %v", err)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	p := newProvider(t)
+	tokens, err := p.issue(context.Background(), &grant{ClientID: "client", Scopes: []string{OpenID}}, &auth.User{ID: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := p.Authenticate(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if tk := TokenFrom(r.Context()); tk == nil || tk.UserID != 3 {
+			t.Errorf("expected the token in the context, got %+v", tk)
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "/api/me", nil))
+	if rw.Code != http.StatusUnauthorized || rw.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("expected a 401 without a token, got %d", rw.Code)
+	}
+
+	r := httptest.NewRequest("GET", "/api/me", nil)
+	r.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, r)
+	if rw.Code != http.StatusNoContent {
+		t.Errorf("expected the token to be let through, got %d", rw.Code)
+	}
+}
+
+func TestTokenHandlerGrantType(t *testing.T) {
+	p := newProvider(t)
+
+	r := httptest.NewRequest("POST", "/api/oauth/token", strings.NewReader(url.Values{"grant_type": {"password"}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rw := httptest.NewRecorder()
+	p.TokenHandler(rw, r)
+
+	if rw.Code != http.StatusBadRequest || !strings.Contains(rw.Body.String(), "unsupported_grant_type") {
+		t.Errorf("expected unsupported_grant_type, got %d %s", rw.Code, rw.Body)
+	}
+	if rw.Header().Get("Cache-Control") != "no-store" {
+		t.Error("expected the answer not to be cached")
+	}
+}
+
+func TestRedirect(t *testing.T) {
+	req := &request{RedirectURI: "https://app.example.com/callback?tenant=a", State: "xyz"}
+	rw := httptest.NewRecorder()
+	redirect(rw, httptest.NewRequest("GET", "/oauth/authorize", nil), req, url.Values{"code": {"abc"}})
+
+	if rw.Code != http.StatusFound || rw.Header().Get("Location") != "https://app.example.com/callback?tenant=a&code=abc&state=xyz" {
+		t.Errorf("unexpected redirect %d %s", rw.Code, rw.Header().Get("Location"))
+	}
+}
+
+func TestFail(t *testing.T) {
+	p := newProvider(t)
+	r := httptest.NewRequest("GET", "/oauth/authorize", nil)
+
+	// an unknown client is never redirected to
+	rw := httptest.NewRecorder()
+	p.fail(rw, r, nil, ErrRedirect)
+	if rw.Code != http.StatusBadRequest {
+		t.Errorf("expected a 400 for the user, got %d", rw.Code)
+	}
+
+	rw = httptest.NewRecorder()
+	p.fail(rw, r, &request{RedirectURI: "https://app.example.com/cb"}, &authError{"invalid_scope", "unknown scope admin"})
+	loc, _ := url.Parse(rw.Header().Get("Location"))
+	if loc.Query().Get("error") != "invalid_scope" || loc.Query().Get("error_description") != "unknown scope admin" {
+		t.Errorf("expected the error sent to the client, got %s", loc)
+	}
+}
+
+func TestDiscovery(t *testing.T) {
+	d := newProvider(t).Discovery()
+	if d["issuer"] != "https://id.example.com" || d["jwks_uri"] != "https://id.example.com/.well-known/jwks.json" {
+		t.Errorf("unexpected discovery %v", d)
+	}
+	if scopes := d["scopes_supported"].([]string); strings.Join(scopes, " ") != "email openid profile" {
+		t.Errorf("expected the sorted scopes, got %v", scopes)
+	}
+}
+
+func TestAllowsRedirect(t *testing.T) {
+	c := &Client{RedirectURIs: []string{"https://app.example.com/cb"}}
+	if !c.AllowsRedirect("https://app.example.com/cb") || c.AllowsRedirect("https://app.example.com/cb/") || c.AllowsRedirect("https://evil.example.com/cb") {
+		t.Error("expected the redirect uris to be matched exactly")
+	}
+}
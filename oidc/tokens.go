@@ -0,0 +1,183 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/namnguyen191/goravel/auth"
+	"github.com/namnguyen191/goravel/clock"
+)
+
+// The types of the tokens in their JWT header, so an ID token is never taken for an access token
+const (
+	idTokenType     = "JWT"
+	accessTokenType = "at+jwt"
+)
+
+// ErrExpiredToken is returned for an access token past its expiry
+var ErrExpiredToken = errors.New("oidc: token expired")
+
+// AccessToken is an access token of the provider, sent by a client as "Authorization: Bearer <token>"
+type AccessToken struct {
+	UserID    int
+	ClientID  string
+	Scopes    []string
+	ExpiresAt time.Time
+}
+
+// Can reports whether the user let the client of the token have scope
+func (t *AccessToken) Can(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Tokens is the answer of the token endpoint
+type Tokens struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	IDToken     string `json:"id_token"`
+	Scope       string `json:"scope"`
+}
+
+// userClaims returns the claims about u the client may have with scopes, with the ones of Claims
+func (p *Provider) userClaims(ctx context.Context, u *auth.User, scopes []string) (map[string]interface{}, error) {
+	claims := map[string]interface{}{"sub": strconv.Itoa(u.ID)}
+	if covers(scopes, []string{Profile}) {
+		claims["name"] = strings.TrimSpace(u.FirstName + " " + u.LastName)
+		claims["given_name"] = u.FirstName
+		claims["family_name"] = u.LastName
+		claims["updated_at"] = u.UpdatedAt.Unix()
+	}
+	if covers(scopes, []string{Email}) {
+		claims["email"] = u.Email
+	}
+
+	if p.Claims != nil {
+		extra, err := p.Claims(ctx, u, scopes)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range extra {
+			claims[k] = v
+		}
+	}
+	return claims, nil
+}
+
+// issue returns the access and ID tokens of g for u
+func (p *Provider) issue(ctx context.Context, g *grant, u *auth.User) (*Tokens, error) {
+	now := clock.Now(p.Clock)
+	expires := now.Add(p.TokenTTL)
+
+	jti, err := random(16)
+	if err != nil {
+		return nil, err
+	}
+	access, err := p.sign(accessTokenType, map[string]interface{}{
+		"iss":       p.Issuer,
+		"sub":       strconv.Itoa(u.ID),
+		"aud":       g.ClientID,
+		"client_id": g.ClientID,
+		"scope":     strings.Join(g.Scopes, " "),
+		"iat":       now.Unix(),
+		"exp":       expires.Unix(),
+		"jti":       jti,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := p.userClaims(ctx, u, g.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	// the registered claims are set last, so the ones of the app cannot replace them
+	claims["iss"] = p.Issuer
+	claims["sub"] = strconv.Itoa(u.ID)
+	claims["aud"] = g.ClientID
+	claims["iat"] = now.Unix()
+	claims["exp"] = expires.Unix()
+	if g.Nonce != "" {
+		claims["nonce"] = g.Nonce
+	}
+	id, err := p.sign(idTokenType, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tokens{
+		AccessToken: access,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(p.TokenTTL.Seconds()),
+		IDToken:     id,
+		Scope:       strings.Join(g.Scopes, " "),
+	}, nil
+}
+
+// VerifyAccessToken returns the access token of token, checking its signature, issuer and expiry
+func (p *Provider) VerifyAccessToken(token string) (*AccessToken, error) {
+	claims, err := p.verify(accessTokenType, token)
+	if err != nil {
+		return nil, err
+	}
+	if iss, _ := claims["iss"].(string); iss != p.Issuer {
+		return nil, ErrInvalidToken
+	}
+
+	sub, _ := claims["sub"].(string)
+	userID, err := strconv.Atoi(sub)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	exp, _ := claims["exp"].(float64)
+	t := &AccessToken{UserID: userID, ExpiresAt: time.Unix(int64(exp), 0)}
+	t.ClientID, _ = claims["client_id"].(string)
+	scope, _ := claims["scope"].(string)
+	t.Scopes = strings.Fields(scope)
+
+	if !clock.Now(p.Clock).Before(t.ExpiresAt) {
+		return nil, ErrExpiredToken
+	}
+	return t, nil
+}
+
+type tokenKey struct{}
+
+// TokenFrom returns the access token of the request context set by Authenticate, or nil
+func TokenFrom(ctx context.Context) *AccessToken {
+	t, _ := ctx.Value(tokenKey{}).(*AccessToken)
+	return t
+}
+
+// bearer returns the token of the Authorization header of r
+func bearer(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(header[7:])
+}
+
+// Authenticate lets through the requests with a valid access token of the provider, putting it
+// in the request context for TokenFrom, e.g. for the API of the app the clients call on
+// behalf of their users
+func (p *Provider) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		t, err := p.VerifyAccessToken(bearer(r))
+		if err != nil {
+			rw.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), tokenKey{}, t)))
+	})
+}
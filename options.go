@@ -0,0 +1,77 @@
+package goravel
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/namnguyen191/goravel/boot"
+	"github.com/namnguyen191/goravel/cache"
+	"github.com/namnguyen191/goravel/clock"
+)
+
+// Option changes what New starts, so CLI tools, workers and tests boot only what they need:
+//
+//	err := app.New(path, goravel.WithoutMailer(), goravel.WithoutViews())
+type Option func(grv *Goravel)
+
+// Without skips boot steps by name, see bootSteps, and the steps which require them
+func Without(steps ...string) Option {
+	return func(grv *Goravel) {
+		grv.Boot.Remove(steps...)
+	}
+}
+
+// WithoutMailer skips the mailer and its listener goroutine
+func WithoutMailer() Option {
+	return Without("mail")
+}
+
+// WithoutViews skips loading the views, along with the features rendering pages such as the
+// admin panel
+func WithoutViews() Option {
+	return Without("views")
+}
+
+// WithoutWorkers skips the goroutines sending the queued mail and sms
+func WithoutWorkers() Option {
+	return Without("workers")
+}
+
+// WithCache uses c instead of the cache configured with CACHE
+func WithCache(c cache.Cache) Option {
+	return func(grv *Goravel) {
+		grv.Boot.Replace(step("cache", func() error {
+			grv.Cache = c
+			return nil
+		}))
+	}
+}
+
+// WithClock uses c as the clock of the app, e.g. a clock.Test making expiries deterministic;
+// the scheduler runs in the location of its times
+func WithClock(c clock.Clock) Option {
+	return func(grv *Goravel) {
+		grv.Boot.Replace(step("clock", func() error {
+			grv.Clock = c
+			return nil
+		}))
+	}
+}
+
+// WithRouter uses mux instead of the router with the framework middleware
+func WithRouter(mux *chi.Mux) Option {
+	return func(grv *Goravel) {
+		grv.Boot.Replace(step("routes", func() error {
+			grv.meta = grv.createMeta()
+			grv.Routes = mux
+			return nil
+		}))
+	}
+}
+
+// WithStep adds a step of the app, e.g. connecting to a search server after the database:
+//
+//	goravel.WithStep(boot.Step{Name: "search", After: []string{"db"}, Run: connectSearch})
+func WithStep(s boot.Step) Option {
+	return func(grv *Goravel) {
+		grv.Boot.Add(s)
+	}
+}
@@ -0,0 +1,367 @@
+package pagecache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/namnguyen191/goravel/cache"
+	"github.com/namnguyen191/goravel/clock"
+)
+
+// Lifetime is how long a response may be served from a shared cache, read from Cache-Control
+type Lifetime struct {
+	MaxAge time.Duration
+	// StaleWhileRevalidate is how long after MaxAge the page is served while it is refreshed
+	StaleWhileRevalidate time.Duration
+	// StaleIfError is how long after MaxAge the page is served when refreshing it fails
+	StaleIfError time.Duration
+}
+
+// Parse reads the lifetime of a response marked public; responses which are private,
+// no-store or without a max-age are not shareable
+func Parse(h http.Header) (Lifetime, bool) {
+	cc := strings.ToLower(h.Get("Cache-Control"))
+	if !strings.Contains(cc, "public") || strings.Contains(cc, "no-store") || strings.Contains(cc, "private") {
+		return Lifetime{}, false
+	}
+
+	maxAge, sharedMaxAge, swr, sie := -1, -1, -1, -1
+	for _, directive := range strings.Split(cc, ",") {
+		directive = strings.TrimSpace(directive)
+		_, _ = fmt.Sscanf(directive, "s-maxage=%d", &sharedMaxAge)
+		_, _ = fmt.Sscanf(directive, "max-age=%d", &maxAge)
+		_, _ = fmt.Sscanf(directive, "stale-while-revalidate=%d", &swr)
+		_, _ = fmt.Sscanf(directive, "stale-if-error=%d", &sie)
+	}
+
+	// shared caches prefer s-maxage over max-age
+	if sharedMaxAge >= 0 {
+		maxAge = sharedMaxAge
+	}
+
+	l := Lifetime{MaxAge: time.Duration(maxAge) * time.Second, StaleWhileRevalidate: -1, StaleIfError: -1}
+	if swr >= 0 {
+		l.StaleWhileRevalidate = time.Duration(swr) * time.Second
+	}
+	if sie >= 0 {
+		l.StaleIfError = time.Duration(sie) * time.Second
+	}
+
+	return l, maxAge > 0
+}
+
+// page is a cached response
+type page struct {
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+	Stored   time.Time   `json:"stored"`
+	Lifetime Lifetime    `json:"lifetime"`
+}
+
+// age is how long ago the page was stored
+func (pc *PageCache) age(p *page) time.Duration {
+	return clock.Since(pc.Clock, p.Stored)
+}
+
+// PageCache keeps public pages, as marked by their Cache-Control header, in a cache store.
+// Stale pages are served right away and refreshed in the background, so a burst of traffic
+// on an expired page renders it once, off the request path.
+type PageCache struct {
+	Cache cache.Cache
+	// StaleWhileRevalidate and StaleIfError apply to responses without their own directives
+	StaleWhileRevalidate time.Duration
+	StaleIfError         time.Duration
+	// Key identifies a page; by default its host, path and query
+	Key func(r *http.Request) string
+	// BypassCookie names a cookie, usually the session cookie, whose requests skip the cache
+	BypassCookie string
+	ErrorLog     func(v ...interface{})
+	// Clock tells how old the stored pages are; the time of the machine when nil
+	Clock clock.Clock
+
+	mu         sync.Mutex
+	refreshing map[string]bool
+}
+
+// New returns a page cache storing pages in c
+func New(c cache.Cache) *PageCache {
+	return &PageCache{
+		Cache:                c,
+		StaleWhileRevalidate: time.Minute,
+		StaleIfError:         time.Hour,
+		ErrorLog:             log.Println,
+		refreshing:           map[string]bool{},
+	}
+}
+
+func (pc *PageCache) key(r *http.Request) string {
+	if pc.Key != nil {
+		return pc.Key(r)
+	}
+	return "page:" + r.Host + r.URL.RequestURI()
+}
+
+// cacheable requests are anonymous reads; pages of logged in users are never shared
+func (pc *PageCache) cacheable(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if r.Header.Get("Authorization") != "" {
+		return false
+	}
+	if pc.BypassCookie != "" {
+		if _, err := r.Cookie(pc.BypassCookie); err == nil {
+			return false
+		}
+	}
+	return !strings.Contains(strings.ToLower(r.Header.Get("Cache-Control")), "no-cache")
+}
+
+func (pc *PageCache) load(ctx context.Context, key string) *page {
+	v, err := cache.Get(ctx, pc.Cache, key)
+	if err != nil || v == nil {
+		return nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return nil
+	}
+
+	var p page
+	if err := json.Unmarshal([]byte(s), &p); err != nil {
+		return nil
+	}
+	return &p
+}
+
+func (pc *PageCache) lifetime(h http.Header) (Lifetime, bool) {
+	l, ok := Parse(h)
+	if !ok {
+		return l, false
+	}
+	if l.StaleWhileRevalidate < 0 {
+		l.StaleWhileRevalidate = pc.StaleWhileRevalidate
+	}
+	if l.StaleIfError < 0 {
+		l.StaleIfError = pc.StaleIfError
+	}
+	return l, true
+}
+
+func (pc *PageCache) store(key string, rec *recorder) {
+	if rec.status != http.StatusOK || rec.header.Get("Set-Cookie") != "" {
+		return
+	}
+
+	l, ok := pc.lifetime(rec.header)
+	if !ok {
+		return
+	}
+
+	b, err := json.Marshal(page{Status: rec.status, Header: rec.header, Body: rec.body.Bytes(), Stored: clock.Now(pc.Clock), Lifetime: l})
+	if err != nil {
+		return
+	}
+
+	keep := l.StaleWhileRevalidate
+	if l.StaleIfError > keep {
+		keep = l.StaleIfError
+	}
+
+	if err := pc.Cache.Set(key, string(b), int((l.MaxAge+keep)/time.Second)); err != nil {
+		pc.ErrorLog("pagecache:", err)
+	}
+}
+
+// render runs the handler into a recorder; panics are turned into a 500 so stale pages can be served
+func (pc *PageCache) render(next http.Handler, r *http.Request) (rec *recorder) {
+	rec = newRecorder()
+	defer func() {
+		if v := recover(); v != nil {
+			pc.ErrorLog("pagecache: panic rendering", r.URL.Path, v)
+			rec = newRecorder()
+			rec.WriteHeader(http.StatusInternalServerError)
+		}
+	}()
+
+	next.ServeHTTP(rec, r)
+	return rec
+}
+
+// refresh renders the page again in the background, once per key at a time
+func (pc *PageCache) refresh(next http.Handler, r *http.Request, key string) {
+	pc.mu.Lock()
+	if pc.refreshing[key] {
+		pc.mu.Unlock()
+		return
+	}
+	pc.refreshing[key] = true
+	pc.mu.Unlock()
+
+	bg := r.Clone(context.Background())
+	bg.Method = http.MethodGet
+	go func() {
+		defer func() {
+			pc.mu.Lock()
+			delete(pc.refreshing, key)
+			pc.mu.Unlock()
+		}()
+
+		pc.store(key, pc.render(next, bg))
+	}()
+}
+
+// Middleware serves cached public pages, refreshing stale ones in the background. Responses
+// to anonymous GET requests are buffered to be cached, so mount it on page routes rather than
+// on streamed responses or downloads.
+func (pc *PageCache) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if !pc.cacheable(r) {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		key := pc.key(r)
+		p := pc.load(r.Context(), key)
+
+		if p != nil {
+			age := pc.age(p)
+			switch {
+			case age < p.Lifetime.MaxAge:
+				pc.serve(rw, r, p, "HIT")
+				return
+			case age < p.Lifetime.MaxAge+p.Lifetime.StaleWhileRevalidate:
+				pc.refresh(next, r, key)
+				pc.serve(rw, r, p, "STALE")
+				return
+			}
+		}
+
+		rec := pc.render(next, r)
+		if rec.status >= 500 && p != nil && pc.age(p) < p.Lifetime.MaxAge+p.Lifetime.StaleIfError {
+			pc.serve(rw, r, p, "STALE")
+			return
+		}
+
+		// HEAD responses have no body to keep
+		if r.Method == http.MethodGet {
+			pc.store(key, rec)
+		}
+		rec.copyTo(rw, "MISS")
+	})
+}
+
+// Forget drops a page cached with the default key, e.g. after its content changed
+func (pc *PageCache) Forget(host, path string) error {
+	return pc.Cache.Forget("page:" + host + path)
+}
+
+// Warm renders the paths through the handler into the cache, e.g. the pages of the sitemap
+// after a deploy. base is the scheme and host the pages are requested with.
+func (pc *PageCache) Warm(h http.Handler, base string, paths ...string) error {
+	u, err := url.Parse(base)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		r, err := http.NewRequest(http.MethodGet, base+path, nil)
+		if err != nil {
+			return err
+		}
+		r.Host = u.Host
+
+		pc.store(pc.key(r), pc.render(h, r))
+	}
+
+	return nil
+}
+
+// SitemapPaths returns the paths of the urls listed in a sitemap
+func SitemapPaths(r io.Reader) ([]string, error) {
+	var sitemap struct {
+		URLs []struct {
+			Loc string `xml:"loc"`
+		} `xml:"url"`
+	}
+	if err := xml.NewDecoder(r).Decode(&sitemap); err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, u := range sitemap.URLs {
+		loc, err := url.Parse(strings.TrimSpace(u.Loc))
+		if err != nil {
+			continue
+		}
+		paths = append(paths, loc.RequestURI())
+	}
+
+	return paths, nil
+}
+
+func (pc *PageCache) serve(rw http.ResponseWriter, r *http.Request, p *page, state string) {
+	for k, v := range p.Header {
+		rw.Header()[k] = v
+	}
+	rw.Header().Set("Age", strconv.Itoa(int(pc.age(p)/time.Second)))
+	rw.Header().Set("X-Cache", state)
+	rw.WriteHeader(p.Status)
+
+	if r.Method != http.MethodHead {
+		rw.Write(p.Body)
+	}
+}
+
+// recorder buffers a response so it can be cached before it is sent
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+	wrote  bool
+}
+
+func newRecorder() *recorder {
+	return &recorder{header: http.Header{}, status: http.StatusOK}
+}
+
+func (rec *recorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if rec.wrote {
+		return
+	}
+	rec.status = status
+	rec.wrote = true
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	rec.wrote = true
+	return rec.body.Write(b)
+}
+
+func (rec *recorder) copyTo(rw http.ResponseWriter, state string) {
+	for k, v := range rec.header {
+		rw.Header()[k] = v
+	}
+	if _, ok := Parse(rec.header); ok {
+		rw.Header().Set("X-Cache", state)
+	}
+	rw.WriteHeader(rec.status)
+	rw.Write(rec.body.Bytes())
+}
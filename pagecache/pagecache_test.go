@@ -0,0 +1,119 @@
+package pagecache
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/namnguyen191/goravel/cache"
+)
+
+func TestParse(t *testing.T) {
+	h := http.Header{"Cache-Control": {"public, max-age=60, s-maxage=120, stale-while-revalidate=30"}}
+	l, ok := Parse(h)
+	if !ok || l.MaxAge != 2*time.Minute || l.StaleWhileRevalidate != 30*time.Second || l.StaleIfError != -1 {
+		t.Errorf("unexpected lifetime %+v", l)
+	}
+
+	if _, ok := Parse(http.Header{"Cache-Control": {"private, max-age=60"}}); ok {
+		t.Error("expected private responses not to be shareable")
+	}
+}
+
+// age moves a cached page back in time
+func age(t *testing.T, pc *PageCache, key string, by time.Duration) {
+	p := pc.load(context.Background(), key)
+	if p == nil {
+		t.Fatal("page not cached")
+	}
+	p.Stored = p.Stored.Add(-by)
+
+	b, _ := json.Marshal(p)
+	if err := pc.Cache.Set(key, string(b)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var renders, fail int32
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&renders, 1)
+		if atomic.LoadInt32(&fail) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Cache-Control", "public, max-age=60, stale-while-revalidate=60, stale-if-error=600")
+		w.Write([]byte(strings.Repeat("v", int(n))))
+	})
+
+	pc := New(cache.NewMemoryCache("test"))
+	srv := pc.Middleware(h)
+	get := func(cookie bool) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "http://example.com/home", nil)
+		if cookie {
+			r.AddCookie(&http.Cookie{Name: "session", Value: "x"})
+		}
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, r)
+		return rr
+	}
+
+	if rr := get(false); rr.Header().Get("X-Cache") != "MISS" || rr.Body.String() != "v" {
+		t.Fatalf("expected a miss, got %s %q", rr.Header().Get("X-Cache"), rr.Body.String())
+	}
+	if rr := get(false); rr.Header().Get("X-Cache") != "HIT" || rr.Body.String() != "v" {
+		t.Fatalf("expected a hit, got %s", rr.Header().Get("X-Cache"))
+	}
+
+	pc.BypassCookie = "session"
+	if rr := get(true); rr.Header().Get("X-Cache") == "HIT" {
+		t.Error("expected requests with the session cookie to bypass the cache")
+	}
+	pc.BypassCookie = ""
+
+	// past max-age the stale page is served and refreshed in the background
+	age(t, pc, "page:example.com/home", 90*time.Second)
+	if rr := get(false); rr.Header().Get("X-Cache") != "STALE" || rr.Body.String() != "v" {
+		t.Fatalf("expected the stale page, got %s %q", rr.Header().Get("X-Cache"), rr.Body.String())
+	}
+	for i := 0; i < 100 && pc.age(pc.load(context.Background(), "page:example.com/home")) > time.Minute; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if rr := get(false); rr.Header().Get("X-Cache") != "HIT" || rr.Body.String() != "vvv" {
+		t.Fatalf("expected the refreshed page, got %s %q", rr.Header().Get("X-Cache"), rr.Body.String())
+	}
+
+	// past the revalidation window a failing render falls back to the stale page
+	atomic.StoreInt32(&fail, 1)
+	age(t, pc, "page:example.com/home", 5*time.Minute)
+	if rr := get(false); rr.Code != http.StatusOK || rr.Header().Get("X-Cache") != "STALE" || rr.Body.String() != "vvv" {
+		t.Fatalf("expected the stale page on error, got %d %s", rr.Code, rr.Header().Get("X-Cache"))
+	}
+}
+
+func TestWarm(t *testing.T) {
+	sitemap := `<?xml version="1.0"?><urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+		<url><loc>https://example.com/</loc></url><url><loc>https://example.com/about?x=1</loc></url></urlset>`
+
+	paths, err := SitemapPaths(strings.NewReader(sitemap))
+	if err != nil || len(paths) != 2 || paths[1] != "/about?x=1" {
+		t.Fatalf("unexpected paths %v %v", paths, err)
+	}
+
+	pc := New(cache.NewMemoryCache("test"))
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		w.Write([]byte(r.URL.Path))
+	})
+	if err := pc.Warm(h, "https://example.com", paths...); err != nil {
+		t.Fatal(err)
+	}
+	if p := pc.load(context.Background(), "page:example.com/about?x=1"); p == nil || string(p.Body) != "/about" {
+		t.Errorf("expected the page to be warmed, got %+v", p)
+	}
+}
@@ -0,0 +1,32 @@
+package goravel
+
+import (
+	"os"
+	"strings"
+
+	"github.com/namnguyen191/goravel/payments"
+)
+
+// createPayments builds payments from PAYMENTS_DRIVER; only stripe is built in.
+// Provider calls go through the "payments" circuit breaker.
+func (grv *Goravel) createPayments() *payments.Payments {
+	var provider payments.Provider
+
+	switch strings.ToLower(os.Getenv("PAYMENTS_DRIVER")) {
+	case "stripe":
+		provider = &payments.Stripe{
+			Key:           os.Getenv("STRIPE_KEY"),
+			WebhookSecret: os.Getenv("STRIPE_WEBHOOK_SECRET"),
+			Client:        grv.HTTPClient("payments"),
+			Clock:         grv.Clock,
+		}
+	default:
+		return nil
+	}
+
+	p := payments.New(provider, grv.DB.Pool, grv.DB.DataBaseType)
+	p.ErrorLog = grv.ErrorLog.Println
+	p.Clock = grv.Clock
+
+	return p
+}
@@ -0,0 +1,313 @@
+package payments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+	"github.com/namnguyen191/goravel/money"
+)
+
+// ErrInvalidSignature is returned for webhook requests that were not signed by the provider
+var ErrInvalidSignature = errors.New("payments: invalid webhook signature")
+
+// The kinds of events handlers are called for
+const (
+	PaymentSucceeded    = "payment.succeeded"
+	PaymentFailed       = "payment.failed"
+	SubscriptionUpdated = "subscription.updated"
+	SubscriptionDeleted = "subscription.deleted"
+)
+
+// Provider is a payment service; Stripe is the built-in driver
+type Provider interface {
+	Name() string
+	CreateCheckout(ctx context.Context, c Checkout) (*Session, error)
+	// ParseWebhook verifies and decodes a webhook request; events the module does not use
+	// are returned with an empty Kind
+	ParseWebhook(r *http.Request) (*Event, error)
+}
+
+// Item is a checkout line: either a provider price id or an ad hoc amount
+type Item struct {
+	Price    string
+	Name     string
+	Amount   money.Money
+	Quantity int
+}
+
+// Checkout describes a hosted checkout page
+type Checkout struct {
+	// Subscription starts a subscription instead of a one-off payment
+	Subscription bool
+	// TrialDays delays the first charge of a subscription
+	TrialDays  int
+	Items      []Item
+	UserID     int
+	Email      string
+	SuccessURL string
+	CancelURL  string
+	Metadata   map[string]string
+}
+
+// Session is a created checkout; users are redirected to URL
+type Session struct {
+	ID  string
+	URL string
+}
+
+// Event is a payment event normalized across providers
+type Event struct {
+	ID             string
+	Type           string
+	Kind           string
+	UserID         int
+	CustomerID     string
+	Email          string
+	SubscriptionID string
+	Status         string
+	Price          string
+	Quantity       int
+	PeriodEnd      time.Time
+	Amount         money.Money
+	Raw            []byte
+}
+
+// Invoice is an invoice issued by the provider
+type Invoice struct {
+	ID      string
+	Number  string
+	Status  string
+	Total   money.Money
+	Created time.Time
+	// URL is the provider's hosted invoice page, PDF its download
+	URL string
+	PDF string
+}
+
+// Payments creates checkouts, receives webhooks and keeps customer and subscription records
+type Payments struct {
+	Provider     Provider
+	DB           *sql.DB
+	DatabaseType string
+	ErrorLog     func(v ...interface{})
+	// Clock dates the recorded events, customers and subscriptions; the time of the machine when nil
+	Clock    clock.Clock
+	handlers map[string][]func(Event)
+}
+
+// New returns payments through provider, recorded in db
+func New(provider Provider, db *sql.DB, dbType string) *Payments {
+	return &Payments{
+		Provider:     provider,
+		DB:           db,
+		DatabaseType: dbType,
+		ErrorLog:     log.Println,
+		handlers:     map[string][]func(Event){},
+	}
+}
+
+// On registers a handler for an event kind, e.g. On(payments.PaymentSucceeded, fulfil)
+func (p *Payments) On(kind string, handler func(Event)) {
+	p.handlers[kind] = append(p.handlers[kind], handler)
+}
+
+// Checkout creates a hosted checkout session
+func (p *Payments) Checkout(ctx context.Context, c Checkout) (*Session, error) {
+	return p.Provider.CreateCheckout(ctx, c)
+}
+
+// WebhookHandler receives the provider's webhooks. Each event is stored first, so retried
+// deliveries are acknowledged without running the handlers twice.
+func (p *Payments) WebhookHandler(rw http.ResponseWriter, r *http.Request) {
+	e, err := p.Provider.ParseWebhook(r)
+	if err != nil {
+		if !errors.Is(err, ErrInvalidSignature) {
+			p.ErrorLog("payments:", err)
+		}
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	if e.Kind == "" {
+		rw.WriteHeader(http.StatusOK)
+		return
+	}
+
+	fresh, err := p.record(r.Context(), e)
+	if err != nil {
+		p.ErrorLog("payments:", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if fresh {
+		for _, h := range p.handlers[e.Kind] {
+			h(*e)
+		}
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+// record stores the event and updates the customer and subscription it refers to.
+// It reports false for events seen before. Records are skipped without a database.
+func (p *Payments) record(ctx context.Context, e *Event) (bool, error) {
+	if p.DB == nil {
+		return true, nil
+	}
+
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var seen int
+	err = tx.QueryRowContext(ctx, p.rebind("select count(*) from payment_events where provider = ? and event_id = ?"), p.Provider.Name(), e.ID).Scan(&seen)
+	if err != nil {
+		return false, err
+	}
+	if seen > 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, p.rebind("insert into payment_events (provider, event_id, kind, created_at) values (?, ?, ?, ?)"),
+		p.Provider.Name(), e.ID, e.Kind, clock.Now(p.Clock))
+	if err != nil {
+		return false, err
+	}
+
+	if e.CustomerID != "" {
+		if err := p.saveCustomer(ctx, tx, e); err != nil {
+			return false, err
+		}
+	}
+
+	if e.SubscriptionID != "" && e.Status != "" {
+		if err := p.saveSubscription(ctx, tx, e); err != nil {
+			return false, err
+		}
+	}
+
+	return true, tx.Commit()
+}
+
+func (p *Payments) saveCustomer(ctx context.Context, tx *sql.Tx, e *Event) error {
+	var userID int
+	err := tx.QueryRowContext(ctx, p.rebind("select user_id from payment_customers where provider = ? and customer_id = ?"), p.Provider.Name(), e.CustomerID).Scan(&userID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if e.UserID == 0 {
+			return nil
+		}
+		_, err = tx.ExecContext(ctx, p.rebind("insert into payment_customers (user_id, provider, customer_id, email, created_at) values (?, ?, ?, ?, ?)"),
+			e.UserID, p.Provider.Name(), e.CustomerID, e.Email, clock.Now(p.Clock))
+		return err
+	case err != nil:
+		return err
+	}
+
+	// later events often carry only the customer id
+	if e.UserID == 0 {
+		e.UserID = userID
+	}
+
+	return nil
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Update stores the subscription state carried by e, as fetched when syncing with the provider
+func (p *Payments) Update(ctx context.Context, e *Event) error {
+	return p.saveSubscription(ctx, p.DB, e)
+}
+
+func (p *Payments) saveSubscription(ctx context.Context, tx execer, e *Event) error {
+	res, err := tx.ExecContext(ctx, p.rebind("update payment_subscriptions set status = ?, price = ?, quantity = ?, period_end = ?, updated_at = ? where provider = ? and subscription_id = ?"),
+		e.Status, e.Price, e.Quantity, nullTime(e.PeriodEnd), clock.Now(p.Clock), p.Provider.Name(), e.SubscriptionID)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n > 0 {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, p.rebind("insert into payment_subscriptions (user_id, provider, customer_id, subscription_id, status, price, quantity, period_end, updated_at) values (?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+		e.UserID, p.Provider.Name(), e.CustomerID, e.SubscriptionID, e.Status, e.Price, e.Quantity, nullTime(e.PeriodEnd), clock.Now(p.Clock))
+
+	return err
+}
+
+// Customer returns the provider customer id of a user, empty when they never paid
+func (p *Payments) Customer(ctx context.Context, userID int) (string, error) {
+	var id string
+	err := p.DB.QueryRowContext(ctx, p.rebind("select customer_id from payment_customers where provider = ? and user_id = ?"), p.Provider.Name(), userID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	return id, err
+}
+
+// Subscription is a stored subscription record
+type Subscription struct {
+	ID         string
+	UserID     int
+	CustomerID string
+	Status     string
+	Price      string
+	Quantity   int
+	PeriodEnd  sql.NullTime
+}
+
+// Active reports whether the subscription is paid for
+func (s Subscription) Active() bool {
+	return s.Status == "active" || s.Status == "trialing"
+}
+
+// Subscriptions returns the subscriptions of a user, most recently updated first
+func (p *Payments) Subscriptions(ctx context.Context, userID int) ([]Subscription, error) {
+	return p.subscriptions(ctx, "where provider = ? and user_id = ? order by updated_at desc", p.Provider.Name(), userID)
+}
+
+// Current returns the subscriptions that are not canceled yet, the ones worth syncing
+func (p *Payments) Current(ctx context.Context) ([]Subscription, error) {
+	return p.subscriptions(ctx, "where provider = ? and status <> ?", p.Provider.Name(), "canceled")
+}
+
+func (p *Payments) subscriptions(ctx context.Context, where string, args ...interface{}) ([]Subscription, error) {
+	rows, err := p.DB.QueryContext(ctx, p.rebind("select subscription_id, user_id, customer_id, status, price, quantity, period_end from payment_subscriptions "+where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []Subscription
+	for rows.Next() {
+		var s Subscription
+		if err := rows.Scan(&s.ID, &s.UserID, &s.CustomerID, &s.Status, &s.Price, &s.Quantity, &s.PeriodEnd); err != nil {
+			return nil, err
+		}
+		subs = append(subs, s)
+	}
+
+	return subs, rows.Err()
+}
+
+func (p *Payments) rebind(query string) string {
+	return database.Rebind(p.DatabaseType, query)
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,82 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/namnguyen191/goravel/money"
+)
+
+func sign(secret, body string) string {
+	ts := fmt.Sprint(time.Now().Unix())
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "." + body))
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestCheckout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if user, _, _ := r.BasicAuth(); user != "sk_test" || r.URL.Path != "/v1/checkout/sessions" {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Form.Get("mode") != "payment" || r.Form.Get("line_items[0][price_data][unit_amount]") != "4900" || r.Form.Get("client_reference_id") != "7" {
+			rw.WriteHeader(http.StatusBadRequest)
+			_, _ = rw.Write([]byte(`{"error":{"message":"bad form"}}`))
+			return
+		}
+		_, _ = rw.Write([]byte(`{"id":"cs_1","url":"https://checkout.stripe.com/c/cs_1"}`))
+	}))
+	defer srv.Close()
+
+	p := New(&Stripe{Key: "sk_test", Endpoint: srv.URL}, nil, "postgres")
+	s, err := p.Checkout(context.Background(), Checkout{
+		UserID: 7,
+		Items:  []Item{{Name: "Pro plan", Amount: money.New(4900, "USD")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != "cs_1" || !strings.HasPrefix(s.URL, "https://checkout.stripe.com") {
+		t.Errorf("unexpected session %+v", s)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	p := New(&Stripe{WebhookSecret: "whsec"}, nil, "postgres")
+
+	var got Event
+	p.On(PaymentSucceeded, func(e Event) { got = e })
+
+	body := `{"id":"evt_1","type":"checkout.session.completed","data":{"object":{"customer":"cus_1","client_reference_id":"7","payment_status":"paid","amount_total":4900,"currency":"usd"}}}`
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/stripe", strings.NewReader(body))
+	req.Header.Set("Stripe-Signature", sign("whsec", body))
+	rw := httptest.NewRecorder()
+	p.WebhookHandler(rw, req)
+
+	if rw.Code != http.StatusOK {
+		t.Fatal("expected 200, got", rw.Code)
+	}
+	if got.UserID != 7 || got.CustomerID != "cus_1" || got.Amount.String() != "$49.00" {
+		t.Errorf("unexpected event %+v", got)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/webhooks/stripe", strings.NewReader(body))
+	req.Header.Set("Stripe-Signature", sign("other", body))
+	rw = httptest.NewRecorder()
+	p.WebhookHandler(rw, req)
+
+	if rw.Code != http.StatusBadRequest {
+		t.Error("expected forged webhook to be refused, got", rw.Code)
+	}
+}
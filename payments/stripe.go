@@ -0,0 +1,376 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/money"
+)
+
+// Stripe is the Stripe driver, using Checkout for payments and signed webhooks for events
+type Stripe struct {
+	Key           string
+	WebhookSecret string
+	// Tolerance is how old a webhook signature may be; five minutes by default
+	Tolerance time.Duration
+	Client    *http.Client
+	// Endpoint is the address of the Stripe API, https://api.stripe.com by default
+	Endpoint string
+	// Clock tells how old a webhook signature is; the time of the machine when nil
+	Clock clock.Clock
+}
+
+func (s *Stripe) Name() string {
+	return "stripe"
+}
+
+func (s *Stripe) CreateCheckout(ctx context.Context, c Checkout) (*Session, error) {
+	form := url.Values{}
+	form.Set("mode", "payment")
+	if c.Subscription {
+		form.Set("mode", "subscription")
+	}
+	form.Set("success_url", c.SuccessURL)
+	form.Set("cancel_url", c.CancelURL)
+	if c.UserID != 0 {
+		form.Set("client_reference_id", strconv.Itoa(c.UserID))
+		form.Set("metadata[user_id]", strconv.Itoa(c.UserID))
+	}
+	if c.Subscription && c.TrialDays > 0 {
+		form.Set("subscription_data[trial_period_days]", strconv.Itoa(c.TrialDays))
+	}
+	if c.Email != "" {
+		form.Set("customer_email", c.Email)
+	}
+	for k, v := range c.Metadata {
+		form.Set("metadata["+k+"]", v)
+	}
+
+	for i, item := range c.Items {
+		key := fmt.Sprintf("line_items[%d]", i)
+		qty := item.Quantity
+		if qty == 0 {
+			qty = 1
+		}
+		form.Set(key+"[quantity]", strconv.Itoa(qty))
+
+		if item.Price != "" {
+			form.Set(key+"[price]", item.Price)
+			continue
+		}
+		form.Set(key+"[price_data][currency]", strings.ToLower(item.Amount.Currency))
+		form.Set(key+"[price_data][unit_amount]", strconv.FormatInt(item.Amount.Amount, 10))
+		form.Set(key+"[price_data][product_data][name]", item.Name)
+	}
+
+	var session Session
+	if err := s.do(ctx, http.MethodPost, "/v1/checkout/sessions", form, &session); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// Subscription fetches the current state of a subscription
+func (s *Stripe) Subscription(ctx context.Context, id string) (*Event, error) {
+	var o stripeObject
+	if err := s.do(ctx, http.MethodGet, "/v1/subscriptions/"+url.PathEscape(id), nil, &o); err != nil {
+		return nil, err
+	}
+
+	e := &Event{Type: "customer.subscription.sync", Kind: SubscriptionUpdated}
+	o.subscription(e)
+
+	return e, nil
+}
+
+// UpdateQuantity changes the seats of a subscription, prorated by Stripe
+func (s *Stripe) UpdateQuantity(ctx context.Context, id string, quantity int) error {
+	var o struct {
+		Items struct {
+			Data []struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		} `json:"items"`
+	}
+	if err := s.do(ctx, http.MethodGet, "/v1/subscriptions/"+url.PathEscape(id), nil, &o); err != nil {
+		return err
+	}
+	if len(o.Items.Data) == 0 {
+		return fmt.Errorf("payments: subscription %s has no items", id)
+	}
+
+	form := url.Values{}
+	form.Set("items[0][id]", o.Items.Data[0].ID)
+	form.Set("items[0][quantity]", strconv.Itoa(quantity))
+
+	return s.do(ctx, http.MethodPost, "/v1/subscriptions/"+url.PathEscape(id), form, &struct{}{})
+}
+
+// Cancel ends a subscription now, or at the end of the paid period
+func (s *Stripe) Cancel(ctx context.Context, id string, atPeriodEnd bool) error {
+	if atPeriodEnd {
+		form := url.Values{}
+		form.Set("cancel_at_period_end", "true")
+		return s.do(ctx, http.MethodPost, "/v1/subscriptions/"+url.PathEscape(id), form, &struct{}{})
+	}
+
+	return s.do(ctx, http.MethodDelete, "/v1/subscriptions/"+url.PathEscape(id), nil, &struct{}{})
+}
+
+// Invoices lists the most recent invoices of a customer
+func (s *Stripe) Invoices(ctx context.Context, customerID string) ([]Invoice, error) {
+	var list struct {
+		Data []struct {
+			ID               string `json:"id"`
+			Number           string `json:"number"`
+			Status           string `json:"status"`
+			Currency         string `json:"currency"`
+			Total            int64  `json:"total"`
+			Created          int64  `json:"created"`
+			HostedInvoiceURL string `json:"hosted_invoice_url"`
+			InvoicePDF       string `json:"invoice_pdf"`
+		} `json:"data"`
+	}
+
+	if err := s.do(ctx, http.MethodGet, "/v1/invoices?limit=24&customer="+url.QueryEscape(customerID), nil, &list); err != nil {
+		return nil, err
+	}
+
+	invoices := make([]Invoice, 0, len(list.Data))
+	for _, in := range list.Data {
+		invoices = append(invoices, Invoice{
+			ID:      in.ID,
+			Number:  in.Number,
+			Status:  in.Status,
+			Total:   money.New(in.Total, in.Currency),
+			Created: time.Unix(in.Created, 0),
+			URL:     in.HostedInvoiceURL,
+			PDF:     in.InvoicePDF,
+		})
+	}
+
+	return invoices, nil
+}
+
+func (s *Stripe) do(ctx context.Context, method, path string, form url.Values, out interface{}) error {
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = "https://api.stripe.com"
+	}
+
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint+path, body)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.Key, "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	b, err := ioutil.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 300 {
+		var e struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(b, &e)
+		return fmt.Errorf("payments: stripe returned %s: %s", res.Status, e.Error.Message)
+	}
+
+	return json.Unmarshal(b, out)
+}
+
+// stripeObject holds the fields used from checkout sessions, invoices, payment intents and subscriptions
+type stripeObject struct {
+	ID                string            `json:"id"`
+	Object            string            `json:"object"`
+	Customer          string            `json:"customer"`
+	Subscription      string            `json:"subscription"`
+	ClientReferenceID string            `json:"client_reference_id"`
+	Metadata          map[string]string `json:"metadata"`
+	CustomerEmail     string            `json:"customer_email"`
+	CustomerDetails   struct {
+		Email string `json:"email"`
+	} `json:"customer_details"`
+	Status           string `json:"status"`
+	PaymentStatus    string `json:"payment_status"`
+	Currency         string `json:"currency"`
+	Amount           int64  `json:"amount"`
+	AmountTotal      int64  `json:"amount_total"`
+	AmountPaid       int64  `json:"amount_paid"`
+	AmountDue        int64  `json:"amount_due"`
+	CurrentPeriodEnd int64  `json:"current_period_end"`
+	Items            struct {
+		Data []struct {
+			Price struct {
+				ID string `json:"id"`
+			} `json:"price"`
+			Quantity int `json:"quantity"`
+		} `json:"data"`
+	} `json:"items"`
+}
+
+func (s *Stripe) ParseWebhook(r *http.Request) (*Event, error) {
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.verify(body, r.Header.Get("Stripe-Signature")); err != nil {
+		return nil, err
+	}
+
+	var payload struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Data struct {
+			Object stripeObject `json:"object"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	o := payload.Data.Object
+	e := &Event{
+		ID:             payload.ID,
+		Type:           payload.Type,
+		CustomerID:     o.Customer,
+		SubscriptionID: o.Subscription,
+		Email:          o.CustomerDetails.Email,
+		Raw:            body,
+	}
+	if e.Email == "" {
+		e.Email = o.CustomerEmail
+	}
+
+	e.UserID, _ = strconv.Atoi(o.ClientReferenceID)
+	if e.UserID == 0 {
+		e.UserID, _ = strconv.Atoi(o.Metadata["user_id"])
+	}
+
+	currency := strings.ToUpper(o.Currency)
+
+	switch payload.Type {
+	case "checkout.session.completed":
+		if o.PaymentStatus == "paid" || o.PaymentStatus == "no_payment_required" {
+			e.Kind = PaymentSucceeded
+		}
+		e.Amount = money.New(o.AmountTotal, currency)
+	case "checkout.session.async_payment_succeeded":
+		e.Kind = PaymentSucceeded
+		e.Amount = money.New(o.AmountTotal, currency)
+	case "checkout.session.async_payment_failed":
+		e.Kind = PaymentFailed
+		e.Amount = money.New(o.AmountTotal, currency)
+	case "invoice.paid":
+		e.Kind = PaymentSucceeded
+		e.Amount = money.New(o.AmountPaid, currency)
+	case "invoice.payment_failed":
+		e.Kind = PaymentFailed
+		e.Amount = money.New(o.AmountDue, currency)
+	case "payment_intent.payment_failed":
+		e.Kind = PaymentFailed
+		e.Amount = money.New(o.Amount, currency)
+	case "customer.subscription.created", "customer.subscription.updated", "customer.subscription.deleted":
+		e.Kind = SubscriptionUpdated
+		if payload.Type == "customer.subscription.deleted" {
+			e.Kind = SubscriptionDeleted
+		}
+		o.subscription(e)
+	}
+
+	return e, nil
+}
+
+// subscription copies the state of a subscription object onto e
+func (o *stripeObject) subscription(e *Event) {
+	e.SubscriptionID = o.ID
+	e.CustomerID = o.Customer
+	e.Status = o.Status
+	if o.CurrentPeriodEnd > 0 {
+		e.PeriodEnd = time.Unix(o.CurrentPeriodEnd, 0)
+	}
+	if len(o.Items.Data) > 0 {
+		e.Price = o.Items.Data[0].Price.ID
+		e.Quantity = o.Items.Data[0].Quantity
+	}
+}
+
+// verify checks the Stripe-Signature header: t=<timestamp>,v1=<hmac of "timestamp.body">
+func (s *Stripe) verify(body []byte, header string) error {
+	var timestamp string
+	var signatures []string
+
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			timestamp = kv[1]
+		case "v1":
+			signatures = append(signatures, kv[1])
+		}
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	tolerance := s.Tolerance
+	if tolerance == 0 {
+		tolerance = 5 * time.Minute
+	}
+	if clock.Since(s.Clock, time.Unix(ts, 0)) > tolerance {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.WebhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	for _, sig := range signatures {
+		b, err := hex.DecodeString(sig)
+		if err == nil && hmac.Equal(b, expected) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
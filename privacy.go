@@ -0,0 +1,32 @@
+package goravel
+
+import (
+	"fmt"
+
+	"github.com/namnguyen191/goravel/privacy"
+)
+
+// createPrivacy sets up the privacy tooling for the tables of "goravel make auth": the account is
+// part of every export bundle, and erasing it anonymizes the user and drops their tokens. Apps add
+// their own tables with grv.Privacy.Collect, grv.Privacy.OnErase and grv.Privacy.Retain. The
+// erasures are workflows, so the ones a restart interrupted are resumed with the others at boot.
+func (grv *Goravel) createPrivacy() *privacy.Privacy {
+	p := privacy.New(grv.DB.Pool, grv.DB.DataBaseType, grv.Exports, grv.Workflows)
+	p.ErrorLog = grv.ErrorLog.Println
+	p.Clock = grv.Clock
+
+	p.Collect("account", "select id, first_name, last_name, email, user_active, created_at, updated_at from users where id = ?")
+
+	p.OnErase("remember tokens", p.Delete("remember_tokens", "user_id"))
+	p.OnErase("api tokens", p.Delete("tokens", "user_id"))
+	// the user row is kept for the rows referring to it, with nothing left to identify them
+	p.OnErase("account", p.Anonymize("users", "id", map[string]interface{}{
+		"first_name":  "Deleted",
+		"last_name":   "User",
+		"email":       func(id int) interface{} { return fmt.Sprintf("deleted-%d@invalid", id) },
+		"password":    "",
+		"user_active": 0,
+	}))
+
+	return p
+}
@@ -0,0 +1,141 @@
+package privacy
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+	"github.com/namnguyen191/goravel/exports"
+)
+
+// Source is a query of the rows about a user put in their export bundle
+type Source struct {
+	// Name is the file of the rows in the bundle, e.g. "orders" for orders.json
+	Name string
+	// Query selects the rows of the user; every ? placeholder gets the id of the user
+	Query string
+}
+
+// Collect adds a source to the export bundle, e.g.
+//
+//	app.Privacy.Collect("orders", "select id, total, created_at from orders where user_id = ?")
+func (p *Privacy) Collect(name, query string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.sources = append(p.sources, Source{Name: name, Query: query})
+}
+
+// bundleGenerator is the name of the generator of the bundles on the exporter
+const bundleGenerator = "privacy-bundle"
+
+// Export generates the bundle of the data of a user in the background, a zip with a json file
+// per source; the download link comes through the Notify func of the exporter
+func (p *Privacy) Export(ctx context.Context, userID int) (exports.Export, error) {
+	if p.Exports == nil {
+		return exports.Export{}, fmt.Errorf("privacy: no exporter")
+	}
+
+	return p.Exports.Request(ctx, exports.Request{
+		UserID:    userID,
+		Name:      "personal-data",
+		Format:    exports.ZIP,
+		Generator: bundleGenerator,
+	})
+}
+
+// manifest describes a bundle, written as manifest.json
+type manifest struct {
+	UserID      int            `json:"user_id"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Files       map[string]int `json:"files"`
+}
+
+// bundle is the generator of the bundles, writing the data of the user of the export
+func (p *Privacy) bundle(ctx context.Context, e exports.Export, w io.Writer) (int, error) {
+	p.mu.RLock()
+	sources := append([]Source(nil), p.sources...)
+	p.mu.RUnlock()
+
+	z := zip.NewWriter(w)
+	m := manifest{UserID: e.UserID, GeneratedAt: clock.Now(p.Clock), Files: map[string]int{}}
+	var total int
+
+	for _, s := range sources {
+		rows, err := p.collect(ctx, s, e.UserID)
+		if err != nil {
+			return total, fmt.Errorf("%s: %w", s.Name, err)
+		}
+
+		name := s.Name + ".json"
+		if err := writeJSON(z, name, rows); err != nil {
+			return total, err
+		}
+		m.Files[name] = len(rows)
+		total += len(rows)
+	}
+
+	if err := writeJSON(z, "manifest.json", m); err != nil {
+		return total, err
+	}
+	return total, z.Close()
+}
+
+func writeJSON(z *zip.Writer, name string, v interface{}) error {
+	f, err := z.Create(name)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// collect returns the rows of a source as objects keyed by column
+func (p *Privacy) collect(ctx context.Context, s Source, userID int) ([]map[string]interface{}, error) {
+	args := make([]interface{}, strings.Count(s.Query, "?"))
+	for i := range args {
+		args[i] = userID
+	}
+
+	rows, err := p.DB.QueryContext(ctx, database.Rebind(p.DatabaseType, s.Query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(values))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	result := []map[string]interface{}{}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			if b, ok := values[i].([]byte); ok {
+				row[column] = string(b)
+			} else {
+				row[column] = values[i]
+			}
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
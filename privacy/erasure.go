@@ -0,0 +1,139 @@
+package privacy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/namnguyen191/goravel/database"
+	"github.com/namnguyen191/goravel/workflow"
+)
+
+// ErasureWorkflow is the name of the workflow erasing an account
+const ErasureWorkflow = "privacy-erasure"
+
+// Eraser removes or anonymizes what a part of the app keeps about a user
+type Eraser func(ctx context.Context, userID int) error
+
+type eraser struct {
+	name  string
+	erase Eraser
+}
+
+// OnErase adds a step to the erasure of an account, run in the order they were added. The steps
+// are persisted as they complete, so an erasure the workflow engine resumes after a restart
+// picks up where it stopped: add them in the same order at every boot, and keep them safe to
+// run twice, since the step which was interrupted runs again.
+func (p *Privacy) OnErase(name string, erase Eraser) {
+	p.mu.Lock()
+	p.erasers = append(p.erasers, eraser{name: name, erase: erase})
+	p.mu.Unlock()
+
+	p.define()
+}
+
+// define (re)defines the erasure workflow with the current steps
+func (p *Privacy) define() {
+	if p.Workflows == nil {
+		return
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	w := &workflow.Workflow{Name: ErasureWorkflow}
+	for _, e := range p.erasers {
+		erase := e.erase
+		w.Steps = append(w.Steps, workflow.Step{
+			Name: e.name,
+			Run: func(ctx context.Context, state workflow.State) error {
+				id, err := stateUserID(state)
+				if err != nil {
+					return err
+				}
+				return erase(ctx, id)
+			},
+		})
+	}
+	p.Workflows.Define(w)
+}
+
+// stateUserID reads the user of an erasure, a float64 once the state was reloaded from json
+func stateUserID(state workflow.State) (int, error) {
+	switch id := state["user_id"].(type) {
+	case int:
+		return id, nil
+	case float64:
+		return int(id), nil
+	}
+	return 0, fmt.Errorf("privacy: no user to erase")
+}
+
+// Erase starts the erasure of the account of a user in the background, returning the id of its
+// workflow instance. Without a workflow engine the steps run right away, and 0 is returned.
+func (p *Privacy) Erase(ctx context.Context, userID int) (int, error) {
+	if p.Workflows != nil {
+		return p.Workflows.Start(ctx, ErasureWorkflow, workflow.State{"user_id": userID})
+	}
+
+	p.mu.RLock()
+	erasers := append([]eraser(nil), p.erasers...)
+	p.mu.RUnlock()
+
+	for _, e := range erasers {
+		if err := e.erase(ctx, userID); err != nil {
+			return 0, fmt.Errorf("privacy: erasing %s: %w", e.name, err)
+		}
+	}
+	return 0, nil
+}
+
+// Delete returns an eraser deleting the rows of table whose column is the id of the user
+func (p *Privacy) Delete(table, column string) Eraser {
+	return func(ctx context.Context, userID int) error {
+		if !identifier.MatchString(table) || !identifier.MatchString(column) {
+			return fmt.Errorf("invalid table or column")
+		}
+
+		query := fmt.Sprintf("delete from %s where %s = ?", table, column)
+		_, err := p.DB.ExecContext(ctx, database.Rebind(p.DatabaseType, query), userID)
+		return err
+	}
+}
+
+// Anonymize returns an eraser overwriting columns of the rows of table whose column is the id of
+// the user, keeping the rows others refer to. A value of set can be a func(userID int) interface{},
+// e.g. for addresses which have to stay unique.
+func (p *Privacy) Anonymize(table, column string, set map[string]interface{}) Eraser {
+	return func(ctx context.Context, userID int) error {
+		if !identifier.MatchString(table) || !identifier.MatchString(column) {
+			return fmt.Errorf("invalid table or column")
+		}
+
+		columns := make([]string, 0, len(set))
+		for c := range set {
+			if !identifier.MatchString(c) {
+				return fmt.Errorf("invalid column %q", c)
+			}
+			columns = append(columns, c)
+		}
+		sort.Strings(columns)
+
+		assignments := make([]string, len(columns))
+		args := make([]interface{}, 0, len(columns)+1)
+		for i, c := range columns {
+			assignments[i] = c + " = ?"
+			v := set[c]
+			if fn, ok := v.(func(int) interface{}); ok {
+				v = fn(userID)
+			}
+			args = append(args, v)
+		}
+		args = append(args, userID)
+
+		query := fmt.Sprintf("update %s set %s where %s = ?", table, strings.Join(assignments, ", "), column)
+		_, err := p.DB.ExecContext(ctx, database.Rebind(p.DatabaseType, query), args...)
+		return err
+	}
+}
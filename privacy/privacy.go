@@ -0,0 +1,152 @@
+package privacy
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+	"github.com/namnguyen191/goravel/exports"
+	"github.com/namnguyen191/goravel/workflow"
+)
+
+// identifier matches the table and column names interpolated in queries
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Policy keeps the rows of a table for a while, deleting or anonymizing them once expired
+type Policy struct {
+	// Name identifies the policy in logs, e.g. "contact messages"
+	Name  string
+	Table string
+	// Column is the timestamp the age of a row is taken from; created_at by default
+	Column string
+	Keep   time.Duration
+	// Anonymize, when set, overwrites these columns of the expired rows instead of deleting them,
+	// e.g. {"email": "", "ip": nil}; Where can then skip the rows already anonymized
+	Anonymize map[string]interface{}
+	// Where further restricts the expired rows, e.g. "status = 'closed'"
+	Where string
+}
+
+// Privacy enforces retention policies, bundles the data of a user for export and erases accounts
+type Privacy struct {
+	DB           *sql.DB
+	DatabaseType string
+	// Exports generates the bundles and hands out their download links
+	Exports *exports.Exporter
+	// Workflows runs the erasures, so they resume after a restart
+	Workflows *workflow.Engine
+	ErrorLog  func(v ...interface{})
+	// Clock tells which records are past their retention; the time of the machine when nil
+	Clock clock.Clock
+
+	mu       sync.RWMutex
+	policies []Policy
+	sources  []Source
+	erasers  []eraser
+}
+
+// New returns the privacy tooling of db; the erasure workflow is defined on w
+func New(db *sql.DB, dbType string, x *exports.Exporter, w *workflow.Engine) *Privacy {
+	p := &Privacy{
+		DB:           db,
+		DatabaseType: dbType,
+		Exports:      x,
+		Workflows:    w,
+		ErrorLog:     log.Println,
+	}
+	if x != nil {
+		x.Define(bundleGenerator, p.bundle)
+	}
+	p.define()
+	return p
+}
+
+// Retain adds a retention policy, enforced by Enforce
+func (p *Privacy) Retain(policy Policy) error {
+	if policy.Column == "" {
+		policy.Column = "created_at"
+	}
+	if policy.Name == "" {
+		policy.Name = policy.Table
+	}
+	if policy.Keep <= 0 {
+		return fmt.Errorf("privacy: %s: a policy needs to keep rows for some time", policy.Name)
+	}
+	if !identifier.MatchString(policy.Table) || !identifier.MatchString(policy.Column) {
+		return fmt.Errorf("privacy: %s: invalid table or column", policy.Name)
+	}
+	for column := range policy.Anonymize {
+		if !identifier.MatchString(column) {
+			return fmt.Errorf("privacy: %s: invalid column %q", policy.Name, column)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.policies = append(p.policies, policy)
+	return nil
+}
+
+// Enforce applies every retention policy, returning the number of rows deleted or anonymized.
+// A failing policy does not stop the others; the first error is returned.
+func (p *Privacy) Enforce(ctx context.Context) (int, error) {
+	p.mu.RLock()
+	policies := append([]Policy(nil), p.policies...)
+	p.mu.RUnlock()
+
+	var total int
+	var first error
+	for _, policy := range policies {
+		n, err := p.enforce(ctx, policy, clock.Now(p.Clock).Add(-policy.Keep))
+		total += int(n)
+		if err != nil {
+			p.ErrorLog("privacy: retention of", policy.Name, err)
+			if first == nil {
+				first = fmt.Errorf("%s: %w", policy.Name, err)
+			}
+		}
+	}
+	return total, first
+}
+
+func (p *Privacy) enforce(ctx context.Context, policy Policy, before time.Time) (int64, error) {
+	where := policy.Column + " < ?"
+	if policy.Where != "" {
+		where += " and (" + policy.Where + ")"
+	}
+
+	query := "delete from " + policy.Table + " where " + where
+	var args []interface{}
+
+	if len(policy.Anonymize) > 0 {
+		// columns are sorted so the query, and the order of its arguments, are stable
+		columns := make([]string, 0, len(policy.Anonymize))
+		for column := range policy.Anonymize {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+
+		set := make([]string, len(columns))
+		for i, column := range columns {
+			set[i] = column + " = ?"
+			args = append(args, policy.Anonymize[column])
+		}
+		query = "update " + policy.Table + " set " + strings.Join(set, ", ") + " where " + where
+	}
+	args = append(args, before)
+
+	res, err := p.DB.ExecContext(ctx, database.Rebind(p.DatabaseType, query), args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
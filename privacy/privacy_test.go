@@ -0,0 +1,201 @@
+package privacy
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/namnguyen191/goravel/exports"
+	"github.com/namnguyen191/goravel/workflow"
+)
+
+// recordDriver records the statements executed, and answers every query with one row
+type recordDriver struct {
+	execs []string
+	args  [][]driver.Value
+}
+
+func (d *recordDriver) Open(string) (driver.Conn, error) { return &recordConn{d}, nil }
+
+type recordConn struct{ d *recordDriver }
+
+func (c *recordConn) Prepare(query string) (driver.Stmt, error) { return &recordStmt{c.d, query}, nil }
+func (c *recordConn) Close() error                              { return nil }
+func (c *recordConn) Begin() (driver.Tx, error)                 { return nil, errors.New("no transactions") }
+
+type recordStmt struct {
+	d     *recordDriver
+	query string
+}
+
+func (s *recordStmt) Close() error  { return nil }
+func (s *recordStmt) NumInput() int { return -1 }
+func (s *recordStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.execs = append(s.d.execs, s.query)
+	s.d.args = append(s.d.args, args)
+	return driver.RowsAffected(3), nil
+}
+func (s *recordStmt) Query(args []driver.Value) (driver.Rows, error) {
+	// an erasure interrupted after its first step
+	if strings.HasPrefix(s.query, "select id, workflow,") {
+		now := time.Now()
+		return &recordRows{
+			columns: []string{"id", "workflow", "status", "step", "state", "error", "created_at", "updated_at"},
+			rows:    [][]driver.Value{{args[0], ErasureWorkflow, "running", int64(1), []byte(`{"user_id":7}`), "", now, now}},
+		}, nil
+	}
+	return &recordRows{columns: []string{"id", "email"}, rows: [][]driver.Value{{args[0], []byte("ann@example.com")}}}, nil
+}
+
+type recordRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *recordRows) Columns() []string { return r.columns }
+func (r *recordRows) Close() error      { return nil }
+func (r *recordRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func open(t *testing.T, name string) (*sql.DB, *recordDriver) {
+	d := &recordDriver{}
+	sql.Register(name, d)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+func TestEnforce(t *testing.T) {
+	db, d := open(t, "privacy-enforce")
+	p := New(db, "postgres", nil, nil)
+
+	if err := p.Retain(Policy{Table: "logins"}); err == nil {
+		t.Error("expected a policy without a period to be refused")
+	}
+	if err := p.Retain(Policy{Table: "logins; drop table users", Keep: time.Hour}); err == nil {
+		t.Error("expected an invalid table to be refused")
+	}
+
+	_ = p.Retain(Policy{Table: "logins", Keep: 24 * time.Hour})
+	_ = p.Retain(Policy{Table: "orders", Column: "placed_at", Keep: time.Hour, Where: "email <> ''",
+		Anonymize: map[string]interface{}{"name": "", "email": ""}})
+
+	n, err := p.Enforce(context.Background())
+	if err != nil || n != 6 {
+		t.Fatalf("expected 6 rows, got %d %v", n, err)
+	}
+
+	want := []string{
+		"delete from logins where created_at < $1",
+		"update orders set email = $1, name = $2 where placed_at < $3 and (email <> '')",
+	}
+	for i, q := range want {
+		if d.execs[i] != q {
+			t.Errorf("expected %q, got %q", q, d.execs[i])
+		}
+	}
+	if before, ok := d.args[0][0].(time.Time); !ok || time.Since(before) < 24*time.Hour {
+		t.Errorf("expected the rows from before a day ago, got %v", d.args[0])
+	}
+}
+
+func TestBundle(t *testing.T) {
+	db, _ := open(t, "privacy-bundle")
+	p := New(db, "mysql", nil, nil)
+	p.Collect("account", "select id, email from users where id = ?")
+
+	var buf bytes.Buffer
+	n, err := p.bundle(context.Background(), exports.Export{UserID: 7}, &buf)
+	if err != nil || n != 1 {
+		t.Fatalf("expected a row, got %d %v", n, err)
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{}
+	for _, f := range z.File {
+		r, _ := f.Open()
+		files[f.Name], _ = io.ReadAll(r)
+		r.Close()
+	}
+
+	var account []map[string]interface{}
+	if err := json.Unmarshal(files["account.json"], &account); err != nil {
+		t.Fatal(err)
+	}
+	if len(account) != 1 || account[0]["email"] != "ann@example.com" || account[0]["id"] != float64(7) {
+		t.Errorf("unexpected account %v", account)
+	}
+	if !strings.Contains(string(files["manifest.json"]), `"account.json": 1`) {
+		t.Errorf("unexpected manifest %s", files["manifest.json"])
+	}
+}
+
+func TestErase(t *testing.T) {
+	db, d := open(t, "privacy-erase")
+	p := New(db, "mysql", nil, nil)
+
+	p.OnErase("tokens", p.Delete("tokens", "user_id"))
+	p.OnErase("account", p.Anonymize("users", "id", map[string]interface{}{
+		"first_name": "Deleted",
+		"email":      func(id int) interface{} { return "deleted-7@invalid" },
+	}))
+
+	if _, err := p.Erase(context.Background(), 7); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.execs) != 2 || d.execs[1] != "update users set email = ?, first_name = ? where id = ?" {
+		t.Fatalf("unexpected statements %v", d.execs)
+	}
+	if d.args[1][0] != "deleted-7@invalid" || d.args[1][2] != int64(7) {
+		t.Errorf("unexpected arguments %v", d.args[1])
+	}
+
+	p.OnErase("broken", p.Delete("bad table", "user_id"))
+	if _, err := p.Erase(context.Background(), 7); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("expected the failing step named, got %v", err)
+	}
+}
+
+func TestEraseResumed(t *testing.T) {
+	db, d := open(t, "privacy-resume")
+	p := New(db, "mysql", nil, workflow.New(db, "mysql"))
+
+	p.OnErase("tokens", p.Delete("tokens", "user_id"))
+	p.OnErase("account", p.Anonymize("users", "id", map[string]interface{}{"first_name": "Deleted"}))
+
+	if err := p.Workflows.Continue(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	var erased []string
+	for i, query := range d.execs {
+		if !strings.Contains(query, "workflows") {
+			erased = append(erased, query)
+			if d.args[i][1] != int64(7) {
+				t.Errorf("expected the user of the state, got %v", d.args[i])
+			}
+		}
+	}
+	if len(erased) != 1 || erased[0] != "update users set first_name = ? where id = ?" {
+		t.Errorf("expected the erasure to pick up after its first step, got %v", erased)
+	}
+}
@@ -0,0 +1,15 @@
+package goravel
+
+import (
+	"path/filepath"
+
+	"github.com/namnguyen191/goravel/static"
+)
+
+// createPublic serves public/ at /public, preferring the .br and .gz files written by
+// "goravel assets:compress" and gzipping other text files on the fly
+func (grv *Goravel) createPublic() *static.Files {
+	files := static.New(filepath.Join(grv.RootPath, "public"))
+	files.Policy.MinSize = int64(grv.Env.Int("PUBLIC_COMPRESS_MIN_SIZE", int(files.Policy.MinSize)))
+	return files
+}
@@ -0,0 +1,42 @@
+package goravel
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/namnguyen191/goravel/push"
+)
+
+// createPush enables Web Push when VAPID keys are set and FCM when FCM_CREDENTIALS points to
+// a service account key file. Push service calls go through the "push" circuit breaker.
+func (grv *Goravel) createPush() (*push.Push, error) {
+	var drivers []push.Driver
+	client := grv.HTTPClient("push")
+
+	if os.Getenv("VAPID_PUBLIC_KEY") != "" {
+		drivers = append(drivers, &push.WebPush{
+			PublicKey:  os.Getenv("VAPID_PUBLIC_KEY"),
+			PrivateKey: os.Getenv("VAPID_PRIVATE_KEY"),
+			Subject:    os.Getenv("VAPID_SUBJECT"),
+			Client:     client,
+		})
+	}
+
+	if path := os.Getenv("FCM_CREDENTIALS"); path != "" {
+		creds, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		drivers = append(drivers, &push.FCM{Credentials: creds, ProjectID: os.Getenv("FCM_PROJECT_ID"), Client: client})
+	}
+
+	if len(drivers) == 0 {
+		return nil, nil
+	}
+
+	p := push.New(grv.DB.Pool, grv.DB.DataBaseType, grv.Session, drivers...)
+	p.ErrorLog = grv.ErrorLog.Println
+	p.Clock = grv.Clock
+
+	return p, nil
+}
@@ -0,0 +1,236 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FCM sends notifications through the Firebase Cloud Messaging HTTP v1 API,
+// authenticated with a service account
+type FCM struct {
+	// Credentials is the JSON key file of the service account
+	Credentials []byte
+	// ProjectID defaults to the project of the service account
+	ProjectID string
+	Client    *http.Client
+	// Endpoint is the address of the FCM HTTP v1 API, https://fcm.googleapis.com by default
+	Endpoint string
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+type serviceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+func (f *FCM) Name() string {
+	return "fcm"
+}
+
+func (f *FCM) client() *http.Client {
+	if f.Client == nil {
+		return &http.Client{Timeout: 30 * time.Second}
+	}
+	return f.Client
+}
+
+func (f *FCM) account() (*serviceAccount, error) {
+	var sa serviceAccount
+	if err := json.Unmarshal(f.Credentials, &sa); err != nil {
+		return nil, fmt.Errorf("push: invalid FCM credentials: %w", err)
+	}
+	if f.ProjectID != "" {
+		sa.ProjectID = f.ProjectID
+	}
+	if sa.TokenURI == "" {
+		sa.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	return &sa, nil
+}
+
+// accessToken exchanges a signed JWT for an OAuth2 token, kept until shortly before it expires
+func (f *FCM) accessToken(ctx context.Context, sa *serviceAccount) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.token != "" && time.Now().Before(f.expires) {
+		return f.token, nil
+	}
+
+	block, _ := pem.Decode([]byte(sa.PrivateKey))
+	if block == nil {
+		return "", errors.New("push: invalid FCM private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return "", err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", errors.New("push: FCM private key is not an RSA key")
+	}
+
+	now := time.Now()
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   sa.ClientEmail,
+		"scope": "https://www.googleapis.com/auth/firebase.messaging",
+		"aud":   sa.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := b64.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`)) + "." + b64.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", unsigned+"."+b64.EncodeToString(sig))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sa.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := f.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	var out struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&out); err != nil || res.StatusCode >= 300 || out.AccessToken == "" {
+		return "", fmt.Errorf("push: fcm: token exchange failed: %s", res.Status)
+	}
+
+	f.token = out.AccessToken
+	f.expires = now.Add(time.Duration(out.ExpiresIn)*time.Second - time.Minute)
+
+	return f.token, nil
+}
+
+func (f *FCM) Send(ctx context.Context, sub Subscription, n Notification) error {
+	sa, err := f.account()
+	if err != nil {
+		return err
+	}
+	token, err := f.accessToken(ctx, sa)
+	if err != nil {
+		return err
+	}
+
+	ttl := n.ttl()
+	priority := "normal"
+	if n.Urgency == High {
+		priority = "high"
+	}
+
+	data := map[string]string{}
+	for k, v := range n.Data {
+		data[k] = v
+	}
+	if n.URL != "" {
+		data["url"] = n.URL
+	}
+
+	msg := map[string]interface{}{
+		"token": sub.Endpoint,
+		"notification": map[string]string{
+			"title": n.Title,
+			"body":  n.Body,
+		},
+		"android": map[string]interface{}{
+			"ttl":          strconv.Itoa(int(ttl.Seconds())) + "s",
+			"priority":     priority,
+			"notification": map[string]string{"tag": n.Tag, "icon": n.Icon},
+		},
+		"apns": map[string]interface{}{
+			"headers": map[string]string{"apns-expiration": strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)},
+		},
+	}
+	if len(data) > 0 {
+		msg["data"] = data
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"message": msg})
+	if err != nil {
+		return err
+	}
+
+	endpoint := f.Endpoint
+	if endpoint == "" {
+		endpoint = "https://fcm.googleapis.com"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/v1/projects/"+url.PathEscape(sa.ProjectID)+"/messages:send", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := f.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 300 {
+		return nil
+	}
+
+	b, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1<<20))
+	var e struct {
+		Error struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+			Details []struct {
+				ErrorCode string `json:"errorCode"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	_ = json.Unmarshal(b, &e)
+
+	if res.StatusCode == http.StatusNotFound || e.Error.Status == "NOT_FOUND" {
+		return ErrExpired
+	}
+	for _, d := range e.Error.Details {
+		if d.ErrorCode == "UNREGISTERED" {
+			return ErrExpired
+		}
+	}
+
+	return fmt.Errorf("push: fcm: %s %s", res.Status, e.Error.Message)
+}
@@ -0,0 +1,296 @@
+package push
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+)
+
+// ErrExpired is returned by drivers when a subscription is gone, e.g. the user
+// revoked the permission or the app was uninstalled; such subscriptions are deleted
+var ErrExpired = errors.New("push: subscription expired")
+
+// Urgency hints how soon a notification must be delivered to a device on battery
+const (
+	VeryLow = "very-low"
+	Low     = "low"
+	Normal  = "normal"
+	High    = "high"
+)
+
+// Subscription is a device of a user. Web Push subscriptions have an endpoint and keys,
+// FCM ones keep the registration token in Endpoint.
+type Subscription struct {
+	ID        int
+	UserID    int
+	Driver    string
+	Endpoint  string
+	P256dh    string
+	Auth      string
+	UserAgent string
+	CreatedAt time.Time
+}
+
+// Notification is what is shown on the device; Data is handed to the service worker or app
+type Notification struct {
+	Title string            `json:"title"`
+	Body  string            `json:"body,omitempty"`
+	URL   string            `json:"url,omitempty"`
+	Icon  string            `json:"icon,omitempty"`
+	Tag   string            `json:"tag,omitempty"`
+	Data  map[string]string `json:"data,omitempty"`
+	// TTL is how long the push service keeps the notification for an offline device;
+	// queued notifications older than TTL are dropped. Four weeks when zero.
+	TTL     time.Duration `json:"-"`
+	Urgency string        `json:"-"`
+}
+
+func (n Notification) ttl() time.Duration {
+	if n.TTL <= 0 {
+		return 28 * 24 * time.Hour
+	}
+	return n.TTL
+}
+
+// Driver delivers a notification to one subscription
+type Driver interface {
+	Name() string
+	Send(ctx context.Context, sub Subscription, n Notification) error
+}
+
+type job struct {
+	userID int
+	n      Notification
+	queued time.Time
+}
+
+// Push stores the devices of users and sends them notifications through the driver
+// each device subscribed with
+type Push struct {
+	DB           *sql.DB
+	DatabaseType string
+	Session      *scs.SessionManager
+	Drivers      map[string]Driver
+	ErrorLog     func(v ...interface{})
+	// Clock tells how long queued notifications waited; the time of the machine when nil
+	Clock clock.Clock
+
+	jobs chan job
+}
+
+// New returns push notifications sent through drivers, with a queue of 100 notifications
+func New(db *sql.DB, dbType string, session *scs.SessionManager, drivers ...Driver) *Push {
+	p := &Push{
+		DB:           db,
+		DatabaseType: dbType,
+		Session:      session,
+		Drivers:      map[string]Driver{},
+		jobs:         make(chan job, 100),
+		ErrorLog:     log.Println,
+	}
+	for _, d := range drivers {
+		p.Drivers[d.Name()] = d
+	}
+
+	return p
+}
+
+func (p *Push) rebind(query string) string {
+	return database.Rebind(p.DatabaseType, query)
+}
+
+const columns = "id, user_id, driver, endpoint, p256dh, auth, user_agent, created_at"
+
+func scan(row interface{ Scan(...interface{}) error }) (*Subscription, error) {
+	var s Subscription
+	if err := row.Scan(&s.ID, &s.UserID, &s.Driver, &s.Endpoint, &s.P256dh, &s.Auth, &s.UserAgent, &s.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Subscribe saves a device for a user; an endpoint subscribed again moves to the new user
+func (p *Push) Subscribe(ctx context.Context, userID int, sub Subscription) error {
+	if _, ok := p.Drivers[sub.Driver]; !ok {
+		return errors.New("push: unknown driver " + sub.Driver)
+	}
+	if sub.Endpoint == "" {
+		return errors.New("push: subscription has no endpoint")
+	}
+
+	if err := p.Unsubscribe(ctx, sub.Endpoint); err != nil {
+		return err
+	}
+
+	_, err := p.DB.ExecContext(ctx, p.rebind("insert into push_subscriptions (user_id, driver, endpoint, p256dh, auth, user_agent, created_at) values (?, ?, ?, ?, ?, ?, ?)"),
+		userID, sub.Driver, sub.Endpoint, sub.P256dh, sub.Auth, sub.UserAgent, clock.Now(p.Clock))
+	return err
+}
+
+// Unsubscribe deletes a device by endpoint or token
+func (p *Push) Unsubscribe(ctx context.Context, endpoint string) error {
+	_, err := p.DB.ExecContext(ctx, p.rebind("delete from push_subscriptions where endpoint = ?"), endpoint)
+	return err
+}
+
+// For returns the devices of a user
+func (p *Push) For(ctx context.Context, userID int) ([]Subscription, error) {
+	rows, err := p.DB.QueryContext(ctx, p.rebind("select "+columns+" from push_subscriptions where user_id = ? order by id"), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []Subscription
+	for rows.Next() {
+		s, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, *s)
+	}
+
+	return subs, rows.Err()
+}
+
+// Send delivers a notification to every device of a user right away, deleting expired
+// subscriptions. It returns the first delivery error after trying all devices.
+func (p *Push) Send(ctx context.Context, userID int, n Notification) error {
+	subs, err := p.For(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var first error
+	for _, sub := range subs {
+		d, ok := p.Drivers[sub.Driver]
+		if !ok {
+			continue
+		}
+
+		err := d.Send(ctx, sub, n)
+		if errors.Is(err, ErrExpired) {
+			if err := p.Unsubscribe(ctx, sub.Endpoint); err != nil && first == nil {
+				first = err
+			}
+			continue
+		}
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return first
+}
+
+// Queue puts a notification on the queue to be sent by ListenForPush
+func (p *Push) Queue(userID int, n Notification) {
+	p.jobs <- job{userID: userID, n: n, queued: clock.Now(p.Clock)}
+}
+
+// ListenForPush sends queued notifications, dropping those which outlived their TTL while waiting
+func (p *Push) ListenForPush() {
+	for j := range p.jobs {
+		ttl := j.n.ttl() - clock.Since(p.Clock, j.queued)
+		if ttl <= 0 {
+			continue
+		}
+		j.n.TTL = ttl
+
+		if err := p.Send(context.Background(), j.userID, j.n); err != nil {
+			p.ErrorLog("push:", j.userID, err)
+		}
+	}
+}
+
+// SubscribeHandler saves the device of the logged in user. It accepts the JSON of a browser
+// PushSubscription, {"endpoint": "...", "keys": {"p256dh": "...", "auth": "..."}}, or
+// {"token": "..."} from an FCM client.
+func (p *Push) SubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	userID := p.Session.GetInt(r.Context(), "userID")
+	if userID == 0 {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	var in struct {
+		Endpoint string `json:"endpoint"`
+		Token    string `json:"token"`
+		Keys     struct {
+			P256dh string `json:"p256dh"`
+			Auth   string `json:"auth"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 16<<10)).Decode(&in); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	sub := Subscription{Driver: "webpush", Endpoint: in.Endpoint, P256dh: in.Keys.P256dh, Auth: in.Keys.Auth, UserAgent: r.UserAgent()}
+	if in.Token != "" {
+		sub = Subscription{Driver: "fcm", Endpoint: in.Token, UserAgent: r.UserAgent()}
+	}
+	if len(sub.UserAgent) > 255 {
+		sub.UserAgent = sub.UserAgent[:255]
+	}
+
+	if err := p.Subscribe(r.Context(), userID, sub); err != nil {
+		p.ErrorLog("push: subscribe", err)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// UnsubscribeHandler deletes a device of the logged in user, posted as the same JSON as SubscribeHandler
+func (p *Push) UnsubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	userID := p.Session.GetInt(r.Context(), "userID")
+	if userID == 0 {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	var in struct {
+		Endpoint string `json:"endpoint"`
+		Token    string `json:"token"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 16<<10)).Decode(&in); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	endpoint := in.Endpoint
+	if in.Token != "" {
+		endpoint = in.Token
+	}
+
+	_, err := p.DB.ExecContext(r.Context(), p.rebind("delete from push_subscriptions where endpoint = ? and user_id = ?"), endpoint, userID)
+	if err != nil {
+		p.ErrorLog("push: unsubscribe", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TemplateFuncs adds vapidPublicKey, the application server key browsers subscribe with
+func (p *Push) TemplateFuncs(r *http.Request) template.FuncMap {
+	return template.FuncMap{
+		"vapidPublicKey": func() string {
+			if wp, ok := p.Drivers["webpush"].(*WebPush); ok {
+				return wp.PublicKey
+			}
+			return ""
+		},
+	}
+}
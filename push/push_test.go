@@ -0,0 +1,180 @@
+package push
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// decrypt is the user agent side of RFC 8291
+func decrypt(t *testing.T, uaPrivate []byte, uaPublic, authSecret, body []byte) []byte {
+	t.Helper()
+
+	salt, idlen := body[:16], int(body[20])
+	if binary.BigEndian.Uint32(body[16:20]) != 4096 {
+		t.Fatal("unexpected record size")
+	}
+	asPublic := body[21 : 21+idlen]
+
+	curve := elliptic.P256()
+	ax, ay := elliptic.Unmarshal(curve, asPublic)
+	sx, _ := curve.ScalarMult(ax, ay, uaPrivate)
+	secret := make([]byte, 32)
+	sx.FillBytes(secret)
+
+	prkKey := hmacSHA256(authSecret, secret)
+	ikm := hmacSHA256(prkKey, []byte("WebPush: info\x00"), uaPublic, asPublic, []byte{1})
+	prk := hmacSHA256(salt, ikm)
+	cek := hmacSHA256(prk, []byte("Content-Encoding: aes128gcm\x00\x01"))[:16]
+	nonce := hmacSHA256(prk, []byte("Content-Encoding: nonce\x00\x01"))[:12]
+
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	plain, err := gcm.Open(nil, nonce, body[21+idlen:], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain[len(plain)-1] != 2 {
+		t.Fatal("missing record delimiter")
+	}
+
+	return plain[:len(plain)-1]
+}
+
+func TestWebPush(t *testing.T) {
+	public, private, err := GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uaPrivate, ux, uy, _ := elliptic.GenerateKey(elliptic.P256(), rand.Reader)
+	uaPublic := elliptic.Marshal(elliptic.P256(), ux, uy)
+	authSecret := []byte("0123456789abcdef")
+
+	var got Notification
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "vapid t=") || !strings.HasSuffix(auth, ", k="+public) || r.Header.Get("Content-Encoding") != "aes128gcm" {
+			t.Errorf("unexpected headers %v", r.Header)
+		}
+
+		// the JWT is signed with the VAPID key for the endpoint's origin
+		jwt := strings.Split(strings.TrimSuffix(strings.TrimPrefix(auth, "vapid t="), ", k="+public), ".")
+		claims, _ := b64.DecodeString(jwt[1])
+		sig, _ := b64.DecodeString(jwt[2])
+		x, y := elliptic.Unmarshal(elliptic.P256(), mustDecode(t, public))
+		hash := sha256.Sum256([]byte(jwt[0] + "." + jwt[1]))
+		if !ecdsa.Verify(&ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, hash[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+			t.Error("invalid VAPID signature")
+		}
+		if !strings.Contains(string(claims), `"sub":"mailto:ops@example.com"`) {
+			t.Errorf("unexpected claims %s", claims)
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(decrypt(t, uaPrivate, uaPublic, authSecret, body), &got)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	wp := &WebPush{PublicKey: public, PrivateKey: private, Subject: "mailto:ops@example.com"}
+	sub := Subscription{Endpoint: srv.URL + "/push", P256dh: b64.EncodeToString(uaPublic), Auth: b64.EncodeToString(authSecret)}
+
+	if err := wp.Send(context.Background(), sub, Notification{Title: "Order shipped", URL: "/orders/1", TTL: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Order shipped" || got.URL != "/orders/1" {
+		t.Errorf("unexpected notification %+v", got)
+	}
+
+	sub.Endpoint = srv.URL + "/gone"
+	if err := wp.Send(context.Background(), sub, Notification{Title: "x"}); err != ErrExpired {
+		t.Errorf("expected expired, got %v", err)
+	}
+}
+
+func mustDecode(t *testing.T, s string) []byte {
+	b, err := b64.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestFCM(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	tokens := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			tokens++
+			w.Write([]byte(`{"access_token":"at","expires_in":3600}`))
+		case "/v1/projects/shop/messages:send":
+			if r.Header.Get("Authorization") != "Bearer at" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			var in struct {
+				Message struct {
+					Token   string            `json:"token"`
+					Data    map[string]string `json:"data"`
+					Android struct {
+						TTL string `json:"ttl"`
+					} `json:"android"`
+				} `json:"message"`
+			}
+			json.NewDecoder(r.Body).Decode(&in)
+			if in.Message.Token == "stale" {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error":{"status":"NOT_FOUND","details":[{"errorCode":"UNREGISTERED"}]}}`))
+				return
+			}
+			if in.Message.Android.TTL != "60s" || in.Message.Data["url"] != "/orders/1" {
+				t.Errorf("unexpected message %+v", in.Message)
+			}
+			w.Write([]byte(`{"name":"projects/shop/messages/1"}`))
+		}
+	}))
+	defer srv.Close()
+
+	creds, _ := json.Marshal(map[string]string{"project_id": "shop", "client_email": "push@shop.iam", "private_key": string(pemKey), "token_uri": srv.URL + "/token"})
+	f := &FCM{Credentials: creds, Endpoint: srv.URL}
+
+	n := Notification{Title: "Order shipped", URL: "/orders/1", TTL: time.Minute}
+	for i := 0; i < 2; i++ {
+		if err := f.Send(context.Background(), Subscription{Endpoint: "device"}, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tokens != 1 {
+		t.Errorf("expected the access token to be reused, exchanged %d times", tokens)
+	}
+
+	if err := f.Send(context.Background(), Subscription{Endpoint: "stale"}, n); err != ErrExpired {
+		t.Errorf("expected expired, got %v", err)
+	}
+}
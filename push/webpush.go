@@ -0,0 +1,247 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxPayload is the largest payload push services must accept, less the encryption overhead
+const maxPayload = 4096 - 86 - 17
+
+var b64 = base64.RawURLEncoding
+
+// GenerateVAPIDKeys returns a new VAPID key pair, base64url encoded, for VAPID_PUBLIC_KEY
+// and VAPID_PRIVATE_KEY
+func GenerateVAPIDKeys() (public, private string, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	d := make([]byte, 32)
+	key.D.FillBytes(d)
+
+	return b64.EncodeToString(elliptic.Marshal(elliptic.P256(), key.X, key.Y)), b64.EncodeToString(d), nil
+}
+
+// WebPush sends encrypted Web Push messages (RFC 8291) authenticated with VAPID (RFC 8292)
+type WebPush struct {
+	PublicKey  string
+	PrivateKey string
+	// Subject is a mailto: or https: contact for the push services
+	Subject string
+	Client  *http.Client
+
+	once sync.Once
+	key  *ecdsa.PrivateKey
+	err  error
+}
+
+func (wp *WebPush) Name() string {
+	return "webpush"
+}
+
+func (wp *WebPush) privateKey() (*ecdsa.PrivateKey, error) {
+	wp.once.Do(func() {
+		pub, err := b64.DecodeString(wp.PublicKey)
+		if err != nil {
+			wp.err = fmt.Errorf("push: invalid VAPID public key: %w", err)
+			return
+		}
+		d, err := b64.DecodeString(wp.PrivateKey)
+		if err != nil || len(d) != 32 {
+			wp.err = errors.New("push: invalid VAPID private key")
+			return
+		}
+
+		x, y := elliptic.Unmarshal(elliptic.P256(), pub)
+		if x == nil {
+			wp.err = errors.New("push: invalid VAPID public key")
+			return
+		}
+
+		wp.key = &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, D: new(big.Int).SetBytes(d)}
+	})
+
+	return wp.key, wp.err
+}
+
+func (wp *WebPush) Send(ctx context.Context, sub Subscription, n Notification) error {
+	key, err := wp.privateKey()
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxPayload {
+		return errors.New("push: notification is too large")
+	}
+
+	body, err := encrypt(sub, payload)
+	if err != nil {
+		return err
+	}
+
+	u, err := url.Parse(sub.Endpoint)
+	if err != nil {
+		return err
+	}
+	token, err := vapidToken(key, u.Scheme+"://"+u.Host, wp.Subject)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(n.ttl().Seconds())))
+	req.Header.Set("Authorization", "vapid t="+token+", k="+wp.PublicKey)
+	if n.Urgency != "" {
+		req.Header.Set("Urgency", n.Urgency)
+	}
+	if n.Tag != "" && len(n.Tag) <= 32 {
+		req.Header.Set("Topic", n.Tag)
+	}
+
+	client := wp.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone:
+		return ErrExpired
+	case res.StatusCode >= 300:
+		b, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("push: webpush: %s %s", res.Status, strings.TrimSpace(string(b)))
+	}
+
+	return nil
+}
+
+// vapidToken signs the ES256 JWT identifying the application server to the push service
+func vapidToken(key *ecdsa.PrivateKey, audience, subject string) (string, error) {
+	claims, err := json.Marshal(map[string]interface{}{
+		"aud": audience,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": subject,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := b64.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`)) + "." + b64.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+	if err != nil {
+		return "", err
+	}
+
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	return unsigned + "." + b64.EncodeToString(sig), nil
+}
+
+func hmacSHA256(key []byte, parts ...[]byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	for _, p := range parts {
+		mac.Write(p)
+	}
+	return mac.Sum(nil)
+}
+
+// encrypt builds the aes128gcm body of a message for the subscription's keys
+func encrypt(sub Subscription, payload []byte) ([]byte, error) {
+	uaPublic, err := b64.DecodeString(strings.TrimRight(sub.P256dh, "="))
+	if err != nil {
+		return nil, err
+	}
+	authSecret, err := b64.DecodeString(strings.TrimRight(sub.Auth, "="))
+	if err != nil {
+		return nil, err
+	}
+
+	curve := elliptic.P256()
+	ux, uy := elliptic.Unmarshal(curve, uaPublic)
+	if ux == nil {
+		return nil, errors.New("push: invalid subscription key")
+	}
+
+	asPrivate, ax, ay, err := elliptic.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := elliptic.Marshal(curve, ax, ay)
+
+	sx, _ := curve.ScalarMult(ux, uy, asPrivate)
+	secret := make([]byte, 32)
+	sx.FillBytes(secret)
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	// HKDF expansions of a single block, as laid out in RFC 8291 section 3.4
+	prkKey := hmacSHA256(authSecret, secret)
+	ikm := hmacSHA256(prkKey, []byte("WebPush: info\x00"), uaPublic, asPublic, []byte{1})
+	prk := hmacSHA256(salt, ikm)
+	cek := hmacSHA256(prk, []byte("Content-Encoding: aes128gcm\x00\x01"))[:16]
+	nonce := hmacSHA256(prk, []byte("Content-Encoding: nonce\x00\x01"))[:12]
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// a single record, ended by the 0x02 delimiter
+	ciphertext := gcm.Seal(nil, nonce, append(payload, 2), nil)
+
+	header := make([]byte, 21)
+	copy(header, salt)
+	binary.BigEndian.PutUint32(header[16:], 4096)
+	header[20] = byte(len(asPublic))
+
+	body := append(header, asPublic...)
+	return append(body, ciphertext...), nil
+}
@@ -0,0 +1,9 @@
+package goravel
+
+import "github.com/namnguyen191/goravel/qrcode"
+
+// QRCode encodes data at medium error correction, size pixels wide. The code is sent
+// with Write(w, "png") or Write(w, "svg"), or stored with Save.
+func (grv *Goravel) QRCode(data string, size int) (*qrcode.Code, error) {
+	return qrcode.New(data, qrcode.Medium, size)
+}
@@ -0,0 +1,463 @@
+package qrcode
+
+import "errors"
+
+// ErrTooLong is returned for data which does not fit in a version 40 symbol at the requested level
+var ErrTooLong = errors.New("qrcode: data too long")
+
+// Level is the error correction level; higher levels survive more damage but hold less data
+type Level int
+
+const (
+	Low Level = iota
+	Medium
+	Quartile
+	High
+)
+
+// formatBits are the two bits identifying each level in the format information
+var formatBits = [4]int{1, 0, 3, 2}
+
+// eccPerBlock and eccBlocks are the error correction layout of each level and version (ISO 18004 table 9)
+var eccPerBlock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+var eccBlocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// rawModules is the number of modules of a version available for data and error correction
+func rawModules(version int) int {
+	n := (16*version+128)*version + 64
+	if version >= 2 {
+		align := version/7 + 2
+		n -= (25*align-10)*align - 55
+		if version >= 7 {
+			n -= 36
+		}
+	}
+	return n
+}
+
+func dataCodewords(version int, level Level) int {
+	return rawModules(version)/8 - eccPerBlock[level][version]*eccBlocks[level][version]
+}
+
+// matrix is a symbol being drawn; function modules are excluded from data and masking
+type matrix struct {
+	size     int
+	dark     [][]bool
+	function [][]bool
+}
+
+func newMatrix(size int) *matrix {
+	m := &matrix{size: size, dark: make([][]bool, size), function: make([][]bool, size)}
+	for i := range m.dark {
+		m.dark[i] = make([]bool, size)
+		m.function[i] = make([]bool, size)
+	}
+	return m
+}
+
+func (m *matrix) set(x, y int, dark bool) {
+	m.dark[y][x] = dark
+	m.function[y][x] = true
+}
+
+// encode builds the symbol of data in byte mode using the smallest version that fits
+func encode(data []byte, level Level) (*matrix, int, error) {
+	version := 0
+	for v := 1; v <= 40; v++ {
+		count := 8
+		if v >= 10 {
+			count = 16
+		}
+		if 4+count+8*len(data) <= dataCodewords(v, level)*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, 0, ErrTooLong
+	}
+
+	codewords := addECC(segment(data, version, level), version, level)
+
+	m := newMatrix(version*4 + 17)
+	m.drawFunctions(version, level)
+	m.drawCodewords(codewords)
+
+	best, penalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		m.applyMask(mask)
+		m.drawFormat(level, mask)
+		if p := m.penalty(); penalty < 0 || p < penalty {
+			best, penalty = mask, p
+		}
+		m.applyMask(mask)
+	}
+	m.applyMask(best)
+	m.drawFormat(level, best)
+
+	return m, version, nil
+}
+
+// segment returns the data codewords: mode, length, data, terminator and padding
+func segment(data []byte, version int, level Level) []byte {
+	var bits []bool
+	put := func(v, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, (v>>uint(i))&1 == 1)
+		}
+	}
+
+	count := 8
+	if version >= 10 {
+		count = 16
+	}
+	put(4, 4)
+	put(len(data), count)
+	for _, b := range data {
+		put(int(b), 8)
+	}
+
+	capacity := dataCodewords(version, level) * 8
+	for i := 0; i < 4 && len(bits) < capacity; i++ {
+		bits = append(bits, false)
+	}
+	for len(bits)%8 != 0 {
+		bits = append(bits, false)
+	}
+
+	out := make([]byte, 0, capacity/8)
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for j := 0; j < 8; j++ {
+			if bits[i+j] {
+				b |= 1 << uint(7-j)
+			}
+		}
+		out = append(out, b)
+	}
+	for pad := byte(0xEC); len(out) < capacity/8; pad ^= 0xEC ^ 0x11 {
+		out = append(out, pad)
+	}
+
+	return out
+}
+
+// addECC splits the data into blocks, appends their Reed-Solomon codewords and interleaves them
+func addECC(data []byte, version int, level Level) []byte {
+	numBlocks := eccBlocks[level][version]
+	eccLen := eccPerBlock[level][version]
+	raw := rawModules(version) / 8
+	numShort := numBlocks - raw%numBlocks
+	shortLen := raw / numBlocks
+
+	divisor := rsDivisor(eccLen)
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := range blocks {
+		n := shortLen - eccLen
+		if i >= numShort {
+			n++
+		}
+		block := append([]byte{}, data[k:k+n]...)
+		k += n
+		ecc := rsRemainder(block, divisor)
+		if i < numShort {
+			block = append(block, 0)
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	out := make([]byte, 0, raw)
+	for i := 0; i < len(blocks[0]); i++ {
+		for j, block := range blocks {
+			// short blocks have a placeholder where long blocks have their last data codeword
+			if i != shortLen-eccLen || j >= numShort {
+				out = append(out, block[i])
+			}
+		}
+	}
+
+	return out
+}
+
+// gfMul multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMul(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>uint(i))&1) * int(x)
+	}
+	return byte(z)
+}
+
+func rsDivisor(degree int) []byte {
+	out := make([]byte, degree)
+	out[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range out {
+			out[j] = gfMul(out[j], root)
+			if j+1 < degree {
+				out[j] ^= out[j+1]
+			}
+		}
+		root = gfMul(root, 2)
+	}
+	return out
+}
+
+func rsRemainder(data, divisor []byte) []byte {
+	out := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ out[0]
+		copy(out, out[1:])
+		out[len(out)-1] = 0
+		for i, c := range divisor {
+			out[i] ^= gfMul(c, factor)
+		}
+	}
+	return out
+}
+
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+
+	n := version/7 + 2
+	step := (version*8 + n*3 + 5) / (n*4 - 4) * 2
+	out := make([]int, n)
+	out[0] = 6
+	for i, pos := n-1, version*4+10; i > 0; i, pos = i-1, pos-step {
+		out[i] = pos
+	}
+	return out
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func (m *matrix) drawFunctions(version int, level Level) {
+	for i := 0; i < m.size; i++ {
+		m.set(6, i, i%2 == 0)
+		m.set(i, 6, i%2 == 0)
+	}
+
+	for _, c := range [][2]int{{3, 3}, {m.size - 4, 3}, {3, m.size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x >= 0 && x < m.size && y >= 0 && y < m.size {
+					d := max(abs(dx), abs(dy))
+					m.set(x, y, d != 2 && d != 4)
+				}
+			}
+		}
+	}
+
+	pos := alignmentPositions(version)
+	for i := range pos {
+		for j := range pos {
+			// the corners taken by finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == len(pos)-1) || (i == len(pos)-1 && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					m.set(pos[i]+dx, pos[j]+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// reserve the format areas until the mask is known
+	m.drawFormat(level, 0)
+
+	if version >= 7 {
+		rem := version
+		for i := 0; i < 12; i++ {
+			rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+		}
+		bits := version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := (bits>>uint(i))&1 == 1
+			a, b := m.size-11+i%3, i/3
+			m.set(a, b, dark)
+			m.set(b, a, dark)
+		}
+	}
+}
+
+func (m *matrix) drawFormat(level Level, mask int) {
+	data := formatBits[level]<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return (bits>>uint(i))&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		m.set(8, i, bit(i))
+	}
+	m.set(8, 7, bit(6))
+	m.set(8, 8, bit(7))
+	m.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		m.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		m.set(m.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		m.set(8, m.size-15+i, bit(i))
+	}
+	m.set(8, m.size-8, true)
+}
+
+// drawCodewords places the bits in the zigzag order, two columns at a time from the bottom right
+func (m *matrix) drawCodewords(data []byte) {
+	i := 0
+	for right := m.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < m.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = m.size - 1 - vert
+				}
+				if !m.function[y][x] && i < len(data)*8 {
+					m.dark[y][x] = (data[i>>3]>>uint(7-i&7))&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+func (m *matrix) applyMask(mask int) {
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip && !m.function[y][x] {
+				m.dark[y][x] = !m.dark[y][x]
+			}
+		}
+	}
+}
+
+// finderLike are the 1:1:3:1:1 patterns with a light border penalised by rule 3
+var finderLike = [2][11]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// penalty scores a masked symbol; the mask with the lowest score is used
+func (m *matrix) penalty() int {
+	score := 0
+	at := func(i, j int, rows bool) bool {
+		if rows {
+			return m.dark[i][j]
+		}
+		return m.dark[j][i]
+	}
+
+	for _, rows := range []bool{true, false} {
+		for i := 0; i < m.size; i++ {
+			run := 1
+			for j := 1; j < m.size; j++ {
+				if at(i, j, rows) == at(i, j-1, rows) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += 3 + run - 5
+				}
+				run = 1
+			}
+			if run >= 5 {
+				score += 3 + run - 5
+			}
+
+			for j := 0; j+11 <= m.size; j++ {
+				for _, p := range finderLike {
+					match := true
+					for k, dark := range p {
+						if at(i, j+k, rows) != dark {
+							match = false
+							break
+						}
+					}
+					if match {
+						score += 40
+					}
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if m.dark[y][x] {
+				dark++
+			}
+			if x > 0 && y > 0 {
+				c := m.dark[y][x]
+				if c == m.dark[y][x-1] && c == m.dark[y-1][x] && c == m.dark[y-1][x-1] {
+					score += 3
+				}
+			}
+		}
+	}
+
+	total := m.size * m.size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	score += k * 10
+
+	return score
+}
@@ -0,0 +1,183 @@
+package qrcode
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// quietZone is the light border, in modules, scanners need around the symbol
+const quietZone = 4
+
+// Code is an encoded QR code rendered Size pixels wide
+type Code struct {
+	Version int
+	Level   Level
+	// Size is the width in pixels of PNG output and of the SVG viewport. Modules are
+	// whole pixels, so PNGs may be a little smaller, and never below one pixel per module.
+	Size int
+	m    *matrix
+}
+
+// New encodes data in byte mode at the given error correction level
+func New(data string, level Level, size int) (*Code, error) {
+	m, version, err := encode([]byte(data), level)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Code{Version: version, Level: level, Size: size, m: m}, nil
+}
+
+// Modules is the number of modules per side, without the quiet zone
+func (c *Code) Modules() int {
+	return c.m.size
+}
+
+// Dark reports whether the module at column x and row y is dark
+func (c *Code) Dark(x, y int) bool {
+	return c.m.dark[y][x]
+}
+
+// Image renders the code with its quiet zone
+func (c *Code) Image() image.Image {
+	n := c.m.size + 2*quietZone
+	scale := c.Size / n
+	if scale < 1 {
+		scale = 1
+	}
+
+	img := image.NewPaletted(image.Rect(0, 0, n*scale, n*scale), color.Palette{color.White, color.Black})
+	for y := 0; y < c.m.size; y++ {
+		for x := 0; x < c.m.size; x++ {
+			if !c.m.dark[y][x] {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex((x+quietZone)*scale+dx, (y+quietZone)*scale+dy, 1)
+				}
+			}
+		}
+	}
+
+	return img
+}
+
+// PNG returns the code as a PNG image
+func (c *Code) PNG() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.Image()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG returns the code as an SVG image drawn with a single path
+func (c *Code) SVG() []byte {
+	n := c.m.size + 2*quietZone
+	size := c.Size
+	if size <= 0 {
+		size = n * 4
+	}
+
+	var path strings.Builder
+	for y := 0; y < c.m.size; y++ {
+		for x := 0; x < c.m.size; x++ {
+			if c.m.dark[y][x] {
+				fmt.Fprintf(&path, "M%d,%dh1v1h-1z", x+quietZone, y+quietZone)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, n, n)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="%s"/></svg>`, n, n, path.String())
+
+	return buf.Bytes()
+}
+
+// DataURI returns the PNG as a data: URI for an img src
+func (c *Code) DataURI() (template.URL, error) {
+	b, err := c.PNG()
+	if err != nil {
+		return "", err
+	}
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(b)), nil
+}
+
+// Write sends the code as a "png" or "svg" response
+func (c *Code) Write(rw http.ResponseWriter, format string) error {
+	var b []byte
+	switch strings.ToLower(format) {
+	case "svg":
+		b = c.SVG()
+		rw.Header().Set("Content-Type", "image/svg+xml")
+	case "png", "":
+		var err error
+		if b, err = c.PNG(); err != nil {
+			return err
+		}
+		rw.Header().Set("Content-Type", "image/png")
+	default:
+		return fmt.Errorf("qrcode: unknown format %q", format)
+	}
+
+	rw.Header().Set("Cache-Control", "private, max-age=300")
+	_, err := rw.Write(b)
+	return err
+}
+
+// Save writes the code to a file, as SVG when the name ends in .svg and PNG otherwise
+func (c *Code) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".svg") {
+		return ioutil.WriteFile(path, c.SVG(), 0644)
+	}
+
+	b, err := c.PNG()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// OTPAuthURI returns the provisioning URI authenticator apps scan to add a TOTP account;
+// secret is the base32 shared secret
+func OTPAuthURI(issuer, account, secret string) string {
+	q := url.Values{}
+	q.Set("secret", strings.TrimRight(strings.ToUpper(secret), "="))
+	if issuer != "" {
+		q.Set("issuer", issuer)
+		account = issuer + ":" + account
+	}
+
+	return "otpauth://totp/" + url.PathEscape(account) + "?" + q.Encode()
+}
+
+// TemplateFuncs adds qrcode, which renders data as a PNG data URI of the given size:
+// <img src="{{qrcode .CheckInURL 200}}">
+func TemplateFuncs(r *http.Request) template.FuncMap {
+	return template.FuncMap{
+		"qrcode": func(data string, size int) (template.URL, error) {
+			c, err := New(data, Medium, size)
+			if err != nil {
+				return "", err
+			}
+			return c.DataURI()
+		},
+	}
+}
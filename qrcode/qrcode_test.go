@@ -0,0 +1,196 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCapacity(t *testing.T) {
+	for _, tt := range []struct {
+		level   Level
+		length  int
+		version int
+	}{
+		{Low, 17, 1},
+		{Low, 18, 2},
+		{Medium, 14, 1},
+		{High, 7, 1},
+		{Low, 271, 10},
+		{Medium, 213, 10},
+		{Medium, 214, 11},
+		{Low, 2953, 40},
+	} {
+		c, err := New(strings.Repeat("a", tt.length), tt.level, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Version != tt.version || c.Modules() != tt.version*4+17 {
+			t.Errorf("%d bytes at level %d: expected version %d, got %d", tt.length, tt.level, tt.version, c.Version)
+		}
+	}
+
+	if _, err := New(strings.Repeat("a", 2954), Low, 0); err != ErrTooLong {
+		t.Errorf("expected too long, got %v", err)
+	}
+}
+
+func TestReedSolomon(t *testing.T) {
+	// a codeword block with its remainder is divisible by the generator: it has no syndromes
+	data := []byte("check-in 1234")
+	block := append(append([]byte{}, data...), rsRemainder(data, rsDivisor(10))...)
+
+	root := byte(1)
+	for i := 0; i < 10; i++ {
+		var s byte
+		for _, b := range block {
+			s = gfMul(s, root) ^ b
+		}
+		if s != 0 {
+			t.Fatalf("syndrome %d is %d", i, s)
+		}
+		root = gfMul(root, 2)
+	}
+}
+
+// readCodewords walks the symbol in placement order, reading back the unmasked data modules
+func readCodewords(m *matrix, mask int) []byte {
+	m.applyMask(mask)
+	defer m.applyMask(mask)
+
+	var out []byte
+	var b byte
+	n := 0
+	for right := m.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < m.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = m.size - 1 - vert
+				}
+				if m.function[y][x] {
+					continue
+				}
+				b <<= 1
+				if m.dark[y][x] {
+					b |= 1
+				}
+				if n++; n%8 == 0 {
+					out = append(out, b)
+				}
+			}
+		}
+	}
+	return out
+}
+
+func TestRoundTrip(t *testing.T) {
+	data := []byte("https://example.com/tickets/42?sig=abc")
+
+	for level := Low; level <= High; level++ {
+		m, version, err := encode(data, level)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the format information names the level and the mask in use
+		var format int
+		for i := 0; i <= 5; i++ {
+			if m.dark[i][8] {
+				format |= 1 << uint(i)
+			}
+		}
+		bits := [][2]int{{8, 7}, {8, 8}, {7, 8}}
+		for i, p := range bits {
+			if m.dark[p[1]][p[0]] {
+				format |= 1 << uint(6+i)
+			}
+		}
+		for i := 9; i < 15; i++ {
+			if m.dark[8][14-i] {
+				format |= 1 << uint(i)
+			}
+		}
+		format ^= 0x5412
+		if formatBits[level] != format>>13 {
+			t.Fatalf("level %d: format bits %015b", level, format)
+		}
+		mask := format >> 10 & 7
+
+		want := addECC(segment(data, version, level), version, level)
+		got := readCodewords(m, mask)
+		if !bytes.Equal(got[:len(want)], want) {
+			t.Errorf("level %d: codewords differ", level)
+		}
+	}
+}
+
+func TestAlignmentPositions(t *testing.T) {
+	for version, want := range map[int][]int{2: {6, 18}, 7: {6, 22, 38}, 32: {6, 34, 60, 86, 112, 138}, 40: {6, 30, 58, 86, 114, 142, 170}} {
+		got := alignmentPositions(version)
+		if len(got) != len(want) {
+			t.Fatalf("version %d: got %v", version, got)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("version %d: got %v, want %v", version, got, want)
+				break
+			}
+		}
+	}
+}
+
+func TestOutput(t *testing.T) {
+	c, err := New("hello", Medium, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(mustPNG(t, c)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 21 modules and a quiet zone of 4 on each side, 6 pixels per module
+	if img.Bounds().Dx() != 174 {
+		t.Errorf("unexpected width %d", img.Bounds().Dx())
+	}
+
+	if svg := string(c.SVG()); !strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="200" height="200" viewBox="0 0 29 29"`) {
+		t.Errorf("unexpected svg %s", svg[:80])
+	}
+
+	uri, _ := c.DataURI()
+	if !strings.HasPrefix(string(uri), "data:image/png;base64,") {
+		t.Errorf("unexpected data uri %s", uri[:30])
+	}
+
+	rr := httptest.NewRecorder()
+	if err := c.Write(rr, "svg"); err != nil || rr.Header().Get("Content-Type") != "image/svg+xml" {
+		t.Errorf("unexpected response %v %v", err, rr.Header())
+	}
+
+	if err := c.Save(filepath.Join(t.TempDir(), "codes", "hello.png")); err != nil {
+		t.Error(err)
+	}
+}
+
+func mustPNG(t *testing.T, c *Code) []byte {
+	b, err := c.PNG()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestOTPAuthURI(t *testing.T) {
+	got := OTPAuthURI("Acme Shop", "ann@example.com", "jbswy3dpehpk3pxp")
+	if got != "otpauth://totp/Acme%20Shop:ann@example.com?issuer=Acme+Shop&secret=JBSWY3DPEHPK3PXP" {
+		t.Errorf("unexpected uri %s", got)
+	}
+}
@@ -0,0 +1,226 @@
+package queuedash
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/justinas/nosurf"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// QueueStats describes a single queue
+type QueueStats struct {
+	Name      string
+	Pending   int
+	Delayed   int
+	Processed int
+	Failed    int
+	// Throughput is the number of jobs processed per minute
+	Throughput float64
+}
+
+// FailedJob is a job that exhausted its retries
+type FailedJob struct {
+	ID       string
+	Queue    string
+	Type     string
+	Payload  string
+	Error    string
+	Stack    string
+	Attempts int
+	FailedAt time.Time
+}
+
+// WorkerStatus describes a running worker
+type WorkerStatus struct {
+	ID       string
+	Queue    string
+	Busy     bool
+	Job      string
+	JobID    string
+	LastSeen time.Time
+	// Progress is the one of the running job, when it reports any
+	Progress *JobProgress
+}
+
+// JobProgress is how far a job got
+type JobProgress struct {
+	ID              string    `json:"id"`
+	Type            string    `json:"type"`
+	State           string    `json:"state"`
+	Percent         int       `json:"percent"`
+	Message         string    `json:"message,omitempty"`
+	CancelRequested bool      `json:"cancel_requested,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// ErrNotFound is returned by a source for a job it does not know
+var ErrNotFound = errors.New("queuedash: job not found")
+
+// Source is implemented by the job queue to feed the dashboard
+type Source interface {
+	Stats() ([]QueueStats, error)
+	Failed(limit int) ([]FailedJob, error)
+	Retry(id string) error
+	Forget(id string) error
+	Workers() ([]WorkerStatus, error)
+	// Progress returns the progress of a job, or ErrNotFound
+	Progress(id string) (*JobProgress, error)
+	// Cancel asks a job to stop, returning ErrNotFound for an unknown job
+	Cancel(id string) error
+}
+
+// Dashboard serves an overview of the queues
+type Dashboard struct {
+	Source  Source
+	Session *scs.SessionManager
+	// Authorize decides who can see the dashboard; by default any logged in user can
+	Authorize func(r *http.Request) bool
+	// LoginURL is where unauthorized visitors are redirected
+	LoginURL string
+	// Prefix is the path the routes are mounted on
+	Prefix string
+}
+
+type pageData struct {
+	Prefix    string
+	Queues    []QueueStats
+	Failed    []FailedJob
+	Workers   []WorkerStatus
+	CSRFToken string
+}
+
+// New returns a dashboard reading from the given source
+func New(source Source, session *scs.SessionManager) *Dashboard {
+	return &Dashboard{
+		Source:   source,
+		Session:  session,
+		LoginURL: "/users/login",
+		Prefix:   "/queues",
+	}
+}
+
+// Routes returns the dashboard handlers, meant to be mounted with Routes.Mount("/queues", ...)
+func (d *Dashboard) Routes() http.Handler {
+	mux := chi.NewRouter()
+	mux.Use(d.auth)
+
+	mux.Get("/", d.index)
+	mux.Post("/failed/{id}/retry", d.retry)
+	mux.Post("/failed/{id}/forget", d.forget)
+	mux.Get("/jobs/{id}", d.progress)
+	mux.Post("/jobs/{id}/cancel", d.cancel)
+
+	return mux
+}
+
+func (d *Dashboard) auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		allowed := false
+		if d.Authorize != nil {
+			allowed = d.Authorize(r)
+		} else if d.Session != nil {
+			allowed = d.Session.Exists(r.Context(), "userID")
+		}
+
+		if !allowed {
+			http.Redirect(rw, r, d.LoginURL, http.StatusSeeOther)
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+func (d *Dashboard) index(rw http.ResponseWriter, r *http.Request) {
+	data := pageData{Prefix: d.Prefix, CSRFToken: nosurf.Token(r)}
+
+	var err error
+	if data.Queues, err = d.Source.Stats(); err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if data.Failed, err = d.Source.Failed(50); err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if data.Workers, err = d.Source.Workers(); err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tmpl, err := template.ParseFS(templateFS, "templates/dashboard.page.tmpl")
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tmpl.Execute(rw, data); err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (d *Dashboard) retry(rw http.ResponseWriter, r *http.Request) {
+	if err := d.Source.Retry(chi.URLParam(r, "id")); err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(rw, r, d.Prefix, http.StatusSeeOther)
+}
+
+func (d *Dashboard) forget(rw http.ResponseWriter, r *http.Request) {
+	if err := d.Source.Forget(chi.URLParam(r, "id")); err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(rw, r, d.Prefix, http.StatusSeeOther)
+}
+
+// progress answers the progress of a job as json, for pages polling it
+func (d *Dashboard) progress(rw http.ResponseWriter, r *http.Request) {
+	p, err := d.Source.Progress(chi.URLParam(r, "id"))
+	if errors.Is(err, ErrNotFound) {
+		http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(rw).Encode(p)
+}
+
+// cancel asks a job to stop, going back to the dashboard, or answering 202 to a request
+// for json
+func (d *Dashboard) cancel(rw http.ResponseWriter, r *http.Request) {
+	err := d.Source.Cancel(chi.URLParam(r, "id"))
+	if errors.Is(err, ErrNotFound) {
+		http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		rw.WriteHeader(http.StatusAccepted)
+		return
+	}
+	http.Redirect(rw, r, d.Prefix, http.StatusSeeOther)
+}
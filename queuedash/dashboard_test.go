@@ -0,0 +1,101 @@
+package queuedash
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeSource struct {
+	retried   string
+	cancelled string
+}
+
+func (f *fakeSource) Stats() ([]QueueStats, error) {
+	return []QueueStats{{Name: "emails", Pending: 4, Throughput: 12.5}}, nil
+}
+
+func (f *fakeSource) Failed(limit int) ([]FailedJob, error) {
+	return []FailedJob{{ID: "j1", Queue: "emails", Type: "SendWelcome", Error: "smtp timeout", Stack: "main.go:12", FailedAt: time.Now()}}, nil
+}
+
+func (f *fakeSource) Retry(id string) error {
+	f.retried = id
+	return nil
+}
+
+func (f *fakeSource) Forget(id string) error {
+	return nil
+}
+
+func (f *fakeSource) Workers() ([]WorkerStatus, error) {
+	p, _ := f.Progress("j2")
+	return []WorkerStatus{{ID: "w1", Queue: "emails", Busy: true, Job: "SendWelcome", JobID: "j2", LastSeen: time.Now(), Progress: p}}, nil
+}
+
+func (f *fakeSource) Progress(id string) (*JobProgress, error) {
+	if id != "j2" {
+		return nil, ErrNotFound
+	}
+	return &JobProgress{ID: "j2", Type: "SendWelcome", State: "running", Percent: 40, Message: "4 of 10 sent"}, nil
+}
+
+func (f *fakeSource) Cancel(id string) error {
+	if id != "j2" {
+		return ErrNotFound
+	}
+	f.cancelled = id
+	return nil
+}
+
+func TestDashboard(t *testing.T) {
+	src := &fakeSource{}
+	d := New(src, nil)
+
+	rw := httptest.NewRecorder()
+	d.Routes().ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	if rw.Code != http.StatusSeeOther {
+		t.Errorf("expected unauthenticated redirect, got %d", rw.Code)
+	}
+
+	d.Authorize = func(r *http.Request) bool { return true }
+
+	rw = httptest.NewRecorder()
+	d.Routes().ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	body := rw.Body.String()
+	for _, want := range []string{"emails", "12.5", "smtp timeout", "main.go:12", "running SendWelcome", "4 of 10 sent", "/queues/jobs/j2/cancel"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in dashboard", want)
+		}
+	}
+
+	rw = httptest.NewRecorder()
+	d.Routes().ServeHTTP(rw, httptest.NewRequest("POST", "/failed/j1/retry", nil))
+	if src.retried != "j1" || rw.Code != http.StatusSeeOther {
+		t.Errorf("retry not forwarded to the source, got %q %d", src.retried, rw.Code)
+	}
+
+	rw = httptest.NewRecorder()
+	d.Routes().ServeHTTP(rw, httptest.NewRequest("GET", "/jobs/j2", nil))
+	var p JobProgress
+	if err := json.NewDecoder(rw.Body).Decode(&p); err != nil || p.Percent != 40 {
+		t.Errorf("expected the progress as json, got %d %v %+v", rw.Code, err, p)
+	}
+
+	rw = httptest.NewRecorder()
+	d.Routes().ServeHTTP(rw, httptest.NewRequest("GET", "/jobs/nope", nil))
+	if rw.Code != http.StatusNotFound {
+		t.Errorf("expected a 404 for an unknown job, got %d", rw.Code)
+	}
+
+	r := httptest.NewRequest("POST", "/jobs/j2/cancel", nil)
+	r.Header.Set("Accept", "application/json")
+	rw = httptest.NewRecorder()
+	d.Routes().ServeHTTP(rw, r)
+	if src.cancelled != "j2" || rw.Code != http.StatusAccepted {
+		t.Errorf("cancel not forwarded to the source, got %q %d", src.cancelled, rw.Code)
+	}
+}
@@ -0,0 +1,546 @@
+package redisconn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// slots is the number of hash slots of a cluster
+const slots = 16384
+
+// cluster knows which node serves each slot, and keeps a pool per node
+type cluster struct {
+	seeds []string
+	opts  []redis.DialOption
+
+	mu         sync.RWMutex
+	owner      []string
+	masters    []string
+	pools      map[string]*redis.Pool
+	refreshing bool
+}
+
+func newCluster(seeds []string, opts []redis.DialOption) (*cluster, error) {
+	cl := &cluster{seeds: seeds, opts: opts, pools: map[string]*redis.Pool{}}
+	if err := cl.refresh(context.Background()); err != nil {
+		return nil, err
+	}
+	return cl, nil
+}
+
+// pool returns the pool of the node at addr
+func (cl *cluster) pool(addr string) *redis.Pool {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	p := cl.pools[addr]
+	if p == nil {
+		p = &redis.Pool{
+			MaxIdle:     8,
+			IdleTimeout: 4 * time.Minute,
+			DialContext: func(ctx context.Context) (redis.Conn, error) {
+				return redis.DialContext(ctx, "tcp", addr, cl.opts...)
+			},
+		}
+		cl.pools[addr] = p
+	}
+	return p
+}
+
+// refresh loads the owner of every slot with CLUSTER SLOTS, from the first node which answers
+func (cl *cluster) refresh(ctx context.Context) error {
+	cl.mu.RLock()
+	nodes := append(append([]string{}, cl.masters...), cl.seeds...)
+	cl.mu.RUnlock()
+
+	last := errors.New("no nodes")
+	for _, addr := range nodes {
+		conn, err := cl.pool(addr).GetContext(ctx)
+		if err != nil {
+			last = err
+			continue
+		}
+		reply, err := redis.Values(redis.DoContext(conn, ctx, "CLUSTER", "SLOTS"))
+		conn.Close()
+		if err != nil {
+			last = err
+			continue
+		}
+
+		owner, masters := parseSlots(reply, addr)
+		if len(masters) == 0 {
+			last = errors.New("no slots are served")
+			continue
+		}
+
+		cl.mu.Lock()
+		cl.owner, cl.masters = owner, masters
+		cl.mu.Unlock()
+		return nil
+	}
+	return fmt.Errorf("redisconn: loading the cluster slots: %w", last)
+}
+
+// parseSlots reads a CLUSTER SLOTS reply: ranges of slots with their master first, then replicas
+func parseSlots(reply []interface{}, from string) ([]string, []string) {
+	owner := make([]string, slots)
+	seen := map[string]bool{}
+	var masters []string
+
+	for _, r := range reply {
+		rng, err := redis.Values(r, nil)
+		if err != nil || len(rng) < 3 {
+			continue
+		}
+		start, _ := redis.Int(rng[0], nil)
+		end, _ := redis.Int(rng[1], nil)
+		node, err := redis.Values(rng[2], nil)
+		if err != nil || len(node) < 2 {
+			continue
+		}
+		host, _ := redis.String(node[0], nil)
+		port, _ := redis.Int(node[1], nil)
+		if host == "" {
+			// a node which does not know its own address reports it empty
+			host, _, _ = net.SplitHostPort(from)
+		}
+
+		addr := net.JoinHostPort(host, strconv.Itoa(port))
+		for s := start; s <= end && s < slots; s++ {
+			owner[s] = addr
+		}
+		if !seen[addr] {
+			seen[addr] = true
+			masters = append(masters, addr)
+		}
+	}
+
+	sort.Strings(masters)
+	return owner, masters
+}
+
+// addr returns the node serving slot, or any master for a command without a key
+func (cl *cluster) addr(slot int) (string, error) {
+	cl.mu.RLock()
+	defer cl.mu.RUnlock()
+
+	if slot >= 0 && cl.owner[slot] != "" {
+		return cl.owner[slot], nil
+	}
+	if len(cl.masters) > 0 {
+		return cl.masters[0], nil
+	}
+	return "", errors.New("redisconn: no cluster nodes")
+}
+
+func (cl *cluster) nodes() []string {
+	cl.mu.RLock()
+	defer cl.mu.RUnlock()
+
+	return append([]string{}, cl.masters...)
+}
+
+// moved records the new owner of slot, and reloads every slot in the background since a
+// resharding rarely moves a single one
+func (cl *cluster) moved(slot int, addr string) {
+	cl.mu.Lock()
+	if slot >= 0 {
+		cl.owner[slot] = addr
+	}
+	start := !cl.refreshing
+	cl.refreshing = true
+	cl.mu.Unlock()
+
+	if start {
+		go func() {
+			_ = cl.refresh(context.Background())
+			cl.mu.Lock()
+			cl.refreshing = false
+			cl.mu.Unlock()
+		}()
+	}
+}
+
+func (cl *cluster) conn() *clusterConn {
+	return &clusterConn{cl: cl, conns: map[string]redis.Conn{}}
+}
+
+var errClosed = errors.New("redisconn: connection closed")
+
+type command struct {
+	name string
+	args []interface{}
+}
+
+// clusterConn is a redis.Conn sending each command to the node of its key. Pipelined commands
+// are sent one by one, and a transaction goes to the node of its first key, so every key of a
+// transaction or a script must hash to the same slot, e.g. by sharing a {hash tag}.
+type clusterConn struct {
+	cl      *cluster
+	conns   map[string]redis.Conn
+	pending []command
+	replies []interface{}
+	err     error
+}
+
+func (c *clusterConn) Close() error {
+	c.release()
+	c.err = errClosed
+	return nil
+}
+
+func (c *clusterConn) Err() error {
+	return c.err
+}
+
+// node returns the connection to addr, borrowed from its pool until release
+func (c *clusterConn) node(ctx context.Context, addr string) (redis.Conn, error) {
+	if conn, ok := c.conns[addr]; ok && conn.Err() == nil {
+		return conn, nil
+	}
+	conn, err := c.cl.pool(addr).GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.conns[addr] = conn
+	return conn, nil
+}
+
+func (c *clusterConn) release() {
+	for addr, conn := range c.conns {
+		conn.Close()
+		delete(c.conns, addr)
+	}
+}
+
+func (c *clusterConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	return c.DoContext(context.Background(), cmd, args...)
+}
+
+// DoContext runs the pending commands and cmd, returning the reply of cmd and the first error
+// reply, like a redigo connection; an empty cmd returns the replies of the pending commands
+func (c *clusterConn) DoContext(ctx context.Context, cmd string, args ...interface{}) (interface{}, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if cmd == "" && len(c.pending) == 0 {
+		return nil, nil
+	}
+	if cmd != "" {
+		c.pending = append(c.pending, command{name: cmd, args: args})
+	}
+
+	replies, err := c.exec(ctx, c.pending)
+	c.pending = nil
+	c.release()
+	if err != nil {
+		return nil, err
+	}
+	if cmd == "" {
+		return replies, nil
+	}
+
+	for _, r := range replies {
+		if e, ok := r.(redis.Error); ok {
+			err = e
+			break
+		}
+	}
+	return replies[len(replies)-1], err
+}
+
+func (c *clusterConn) Send(cmd string, args ...interface{}) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.pending = append(c.pending, command{name: cmd, args: args})
+	return nil
+}
+
+func (c *clusterConn) Flush() error {
+	if c.err != nil {
+		return c.err
+	}
+
+	replies, err := c.exec(context.Background(), c.pending)
+	c.pending = nil
+	c.release()
+	if err != nil {
+		return err
+	}
+	c.replies = append(c.replies, replies...)
+	return nil
+}
+
+func (c *clusterConn) Receive() (interface{}, error) {
+	if len(c.replies) == 0 {
+		if len(c.pending) == 0 {
+			return nil, errors.New("redisconn: no pending replies")
+		}
+		if err := c.Flush(); err != nil {
+			return nil, err
+		}
+	}
+
+	r := c.replies[0]
+	c.replies = c.replies[1:]
+	if e, ok := r.(redis.Error); ok {
+		return nil, e
+	}
+	return r, nil
+}
+
+func (c *clusterConn) ReceiveContext(ctx context.Context) (interface{}, error) {
+	return c.Receive()
+}
+
+// exec runs cmds in order, a reply per command; error replies are replies, other errors end it
+func (c *clusterConn) exec(ctx context.Context, cmds []command) ([]interface{}, error) {
+	var out []interface{}
+	for i := 0; i < len(cmds); i++ {
+		if strings.EqualFold(cmds[i].name, "MULTI") {
+			j := i + 1
+			for j < len(cmds) && !strings.EqualFold(cmds[j].name, "EXEC") && !strings.EqualFold(cmds[j].name, "DISCARD") {
+				j++
+			}
+			if j == len(cmds) {
+				return nil, errors.New("redisconn: a transaction is sent with its EXEC on a cluster")
+			}
+
+			replies, err := c.transaction(ctx, cmds[i:j+1])
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, replies...)
+			i = j
+			continue
+		}
+
+		r, err := c.run(ctx, cmds[i])
+		if e, ok := err.(redis.Error); ok {
+			r, err = e, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// run sends cmd to the node of its key, following the redirections of a resharding
+func (c *clusterConn) run(ctx context.Context, cmd command) (interface{}, error) {
+	if strings.EqualFold(cmd.name, "SCAN") {
+		return c.scan(ctx, cmd.args)
+	}
+
+	slot := -1
+	if key, ok := firstKey(cmd); ok {
+		slot = Slot(key)
+	}
+	addr, err := c.cl.addr(slot)
+	if err != nil {
+		return nil, err
+	}
+
+	asking := false
+	for attempt := 0; ; attempt++ {
+		conn, err := c.node(ctx, addr)
+		if err != nil {
+			return nil, err
+		}
+		if asking {
+			_ = conn.Send("ASKING")
+		}
+		reply, err := redis.DoContext(conn, ctx, cmd.name, cmd.args...)
+
+		e, ok := err.(redis.Error)
+		if !ok || attempt >= 5 {
+			return reply, err
+		}
+		switch redirect, to := redirection(e); redirect {
+		case "MOVED":
+			c.cl.moved(slot, to)
+			addr, asking = to, false
+		case "ASK":
+			addr, asking = to, true
+		case "TRYAGAIN", "CLUSTERDOWN":
+			time.Sleep(100 * time.Millisecond)
+		default:
+			return reply, err
+		}
+	}
+}
+
+// transaction sends MULTI, the queued commands and EXEC to the node of the first key
+func (c *clusterConn) transaction(ctx context.Context, cmds []command) ([]interface{}, error) {
+	slot := -1
+	for _, cmd := range cmds[1 : len(cmds)-1] {
+		if key, ok := firstKey(cmd); ok {
+			slot = Slot(key)
+			break
+		}
+	}
+	addr, err := c.cl.addr(slot)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		conn, err := c.node(ctx, addr)
+		if err != nil {
+			return nil, err
+		}
+		for _, cmd := range cmds {
+			if err := conn.Send(cmd.name, cmd.args...); err != nil {
+				return nil, err
+			}
+		}
+		reply, err := redis.Values(redis.DoContext(conn, ctx, ""))
+		if err != nil {
+			return nil, err
+		}
+
+		// a command queued on a node which lost its slot is refused; the whole transaction
+		// then moves to the new owner
+		moved := ""
+		for _, r := range reply {
+			if e, ok := r.(redis.Error); ok {
+				if redirect, to := redirection(e); redirect == "MOVED" {
+					moved = to
+				}
+			}
+		}
+		if moved == "" || attempt >= 2 {
+			return reply, nil
+		}
+		c.cl.moved(slot, moved)
+		addr = moved
+	}
+}
+
+// scan runs SCAN on every master in turn. The cursor given back holds the position on the
+// current master and its index, so an iteration going round as usual sees every key.
+func (c *clusterConn) scan(ctx context.Context, args []interface{}) (interface{}, error) {
+	nodes := c.cl.nodes()
+	if len(nodes) == 0 || len(args) == 0 {
+		return nil, errors.New("redisconn: SCAN needs a cursor and cluster nodes")
+	}
+	n := uint64(len(nodes))
+
+	cursor, err := strconv.ParseUint(fmt.Sprint(redisString(args[0])), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("redisconn: invalid SCAN cursor %v", args[0])
+	}
+	node, inner := cursor%n, cursor/n
+
+	conn, err := c.node(ctx, nodes[node])
+	if err != nil {
+		return nil, err
+	}
+	reply, err := redis.Values(redis.DoContext(conn, ctx, "SCAN", append([]interface{}{inner}, args[1:]...)...))
+	if err != nil {
+		return nil, err
+	}
+	if len(reply) != 2 {
+		return nil, errors.New("redisconn: unexpected SCAN reply")
+	}
+	next, err := redis.Uint64(reply[0], nil)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case next != 0:
+		cursor = next*n + node
+	case node+1 < n:
+		cursor = node + 1
+	default:
+		cursor = 0
+	}
+	return []interface{}{[]byte(strconv.FormatUint(cursor, 10)), reply[1]}, nil
+}
+
+// redirection splits an error such as "MOVED 3999 10.0.0.2:6379" into its kind and address
+func redirection(e redis.Error) (string, string) {
+	f := strings.Fields(string(e))
+	if len(f) == 0 {
+		return "", ""
+	}
+	if len(f) == 3 && (f[0] == "MOVED" || f[0] == "ASK") {
+		return f[0], f[2]
+	}
+	return f[0], ""
+}
+
+// keyless are the commands used by the framework which have no key
+var keyless = map[string]bool{
+	"PING": true, "INFO": true, "ECHO": true, "TIME": true, "ROLE": true, "SCRIPT": true,
+	"CLIENT": true, "CLUSTER": true, "DBSIZE": true, "MULTI": true, "EXEC": true, "DISCARD": true,
+	"ASKING": true, "FLUSHALL": true, "FLUSHDB": true, "UNWATCH": true,
+}
+
+// firstKey returns the key cmd is routed by
+func firstKey(cmd command) (string, bool) {
+	name := strings.ToUpper(cmd.name)
+	if keyless[name] {
+		return "", false
+	}
+
+	i := 0
+	if name == "EVAL" || name == "EVALSHA" {
+		if len(cmd.args) < 3 {
+			return "", false
+		}
+		if n, err := strconv.Atoi(fmt.Sprint(redisString(cmd.args[1]))); err != nil || n < 1 {
+			return "", false
+		}
+		i = 2
+	}
+	if len(cmd.args) <= i {
+		return "", false
+	}
+	return fmt.Sprint(redisString(cmd.args[i])), true
+}
+
+func redisString(v interface{}) interface{} {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return v
+}
+
+// Slot returns the hash slot of key, which is the one of its {hash tag} when it has one
+func Slot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return int(crc16(key) % slots)
+}
+
+// crc16 is the CRC16-CCITT (XMODEM) checksum redis hashes keys with
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
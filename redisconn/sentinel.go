@@ -0,0 +1,77 @@
+package redisconn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// masterAddr asks the sentinels in turn for the address of the master
+func (c Config) masterAddr(ctx context.Context) (string, error) {
+	sc := c
+	sc.Username, sc.Password, sc.DB = "", c.SentinelPassword, 0
+	opts := sc.options()
+
+	var last error
+	for _, s := range c.Sentinels {
+		conn, err := redis.DialContext(ctx, "tcp", s, opts...)
+		if err != nil {
+			last = err
+			continue
+		}
+		addr, err := redis.Strings(redis.DoContext(conn, ctx, "SENTINEL", "get-master-addr-by-name", c.MasterName))
+		conn.Close()
+		if err == redis.ErrNil {
+			return "", fmt.Errorf("redisconn: sentinel %s does not know master %q", s, c.MasterName)
+		}
+		if err != nil {
+			last = err
+			continue
+		}
+		if len(addr) == 2 {
+			return net.JoinHostPort(addr[0], addr[1]), nil
+		}
+	}
+
+	if last == nil {
+		last = errors.New("no sentinels")
+	}
+	return "", fmt.Errorf("redisconn: finding master %q: %w", c.MasterName, last)
+}
+
+// dialMaster connects to the current master, so new connections follow a failover
+func (c Config) dialMaster(ctx context.Context) (redis.Conn, error) {
+	addr, err := c.masterAddr(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := redis.DialContext(ctx, "tcp", addr, c.options()...)
+	if err != nil {
+		return nil, err
+	}
+	if err := isMaster(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// isMaster checks the role of the server of conn, which is still a replica for a moment
+// after sentinel promoted it
+func isMaster(conn redis.Conn) error {
+	role, err := redis.Values(conn.Do("ROLE"))
+	if err != nil {
+		return err
+	}
+	if len(role) == 0 {
+		return errors.New("redisconn: empty ROLE reply")
+	}
+	if name, _ := redis.String(role[0], nil); name != "master" {
+		return fmt.Errorf("redisconn: server is a %s, not the master", name)
+	}
+	return nil
+}
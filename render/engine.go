@@ -0,0 +1,155 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Engine renders the views of one template language. variables and data are passed on from
+// Page as they are; data is a *TemplateData for the built in engines.
+type Engine interface {
+	Render(w io.Writer, r *http.Request, view string, variables, data interface{}) error
+	RenderString(r *http.Request, view string, variables, data interface{}) (string, error)
+	// Exists reports whether the engine has a template for view
+	Exists(view string) bool
+}
+
+// EngineFactory creates an engine for a Render, reading its RootPath or Funcs
+type EngineFactory func(ren *Render) (Engine, error)
+
+var (
+	enginesMu sync.RWMutex
+	factories = map[string]EngineFactory{}
+)
+
+func init() {
+	Register("go", func(ren *Render) (Engine, error) { return goEngine{ren}, nil })
+	Register("jet", func(ren *Render) (Engine, error) { return jetEngine{ren}, nil })
+}
+
+// Register makes an engine available as RENDERER=name, or on some routes with UseEngine,
+// usually from the init function of the package implementing it. It panics when name is
+// taken or factory is nil.
+func Register(name string, factory EngineFactory) {
+	enginesMu.Lock()
+	defer enginesMu.Unlock()
+
+	if factory == nil {
+		panic("render: Register factory is nil")
+	}
+	if _, dup := factories[name]; dup {
+		panic("render: Register called twice for engine " + name)
+	}
+	factories[name] = factory
+}
+
+// Engines returns the names of the registered engines, sorted
+func Engines() []string {
+	enginesMu.RLock()
+	defer enginesMu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Engine returns the engine registered as name, created once per Render
+func (ren *Render) Engine(name string) (Engine, error) {
+	ren.mu.Lock()
+	defer ren.mu.Unlock()
+
+	if e, ok := ren.engines[name]; ok {
+		return e, nil
+	}
+
+	enginesMu.RLock()
+	factory := factories[name]
+	enginesMu.RUnlock()
+
+	if factory == nil {
+		return nil, fmt.Errorf("render: unknown engine %q", name)
+	}
+
+	e, err := factory(ren)
+	if err != nil {
+		return nil, fmt.Errorf("render: %s: %w", name, err)
+	}
+
+	if ren.engines == nil {
+		ren.engines = map[string]Engine{}
+	}
+	ren.engines[name] = e
+	return e, nil
+}
+
+type engineKey struct{}
+
+// UseEngine renders the pages of the routes it wraps with the engine registered as name
+// instead of the one of the app, e.g. r.With(render.UseEngine("templ")).Get(...)
+func UseEngine(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), engineKey{}, name)))
+		})
+	}
+}
+
+// engineName is the engine picked by UseEngine for r, or the one of the app
+func (ren *Render) engineName(r *http.Request) string {
+	if r != nil {
+		if name, ok := r.Context().Value(engineKey{}).(string); ok {
+			return name
+		}
+	}
+	return strings.ToLower(ren.Renderer)
+}
+
+// Funcs returns the template functions of the app for r, for engines which take functions
+func (ren *Render) Funcs(r *http.Request) template.FuncMap {
+	return ren.templateFuncs(r)
+}
+
+type goEngine struct{ ren *Render }
+
+func (e goEngine) Render(w io.Writer, r *http.Request, view string, variables, data interface{}) error {
+	return e.ren.GoPage(w, r, view, data)
+}
+
+func (e goEngine) RenderString(r *http.Request, view string, variables, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	err := e.Render(&buf, r, view, variables, data)
+	return buf.String(), err
+}
+
+func (e goEngine) Exists(view string) bool {
+	_, err := os.Stat(fmt.Sprintf("%s/views/%s.page.tmpl", e.ren.RootPath, view))
+	return err == nil
+}
+
+type jetEngine struct{ ren *Render }
+
+func (e jetEngine) Render(w io.Writer, r *http.Request, view string, variables, data interface{}) error {
+	return e.ren.JetPage(w, r, view, variables, data)
+}
+
+func (e jetEngine) RenderString(r *http.Request, view string, variables, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	err := e.Render(&buf, r, view, variables, data)
+	return buf.String(), err
+}
+
+func (e jetEngine) Exists(view string) bool {
+	_, err := os.Stat(fmt.Sprintf("%s/views/%s.jet", e.ren.RootPath, view))
+	return err == nil
+}
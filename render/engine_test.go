@@ -0,0 +1,54 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// echoEngine writes the name of the view
+type echoEngine struct{}
+
+func (echoEngine) Render(w io.Writer, r *http.Request, view string, variables, data interface{}) error {
+	_, err := fmt.Fprintf(w, "echo %s", view)
+	return err
+}
+
+func (e echoEngine) RenderString(r *http.Request, view string, variables, data interface{}) (string, error) {
+	return "echo " + view, nil
+}
+
+func (echoEngine) Exists(view string) bool { return view == "home" }
+
+func TestRegister(t *testing.T) {
+	Register("echo", func(ren *Render) (Engine, error) { return echoEngine{}, nil })
+
+	ren := &Render{Renderer: "jet", RootPath: "./testdata", JetViews: views}
+
+	// the route picks the engine over the one of the app
+	h := UseEngine("echo")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := ren.Page(w, r, "home", nil, nil); err != nil {
+			t.Error(err)
+		}
+	}))
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rw.Body.String() != "echo home" {
+		t.Errorf("expected the echo engine, got %q", rw.Body.String())
+	}
+
+	ren.Renderer = "Echo"
+	if !ren.Exists("home") || ren.Exists("about") {
+		t.Error("expected Exists to ask the engine of the app")
+	}
+	if s, _ := ren.String(httptest.NewRequest(http.MethodGet, "/", nil), "about", nil, nil); s != "echo about" {
+		t.Errorf("unexpected string %q", s)
+	}
+
+	ren.Renderer = "templ"
+	if err := ren.Page(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "home", nil, nil); err == nil {
+		t.Error("expected an error for an unknown engine")
+	}
+}
@@ -0,0 +1,208 @@
+package render
+
+import (
+	"encoding/gob"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/justinas/nosurf"
+)
+
+const (
+	// OldInputKey is the session key holding the form values flashed before a redirect
+	OldInputKey = "_old_input"
+	// ErrorBagKey is the session key holding the validation errors flashed before a redirect
+	ErrorBagKey = "_errors"
+)
+
+func init() {
+	gob.Register(map[string]string{})
+}
+
+// Form builds HTML form controls for a single request, carrying the CSRF token,
+// the flashed old input and the validation error bag
+type Form struct {
+	CSRFToken  string
+	Old        map[string]string
+	Errors     map[string]string
+	InputClass string
+	ErrorClass string
+}
+
+// form returns the form builder for the current request; old input and errors are popped
+// from the session so they are only shown once
+func (ren *Render) form(r *http.Request) *Form {
+	f := &Form{
+		CSRFToken:  nosurf.Token(r),
+		Old:        map[string]string{},
+		Errors:     map[string]string{},
+		InputClass: "form-control",
+		ErrorClass: "is-invalid",
+	}
+
+	if ren.Session == nil {
+		return f
+	}
+
+	if old, ok := ren.Session.Pop(r.Context(), OldInputKey).(map[string]string); ok {
+		f.Old = old
+	}
+
+	if errs, ok := ren.Session.Pop(r.Context(), ErrorBagKey).(map[string]string); ok {
+		f.Errors = errs
+	}
+
+	return f
+}
+
+// Open starts a form; methods other than GET and POST are sent as POST with a _method field,
+// which the MethodOverride middleware of the app routes as PUT, PATCH or DELETE
+func (f *Form) Open(action string, method ...string) template.HTML {
+	m := "POST"
+	if len(method) > 0 {
+		m = strings.ToUpper(method[0])
+	}
+
+	var b strings.Builder
+	switch m {
+	case "GET", "POST":
+		fmt.Fprintf(&b, `<form action="%s" method="%s">`, esc(action), m)
+	default:
+		fmt.Fprintf(&b, `<form action="%s" method="POST">`, esc(action))
+		fmt.Fprintf(&b, `<input type="hidden" name="_method" value="%s">`, esc(m))
+	}
+
+	if m != "GET" {
+		b.WriteString(string(f.CSRFField()))
+	}
+
+	return template.HTML(b.String())
+}
+
+// Close ends a form
+func (f *Form) Close() template.HTML {
+	return "</form>"
+}
+
+// CSRFField returns the hidden input carrying the CSRF token
+func (f *Form) CSRFField() template.HTML {
+	return template.HTML(fmt.Sprintf(`<input type="hidden" name="csrf_token" value="%s">`, esc(f.CSRFToken)))
+}
+
+// TextField renders a labelled text input repopulated from old input
+func (f *Form) TextField(name, label string, value ...string) template.HTML {
+	return f.input("text", name, label, f.value(name, value...))
+}
+
+// EmailField renders a labelled email input repopulated from old input
+func (f *Form) EmailField(name, label string, value ...string) template.HTML {
+	return f.input("email", name, label, f.value(name, value...))
+}
+
+// PasswordField renders a labelled password input; passwords are never repopulated
+func (f *Form) PasswordField(name, label string) template.HTML {
+	return f.input("password", name, label, "")
+}
+
+// TextArea renders a labelled textarea repopulated from old input
+func (f *Form) TextArea(name, label string, value ...string) template.HTML {
+	var b strings.Builder
+	f.label(&b, name, label)
+	fmt.Fprintf(&b, `<textarea id="%s" name="%s" class="%s">%s</textarea>`, esc(name), esc(name), f.class(name), esc(f.value(name, value...)))
+	b.WriteString(string(f.ErrorsFor(name)))
+
+	return template.HTML(b.String())
+}
+
+// Select renders a labelled select with the options sorted by label; the selected option is
+// taken from old input first and then from the optional selected argument
+func (f *Form) Select(name, label string, options map[string]string, selected ...string) template.HTML {
+	current := f.value(name, selected...)
+
+	values := make([]string, 0, len(options))
+	for v := range options {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return options[values[i]] < options[values[j]]
+	})
+
+	var b strings.Builder
+	f.label(&b, name, label)
+	fmt.Fprintf(&b, `<select id="%s" name="%s" class="%s">`, esc(name), esc(name), f.class(name))
+	for _, v := range values {
+		sel := ""
+		if v == current {
+			sel = " selected"
+		}
+		fmt.Fprintf(&b, `<option value="%s"%s>%s</option>`, esc(v), sel, esc(options[v]))
+	}
+	b.WriteString("</select>")
+	b.WriteString(string(f.ErrorsFor(name)))
+
+	return template.HTML(b.String())
+}
+
+// ErrorsFor renders the validation error for a field, or nothing when the field is valid
+func (f *Form) ErrorsFor(name string) template.HTML {
+	msg, ok := f.Errors[name]
+	if !ok {
+		return ""
+	}
+
+	return template.HTML(fmt.Sprintf(`<div class="invalid-feedback">%s</div>`, esc(msg)))
+}
+
+// OldValue returns the flashed value for a field, or the fallback when nothing was flashed
+func (f *Form) OldValue(name string, fallback ...string) string {
+	return f.value(name, fallback...)
+}
+
+// HasError reports whether the field failed validation
+func (f *Form) HasError(name string) bool {
+	_, ok := f.Errors[name]
+
+	return ok
+}
+
+func (f *Form) input(kind, name, label, value string) template.HTML {
+	var b strings.Builder
+	f.label(&b, name, label)
+	fmt.Fprintf(&b, `<input type="%s" id="%s" name="%s" value="%s" class="%s">`, kind, esc(name), esc(name), esc(value), f.class(name))
+	b.WriteString(string(f.ErrorsFor(name)))
+
+	return template.HTML(b.String())
+}
+
+func (f *Form) label(b *strings.Builder, name, label string) {
+	if label != "" {
+		fmt.Fprintf(b, `<label for="%s">%s</label>`, esc(name), esc(label))
+	}
+}
+
+func (f *Form) class(name string) string {
+	if f.HasError(name) {
+		return strings.TrimSpace(f.InputClass + " " + f.ErrorClass)
+	}
+
+	return f.InputClass
+}
+
+func (f *Form) value(name string, fallback ...string) string {
+	if v, ok := f.Old[name]; ok {
+		return v
+	}
+
+	if len(fallback) > 0 {
+		return fallback[0]
+	}
+
+	return ""
+}
+
+func esc(s string) string {
+	return template.HTMLEscapeString(s)
+}
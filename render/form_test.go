@@ -0,0 +1,93 @@
+package render
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/CloudyKit/jet/v6"
+)
+
+var testForm = Form{
+	CSRFToken:  "token123",
+	Old:        map[string]string{"email": "old@here.com", "color": "blue"},
+	Errors:     map[string]string{"email": "Invalid email address"},
+	InputClass: "form-control",
+	ErrorClass: "is-invalid",
+}
+
+var formData = []struct {
+	name     string
+	output   string
+	contains []string
+	excludes []string
+}{
+	{"open_post", string(testForm.Open("/users")), []string{`method="POST"`, `name="csrf_token" value="token123"`}, nil},
+	{"open_get", string(testForm.Open("/search", "get")), []string{`method="GET"`}, []string{"csrf_token"}},
+	{"open_put", string(testForm.Open("/users/1", "put")), []string{`method="POST"`, `name="_method" value="PUT"`, "csrf_token"}, nil},
+	{"open_escapes_method", string(testForm.Open("/users/1", `put"><script>`)), []string{`value="PUT&#34;&gt;&lt;SCRIPT&gt;"`}, []string{"<SCRIPT>"}},
+	{"text_old_input", string(testForm.TextField("email", "Email", "default@here.com")), []string{`value="old@here.com"`, `class="form-control is-invalid"`, "Invalid email address"}, []string{"default@here.com"}},
+	{"text_fallback", string(testForm.TextField("name", "Name", "Jane")), []string{`value="Jane"`, `class="form-control"`}, []string{"invalid-feedback"}},
+	{"password_not_repopulated", string(testForm.PasswordField("email", "Password")), []string{`value=""`}, []string{"old@here.com"}},
+	{"select_old_input", string(testForm.Select("color", "Color", map[string]string{"red": "Red", "blue": "Blue"}, "red")), []string{`<option value="blue" selected>`}, []string{`<option value="red" selected>`}},
+	{"escapes_values", string(testForm.TextField("q", "", `"><script>`)), []string{"&#34;&gt;&lt;script&gt;"}, []string{"<script>"}},
+}
+
+func TestForm(t *testing.T) {
+	for _, e := range formData {
+		for _, c := range e.contains {
+			if !strings.Contains(e.output, c) {
+				t.Errorf("%s: expected %q in %s", e.name, c, e.output)
+			}
+		}
+
+		for _, c := range e.excludes {
+			if strings.Contains(e.output, c) {
+				t.Errorf("%s: did not expect %q in %s", e.name, c, e.output)
+			}
+		}
+	}
+}
+
+func TestForm_ErrorsFor(t *testing.T) {
+	if testForm.ErrorsFor("name") != "" {
+		t.Error("expected no errors for a valid field")
+	}
+
+	if !strings.Contains(string(testForm.ErrorsFor("email")), "Invalid email address") {
+		t.Error("expected the error message for an invalid field")
+	}
+}
+
+func TestJetFunc(t *testing.T) {
+	loader := jet.NewInMemLoader()
+	loader.Set("form.jet", `{{ textField("email", "Email") }}`)
+	set := jet.NewSet(loader)
+
+	tmpl, err := set.GetTemplate("form.jet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vars := make(jet.VarMap)
+	vars.Set("textField", jetFunc(testForm.TextField))
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), `<input type="text"`) {
+		t.Errorf("expected unescaped html from jet helper, got %s", buf.String())
+	}
+}
+
+func TestForm_OldValue(t *testing.T) {
+	if testForm.OldValue("email") != "old@here.com" {
+		t.Error("expected flashed value for email")
+	}
+
+	if testForm.OldValue("name", "Jane") != "Jane" {
+		t.Error("expected fallback when nothing was flashed")
+	}
+}
@@ -0,0 +1,117 @@
+package render
+
+import (
+	"html/template"
+	"net/http"
+	"reflect"
+
+	"github.com/CloudyKit/jet/v6"
+)
+
+var htmlType = reflect.TypeOf(template.HTML(""))
+
+// AddFuncs registers a provider of request-scoped template helpers, letting other packages
+// expose functions to views without changing the renderer
+func (ren *Render) AddFuncs(provider func(r *http.Request) template.FuncMap) {
+	ren.funcs = append(ren.funcs, provider)
+}
+
+// AddData registers a function filling TemplateData for every page, such as announcements or
+// shared navigation, so handlers don't have to pass it themselves
+func (ren *Render) AddData(provider func(r *http.Request, td *TemplateData)) {
+	ren.data = append(ren.data, provider)
+}
+
+// addData merges the data shared for r and runs the data providers, making sure Data is
+// usable by them
+func (ren *Render) addData(td *TemplateData, r *http.Request) {
+	shared := Shared(r)
+	if len(ren.data) == 0 && len(shared) == 0 {
+		return
+	}
+
+	if td.Data == nil {
+		td.Data = make(map[string]interface{})
+	}
+
+	for k, v := range shared {
+		if _, ok := td.Data[k]; !ok {
+			td.Data[k] = v
+		}
+	}
+
+	for _, provider := range ren.data {
+		provider(r, td)
+	}
+}
+
+// templateFuncs returns the helpers made available to both Go and Jet templates for a request
+func (ren *Render) templateFuncs(r *http.Request) template.FuncMap {
+	f := ren.form(r)
+
+	funcs := template.FuncMap{
+		"formOpen":      f.Open,
+		"formClose":     f.Close,
+		"csrfField":     f.CSRFField,
+		"csrfToken":     func() string { return f.CSRFToken },
+		"textField":     f.TextField,
+		"emailField":    f.EmailField,
+		"passwordField": f.PasswordField,
+		"textArea":      f.TextArea,
+		"select":        f.Select,
+		"errorsFor":     f.ErrorsFor,
+		"hasError":      f.HasError,
+		"old":           f.OldValue,
+	}
+
+	for _, provider := range ren.funcs {
+		for name, fn := range provider(r) {
+			funcs[name] = fn
+		}
+	}
+
+	return funcs
+}
+
+// addJetFuncs adds the template helpers to a Jet variable map
+func (ren *Render) addJetFuncs(vars jet.VarMap, r *http.Request) {
+	for name, fn := range ren.templateFuncs(r) {
+		if _, exists := vars[name]; !exists {
+			vars.Set(name, jetFunc(fn))
+		}
+	}
+}
+
+// jetFunc wraps helpers returning template.HTML so Jet writes their output without escaping it again
+func jetFunc(fn interface{}) interface{} {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+
+	if ft.NumOut() != 1 || ft.Out(0) != htmlType {
+		return fn
+	}
+
+	in := make([]reflect.Type, ft.NumIn())
+	for i := range in {
+		in[i] = ft.In(i)
+	}
+
+	out := []reflect.Type{reflect.TypeOf(jet.RendererFunc(nil))}
+	wrapped := reflect.FuncOf(in, out, ft.IsVariadic())
+
+	return reflect.MakeFunc(wrapped, func(args []reflect.Value) []reflect.Value {
+		var res []reflect.Value
+		if ft.IsVariadic() {
+			res = fv.CallSlice(args)
+		} else {
+			res = fv.Call(args)
+		}
+
+		html := res[0].String()
+		renderer := jet.RendererFunc(func(r *jet.Runtime) {
+			_, _ = r.Writer.Write([]byte(html))
+		})
+
+		return []reflect.Value{reflect.ValueOf(renderer)}
+	}).Interface()
+}
@@ -4,9 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"log"
 	"net/http"
-	"strings"
+	"path/filepath"
+	"sync"
 
 	"github.com/CloudyKit/jet/v6"
 	"github.com/alexedwards/scs/v2"
@@ -21,6 +23,10 @@ type Render struct {
 	ServerName string
 	JetViews   *jet.Set
 	Session    *scs.SessionManager
+	funcs      []func(*http.Request) template.FuncMap
+	data       []func(*http.Request, *TemplateData)
+	mu         sync.Mutex
+	engines    map[string]Engine
 }
 
 type TemplateData struct {
@@ -50,23 +56,50 @@ func (ren *Render) defaultData(td *TemplateData, r *http.Request) *TemplateData
 	td.Error = ren.Session.PopString(r.Context(), "error")
 	td.Flash = ren.Session.PopString(r.Context(), "flash")
 
+	ren.addData(td, r)
+
 	return td
 }
 
+// Page renders view with the engine of the app, or the one picked with UseEngine for the route
 func (ren *Render) Page(rw http.ResponseWriter, r *http.Request, view string, variables, data interface{}) error {
-	switch strings.ToLower(ren.Renderer) {
-	case "go":
-		return ren.GoPage(rw, r, view, data)
-	case "jet":
-		return ren.JetPage(rw, r, view, variables, data)
-	default:
+	name := ren.engineName(r)
+	if name == "" {
 		return errors.New("no rendering engine specify")
 	}
+
+	e, err := ren.Engine(name)
+	if err != nil {
+		return err
+	}
+
+	return e.Render(rw, r, view, variables, data)
+}
+
+// String renders view like Page, returning the output, e.g. for the body of an email
+func (ren *Render) String(r *http.Request, view string, variables, data interface{}) (string, error) {
+	e, err := ren.Engine(ren.engineName(r))
+	if err != nil {
+		return "", err
+	}
+
+	return e.RenderString(r, view, variables, data)
+}
+
+// Exists reports whether the view can be found for the configured rendering engine
+func (ren *Render) Exists(view string) bool {
+	e, err := ren.Engine(ren.engineName(nil))
+	if err != nil {
+		return false
+	}
+
+	return e.Exists(view)
 }
 
 // GoPage renders a standard Go template
-func (ren *Render) GoPage(rw http.ResponseWriter, r *http.Request, view string, data interface{}) error {
-	tmpl, err := template.ParseFiles(fmt.Sprintf("%s/views/%s.page.tmpl", ren.RootPath, view))
+func (ren *Render) GoPage(rw io.Writer, r *http.Request, view string, data interface{}) error {
+	file := fmt.Sprintf("%s/views/%s.page.tmpl", ren.RootPath, view)
+	tmpl, err := template.New(filepath.Base(file)).Funcs(ren.templateFuncs(r)).ParseFiles(file)
 
 	if err != nil {
 		return err
@@ -77,6 +110,8 @@ func (ren *Render) GoPage(rw http.ResponseWriter, r *http.Request, view string,
 		td = data.(*TemplateData)
 	}
 
+	ren.addData(td, r)
+
 	err = tmpl.Execute(rw, &td)
 
 	if err != nil {
@@ -87,7 +122,7 @@ func (ren *Render) GoPage(rw http.ResponseWriter, r *http.Request, view string,
 }
 
 // JetPage render the template using Jet templating engine
-func (ren *Render) JetPage(rw http.ResponseWriter, r *http.Request, templateName string, variables, data interface{}) error {
+func (ren *Render) JetPage(rw io.Writer, r *http.Request, templateName string, variables, data interface{}) error {
 	var vars jet.VarMap
 
 	if variables == nil {
@@ -103,6 +138,7 @@ func (ren *Render) JetPage(rw http.ResponseWriter, r *http.Request, templateName
 	}
 
 	td = ren.defaultData(td, r)
+	ren.addJetFuncs(vars, r)
 
 	t, err := ren.JetViews.GetTemplate(fmt.Sprintf("%s.jet", templateName))
 
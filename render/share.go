@@ -0,0 +1,61 @@
+package render
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+type shareKey struct{}
+
+type shared struct {
+	mu   sync.Mutex
+	data map[string]interface{}
+}
+
+// Sharing makes room in the request for the data middleware share with Share; the framework
+// adds it to every route
+func Sharing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value(shareKey{}).(*shared); ok {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), shareKey{}, &shared{data: map[string]interface{}{}})
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// Share adds key to the Data of every page rendered for r, letting middleware provide
+// things like an unread count without every handler passing it. Data set by the handler
+// wins over shared data. It reports false when r did not go through Sharing.
+func (ren *Render) Share(r *http.Request, key string, value interface{}) bool {
+	s, ok := r.Context().Value(shareKey{}).(*shared)
+	if !ok {
+		return false
+	}
+
+	s.mu.Lock()
+	s.data[key] = value
+	s.mu.Unlock()
+
+	return true
+}
+
+// Shared returns a copy of the data shared for r
+func Shared(r *http.Request) map[string]interface{} {
+	s, ok := r.Context().Value(shareKey{}).(*shared)
+	if !ok {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := make(map[string]interface{}, len(s.data))
+	for k, v := range s.data {
+		data[k] = v
+	}
+	return data
+}
@@ -0,0 +1,33 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestShare(t *testing.T) {
+	ren := &Render{}
+
+	var td *TemplateData
+	handler := Sharing(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if !ren.Share(r, "cartCount", 3) || !ren.Share(r, "title", "shared") {
+			t.Error("expected the data to be shared")
+		}
+
+		td = &TemplateData{Data: map[string]interface{}{"title": "handler"}}
+		ren.addData(td, r)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if td.Data["cartCount"] != 3 {
+		t.Errorf("expected the shared count, got %v", td.Data["cartCount"])
+	}
+	if td.Data["title"] != "handler" {
+		t.Errorf("expected the handler's data to win, got %v", td.Data["title"])
+	}
+
+	if ren.Share(httptest.NewRequest("GET", "/", nil), "cartCount", 1) {
+		t.Error("expected Share to report a request without Sharing")
+	}
+}
@@ -0,0 +1,43 @@
+package goravel
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/CloudyKit/jet/v6"
+	"github.com/namnguyen191/goravel/render"
+	"github.com/namnguyen191/goravel/reports"
+)
+
+// createReports mails the reports defined with grv.Reports.Define through the mailer of the app,
+// rendering their views with its renderer
+func (grv *Goravel) createReports() *reports.Reports {
+	rs := reports.New(grv.DB.Pool, grv.DB.DataBaseType)
+	rs.Render = grv.renderReport
+	rs.Send = grv.Mail.SendContext
+	rs.From = grv.Mail.FromAddress
+	rs.ErrorLog = grv.ErrorLog.Println
+	rs.Clock = grv.Clock
+
+	return rs
+}
+
+// renderReport renders the view of a report outside of a request, with an empty session so the
+// template funcs reading it still work; the result is the report variable of jet views and
+// .Data.report of go templates
+func (grv *Goravel) renderReport(ctx context.Context, view string, result *reports.Result) (string, error) {
+	ctx, err := grv.Session.Load(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	r, err := http.NewRequestWithContext(ctx, "GET", "/", nil)
+	if err != nil {
+		return "", err
+	}
+
+	vars := make(jet.VarMap)
+	vars.Set("report", result)
+	data := &render.TemplateData{Data: map[string]interface{}{"report": result}}
+
+	return grv.Render.String(r, view, vars, data)
+}
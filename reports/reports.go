@@ -0,0 +1,345 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+	"github.com/namnguyen191/goravel/mailer"
+)
+
+// Formats of a report
+const (
+	// HTML renders the view of a report as the body of the email
+	HTML = "html"
+	// CSV attaches the rows of a report as a spreadsheet
+	CSV = "csv"
+)
+
+// States of a run
+const (
+	Sent   = "sent"
+	Failed = "failed"
+)
+
+// ErrUnknown is returned for a report which was not defined
+var ErrUnknown = errors.New("reports: unknown report")
+
+// Report is a query mailed to its recipients, e.g. every Monday from the scheduler
+type Report struct {
+	// Name identifies the report in its history, e.g. "weekly-sales"
+	Name string
+	// Title is the subject of the email
+	Title string
+	// Query returns the rows of the report; it is written with ? placeholders
+	Query string
+	Args  []interface{}
+	// View renders the rows for the HTML format, e.g. "reports/weekly-sales"; it gets the
+	// *Result as report
+	View string
+	// Formats are HTML, CSV or both; HTML by default
+	Formats    []string
+	Recipients []string
+}
+
+// Result is what a report query returned, given to its view
+type Result struct {
+	Report      *Report
+	Columns     []string
+	Rows        [][]string
+	GeneratedAt time.Time
+	// Truncated is set when the query returned more than MaxRows rows
+	Truncated bool
+}
+
+// Run is a report sent, or failing to be, kept in the report_runs table
+type Run struct {
+	ID         int
+	Report     string
+	Status     string
+	Rows       int
+	Recipients string
+	Error      string
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// Reports runs the defined reports, keeping their runs in the report_runs table
+type Reports struct {
+	DB           *sql.DB
+	DatabaseType string
+	// Render renders the view of a report with its result, through the renderer of the app
+	Render func(ctx context.Context, view string, result *Result) (string, error)
+	// Send delivers a message, through the mailer of the app
+	Send func(ctx context.Context, msg mailer.Message) error
+	// Template is the mail template wrapping the report, getting its Title, HTML and Result
+	Template string
+	From     string
+	// MaxRows bounds the rows of a report, the rest being left out
+	MaxRows  int
+	ErrorLog func(v ...interface{})
+	// Clock tells when the reports run; the time of the machine when nil
+	Clock clock.Clock
+
+	mu      sync.RWMutex
+	reports map[string]*Report
+}
+
+// New returns reports keeping their history in db
+func New(db *sql.DB, dbType string) *Reports {
+	return &Reports{
+		DB:           db,
+		DatabaseType: dbType,
+		Template:     "report",
+		MaxRows:      10000,
+		ErrorLog:     log.Println,
+		reports:      map[string]*Report{},
+	}
+}
+
+// Define adds a report, replacing the one with the same name
+func (rs *Reports) Define(r Report) error {
+	if r.Name == "" || r.Query == "" {
+		return fmt.Errorf("reports: a report needs a name and a query")
+	}
+	if len(r.Recipients) == 0 {
+		return fmt.Errorf("reports: %s has no recipients", r.Name)
+	}
+	if len(r.Formats) == 0 {
+		r.Formats = []string{HTML}
+	}
+	for _, f := range r.Formats {
+		if f != HTML && f != CSV {
+			return fmt.Errorf("reports: %s: unknown format %q", r.Name, f)
+		}
+		if f == HTML && r.View == "" {
+			return fmt.Errorf("reports: %s: the html format needs a view", r.Name)
+		}
+	}
+	if r.Title == "" {
+		r.Title = r.Name
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.reports[r.Name] = &r
+	return nil
+}
+
+// Names returns the names of the defined reports, sorted
+func (rs *Reports) Names() []string {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	names := make([]string, 0, len(rs.reports))
+	for name := range rs.reports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (rs *Reports) report(name string) (*Report, error) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	r, ok := rs.reports[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknown, name)
+	}
+	return r, nil
+}
+
+// Job returns a function running the report for the scheduler, e.g.
+//
+//	app.Schedule().Weekly().At("07:00").Func(app.Reports.Job("weekly-sales"))
+func (rs *Reports) Job(name string) func() {
+	return func() {
+		if _, err := rs.Run(context.Background(), name); err != nil {
+			rs.ErrorLog("reports:", name, err)
+		}
+	}
+}
+
+// Run queries the report, mails it to its recipients and records the run, which is returned
+// even when it failed
+func (rs *Reports) Run(ctx context.Context, name string) (*Run, error) {
+	r, err := rs.report(name)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &Run{Report: r.Name, Recipients: strings.Join(r.Recipients, ", "), StartedAt: clock.Now(rs.Clock)}
+	n, err := rs.deliver(ctx, r)
+	run.Rows, run.Status, run.FinishedAt = n, Sent, clock.Now(rs.Clock)
+	if err != nil {
+		run.Status, run.Error = Failed, err.Error()
+	}
+
+	if err := rs.record(ctx, run); err != nil {
+		rs.ErrorLog("reports: history of", r.Name, err)
+	}
+	return run, err
+}
+
+// deliver sends the report to each recipient, returning the number of rows sent
+func (rs *Reports) deliver(ctx context.Context, r *Report) (int, error) {
+	result, err := rs.query(ctx, r)
+	if err != nil {
+		return 0, err
+	}
+
+	data := struct {
+		Title  string
+		HTML   template.HTML
+		Result *Result
+	}{Title: r.Title, Result: result}
+	var files []mailer.File
+
+	for _, f := range r.Formats {
+		switch f {
+		case HTML:
+			if rs.Render == nil {
+				return 0, fmt.Errorf("no renderer")
+			}
+			html, err := rs.Render(ctx, r.View, result)
+			if err != nil {
+				return 0, err
+			}
+			data.HTML = template.HTML(html)
+		case CSV:
+			b, err := result.CSV()
+			if err != nil {
+				return 0, err
+			}
+			files = append(files, mailer.File{
+				Name:        fmt.Sprintf("%s-%s.csv", r.Name, result.GeneratedAt.Format("2006-01-02")),
+				ContentType: "text/csv",
+				Data:        b,
+			})
+		}
+	}
+
+	for _, to := range r.Recipients {
+		msg := mailer.Message{From: rs.From, To: to, Subject: r.Title, Template: rs.Template, Data: data, Files: files}
+		if err := rs.Send(ctx, msg); err != nil {
+			return len(result.Rows), fmt.Errorf("to %s: %w", to, err)
+		}
+	}
+	return len(result.Rows), nil
+}
+
+func (rs *Reports) query(ctx context.Context, r *Report) (*Result, error) {
+	rows, err := rs.DB.QueryContext(ctx, database.Rebind(rs.DatabaseType, r.Query), r.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := &Result{Report: r, GeneratedAt: clock.Now(rs.Clock)}
+	if result.Columns, err = rows.Columns(); err != nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, len(result.Columns))
+	ptrs := make([]interface{}, len(values))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if len(result.Rows) >= rs.MaxRows {
+			result.Truncated = true
+			break
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+
+		row := make([]string, len(values))
+		for i, v := range values {
+			row[i] = format(v)
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result, rows.Err()
+}
+
+// format writes a value of the database as text
+func format(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// CSV returns the columns and rows of the result as csv
+func (res *Result) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(res.Columns); err != nil {
+		return nil, err
+	}
+	if err := w.WriteAll(res.Rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (rs *Reports) record(ctx context.Context, run *Run) error {
+	query := `insert into report_runs (report, status, rows_count, recipients, error, started_at, finished_at)
+		values (?, ?, ?, ?, ?, ?, ?)`
+	args := []interface{}{run.Report, run.Status, run.Rows, run.Recipients, run.Error, run.StartedAt, run.FinishedAt}
+
+	if database.IsPostgres(rs.DatabaseType) {
+		return rs.DB.QueryRowContext(ctx, database.Rebind(rs.DatabaseType, query+" returning id"), args...).Scan(&run.ID)
+	}
+
+	res, err := rs.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	run.ID = int(id)
+	return err
+}
+
+// History returns the runs of a report, the most recent first
+func (rs *Reports) History(ctx context.Context, name string, limit int) ([]Run, error) {
+	query := database.Rebind(rs.DatabaseType, `select id, report, status, rows_count, recipients, error, started_at, finished_at
+		from report_runs where report = ? order by started_at desc limit ?`)
+
+	rows, err := rs.DB.QueryContext(ctx, query, name, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []Run
+	for rows.Next() {
+		var run Run
+		if err := rows.Scan(&run.ID, &run.Report, &run.Status, &run.Rows, &run.Recipients, &run.Error, &run.StartedAt, &run.FinishedAt); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
@@ -0,0 +1,144 @@
+package reports
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/namnguyen191/goravel/mailer"
+)
+
+// salesDriver answers every query with two rows of sales, and records the runs inserted
+type salesDriver struct {
+	runs [][]driver.Value
+}
+
+func (d *salesDriver) Open(string) (driver.Conn, error) { return &salesConn{d}, nil }
+
+type salesConn struct{ d *salesDriver }
+
+func (c *salesConn) Prepare(query string) (driver.Stmt, error) { return &salesStmt{c.d, query}, nil }
+func (c *salesConn) Close() error                              { return nil }
+func (c *salesConn) Begin() (driver.Tx, error)                 { return nil, errors.New("no transactions") }
+
+type salesStmt struct {
+	d     *salesDriver
+	query string
+}
+
+func (s *salesStmt) Close() error  { return nil }
+func (s *salesStmt) NumInput() int { return -1 }
+func (s *salesStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.runs = append(s.d.runs, args)
+	return driver.RowsAffected(1), nil
+}
+func (s *salesStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return &salesRows{rows: [][]driver.Value{
+		{"north", int64(1200), []byte("a, b")},
+		{"south", int64(800), nil},
+	}}, nil
+}
+
+type salesRows struct {
+	rows [][]driver.Value
+}
+
+func (r *salesRows) Columns() []string { return []string{"region", "total", "notes"} }
+func (r *salesRows) Close() error      { return nil }
+func (r *salesRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	d := &salesDriver{}
+	sql.Register("reports-sales", d)
+	db, err := sql.Open("reports-sales", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var sent []mailer.Message
+	rs := New(db, "mysql")
+	rs.Render = func(ctx context.Context, view string, result *Result) (string, error) {
+		return "<table>" + view + " " + result.Rows[0][0] + "</table>", nil
+	}
+	rs.Send = func(ctx context.Context, msg mailer.Message) error {
+		sent = append(sent, msg)
+		return nil
+	}
+
+	if err := rs.Define(Report{Name: "sales", Query: "select region, total, notes from sales", Formats: []string{HTML, CSV}}); err == nil {
+		t.Error("expected a report without recipients to be refused")
+	}
+	if err := rs.Define(Report{Name: "sales", Query: "select 1", Formats: []string{HTML}, Recipients: []string{"a@b.c"}}); err == nil {
+		t.Error("expected the html format without a view to be refused")
+	}
+
+	err = rs.Define(Report{
+		Name:       "sales",
+		Title:      "Weekly sales",
+		Query:      "select region, total, notes from sales",
+		View:       "reports/sales",
+		Formats:    []string{HTML, CSV},
+		Recipients: []string{"boss@example.com", "sales@example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run, err := rs.Run(context.Background(), "sales")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Status != Sent || run.Rows != 2 {
+		t.Errorf("unexpected run %+v", run)
+	}
+	if len(d.runs) != 1 || d.runs[0][0] != "sales" || d.runs[0][1] != Sent {
+		t.Errorf("expected the run to be recorded, got %v", d.runs)
+	}
+
+	if len(sent) != 2 || sent[1].To != "sales@example.com" || sent[0].Subject != "Weekly sales" {
+		t.Fatalf("expected a message per recipient, got %+v", sent)
+	}
+	if len(sent[0].Files) != 1 || !strings.HasPrefix(sent[0].Files[0].Name, "sales-"+time.Now().Format("2006-01")) {
+		t.Fatalf("expected the csv attached, got %+v", sent[0].Files)
+	}
+	if csv := string(sent[0].Files[0].Data); csv != "region,total,notes\nnorth,1200,\"a, b\"\nsouth,800,\n" {
+		t.Errorf("unexpected csv %q", csv)
+	}
+
+	if _, err := rs.Run(context.Background(), "nope"); !errors.Is(err, ErrUnknown) {
+		t.Errorf("expected an unknown report, got %v", err)
+	}
+}
+
+func TestRunFailure(t *testing.T) {
+	d := &salesDriver{}
+	sql.Register("reports-failing", d)
+	db, _ := sql.Open("reports-failing", "")
+	defer db.Close()
+
+	rs := New(db, "mysql")
+	rs.MaxRows = 1
+	rs.Send = func(ctx context.Context, msg mailer.Message) error { return errors.New("smtp down") }
+	_ = rs.Define(Report{Name: "sales", Query: "select 1", Formats: []string{CSV}, Recipients: []string{"a@b.c"}})
+
+	run, err := rs.Run(context.Background(), "sales")
+	if err == nil || run.Status != Failed || !strings.Contains(run.Error, "smtp down") || run.Rows != 1 {
+		t.Errorf("expected a failed run of one row, got %+v %v", run, err)
+	}
+	if len(d.runs) != 1 || d.runs[0][1] != Failed {
+		t.Errorf("expected the failure to be recorded, got %v", d.runs)
+	}
+}
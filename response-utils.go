@@ -6,29 +6,64 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
-	"path"
 	"path/filepath"
+	"strings"
 )
 
+// ReadJSON decodes the json body of r into data, which must hold a single value with only
+// known fields, up to JSON_MAX_BYTES (1MB by default). Its errors are *StatusError with a
+// message meant for the client, a 413 for a body too large and a 400 otherwise.
 func (grv *Goravel) ReadJSON(rw http.ResponseWriter, r *http.Request, data interface{}) error {
-	maxBytes := 1048576 // one megabyte
-	r.Body = http.MaxBytesReader(rw, r.Body, int64(maxBytes))
+	return grv.ReadJSONLimit(rw, r, data, int64(grv.Env.Int("JSON_MAX_BYTES", 1048576)))
+}
+
+// ReadJSONLimit is ReadJSON with a limit for one route, e.g. an import taking larger bodies
+func (grv *Goravel) ReadJSONLimit(rw http.ResponseWriter, r *http.Request, data interface{}, maxBytes int64) error {
+	r.Body = http.MaxBytesReader(rw, r.Body, maxBytes)
 
 	dec := json.NewDecoder(r.Body)
-	err := dec.Decode(data)
-	if err != nil {
-		return err
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(data); err != nil {
+		return jsonError(err, maxBytes)
 	}
 
-	err = dec.Decode(&struct{}{})
-	if err != io.EOF {
-		return errors.New("body must only have a single json value")
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return NewStatusError(http.StatusBadRequest, "body must only have a single json value")
 	}
 
 	return nil
 }
 
+// jsonError explains a decoding error to the client, without the go types of the server
+func jsonError(err error, maxBytes int64) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		return NewStatusError(http.StatusBadRequest, fmt.Sprintf("body has badly formed json at character %d", syntaxErr.Offset))
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return NewStatusError(http.StatusBadRequest, "body has badly formed json")
+	case errors.As(err, &typeErr):
+		if typeErr.Field != "" {
+			return NewStatusError(http.StatusBadRequest, fmt.Sprintf("body has the wrong type for field %q", typeErr.Field))
+		}
+		return NewStatusError(http.StatusBadRequest, fmt.Sprintf("body has the wrong type at character %d", typeErr.Offset))
+	case errors.Is(err, io.EOF):
+		return NewStatusError(http.StatusBadRequest, "body must not be empty")
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return NewStatusError(http.StatusBadRequest, "body has unknown field "+strings.TrimPrefix(err.Error(), "json: unknown field "))
+	case err.Error() == "http: request body too large":
+		return NewStatusError(http.StatusRequestEntityTooLarge, fmt.Sprintf("body must not be larger than %d bytes", maxBytes))
+	default:
+		return NewStatusError(http.StatusBadRequest, err.Error())
+	}
+}
+
+// WriteJSON sends data as indented json with status; headers are added to the response
 func (grv *Goravel) WriteJSON(rw http.ResponseWriter, status int, data interface{}, headers ...http.Header) error {
 	out, err := json.MarshalIndent(data, "", "\t")
 	if err != nil {
@@ -51,6 +86,7 @@ func (grv *Goravel) WriteJSON(rw http.ResponseWriter, status int, data interface
 	return nil
 }
 
+// WriteXML sends data as indented xml with status, like WriteJSON
 func (grv *Goravel) WriteXML(rw http.ResponseWriter, status int, data interface{}, headers ...http.Header) error {
 	out, err := xml.MarshalIndent(data, "", "  ")
 	if err != nil {
@@ -73,31 +109,46 @@ func (grv *Goravel) WriteXML(rw http.ResponseWriter, status int, data interface{
 	return nil
 }
 
+// DownloadFile sends the file fileName of the directory pathToFile as an attachment. A
+// fileName reaching outside of pathToFile, e.g. ../.env, is refused with ErrNotFound.
 func (grv *Goravel) DownloadFile(rw http.ResponseWriter, r *http.Request, pathToFile, fileName string) error {
-	fp := path.Join(pathToFile, fileName)
-	fileToServe := filepath.Clean(fp)
-	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
+	dir := filepath.Clean(pathToFile)
+	fileToServe := filepath.Join(dir, fileName)
+	if rel, err := filepath.Rel(dir, fileToServe); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("download %s: %w", fileName, ErrNotFound)
+	}
+
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": filepath.Base(fileToServe)})
+	rw.Header().Set("Content-Disposition", disposition)
 	http.ServeFile(rw, r, fileToServe)
 
 	return nil
 }
 
+// Error404 sends the not found page: views/errors/404, views/errors/default or the built in
+// page, or json for api requests. The other Error methods do the same for their status.
 func (grv *Goravel) Error404(rw http.ResponseWriter, r *http.Request) {
-	grv.ErrorStatus(rw, http.StatusNotFound)
+	grv.routeError(rw, r, http.StatusNotFound)
 }
 
 func (grv *Goravel) Error500(rw http.ResponseWriter, r *http.Request) {
-	grv.ErrorStatus(rw, http.StatusInternalServerError)
+	grv.routeError(rw, r, http.StatusInternalServerError)
 }
 
 func (grv *Goravel) ErrorUnauthorized(rw http.ResponseWriter, r *http.Request) {
-	grv.ErrorStatus(rw, http.StatusUnauthorized)
+	grv.routeError(rw, r, http.StatusUnauthorized)
 }
 
 func (grv *Goravel) ErrorForbidden(rw http.ResponseWriter, r *http.Request) {
-	grv.ErrorStatus(rw, http.StatusForbidden)
+	grv.routeError(rw, r, http.StatusForbidden)
+}
+
+// ErrorUnavailable sends the 503 page, e.g. while the app is down for maintenance
+func (grv *Goravel) ErrorUnavailable(rw http.ResponseWriter, r *http.Request) {
+	grv.routeError(rw, r, http.StatusServiceUnavailable)
 }
 
+// ErrorStatus sends a plain text status, for when there is no request to render a page for
 func (grv *Goravel) ErrorStatus(rw http.ResponseWriter, status int) {
 	http.Error(rw, http.StatusText(status), status)
 }
@@ -2,22 +2,112 @@ package goravel
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/namnguyen191/goravel/assets"
+	"github.com/namnguyen191/goravel/livereload"
+	"github.com/namnguyen191/goravel/render"
+	"github.com/namnguyen191/goravel/trace"
 )
 
 func (grv *Goravel) routes() http.Handler {
 	mux := chi.NewRouter()
 	mux.Use(middleware.RequestID)
+	mux.Use(trace.Middleware)
+	// before RealIP, which hides the address of the proxy
+	if grv.Canonical != nil {
+		mux.Use(grv.Canonical.Middleware)
+	}
 	mux.Use(middleware.RealIP)
-	mux.Use(middleware.Recoverer)
+	mux.Use(grv.ClientInfo.Middleware)
+	mux.Use(render.Sharing)
+	mux.Use(grv.meta.Middleware)
+	if grv.Flags.Enabled(FlagStrictErrors) {
+		mux.Use(grv.recoverer)
+	} else {
+		mux.Use(middleware.Recoverer)
+	}
+
+	switch grv.config.router.trailingSlash {
+	case "redirect":
+		mux.Use(middleware.RedirectSlashes)
+	case "strip":
+		mux.Use(middleware.StripSlashes)
+	}
+
+	// minifying pages costs time on every request, so it is opt in, and off while debugging
+	if !grv.Debug && grv.Env.Bool("HTML_MINIFY", false) {
+		mux.Use(assets.MinifyResponses)
+	}
+
+	if grv.config.router.autoHead {
+		mux.Use(middleware.GetHead)
+	}
+
+	if grv.BotGuard != nil {
+		mux.Use(grv.BotGuard.Middleware)
+	}
+
 	mux.Use(grv.SessionLoad)
+
+	if grv.Analytics != nil {
+		mux.Use(grv.Analytics.Middleware)
+	}
 	mux.Use(grv.NoSurf)
+	// after NoSurf, so the token of the form is checked whichever method it asks for
+	mux.Use(grv.MethodOverride)
 
-	if grv.Debug {
-		mux.Use(middleware.Logger)
+	if grv.Env.Bool("LOG_REQUESTS", grv.Debug) {
+		mux.Use(grv.Logger.Middleware)
 	}
 
+	if grv.LiveReload != nil {
+		mux.Use(grv.LiveReload.Middleware)
+		mux.Get(livereload.Path, grv.LiveReload.ServeHTTP)
+	}
+
+	// apps mounting /public themselves replace this route
+	mux.Handle("/public/*", http.StripPrefix("/public", grv.Public))
+
+	mux.NotFound(grv.routeNotFound)
+	mux.MethodNotAllowed(grv.routeMethodNotAllowed)
+
 	return mux
 }
+
+func (grv *Goravel) routeNotFound(rw http.ResponseWriter, r *http.Request) {
+	if grv.NotFoundHandler != nil {
+		grv.NotFoundHandler(rw, r)
+		return
+	}
+
+	grv.routeError(rw, r, http.StatusNotFound)
+}
+
+func (grv *Goravel) routeMethodNotAllowed(rw http.ResponseWriter, r *http.Request) {
+	if grv.MethodNotAllowedHandler != nil {
+		grv.MethodNotAllowedHandler(rw, r)
+		return
+	}
+
+	grv.routeError(rw, r, http.StatusMethodNotAllowed)
+}
+
+// routeError sends a status without details, e.g. for unmatched routes
+func (grv *Goravel) routeError(rw http.ResponseWriter, r *http.Request, status int) {
+	grv.errorPage(rw, r, status, "", nil)
+}
+
+func wantsJSON(r *http.Request) bool {
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		return true
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		return true
+	}
+
+	return strings.HasPrefix(r.Header.Get("Content-Type"), "application/json")
+}
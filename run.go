@@ -0,0 +1,149 @@
+package goravel
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// worker is a long running loop started by RunWorker
+type worker struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+var (
+	workers   []worker
+	workersMu sync.Mutex
+)
+
+// Worker registers a loop for RunWorker, e.g. one consuming a queue. run should return once
+// ctx is done; when it fails or panics earlier it is started again after a second.
+func (grv *Goravel) Worker(name string, run func(ctx context.Context) error) {
+	workersMu.Lock()
+	defer workersMu.Unlock()
+
+	workers = append(workers, worker{name: name, run: run})
+}
+
+// RunWorker runs the registered workers, without the web server or the scheduler, until the
+// process is asked to stop; the workers then get DRAIN_TIMEOUT to finish their job. With
+// RunScheduler and ListenAndServe it splits an app into web, worker and cron processes:
+//
+//	switch os.Getenv("PROCESS") {
+//	case "worker":
+//		app.RunWorker()
+//	case "scheduler":
+//		app.RunScheduler()
+//	default:
+//		app.ListenAndServe()
+//	}
+func (grv *Goravel) RunWorker() {
+	grv.start()
+	defer grv.shutdown()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	workersMu.Lock()
+	running := append([]worker{}, workers...)
+	workersMu.Unlock()
+
+	if len(running) == 0 {
+		grv.InfoLog.Println("worker: no workers registered")
+	}
+
+	var wg sync.WaitGroup
+	for _, w := range running {
+		w := w
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			grv.keepRunning(ctx, w)
+		}()
+	}
+
+	grv.InfoLog.Printf("worker: running %d workers", len(running))
+	<-ctx.Done()
+	grv.InfoLog.Println("worker: stopping")
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(grv.Env.Duration("DRAIN_TIMEOUT", 30*time.Second)):
+		grv.ErrorLog.Println("worker: workers still running after DRAIN_TIMEOUT")
+	}
+	grv.InfoLog.Println("worker: stopped")
+}
+
+// keepRunning restarts w until ctx is done
+func (grv *Goravel) keepRunning(ctx context.Context, w worker) {
+	for ctx.Err() == nil {
+		err := grv.Concurrency.ForEach(ctx, 1, 1, func(ctx context.Context, i int) error {
+			return w.run(ctx)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			grv.ErrorLog.Printf("worker %s: %v", w.name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// RunScheduler runs the scheduled jobs, without the web server or the workers, until the
+// process is asked to stop; shutting down waits for the running jobs. With several instances, the
+// jobs still only run on the leader, see createLeader.
+func (grv *Goravel) RunScheduler() {
+	grv.start()
+	defer grv.shutdown()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	grv.Scheduler.Start()
+	grv.InfoLog.Printf("scheduler: running %d jobs", len(grv.Scheduler.Entries()))
+
+	<-ctx.Done()
+	grv.InfoLog.Println("scheduler: stopping")
+}
+
+// closeStores closes the database and the stores when a process stops
+func (grv *Goravel) closeStores() {
+	if grv.DB.Pool != nil {
+		_ = grv.DB.Pool.Close()
+	}
+
+	if redisPool != nil {
+		_ = redisPool.Close()
+	}
+
+	if badgerConn != nil {
+		_ = badgerConn.Close()
+	}
+
+	if grv.KV != nil {
+		_ = grv.KV.Close()
+	}
+
+	if grv.jobsKV != nil {
+		_ = grv.jobsKV.Close()
+	}
+
+	if logFile != nil {
+		_ = logFile.Close()
+	}
+}
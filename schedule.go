@@ -0,0 +1,19 @@
+package goravel
+
+import (
+	"strings"
+
+	"github.com/namnguyen191/goravel/schedule"
+)
+
+// Environment is APP_ENV, e.g. local, staging or production, which is the default
+func (grv *Goravel) Environment() string {
+	return strings.ToLower(grv.Env.String("APP_ENV", "production"))
+}
+
+// Schedule starts the definition of a job run by the scheduler, in the timezone of grv.Clock, e.g.
+//
+//	app.Schedule().Daily().At("03:00").Environments("production").Job(cleanup)
+func (grv *Goravel) Schedule() *schedule.Event {
+	return schedule.New(grv.Scheduler, grv.Environment())
+}
@@ -0,0 +1,250 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/namnguyen191/goravel/leader"
+	"github.com/robfig/cron/v3"
+)
+
+// Event describes when a job runs, built up in words rather than as a cron string:
+//
+//	schedule.New(c, env).Weekdays().At("07:30").Environments("production").Func(sendDigest)
+//
+// Nothing is added to the cron until Job or Func is called.
+type Event struct {
+	cron *cron.Cron
+	env  string
+
+	minute, hour, day, month, weekday string
+	spec                              string
+	timezone                          string
+	envs                              []string
+	when                              []func() bool
+	everywhere                        bool
+	err                               error
+}
+
+// New starts an event, every minute until told otherwise, added to c for an app running in
+// the environment env
+func New(c *cron.Cron, env string) *Event {
+	return &Event{cron: c, env: env, minute: "*", hour: "*", day: "*", month: "*", weekday: "*"}
+}
+
+// fail keeps the first mistake in the definition, returned by Job
+func (e *Event) fail(format string, args ...interface{}) *Event {
+	if e.err == nil {
+		e.err = fmt.Errorf("schedule: "+format, args...)
+	}
+	return e
+}
+
+// Cron runs at a raw cron spec, e.g. "0 */2 * * *" or "@every 90s"
+func (e *Event) Cron(spec string) *Event {
+	e.spec = spec
+	return e
+}
+
+// EveryMinute runs every minute
+func (e *Event) EveryMinute() *Event {
+	return e.EveryMinutes(1)
+}
+
+// EveryMinutes runs every n minutes, on the minutes divisible by n
+func (e *Event) EveryMinutes(n int) *Event {
+	if n < 1 || n > 59 {
+		return e.fail("every %d minutes", n)
+	}
+
+	e.minute, e.hour = "*", "*"
+	if n > 1 {
+		e.minute = "*/" + strconv.Itoa(n)
+	}
+	return e
+}
+
+// Hourly runs at the start of every hour
+func (e *Event) Hourly() *Event {
+	return e.HourlyAt(0)
+}
+
+// HourlyAt runs every hour at minute
+func (e *Event) HourlyAt(minute int) *Event {
+	if minute < 0 || minute > 59 {
+		return e.fail("hourly at minute %d", minute)
+	}
+
+	e.minute, e.hour = strconv.Itoa(minute), "*"
+	return e
+}
+
+// Daily runs at midnight, or at the time set with At
+func (e *Event) Daily() *Event {
+	e.minute, e.hour = "0", "0"
+	return e
+}
+
+// DailyAt runs every day at clock, e.g. "03:00"
+func (e *Event) DailyAt(clock string) *Event {
+	return e.Daily().At(clock)
+}
+
+// Weekdays runs from Monday to Friday
+func (e *Event) Weekdays() *Event {
+	return e.daily("1-5")
+}
+
+// Weekends runs on Saturday and Sunday
+func (e *Event) Weekends() *Event {
+	return e.daily("0,6")
+}
+
+// Weekly runs on Sunday, or on the days set with On
+func (e *Event) Weekly() *Event {
+	return e.daily("0")
+}
+
+// On runs on the given days of the week
+func (e *Event) On(days ...time.Weekday) *Event {
+	if len(days) == 0 {
+		return e.fail("no days given")
+	}
+
+	list := make([]string, len(days))
+	for i, d := range days {
+		list[i] = strconv.Itoa(int(d))
+	}
+	return e.daily(strings.Join(list, ","))
+}
+
+// Monthly runs on the first of the month
+func (e *Event) Monthly() *Event {
+	return e.MonthlyOn(1)
+}
+
+// MonthlyOn runs on day of the month; a month without it, e.g. the 31st in April, is skipped
+func (e *Event) MonthlyOn(day int) *Event {
+	if day < 1 || day > 31 {
+		return e.fail("monthly on day %d", day)
+	}
+
+	e.daily("*")
+	e.day = strconv.Itoa(day)
+	return e
+}
+
+// daily runs on weekday at midnight, keeping a time set with At before
+func (e *Event) daily(weekday string) *Event {
+	if e.hour == "*" || strings.Contains(e.hour, "/") {
+		e.minute, e.hour = "0", "0"
+	}
+	e.weekday = weekday
+	return e
+}
+
+// At sets the time of day, e.g. "03:00" or "17:45", in the timezone of the schedule
+func (e *Event) At(clock string) *Event {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return e.fail("at %q: want hh:mm", clock)
+	}
+
+	e.minute, e.hour = strconv.Itoa(t.Minute()), strconv.Itoa(t.Hour())
+	return e
+}
+
+// Timezone runs the event in the named timezone, e.g. "Europe/Paris", instead of the one of
+// the scheduler
+func (e *Event) Timezone(name string) *Event {
+	if _, err := time.LoadLocation(name); err != nil {
+		return e.fail("timezone %q: %v", name, err)
+	}
+
+	e.timezone = name
+	return e
+}
+
+// Environments only adds the event when the app runs in one of envs, e.g. "production"
+func (e *Event) Environments(envs ...string) *Event {
+	e.envs = append(e.envs, envs...)
+	return e
+}
+
+// When skips the runs for which fn returns false, e.g. while a feature is switched off
+func (e *Event) When(fn func() bool) *Event {
+	e.when = append(e.when, fn)
+	return e
+}
+
+// Everywhere runs the event on every instance, not only on the elected leader, for work on
+// local state such as temporary files
+func (e *Event) Everywhere() *Event {
+	e.everywhere = true
+	return e
+}
+
+// Spec returns the cron spec of the event
+func (e *Event) Spec() string {
+	spec := e.spec
+	if spec == "" {
+		spec = strings.Join([]string{e.minute, e.hour, e.day, e.month, e.weekday}, " ")
+	}
+	if e.timezone != "" {
+		spec = "CRON_TZ=" + e.timezone + " " + spec
+	}
+	return spec
+}
+
+// enabled reports whether the event belongs to the environment of the app
+func (e *Event) enabled() bool {
+	if len(e.envs) == 0 {
+		return true
+	}
+	for _, env := range e.envs {
+		if strings.EqualFold(env, e.env) {
+			return true
+		}
+	}
+	return false
+}
+
+// Job adds job to the cron. An event of another environment is not added, and has the id 0.
+func (e *Event) Job(job cron.Job) (cron.EntryID, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	if !e.enabled() {
+		return 0, nil
+	}
+
+	run := job.Run
+	if len(e.when) > 0 {
+		run = func() {
+			for _, fn := range e.when {
+				if !fn() {
+					return
+				}
+			}
+			job.Run()
+		}
+	}
+
+	var wrapped cron.Job = cron.FuncJob(run)
+	if e.everywhere {
+		wrapped = leader.Everywhere(run)
+	}
+
+	id, err := e.cron.AddJob(e.Spec(), wrapped)
+	if err != nil {
+		return 0, fmt.Errorf("schedule: %s: %w", e.Spec(), err)
+	}
+	return id, nil
+}
+
+// Func adds fn to the cron, like Job
+func (e *Event) Func(fn func()) (cron.EntryID, error) {
+	return e.Job(cron.FuncJob(fn))
+}
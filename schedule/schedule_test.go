@@ -0,0 +1,83 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+func TestSpec(t *testing.T) {
+	c := cron.New()
+	tests := []struct {
+		name  string
+		event *Event
+		want  string
+	}{
+		{"every minute", New(c, "").EveryMinute(), "* * * * *"},
+		{"every 15 minutes", New(c, "").EveryMinutes(15), "*/15 * * * *"},
+		{"hourly at", New(c, "").HourlyAt(30), "30 * * * *"},
+		{"daily", New(c, "").Daily(), "0 0 * * *"},
+		{"daily at", New(c, "").Daily().At("03:00"), "0 3 * * *"},
+		{"daily at shorthand", New(c, "").DailyAt("17:45"), "45 17 * * *"},
+		{"weekdays", New(c, "").Weekdays().At("07:30"), "30 7 * * 1-5"},
+		{"at before weekly", New(c, "").At("07:30").Weekly(), "30 7 * * 0"},
+		{"on", New(c, "").On(time.Monday, time.Thursday), "0 0 * * 1,4"},
+		{"monthly on", New(c, "").MonthlyOn(15).At("09:00"), "0 9 15 * *"},
+		{"raw", New(c, "").Cron("@every 90s"), "@every 90s"},
+		{"timezone", New(c, "").Daily().Timezone("Europe/Paris"), "CRON_TZ=Europe/Paris 0 0 * * *"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.event.Spec(); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+		if _, err := tt.event.Func(func() {}); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	c := cron.New()
+	for name, e := range map[string]*Event{
+		"at":       New(c, "").Daily().At("3pm"),
+		"minutes":  New(c, "").EveryMinutes(90),
+		"day":      New(c, "").MonthlyOn(32),
+		"timezone": New(c, "").Timezone("Mars/Olympus"),
+		"cron":     New(c, "").Cron("every day"),
+	} {
+		if _, err := e.Func(func() {}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if len(c.Entries()) != 0 {
+		t.Errorf("expected nothing scheduled, got %d entries", len(c.Entries()))
+	}
+}
+
+func TestEnvironmentsAndWhen(t *testing.T) {
+	c := cron.New()
+
+	id, err := New(c, "local").Daily().Environments("production").Func(func() {})
+	if err != nil || id != 0 || len(c.Entries()) != 0 {
+		t.Errorf("a production event should not be added locally, got %d %v", id, err)
+	}
+
+	id, err = New(c, "Production").Daily().Environments("staging", "production").Func(func() {})
+	if err != nil || id == 0 {
+		t.Fatalf("expected the event to be added, got %d %v", id, err)
+	}
+
+	ran := 0
+	enabled := false
+	_, _ = New(c, "").EveryMinute().When(func() bool { return enabled }).Func(func() { ran++ })
+	job := c.Entries()[1].Job
+
+	job.Run()
+	enabled = true
+	job.Run()
+	if ran != 1 {
+		t.Errorf("expected one run while enabled, got %d", ran)
+	}
+}
@@ -0,0 +1,199 @@
+package goravel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema maps a field of the query string or body to its rules, separated by |, e.g.
+// Schema{"email": "required|email", "age": "int|min:18", "plan": "in:free,pro"}.
+// Available rules are required, email, int, float, date, nospaces, phone, min:n and max:n
+// (the length of text, the value of int and float fields) and in:a,b,c.
+type Schema map[string]string
+
+type validatedKey struct{}
+
+// Validate is route middleware checking the query string and the form or JSON body against
+// the schema before the handler runs, e.g. r.With(grv.Validate(schema)).Post("/api/users", h).
+// Failing requests get a 422 with the error of every field; the handler can read the checked
+// values with Validated, and the body is left in place for ReadJSON.
+func (grv *Goravel) Validate(schema Schema) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			data, err := grv.requestValues(rw, r)
+			if err != nil {
+				grv.HandleError(rw, r, NewStatusError(http.StatusBadRequest, err.Error()))
+				return
+			}
+
+			v := grv.Validator(data)
+			v.Schema(schema)
+			if !v.Valid() {
+				grv.HandleError(rw, r, &ValidationError{Errors: v.Error})
+				return
+			}
+
+			next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), validatedKey{}, data)))
+		})
+	}
+}
+
+// Validated returns the values checked by Validate
+func Validated(r *http.Request) url.Values {
+	if data, ok := r.Context().Value(validatedKey{}).(url.Values); ok {
+		return data
+	}
+	return url.Values{}
+}
+
+// requestValues merges the query string with the body; top level JSON values are read as text
+// and the body is restored so the handler can decode it again
+func (grv *Goravel) requestValues(rw http.ResponseWriter, r *http.Request) (url.Values, error) {
+	data := url.Values{}
+	for k, v := range r.URL.Query() {
+		data[k] = v
+	}
+
+	if r.Body == nil || r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return data, nil
+	}
+
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := r.ParseForm(); err != nil {
+			return nil, err
+		}
+		for k, v := range r.PostForm {
+			data[k] = v
+		}
+		return data, nil
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(rw, r.Body, 1048576))
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return data, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, errors.New("body must be a json object")
+	}
+	for k, v := range fields {
+		switch v := v.(type) {
+		case nil:
+		case string:
+			data.Set(k, v)
+		case []interface{}:
+			for _, item := range v {
+				data.Add(k, fmt.Sprint(item))
+			}
+		case map[string]interface{}:
+			// nested objects are left to the handler, but they count as present
+			data.Set(k, "{}")
+		default:
+			b, _ := json.Marshal(v)
+			data.Set(k, string(b))
+		}
+	}
+
+	return data, nil
+}
+
+// Schema checks v.Data against the rules of every field
+func (v *Validation) Schema(schema Schema) {
+	for field, rules := range schema {
+		value := strings.TrimSpace(v.Data.Get(field))
+		list := strings.Split(rules, "|")
+
+		if value == "" {
+			for _, rule := range list {
+				if rule == "required" {
+					v.AddError(field, "This field cannot be blank")
+				}
+			}
+			continue
+		}
+
+		numeric := false
+		for _, rule := range list {
+			if rule == "int" || rule == "float" {
+				numeric = true
+			}
+		}
+
+		for _, rule := range list {
+			name, arg := rule, ""
+			if i := strings.Index(rule, ":"); i >= 0 {
+				name, arg = rule[:i], rule[i+1:]
+			}
+
+			switch name {
+			case "", "required":
+			case "email":
+				v.IsEmail(field, value)
+			case "int":
+				v.IsInt(field, value)
+			case "float":
+				v.IsFloat(field, value)
+			case "date":
+				v.IsDateISO(field, value)
+			case "nospaces":
+				v.NoSpaces(field, value)
+			case "phone":
+				v.IsPhone(field, value, arg)
+			case "min", "max":
+				v.bound(field, value, name, arg, numeric)
+			case "in":
+				v.Check(inList(value, strings.Split(arg, ",")), field, "This field must be one of "+strings.ReplaceAll(arg, ",", ", "))
+			default:
+				v.AddError(field, "Unknown validation rule "+name)
+			}
+		}
+	}
+}
+
+func (v *Validation) bound(field, value, rule, arg string, numeric bool) {
+	limit, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		v.AddError(field, "Invalid limit for rule "+rule)
+		return
+	}
+
+	n := float64(utf8.RuneCountInString(value))
+	unit := " characters"
+	if numeric {
+		if n, err = strconv.ParseFloat(value, 64); err != nil {
+			// the int or float rule reports the error
+			return
+		}
+		unit = ""
+	}
+
+	if rule == "min" {
+		v.Check(n >= limit, field, fmt.Sprintf("This field must be at least %s%s", arg, unit))
+	} else {
+		v.Check(n <= limit, field, fmt.Sprintf("This field must be at most %s%s", arg, unit))
+	}
+}
+
+func inList(value string, list []string) bool {
+	for _, item := range list {
+		if value == strings.TrimSpace(item) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package goravel
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	grv := testApp(&bytes.Buffer{})
+
+	tests := []struct {
+		name   string
+		schema Schema
+		data   url.Values
+		errors map[string]string
+	}{
+		{"required missing", Schema{"email": "required"}, url.Values{}, map[string]string{"email": "This field cannot be blank"}},
+		{"required blank", Schema{"email": "required|email"}, url.Values{"email": {"  "}}, map[string]string{"email": "This field cannot be blank"}},
+		{"optional missing", Schema{"age": "int|min:18"}, url.Values{}, nil},
+		{"int", Schema{"age": "int"}, url.Values{"age": {"ten"}}, map[string]string{"age": ""}},
+		{"float", Schema{"price": "float"}, url.Values{"price": {"1.5"}}, nil},
+		{"float fails", Schema{"price": "float"}, url.Values{"price": {"cheap"}}, map[string]string{"price": ""}},
+		{"email", Schema{"email": "email"}, url.Values{"email": {"ann.example.com"}}, map[string]string{"email": ""}},
+		{"date", Schema{"born": "date"}, url.Values{"born": {"01/02/2000"}}, map[string]string{"born": ""}},
+		{"date passes", Schema{"born": "date"}, url.Values{"born": {"2000-01-02"}}, nil},
+		{"nospaces", Schema{"slug": "nospaces"}, url.Values{"slug": {"a b"}}, map[string]string{"slug": ""}},
+		{"min of a number", Schema{"age": "int|min:18"}, url.Values{"age": {"17"}}, map[string]string{"age": "This field must be at least 18"}},
+		{"max of text", Schema{"name": "max:3"}, url.Values{"name": {"Anna"}}, map[string]string{"name": "This field must be at most 3 characters"}},
+		{"min of text in runes", Schema{"name": "min:3"}, url.Values{"name": {"Zoë"}}, nil},
+		{"in", Schema{"plan": "in:free,pro"}, url.Values{"plan": {"gold"}}, map[string]string{"plan": "This field must be one of free, pro"}},
+		{"unknown rule", Schema{"plan": "upper"}, url.Values{"plan": {"pro"}}, map[string]string{"plan": "Unknown validation rule upper"}},
+		{"bad limit", Schema{"name": "max:few"}, url.Values{"name": {"Ann"}}, map[string]string{"name": "Invalid limit for rule max"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := grv.Validator(tt.data)
+			v.Schema(tt.schema)
+
+			if len(v.Error) != len(tt.errors) {
+				t.Fatalf("expected the errors %v, got %v", tt.errors, v.Error)
+			}
+			for field, message := range tt.errors {
+				got, ok := v.Error[field]
+				if !ok || (message != "" && got != message) {
+					t.Errorf("expected %s to fail with %q, got %q", field, message, got)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	grv := testApp(&bytes.Buffer{})
+	var checked url.Values
+	h := grv.Validate(Schema{"email": "required|email", "age": "int|min:18", "plan": "in:free,pro"})(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			checked = Validated(r)
+			var body map[string]interface{}
+			if err := grv.ReadJSON(rw, r, &body); err != nil {
+				t.Errorf("expected the body left for the handler, got %v", err)
+			}
+			rw.WriteHeader(http.StatusNoContent)
+		}))
+
+	rw := postJSON(h, "/api/users?plan=pro", `{"email": "ann@example.com", "age": 30}`)
+	if rw.Code != http.StatusNoContent {
+		t.Fatalf("expected the request let through, got %d %s", rw.Code, rw.Body)
+	}
+	if checked.Get("email") != "ann@example.com" || checked.Get("age") != "30" || checked.Get("plan") != "pro" {
+		t.Errorf("expected the checked values of the body and query, got %v", checked)
+	}
+
+	rw = postJSON(h, "/api/users?plan=gold", `{"age": 17}`)
+	if rw.Code != http.StatusUnprocessableEntity || rw.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("expected a JSON 422, got %d %s", rw.Code, rw.Body)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(rw.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"error":   true,
+		"message": "The given data was invalid",
+		"errors": map[string]interface{}{
+			"email": "This field cannot be blank",
+			"age":   "This field must be at least 18",
+			"plan":  "This field must be one of free, pro",
+		},
+	}
+	if got, _ := json.Marshal(body); !bytes.Equal(got, mustMarshal(t, want)) {
+		t.Errorf("expected the body %s, got %s", mustMarshal(t, want), got)
+	}
+
+	rw = postJSON(h, "/api/users", `["ann@example.com"]`)
+	if rw.Code != http.StatusBadRequest || !strings.Contains(rw.Body.String(), "body must be a json object") {
+		t.Errorf("expected a 400 for a body which is not an object, got %d %s", rw.Code, rw.Body)
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -0,0 +1,189 @@
+package semaphore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrBusy is returned by TryDo when every permit is taken
+var ErrBusy = errors.New("semaphore: all permits are taken")
+
+// Store keeps the permits of semaphores, shared by the instances using it
+type Store interface {
+	// Acquire takes a permit of name for holder while fewer than limit are held, or extends
+	// the one holder has, reporting whether holder holds one now
+	Acquire(ctx context.Context, name, holder string, limit int, ttl time.Duration) (bool, error)
+	// Release gives the permit of holder back
+	Release(ctx context.Context, name, holder string) error
+}
+
+// Semaphore caps the number of concurrent runs of an operation across instances, e.g. at
+// most 3 imports at a time. Permits expire after TTL unless renewed, so the permits of a
+// crashed instance come back by themselves.
+type Semaphore struct {
+	Store Store
+	Name  string
+	Limit int
+	TTL   time.Duration
+	// Retry is how often Acquire tries again while every permit is taken
+	Retry time.Duration
+	// Busy answers the requests refused by Middleware; by default a 503
+	Busy     http.Handler
+	ErrorLog func(v ...interface{})
+}
+
+// New returns a semaphore of limit permits with a TTL of 30 seconds
+func New(store Store, name string, limit int) *Semaphore {
+	return &Semaphore{
+		Store:    store,
+		Name:     name,
+		Limit:    limit,
+		TTL:      30 * time.Second,
+		Retry:    250 * time.Millisecond,
+		ErrorLog: log.Println,
+	}
+}
+
+// Permit is a held permit, renewed in the background until released
+type Permit struct {
+	s      *Semaphore
+	holder string
+	stop   chan struct{}
+	once   sync.Once
+}
+
+func holderID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// TryAcquire takes a permit without waiting, returning nil when every permit is taken
+func (s *Semaphore) TryAcquire(ctx context.Context) (*Permit, error) {
+	holder := holderID()
+	ok, err := s.Store.Acquire(ctx, s.Name, holder, s.Limit, s.TTL)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	p := &Permit{s: s, holder: holder, stop: make(chan struct{})}
+	go p.renew()
+	return p, nil
+}
+
+// Acquire waits for a permit until ctx is done
+func (s *Semaphore) Acquire(ctx context.Context) (*Permit, error) {
+	for {
+		p, err := s.TryAcquire(ctx)
+		if err != nil || p != nil {
+			return p, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(s.Retry):
+		}
+	}
+}
+
+// renew extends the permit every third of the TTL, so it only expires when its holder is gone
+func (p *Permit) renew() {
+	ticker := time.NewTicker(p.s.TTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), p.s.TTL/3)
+			ok, err := p.s.Store.Acquire(ctx, p.s.Name, p.holder, p.s.Limit, p.s.TTL)
+			cancel()
+			if err != nil {
+				p.s.logError("semaphore:", p.s.Name, err)
+			} else if !ok {
+				p.s.logError("semaphore:", p.s.Name, "permit expired before it was renewed")
+			}
+		}
+	}
+}
+
+// Release gives the permit back; releasing it again does nothing
+func (p *Permit) Release() {
+	p.once.Do(func() {
+		close(p.stop)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := p.s.Store.Release(ctx, p.s.Name, p.holder); err != nil {
+			// the permit comes back once its TTL runs out
+			p.s.logError("semaphore: release:", p.s.Name, err)
+		}
+	})
+}
+
+// Do runs fn holding a permit, waiting for one until ctx is done. The permit is released
+// when fn returns or panics.
+func (s *Semaphore) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	p, err := s.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer p.Release()
+
+	return fn(ctx)
+}
+
+// TryDo runs fn holding a permit, or returns ErrBusy when every permit is taken
+func (s *Semaphore) TryDo(ctx context.Context, fn func(ctx context.Context) error) error {
+	p, err := s.TryAcquire(ctx)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return ErrBusy
+	}
+	defer p.Release()
+
+	return fn(ctx)
+}
+
+// Middleware lets at most Limit requests run the handler at a time, answering the others with Busy
+func (s *Semaphore) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		p, err := s.TryAcquire(r.Context())
+		if err != nil {
+			s.logError("semaphore:", s.Name, err)
+		}
+		if p == nil {
+			s.busy(rw, r)
+			return
+		}
+		defer p.Release()
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+func (s *Semaphore) busy(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Retry-After", strconv.Itoa(int(s.Retry/time.Second)+1))
+	if s.Busy != nil {
+		s.Busy.ServeHTTP(rw, r)
+		return
+	}
+	http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+}
+
+func (s *Semaphore) logError(v ...interface{}) {
+	if s.ErrorLog != nil {
+		s.ErrorLog(v...)
+	}
+}
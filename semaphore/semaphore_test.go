@@ -0,0 +1,117 @@
+package semaphore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+)
+
+func stores(t *testing.T) map[string]Store {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+
+	pool := &redis.Pool{Dial: func() (redis.Conn, error) { return redis.Dial("tcp", s.Addr()) }}
+	return map[string]Store{
+		"memory": &MemoryStore{},
+		"redis":  &RedisStore{Pool: pool, Prefix: "test"},
+	}
+}
+
+func TestLimit(t *testing.T) {
+	for name, store := range stores(t) {
+		s := New(store, "imports", 2)
+		s.Retry = time.Millisecond
+
+		var running, peak int32
+		var wg sync.WaitGroup
+		for i := 0; i < 6; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := s.Do(context.Background(), func(ctx context.Context) error {
+					n := atomic.AddInt32(&running, 1)
+					for {
+						p := atomic.LoadInt32(&peak)
+						if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+							break
+						}
+					}
+					time.Sleep(5 * time.Millisecond)
+					atomic.AddInt32(&running, -1)
+					return nil
+				})
+				if err != nil {
+					t.Error(name, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if peak != 2 {
+			t.Errorf("%s: expected 2 runs at a time, got %d", name, peak)
+		}
+	}
+}
+
+func TestReleaseOnPanicAndExpiry(t *testing.T) {
+	for name, store := range stores(t) {
+		s := New(store, "reports", 1)
+
+		func() {
+			defer func() { _ = recover() }()
+			_ = s.Do(context.Background(), func(ctx context.Context) error { panic("boom") })
+		}()
+		if err := s.TryDo(context.Background(), func(ctx context.Context) error { return nil }); err != nil {
+			t.Errorf("%s: expected the permit to be released after a panic, got %v", name, err)
+		}
+
+		// a holder which died without releasing
+		if ok, _ := store.Acquire(context.Background(), "reports", "gone", 1, 20*time.Millisecond); !ok {
+			t.Fatal(name, "expected a permit")
+		}
+		if err := s.TryDo(context.Background(), func(ctx context.Context) error { return nil }); err != ErrBusy {
+			t.Errorf("%s: expected ErrBusy, got %v", name, err)
+		}
+		time.Sleep(30 * time.Millisecond)
+		if p, _ := s.TryAcquire(context.Background()); p == nil {
+			t.Errorf("%s: expected the expired permit to come back", name)
+		} else {
+			p.Release()
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	s := New(&MemoryStore{}, "exports", 1)
+
+	release := make(chan struct{})
+	h := s.Middleware(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/export", nil))
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/export", nil))
+	if rw.Code != http.StatusServiceUnavailable || rw.Header().Get("Retry-After") == "" {
+		t.Errorf("expected a 503 while the permit is taken, got %d", rw.Code)
+	}
+
+	close(release)
+	<-done
+}
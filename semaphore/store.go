@@ -0,0 +1,101 @@
+package semaphore
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/namnguyen191/goravel/clock"
+)
+
+// acquire drops expired permits, then renews the permit of the holder or adds one while
+// there is room. The permits are a sorted set of holders scored by their expiry in ms.
+var acquire = redis.NewScript(1, `
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[1])
+if redis.call("ZSCORE", KEYS[1], ARGV[3]) or redis.call("ZCARD", KEYS[1]) < tonumber(ARGV[4]) then
+	redis.call("ZADD", KEYS[1], ARGV[2], ARGV[3])
+	redis.call("PEXPIRE", KEYS[1], ARGV[5])
+	return 1
+end
+return 0`)
+
+// RedisStore keeps permits in redis, shared by every instance
+type RedisStore struct {
+	Pool   *redis.Pool
+	Prefix string
+	// Clock tells when a permit expires; the time of the machine when nil
+	Clock clock.Clock
+}
+
+func (s *RedisStore) key(name string) string {
+	return s.Prefix + ":semaphore:" + name
+}
+
+func (s *RedisStore) Acquire(ctx context.Context, name, holder string, limit int, ttl time.Duration) (bool, error) {
+	conn, err := s.Pool.GetContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	// expiries use the clock of the instances, which should be kept in sync
+	now := clock.Now(s.Clock).UnixNano() / int64(time.Millisecond)
+	ms := ttl.Milliseconds()
+	n, err := redis.Int(acquire.DoContext(ctx, conn, s.key(name), now, now+ms, holder, limit, ms))
+	return n == 1, err
+}
+
+func (s *RedisStore) Release(ctx context.Context, name, holder string) error {
+	conn, err := s.Pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = redis.DoContext(conn, ctx, "ZREM", s.key(name), holder)
+	return err
+}
+
+// MemoryStore keeps permits in the process, for tests and single instance apps
+type MemoryStore struct {
+	mu      sync.Mutex
+	permits map[string]map[string]time.Time
+	// Clock tells when a permit expires; the time of the machine when nil
+	Clock clock.Clock
+}
+
+func (s *MemoryStore) Acquire(ctx context.Context, name, holder string, limit int, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.permits == nil {
+		s.permits = map[string]map[string]time.Time{}
+	}
+	held := s.permits[name]
+	if held == nil {
+		held = map[string]time.Time{}
+		s.permits[name] = held
+	}
+
+	now := clock.Now(s.Clock)
+	for h, expires := range held {
+		if !expires.After(now) {
+			delete(held, h)
+		}
+	}
+
+	if _, ok := held[holder]; !ok && len(held) >= limit {
+		return false, nil
+	}
+	held[holder] = now.Add(ttl)
+	return true, nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, name, holder string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.permits[name], holder)
+	return nil
+}
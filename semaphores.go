@@ -0,0 +1,35 @@
+package goravel
+
+import (
+	"net/http"
+
+	"github.com/namnguyen191/goravel/semaphore"
+)
+
+// semaphores keeps permits in redis when it is configured so the limits hold across
+// instances, and in the process otherwise
+func (grv *Goravel) semaphores() semaphore.Store {
+	grv.semaphoreOnce.Do(func() {
+		if redisPool != nil {
+			grv.semaphoreStore = &semaphore.RedisStore{Pool: redisPool, Prefix: grv.Namespace, Clock: grv.Clock}
+		} else {
+			grv.semaphoreStore = &semaphore.MemoryStore{Clock: grv.Clock}
+		}
+	})
+	return grv.semaphoreStore
+}
+
+// Semaphore caps the concurrent runs of an operation, e.g. in a job:
+//
+//	err := app.Semaphore("imports", 3).Do(ctx, func(ctx context.Context) error {
+//		return importFile(ctx, path)
+//	})
+//
+// or for a handler with app.Routes.With(app.Semaphore("exports", 2).Middleware). Refused
+// requests get the 503 error page.
+func (grv *Goravel) Semaphore(name string, limit int) *semaphore.Semaphore {
+	s := semaphore.New(grv.semaphores(), name, limit)
+	s.ErrorLog = grv.ErrorLog.Println
+	s.Busy = http.HandlerFunc(grv.ErrorUnavailable)
+	return s
+}
@@ -0,0 +1,201 @@
+package session
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+)
+
+// maxCookie is the most browsers keep of a cookie, its name and attributes included
+const maxCookie = 4096
+
+var (
+	// ErrNoExchange is returned when a CookieStore commits outside of WithCookies
+	ErrNoExchange = errors.New("session: the cookie store needs WithCookies around the session middleware")
+	// ErrTooLarge is returned when the data of a session does not fit in a cookie
+	ErrTooLarge = errors.New("session: the data does not fit in a cookie")
+)
+
+// CookieStore keeps the data of each session sealed with AES-GCM in a cookie of the client,
+// so sessions need no server. It reads and writes the cookie through the request and
+// response WithCookies puts in the context, which scs passes to the Ctx methods.
+type CookieStore struct {
+	// Secret keys the cipher, hashed so a KEY of any length will do
+	Secret []byte
+	// Name is the name of the cookie; "session_data" when empty
+	Name   string
+	Domain string
+	Secure bool
+	// Clock tells whether a session has expired; the time of the machine when nil
+	Clock clock.Clock
+}
+
+type exchangeKey struct{}
+
+// exchange is the request and response of the session being loaded and saved
+type exchange struct {
+	r *http.Request
+	w http.ResponseWriter
+}
+
+// WithCookies lets a CookieStore, or a FailoverStore falling back to one, reach the cookies
+// of the request; it wraps the LoadAndSave middleware of the session manager
+func WithCookies(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), exchangeKey{}, &exchange{r: r, w: rw})
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// cookieData is what the cookie holds, bound to the token of the session cookie
+type cookieData struct {
+	Token  string    `json:"t"`
+	Expiry time.Time `json:"e"`
+	Data   []byte    `json:"d"`
+}
+
+func (s *CookieStore) name() string {
+	if s.Name == "" {
+		return "session_data"
+	}
+	return s.Name
+}
+
+// FindCtx returns the data of the session for token kept in the cookie of the request
+func (s *CookieStore) FindCtx(ctx context.Context, token string) ([]byte, bool, error) {
+	ex, ok := ctx.Value(exchangeKey{}).(*exchange)
+	if !ok {
+		return nil, false, nil
+	}
+	cookie, err := ex.r.Cookie(s.name())
+	if err != nil {
+		return nil, false, nil
+	}
+
+	// a cookie which was tampered with, sealed with another key or expired is no session
+	var data cookieData
+	if err := s.open(cookie.Value, &data); err != nil || data.Token != token || !clock.Now(s.Clock).Before(data.Expiry) {
+		return nil, false, nil
+	}
+
+	return data.Data, true, nil
+}
+
+// CommitCtx seals the data of the session for token into the cookie of the response
+func (s *CookieStore) CommitCtx(ctx context.Context, token string, b []byte, expiry time.Time) error {
+	ex, ok := ctx.Value(exchangeKey{}).(*exchange)
+	if !ok {
+		return ErrNoExchange
+	}
+
+	value, err := s.seal(cookieData{Token: token, Expiry: expiry, Data: b})
+	if err != nil {
+		return err
+	}
+	cookie := s.cookie(value, expiry)
+	if len(cookie.String()) > maxCookie {
+		return ErrTooLarge
+	}
+	http.SetCookie(ex.w, cookie)
+
+	return nil
+}
+
+// DeleteCtx expires the cookie of the request, if it has one
+func (s *CookieStore) DeleteCtx(ctx context.Context, token string) error {
+	ex, ok := ctx.Value(exchangeKey{}).(*exchange)
+	if !ok {
+		return nil
+	}
+	if _, err := ex.r.Cookie(s.name()); err != nil {
+		return nil
+	}
+
+	cookie := s.cookie("", time.Unix(1, 0))
+	cookie.MaxAge = -1
+	http.SetCookie(ex.w, cookie)
+
+	return nil
+}
+
+// Find is FindCtx without the request, so never finds a session
+func (s *CookieStore) Find(token string) ([]byte, bool, error) {
+	return s.FindCtx(context.Background(), token)
+}
+
+// Commit is CommitCtx without the response, so always fails with ErrNoExchange
+func (s *CookieStore) Commit(token string, b []byte, expiry time.Time) error {
+	return s.CommitCtx(context.Background(), token, b, expiry)
+}
+
+// Delete is DeleteCtx without the request, so does nothing
+func (s *CookieStore) Delete(token string) error {
+	return s.DeleteCtx(context.Background(), token)
+}
+
+func (s *CookieStore) cookie(value string, expiry time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     s.name(),
+		Value:    value,
+		Path:     "/",
+		Domain:   s.Domain,
+		Expires:  expiry,
+		Secure:   s.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func (s *CookieStore) seal(data cookieData) (string, error) {
+	plain, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := s.cipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(gcm.Seal(nonce, nonce, plain, nil)), nil
+}
+
+func (s *CookieStore) open(sealed string, data *cookieData) error {
+	gcm, err := s.cipher()
+	if err != nil {
+		return err
+	}
+	b, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil || len(b) < gcm.NonceSize() {
+		return errors.New("session: malformed cookie")
+	}
+	plain, err := gcm.Open(nil, b[:gcm.NonceSize()], b[gcm.NonceSize():], nil)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(plain, data)
+}
+
+func (s *CookieStore) cipher() (cipher.AEAD, error) {
+	if len(s.Secret) == 0 {
+		return nil, errors.New("session: the cookie store needs a secret")
+	}
+	sum := sha256.Sum256(s.Secret)
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,139 @@
+package session
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/alexedwards/scs/v2/memstore"
+)
+
+// visit serves one request with the cookies of the last response, returning the new ones
+func visit(t *testing.T, h http.Handler, cookies []*http.Cookie) (*httptest.ResponseRecorder, []*http.Cookie) {
+	t.Helper()
+	r := httptest.NewRequest("GET", "/", nil)
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, r)
+	if rw.Code != http.StatusOK {
+		t.Fatalf("expected a 200, got %d %s", rw.Code, rw.Body)
+	}
+
+	// keep the cookies of the last response the client would still send
+	kept := map[string]*http.Cookie{}
+	for _, c := range cookies {
+		kept[c.Name] = c
+	}
+	for _, c := range rw.Result().Cookies() {
+		if c.MaxAge < 0 {
+			delete(kept, c.Name)
+			continue
+		}
+		kept[c.Name] = c
+	}
+	cookies = cookies[:0]
+	for _, c := range kept {
+		cookies = append(cookies, c)
+	}
+	return rw, cookies
+}
+
+// counter counts the visits of a session
+func counter(sm *scs.SessionManager) http.Handler {
+	return WithCookies(sm.LoadAndSave(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		n := sm.GetInt(r.Context(), "visits") + 1
+		sm.Put(r.Context(), "visits", n)
+		rw.Write([]byte(strings.Repeat("*", n)))
+	})))
+}
+
+func TestCookieStore(t *testing.T) {
+	sm := scs.New()
+	sm.Store = &CookieStore{Secret: []byte("secret")}
+	h := counter(sm)
+
+	rw, cookies := visit(t, h, nil)
+	rw, cookies = visit(t, h, cookies)
+	if rw.Body.String() != "**" {
+		t.Fatalf("expected the session kept in the cookie, got %q", rw.Body)
+	}
+
+	for _, c := range cookies {
+		if c.Name == "session_data" {
+			c.Value = c.Value[:len(c.Value)-2] + "AA"
+		}
+	}
+	if rw, _ = visit(t, h, cookies); rw.Body.String() != "*" {
+		t.Errorf("expected a tampered cookie to start a new session, got %q", rw.Body)
+	}
+
+	other := scs.New()
+	other.Store = &CookieStore{Secret: []byte("another secret")}
+	_, cookies = visit(t, h, nil)
+	if rw, _ = visit(t, counter(other), cookies); rw.Body.String() != "*" {
+		t.Errorf("expected a cookie sealed with another secret to start a new session, got %q", rw.Body)
+	}
+}
+
+func TestCookieStore_limits(t *testing.T) {
+	s := &CookieStore{Secret: []byte("secret")}
+	if err := s.Commit("abc", []byte("data"), time.Now().Add(time.Hour)); err != ErrNoExchange {
+		t.Errorf("expected ErrNoExchange outside of WithCookies, got %v", err)
+	}
+
+	sm := scs.New()
+	sm.Store = s
+	var err error
+	h := WithCookies(sm.LoadAndSave(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		sm.Put(r.Context(), "cart", strings.Repeat("x", maxCookie))
+		_, _, err = sm.Commit(r.Context())
+	})))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if err != ErrTooLarge {
+		t.Errorf("expected ErrTooLarge, got %v", err)
+	}
+}
+
+func TestFailoverStore_cookies(t *testing.T) {
+	down := false
+	primary := &brokenStore{MemStore: memstore.New(), down: &down}
+	store := NewFailoverStore(primary, func() error {
+		if down {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	store.Fallback = &CookieStore{Secret: []byte("secret")}
+	store.RetryEvery = 0
+
+	sm := scs.New()
+	sm.Store = store
+	h := counter(sm)
+
+	down = true
+	_, cookies := visit(t, h, nil)
+	rw, cookies := visit(t, h, cookies)
+	if !store.Degraded() || rw.Body.String() != "**" {
+		t.Fatalf("expected the session kept in the cookie while degraded, got %q", rw.Body)
+	}
+
+	down = false
+	rw, cookies = visit(t, h, cookies)
+	if store.Degraded() || rw.Body.String() != "***" {
+		t.Fatalf("expected the session moved to Primary on recovery, got %q", rw.Body)
+	}
+	for _, c := range cookies {
+		if c.Name == "session_data" {
+			t.Error("expected the cookie of the fallback removed once the session moved")
+		}
+	}
+	if rw, _ = visit(t, h, cookies); rw.Body.String() != "****" {
+		t.Errorf("expected the session served by Primary, got %q", rw.Body)
+	}
+}
@@ -0,0 +1,193 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/alexedwards/scs/v2/memstore"
+	"github.com/namnguyen191/goravel/clock"
+)
+
+// FailoverStore keeps sessions in Primary and moves them to Fallback while Primary is
+// unreachable, so users stay logged in instead of getting errors. Fallback is a store in
+// memory, which only this instance sees, unless set to a CookieStore, which every one does.
+type FailoverStore struct {
+	Primary  scs.Store
+	Fallback scs.Store
+	// Ping reports whether Primary is reachable
+	Ping func() error
+	// RetryEvery is how often Primary is probed while degraded
+	RetryEvery time.Duration
+	// OnChange is called whenever the store enters or leaves degraded mode
+	OnChange func(degraded bool, err error)
+	// Clock tells when Primary is due for a probe; the time of the machine when nil
+	Clock clock.Clock
+
+	mu        sync.Mutex
+	degraded  bool
+	lastProbe time.Time
+	failovers uint64
+}
+
+// NewFailoverStore wraps primary with a fallback in memory
+func NewFailoverStore(primary scs.Store, ping func() error) *FailoverStore {
+	return &FailoverStore{
+		Primary:    primary,
+		Fallback:   memstore.NewWithCleanupInterval(time.Minute),
+		Ping:       ping,
+		RetryEvery: 5 * time.Second,
+	}
+}
+
+// Degraded reports whether sessions are currently kept in Fallback
+func (s *FailoverStore) Degraded() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.degraded
+}
+
+// Failovers returns how many times the store switched to the fallback
+func (s *FailoverStore) Failovers() uint64 {
+	return atomic.LoadUint64(&s.failovers)
+}
+
+func (s *FailoverStore) active() scs.Store {
+	s.mu.Lock()
+	if !s.degraded || clock.Since(s.Clock, s.lastProbe) < s.RetryEvery {
+		defer s.mu.Unlock()
+		if s.degraded {
+			return s.Fallback
+		}
+		return s.Primary
+	}
+	s.lastProbe = clock.Now(s.Clock)
+	s.mu.Unlock()
+
+	if s.Ping() != nil {
+		return s.Fallback
+	}
+
+	s.setDegraded(false, nil)
+
+	return s.Primary
+}
+
+func (s *FailoverStore) failed(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	pingErr := s.Ping()
+	if pingErr == nil {
+		return false
+	}
+
+	s.setDegraded(true, pingErr)
+
+	return true
+}
+
+func (s *FailoverStore) setDegraded(degraded bool, err error) {
+	s.mu.Lock()
+	if s.degraded == degraded {
+		s.mu.Unlock()
+		return
+	}
+	s.degraded = degraded
+	s.lastProbe = clock.Now(s.Clock)
+	s.mu.Unlock()
+
+	if degraded {
+		atomic.AddUint64(&s.failovers, 1)
+	}
+
+	if s.OnChange != nil {
+		s.OnChange(degraded, err)
+	}
+}
+
+// FindCtx returns the session data for token
+func (s *FailoverStore) FindCtx(ctx context.Context, token string) ([]byte, bool, error) {
+	store := s.active()
+	b, found, err := find(ctx, store, token)
+	if store == s.Primary && s.failed(err) {
+		return find(ctx, s.Fallback, token)
+	}
+
+	// sessions started while degraded only live in the fallback
+	if !found && err == nil && store == s.Primary {
+		return find(ctx, s.Fallback, token)
+	}
+
+	return b, found, err
+}
+
+// CommitCtx saves the session data for token
+func (s *FailoverStore) CommitCtx(ctx context.Context, token string, b []byte, expiry time.Time) error {
+	store := s.active()
+	err := commit(ctx, store, token, b, expiry)
+	if store == s.Primary && s.failed(err) {
+		return commit(ctx, s.Fallback, token, b, expiry)
+	}
+
+	// a session started while degraded has moved to Primary
+	if err == nil && store == s.Primary {
+		_ = remove(ctx, s.Fallback, token)
+	}
+
+	return err
+}
+
+// DeleteCtx removes the session for token from both stores
+func (s *FailoverStore) DeleteCtx(ctx context.Context, token string) error {
+	_ = remove(ctx, s.Fallback, token)
+
+	store := s.active()
+	err := remove(ctx, store, token)
+	if store == s.Primary && s.failed(err) {
+		return nil
+	}
+
+	return err
+}
+
+// Find is FindCtx for callers without a context
+func (s *FailoverStore) Find(token string) ([]byte, bool, error) {
+	return s.FindCtx(context.Background(), token)
+}
+
+// Commit is CommitCtx for callers without a context
+func (s *FailoverStore) Commit(token string, b []byte, expiry time.Time) error {
+	return s.CommitCtx(context.Background(), token, b, expiry)
+}
+
+// Delete is DeleteCtx for callers without a context
+func (s *FailoverStore) Delete(token string) error {
+	return s.DeleteCtx(context.Background(), token)
+}
+
+// find, commit and remove pass ctx on to stores which take one, like CookieStore
+func find(ctx context.Context, store scs.Store, token string) ([]byte, bool, error) {
+	if cs, ok := store.(scs.CtxStore); ok {
+		return cs.FindCtx(ctx, token)
+	}
+	return store.Find(token)
+}
+
+func commit(ctx context.Context, store scs.Store, token string, b []byte, expiry time.Time) error {
+	if cs, ok := store.(scs.CtxStore); ok {
+		return cs.CommitCtx(ctx, token, b, expiry)
+	}
+	return store.Commit(token, b, expiry)
+}
+
+func remove(ctx context.Context, store scs.Store, token string) error {
+	if cs, ok := store.(scs.CtxStore); ok {
+		return cs.DeleteCtx(ctx, token)
+	}
+	return store.Delete(token)
+}
@@ -0,0 +1,64 @@
+package session
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2/memstore"
+)
+
+type brokenStore struct {
+	*memstore.MemStore
+	down *bool
+}
+
+func (b *brokenStore) Find(token string) ([]byte, bool, error) {
+	if *b.down {
+		return nil, false, errors.New("connection refused")
+	}
+	return b.MemStore.Find(token)
+}
+
+func (b *brokenStore) Commit(token string, v []byte, expiry time.Time) error {
+	if *b.down {
+		return errors.New("connection refused")
+	}
+	return b.MemStore.Commit(token, v, expiry)
+}
+
+func TestFailoverStore(t *testing.T) {
+	down := false
+	primary := &brokenStore{MemStore: memstore.New(), down: &down}
+	store := NewFailoverStore(primary, func() error {
+		if down {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	store.RetryEvery = 0
+
+	expiry := time.Now().Add(time.Hour)
+
+	down = true
+	if err := store.Commit("abc", []byte("data"), expiry); err != nil {
+		t.Fatal("expected commit to fall back:", err)
+	}
+	if !store.Degraded() || store.Failovers() != 1 {
+		t.Error("expected store to be degraded")
+	}
+
+	b, found, err := store.Find("abc")
+	if err != nil || !found || string(b) != "data" {
+		t.Error("expected session from the fallback, got", string(b), found, err)
+	}
+
+	down = false
+	b, found, _ = store.Find("abc")
+	if store.Degraded() {
+		t.Error("expected store to recover")
+	}
+	if !found || string(b) != "data" {
+		t.Error("expected sessions started while degraded to survive recovery")
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/alexedwards/scs/redisstore"
 	"github.com/alexedwards/scs/v2"
 	"github.com/gomodule/redigo/redis"
+	"github.com/namnguyen191/goravel/clock"
 )
 
 type Session struct {
@@ -23,6 +24,19 @@ type Session struct {
 	CookieSecure   string
 	DBPool         *sql.DB
 	RedisPool      *redis.Pool
+	// Prefix namespaces the redis keys of sessions, e.g. "myapp:session:"; by default "scs:session:"
+	Prefix string
+	// Failover keeps redis sessions in cookies while redis is unreachable, sealed with Secret;
+	// in memory without one. LoadAndSave must be wrapped with WithCookies.
+	Failover bool
+	// Secret seals the sessions kept in cookies, usually KEY
+	Secret string
+	// OnFailover is called when the redis store goes down or recovers
+	OnFailover func(degraded bool, err error)
+	// Clock is the clock of the app, which the failover store probes redis by
+	Clock clock.Clock
+	// Store, when set, is used instead of the store SessionType names, e.g. one opened with Open
+	Store scs.Store
 }
 
 func (c *Session) InitSession() *scs.SessionManager {
@@ -56,10 +70,36 @@ func (c *Session) InitSession() *scs.SessionManager {
 	session.Cookie.Domain = c.CookieDomain
 	session.Cookie.SameSite = http.SameSiteLaxMode
 
+	if c.Store != nil {
+		session.Store = c.Store
+		return session
+	}
+
 	// which session store
 	switch strings.ToLower(c.SessionType) {
 	case "redis":
-		session.Store = redisstore.New(c.RedisPool)
+		redisStore := redisstore.New(c.RedisPool)
+		if c.Prefix != "" {
+			redisStore = redisstore.NewWithPrefix(c.RedisPool, c.Prefix)
+		}
+
+		if c.Failover {
+			store := NewFailoverStore(redisStore, c.pingRedis)
+			store.OnChange = c.OnFailover
+			store.Clock = c.Clock
+			if c.Secret != "" {
+				store.Fallback = &CookieStore{
+					Secret: []byte(c.Secret),
+					Name:   c.CookieName + "_data",
+					Domain: c.CookieDomain,
+					Secure: secure,
+					Clock:  c.Clock,
+				}
+			}
+			session.Store = store
+		} else {
+			session.Store = redisStore
+		}
 	case "mysql", "mariadb":
 		session.Store = mysqlstore.New(c.DBPool)
 
@@ -73,3 +113,12 @@ func (c *Session) InitSession() *scs.SessionManager {
 
 	return session
 }
+
+func (c *Session) pingRedis() error {
+	conn := c.RedisPool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("PING")
+
+	return err
+}
@@ -0,0 +1,97 @@
+package session
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/gomodule/redigo/redis"
+)
+
+// Config is what a store factory gets: the connections of the app, so a store kept in the
+// database or redis can share them, and the lifetime of the sessions
+type Config struct {
+	DBPool    *sql.DB
+	RedisPool *redis.Pool
+	// Prefix namespaces the keys of the sessions, e.g. "myapp:session:"
+	Prefix   string
+	Lifetime time.Duration
+}
+
+// Factory opens a session store, e.g. a scs store backed by DynamoDB or Memcached
+type Factory func(cfg Config) (scs.Store, error)
+
+var (
+	storesMu sync.RWMutex
+	stores   = map[string]Factory{}
+)
+
+// builtin are the SESSION_TYPE values InitSession handles itself
+var builtin = map[string]bool{
+	"cookie": true, "redis": true, "mysql": true, "mariadb": true, "postgres": true, "postgresql": true,
+}
+
+// Register makes a store available as SESSION_TYPE=name, usually from the init function of
+// the package implementing it. It panics when name is taken or factory is nil.
+func Register(name string, factory Factory) {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+
+	if factory == nil {
+		panic("session: Register factory is nil")
+	}
+	if builtin[name] {
+		panic("session: Register called for the built in store " + name)
+	}
+	if _, dup := stores[name]; dup {
+		panic("session: Register called twice for store " + name)
+	}
+	stores[name] = factory
+}
+
+// Stores returns the names of the registered stores, sorted
+func Stores() []string {
+	storesMu.RLock()
+	defer storesMu.RUnlock()
+
+	names := make([]string, 0, len(stores))
+	for name := range stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Registered reports whether a store was registered as name
+func Registered(name string) bool {
+	storesMu.RLock()
+	defer storesMu.RUnlock()
+
+	_, ok := stores[name]
+	return ok
+}
+
+// Builtin reports whether name is a store InitSession handles without registering it
+func Builtin(name string) bool {
+	return name == "" || builtin[name]
+}
+
+// Open opens the store registered as name
+func Open(name string, cfg Config) (scs.Store, error) {
+	storesMu.RLock()
+	factory := stores[name]
+	storesMu.RUnlock()
+
+	if factory == nil {
+		return nil, fmt.Errorf("session: unknown store %q (forgotten import?)", name)
+	}
+
+	store, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("session: %s: %w", name, err)
+	}
+	return store, nil
+}
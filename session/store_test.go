@@ -0,0 +1,38 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/alexedwards/scs/v2/memstore"
+)
+
+func TestRegister(t *testing.T) {
+	store := memstore.New()
+	Register("memory", func(cfg Config) (scs.Store, error) { return store, nil })
+
+	if !Registered("memory") || Builtin("memory") || !Builtin("redis") {
+		t.Fatalf("expected memory to be registered, got %v", Stores())
+	}
+
+	opened, err := Open("memory", Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Session{CookieName: "goravel", SessionType: "memory", Store: opened}
+	if sm := c.InitSession(); sm.Store != store {
+		t.Errorf("expected the registered store, got %T", sm.Store)
+	}
+
+	if _, err := Open("dynamodb", Config{}); err == nil {
+		t.Error("expected an error for an unknown store")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected registering a built in store to panic")
+		}
+	}()
+	Register("redis", func(cfg Config) (scs.Store, error) { return store, nil })
+}
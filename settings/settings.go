@@ -0,0 +1,323 @@
+package settings
+
+import (
+	"context"
+	"database/sql"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/namnguyen191/goravel/cache"
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+)
+
+const cacheKey = "settings"
+
+// ErrNotFound is returned when a setting does not exist
+var ErrNotFound = errors.New("setting not found")
+
+func init() {
+	gob.Register(map[string]string{})
+}
+
+// Settings gives typed access to site-wide options stored in the settings table. Values are
+// loaded once, kept in memory and shared with other instances through the cache when one is set.
+type Settings struct {
+	DB           *sql.DB
+	DatabaseType string
+	Cache        cache.Cache
+	// TTL in seconds of the cached copy shared between instances
+	TTL int
+	// Clock tells the time of the changes; the time of the machine when nil
+	Clock clock.Clock
+
+	mu        sync.RWMutex
+	values    map[string]string
+	loaded    bool
+	listeners []func(Change)
+}
+
+// Change describes an update of a setting
+type Change struct {
+	Key string
+	Old string
+	New string
+}
+
+// Setting is the database row of a setting, registered with the admin panel for editing
+type Setting struct {
+	ID        int       `db:"id,omitempty"`
+	Name      string    `db:"name"`
+	Value     string    `db:"value"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// Table returns the table name
+func (s *Setting) Table() string {
+	return "settings"
+}
+
+// New returns settings backed by the given database and optional cache
+func New(db *sql.DB, dbType string, c cache.Cache) *Settings {
+	return &Settings{
+		DB:           db,
+		DatabaseType: dbType,
+		Cache:        c,
+		TTL:          3600,
+	}
+}
+
+func (s *Settings) load() error {
+	s.mu.RLock()
+	loaded := s.loaded
+	s.mu.RUnlock()
+
+	if loaded {
+		return nil
+	}
+
+	values, err := s.fetch()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.values = values
+	s.loaded = true
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *Settings) fetch() (map[string]string, error) {
+	if s.Cache != nil {
+		if cached, err := s.Cache.Get(cacheKey); err == nil {
+			if values, ok := cached.(map[string]string); ok {
+				return values, nil
+			}
+		}
+	}
+
+	rows, err := s.DB.QueryContext(context.Background(), "select name, value from settings")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make(map[string]string)
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		values[name] = value
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if s.Cache != nil {
+		_ = s.Cache.Set(cacheKey, values, s.TTL)
+	}
+
+	return values, nil
+}
+
+// Get returns the raw value of a setting
+func (s *Settings) Get(key string) (string, error) {
+	if err := s.load(); err != nil {
+		return "", err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.values[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	return value, nil
+}
+
+// String returns a setting, or def when it is missing
+func (s *Settings) String(key, def string) string {
+	value, err := s.Get(key)
+	if err != nil {
+		return def
+	}
+
+	return value
+}
+
+// Int returns a setting as an int, or def when it is missing or invalid
+func (s *Settings) Int(key string, def int) int {
+	value, err := s.Get(key)
+	if err != nil {
+		return def
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return def
+	}
+
+	return i
+}
+
+// Float returns a setting as a float64, or def when it is missing or invalid
+func (s *Settings) Float(key string, def float64) float64 {
+	value, err := s.Get(key)
+	if err != nil {
+		return def
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return def
+	}
+
+	return f
+}
+
+// Bool returns a setting as a bool, or def when it is missing or invalid
+func (s *Settings) Bool(key string, def bool) bool {
+	value, err := s.Get(key)
+	if err != nil {
+		return def
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return def
+	}
+
+	return b
+}
+
+// Duration returns a setting parsed with time.ParseDuration, or def when it is missing or invalid
+func (s *Settings) Duration(key string, def time.Duration) time.Duration {
+	value, err := s.Get(key)
+	if err != nil {
+		return def
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return def
+	}
+
+	return d
+}
+
+// All returns a copy of every setting
+func (s *Settings) All() (map[string]string, error) {
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	all := make(map[string]string, len(s.values))
+	for k, v := range s.values {
+		all[k] = v
+	}
+
+	return all, nil
+}
+
+// Set stores a setting, creating it when needed, and notifies the change listeners
+func (s *Settings) Set(key string, value interface{}) error {
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	str := fmt.Sprint(value)
+	now := clock.Now(s.Clock)
+
+	res, err := s.DB.Exec(database.Rebind(s.DatabaseType, "update settings set value = ?, updated_at = ? where name = ?"), str, now, key)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		_, err = s.DB.Exec(database.Rebind(s.DatabaseType, "insert into settings (name, value, created_at, updated_at) values (?, ?, ?, ?)"), key, str, now, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	old := s.values[key]
+	s.values[key] = str
+	s.mu.Unlock()
+
+	s.forgetCache()
+	s.notify(Change{Key: key, Old: old, New: str})
+
+	return nil
+}
+
+// Delete removes a setting
+func (s *Settings) Delete(key string) error {
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	_, err := s.DB.Exec(database.Rebind(s.DatabaseType, "delete from settings where name = ?"), key)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	old := s.values[key]
+	delete(s.values, key)
+	s.mu.Unlock()
+
+	s.forgetCache()
+	s.notify(Change{Key: key, Old: old})
+
+	return nil
+}
+
+// OnChange registers a listener called after every Set or Delete
+func (s *Settings) OnChange(fn func(Change)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.listeners = append(s.listeners, fn)
+}
+
+// Flush drops the loaded values so the next read goes back to the cache or database; it is
+// used when settings are edited outside of Set, e.g. from the admin panel
+func (s *Settings) Flush() {
+	s.mu.Lock()
+	s.values = nil
+	s.loaded = false
+	s.mu.Unlock()
+
+	s.forgetCache()
+}
+
+func (s *Settings) forgetCache() {
+	if s.Cache != nil {
+		_ = s.Cache.Forget(cacheKey)
+	}
+}
+
+func (s *Settings) notify(c Change) {
+	s.mu.RLock()
+	listeners := append([]func(Change){}, s.listeners...)
+	s.mu.RUnlock()
+
+	for _, fn := range listeners {
+		fn(c)
+	}
+}
@@ -0,0 +1,73 @@
+package settings
+
+import (
+	"testing"
+	"time"
+)
+
+func testSettings() *Settings {
+	s := New(nil, "postgres", nil)
+	s.values = map[string]string{
+		"site_name":   "Goravel",
+		"per_page":    "25",
+		"maintenance": "true",
+		"ratio":       "0.5",
+		"timeout":     "90s",
+		"bad_int":     "abc",
+	}
+	s.loaded = true
+
+	return s
+}
+
+func TestSettings_Typed(t *testing.T) {
+	s := testSettings()
+
+	if s.String("site_name", "") != "Goravel" {
+		t.Error("wrong string value")
+	}
+
+	if s.Int("per_page", 10) != 25 {
+		t.Error("wrong int value")
+	}
+
+	if s.Int("bad_int", 10) != 10 {
+		t.Error("expected default for invalid int")
+	}
+
+	if !s.Bool("maintenance", false) {
+		t.Error("wrong bool value")
+	}
+
+	if s.Float("ratio", 0) != 0.5 {
+		t.Error("wrong float value")
+	}
+
+	if s.Duration("timeout", time.Second) != 90*time.Second {
+		t.Error("wrong duration value")
+	}
+
+	if s.String("missing", "fallback") != "fallback" {
+		t.Error("expected default for missing key")
+	}
+}
+
+func TestSettings_Get(t *testing.T) {
+	s := testSettings()
+
+	if _, err := s.Get("missing"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestSettings_notify(t *testing.T) {
+	s := testSettings()
+
+	var got Change
+	s.OnChange(func(c Change) { got = c })
+	s.notify(Change{Key: "site_name", Old: "a", New: "b"})
+
+	if got.Key != "site_name" || got.New != "b" {
+		t.Errorf("listener not called with the change, got %+v", got)
+	}
+}
@@ -0,0 +1,72 @@
+package goravel
+
+import (
+	"context"
+	"time"
+)
+
+// OnBoot registers fn to run once the process starts serving, working or scheduling, e.g. to
+// warm a cache; an error stops the process before it takes any work
+func (grv *Goravel) OnBoot(fn func() error) {
+	grv.hooksMu.Lock()
+	defer grv.hooksMu.Unlock()
+
+	grv.bootHooks = append(grv.bootHooks, fn)
+}
+
+// OnShutdown registers fn to run when the process stops, once the server stopped taking
+// requests and before the database and stores are closed. The hooks run in the reverse
+// order they were registered and share SHUTDOWN_TIMEOUT through ctx.
+func (grv *Goravel) OnShutdown(fn func(ctx context.Context) error) {
+	grv.hooksMu.Lock()
+	defer grv.hooksMu.Unlock()
+
+	grv.shutdownHooks = append(grv.shutdownHooks, fn)
+}
+
+// start runs the boot hooks, shutting down when one of them fails
+func (grv *Goravel) start() {
+	grv.hooksMu.Lock()
+	hooks := append([]func() error{}, grv.bootHooks...)
+	grv.hooksMu.Unlock()
+
+	for _, fn := range hooks {
+		if err := fn(); err != nil {
+			grv.shutdown()
+			grv.ErrorLog.Fatal("boot hook: ", err)
+		}
+	}
+}
+
+// shutdown stops the scheduler, sends the queued mail and runs the shutdown hooks within
+// SHUTDOWN_TIMEOUT, then closes the database and the stores
+func (grv *Goravel) shutdown() {
+	defer grv.closeStores()
+
+	ctx, cancel := context.WithTimeout(context.Background(), grv.Env.Duration("SHUTDOWN_TIMEOUT", 10*time.Second))
+	defer cancel()
+
+	if grv.Scheduler != nil {
+		select {
+		case <-grv.Scheduler.Stop().Done():
+		case <-ctx.Done():
+			grv.ErrorLog.Println("shutdown: scheduled jobs still running")
+		}
+	}
+
+	if grv.Mail.Jobs != nil {
+		if err := grv.Mail.Drain(ctx); err != nil {
+			grv.ErrorLog.Printf("shutdown: %d queued mails not sent: %v", len(grv.Mail.Jobs), err)
+		}
+	}
+
+	grv.hooksMu.Lock()
+	hooks := append([]func(context.Context) error{}, grv.shutdownHooks...)
+	grv.hooksMu.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i](ctx); err != nil {
+			grv.ErrorLog.Println("shutdown hook:", err)
+		}
+	}
+}
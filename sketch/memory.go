@@ -0,0 +1,148 @@
+package sketch
+
+import (
+	"context"
+	"math"
+	"math/bits"
+	"sync"
+)
+
+// precision is the number of hash bits picking an HyperLogLog register, as in redis
+const precision = 14
+
+const registers = 1 << precision
+
+// MemoryCounter counts in the process, for tests and single instance apps
+type MemoryCounter struct {
+	mu   sync.Mutex
+	keys map[string]*[registers]uint8
+}
+
+// NewMemoryCounter returns an empty counter
+func NewMemoryCounter() *MemoryCounter {
+	return &MemoryCounter{keys: map[string]*[registers]uint8{}}
+}
+
+func (c *MemoryCounter) Add(ctx context.Context, key string, items ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	regs := c.keys[key]
+	if regs == nil {
+		regs = new([registers]uint8)
+		c.keys[key] = regs
+	}
+
+	for _, item := range items {
+		h, _ := hash(item)
+		i := h >> (64 - precision)
+		// the rank of the first set bit of the rest of the hash
+		rank := uint8(bits.LeadingZeros64(h<<precision|1<<(precision-1)) + 1)
+		if rank > regs[i] {
+			regs[i] = rank
+		}
+	}
+	return nil
+}
+
+func (c *MemoryCounter) Count(ctx context.Context, keys ...string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var union [registers]uint8
+	for _, key := range keys {
+		if regs := c.keys[key]; regs != nil {
+			for i, r := range regs {
+				if r > union[i] {
+					union[i] = r
+				}
+			}
+		}
+	}
+
+	return estimate(&union), nil
+}
+
+// Delete forgets the items of key
+func (c *MemoryCounter) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.keys, key)
+}
+
+// estimate is the HyperLogLog estimate, counting empty registers for small sets
+func estimate(regs *[registers]uint8) int64 {
+	m := float64(registers)
+	sum, zeros := 0.0, 0
+	for _, r := range regs {
+		sum += math.Pow(2, -float64(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	e := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if e <= 2.5*m && zeros > 0 {
+		e = m * math.Log(m/float64(zeros))
+	}
+	return int64(math.Round(e))
+}
+
+// MemoryFilter remembers items in the process, for tests and single instance apps
+type MemoryFilter struct {
+	Size Size
+
+	mu   sync.Mutex
+	keys map[string][]uint64
+}
+
+// NewMemoryFilter returns an empty filter of size
+func NewMemoryFilter(size Size) *MemoryFilter {
+	return &MemoryFilter{Size: size, keys: map[string][]uint64{}}
+}
+
+func (f *MemoryFilter) Add(ctx context.Context, key, item string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	set := f.keys[key]
+	if set == nil {
+		set = make([]uint64, (f.Size.Bits+63)/64)
+		f.keys[key] = set
+	}
+
+	seen := true
+	for _, o := range f.Size.offsets(item) {
+		if set[o/64]&(1<<(o%64)) == 0 {
+			seen = false
+			set[o/64] |= 1 << (o % 64)
+		}
+	}
+	return seen, nil
+}
+
+func (f *MemoryFilter) Test(ctx context.Context, key, item string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	set := f.keys[key]
+	if set == nil {
+		return false, nil
+	}
+
+	for _, o := range f.Size.offsets(item) {
+		if set[o/64]&(1<<(o%64)) == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Delete forgets the items of key
+func (f *MemoryFilter) Delete(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.keys, key)
+}
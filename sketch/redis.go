@@ -0,0 +1,128 @@
+package sketch
+
+import (
+	"context"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// RedisCounter counts with the redis HyperLogLog commands, shared by every instance
+type RedisCounter struct {
+	Pool   *redis.Pool
+	Prefix string
+	// TTL expires keys after their last addition, e.g. two days for daily visitor counts; zero keeps them
+	TTL time.Duration
+}
+
+func (c *RedisCounter) key(key string) string {
+	return c.Prefix + ":hll:" + key
+}
+
+func (c *RedisCounter) Add(ctx context.Context, key string, items ...string) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	conn, err := c.Pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	args := redis.Args{}.Add(c.key(key)).AddFlat(items)
+	if _, err := redis.DoContext(conn, ctx, "PFADD", args...); err != nil {
+		return err
+	}
+
+	if c.TTL > 0 {
+		_, err = redis.DoContext(conn, ctx, "PEXPIRE", c.key(key), c.TTL.Milliseconds())
+	}
+	return err
+}
+
+func (c *RedisCounter) Count(ctx context.Context, keys ...string) (int64, error) {
+	conn, err := c.Pool.GetContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	args := redis.Args{}
+	for _, key := range keys {
+		args = args.Add(c.key(key))
+	}
+	return redis.Int64(redis.DoContext(conn, ctx, "PFCOUNT", args...))
+}
+
+// setBits sets the bits in ARGV, after the ttl in ms, returning 1 when all of them were set already
+var setBits = redis.NewScript(1, `
+local seen = 1
+for i = 2, #ARGV do
+	if redis.call("SETBIT", KEYS[1], ARGV[i], 1) == 0 then
+		seen = 0
+	end
+end
+if tonumber(ARGV[1]) > 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return seen`)
+
+// RedisFilter keeps a Bloom filter in a redis bitmap, without needing the RedisBloom module
+type RedisFilter struct {
+	Pool   *redis.Pool
+	Prefix string
+	Size   Size
+	// TTL expires keys after their last addition; zero keeps them
+	TTL time.Duration
+}
+
+func (f *RedisFilter) key(key string) string {
+	return f.Prefix + ":bloom:" + key
+}
+
+func (f *RedisFilter) Add(ctx context.Context, key, item string) (bool, error) {
+	conn, err := f.Pool.GetContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	args := redis.Args{}.Add(f.key(key), f.TTL.Milliseconds())
+	for _, o := range f.Size.offsets(item) {
+		args = args.Add(o)
+	}
+
+	seen, err := redis.Int(setBits.DoContext(ctx, conn, args...))
+	return seen == 1, err
+}
+
+func (f *RedisFilter) Test(ctx context.Context, key, item string) (bool, error) {
+	conn, err := f.Pool.GetContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	offsets := f.Size.offsets(item)
+	for _, o := range offsets {
+		if err := conn.Send("GETBIT", f.key(key), o); err != nil {
+			return false, err
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		return false, err
+	}
+
+	seen := true
+	for range offsets {
+		bit, err := redis.Int(conn.Receive())
+		if err != nil {
+			return false, err
+		}
+		if bit == 0 {
+			seen = false
+		}
+	}
+	return seen, nil
+}
@@ -0,0 +1,66 @@
+package sketch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+)
+
+// Counter estimates the number of distinct items added under a key, like a HyperLogLog,
+// in a few KB whatever the count, with an error of about 1%
+type Counter interface {
+	// Add counts items under key
+	Add(ctx context.Context, key string, items ...string) error
+	// Count estimates the distinct items of the union of keys
+	Count(ctx context.Context, keys ...string) (int64, error)
+}
+
+// Filter remembers items under a key, like a Bloom filter: Test never misses an added item,
+// and reports one which was never added with the false positive rate the filter was sized for
+type Filter interface {
+	// Add remembers item under key, reporting whether it may have been added before
+	Add(ctx context.Context, key, item string) (bool, error)
+	// Test reports whether item may have been added under key
+	Test(ctx context.Context, key, item string) (bool, error)
+}
+
+// Size is the number of bits and hash functions of a Bloom filter
+type Size struct {
+	Bits   uint64
+	Hashes int
+}
+
+// SizeFor returns the size of a filter holding expected items with a false positive rate
+// of rate, e.g. SizeFor(100000, 0.01) takes about 117KB
+func SizeFor(expected int, rate float64) Size {
+	if expected < 1 {
+		expected = 1
+	}
+	if rate <= 0 || rate >= 1 {
+		rate = 0.01
+	}
+
+	bits := math.Ceil(-float64(expected) * math.Log(rate) / (math.Ln2 * math.Ln2))
+	hashes := int(math.Round(bits / float64(expected) * math.Ln2))
+	if hashes < 1 {
+		hashes = 1
+	}
+	return Size{Bits: uint64(bits), Hashes: hashes}
+}
+
+// hash returns two independent 64 bit hashes of item
+func hash(item string) (uint64, uint64) {
+	sum := sha256.Sum256([]byte(item))
+	return binary.BigEndian.Uint64(sum[0:8]), binary.BigEndian.Uint64(sum[8:16])
+}
+
+// offsets returns the bits of item, using double hashing to derive the hash functions
+func (s Size) offsets(item string) []uint64 {
+	h1, h2 := hash(item)
+	offsets := make([]uint64, s.Hashes)
+	for i := range offsets {
+		offsets[i] = (h1 + uint64(i)*h2) % s.Bits
+	}
+	return offsets
+}
@@ -0,0 +1,97 @@
+package sketch
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+)
+
+func testPool(t *testing.T) *redis.Pool {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+
+	return &redis.Pool{Dial: func() (redis.Conn, error) { return redis.Dial("tcp", s.Addr()) }}
+}
+
+func TestCounters(t *testing.T) {
+	ctx := context.Background()
+	counters := map[string]Counter{
+		"memory": NewMemoryCounter(),
+		"redis":  &RedisCounter{Pool: testPool(t), Prefix: "test", TTL: time.Hour},
+	}
+
+	for name, c := range counters {
+		for i := 0; i < 20000; i++ {
+			// every visitor comes back once
+			v := fmt.Sprintf("visitor-%d", i%10000)
+			if err := c.Add(ctx, "day1", v); err != nil {
+				t.Fatal(name, err)
+			}
+		}
+		_ = c.Add(ctx, "day2", "visitor-1", "visitor-new")
+
+		n, err := c.Count(ctx, "day1")
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if math.Abs(float64(n)-10000) > 300 {
+			t.Errorf("%s: expected about 10000 visitors, got %d", name, n)
+		}
+
+		if n, _ := c.Count(ctx, "day2"); n != 2 {
+			t.Errorf("%s: expected small counts to be exact, got %d", name, n)
+		}
+		if n, _ := c.Count(ctx, "day1", "day2"); math.Abs(float64(n)-10001) > 300 {
+			t.Errorf("%s: expected the union to count about 10001, got %d", name, n)
+		}
+		if n, _ := c.Count(ctx, "missing"); n != 0 {
+			t.Errorf("%s: expected nothing counted under a new key, got %d", name, n)
+		}
+	}
+}
+
+func TestFilters(t *testing.T) {
+	ctx := context.Background()
+	size := SizeFor(1000, 0.01)
+	if size.Bits != 9586 || size.Hashes != 7 {
+		t.Errorf("unexpected size %+v", size)
+	}
+
+	filters := map[string]Filter{
+		"memory": NewMemoryFilter(size),
+		"redis":  &RedisFilter{Pool: testPool(t), Prefix: "test", Size: size},
+	}
+
+	for name, f := range filters {
+		for i := 0; i < 1000; i++ {
+			if _, err := f.Add(ctx, "emails", fmt.Sprintf("user%d@example.com", i)); err != nil {
+				t.Fatal(name, err)
+			}
+		}
+
+		if seen, _ := f.Add(ctx, "emails", "user1@example.com"); !seen {
+			t.Errorf("%s: expected an added item to be seen", name)
+		}
+		if ok, _ := f.Test(ctx, "emails", "user999@example.com"); !ok {
+			t.Errorf("%s: expected Test to find an added item", name)
+		}
+
+		positives := 0
+		for i := 0; i < 1000; i++ {
+			if ok, _ := f.Test(ctx, "emails", fmt.Sprintf("other%d@example.com", i)); ok {
+				positives++
+			}
+		}
+		if positives > 30 {
+			t.Errorf("%s: expected about 1%% false positives, got %d in 1000", name, positives)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package goravel
+
+import (
+	"time"
+
+	"github.com/namnguyen191/goravel/sketch"
+)
+
+// NewCounter returns a HyperLogLog counter of distinct items, e.g. unique visitors, kept in
+// redis when it is configured so every instance shares it, and in memory otherwise. Keys
+// expire ttl after their last addition; zero keeps them.
+func (grv *Goravel) NewCounter(ttl time.Duration) sketch.Counter {
+	if redisPool == nil {
+		return sketch.NewMemoryCounter()
+	}
+	return &sketch.RedisCounter{Pool: redisPool, Prefix: grv.Namespace, TTL: ttl}
+}
+
+// NewFilter returns a Bloom filter for seen before checks, sized for expected items per key
+// with a false positive rate of rate, e.g. grv.NewFilter(100000, 0.01, 0). It is kept like
+// the counters of NewCounter.
+func (grv *Goravel) NewFilter(expected int, rate float64, ttl time.Duration) sketch.Filter {
+	size := sketch.SizeFor(expected, rate)
+	if redisPool == nil {
+		return sketch.NewMemoryFilter(size)
+	}
+	return &sketch.RedisFilter{Pool: redisPool, Prefix: grv.Namespace, Size: size, TTL: ttl}
+}
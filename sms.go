@@ -0,0 +1,43 @@
+package goravel
+
+import (
+	"os"
+	"strings"
+
+	"github.com/namnguyen191/goravel/sms"
+)
+
+// createSMS builds the sms sender from SMS_DRIVER; twilio and vonage are built in.
+// Provider calls go through the "sms" circuit breaker and templates are read from sms.
+func (grv *Goravel) createSMS() *sms.SMS {
+	var driver sms.Driver
+	client := grv.HTTPClient("sms")
+
+	switch strings.ToLower(os.Getenv("SMS_DRIVER")) {
+	case "twilio":
+		driver = &sms.Twilio{
+			AccountSID: os.Getenv("TWILIO_SID"),
+			AuthToken:  os.Getenv("TWILIO_TOKEN"),
+			StatusURL:  os.Getenv("SMS_STATUS_URL"),
+			Client:     client,
+		}
+	case "vonage":
+		driver = &sms.Vonage{
+			APIKey:          os.Getenv("VONAGE_KEY"),
+			APISecret:       os.Getenv("VONAGE_SECRET"),
+			SignatureSecret: os.Getenv("VONAGE_SIGNATURE_SECRET"),
+			StatusURL:       os.Getenv("SMS_STATUS_URL"),
+			Client:          client,
+		}
+	default:
+		return nil
+	}
+
+	s := sms.New(driver, os.Getenv("SMS_FROM"), grv.DB.Pool, grv.DB.DataBaseType)
+	s.Region = strings.ToUpper(os.Getenv("SMS_REGION"))
+	s.Templates = grv.RootPath + "/sms"
+	s.ErrorLog = grv.ErrorLog.Println
+	s.Clock = grv.Clock
+
+	return s
+}
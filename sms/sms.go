@@ -0,0 +1,285 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/contact"
+	"github.com/namnguyen191/goravel/database"
+	"github.com/namnguyen191/goravel/trace"
+)
+
+var (
+	// ErrOptedOut is returned when sending to a number on the opt-out list
+	ErrOptedOut = errors.New("sms: recipient has opted out")
+	// ErrInvalidSignature is returned for status webhooks not signed by the provider
+	ErrInvalidSignature = errors.New("sms: invalid webhook signature")
+)
+
+// Delivery states reported by the provider status webhooks
+const (
+	Queued    = "queued"
+	Sent      = "sent"
+	Delivered = "delivered"
+	Failed    = "failed"
+)
+
+// Driver sends text messages through a provider and parses its delivery status webhooks
+type Driver interface {
+	Name() string
+	// Send returns the provider's id of the message
+	Send(ctx context.Context, from, to, body string) (string, error)
+	ParseStatus(r *http.Request) (*Status, error)
+}
+
+// Message is a text message; the body is either rendered from Template with Data or given as Body
+type Message struct {
+	From     string
+	To       string
+	Template string
+	Body     string
+	Data     interface{}
+	// RequestID is the id of the request which queued the message, set by QueueContext
+	RequestID string
+}
+
+// Result is the outcome of a queued message
+type Result struct {
+	ID        string
+	To        string
+	Error     error
+	RequestID string
+}
+
+// Status is a delivery report; OptedOut is set when the provider refused the message
+// because the recipient unsubscribed, e.g. by replying STOP
+type Status struct {
+	ID       string
+	To       string
+	State    string
+	Code     string
+	Error    string
+	OptedOut bool
+}
+
+// SMS sends messages through Driver, skipping numbers on the opt-out list
+type SMS struct {
+	Driver Driver
+	From   string
+	// Region is used for numbers not in international format, e.g. "US"
+	Region string
+	// Templates is the directory of <name>.sms.tmpl message templates
+	Templates string
+	// DB keeps the opt-out list; without it every number is sent to
+	DB           *sql.DB
+	DatabaseType string
+	Jobs         chan Message
+	// Results receives the outcome of queued messages when set; results are dropped while it is full
+	Results chan Result
+	// OnStatus is called for every delivery report received by StatusHandler
+	OnStatus func(s *Status)
+	ErrorLog func(v ...interface{})
+	// Clock tells when numbers opt out; the time of the machine when nil
+	Clock clock.Clock
+}
+
+// New returns an sms sender with a queue of 20 messages
+func New(driver Driver, from string, db *sql.DB, dbType string) *SMS {
+	return &SMS{
+		Driver:       driver,
+		From:         from,
+		DB:           db,
+		DatabaseType: dbType,
+		Jobs:         make(chan Message, 20),
+		ErrorLog:     log.Println,
+	}
+}
+
+func (s *SMS) rebind(query string) string {
+	return database.Rebind(s.DatabaseType, query)
+}
+
+// ListenForSMS sends the messages put on Jobs
+func (s *SMS) ListenForSMS() {
+	for msg := range s.Jobs {
+		id, err := s.Send(trace.WithID(context.Background(), msg.RequestID), msg)
+		if err != nil && !errors.Is(err, ErrOptedOut) {
+			s.ErrorLog("sms:", msg.To, msg.RequestID, err)
+		}
+
+		if s.Results != nil {
+			select {
+			case s.Results <- Result{ID: id, To: msg.To, Error: err, RequestID: msg.RequestID}:
+			default:
+			}
+		}
+	}
+}
+
+// Queue puts a message on Jobs to be sent by ListenForSMS
+func (s *SMS) Queue(msg Message) {
+	s.Jobs <- msg
+}
+
+// QueueContext queues msg under the request id of ctx, which the provider calls then carry
+func (s *SMS) QueueContext(ctx context.Context, msg Message) {
+	if msg.RequestID == "" {
+		msg.RequestID = trace.ID(ctx)
+	}
+	s.Queue(msg)
+}
+
+// Send renders and sends a message right away, returning the provider's id
+func (s *SMS) Send(ctx context.Context, msg Message) (string, error) {
+	if s.Driver == nil {
+		return "", errors.New("sms: no driver configured")
+	}
+
+	to, err := contact.NormalizePhone(msg.To, s.Region)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := s.OptedOut(ctx, to)
+	if err != nil {
+		return "", err
+	}
+	if out {
+		return "", ErrOptedOut
+	}
+
+	body, err := s.render(msg)
+	if err != nil {
+		return "", err
+	}
+
+	from := msg.From
+	if from == "" {
+		from = s.From
+	}
+
+	id, err := s.Driver.Send(ctx, from, to, body)
+	if errors.Is(err, ErrOptedOut) {
+		if err := s.OptOut(ctx, to); err != nil {
+			s.ErrorLog("sms: opt out", to, err)
+		}
+	}
+
+	return id, err
+}
+
+func (s *SMS) render(msg Message) (string, error) {
+	if msg.Template == "" {
+		return msg.Body, nil
+	}
+
+	tmpl, err := template.ParseFiles(filepath.Join(s.Templates, msg.Template+".sms.tmpl"))
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, msg.Data); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// OptedOut reports whether a number, in E.164 form, is on the opt-out list
+func (s *SMS) OptedOut(ctx context.Context, number string) (bool, error) {
+	if s.DB == nil {
+		return false, nil
+	}
+
+	var n int
+	err := s.DB.QueryRowContext(ctx, s.rebind("select count(*) from sms_opt_outs where phone = ?"), number).Scan(&n)
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// OptOut adds a number to the opt-out list
+func (s *SMS) OptOut(ctx context.Context, number string) error {
+	if s.DB == nil {
+		return nil
+	}
+
+	number, err := contact.NormalizePhone(number, s.Region)
+	if err != nil {
+		return err
+	}
+
+	out, err := s.OptedOut(ctx, number)
+	if err != nil || out {
+		return err
+	}
+
+	_, err = s.DB.ExecContext(ctx, s.rebind("insert into sms_opt_outs (phone, created_at) values (?, ?)"), number, clock.Now(s.Clock))
+	return err
+}
+
+// OptIn removes a number from the opt-out list
+func (s *SMS) OptIn(ctx context.Context, number string) error {
+	if s.DB == nil {
+		return nil
+	}
+
+	number, err := contact.NormalizePhone(number, s.Region)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.DB.ExecContext(ctx, s.rebind("delete from sms_opt_outs where phone = ?"), number)
+	return err
+}
+
+// StatusHandler receives the driver's delivery status webhooks. Recipients the
+// provider reports as unsubscribed are added to the opt-out list.
+func (s *SMS) StatusHandler(w http.ResponseWriter, r *http.Request) {
+	if s.Driver == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	st, err := s.Driver.ParseStatus(r)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrInvalidSignature) {
+			status = http.StatusUnauthorized
+		}
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	if st.OptedOut && st.To != "" {
+		if err := s.OptOut(r.Context(), st.To); err != nil {
+			s.ErrorLog("sms: opt out", st.To, err)
+		}
+	}
+
+	if s.OnStatus != nil {
+		s.OnStatus(st)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apiError formats a provider error response
+func apiError(driver string, status int, message string) error {
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return fmt.Errorf("sms: %s: %s", driver, message)
+}
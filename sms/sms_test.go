@@ -0,0 +1,171 @@
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/namnguyen191/goravel/trace"
+)
+
+type fakeDriver struct {
+	to, body string
+	status   *Status
+}
+
+func (f *fakeDriver) Name() string { return "fake" }
+
+func (f *fakeDriver) Send(ctx context.Context, from, to, body string) (string, error) {
+	f.to, f.body = to, body
+	return "m1", nil
+}
+
+func (f *fakeDriver) ParseStatus(r *http.Request) (*Status, error) {
+	return f.status, nil
+}
+
+func TestSend_Template(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "code.sms.tmpl"), []byte("Your code is {{.Code}}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &fakeDriver{}
+	s := New(d, "+15550001111", nil, "")
+	s.Templates = dir
+	s.Region = "US"
+
+	id, err := s.Send(context.Background(), Message{To: "(415) 555-2671", Template: "code", Data: map[string]string{"Code": "1234"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "m1" || d.to != "+14155552671" || d.body != "Your code is 1234" {
+		t.Errorf("unexpected send %q %q %q", id, d.to, d.body)
+	}
+}
+
+func TestListenForSMS(t *testing.T) {
+	d := &fakeDriver{}
+	s := New(d, "+15550001111", nil, "")
+	s.Results = make(chan Result, 1)
+	go s.ListenForSMS()
+
+	s.QueueContext(trace.WithID(context.Background(), "req-1"), Message{To: "+14155552671", Body: "hello"})
+	res := <-s.Results
+	if res.Error != nil || res.ID != "m1" || d.body != "hello" || res.RequestID != "req-1" {
+		t.Errorf("unexpected result %+v", res)
+	}
+}
+
+func TestTwilio(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		if r.URL.Path != "/2010-04-01/Accounts/AC1/Messages.json" || user != "AC1" || pass != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code":20003,"message":"Authenticate"}`))
+			return
+		}
+		r.ParseForm()
+		if r.PostForm.Get("Body") != "hi" || r.PostForm.Get("StatusCallback") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"sid":"SM1","status":"queued"}`))
+	}))
+	defer srv.Close()
+
+	tw := &Twilio{AccountSID: "AC1", AuthToken: "token", StatusURL: "https://example.com/api/sms/status", Endpoint: srv.URL}
+	id, err := tw.Send(context.Background(), "+15550001111", "+14155552671", "hi")
+	if err != nil || id != "SM1" {
+		t.Fatalf("unexpected send %q %v", id, err)
+	}
+
+	tw.AuthToken = "wrong"
+	if _, err := tw.Send(context.Background(), "+15550001111", "+14155552671", "hi"); err == nil || !strings.Contains(err.Error(), "Authenticate") {
+		t.Errorf("expected api error, got %v", err)
+	}
+	tw.AuthToken = "token"
+
+	form := url.Values{"MessageSid": {"SM1"}, "MessageStatus": {"undelivered"}, "To": {"+14155552671"}, "ErrorCode": {"21610"}}
+	mac := hmac.New(sha1.New, []byte("token"))
+	mac.Write([]byte(tw.StatusURL + "ErrorCode21610MessageSidSM1MessageStatusundeliveredTo+14155552671"))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/sms/status", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Twilio-Signature", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+
+	st, err := tw.ParseStatus(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.ID != "SM1" || st.State != Failed || !st.OptedOut {
+		t.Errorf("unexpected status %+v", st)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/sms/status", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Twilio-Signature", base64.StdEncoding.EncodeToString([]byte("forged")))
+	if _, err := tw.ParseStatus(req); err != ErrInvalidSignature {
+		t.Errorf("expected invalid signature, got %v", err)
+	}
+}
+
+func TestVonage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.PostForm.Get("to") {
+		case "14155552671":
+			w.Write([]byte(`{"message-count":"1","messages":[{"status":"0","message-id":"V1"}]}`))
+		default:
+			w.Write([]byte(`{"message-count":"1","messages":[{"status":"7","error-text":"Number barred"}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	v := &Vonage{APIKey: "k", APISecret: "s", SignatureSecret: "secret", Endpoint: srv.URL}
+	id, err := v.Send(context.Background(), "Shop", "+14155552671", "hi")
+	if err != nil || id != "V1" {
+		t.Fatalf("unexpected send %q %v", id, err)
+	}
+	if _, err := v.Send(context.Background(), "Shop", "+14155550000", "hi"); err != ErrOptedOut {
+		t.Errorf("expected opted out, got %v", err)
+	}
+
+	sum := md5.Sum([]byte("&messageId=V1&msisdn=14155552671&status=delivered" + "secret"))
+	q := url.Values{"messageId": {"V1"}, "msisdn": {"14155552671"}, "status": {"delivered"}, "sig": {hex.EncodeToString(sum[:])}}
+
+	st, err := v.ParseStatus(httptest.NewRequest(http.MethodGet, "/api/sms/status?"+q.Encode(), nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.ID != "V1" || st.To != "+14155552671" || st.State != Delivered {
+		t.Errorf("unexpected status %+v", st)
+	}
+
+	q.Set("status", "failed")
+	if _, err := v.ParseStatus(httptest.NewRequest(http.MethodGet, "/api/sms/status?"+q.Encode(), nil)); err != ErrInvalidSignature {
+		t.Errorf("expected invalid signature, got %v", err)
+	}
+}
+
+func TestStatusHandler(t *testing.T) {
+	var got *Status
+	s := New(&fakeDriver{status: &Status{ID: "m1", State: Delivered}}, "", nil, "")
+	s.OnStatus = func(st *Status) { got = st }
+
+	rr := httptest.NewRecorder()
+	s.StatusHandler(rr, httptest.NewRequest(http.MethodPost, "/api/sms/status", nil))
+	if rr.Code != http.StatusNoContent || got == nil || got.ID != "m1" {
+		t.Errorf("unexpected response %d %+v", rr.Code, got)
+	}
+}
@@ -0,0 +1,151 @@
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// twilioOptedOut is the error code of messages to recipients who replied STOP
+const twilioOptedOut = "21610"
+
+// Twilio is the Twilio Programmable Messaging driver
+type Twilio struct {
+	AccountSID string
+	AuthToken  string
+	// StatusURL is the public url of the status webhook; it is sent with every message
+	// and used to check webhook signatures
+	StatusURL string
+	Client    *http.Client
+	// Endpoint is the address of the Twilio REST API, https://api.twilio.com by default
+	Endpoint string
+}
+
+func (t *Twilio) Name() string {
+	return "twilio"
+}
+
+func (t *Twilio) Send(ctx context.Context, from, to, body string) (string, error) {
+	endpoint := t.Endpoint
+	if endpoint == "" {
+		endpoint = "https://api.twilio.com"
+	}
+
+	form := url.Values{}
+	form.Set("From", from)
+	form.Set("To", to)
+	form.Set("Body", body)
+	if t.StatusURL != "" {
+		form.Set("StatusCallback", t.StatusURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		endpoint+"/2010-04-01/Accounts/"+url.PathEscape(t.AccountSID)+"/Messages.json", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(t.AccountSID, t.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := t.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	b, err := ioutil.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+
+	var out struct {
+		SID     string `json:"sid"`
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(b, &out)
+
+	if res.StatusCode >= 300 {
+		return "", apiError("twilio", res.StatusCode, out.Message)
+	}
+
+	return out.SID, nil
+}
+
+func (t *Twilio) ParseStatus(r *http.Request) (*Status, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	if !t.verify(r) {
+		return nil, ErrInvalidSignature
+	}
+
+	st := &Status{
+		ID:    r.PostForm.Get("MessageSid"),
+		To:    r.PostForm.Get("To"),
+		Code:  r.PostForm.Get("ErrorCode"),
+		Error: r.PostForm.Get("ErrorMessage"),
+	}
+
+	switch r.PostForm.Get("MessageStatus") {
+	case "accepted", "scheduled", "queued", "sending":
+		st.State = Queued
+	case "sent":
+		st.State = Sent
+	case "delivered", "read":
+		st.State = Delivered
+	default:
+		st.State = Failed
+	}
+	st.OptedOut = st.Code == twilioOptedOut
+
+	return st, nil
+}
+
+// verify checks X-Twilio-Signature: the HMAC-SHA1 of the webhook url followed by
+// the sorted post parameters and their values
+func (t *Twilio) verify(r *http.Request) bool {
+	sig, err := base64.StdEncoding.DecodeString(r.Header.Get("X-Twilio-Signature"))
+	if err != nil || len(sig) == 0 {
+		return false
+	}
+
+	u := t.StatusURL
+	if u == "" {
+		scheme := "http"
+		if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		u = scheme + "://" + r.Host + r.URL.RequestURI()
+	}
+
+	keys := make([]string, 0, len(r.PostForm))
+	for k := range r.PostForm {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	mac := hmac.New(sha1.New, []byte(t.AuthToken))
+	io.WriteString(mac, u)
+	for _, k := range keys {
+		for _, v := range r.PostForm[k] {
+			io.WriteString(mac, k+v)
+		}
+	}
+
+	return hmac.Equal(sig, mac.Sum(nil))
+}
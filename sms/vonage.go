@@ -0,0 +1,186 @@
+package sms
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Send status of numbers on the account's barred list and receipt error code of
+// recipients who barred the sender
+const (
+	vonageBarred   = "7"
+	vonageOptedOut = "4"
+)
+
+// Vonage is the Vonage (Nexmo) SMS API driver
+type Vonage struct {
+	APIKey    string
+	APISecret string
+	// SignatureSecret checks the md5hash signature of delivery receipts; receipts are
+	// accepted unsigned when it is empty, so keep the webhook url private
+	SignatureSecret string
+	// StatusURL is sent with every message as the delivery receipt callback
+	StatusURL string
+	Client    *http.Client
+	// Endpoint is the address of the Vonage SMS API, https://rest.nexmo.com by default
+	Endpoint string
+}
+
+func (v *Vonage) Name() string {
+	return "vonage"
+}
+
+func (v *Vonage) Send(ctx context.Context, from, to, body string) (string, error) {
+	endpoint := v.Endpoint
+	if endpoint == "" {
+		endpoint = "https://rest.nexmo.com"
+	}
+
+	form := url.Values{}
+	form.Set("api_key", v.APIKey)
+	form.Set("api_secret", v.APISecret)
+	form.Set("from", strings.TrimPrefix(from, "+"))
+	form.Set("to", strings.TrimPrefix(to, "+"))
+	form.Set("text", body)
+	for _, r := range body {
+		if r > 127 {
+			form.Set("type", "unicode")
+			break
+		}
+	}
+	if v.StatusURL != "" {
+		form.Set("callback", v.StatusURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/sms/json", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := v.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	b, err := ioutil.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+
+	var out struct {
+		Messages []struct {
+			Status    string `json:"status"`
+			MessageID string `json:"message-id"`
+			ErrorText string `json:"error-text"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(b, &out); err != nil || res.StatusCode >= 300 {
+		return "", apiError("vonage", res.StatusCode, "")
+	}
+	if len(out.Messages) == 0 {
+		return "", apiError("vonage", res.StatusCode, "no message in response")
+	}
+
+	// long messages are split into parts; the first part's id identifies the message
+	m := out.Messages[0]
+	if m.Status != "0" {
+		if m.Status == vonageBarred {
+			return "", ErrOptedOut
+		}
+		return "", apiError("vonage", res.StatusCode, m.ErrorText)
+	}
+
+	return m.MessageID, nil
+}
+
+func (v *Vonage) ParseStatus(r *http.Request) (*Status, error) {
+	params := map[string]string{}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&params); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			return nil, err
+		}
+		for k := range r.Form {
+			params[k] = r.Form.Get(k)
+		}
+	}
+
+	if v.SignatureSecret != "" && !v.verify(params) {
+		return nil, ErrInvalidSignature
+	}
+
+	st := &Status{
+		ID:   params["messageId"],
+		To:   params["msisdn"],
+		Code: params["err-code"],
+	}
+	if st.To != "" && !strings.HasPrefix(st.To, "+") {
+		st.To = "+" + st.To
+	}
+	if st.Code == "0" {
+		st.Code = ""
+	}
+
+	switch params["status"] {
+	case "accepted", "buffered":
+		st.State = Queued
+	case "delivered":
+		st.State = Delivered
+	default:
+		st.State = Failed
+		st.Error = params["status"]
+	}
+	st.OptedOut = st.Code == vonageOptedOut
+
+	return st, nil
+}
+
+// verify checks the md5hash signature: the md5 of the sorted parameters as
+// "&key=value", with & and = in values replaced by _, followed by the secret
+func (v *Vonage) verify(params map[string]string) bool {
+	sig := params["sig"]
+	if sig == "" {
+		return false
+	}
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k != "sig" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	clean := strings.NewReplacer("&", "_", "=", "_")
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString("&" + k + "=" + clean.Replace(params[k]))
+	}
+	b.WriteString(v.SignatureSecret)
+
+	sum := md5.Sum([]byte(b.String()))
+	expected := hex.EncodeToString(sum[:])
+
+	return subtle.ConstantTimeCompare([]byte(strings.ToLower(sig)), []byte(expected)) == 1
+}
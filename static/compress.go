@@ -0,0 +1,113 @@
+package static
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Compressor writes the pre-compressed variants of the files in a directory at deploy time,
+// so they are not compressed again on every request
+type Compressor struct {
+	Policy Policy
+	// Brotli is the path of the brotli command; without it only .gz files are written
+	Brotli string
+}
+
+// NewCompressor returns a compressor using DefaultPolicy and the brotli command when installed
+func NewCompressor() *Compressor {
+	brotli, _ := exec.LookPath("brotli")
+	return &Compressor{Policy: DefaultPolicy, Brotli: brotli}
+}
+
+// Compress writes the variants of the compressible files under root which are missing or
+// older than their file, returning the number of files written
+func (c *Compressor) Compress(root string) (int, error) {
+	written := 0
+
+	err := filepath.Walk(root, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		ext := strings.ToLower(filepath.Ext(name))
+		if ext == ".gz" || ext == ".br" || !c.Policy.Compressible(name, info.Size()) {
+			return nil
+		}
+
+		if stale(name+".gz", info) {
+			if err := gzipFile(name, name+".gz"); err != nil {
+				return err
+			}
+			written++
+		}
+
+		if c.Brotli != "" && stale(name+".br", info) {
+			if out, err := exec.Command(c.Brotli, "--force", "--best", "--output="+name+".br", name).CombinedOutput(); err != nil {
+				return &BrotliError{Name: name, Output: strings.TrimSpace(string(out)), Err: err}
+			}
+			written++
+		}
+
+		return nil
+	})
+
+	return written, err
+}
+
+// BrotliError is a failed run of the brotli command
+type BrotliError struct {
+	Name   string
+	Output string
+	Err    error
+}
+
+func (e *BrotliError) Error() string {
+	return "brotli " + e.Name + ": " + e.Err.Error() + " " + e.Output
+}
+
+func (e *BrotliError) Unwrap() error {
+	return e.Err
+}
+
+// stale reports whether the variant is missing or older than the file it compresses
+func stale(variant string, file os.FileInfo) bool {
+	info, err := os.Stat(variant)
+	return err != nil || info.ModTime().Before(file.ModTime())
+}
+
+func gzipFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	// written aside and renamed, so a request never gets half a file
+	tmp := dst + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	gz, _ := gzip.NewWriterLevel(out, gzip.BestCompression)
+	_, err = io.Copy(gz, in)
+	if cerr := gz.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, dst)
+}
@@ -0,0 +1,196 @@
+package static
+
+import (
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Policy decides which files are worth compressing
+type Policy struct {
+	// Extensions are compressed, e.g. ".css"; images, fonts and archives already are
+	Extensions []string
+	// MinSize skips small files, whose compressed form is hardly smaller
+	MinSize int64
+}
+
+// DefaultPolicy compresses text files of at least 1KB
+var DefaultPolicy = Policy{
+	Extensions: []string{".css", ".js", ".mjs", ".json", ".map", ".html", ".htm", ".svg", ".txt", ".xml", ".csv", ".ico", ".wasm", ".webmanifest"},
+	MinSize:    1024,
+}
+
+// Compressible reports whether a file of size bytes should be compressed
+func (p Policy) Compressible(name string, size int64) bool {
+	if size < p.MinSize {
+		return false
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range p.Extensions {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// encodings are the pre-compressed variants looked for, in order of preference
+var encodings = []struct {
+	name, ext string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// Files serves a directory such as public/. A file with a .br or .gz variant next to it is
+// served compressed when the client accepts it; other compressible files are gzipped on
+// the fly. Directories are not listed.
+type Files struct {
+	Root   string
+	Policy Policy
+}
+
+// New returns a handler for the files in root using DefaultPolicy
+func New(root string) *Files {
+	return &Files{Root: root, Policy: DefaultPolicy}
+}
+
+func (f *Files) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		rw.Header().Set("Allow", "GET, HEAD")
+		http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := path.Clean("/" + r.URL.Path)
+	// the variants are served in place of their file, never by their own name
+	if ext := path.Ext(name); ext == ".br" || ext == ".gz" {
+		http.NotFound(rw, r)
+		return
+	}
+
+	full := filepath.Join(f.Root, filepath.FromSlash(name))
+	info, err := os.Stat(full)
+	if err != nil || info.IsDir() {
+		http.NotFound(rw, r)
+		return
+	}
+
+	h := rw.Header()
+	if ctype := mime.TypeByExtension(filepath.Ext(name)); ctype != "" {
+		h.Set("Content-Type", ctype)
+	}
+
+	compressible := f.Policy.Compressible(name, info.Size())
+	if compressible {
+		h.Add("Vary", "Accept-Encoding")
+	}
+
+	for _, enc := range encodings {
+		if !accepts(r, enc.name) {
+			continue
+		}
+		variant, err := os.Open(full + enc.ext)
+		if err != nil {
+			continue
+		}
+		defer variant.Close()
+
+		if vinfo, err := variant.Stat(); err == nil && !vinfo.ModTime().Before(info.ModTime()) {
+			if !compressible {
+				h.Add("Vary", "Accept-Encoding")
+			}
+			h.Set("Content-Encoding", enc.name)
+			http.ServeContent(rw, r, name, info.ModTime(), variant)
+			return
+		}
+	}
+
+	file, err := os.Open(full)
+	if err != nil {
+		http.NotFound(rw, r)
+		return
+	}
+	defer file.Close()
+
+	if !compressible || !accepts(r, "gzip") {
+		http.ServeContent(rw, r, name, info.ModTime(), file)
+		return
+	}
+
+	// the length and ranges of the compressed body are not known up front
+	r.Header.Del("Range")
+	gw := &gzipWriter{ResponseWriter: rw}
+	defer gw.Close()
+	http.ServeContent(gw, r, name, info.ModTime(), file)
+}
+
+// accepts reports whether the Accept-Encoding header of r allows encoding
+func accepts(r *http.Request, encoding string) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params := part, ""
+		if i := strings.IndexByte(part, ';'); i >= 0 {
+			name, params = part[:i], part[i+1:]
+		}
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) {
+			continue
+		}
+
+		params = strings.TrimSpace(params)
+		if strings.HasPrefix(params, "q=") {
+			if q, err := strconv.ParseFloat(params[2:], 64); err == nil && q == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipWriter compresses successful responses; errors and not modified responses pass through
+type gzipWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	wroteHeader bool
+	wroteBody   bool
+}
+
+func (w *gzipWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	if status == http.StatusOK {
+		h := w.Header()
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", "gzip")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *gzipWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gz == nil {
+		return w.ResponseWriter.Write(b)
+	}
+	w.wroteBody = true
+	return w.gz.Write(b)
+}
+
+// Close ends the compressed body; answers to HEAD requests have none
+func (w *gzipWriter) Close() error {
+	if w.gz == nil || !w.wroteBody {
+		return nil
+	}
+	return w.gz.Close()
+}
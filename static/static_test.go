@@ -0,0 +1,87 @@
+package static
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	css := strings.Repeat("body { color: red; }\n", 100)
+	if err := os.WriteFile(filepath.Join(dir, "app.css"), []byte(css), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "logo.png"), []byte(strings.Repeat("x", 2048)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := New(dir)
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if accept != "" {
+			r.Header.Set("Accept-Encoding", accept)
+		}
+		rw := httptest.NewRecorder()
+		f.ServeHTTP(rw, r)
+		return rw
+	}
+
+	// compressed on the fly before the variants exist
+	rw := get("/app.css", "gzip, br")
+	if rw.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip on the fly, got %q", rw.Header().Get("Content-Encoding"))
+	}
+	gz, err := gzip.NewReader(rw.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(gz); string(body) != css {
+		t.Error("expected the gzipped body to be the file")
+	}
+
+	if rw := get("/logo.png", "gzip"); rw.Header().Get("Content-Encoding") != "" {
+		t.Error("expected images to be sent as they are")
+	}
+	if rw := get("/app.css", "gzip;q=0"); rw.Header().Get("Content-Encoding") != "" || rw.Body.String() != css {
+		t.Error("expected gzip;q=0 to refuse compression")
+	}
+
+	c := &Compressor{Policy: DefaultPolicy}
+	n, err := c.Compress(dir)
+	if err != nil || n != 1 {
+		t.Fatalf("expected one variant written, got %d %v", n, err)
+	}
+	if n, _ := c.Compress(dir); n != 0 {
+		t.Errorf("expected up to date variants to be kept, got %d written", n)
+	}
+
+	// a brotli variant made by another tool wins when accepted
+	if err := os.WriteFile(filepath.Join(dir, "app.css.br"), []byte("brotli"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rw = get("/app.css", "gzip, br")
+	if rw.Header().Get("Content-Encoding") != "br" || rw.Body.String() != "brotli" {
+		t.Errorf("expected the brotli variant, got %q", rw.Header().Get("Content-Encoding"))
+	}
+	if rw.Header().Get("Content-Type") != "text/css; charset=utf-8" {
+		t.Errorf("expected the type of the file, got %q", rw.Header().Get("Content-Type"))
+	}
+
+	rw = get("/app.css", "gzip")
+	if rw.Header().Get("Content-Encoding") != "gzip" || rw.Header().Get("Vary") != "Accept-Encoding" {
+		t.Error("expected the stored gzip variant")
+	}
+
+	if rw := get("/app.css.gz", "gzip"); rw.Code != http.StatusNotFound {
+		t.Errorf("expected variants to be hidden, got %d", rw.Code)
+	}
+	if rw := get("/../static_test.go", ""); rw.Code != http.StatusNotFound {
+		t.Errorf("expected paths outside the root to be refused, got %d", rw.Code)
+	}
+}
@@ -0,0 +1,341 @@
+package tags
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+	"github.com/namnguyen191/goravel/text"
+)
+
+// The built-in taxonomies; apps may use any other kind, e.g. "genre"
+const (
+	Tags       = "tag"
+	Categories = "category"
+)
+
+// Tag is a term of a taxonomy; Count and Weight are only set by Cloud
+type Tag struct {
+	ID        int
+	Kind      string
+	Name      string
+	Slug      string
+	ParentID  int
+	CreatedAt time.Time
+	Count     int
+	Weight    int
+}
+
+// Taxonomy attaches the terms of one kind to any taggable record by type and id, e.g. ("post", 12)
+type Taxonomy struct {
+	DB           *sql.DB
+	DatabaseType string
+	Kind         string
+	// Clock tells when tags are created and attached; the time of the machine when nil
+	Clock clock.Clock
+}
+
+// New returns the taxonomy of kind, usually Tags or Categories
+func New(db *sql.DB, dbType, kind string) *Taxonomy {
+	return &Taxonomy{DB: db, DatabaseType: dbType, Kind: kind}
+}
+
+func (tax *Taxonomy) rebind(query string) string {
+	return database.Rebind(tax.DatabaseType, query)
+}
+
+// names drops blanks and duplicates by slug, keeping the first spelling
+func names(list []string) []string {
+	seen := map[string]bool{}
+	var out []string
+
+	for _, n := range list {
+		n = strings.TrimSpace(n)
+		slug := text.Slugify(n)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+		out = append(out, n)
+	}
+
+	return out
+}
+
+// Find returns a term by slug
+func (tax *Taxonomy) Find(ctx context.Context, slug string) (*Tag, error) {
+	var t Tag
+	err := tax.DB.QueryRowContext(ctx, tax.rebind("select id, kind, name, slug, parent_id, created_at from tags where kind = ? and slug = ?"), tax.Kind, slug).
+		Scan(&t.ID, &t.Kind, &t.Name, &t.Slug, &t.ParentID, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// FindOrCreate returns the terms with the given names, creating the missing ones.
+// Names are matched by slug, so "Go Lang" and "go-lang" are the same tag.
+func (tax *Taxonomy) FindOrCreate(ctx context.Context, list ...string) ([]Tag, error) {
+	var found []Tag
+
+	for _, name := range names(list) {
+		slug := text.Slugify(name)
+
+		t, err := tax.Find(ctx, slug)
+		if err == nil {
+			found = append(found, *t)
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+
+		created, err := tax.Create(ctx, Tag{Name: name, Slug: slug})
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, *created)
+	}
+
+	return found, nil
+}
+
+// Create adds a term; categories may set ParentID to build a hierarchy
+func (tax *Taxonomy) Create(ctx context.Context, t Tag) (*Tag, error) {
+	t.Kind = tax.Kind
+	t.CreatedAt = clock.Now(tax.Clock)
+	if t.Slug == "" {
+		t.Slug = text.Slugify(t.Name)
+	}
+
+	query := "insert into tags (kind, name, slug, parent_id, created_at) values (?, ?, ?, ?, ?)"
+	args := []interface{}{t.Kind, t.Name, t.Slug, t.ParentID, t.CreatedAt}
+
+	if database.IsPostgres(tax.DatabaseType) {
+		if err := tax.DB.QueryRowContext(ctx, tax.rebind(query+" returning id"), args...).Scan(&t.ID); err != nil {
+			return nil, err
+		}
+		return &t, nil
+	}
+
+	res, err := tax.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	t.ID = int(id)
+
+	return &t, err
+}
+
+// Attach adds terms to a record, creating them when needed
+func (tax *Taxonomy) Attach(ctx context.Context, taggableType string, taggableID int, list ...string) error {
+	terms, err := tax.FindOrCreate(ctx, list...)
+	if err != nil {
+		return err
+	}
+
+	current, err := tax.For(ctx, taggableType, taggableID)
+	if err != nil {
+		return err
+	}
+
+	attached := map[int]bool{}
+	for _, t := range current {
+		attached[t.ID] = true
+	}
+
+	for _, t := range terms {
+		if attached[t.ID] {
+			continue
+		}
+		_, err := tax.DB.ExecContext(ctx, tax.rebind("insert into taggables (tag_id, taggable_type, taggable_id, created_at) values (?, ?, ?, ?)"),
+			t.ID, taggableType, taggableID, clock.Now(tax.Clock))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Detach removes terms from a record; the terms themselves are kept
+func (tax *Taxonomy) Detach(ctx context.Context, taggableType string, taggableID int, list ...string) error {
+	for _, name := range names(list) {
+		t, err := tax.Find(ctx, text.Slugify(name))
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tax.DB.ExecContext(ctx, tax.rebind("delete from taggables where tag_id = ? and taggable_type = ? and taggable_id = ?"), t.ID, taggableType, taggableID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Sync makes the terms of a record exactly list, e.g. from a comma separated form field
+func (tax *Taxonomy) Sync(ctx context.Context, taggableType string, taggableID int, list ...string) error {
+	current, err := tax.For(ctx, taggableType, taggableID)
+	if err != nil {
+		return err
+	}
+
+	keep := map[string]bool{}
+	for _, n := range names(list) {
+		keep[text.Slugify(n)] = true
+	}
+
+	var remove []string
+	for _, t := range current {
+		if !keep[t.Slug] {
+			remove = append(remove, t.Slug)
+		}
+	}
+
+	if err := tax.Detach(ctx, taggableType, taggableID, remove...); err != nil {
+		return err
+	}
+
+	return tax.Attach(ctx, taggableType, taggableID, list...)
+}
+
+// For returns the terms of a record, by name
+func (tax *Taxonomy) For(ctx context.Context, taggableType string, taggableID int) ([]Tag, error) {
+	rows, err := tax.DB.QueryContext(ctx, tax.rebind(`select t.id, t.kind, t.name, t.slug, t.parent_id, t.created_at
+		from tags t join taggables tg on tg.tag_id = t.id
+		where t.kind = ? and tg.taggable_type = ? and tg.taggable_id = ? order by t.name`), tax.Kind, taggableType, taggableID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Tag
+	for rows.Next() {
+		var t Tag
+		if err := rows.Scan(&t.ID, &t.Kind, &t.Name, &t.Slug, &t.ParentID, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+
+	return list, rows.Err()
+}
+
+// Tagged returns the ids of the records of taggableType carrying the term with slug
+func (tax *Taxonomy) Tagged(ctx context.Context, taggableType, slug string) ([]int, error) {
+	rows, err := tax.DB.QueryContext(ctx, tax.rebind(`select tg.taggable_id from taggables tg join tags t on t.id = tg.tag_id
+		where t.kind = ? and t.slug = ? and tg.taggable_type = ? order by tg.taggable_id`), tax.Kind, slug, taggableType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// Cloud returns the limit most used terms on records of taggableType, by name, weighted 1 to 5
+func (tax *Taxonomy) Cloud(ctx context.Context, taggableType string, limit int) ([]Tag, error) {
+	rows, err := tax.DB.QueryContext(ctx, tax.rebind(fmt.Sprintf(`select t.id, t.name, t.slug, count(*) as n
+		from tags t join taggables tg on tg.tag_id = t.id
+		where t.kind = ? and tg.taggable_type = ? group by t.id, t.name, t.slug order by n desc limit %d`, limit)), tax.Kind, taggableType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Tag
+	for rows.Next() {
+		t := Tag{Kind: tax.Kind}
+		if err := rows.Scan(&t.ID, &t.Name, &t.Slug, &t.Count); err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return weigh(list), nil
+}
+
+// weigh spreads counts linearly over weights 1 to 5 and sorts the cloud by name
+func weigh(list []Tag) []Tag {
+	if len(list) == 0 {
+		return list
+	}
+
+	min, max := list[0].Count, list[0].Count
+	for _, t := range list {
+		if t.Count < min {
+			min = t.Count
+		}
+		if t.Count > max {
+			max = t.Count
+		}
+	}
+
+	for i := range list {
+		list[i].Weight = 3
+		if max > min {
+			list[i].Weight = 1 + (list[i].Count-min)*4/(max-min)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool { return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name) })
+
+	return list
+}
+
+// WhereHasTag returns a condition for queries on a taggable table selecting the records carrying
+// any of the slugs, e.g. "select * from posts where " + cond with args. column is the id column
+// of the taggable table. Placeholders are ?, rebind the full query for postgres.
+func (tax *Taxonomy) WhereHasTag(taggableType, column string, slugs ...string) (string, []interface{}) {
+	return tax.where(taggableType, column, false, slugs)
+}
+
+// WhereHasAllTags is like WhereHasTag but selects the records carrying every one of the slugs
+func (tax *Taxonomy) WhereHasAllTags(taggableType, column string, slugs ...string) (string, []interface{}) {
+	return tax.where(taggableType, column, true, slugs)
+}
+
+func (tax *Taxonomy) where(taggableType, column string, all bool, slugs []string) (string, []interface{}) {
+	if len(slugs) == 0 {
+		return "1 = 0", nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(slugs)), ", ")
+	args := []interface{}{taggableType, tax.Kind}
+	for _, s := range slugs {
+		args = append(args, s)
+	}
+
+	cond := fmt.Sprintf(`%s in (select tg.taggable_id from taggables tg join tags t on t.id = tg.tag_id
+		where tg.taggable_type = ? and t.kind = ? and t.slug in (%s)`, column, placeholders)
+	if all {
+		cond += fmt.Sprintf(" group by tg.taggable_id having count(distinct t.id) = %d", len(slugs))
+	}
+
+	return cond + ")", args
+}
@@ -0,0 +1,37 @@
+package tags
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNames(t *testing.T) {
+	got := names([]string{" Go ", "go", "", "Web Dev", "web-dev", "SQL"})
+	if strings.Join(got, "|") != "Go|Web Dev|SQL" {
+		t.Errorf("unexpected names %q", got)
+	}
+}
+
+func TestWeigh(t *testing.T) {
+	cloud := weigh([]Tag{{Name: "sql", Count: 1}, {Name: "Go", Count: 9}, {Name: "web", Count: 5}})
+
+	if cloud[0].Name != "Go" || cloud[0].Weight != 5 || cloud[1].Weight != 1 || cloud[2].Weight != 3 {
+		t.Errorf("unexpected cloud %+v", cloud)
+	}
+}
+
+func TestWhereHasTag(t *testing.T) {
+	tax := New(nil, "postgres", Tags)
+
+	cond, args := tax.WhereHasAllTags("post", "posts.id", "go", "sql")
+	if !strings.HasPrefix(cond, "posts.id in (") || !strings.Contains(cond, "having count(distinct t.id) = 2") {
+		t.Errorf("unexpected condition %s", cond)
+	}
+	if len(args) != 4 || args[0] != "post" || args[1] != Tags || args[3] != "sql" {
+		t.Errorf("unexpected args %v", args)
+	}
+
+	if cond, _ := tax.WhereHasTag("post", "id"); cond != "1 = 0" {
+		t.Error("expected no slugs to match nothing, got", cond)
+	}
+}
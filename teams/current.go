@@ -0,0 +1,146 @@
+package teams
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/namnguyen191/goravel/render"
+)
+
+// currentKey is the session key of the id of the current team
+const currentKey = "team.current"
+
+type contextKey struct{}
+
+// current is the team of a request with the role of its user, kept in the context by Require
+type current struct {
+	team *Team
+	role string
+}
+
+// Current returns the team the user of r works in, picked with Switch, or their first team
+// when they did not pick one or are no longer a member of it; nil for a user without teams
+// or a visitor who is not logged in
+func (ts *Teams) Current(r *http.Request) (*Team, string, error) {
+	if c, ok := r.Context().Value(contextKey{}).(*current); ok {
+		return c.team, c.role, nil
+	}
+
+	userID, ok := ts.Auth.UserID(r)
+	if !ok {
+		return nil, "", nil
+	}
+	ctx := r.Context()
+
+	if id := ts.Session.GetInt(ctx, currentKey); id != 0 {
+		role, err := ts.Role(ctx, id, userID)
+		if err == nil {
+			t, err := ts.Find(ctx, id)
+			if err == nil {
+				return t, role, nil
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				return nil, "", err
+			}
+		} else if !errors.Is(err, ErrNotMember) {
+			return nil, "", err
+		}
+		ts.Session.Remove(ctx, currentKey)
+	}
+
+	list, err := ts.ForUser(ctx, userID)
+	if err != nil || len(list) == 0 {
+		return nil, "", err
+	}
+	role, err := ts.Role(ctx, list[0].ID, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	return list[0], role, nil
+}
+
+// Switch makes the team with teamID the current team of the user of r, who must be a member
+func (ts *Teams) Switch(r *http.Request, teamID int) error {
+	userID, ok := ts.Auth.UserID(r)
+	if !ok {
+		return ErrNotMember
+	}
+	if _, err := ts.Role(r.Context(), teamID, userID); err != nil {
+		return err
+	}
+
+	ts.Session.Put(r.Context(), currentKey, teamID)
+	return nil
+}
+
+// SwitchHandler switches to the team of the id route parameter, then goes back to the page
+// it was posted from; apps mount it with Routes.Post("/teams/{id}/switch", grv.Teams.SwitchHandler)
+func (ts *Teams) SwitchHandler(rw http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	err = ts.Switch(r, id)
+	if errors.Is(err, ErrNotMember) {
+		http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	back := r.Referer()
+	if back == "" {
+		back = "/"
+	}
+	http.Redirect(rw, r, back, http.StatusSeeOther)
+}
+
+// Require lets through the members of the current team allowed what the members with role
+// are, e.g. Require(teams.Admin) on the settings of a team; others get a 403, and visitors
+// who are not logged in a 401. It goes after grv.Auth.Require, and the handlers get the team
+// with Current without loading it again.
+func (ts *Teams) Require(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if !ts.Auth.Check(r) {
+				http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			t, have, err := ts.Current(r)
+			if err != nil {
+				http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			if t == nil || !ts.Allows(have, role) {
+				http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(rw, r.WithContext(withCurrent(r.Context(), t, have)))
+		})
+	}
+}
+
+func withCurrent(ctx context.Context, t *Team, role string) context.Context {
+	return context.WithValue(ctx, contextKey{}, &current{team: t, role: role})
+}
+
+// TemplateData adds the current team to the data of the pages, as .Data.team with the role
+// of the user as .Data.teamRole; it is given to grv.Render.AddData
+func (ts *Teams) TemplateData(r *http.Request, td *render.TemplateData) {
+	t, role, err := ts.Current(r)
+	if err != nil || t == nil {
+		return
+	}
+	td.Data["team"] = t
+	td.Data["teamRole"] = role
+}
@@ -0,0 +1,391 @@
+// Package teams groups users into teams, e.g. the organizations of a B2B app: members have a
+// role in each of their teams, pick the team they work in, which is kept in the session, and
+// routes are allowed to the members of the current team with a role.
+package teams
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/namnguyen191/goravel/auth"
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+	"github.com/namnguyen191/goravel/invitations"
+	"github.com/namnguyen191/goravel/text"
+)
+
+// Roles of the members, from the least allowed; apps may use their own with Teams.Roles
+const (
+	Member = "member"
+	Admin  = "admin"
+	Owner  = "owner"
+)
+
+// TeamKey is the metadata of an invitation holding the id of the team it is to
+const TeamKey = "team_id"
+
+var (
+	// ErrNotMember is returned for a user who is not a member of the team
+	ErrNotMember = errors.New("teams: not a member of the team")
+	// ErrLastOwner is returned when removing or demoting the last owner of a team
+	ErrLastOwner = errors.New("teams: a team needs an owner")
+	// ErrRole is returned for a role which is not one of Teams.Roles
+	ErrRole = errors.New("teams: unknown role")
+	// ErrName is returned by Create and Rename for an empty name
+	ErrName = errors.New("teams: a team needs a name")
+)
+
+// Team is a row of the teams table
+type Team struct {
+	ID        int
+	Name      string
+	Slug      string
+	OwnerID   int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Membership is a row of the team_members table
+type Membership struct {
+	TeamID    int
+	UserID    int
+	Role      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Teams keeps the teams in the teams table and their members in team_members
+type Teams struct {
+	DB           *sql.DB
+	DatabaseType string
+	Session      *scs.SessionManager
+	Auth         *auth.Auth
+	// Invitations sends the invitations of Invite
+	Invitations *invitations.Invitations
+	// Roles are the roles of the members from the least allowed, Member, Admin and Owner by
+	// default; a member is allowed what the roles before theirs are
+	Roles []string
+	// Clock tells the time of the changes; the time of the machine when nil
+	Clock clock.Clock
+}
+
+// New returns the teams of db, whose current team is kept in session
+func New(db *sql.DB, dbType string, session *scs.SessionManager, a *auth.Auth) *Teams {
+	return &Teams{
+		DB:           db,
+		DatabaseType: dbType,
+		Session:      session,
+		Auth:         a,
+		Roles:        []string{Member, Admin, Owner},
+	}
+}
+
+func (ts *Teams) rebind(query string) string {
+	return database.Rebind(ts.DatabaseType, query)
+}
+
+// rank is the place of role in Roles, or -1
+func (ts *Teams) rank(role string) int {
+	for i, r := range ts.Roles {
+		if r == role {
+			return i
+		}
+	}
+	return -1
+}
+
+// Allows reports whether a member with role is allowed what the members with want are
+func (ts *Teams) Allows(role, want string) bool {
+	have, need := ts.rank(role), ts.rank(want)
+	return have >= 0 && need >= 0 && have >= need
+}
+
+// slugName is what the slug of a team named name is made of, "team" for a name without a
+// letter or digit to keep
+func slugName(name string) string {
+	if text.Slugify(name) == "" {
+		return "team"
+	}
+	return name
+}
+
+const columns = "id, name, slug, owner_id, created_at, updated_at"
+
+func scan(row interface{ Scan(...interface{}) error }) (*Team, error) {
+	var t Team
+	if err := row.Scan(&t.ID, &t.Name, &t.Slug, &t.OwnerID, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (ts *Teams) query(ctx context.Context, query string, args ...interface{}) ([]*Team, error) {
+	rows, err := ts.DB.QueryContext(ctx, ts.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*Team
+	for rows.Next() {
+		t, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+// Find returns the team with id, or sql.ErrNoRows
+func (ts *Teams) Find(ctx context.Context, id int) (*Team, error) {
+	return scan(ts.DB.QueryRowContext(ctx, ts.rebind("select "+columns+" from teams where id = ?"), id))
+}
+
+// FindBySlug returns the team with slug, or sql.ErrNoRows
+func (ts *Teams) FindBySlug(ctx context.Context, slug string) (*Team, error) {
+	return scan(ts.DB.QueryRowContext(ctx, ts.rebind("select "+columns+" from teams where slug = ?"), slug))
+}
+
+// ForUser returns the teams the user with id is a member of, by name
+func (ts *Teams) ForUser(ctx context.Context, userID int) ([]*Team, error) {
+	return ts.query(ctx, `select t.id, t.name, t.slug, t.owner_id, t.created_at, t.updated_at from teams t
+		join team_members m on m.team_id = t.id where m.user_id = ? order by t.name`, userID)
+}
+
+// Create stores a team named name, owned by the user with ownerID, who is its first member.
+// Its slug is the one of its name, with a number added when another team has it.
+func (ts *Teams) Create(ctx context.Context, name string, ownerID int) (*Team, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrName
+	}
+
+	now := clock.Now(ts.Clock)
+	t := &Team{Name: name, OwnerID: ownerID, CreatedAt: now, UpdatedAt: now}
+	slug, err := text.UniqueSlug(ctx, ts.DB, ts.DatabaseType, "teams", "slug", slugName(name))
+	if err != nil {
+		return nil, err
+	}
+	t.Slug = slug
+
+	tx, err := ts.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := "insert into teams (name, slug, owner_id, created_at, updated_at) values (?, ?, ?, ?, ?)"
+	args := []interface{}{t.Name, t.Slug, t.OwnerID, t.CreatedAt, t.UpdatedAt}
+
+	if database.IsPostgres(ts.DatabaseType) {
+		err = tx.QueryRowContext(ctx, ts.rebind(query+" returning id"), args...).Scan(&t.ID)
+	} else {
+		var res sql.Result
+		res, err = tx.ExecContext(ctx, query, args...)
+		if err == nil {
+			var id int64
+			id, err = res.LastInsertId()
+			t.ID = int(id)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query = "insert into team_members (team_id, user_id, role, created_at, updated_at) values (?, ?, ?, ?, ?)"
+	if _, err := tx.ExecContext(ctx, ts.rebind(query), t.ID, ownerID, ts.owner(), now, now); err != nil {
+		return nil, err
+	}
+
+	return t, tx.Commit()
+}
+
+// owner is the most allowed role
+func (ts *Teams) owner() string {
+	return ts.Roles[len(ts.Roles)-1]
+}
+
+// Rename changes the name of the team with id, keeping its slug so links to it still work
+func (ts *Teams) Rename(ctx context.Context, id int, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ErrName
+	}
+	_, err := ts.DB.ExecContext(ctx, ts.rebind("update teams set name = ?, updated_at = ? where id = ?"), name, clock.Now(ts.Clock), id)
+	return err
+}
+
+// Delete removes the team with id and its memberships
+func (ts *Teams) Delete(ctx context.Context, id int) error {
+	tx, err := ts.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, ts.rebind("delete from team_members where team_id = ?"), id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, ts.rebind("delete from teams where id = ?"), id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Role returns the role of the user with userID in the team with teamID, or ErrNotMember
+func (ts *Teams) Role(ctx context.Context, teamID, userID int) (string, error) {
+	var role string
+	err := ts.DB.QueryRowContext(ctx, ts.rebind("select role from team_members where team_id = ? and user_id = ?"), teamID, userID).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotMember
+	}
+	return role, err
+}
+
+// Can reports whether the user with userID is a member of the team with teamID allowed what
+// the members with role are
+func (ts *Teams) Can(ctx context.Context, teamID, userID int, role string) (bool, error) {
+	have, err := ts.Role(ctx, teamID, userID)
+	if errors.Is(err, ErrNotMember) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return ts.Allows(have, role), nil
+}
+
+// Members returns the memberships of the team with teamID, the oldest first
+func (ts *Teams) Members(ctx context.Context, teamID int) ([]Membership, error) {
+	rows, err := ts.DB.QueryContext(ctx, ts.rebind("select team_id, user_id, role, created_at, updated_at from team_members where team_id = ? order by created_at"), teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Membership
+	for rows.Next() {
+		var m Membership
+		if err := rows.Scan(&m.TeamID, &m.UserID, &m.Role, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, m)
+	}
+	return list, rows.Err()
+}
+
+// AddMember makes the user with userID a member of the team with teamID with role, or
+// changes their role when they already are one
+func (ts *Teams) AddMember(ctx context.Context, teamID, userID int, role string) error {
+	if ts.rank(role) < 0 {
+		return ErrRole
+	}
+
+	current, err := ts.Role(ctx, teamID, userID)
+	if err == nil {
+		if current == role {
+			return nil
+		}
+		return ts.SetRole(ctx, teamID, userID, role)
+	}
+	if !errors.Is(err, ErrNotMember) {
+		return err
+	}
+
+	now := clock.Now(ts.Clock)
+	query := ts.rebind("insert into team_members (team_id, user_id, role, created_at, updated_at) values (?, ?, ?, ?, ?)")
+	_, err = ts.DB.ExecContext(ctx, query, teamID, userID, role, now, now)
+	return err
+}
+
+// SetRole changes the role of a member; the last owner of a team cannot be demoted
+func (ts *Teams) SetRole(ctx context.Context, teamID, userID int, role string) error {
+	if ts.rank(role) < 0 {
+		return ErrRole
+	}
+	if role != ts.owner() {
+		if err := ts.keepOwner(ctx, teamID, userID); err != nil {
+			return err
+		}
+	}
+
+	query := ts.rebind("update team_members set role = ?, updated_at = ? where team_id = ? and user_id = ?")
+	res, err := ts.DB.ExecContext(ctx, query, role, clock.Now(ts.Clock), teamID, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotMember
+	}
+	return nil
+}
+
+// RemoveMember removes the user with userID from the team; the last owner cannot leave
+func (ts *Teams) RemoveMember(ctx context.Context, teamID, userID int) error {
+	if err := ts.keepOwner(ctx, teamID, userID); err != nil {
+		return err
+	}
+
+	query := ts.rebind("delete from team_members where team_id = ? and user_id = ?")
+	_, err := ts.DB.ExecContext(ctx, query, teamID, userID)
+	return err
+}
+
+// keepOwner fails when the user with userID is the only owner of the team
+func (ts *Teams) keepOwner(ctx context.Context, teamID, userID int) error {
+	role, err := ts.Role(ctx, teamID, userID)
+	if errors.Is(err, ErrNotMember) || (err == nil && role != ts.owner()) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var owners int
+	query := ts.rebind("select count(*) from team_members where team_id = ? and role = ?")
+	if err := ts.DB.QueryRowContext(ctx, query, teamID, ts.owner()).Scan(&owners); err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// Invite sends an invitation to the team with teamID, which makes the invitee a member with
+// role once accepted through Accepted
+func (ts *Teams) Invite(ctx context.Context, teamID int, email, role string, invitedBy int) (*invitations.Invitation, error) {
+	if ts.rank(role) < 0 {
+		return nil, ErrRole
+	}
+	if ts.Invitations == nil {
+		return nil, errors.New("teams: inviting needs invitations")
+	}
+	return ts.Invitations.Create(ctx, invitations.Invite{
+		Email:     email,
+		Role:      role,
+		Metadata:  map[string]string{TeamKey: strconv.Itoa(teamID)},
+		InvitedBy: invitedBy,
+	})
+}
+
+// Accepted adds the user who accepted an invitation of Invite to its team; it is the
+// OnAccept of the invitations, and leaves the other invitations alone
+func (ts *Teams) Accepted(ctx context.Context, inv *invitations.Invitation, u *auth.User) error {
+	id, err := strconv.Atoi(inv.Metadata[TeamKey])
+	if err != nil || id == 0 {
+		return nil
+	}
+
+	role := inv.Role
+	if ts.rank(role) < 0 {
+		role = ts.Roles[0]
+	}
+	return ts.AddMember(ctx, id, u.ID, role)
+}
@@ -0,0 +1,88 @@
+package teams
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/namnguyen191/goravel/auth"
+	"github.com/namnguyen191/goravel/invitations"
+	"github.com/namnguyen191/goravel/text"
+)
+
+func newTeams() *Teams {
+	session := scs.New()
+	return New(nil, "postgres", session, auth.New(nil, "postgres", session, "myapp"))
+}
+
+func TestSlugName(t *testing.T) {
+	for name, want := range map[string]string{
+		"Acme Corp.":        "acme-corp",
+		"  Rock & Roll  ":   "rock-and-roll",
+		"Ünïcode Ltd":       "unicode-ltd",
+		"2021 -- Year Team": "2021-year-team",
+		"!!!":               "team",
+	} {
+		if got := text.Slugify(slugName(name)); got != want {
+			t.Errorf("%q: expected %q, got %q", name, want, got)
+		}
+	}
+}
+
+func TestAllows(t *testing.T) {
+	ts := newTeams()
+
+	if !ts.Allows(Owner, Admin) || !ts.Allows(Admin, Admin) || !ts.Allows(Admin, Member) {
+		t.Error("expected a role to allow the ones before it")
+	}
+	if ts.Allows(Member, Admin) || ts.Allows("guest", Member) || ts.Allows(Owner, "root") {
+		t.Error("expected lower and unknown roles to be turned down")
+	}
+
+	ts.Roles = []string{"viewer", "editor", Owner}
+	if !ts.Allows("editor", "viewer") || ts.owner() != Owner {
+		t.Error("expected the roles of the app to be used")
+	}
+}
+
+func TestRequire(t *testing.T) {
+	ts := newTeams()
+	h := ts.Auth.Session.LoadAndSave(ts.Require(Admin)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		t.Error("expected a visitor to be turned down")
+	})))
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "/teams/settings", nil))
+	if rw.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", rw.Code)
+	}
+}
+
+func TestCurrentFromContext(t *testing.T) {
+	ts := newTeams()
+	team := &Team{ID: 3, Name: "Acme"}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r = r.WithContext(withCurrent(r.Context(), team, Admin))
+
+	got, role, err := ts.Current(r)
+	if err != nil || got != team || role != Admin {
+		t.Errorf("expected the team of Require, got %v %s %v", got, role, err)
+	}
+}
+
+func TestAccepted(t *testing.T) {
+	ts := newTeams()
+
+	// an invitation which is not to a team is left alone, without touching the database
+	inv := &invitations.Invitation{Email: "ann@example.com", Role: Admin}
+	if err := ts.Accepted(context.Background(), inv, &auth.User{ID: 9}); err != nil {
+		t.Errorf("expected nothing to do, got %v", err)
+	}
+
+	if _, err := ts.Invite(context.Background(), 3, "ann@example.com", "root", 1); err != ErrRole {
+		t.Errorf("expected an unknown role to fail, got %v", err)
+	}
+}
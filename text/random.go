@@ -0,0 +1,58 @@
+package text
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+)
+
+// Alphabets for Random
+const (
+	Alphanumeric = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	Lowercase    = "abcdefghijklmnopqrstuvwxyz0123456789"
+	Digits       = "0123456789"
+	// Unambiguous leaves out characters that are easy to misread, such as 0/O and 1/l/I
+	Unambiguous = "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// Random returns a string of n characters picked uniformly from alphabet with crypto/rand;
+// Alphanumeric is used when alphabet is empty
+func Random(n int, alphabet ...string) string {
+	chars := []rune(Alphanumeric)
+	if len(alphabet) > 0 && alphabet[0] != "" {
+		chars = []rune(alphabet[0])
+	}
+
+	max := big.NewInt(int64(len(chars)))
+	s := make([]rune, n)
+
+	for i := range s {
+		x, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		s[i] = chars[x.Int64()]
+	}
+
+	return string(s)
+}
+
+// Token returns n random bytes encoded as url safe base64, suitable for links and api tokens
+func Token(n int) string {
+	return base64.RawURLEncoding.EncodeToString(randomBytes(n))
+}
+
+// HexToken returns n random bytes encoded as hex
+func HexToken(n int) string {
+	return hex.EncodeToString(randomBytes(n))
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return b
+}
@@ -0,0 +1,86 @@
+package text
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/namnguyen191/goravel/database"
+	"golang.org/x/text/unicode/norm"
+)
+
+// transliterations covers letters that don't decompose into a base letter plus marks
+var transliterations = map[rune]string{
+	'đ': "d", 'Đ': "d", 'ß': "ss", 'æ': "ae", 'Æ': "ae", 'ø': "o", 'Ø': "o",
+	'œ': "oe", 'Œ': "oe", 'ł': "l", 'Ł': "l", 'þ': "th", 'Þ': "th", 'ð': "d",
+	'ı': "i", '&': " and ", '@': " at ",
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify turns s into a lowercase, dash separated ascii slug: "Phở Hà Nội!" becomes "pho-ha-noi"
+func Slugify(s string) string {
+	var b strings.Builder
+
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+
+		if t, ok := transliterations[r]; ok {
+			b.WriteString(t)
+			continue
+		}
+
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return strings.Trim(nonSlug.ReplaceAllString(b.String(), "-"), "-")
+}
+
+// UniqueSlug slugifies s and appends -2, -3... until no row of table has it in column.
+// Table and column are identifiers from code, never user input.
+func UniqueSlug(ctx context.Context, db *sql.DB, dbType, table, column, s string) (string, error) {
+	base := Slugify(s)
+	if base == "" {
+		base = "n-a"
+	}
+
+	query := database.Rebind(dbType, fmt.Sprintf("select %s from %s where %s = ? or %s like ?", column, table, column, column))
+	rows, err := db.QueryContext(ctx, query, base, base+"-%")
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	taken := map[string]bool{}
+	for rows.Next() {
+		var existing string
+		if err := rows.Scan(&existing); err != nil {
+			return "", err
+		}
+		taken[existing] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	return nextSlug(base, taken), nil
+}
+
+func nextSlug(base string, taken map[string]bool) string {
+	if !taken[base] {
+		return base
+	}
+
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", base, i)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package text
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Hello World", "hello-world"},
+		{"  Phở Hà Nội!  ", "pho-ha-noi"},
+		{"Đường Lê Lợi", "duong-le-loi"},
+		{"Straße & Café", "strasse-and-cafe"},
+		{"Ærøskøbing", "aeroskobing"},
+		{"already-a-slug", "already-a-slug"},
+		{"---", ""},
+	}
+
+	for _, e := range tests {
+		if got := Slugify(e.in); got != e.want {
+			t.Errorf("%q: expected %q, got %q", e.in, e.want, got)
+		}
+	}
+}
+
+func TestNextSlug(t *testing.T) {
+	taken := map[string]bool{"post": true, "post-2": true, "post-4": true}
+
+	if got := nextSlug("post", taken); got != "post-3" {
+		t.Error("expected post-3, got", got)
+	}
+	if got := nextSlug("page", taken); got != "page" {
+		t.Error("expected free slug unchanged, got", got)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	s := Random(32, Digits)
+	if len(s) != 32 || strings.Trim(s, Digits) != "" {
+		t.Error("expected 32 digits, got", s)
+	}
+
+	if Random(16) == Random(16) {
+		t.Error("expected different random strings")
+	}
+
+	if len(Token(32)) != 43 || len(HexToken(16)) != 32 {
+		t.Error("unexpected token lengths")
+	}
+}
@@ -0,0 +1,196 @@
+// Package tokens issues the tokens of links sent to users: signed tokens carrying a purpose,
+// a subject and an expiry, which can be made single use, and tokens derived from their subject,
+// which stay the same every time they are computed.
+package tokens
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/namnguyen191/goravel/cache"
+	"github.com/namnguyen191/goravel/clock"
+)
+
+// Purposes of the tokens issued by the framework; apps use their own strings for the others
+const (
+	VerifyEmail   = "verify-email"
+	Invite        = "invite"
+	Unsubscribe   = "unsubscribe"
+	ResetPassword = "reset-password"
+)
+
+var (
+	// ErrInvalid is returned for a token which is malformed, forged, or was issued for another purpose
+	ErrInvalid = errors.New("tokens: invalid token")
+	// ErrExpired is returned for a token past its expiry
+	ErrExpired = errors.New("tokens: token expired")
+	// ErrUsed is returned by Consume for a token which was already consumed
+	ErrUsed = errors.New("tokens: token already used")
+)
+
+// Tokens signs and checks tokens with Secret
+type Tokens struct {
+	Secret []byte
+	// Cache remembers the consumed tokens until they expire; Consume fails without it
+	Cache cache.Cache
+	// Clock tells when the tokens expire; the time of the machine when nil
+	Clock clock.Clock
+	// Rand is the source of the nonces and random tokens, crypto/rand when nil; tests set a
+	// fixed one to get the same tokens every run
+	Rand io.Reader
+}
+
+// New returns the tokens signed with secret, remembering the consumed ones in c
+func New(secret []byte, c cache.Cache) *Tokens {
+	return &Tokens{Secret: secret, Cache: c}
+}
+
+// Claims are what a signed token carries
+type Claims struct {
+	Purpose   string
+	Subject   string
+	ExpiresAt time.Time
+	// Nonce makes every token unique, and is the key of a consumed token in the cache
+	Nonce string
+}
+
+// payload is Claims as signed, with short names to keep links short
+type payload struct {
+	Purpose string `json:"p"`
+	Subject string `json:"s"`
+	Expires int64  `json:"e"`
+	Nonce   string `json:"n"`
+}
+
+func (t *Tokens) random(n int) ([]byte, error) {
+	r := t.Rand
+	if r == nil {
+		r = rand.Reader
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Random returns n random bytes as url safe base64, e.g. for a token kept in the database
+func (t *Tokens) Random(n int) (string, error) {
+	b, err := t.random(n)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (t *Tokens) mac(kind string, parts ...string) []byte {
+	h := hmac.New(sha256.New, t.Secret)
+	h.Write([]byte(kind))
+	for _, p := range parts {
+		h.Write([]byte{0})
+		h.Write([]byte(p))
+	}
+	return h.Sum(nil)
+}
+
+// Sign returns a token for subject, e.g. a user id or an email address, valid for purpose
+// until ttl has passed
+func (t *Tokens) Sign(purpose, subject string, ttl time.Duration) (string, error) {
+	nonce, err := t.random(16)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := json.Marshal(payload{
+		Purpose: purpose,
+		Subject: subject,
+		Expires: clock.Now(t.Clock).Add(ttl).Unix(),
+		Nonce:   base64.RawURLEncoding.EncodeToString(nonce),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	p := base64.RawURLEncoding.EncodeToString(b)
+	return p + "." + base64.RawURLEncoding.EncodeToString(t.mac("sign", p)), nil
+}
+
+// Verify returns the claims of a token signed for purpose which has not expired. A token may
+// be verified any number of times; links which must only work once use Consume.
+func (t *Tokens) Verify(token, purpose string) (*Claims, error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return nil, ErrInvalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(mac, t.mac("sign", parts[0])) {
+		return nil, ErrInvalid
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	var p payload
+	if err := json.Unmarshal(b, &p); err != nil || p.Purpose != purpose {
+		return nil, ErrInvalid
+	}
+
+	c := &Claims{Purpose: p.Purpose, Subject: p.Subject, ExpiresAt: time.Unix(p.Expires, 0), Nonce: p.Nonce}
+	if !clock.Now(t.Clock).Before(c.ExpiresAt) {
+		return nil, ErrExpired
+	}
+	return c, nil
+}
+
+// Consume is Verify for a token which only works once: the first call returns its claims, the
+// next ones ErrUsed until it expires
+func (t *Tokens) Consume(token, purpose string) (*Claims, error) {
+	c, err := t.Verify(token, purpose)
+	if err != nil {
+		return nil, err
+	}
+	if t.Cache == nil {
+		return nil, errors.New("tokens: consuming a token needs a cache")
+	}
+
+	key := "tokens:used:" + c.Nonce
+	if used, err := t.Cache.Has(key); err != nil {
+		return nil, err
+	} else if used {
+		return nil, ErrUsed
+	}
+
+	// the counter settles a race between two requests consuming the token at once
+	n, err := t.Cache.Increment(key, 1)
+	if err != nil {
+		return nil, err
+	}
+	if n > 1 {
+		return nil, ErrUsed
+	}
+	// the nonce is only remembered until the token expires, when Verify turns it down anyway
+	if err := t.Cache.SetWithTTL(key, n, c.ExpiresAt.Sub(clock.Now(t.Clock))+time.Second); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Derive returns the token of subject for purpose, which is the same every time, e.g. for the
+// unsubscribe links of a newsletter; it never expires, so it is only fit for harmless actions
+func (t *Tokens) Derive(purpose, subject string) string {
+	return base64.RawURLEncoding.EncodeToString(t.mac("derive", purpose, subject))
+}
+
+// Equal reports whether token is the one Derive returns for purpose and subject, in constant time
+func (t *Tokens) Equal(token, purpose, subject string) bool {
+	return hmac.Equal([]byte(token), []byte(t.Derive(purpose, subject)))
+}
@@ -0,0 +1,108 @@
+package tokens
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/namnguyen191/goravel/cache"
+	"github.com/namnguyen191/goravel/clock"
+)
+
+func newTokens() (*Tokens, *clock.Test) {
+	now := clock.NewTest(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	t := New([]byte("secret"), cache.NewMemoryCache("test"))
+	t.Clock = now
+	return t, now
+}
+
+func TestSignVerify(t *testing.T) {
+	tk, now := newTokens()
+
+	token, err := tk.Sign(VerifyEmail, "ada@example.com", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := tk.Verify(token, VerifyEmail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Subject != "ada@example.com" || !c.ExpiresAt.Equal(now.Now().Add(time.Hour)) {
+		t.Errorf("unexpected claims %+v", c)
+	}
+
+	if _, err := tk.Verify(token, Invite); err != ErrInvalid {
+		t.Errorf("expected a token of another purpose to be invalid, got %v", err)
+	}
+	if _, err := tk.Verify(strings.Replace(token, ".", "x.", 1), VerifyEmail); err != ErrInvalid {
+		t.Errorf("expected a tampered token to be invalid, got %v", err)
+	}
+	other := New([]byte("other"), nil)
+	if _, err := other.Verify(token, VerifyEmail); err != ErrInvalid {
+		t.Errorf("expected a token of another secret to be invalid, got %v", err)
+	}
+
+	now.Advance(time.Hour)
+	if _, err := tk.Verify(token, VerifyEmail); err != ErrExpired {
+		t.Errorf("expected ErrExpired, got %v", err)
+	}
+}
+
+func TestConsume(t *testing.T) {
+	tk, _ := newTokens()
+	token, _ := tk.Sign(Invite, "42", time.Hour)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	consumed := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := tk.Consume(token, Invite); err == nil {
+				mu.Lock()
+				consumed++
+				mu.Unlock()
+			} else if err != ErrUsed {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if consumed != 1 {
+		t.Errorf("expected the token to be consumed once, got %d", consumed)
+	}
+	if _, err := tk.Consume(token, Invite); err != ErrUsed {
+		t.Errorf("expected ErrUsed on replay, got %v", err)
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	a, _ := newTokens()
+	b, _ := newTokens()
+	a.Rand = bytes.NewReader(bytes.Repeat([]byte{1}, 64))
+	b.Rand = bytes.NewReader(bytes.Repeat([]byte{1}, 64))
+
+	ta, _ := a.Sign(Unsubscribe, "7", time.Hour)
+	tb, _ := b.Sign(Unsubscribe, "7", time.Hour)
+	if ta != tb {
+		t.Error("expected the same tokens from the same source of randomness")
+	}
+
+	d := a.Derive(Unsubscribe, "7")
+	if d != b.Derive(Unsubscribe, "7") || !a.Equal(d, Unsubscribe, "7") {
+		t.Error("expected Derive to be stable")
+	}
+	if a.Equal(d, Unsubscribe, "8") || a.Equal(d, Invite, "7") {
+		t.Error("expected a derived token to only match its purpose and subject")
+	}
+
+	r, err := a.Random(16)
+	if err != nil || len(r) != 22 {
+		t.Errorf("expected 22 characters, got %q (%v)", r, err)
+	}
+}
@@ -0,0 +1,77 @@
+package trace
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Header carries the request id to other services and back to the client
+const Header = "X-Request-Id"
+
+type contextKey struct{}
+
+// WithID returns a context carrying the request id, e.g. to run a queued job under the id of
+// the request which queued it
+func WithID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// ID returns the request id of ctx, set by WithID or by chi's RequestID middleware
+func ID(ctx context.Context) string {
+	if id, ok := ctx.Value(contextKey{}).(string); ok {
+		return id
+	}
+	return middleware.GetReqID(ctx)
+}
+
+// Middleware sends the request id back in the response, so a user reporting a problem can quote
+// it; it goes after chi's RequestID middleware
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if id := ID(r.Context()); id != "" {
+			rw.Header().Set(Header, id)
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// Transport adds the request id of the request context to outgoing requests
+type Transport struct {
+	// Base is the wrapped transport; http.DefaultTransport when nil
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	id := ID(r.Context())
+	if id == "" || r.Header.Get(Header) != "" {
+		return base.RoundTrip(r)
+	}
+
+	// a RoundTripper must not modify the request it was given
+	r = r.Clone(r.Context())
+	r.Header.Set(Header, id)
+	return base.RoundTrip(r)
+}
+
+// Client returns an http.Client whose requests carry the request id
+func Client(base *http.Client) *http.Client {
+	if base == nil {
+		base = &http.Client{}
+	}
+
+	client := *base
+	client.Transport = &Transport{Base: base.Transport}
+
+	return &client
+}
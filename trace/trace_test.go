@@ -0,0 +1,53 @@
+package trace
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestID(t *testing.T) {
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "chi-1")
+	if id := ID(ctx); id != "chi-1" {
+		t.Errorf("expected the id of chi's middleware, got %q", id)
+	}
+	if id := ID(WithID(ctx, "job-2")); id != "job-2" {
+		t.Errorf("expected the id set with WithID, got %q", id)
+	}
+	if id := ID(WithID(context.Background(), "")); id != "" {
+		t.Errorf("expected no id, got %q", id)
+	}
+}
+
+func TestMiddlewareAndClient(t *testing.T) {
+	var received string
+	upstream := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(Header)
+	}))
+	defer upstream.Close()
+
+	client := Client(nil)
+	handler := middleware.RequestID(Middleware(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, upstream.URL, nil)
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	})))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(Header, "abc-123")
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, r)
+
+	if received != "abc-123" {
+		t.Errorf("expected the id to reach the upstream, got %q", received)
+	}
+	if got := rw.Header().Get(Header); got != "abc-123" {
+		t.Errorf("expected the id in the response, got %q", got)
+	}
+}
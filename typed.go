@@ -0,0 +1,207 @@
+package goravel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Typed adapts a handler of the form func(ctx context.Context, req Req) (Resp, error), where Req
+// is a struct or a pointer to one, into an http.HandlerFunc. Fields of Req are filled from the
+// JSON body by their json tags, and from route parameters and the query string by `param:"id"`
+// and `query:"page"` tags; `validate:"required|email"` tags are checked with the Schema rules
+// and failures are sent as a 422. Resp is sent as JSON with a 200, or the status of its
+// StatusCode method; a nil pointer sends a 204. Errors are sent by HandleError.
+// Typed panics when fn does not have that form, so mistakes surface when routes are declared.
+func (grv *Goravel) Typed(fn interface{}) http.HandlerFunc {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func || ft.NumIn() != 2 || ft.NumOut() != 2 || ft.In(0) != contextType || ft.Out(1) != errorType {
+		panic(fmt.Sprintf("goravel: typed handler must be func(context.Context, Req) (Resp, error), got %T", fn))
+	}
+
+	reqType := ft.In(1)
+	structType := reqType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("goravel: typed handler request must be a struct, got %s", reqType))
+	}
+	schema := typedSchema(structType)
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		req := reflect.New(structType)
+
+		if r.ContentLength != 0 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			if err := grv.ReadJSON(rw, r, req.Interface()); err != nil {
+				grv.HandleError(rw, r, err)
+				return
+			}
+		}
+
+		if err := bindValues(req.Elem(), r); err != nil {
+			grv.HandleError(rw, r, NewStatusError(http.StatusBadRequest, err.Error()))
+			return
+		}
+
+		if len(schema) > 0 {
+			v := grv.Validator(structValues(req.Elem()))
+			v.Schema(schema)
+			if !v.Valid() {
+				grv.HandleError(rw, r, &ValidationError{Errors: v.Error})
+				return
+			}
+		}
+
+		if reqType.Kind() != reflect.Ptr {
+			req = req.Elem()
+		}
+		out := fv.Call([]reflect.Value{reflect.ValueOf(r.Context()), req})
+
+		if err, _ := out[1].Interface().(error); err != nil {
+			grv.HandleError(rw, r, err)
+			return
+		}
+
+		resp := out[0]
+		if (resp.Kind() == reflect.Ptr || resp.Kind() == reflect.Interface) && resp.IsNil() {
+			rw.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		status := http.StatusOK
+		if sc, ok := resp.Interface().(StatusCoder); ok {
+			status = sc.StatusCode()
+		}
+		if err := grv.WriteJSON(rw, status, resp.Interface()); err != nil {
+			grv.ErrorLog.Println(err)
+		}
+	}
+}
+
+// fieldName is the name a field is known by to clients, used as the key of its validation error
+func fieldName(f reflect.StructField) string {
+	for _, tag := range []string{"param", "query", "json"} {
+		if name := strings.Split(f.Tag.Get(tag), ",")[0]; name != "" && name != "-" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+func typedSchema(t reflect.Type) Schema {
+	schema := Schema{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if rules := f.Tag.Get("validate"); rules != "" && f.PkgPath == "" {
+			schema[fieldName(f)] = rules
+		}
+	}
+	return schema
+}
+
+// bindValues sets the fields tagged param or query from the route and the query string
+func bindValues(v reflect.Value, r *http.Request) error {
+	query := r.URL.Query()
+
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		var raw string
+		var present bool
+		if name := f.Tag.Get("param"); name != "" {
+			raw = chi.URLParam(r, name)
+			present = raw != ""
+		} else if name := f.Tag.Get("query"); name != "" {
+			_, present = query[name]
+			raw = query.Get(name)
+		}
+		if !present {
+			continue
+		}
+
+		if err := setField(v.Field(i), raw); err != nil {
+			return fmt.Errorf("%s: %w", fieldName(f), err)
+		}
+	}
+
+	return nil
+}
+
+func setField(field reflect.Value, raw string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return errors.New("must be a positive integer")
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		field.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+// structValues reads the fields of the decoded request as text for the validation rules;
+// zero values count as missing so required works the same for JSON and query fields
+func structValues(v reflect.Value) map[string][]string {
+	data := map[string][]string{}
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if f.PkgPath != "" || f.Tag.Get("validate") == "" {
+			continue
+		}
+
+		field := v.Field(i)
+		if field.IsZero() {
+			continue
+		}
+		for field.Kind() == reflect.Ptr {
+			field = field.Elem()
+		}
+
+		if field.Kind() == reflect.Slice {
+			for j := 0; j < field.Len(); j++ {
+				data[fieldName(f)] = append(data[fieldName(f)], fmt.Sprint(field.Index(j).Interface()))
+			}
+			continue
+		}
+		data[fieldName(f)] = []string{fmt.Sprint(field.Interface())}
+	}
+	return data
+}
@@ -0,0 +1,170 @@
+package goravel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/namnguyen191/goravel/env"
+)
+
+// testApp returns an app with what the handlers and middleware under test use, its error
+// log written to logged
+func testApp(logged *bytes.Buffer) *Goravel {
+	return &Goravel{
+		Env:      env.NewReader(),
+		ErrorLog: log.New(logged, "", 0),
+		InfoLog:  log.New(&bytes.Buffer{}, "", 0),
+	}
+}
+
+// errorBody is the JSON payload of an error sent by HandleError
+type errorBody struct {
+	Error   bool              `json:"error"`
+	Message string            `json:"message"`
+	Errors  map[string]string `json:"errors"`
+}
+
+func decodeError(t *testing.T, rw *httptest.ResponseRecorder) errorBody {
+	t.Helper()
+	var body errorBody
+	if err := json.Unmarshal(rw.Body.Bytes(), &body); err != nil {
+		t.Fatalf("expected a JSON error, got %s", rw.Body)
+	}
+	return body
+}
+
+type addMember struct {
+	TeamID int    `param:"team"`
+	Notify bool   `query:"notify"`
+	Email  string `json:"email" validate:"required|email"`
+	Role   string `json:"role" validate:"in:admin,member"`
+}
+
+type member struct {
+	TeamID int    `json:"team_id"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+	Notify bool   `json:"notify"`
+}
+
+func (m *member) StatusCode() int {
+	return http.StatusCreated
+}
+
+func typedRouter(grv *Goravel) http.Handler {
+	mux := chi.NewRouter()
+	mux.Post("/api/teams/{team}/members", grv.Typed(func(ctx context.Context, req addMember) (*member, error) {
+		if req.Email == "gone@example.com" {
+			return nil, nil
+		}
+		return &member{TeamID: req.TeamID, Email: req.Email, Role: req.Role, Notify: req.Notify}, nil
+	}))
+	return mux
+}
+
+func postJSON(h http.Handler, url, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", url, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, r)
+	return rw
+}
+
+func TestTyped(t *testing.T) {
+	var logged bytes.Buffer
+	h := typedRouter(testApp(&logged))
+
+	rw := postJSON(h, "/api/teams/7/members?notify=true", `{"email": "ann@example.com", "role": "admin"}`)
+	if rw.Code != http.StatusCreated {
+		t.Fatalf("expected the status of the response, got %d %s", rw.Code, rw.Body)
+	}
+	var m member
+	if err := json.Unmarshal(rw.Body.Bytes(), &m); err != nil || m != (member{7, "ann@example.com", "admin", true}) {
+		t.Errorf("expected the member from the route, query and body, got %+v %v", m, err)
+	}
+
+	rw = postJSON(h, "/api/teams/7/members", `{"email": "gone@example.com"}`)
+	if rw.Code != http.StatusNoContent || rw.Body.Len() != 0 {
+		t.Errorf("expected a 204 for a nil response, got %d %s", rw.Code, rw.Body)
+	}
+
+	if logged.Len() != 0 {
+		t.Errorf("expected nothing logged, got %s", logged.String())
+	}
+}
+
+func TestTyped_badRequests(t *testing.T) {
+	h := typedRouter(testApp(&bytes.Buffer{}))
+
+	tests := []struct {
+		name, url, body string
+		status          int
+		message         string
+	}{
+		{"malformed json", "/api/teams/7/members", `{"email": `, http.StatusBadRequest, "body has badly formed json"},
+		{"syntax error", "/api/teams/7/members", `{"email" "ann@example.com"}`, http.StatusBadRequest, "body has badly formed json at character 10"},
+		{"unknown field", "/api/teams/7/members", `{"email": "ann@example.com", "admin": true}`, http.StatusBadRequest, `body has unknown field "admin"`},
+		{"wrong type", "/api/teams/7/members", `{"email": 3}`, http.StatusBadRequest, `body has the wrong type for field "email"`},
+		{"two values", "/api/teams/7/members", `{"email": "ann@example.com"} {}`, http.StatusBadRequest, "body must only have a single json value"},
+		{"bad param", "/api/teams/seven/members", `{"email": "ann@example.com"}`, http.StatusBadRequest, "team: must be an integer"},
+		{"bad query", "/api/teams/7/members?notify=maybe", `{"email": "ann@example.com"}`, http.StatusBadRequest, "notify: must be true or false"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := postJSON(h, tt.url, tt.body)
+			if rw.Code != tt.status {
+				t.Errorf("expected %d, got %d %s", tt.status, rw.Code, rw.Body)
+			}
+			if body := decodeError(t, rw); !body.Error || body.Message != tt.message {
+				t.Errorf("expected the message %q, got %+v", tt.message, body)
+			}
+		})
+	}
+}
+
+func TestTyped_validation(t *testing.T) {
+	h := typedRouter(testApp(&bytes.Buffer{}))
+
+	rw := postJSON(h, "/api/teams/7/members", `{"email": "not an address", "role": "owner"}`)
+	if rw.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected a 422, got %d %s", rw.Code, rw.Body)
+	}
+	body := decodeError(t, rw)
+	if body.Message != "The given data was invalid" || body.Errors["email"] == "" || body.Errors["role"] != "This field must be one of admin, member" {
+		t.Errorf("expected the errors of email and role, got %+v", body)
+	}
+
+	rw = postJSON(h, "/api/teams/7/members", `{}`)
+	if body := decodeError(t, rw); rw.Code != http.StatusUnprocessableEntity || body.Errors["email"] != "This field cannot be blank" {
+		t.Errorf("expected the missing email, got %d %+v", rw.Code, body)
+	}
+	if _, ok := decodeError(t, rw).Errors["role"]; ok {
+		t.Error("expected an optional field to pass when missing")
+	}
+}
+
+func TestTyped_signature(t *testing.T) {
+	grv := testApp(&bytes.Buffer{})
+
+	for _, fn := range []interface{}{
+		func(req addMember) (*member, error) { return nil, nil },
+		func(ctx context.Context, req addMember) *member { return nil },
+		func(ctx context.Context, id int) (*member, error) { return nil, nil },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected %T to be refused", fn)
+				}
+			}()
+			grv.Typed(fn)
+		}()
+	}
+}
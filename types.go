@@ -28,5 +28,20 @@ type Database struct {
 type redisConfig struct {
 	host     string
 	password string
-	prefix   string
+	failover bool
+	// sentinels and cluster are the REDIS_SENTINELS and REDIS_CLUSTER_NODES addresses
+	sentinels []string
+	cluster   []string
+}
+
+// configured tells whether redis can be reached, directly, through sentinel or as a cluster
+func (c redisConfig) configured() bool {
+	return c.host != "" || len(c.sentinels) > 0 || len(c.cluster) > 0
+}
+
+type routerConfig struct {
+	// how to treat a trailing slash: "redirect", "strip" or "" to leave the path untouched
+	trailingSlash string
+	// answer HEAD requests with the matching GET route
+	autoHead bool
 }
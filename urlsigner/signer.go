@@ -6,10 +6,13 @@ import (
 	"time"
 
 	goalone "github.com/bwmarrin/go-alone"
+	"github.com/namnguyen191/goravel/clock"
 )
 
 type Signer struct {
 	Secret []byte
+	// Clock tells when a link expires; the time of the machine when nil
+	Clock clock.Clock
 }
 
 func (s *Signer) GenerateTokenFromString(data string) string {
@@ -41,5 +44,5 @@ func (s *Signer) Expired(token string, minutesUntilExpire int) bool {
 
 	ts := crypt.Parse([]byte(token))
 
-	return time.Since(ts.Timestamp) > time.Duration(minutesUntilExpire)*time.Minute
+	return clock.Since(s.Clock, ts.Timestamp) > time.Duration(minutesUntilExpire)*time.Minute
 }
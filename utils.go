@@ -2,8 +2,10 @@ package goravel
 
 import (
 	"fmt"
+	"os"
 	"regexp"
 	"runtime"
+	"strings"
 	"time"
 )
 
@@ -16,3 +18,19 @@ func (grv *Goravel) LoadTime(start time.Time) {
 
 	grv.InfoLog.Println(fmt.Sprintf("Load Time: %s took %s", name, elapsed))
 }
+
+// namespace is the prefix of the app's keys in shared stores
+func namespace() string {
+	for _, key := range []string{"NAMESPACE", "REDIS_PREFIX", "APP_NAME"} {
+		if ns := strings.TrimSpace(os.Getenv(key)); ns != "" {
+			return ns
+		}
+	}
+	return "goravel"
+}
+
+// Key namespaces a key of a shared store, e.g. grv.Key("queue", "mail") is "myapp:queue:mail";
+// the cache and sessions already prefix their keys
+func (grv *Goravel) Key(parts ...string) string {
+	return strings.Join(append([]string{grv.Namespace}, parts...), ":")
+}
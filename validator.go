@@ -1,27 +1,79 @@
 package goravel
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/asaskevich/govalidator"
+	"github.com/namnguyen191/goravel/contact"
+	"github.com/namnguyen191/goravel/database"
+	"github.com/namnguyen191/goravel/validator"
 )
 
 type Validation struct {
 	Data  url.Values
 	Error map[string]string
+	// checker runs the rules of the validator package against the database of the app
+	checker *validator.Validator
 }
 
 func (grv *Goravel) Validator(data url.Values) *Validation {
 	return &Validation{
-		Error: make(map[string]string),
-		Data:  data,
+		Error:   make(map[string]string),
+		Data:    data,
+		checker: validator.New(grv.DB.Pool, database.Dialect(grv.DB.DataBaseType)),
 	}
 }
 
+// ValidateRequest checks the form of r against rules, e.g.
+//
+//	v, err := app.ValidateRequest(r, map[string]string{
+//		"email":    "required|email|unique:users",
+//		"password": "required|min:8|confirmed",
+//	})
+//	if err != nil {
+//		app.Error500(rw, r)
+//		return
+//	}
+//	if !v.Valid() {
+//		app.FlashValidation(r, v)
+//		http.Redirect(rw, r, "/register", http.StatusSeeOther)
+//		return
+//	}
+func (grv *Goravel) ValidateRequest(r *http.Request, rules map[string]string) (*Validation, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	v := grv.Validator(r.Form)
+	return v, v.Rules(r.Context(), rules)
+}
+
+// Rules checks Data against rules written for the validator package, e.g. "required|max:255"
+// for a field, keeping the errors added before. The error is for a rule which could not
+// run, not for invalid data.
+func (v *Validation) Rules(ctx context.Context, rules map[string]string) error {
+	checker := v.checker
+	if checker == nil {
+		checker = validator.New(nil, "")
+	}
+
+	errs, err := checker.Validate(ctx, v.Data, rules)
+	if err != nil {
+		return err
+	}
+	for field, message := range errs {
+		v.AddError(field, message)
+	}
+	return nil
+}
+
 func (v *Validation) Valid() bool {
 	return len(v.Error) == 0
 }
@@ -80,8 +132,43 @@ func (v *Validation) IsDateISO(field, value string) {
 	}
 }
 
+// MinLength checks that value has at least n characters
+func (v *Validation) MinLength(field, value string, n int) {
+	if utf8.RuneCountInString(value) < n {
+		v.AddError(field, fmt.Sprintf("This field must be at least %d characters long", n))
+	}
+}
+
+// MaxLength checks that value has at most n characters
+func (v *Validation) MaxLength(field, value string, n int) {
+	if utf8.RuneCountInString(value) > n {
+		v.AddError(field, fmt.Sprintf("This field must be at most %d characters long", n))
+	}
+}
+
 func (v *Validation) NoSpaces(field, value string) {
 	if govalidator.HasWhitespace(value) {
 		v.AddError(field, "Spaces are not permitted")
 	}
 }
+
+// IsPhone checks that value is a phone number; numbers without a +country prefix are read as national numbers of region
+func (v *Validation) IsPhone(field, value, region string) {
+	if _, err := contact.ParsePhone(value, region); err != nil {
+		v.AddError(field, "Invalid phone number")
+	}
+}
+
+// IsDeliverableEmail checks the address and that its domain accepts mail
+func (v *Validation) IsDeliverableEmail(field, value string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	switch contact.ValidateEmail(ctx, value, true) {
+	case nil:
+	case contact.ErrNoMailServer:
+		v.AddError(field, "This email domain does not receive mail")
+	default:
+		v.AddError(field, "Invalid email address")
+	}
+}
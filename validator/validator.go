@@ -0,0 +1,299 @@
+package validator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/asaskevich/govalidator"
+)
+
+// Field is the value a rule checks, with the parameters of the rule, e.g. 3 for min:3
+type Field struct {
+	Name   string
+	Value  string
+	Params []string
+	// Values are all the submitted values, for rules comparing fields
+	Values url.Values
+	// Validator runs the rule, giving it the database
+	Validator *Validator
+}
+
+// Rule checks a field, returning the message shown when it is invalid or "" when it is valid
+type Rule func(ctx context.Context, f Field) (string, error)
+
+var (
+	rulesMu sync.RWMutex
+	rules   = map[string]Rule{}
+)
+
+func init() {
+	Register("required", required)
+	Register("email", email)
+	Register("min", minLength)
+	Register("max", maxLength)
+	Register("numeric", numeric)
+	Register("integer", integer)
+	Register("date", date)
+	Register("in", in)
+	Register("confirmed", confirmed)
+	Register("unique", unique)
+	Register("exists", exists)
+}
+
+// Register makes a rule available by name in the rules of Validate, usually from an init
+// function. It panics when name is taken or rule is nil.
+func Register(name string, rule Rule) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+
+	if rule == nil {
+		panic("validator: Register rule is nil")
+	}
+	if _, dup := rules[name]; dup {
+		panic("validator: Register called twice for rule " + name)
+	}
+	rules[name] = rule
+}
+
+// Rules returns the names of the registered rules, sorted
+func Rules() []string {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Errors are the messages of the invalid fields
+type Errors map[string]string
+
+// Validator checks submitted values against rules written as "required|email|max:255"
+type Validator struct {
+	// DB is queried by the unique and exists rules
+	DB *sql.DB
+	// Dialect is the SQL dialect of DB, "postgres" or "mysql", for its placeholders
+	Dialect string
+}
+
+// New returns a validator querying db, which may be nil when no rule needs it
+func New(db *sql.DB, dialect string) *Validator {
+	return &Validator{DB: db, Dialect: dialect}
+}
+
+// Validate checks values against the rules of each field, keeping the first message of a
+// field. A field left empty is only checked by required, so optional fields need no more.
+// The error is for a rule which could not run, e.g. an unknown rule or a database failure.
+func (v *Validator) Validate(ctx context.Context, values url.Values, fieldRules map[string]string) (Errors, error) {
+	errs := Errors{}
+
+	// sorted, so a failing database query is reported the same way every time
+	fields := make([]string, 0, len(fieldRules))
+	for field := range fieldRules {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		value := values.Get(field)
+		for _, spec := range strings.Split(fieldRules[field], "|") {
+			spec = strings.TrimSpace(spec)
+			if spec == "" {
+				continue
+			}
+
+			name, params := spec, []string(nil)
+			if i := strings.Index(spec, ":"); i >= 0 {
+				name, params = spec[:i], strings.Split(spec[i+1:], ",")
+			}
+			if name != "required" && strings.TrimSpace(value) == "" {
+				continue
+			}
+
+			rulesMu.RLock()
+			rule := rules[name]
+			rulesMu.RUnlock()
+			if rule == nil {
+				return nil, fmt.Errorf("validator: unknown rule %q for %s", name, field)
+			}
+
+			msg, err := rule(ctx, Field{Name: field, Value: value, Params: params, Values: values, Validator: v})
+			if err != nil {
+				return nil, fmt.Errorf("validator: %s of %s: %w", name, field, err)
+			}
+			if msg != "" {
+				errs[field] = msg
+				break
+			}
+		}
+	}
+
+	return errs, nil
+}
+
+// param returns the parameter i of the rule as an int
+func (f Field) param(i int) (int, error) {
+	if i >= len(f.Params) {
+		return 0, fmt.Errorf("missing parameter")
+	}
+	return strconv.Atoi(strings.TrimSpace(f.Params[i]))
+}
+
+func required(ctx context.Context, f Field) (string, error) {
+	if strings.TrimSpace(f.Value) == "" {
+		return "This field cannot be blank", nil
+	}
+	return "", nil
+}
+
+func email(ctx context.Context, f Field) (string, error) {
+	if !govalidator.IsEmail(f.Value) {
+		return "Invalid email address", nil
+	}
+	return "", nil
+}
+
+func minLength(ctx context.Context, f Field) (string, error) {
+	n, err := f.param(0)
+	if err != nil {
+		return "", err
+	}
+	if utf8.RuneCountInString(f.Value) < n {
+		return fmt.Sprintf("This field must be at least %d characters long", n), nil
+	}
+	return "", nil
+}
+
+func maxLength(ctx context.Context, f Field) (string, error) {
+	n, err := f.param(0)
+	if err != nil {
+		return "", err
+	}
+	if utf8.RuneCountInString(f.Value) > n {
+		return fmt.Sprintf("This field must be at most %d characters long", n), nil
+	}
+	return "", nil
+}
+
+func numeric(ctx context.Context, f Field) (string, error) {
+	if _, err := strconv.ParseFloat(f.Value, 64); err != nil {
+		return "This field must be a number", nil
+	}
+	return "", nil
+}
+
+func integer(ctx context.Context, f Field) (string, error) {
+	if _, err := strconv.Atoi(f.Value); err != nil {
+		return "This field must be an integer", nil
+	}
+	return "", nil
+}
+
+// date takes the layout of the value, YYYY-MM-DD by default, e.g. date:2006-01-02 15:04
+func date(ctx context.Context, f Field) (string, error) {
+	layout := "2006-01-02"
+	if len(f.Params) > 0 {
+		layout = strings.Join(f.Params, ",")
+	}
+	if _, err := time.Parse(layout, f.Value); err != nil {
+		return "This field must be a date like " + layout, nil
+	}
+	return "", nil
+}
+
+func in(ctx context.Context, f Field) (string, error) {
+	for _, p := range f.Params {
+		if f.Value == strings.TrimSpace(p) {
+			return "", nil
+		}
+	}
+	return "This field must be one of " + strings.Join(f.Params, ", "), nil
+}
+
+// confirmed checks the field has the same value as the field with _confirmation appended,
+// e.g. password and password_confirmation
+func confirmed(ctx context.Context, f Field) (string, error) {
+	if f.Values.Get(f.Name+"_confirmation") != f.Value {
+		return "The confirmation does not match", nil
+	}
+	return "", nil
+}
+
+// unique checks no row of the table has the value, as unique:table,column; a third
+// parameter is the id of the row being edited, which may keep its own value
+func unique(ctx context.Context, f Field) (string, error) {
+	found, err := f.Validator.count(ctx, f)
+	if err != nil {
+		return "", err
+	}
+	if found {
+		return "This value is already taken", nil
+	}
+	return "", nil
+}
+
+// exists checks a row of the table has the value, as exists:table,column
+func exists(ctx context.Context, f Field) (string, error) {
+	found, err := f.Validator.count(ctx, f)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "This value does not exist", nil
+	}
+	return "", nil
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// count reports whether the table of the rule of f has a row with its value in the column,
+// leaving out the row with the id of its third parameter
+func (v *Validator) count(ctx context.Context, f Field) (bool, error) {
+	if v == nil || v.DB == nil {
+		return false, fmt.Errorf("no database")
+	}
+	if len(f.Params) < 1 {
+		return false, fmt.Errorf("missing table")
+	}
+
+	table, column := strings.TrimSpace(f.Params[0]), f.Name
+	if len(f.Params) > 1 && strings.TrimSpace(f.Params[1]) != "" {
+		column = strings.TrimSpace(f.Params[1])
+	}
+	// the names are written into the query, so they cannot come from the client
+	if !identifier.MatchString(table) || !identifier.MatchString(column) {
+		return false, fmt.Errorf("bad table or column %q.%q", table, column)
+	}
+
+	query := fmt.Sprintf("select count(*) from %s where %s = %s", table, column, v.placeholder(1))
+	args := []interface{}{f.Value}
+	if len(f.Params) > 2 && strings.TrimSpace(f.Params[2]) != "" {
+		query += " and id <> " + v.placeholder(2)
+		args = append(args, strings.TrimSpace(f.Params[2]))
+	}
+
+	var n int
+	if err := v.DB.QueryRowContext(ctx, query, args...).Scan(&n); err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (v *Validator) placeholder(n int) string {
+	if v.Dialect == "postgres" {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
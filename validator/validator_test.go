@@ -0,0 +1,170 @@
+package validator
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net/url"
+	"testing"
+)
+
+// countDriver answers every query with a count of 1 when the first argument is taken
+type countDriver struct {
+	taken   string
+	queries []string
+}
+
+func (d *countDriver) Open(string) (driver.Conn, error) { return &countConn{d}, nil }
+
+type countConn struct{ d *countDriver }
+
+func (c *countConn) Prepare(query string) (driver.Stmt, error) {
+	c.d.queries = append(c.d.queries, query)
+	return &countStmt{c.d}, nil
+}
+func (c *countConn) Close() error              { return nil }
+func (c *countConn) Begin() (driver.Tx, error) { return nil, errors.New("no transactions") }
+
+type countStmt struct{ d *countDriver }
+
+func (s *countStmt) Close() error  { return nil }
+func (s *countStmt) NumInput() int { return -1 }
+func (s *countStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("no exec")
+}
+func (s *countStmt) Query(args []driver.Value) (driver.Rows, error) {
+	n := int64(0)
+	if args[0] == s.d.taken && (len(args) < 2 || args[1] != "7") {
+		n = 1
+	}
+	return &countRows{n: n}, nil
+}
+
+type countRows struct {
+	n    int64
+	done bool
+}
+
+func (r *countRows) Columns() []string { return []string{"count"} }
+func (r *countRows) Close() error      { return nil }
+func (r *countRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.n
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	values := url.Values{
+		"name":                  {"Jo"},
+		"email":                 {"not-an-email"},
+		"age":                   {"forty"},
+		"birthday":              {"1990-02-30"},
+		"color":                 {"blue"},
+		"password":              {"secret123"},
+		"password_confirmation": {"secret124"},
+		"nickname":              {""},
+	}
+
+	errs, err := New(nil, "").Validate(context.Background(), values, map[string]string{
+		"name":     "required|min:3|max:10",
+		"email":    "required|email",
+		"age":      "numeric",
+		"birthday": "date",
+		"color":    "in:red,green",
+		"password": "required|min:8|confirmed",
+		"nickname": "min:3",
+		"title":    "required",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"name":     "This field must be at least 3 characters long",
+		"email":    "Invalid email address",
+		"age":      "This field must be a number",
+		"birthday": "This field must be a date like 2006-01-02",
+		"color":    "This field must be one of red, green",
+		"password": "The confirmation does not match",
+		"title":    "This field cannot be blank",
+	}
+	for field, msg := range want {
+		if errs[field] != msg {
+			t.Errorf("%s: expected %q, got %q", field, msg, errs[field])
+		}
+	}
+	if _, ok := errs["nickname"]; ok {
+		t.Error("an empty optional field should not be checked")
+	}
+	if len(errs) != len(want) {
+		t.Errorf("unexpected errors %v", errs)
+	}
+}
+
+func TestUnique(t *testing.T) {
+	d := &countDriver{taken: "taken@here.com"}
+	sql.Register("validator-count", d)
+	db, err := sql.Open("validator-count", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	v := New(db, "postgres")
+	ctx := context.Background()
+
+	errs, err := v.Validate(ctx, url.Values{"email": {"taken@here.com"}}, map[string]string{"email": "unique:users"})
+	if err != nil || errs["email"] != "This value is already taken" {
+		t.Errorf("expected the email to be taken, got %v %v", errs, err)
+	}
+	if d.queries[0] != "select count(*) from users where email = $1" {
+		t.Errorf("unexpected query %q", d.queries[0])
+	}
+
+	// the row being edited keeps its own address
+	errs, _ = v.Validate(ctx, url.Values{"email": {"taken@here.com"}}, map[string]string{"email": "unique:users,email,7"})
+	if len(errs) != 0 {
+		t.Errorf("expected the edited row to keep its value, got %v", errs)
+	}
+
+	errs, _ = v.Validate(ctx, url.Values{"owner": {"free@here.com"}}, map[string]string{"owner": "exists:users,email"})
+	if errs["owner"] != "This value does not exist" {
+		t.Errorf("expected the owner to be missing, got %v", errs)
+	}
+
+	if _, err := v.Validate(ctx, url.Values{"email": {"a"}}, map[string]string{"email": "unique:users;drop table users"}); err == nil {
+		t.Error("expected an error for a bad table name")
+	}
+}
+
+func TestUnknownRule(t *testing.T) {
+	if _, err := New(nil, "").Validate(context.Background(), url.Values{"a": {"b"}}, map[string]string{"a": "shiny"}); err == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("lowercase", func(ctx context.Context, f Field) (string, error) {
+		if f.Value != "" && f.Value[0] >= 'A' && f.Value[0] <= 'Z' {
+			return "This field must be lowercase", nil
+		}
+		return "", nil
+	})
+
+	errs, err := New(nil, "").Validate(context.Background(), url.Values{"slug": {"Hello"}}, map[string]string{"slug": "lowercase"})
+	if err != nil || errs["slug"] != "This field must be lowercase" {
+		t.Errorf("expected the custom rule to run, got %v %v", errs, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected registering a rule twice to panic")
+		}
+	}()
+	Register("required", required)
+}
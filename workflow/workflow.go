@@ -0,0 +1,378 @@
+package workflow
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/namnguyen191/goravel/clock"
+	"github.com/namnguyen191/goravel/database"
+	"github.com/namnguyen191/goravel/text"
+)
+
+// Job is the type of the queued job running an instance, its payload the id of the instance
+const Job = "workflow"
+
+// status values stored in the workflows table
+const (
+	StatusRunning     = "running"
+	StatusCompleted   = "completed"
+	StatusCompensated = "compensated"
+	StatusFailed      = "failed"
+)
+
+// State is the data shared by the steps of a running workflow; it is persisted after every step
+type State map[string]interface{}
+
+// Step is a unit of work. When a later step fails, Compensate is called for every completed step
+// in reverse order to undo its effects.
+type Step struct {
+	Name       string
+	Run        func(ctx context.Context, state State) error
+	Compensate func(ctx context.Context, state State) error
+	// Timeout bounds a single run of the step; zero means no limit
+	Timeout time.Duration
+}
+
+// Workflow is a named sequence of steps
+type Workflow struct {
+	Name  string
+	Steps []Step
+}
+
+// Instance is a persisted execution of a workflow
+type Instance struct {
+	ID        int
+	Workflow  string
+	Status    string
+	Step      int
+	State     State
+	Error     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	// holder is the run which claimed the instance; only its saves are kept
+	holder string
+}
+
+// Engine runs workflows and keeps their progress in the database so they can resume after a restart
+type Engine struct {
+	DB           *sql.DB
+	DatabaseType string
+	// Dispatch has Continue run an instance in the background; it defaults to a new goroutine,
+	// and pushes a Job with the id of the instance when the engine works off a job queue
+	Dispatch func(ctx context.Context, id int) error
+	// ErrorLog receives errors from background runs
+	ErrorLog func(err error)
+	// Lease is how long a run holds an instance between two steps before Resume hands it to
+	// another run; it must be longer than the slowest step
+	Lease time.Duration
+	Clock clock.Clock
+
+	mu        sync.RWMutex
+	workflows map[string]*Workflow
+}
+
+// New returns an engine backed by the workflows table
+func New(db *sql.DB, dbType string) *Engine {
+	e := &Engine{
+		DB:           db,
+		DatabaseType: dbType,
+		ErrorLog:     func(err error) {},
+		Lease:        5 * time.Minute,
+		workflows:    make(map[string]*Workflow),
+	}
+	e.Dispatch = func(ctx context.Context, id int) error {
+		go func() {
+			if err := e.Continue(context.Background(), id); err != nil {
+				e.ErrorLog(err)
+			}
+		}()
+		return nil
+	}
+
+	return e
+}
+
+// Define registers a workflow
+func (e *Engine) Define(w *Workflow) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.workflows[w.Name] = w
+}
+
+func (e *Engine) workflow(name string) (*Workflow, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	w, ok := e.workflows[name]
+	if !ok {
+		return nil, fmt.Errorf("workflow %q is not defined", name)
+	}
+
+	return w, nil
+}
+
+// Start persists a new instance of the workflow and runs it in the background
+func (e *Engine) Start(ctx context.Context, name string, state State) (int, error) {
+	if _, err := e.workflow(name); err != nil {
+		return 0, err
+	}
+
+	if state == nil {
+		state = State{}
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return 0, err
+	}
+
+	now := clock.Now(e.Clock)
+	inst := &Instance{Workflow: name, Status: StatusRunning, State: state, CreatedAt: now, UpdatedAt: now}
+
+	query := "insert into workflows (workflow, status, step, state, error, created_at, updated_at) values (?, ?, 0, ?, '', ?, ?)"
+	if database.IsPostgres(e.DatabaseType) {
+		err = e.DB.QueryRowContext(ctx, database.Rebind(e.DatabaseType, query+" returning id"), name, StatusRunning, string(data), now, now).Scan(&inst.ID)
+	} else {
+		var res sql.Result
+		res, err = e.DB.ExecContext(ctx, query, name, StatusRunning, string(data), now, now)
+		if err == nil {
+			var id int64
+			id, err = res.LastInsertId()
+			inst.ID = int(id)
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// an instance which could not be dispatched is still picked up by Resume
+	return inst.ID, e.Dispatch(ctx, inst.ID)
+}
+
+// Resume dispatches every instance left running by a run which stopped, e.g. with a restart,
+// once its lease has expired. It is called at boot and then regularly; Continue claims the
+// instances, so several processes resuming at once run each of them only once.
+func (e *Engine) Resume(ctx context.Context) error {
+	rows, err := e.DB.QueryContext(ctx, database.Rebind(e.DatabaseType,
+		"select id from workflows where status = ? and (lease_until is null or lease_until < ?)"), StatusRunning, clock.Now(e.Clock))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var pending []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		pending = append(pending, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range pending {
+		if err := e.Dispatch(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Continue claims an instance and runs its remaining steps. An instance which is finished, or
+// held by another run whose lease has not expired, is left alone, so a job delivered twice
+// does nothing the second time.
+func (e *Engine) Continue(ctx context.Context, id int) error {
+	holder := text.HexToken(8)
+
+	now := clock.Now(e.Clock)
+	res, err := e.DB.ExecContext(ctx, database.Rebind(e.DatabaseType,
+		"update workflows set holder = ?, lease_until = ? where id = ? and status = ? and (lease_until is null or lease_until < ?)"),
+		holder, now.Add(e.Lease), id, StatusRunning, now)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+
+	defer func() {
+		// the lease is given up, so a run which stopped on an error is resumed right away
+		_, err := e.DB.ExecContext(context.Background(), database.Rebind(e.DatabaseType,
+			"update workflows set holder = '', lease_until = null where id = ? and holder = ?"), id, holder)
+		if err != nil {
+			e.ErrorLog(fmt.Errorf("workflow #%d: %w", id, err))
+		}
+	}()
+
+	inst, err := e.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+	inst.holder = holder
+
+	if err := e.Run(ctx, inst); err != nil {
+		return fmt.Errorf("workflow %s #%d: %w", inst.Workflow, inst.ID, err)
+	}
+
+	return nil
+}
+
+// Find returns a persisted instance
+func (e *Engine) Find(ctx context.Context, id int) (*Instance, error) {
+	row := e.DB.QueryRowContext(ctx, database.Rebind(e.DatabaseType,
+		"select id, workflow, status, step, state, error, created_at, updated_at from workflows where id = ?"), id)
+
+	return scanInstance(row)
+}
+
+// Run executes the remaining steps of an instance, compensating completed steps on failure.
+// Only the instances claimed by Continue hold a lease; prefer Start and Continue.
+func (e *Engine) Run(ctx context.Context, inst *Instance) error {
+	w, err := e.workflow(inst.Workflow)
+	if err != nil {
+		return err
+	}
+
+	for inst.Step < len(w.Steps) {
+		step := w.Steps[inst.Step]
+
+		state, err := runStep(ctx, step, inst.State)
+		if err != nil {
+			inst.Error = fmt.Sprintf("%s: %s", step.Name, err)
+			inst.Status = e.compensate(ctx, w, inst)
+			if saveErr := e.save(ctx, inst); saveErr != nil {
+				return saveErr
+			}
+
+			return errors.New(inst.Error)
+		}
+
+		inst.State = state
+		inst.Step++
+		if err := e.save(ctx, inst); err != nil {
+			return err
+		}
+	}
+
+	inst.Status = StatusCompleted
+
+	return e.save(ctx, inst)
+}
+
+// compensate undoes the completed steps in reverse order
+func (e *Engine) compensate(ctx context.Context, w *Workflow, inst *Instance) string {
+	status := StatusCompensated
+
+	for i := inst.Step - 1; i >= 0; i-- {
+		step := w.Steps[i]
+		if step.Compensate == nil {
+			continue
+		}
+
+		if err := step.Compensate(ctx, inst.State); err != nil {
+			inst.Error = fmt.Sprintf("%s; compensating %s: %s", inst.Error, step.Name, err)
+			status = StatusFailed
+		}
+	}
+
+	return status
+}
+
+// runStep runs step on a copy of state and returns the copy once the step succeeds. A step
+// which times out keeps running in its goroutine, so it must never share the state the
+// compensation and the save read.
+func runStep(ctx context.Context, step Step, state State) (State, error) {
+	if step.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, step.Timeout)
+		defer cancel()
+	}
+
+	working, err := copyState(state)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- step.Run(ctx, working)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, err
+		}
+		return working, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// copyState returns a deep copy of state the way it is persisted, so a resumed instance sees
+// the same values as one which never stopped
+func copyState(state State) (State, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+
+	copied := State{}
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, err
+	}
+
+	return copied, nil
+}
+
+func (e *Engine) save(ctx context.Context, inst *Instance) error {
+	data, err := json.Marshal(inst.State)
+	if err != nil {
+		return err
+	}
+
+	// every save renews the lease of the run; once another run took the instance over, the
+	// saves of this one are dropped
+	inst.UpdatedAt = clock.Now(e.Clock)
+	_, err = e.DB.ExecContext(ctx, database.Rebind(e.DatabaseType,
+		"update workflows set status = ?, step = ?, state = ?, error = ?, updated_at = ?, lease_until = ? where id = ? and holder = ?"),
+		inst.Status, inst.Step, string(data), inst.Error, inst.UpdatedAt, inst.UpdatedAt.Add(e.Lease), inst.ID, inst.holder)
+
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanInstance(row scanner) (*Instance, error) {
+	var inst Instance
+	var data string
+
+	err := row.Scan(&inst.ID, &inst.Workflow, &inst.Status, &inst.Step, &data, &inst.Error, &inst.CreatedAt, &inst.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(data), &inst.State); err != nil {
+		return nil, err
+	}
+
+	return &inst, nil
+}
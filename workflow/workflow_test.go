@@ -0,0 +1,77 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunStep(t *testing.T) {
+	ok := Step{Name: "ok", Run: func(ctx context.Context, s State) error { s["done"] = true; return nil }}
+	state := State{}
+	got, err := runStep(context.Background(), ok, state)
+	if err != nil || got["done"] != true {
+		t.Errorf("step did not run: %v", err)
+	}
+	if _, ok := state["done"]; ok {
+		t.Error("expected the step to run on a copy of the state")
+	}
+
+	// the step keeps writing after its timeout, which must not reach the state of the instance
+	slow := Step{Name: "slow", Timeout: 10 * time.Millisecond, Run: func(ctx context.Context, s State) error {
+		<-ctx.Done()
+		s["late"] = true
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}}
+	state = State{"n": 1}
+	if _, err := runStep(context.Background(), slow, state); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := state["late"]; ok {
+		t.Error("expected a timed out step not to touch the state")
+	}
+
+	panics := Step{Name: "panics", Run: func(ctx context.Context, s State) error { panic("boom") }}
+	if _, err := runStep(context.Background(), panics, State{}); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("expected panic to be returned as an error, got %v", err)
+	}
+}
+
+func TestEngine_compensate(t *testing.T) {
+	var undone []string
+	undo := func(name string) func(context.Context, State) error {
+		return func(ctx context.Context, s State) error {
+			undone = append(undone, name)
+			return nil
+		}
+	}
+
+	w := &Workflow{Name: "order", Steps: []Step{
+		{Name: "reserve", Compensate: undo("reserve")},
+		{Name: "notify"},
+		{Name: "charge", Compensate: undo("charge")},
+		{Name: "ship", Compensate: undo("ship")},
+	}}
+
+	e := New(nil, "postgres")
+	inst := &Instance{Workflow: "order", Step: 3, State: State{}}
+
+	if status := e.compensate(context.Background(), w, inst); status != StatusCompensated {
+		t.Errorf("expected compensated status, got %s", status)
+	}
+
+	if strings.Join(undone, ",") != "charge,reserve" {
+		t.Errorf("expected completed steps undone in reverse order, got %v", undone)
+	}
+}
+
+func TestEngine_Start_undefined(t *testing.T) {
+	e := New(nil, "postgres")
+	if _, err := e.Start(context.Background(), "missing", nil); err == nil {
+		t.Error("expected an error starting an undefined workflow")
+	}
+}
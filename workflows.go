@@ -0,0 +1,34 @@
+package goravel
+
+import (
+	"context"
+
+	"github.com/namnguyen191/goravel/leader"
+	"github.com/namnguyen191/goravel/workflow"
+)
+
+// createWorkflows runs the workflow instances as jobs of the queue. The instances a process left
+// running when it stopped are resumed at boot and then every minute, once their lease expired;
+// an app without the workflows table only gets the error at boot.
+func (grv *Goravel) createWorkflows() *workflow.Engine {
+	e := workflow.New(grv.DB.Pool, grv.DB.DataBaseType)
+	e.ErrorLog = func(err error) { grv.ErrorLog.Println(err) }
+	e.Clock = grv.Clock
+	e.Dispatch = grv.pushID(workflow.Job)
+	grv.handleID(workflow.Job, e.Continue)
+
+	grv.OnBoot(func() error {
+		if err := e.Resume(context.Background()); err != nil {
+			grv.ErrorLog.Println("workflows: resuming:", err)
+			return nil
+		}
+		_, err := grv.Scheduler.AddJob("@every 1m", leader.Everywhere(func() {
+			if err := e.Resume(context.Background()); err != nil {
+				grv.ErrorLog.Println("workflows: resuming:", err)
+			}
+		}))
+		return err
+	})
+
+	return e
+}
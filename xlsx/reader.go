@@ -0,0 +1,134 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// ErrNoSheet is returned for workbooks without a worksheet
+var ErrNoSheet = errors.New("xlsx: workbook has no sheet")
+
+type sharedStrings struct {
+	Items []struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"si"`
+}
+
+type sheetXML struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline struct {
+				Text string `xml:"t"`
+			} `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadAll returns the cells of the first worksheet as text, with gaps filled by empty strings
+func ReadAll(r io.ReaderAt, size int64) ([][]string, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	var strs []string
+	var sheet *zip.File
+
+	for _, f := range z.File {
+		switch {
+		case f.Name == "xl/sharedStrings.xml":
+			var ss sharedStrings
+			if err := decode(f, &ss); err != nil {
+				return nil, err
+			}
+			for _, item := range ss.Items {
+				text := item.Text
+				for _, run := range item.Runs {
+					text += run.Text
+				}
+				strs = append(strs, text)
+			}
+		case strings.HasPrefix(f.Name, "xl/worksheets/sheet") && (sheet == nil || f.Name < sheet.Name):
+			sheet = f
+		}
+	}
+
+	if sheet == nil {
+		return nil, ErrNoSheet
+	}
+
+	var data sheetXML
+	if err := decode(sheet, &data); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(data.Rows))
+	for _, row := range data.Rows {
+		var values []string
+		for i, c := range row.Cells {
+			col := i
+			if c.Ref != "" {
+				col = columnIndex(c.Ref)
+			}
+			for len(values) < col {
+				values = append(values, "")
+			}
+
+			switch c.Type {
+			case "s":
+				n, _ := strconv.Atoi(c.Value)
+				if n >= 0 && n < len(strs) {
+					values = append(values, strs[n])
+				} else {
+					values = append(values, "")
+				}
+			case "inlineStr":
+				values = append(values, c.Inline.Text)
+			default:
+				values = append(values, c.Value)
+			}
+		}
+		rows = append(rows, values)
+	}
+
+	return rows, nil
+}
+
+func decode(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+
+	return xml.Unmarshal(b, v)
+}
+
+// columnIndex turns a cell reference like "AB12" into a zero based column index
+func columnIndex(ref string) int {
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		n = n*26 + int(r-'A') + 1
+	}
+
+	return n - 1
+}
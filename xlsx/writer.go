@@ -0,0 +1,136 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	contentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+	rootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	workbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+	workbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	sheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	sheetEnd = `</sheetData></worksheet>`
+)
+
+// Writer streams rows into a single sheet workbook, so large exports never sit in memory
+type Writer struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	row   int
+}
+
+// NewWriter starts a workbook with one sheet called name
+func NewWriter(w io.Writer, name string) (*Writer, error) {
+	z := zip.NewWriter(w)
+
+	var sheetName strings.Builder
+	_ = xml.EscapeText(&sheetName, []byte(name))
+
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", fmt.Sprintf(workbook, sheetName.String())},
+		{"xl/_rels/workbook.xml.rels", workbookRels},
+	}
+
+	for _, p := range parts {
+		f, err := z.Create(p.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, p.body); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	xw := &Writer{zip: z, sheet: bufio.NewWriter(sheet)}
+	if _, err := xw.sheet.WriteString(sheetStart); err != nil {
+		return nil, err
+	}
+
+	return xw, nil
+}
+
+// Write appends a row; numbers are stored as numbers, everything else as text
+func (w *Writer) Write(values []interface{}) error {
+	w.row++
+	fmt.Fprintf(w.sheet, `<row r="%d">`, w.row)
+
+	for i, v := range values {
+		ref := column(i) + strconv.Itoa(w.row)
+
+		switch n := v.(type) {
+		case nil:
+			continue
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			fmt.Fprintf(w.sheet, `<c r="%s"><v>%v</v></c>`, ref, n)
+		default:
+			fmt.Fprintf(w.sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			if err := xml.EscapeText(w.sheet, []byte(text(v))); err != nil {
+				return err
+			}
+			w.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+
+	_, err := w.sheet.WriteString(`</row>`)
+
+	return err
+}
+
+// Close finishes the sheet and the zip archive
+func (w *Writer) Close() error {
+	if _, err := w.sheet.WriteString(sheetEnd); err != nil {
+		return err
+	}
+
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+
+	return w.zip.Close()
+}
+
+// column turns a zero based index into a column name: 0 is A, 26 is AA
+func column(i int) string {
+	name := ""
+	for i >= 0 {
+		name = string(rune('A'+i%26)) + name
+		i = i/26 - 1
+	}
+
+	return name
+}
+
+func text(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	case time.Time:
+		return s.Format("2006-01-02 15:04:05")
+	case fmt.Stringer:
+		return s.String()
+	}
+
+	return fmt.Sprint(v)
+}
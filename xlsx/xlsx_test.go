@@ -0,0 +1,71 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, "Users & Roles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = w.Write([]interface{}{"id", "name"})
+	_ = w.Write([]interface{}{1, "<Ann>"})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{}
+	for _, f := range z.File {
+		rc, _ := f.Open()
+		b, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(b)
+	}
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	if !strings.Contains(sheet, `<c r="A2"><v>1</v></c>`) || !strings.Contains(sheet, "&lt;Ann&gt;") {
+		t.Error("unexpected sheet", sheet)
+	}
+	if !strings.Contains(files["xl/workbook.xml"], `name="Users &amp; Roles"`) {
+		t.Error("expected escaped sheet name")
+	}
+}
+
+func TestColumn(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 701: "ZZ", 702: "AAA"} {
+		if got := column(i); got != want {
+			t.Errorf("%d: expected %s, got %s", i, want, got)
+		}
+	}
+}
+
+func TestReadAll(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, _ := NewWriter(&buf, "Sheet")
+	_ = w.Write([]interface{}{"email", "age", "note"})
+	_ = w.Write([]interface{}{"ann@example.com", 31, nil})
+	_ = w.Write([]interface{}{"bob@example.com", nil, "x"})
+	_ = w.Close()
+
+	rows, err := ReadAll(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 3 || rows[1][0] != "ann@example.com" || rows[1][1] != "31" || rows[2][2] != "x" || rows[2][1] != "" {
+		t.Errorf("unexpected rows %q", rows)
+	}
+}